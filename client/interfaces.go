@@ -160,6 +160,8 @@ type InstanceServer interface {
 
 	GetInstanceConsoleLog(instanceName string, args *InstanceConsoleLogArgs) (content io.ReadCloser, err error)
 	DeleteInstanceConsoleLog(instanceName string, args *InstanceConsoleLogArgs) (err error)
+	GetInstanceConsoleScreenshot(instanceName string) (content io.ReadCloser, err error)
+	SendInstanceConsoleKeys(instanceName string, keys api.InstanceConsoleKeysPost) (err error)
 
 	GetInstanceFile(instanceName string, path string) (content io.ReadCloser, resp *InstanceFileResponse, err error)
 	CreateInstanceFile(instanceName string, path string, args InstanceFileArgs) (err error)
@@ -220,12 +222,55 @@ type InstanceServer interface {
 	GetNetworks() (networks []api.Network, err error)
 	GetNetwork(name string) (network *api.Network, ETag string, err error)
 	GetNetworkLeases(name string) (leases []api.NetworkLease, err error)
+	CreateNetworkLease(name string, reservation api.NetworkLeasesPost) (err error)
+	DeleteNetworkLease(name string, hwaddr string) (err error)
 	GetNetworkState(name string) (state *api.NetworkState, err error)
 	CreateNetwork(network api.NetworksPost) (err error)
 	UpdateNetwork(name string, network api.NetworkPut, ETag string) (err error)
 	RenameNetwork(name string, network api.NetworkPost) (err error)
 	DeleteNetwork(name string) (err error)
 
+	// Network ACL functions ("network_acl" API extension)
+	GetNetworkACLNames() (names []string, err error)
+	GetNetworkACLs() (acls []api.NetworkACL, err error)
+	GetNetworkACL(name string) (acl *api.NetworkACL, ETag string, err error)
+	CreateNetworkACL(acl api.NetworkACLsPost) (err error)
+	UpdateNetworkACL(name string, acl api.NetworkACLPut, ETag string) (err error)
+	RenameNetworkACL(name string, acl api.NetworkACLPost) (err error)
+	DeleteNetworkACL(name string) (err error)
+
+	// Network forward functions ("network_forward" API extension)
+	GetNetworkForwardAddresses(networkName string) (listenAddresses []string, err error)
+	GetNetworkForwards(networkName string) (forwards []api.NetworkForward, err error)
+	GetNetworkForward(networkName string, listenAddress string) (forward *api.NetworkForward, ETag string, err error)
+	CreateNetworkForward(networkName string, forward api.NetworkForwardsPost) (err error)
+	UpdateNetworkForward(networkName string, listenAddress string, forward api.NetworkForwardPut, ETag string) (err error)
+	DeleteNetworkForward(networkName string, listenAddress string) (err error)
+
+	// Network load balancer functions ("network_load_balancer" API extension)
+	GetNetworkLoadBalancerAddresses(networkName string) (listenAddresses []string, err error)
+	GetNetworkLoadBalancers(networkName string) (loadBalancers []api.NetworkLoadBalancer, err error)
+	GetNetworkLoadBalancer(networkName string, listenAddress string) (loadBalancer *api.NetworkLoadBalancer, ETag string, err error)
+	CreateNetworkLoadBalancer(networkName string, loadBalancer api.NetworkLoadBalancersPost) (err error)
+	UpdateNetworkLoadBalancer(networkName string, listenAddress string, loadBalancer api.NetworkLoadBalancerPut, ETag string) (err error)
+	DeleteNetworkLoadBalancer(networkName string, listenAddress string) (err error)
+
+	// Network peer functions ("network_peer" API extension)
+	GetNetworkPeerNames(networkName string) (peerNames []string, err error)
+	GetNetworkPeers(networkName string) (peers []api.NetworkPeer, err error)
+	GetNetworkPeer(networkName string, peerName string) (peer *api.NetworkPeer, ETag string, err error)
+	CreateNetworkPeer(networkName string, peer api.NetworkPeersPost) (err error)
+	UpdateNetworkPeer(networkName string, peerName string, peer api.NetworkPeerPut, ETag string) (err error)
+	DeleteNetworkPeer(networkName string, peerName string) (err error)
+
+	// Network zone functions ("network_zones" API extension)
+	GetNetworkZoneNames() (names []string, err error)
+	GetNetworkZones() (zones []api.NetworkZone, err error)
+	GetNetworkZone(name string) (zone *api.NetworkZone, ETag string, err error)
+	CreateNetworkZone(zone api.NetworkZonesPost) (err error)
+	UpdateNetworkZone(name string, zone api.NetworkZonePut, ETag string) (err error)
+	DeleteNetworkZone(name string) (err error)
+
 	// Operation functions
 	GetOperationUUIDs() (uuids []string, err error)
 	GetOperations() (operations []api.Operation, err error)
@@ -283,6 +328,30 @@ type InstanceServer interface {
 	RenameStoragePoolVolumeSnapshot(pool string, volumeType string, volumeName string, snapshotName string, snapshot api.StorageVolumeSnapshotPost) (op Operation, err error)
 	UpdateStoragePoolVolumeSnapshot(pool string, volumeType string, volumeName string, snapshotName string, volume api.StorageVolumeSnapshotPut, ETag string) (err error)
 
+	// Storage volume backup functions ("custom_volume_backup" API extension)
+	GetStoragePoolVolumeBackupNames(pool string, volName string) (names []string, err error)
+	GetStoragePoolVolumeBackups(pool string, volName string) (backups []api.StoragePoolVolumeBackup, err error)
+	GetStoragePoolVolumeBackup(pool string, volName string, name string) (backup *api.StoragePoolVolumeBackup, ETag string, err error)
+	CreateStoragePoolVolumeBackup(pool string, volName string, backup api.StoragePoolVolumeBackupsPost) (op Operation, err error)
+	RenameStoragePoolVolumeBackup(pool string, volName string, name string, backup api.StoragePoolVolumeBackupPost) (op Operation, err error)
+	DeleteStoragePoolVolumeBackup(pool string, volName string, name string) (op Operation, err error)
+	GetStoragePoolVolumeBackupFile(pool string, volName string, name string, req *BackupFileRequest) (resp *BackupFileResponse, err error)
+	CreateStoragePoolVolumeFromBackup(pool string, args StoragePoolVolumeBackupArgs) (op Operation, err error)
+
+	// Storage bucket functions ("storage_buckets" API extension)
+	GetStoragePoolBucketNames(poolName string) (names []string, err error)
+	GetStoragePoolBuckets(poolName string) (buckets []api.StorageBucket, err error)
+	GetStoragePoolBucket(poolName string, bucketName string) (bucket *api.StorageBucket, ETag string, err error)
+	CreateStoragePoolBucket(poolName string, bucket api.StorageBucketsPost) (err error)
+	UpdateStoragePoolBucket(poolName string, bucketName string, bucket api.StorageBucketPut, ETag string) (err error)
+	DeleteStoragePoolBucket(poolName string, bucketName string) (err error)
+	GetStoragePoolBucketKeyNames(poolName string, bucketName string) (names []string, err error)
+	GetStoragePoolBucketKeys(poolName string, bucketName string) (keys []api.StorageBucketKey, err error)
+	GetStoragePoolBucketKey(poolName string, bucketName string, keyName string) (key *api.StorageBucketKey, ETag string, err error)
+	CreateStoragePoolBucketKey(poolName string, bucketName string, key api.StorageBucketKeysPost) (newKey *api.StorageBucketKey, err error)
+	UpdateStoragePoolBucketKey(poolName string, bucketName string, keyName string, key api.StorageBucketKeyPut, ETag string) (err error)
+	DeleteStoragePoolBucketKey(poolName string, bucketName string, keyName string) (err error)
+
 	// Cluster functions ("cluster" API extensions)
 	GetCluster() (cluster *api.Cluster, ETag string, err error)
 	UpdateCluster(cluster api.ClusterPut, ETag string) (op Operation, err error)
@@ -422,6 +491,15 @@ type StoragePoolVolumeMoveArgs struct {
 	StoragePoolVolumeCopyArgs
 }
 
+// The StoragePoolVolumeBackupArgs struct is used when creating a custom volume from a backup.
+type StoragePoolVolumeBackupArgs struct {
+	// The backup file
+	BackupFile io.Reader
+
+	// Name to import the volume as (defaults to the name of the backed up volume)
+	Name string
+}
+
 // The InstanceBackupArgs struct is used when creating a instance from a backup.
 type InstanceBackupArgs struct {
 	// The backup file
@@ -2021,6 +2021,74 @@ func (r *ProtocolLXD) DeleteInstanceConsoleLog(instanceName string, args *Instan
 	return nil
 }
 
+// GetInstanceConsoleScreenshot returns a PNG screenshot of the VGA console of a virtual machine.
+//
+// Note that it's the caller's responsibility to close the returned ReadCloser
+func (r *ProtocolLXD) GetInstanceConsoleScreenshot(instanceName string) (io.ReadCloser, error) {
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.HasExtension("console_vga_screenshot") {
+		return nil, fmt.Errorf("The server is missing the required \"console_vga_screenshot\" API extension")
+	}
+
+	// Prepare the HTTP request
+	url := fmt.Sprintf("%s/1.0%s/%s/console?type=vga&format=png", r.httpHost, path, url.PathEscape(instanceName))
+
+	url, err = r.setQueryAttributes(url)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set the user agent
+	if r.httpUserAgent != "" {
+		req.Header.Set("User-Agent", r.httpUserAgent)
+	}
+
+	// Send the request
+	resp, err := r.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the return value for a cleaner error
+	if resp.StatusCode != http.StatusOK {
+		_, _, err := lxdParseResponse(resp)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resp.Body, err
+}
+
+// SendInstanceConsoleKeys presses keys on the VGA console of a virtual machine.
+func (r *ProtocolLXD) SendInstanceConsoleKeys(instanceName string, keys api.InstanceConsoleKeysPost) error {
+	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
+	if err != nil {
+		return err
+	}
+
+	if !r.HasExtension("console_vga_screenshot") {
+		return fmt.Errorf("The server is missing the required \"console_vga_screenshot\" API extension")
+	}
+
+	// Send the request
+	_, _, err = r.query("POST", fmt.Sprintf("%s/%s/console/keys", path, url.PathEscape(instanceName)), keys, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // GetInstanceBackupNames returns a list of backup names for the instance.
 func (r *ProtocolLXD) GetInstanceBackupNames(instanceName string) ([]string, error) {
 	path, _, err := r.instanceTypeToPath(api.InstanceTypeAny)
@@ -2103,6 +2171,10 @@ func (r *ProtocolLXD) CreateInstanceBackup(instanceName string, backup api.Insta
 		return nil, fmt.Errorf("The server is missing the required \"container_backup\" API extension")
 	}
 
+	if backup.Parent != "" && !r.HasExtension("backup_incremental") {
+		return nil, fmt.Errorf("The server is missing the required \"backup_incremental\" API extension")
+	}
+
 	// Send the request
 	op, _, err := r.queryOperation("POST", fmt.Sprintf("%s/%s/backups", path, url.PathEscape(instanceName)), backup, "")
 	if err != nil {
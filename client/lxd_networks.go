@@ -83,6 +83,36 @@ func (r *ProtocolLXD) GetNetworkLeases(name string) ([]api.NetworkLease, error)
 	return leases, nil
 }
 
+// CreateNetworkLease defines a new DHCP reservation using the provided struct
+func (r *ProtocolLXD) CreateNetworkLease(name string, reservation api.NetworkLeasesPost) error {
+	if !r.HasExtension("network_leases_reservations") {
+		return fmt.Errorf("The server is missing the required \"network_leases_reservations\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("POST", fmt.Sprintf("/networks/%s/leases", url.PathEscape(name)), reservation, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteNetworkLease deletes an existing DHCP reservation
+func (r *ProtocolLXD) DeleteNetworkLease(name string, hwaddr string) error {
+	if !r.HasExtension("network_leases_reservations") {
+		return fmt.Errorf("The server is missing the required \"network_leases_reservations\" API extension")
+	}
+
+	// Send the request
+	_, _, err := r.query("DELETE", fmt.Sprintf("/networks/%s/leases/%s", url.PathEscape(name), url.PathEscape(hwaddr)), nil, "")
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // GetNetworkState returns metrics and information on the running network
 func (r *ProtocolLXD) GetNetworkState(name string) (*api.NetworkState, error) {
 	if !r.HasExtension("network_state") {
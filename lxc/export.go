@@ -22,6 +22,7 @@ type cmdExport struct {
 
 	flagInstanceOnly         bool
 	flagOptimizedStorage     bool
+	flagAllowPlaintext       bool
 	flagCompressionAlgorithm string
 }
 
@@ -40,6 +41,8 @@ func (c *cmdExport) Command() *cobra.Command {
 		i18n.G("Whether or not to only backup the instance (without snapshots)"))
 	cmd.Flags().BoolVar(&c.flagOptimizedStorage, "optimized-storage", false,
 		i18n.G("Use storage driver optimized format (can only be restored on a similar pool)"))
+	cmd.Flags().BoolVar(&c.flagAllowPlaintext, "allow-plaintext", false,
+		i18n.G("Allow exporting instances on encrypted volumes (the backup isn't encrypted)"))
 	cmd.Flags().StringVar(&c.flagCompressionAlgorithm, "compression", "", i18n.G("Define a compression algorithm: for backup or none")+"``")
 
 	return cmd
@@ -74,6 +77,7 @@ func (c *cmdExport) Run(cmd *cobra.Command, args []string) error {
 		InstanceOnly:         instanceOnly,
 		OptimizedStorage:     c.flagOptimizedStorage,
 		CompressionAlgorithm: c.flagCompressionAlgorithm,
+		AllowPlaintext:       c.flagAllowPlaintext,
 	}
 
 	op, err := d.CreateInstanceBackup(name, req)
@@ -22,6 +22,7 @@ var api10 = []APIEndpoint{
 	operationsCmd,
 	operationCmd,
 	operationWebsocket,
+	proxyCmd,
 	stateCmd,
 }
 
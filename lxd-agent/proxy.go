@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+var proxyCmd = APIEndpoint{
+	Path: "proxy",
+
+	Get: APIEndpointAction{Handler: proxyGet},
+}
+
+type proxyServe struct {
+	req  *http.Request
+	conn net.Conn
+}
+
+func (r *proxyServe) Render(w http.ResponseWriter) error {
+	return proxySocket(r.req, w, r.conn)
+}
+
+func (r *proxyServe) String() string {
+	return "proxy handler"
+}
+
+// proxySocket upgrades the request to a websocket and relays it to the connection.
+func proxySocket(r *http.Request, w http.ResponseWriter, conn net.Conn) error {
+	c, err := shared.WebsocketUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	logger.Debugf("New proxy connection to %s", conn.RemoteAddr())
+
+	_, isUDP := conn.(*net.UDPConn)
+	if isUDP {
+		proxyDatagrams(c, conn)
+	} else {
+		readDone, writeDone := shared.WebsocketMirror(c, conn, conn, nil, nil)
+		<-readDone
+		<-writeDone
+	}
+
+	logger.Debugf("Proxy connection to %s finished", conn.RemoteAddr())
+
+	return nil
+}
+
+// proxyDatagrams relays datagrams between the websocket and the connection. Each websocket message carries
+// exactly one datagram, so that datagram boundaries are kept in both directions.
+func proxyDatagrams(c *websocket.Conn, conn net.Conn) {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		buf := make([]byte, 64*1024)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return
+			}
+
+			err = c.WriteMessage(websocket.BinaryMessage, buf[:n])
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		mt, data, err := c.ReadMessage()
+		if err != nil || mt != websocket.BinaryMessage {
+			break
+		}
+
+		_, err = conn.Write(data)
+		if err != nil {
+			break
+		}
+	}
+
+	conn.Close()
+	<-done
+}
+
+// proxyGet connects to the requested address and relays it over a websocket.
+// The connect address uses the "<type>:<address>" format of the proxy device.
+func proxyGet(d *Daemon, r *http.Request) response.Response {
+	connect := r.FormValue("connect")
+
+	fields := strings.SplitN(connect, ":", 2)
+	if len(fields) != 2 || !shared.StringInSlice(fields[0], []string{"tcp", "udp", "unix"}) {
+		return response.BadRequest(fmt.Errorf("Invalid connect address %q", connect))
+	}
+
+	// Connect before upgrading the request so that failures can be reported to the client.
+	conn, err := net.Dial(fields[0], fields[1])
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return &proxyServe{req: r, conn: conn}
+}
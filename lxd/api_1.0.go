@@ -43,6 +43,7 @@ var api10 = []APIEndpoint{
 	instanceBackupsCmd,
 	instanceCmd,
 	instanceConsoleCmd,
+	instanceConsoleKeysCmd,
 	instanceExecCmd,
 	instanceFileCmd,
 	instanceLogCmd,
@@ -61,10 +62,21 @@ var api10 = []APIEndpoint{
 	imageRefreshCmd,
 	imagesCmd,
 	imageSecretCmd,
+	networkACLCmd,
+	networkACLsCmd,
 	networkCmd,
+	networkForwardCmd,
+	networkForwardsCmd,
+	networkLeaseCmd,
 	networkLeasesCmd,
+	networkLoadBalancerCmd,
+	networkLoadBalancersCmd,
+	networkPeerCmd,
+	networkPeersCmd,
 	networksCmd,
 	networkStateCmd,
+	networkZoneCmd,
+	networkZonesCmd,
 	operationCmd,
 	operationsCmd,
 	operationWait,
@@ -73,12 +85,19 @@ var api10 = []APIEndpoint{
 	profilesCmd,
 	projectCmd,
 	projectsCmd,
+	storagePoolBucketCmd,
+	storagePoolBucketsCmd,
+	storagePoolBucketKeyCmd,
+	storagePoolBucketKeysCmd,
 	storagePoolCmd,
 	storagePoolResourcesCmd,
 	storagePoolsCmd,
 	storagePoolVolumesCmd,
 	storagePoolVolumeSnapshotsTypeCmd,
 	storagePoolVolumeSnapshotTypeCmd,
+	storagePoolVolumeTypeCustomBackupsCmd,
+	storagePoolVolumeTypeCustomBackupCmd,
+	storagePoolVolumeTypeCustomBackupExportCmd,
 	storagePoolVolumesTypeCmd,
 	storagePoolVolumeTypeContainerCmd,
 	storagePoolVolumeTypeCustomCmd,
@@ -561,6 +580,22 @@ func doApi10UpdateTriggers(d *Daemon, nodeChanged, clusterChanged map[string]str
 		}
 	}
 
+	value, ok = nodeChanged["core.dns_address"]
+	if ok {
+		err := d.dns.Start(value)
+		if err != nil {
+			return err
+		}
+	}
+
+	value, ok = nodeChanged["core.storage_buckets_address"]
+	if ok {
+		err := d.endpoints.StorageBucketsUpdateAddress(value)
+		if err != nil {
+			return err
+		}
+	}
+
 	value, ok = nodeChanged["storage.backups_volume"]
 	if ok {
 		err := daemonStorageMove(s, "backups", value)
@@ -41,6 +41,8 @@ var apiInternal = []APIEndpoint{
 	internalContainerOnStopNSCmd,
 	internalContainerOnStopCmd,
 	internalContainersCmd,
+	internalRecoverValidateCmd,
+	internalRecoverImportCmd,
 	internalSQLCmd,
 	internalClusterAcceptCmd,
 	internalClusterRebalanceCmd,
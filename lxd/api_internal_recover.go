@@ -0,0 +1,444 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/backup"
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/state"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/osarch"
+)
+
+var internalRecoverValidateCmd = APIEndpoint{
+	Path: "recover/validate",
+
+	Post: APIEndpointAction{Handler: internalRecoverValidate},
+}
+
+var internalRecoverImportCmd = APIEndpoint{
+	Path: "recover/import",
+
+	Post: APIEndpointAction{Handler: internalRecoverImport},
+}
+
+// internalRecoverValidatePost is used to scan the given pools for unknown volumes.
+type internalRecoverValidatePost struct {
+	Pools []api.StoragePoolsPost `json:"pools" yaml:"pools"`
+}
+
+// internalRecoverValidateVolume describes an unknown volume found by the scan.
+type internalRecoverValidateVolume struct {
+	Name          string `json:"name" yaml:"name"`
+	Type          string `json:"type" yaml:"type"`
+	SnapshotCount int    `json:"snapshot_count" yaml:"snapshot_count"`
+	Project       string `json:"project" yaml:"project"`
+	Pool          string `json:"pool" yaml:"pool"`
+}
+
+// internalRecoverValidateResult is the result of the scan.
+type internalRecoverValidateResult struct {
+	// Volumes that can be imported.
+	UnknownVolumes []internalRecoverValidateVolume `json:"unknown_volumes" yaml:"unknown_volumes"`
+
+	// Missing dependencies that prevent the import.
+	DependencyErrors []string `json:"dependency_errors" yaml:"dependency_errors"`
+}
+
+// internalRecoverImportPost is used to import the unknown volumes of the given pools.
+type internalRecoverImportPost struct {
+	Pools []api.StoragePoolsPost `json:"pools" yaml:"pools"`
+}
+
+func internalRecoverValidate(d *Daemon, r *http.Request) response.Response {
+	req := &internalRecoverValidatePost{}
+	err := json.NewDecoder(r.Body).Decode(req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	return internalRecoverScan(d.State(), req.Pools, true)
+}
+
+func internalRecoverImport(d *Daemon, r *http.Request) response.Response {
+	req := &internalRecoverImportPost{}
+	err := json.NewDecoder(r.Body).Decode(req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	return internalRecoverScan(d.State(), req.Pools, false)
+}
+
+// internalRecoverScan scans the given pools for volumes that have no database records and checks that the
+// projects, profiles and networks they depend on exist. If validateOnly is false, the database records of the
+// pools, custom volumes and instances (along with their snapshots) are then recreated.
+func internalRecoverScan(s *state.State, userPools []api.StoragePoolsPost, validateOnly bool) response.Response {
+	var projectNames []string
+	err := s.Cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		projectNames, err = tx.GetProjectNames()
+		return err
+	})
+	if err != nil {
+		return response.SmartError(errors.Wrap(err, "Failed getting projects"))
+	}
+
+	clustered, err := cluster.Enabled(s.Node)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	pools := make(map[string]storagePools.Pool)
+	poolsProjectVols := make(map[string]map[string][]*backup.InstanceConfig)
+
+	for _, p := range userPools {
+		pool, err := storagePools.GetPoolByName(s, p.Name)
+		if err != nil {
+			if errors.Cause(err) != db.ErrNoSuchObject {
+				return response.SmartError(errors.Wrapf(err, "Failed loading existing pool %q", p.Name))
+			}
+
+			// Recreating pool records isn't supported on clusters as each member would need to be scanned.
+			if clustered {
+				return response.BadRequest(fmt.Errorf("Storage pool %q doesn't exist and can't be recovered when clustered", p.Name))
+			}
+
+			// Use a temporary pool with the supplied info to scan the unknown pool.
+			poolInfo := api.StoragePool{
+				StoragePoolPut: p.StoragePoolPut,
+				Name:           p.Name,
+				Driver:         p.Driver,
+			}
+
+			pool, err = storagePools.NewTemporary(s, &poolInfo)
+			if err != nil {
+				return response.SmartError(errors.Wrapf(err, "Failed initialising unknown pool %q", p.Name))
+			}
+
+			err = pool.Driver().Validate(poolInfo.Config)
+			if err != nil {
+				return response.SmartError(errors.Wrapf(err, "Failed config validation for unknown pool %q", p.Name))
+			}
+		}
+
+		pools[p.Name] = pool
+
+		ourMount, err := pool.Mount()
+		if err != nil {
+			return response.SmartError(errors.Wrapf(err, "Failed mounting pool %q", p.Name))
+		}
+
+		// Unmount the pools we mounted unless they end up with a database record.
+		if ourMount {
+			defer func(poolName string) {
+				if pools[poolName].ID() == storagePools.PoolIDTemporary {
+					pools[poolName].Unmount()
+				}
+			}(p.Name)
+		}
+
+		poolsProjectVols[p.Name], err = pool.ListUnknownVolumes(nil)
+		if err != nil {
+			return response.SmartError(errors.Wrapf(err, "Failed checking volumes on pool %q", p.Name))
+		}
+	}
+
+	res := internalRecoverValidateResult{
+		UnknownVolumes:   []internalRecoverValidateVolume{},
+		DependencyErrors: []string{},
+	}
+
+	dependencyErrors := map[string]struct{}{}
+	for poolName, poolProjectVols := range poolsProjectVols {
+		for projectName, poolVols := range poolProjectVols {
+			if !shared.StringInSlice(projectName, projectNames) {
+				dependencyErrors[fmt.Sprintf("Project %q", projectName)] = struct{}{}
+			}
+
+			for _, poolVol := range poolVols {
+				if poolVol.Container == nil {
+					res.UnknownVolumes = append(res.UnknownVolumes, internalRecoverValidateVolume{
+						Name:          poolVol.Volume.Name,
+						Type:          poolVol.Volume.Type,
+						SnapshotCount: len(poolVol.VolumeSnapshots),
+						Project:       projectName,
+						Pool:          poolName,
+					})
+
+					continue
+				}
+
+				res.UnknownVolumes = append(res.UnknownVolumes, internalRecoverValidateVolume{
+					Name:          poolVol.Container.Name,
+					Type:          poolVol.Container.Type,
+					SnapshotCount: len(poolVol.Snapshots),
+					Project:       projectName,
+					Pool:          poolName,
+				})
+
+				// The remaining dependencies can only be checked once the project exists.
+				if !shared.StringInSlice(projectName, projectNames) {
+					continue
+				}
+
+				for _, depErr := range internalRecoverInstanceDependencies(s, projectName, poolVol) {
+					dependencyErrors[depErr] = struct{}{}
+				}
+			}
+		}
+	}
+
+	for depErr := range dependencyErrors {
+		res.DependencyErrors = append(res.DependencyErrors, depErr)
+	}
+
+	sort.Strings(res.DependencyErrors)
+
+	if validateOnly {
+		return response.SyncResponse(true, &res)
+	}
+
+	if len(res.DependencyErrors) > 0 {
+		return response.BadRequest(fmt.Errorf("Cannot import volumes with missing dependencies"))
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	for _, p := range userPools {
+		pool := pools[p.Name]
+
+		// Create the database record of unknown pools using the supplied config.
+		if pool.ID() == storagePools.PoolIDTemporary {
+			_, err = storagePoolDBCreate(s, p.Name, p.Description, p.Driver, p.Config)
+			if err != nil {
+				return response.SmartError(errors.Wrapf(err, "Failed creating storage pool %q database entry", p.Name))
+			}
+
+			poolName := p.Name
+			revert.Add(func() { dbStoragePoolDeleteAndUpdateCache(s, poolName) })
+
+			pool, err = storagePools.GetPoolByName(s, p.Name)
+			if err != nil {
+				return response.SmartError(errors.Wrapf(err, "Failed loading created storage pool %q", p.Name))
+			}
+
+			pools[p.Name] = pool
+		}
+
+		// Import custom volumes first so that instances using them can be created.
+		for projectName, poolVols := range poolsProjectVols[p.Name] {
+			for _, poolVol := range poolVols {
+				if poolVol.Container != nil {
+					continue
+				}
+
+				err = pool.ImportCustomVolume(projectName, poolVol, nil)
+				if err != nil {
+					return response.SmartError(errors.Wrapf(err, "Failed importing custom volume %q in project %q", poolVol.Volume.Name, projectName))
+				}
+
+				volProjectName := projectName
+				volName := poolVol.Volume.Name
+				revert.Add(func() {
+					s.Cluster.RemoveStoragePoolVolume(volProjectName, volName, db.StoragePoolVolumeTypeCustom, pool.ID())
+				})
+			}
+		}
+
+		for projectName, poolVols := range poolsProjectVols[p.Name] {
+			for _, poolVol := range poolVols {
+				if poolVol.Container == nil {
+					continue
+				}
+
+				cleanup, err := internalRecoverImportInstance(s, pool, projectName, poolVol)
+				if err != nil {
+					return response.SmartError(errors.Wrapf(err, "Failed importing instance %q in project %q", poolVol.Container.Name, projectName))
+				}
+
+				revert.Add(cleanup)
+			}
+		}
+	}
+
+	revert.Success()
+	return response.EmptySyncResponse
+}
+
+// internalRecoverInstanceDependencies returns the missing dependencies of the instance described by poolVol.
+func internalRecoverInstanceDependencies(s *state.State, projectName string, poolVol *backup.InstanceConfig) []string {
+	depErrors := []string{}
+
+	_, err := s.Cluster.GetInstanceID(projectName, poolVol.Container.Name)
+	if err == nil {
+		depErrors = append(depErrors, fmt.Sprintf("Instance %q in project %q already exists in the database", poolVol.Container.Name, projectName))
+	}
+
+	profileNames, err := s.Cluster.GetProfileNames(projectName)
+	if err == nil {
+		for _, profileName := range poolVol.Container.Profiles {
+			if !shared.StringInSlice(profileName, profileNames) {
+				depErrors = append(depErrors, fmt.Sprintf("Profile %q in project %q", profileName, projectName))
+			}
+		}
+	}
+
+	networkProjectName, err := project.NetworkProject(s.Cluster, projectName)
+	if err != nil {
+		return depErrors
+	}
+
+	for _, dev := range poolVol.Container.Devices {
+		if dev["type"] != "nic" || dev["network"] == "" {
+			continue
+		}
+
+		_, _, err = s.Cluster.GetNetworkInAnyState(networkProjectName, dev["network"])
+		if err == db.ErrNoSuchObject {
+			depErrors = append(depErrors, fmt.Sprintf("Network %q in project %q", dev["network"], networkProjectName))
+		}
+	}
+
+	return depErrors
+}
+
+// internalRecoverRootDevice ensures the devices contain a root disk device using the given pool.
+func internalRecoverRootDevice(devices map[string]map[string]string, poolName string) map[string]map[string]string {
+	if devices == nil {
+		devices = map[string]map[string]string{}
+	}
+
+	rootDevName, _, err := shared.GetRootDiskDevice(devices)
+	if err == nil {
+		devices[rootDevName]["pool"] = poolName
+		return devices
+	}
+
+	rootDevName = "root"
+	for i := 0; i < 100; i++ {
+		if devices[rootDevName] == nil {
+			break
+		}
+
+		rootDevName = fmt.Sprintf("root%d", i)
+	}
+
+	devices[rootDevName] = map[string]string{
+		"type": "disk",
+		"path": "/",
+		"pool": poolName,
+	}
+
+	return devices
+}
+
+// internalRecoverImportInstance recreates the database records of the instance described by poolVol and of its
+// snapshots, and then restores its mount paths. Returns a function that removes the created records.
+func internalRecoverImportInstance(s *state.State, pool storagePools.Pool, projectName string, poolVol *backup.InstanceConfig) (func(), error) {
+	instType, err := instancetype.New(poolVol.Container.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	volType, err := storagePools.InstanceTypeToVolumeType(instType)
+	if err != nil {
+		return nil, err
+	}
+
+	volDBType, err := storagePools.VolumeTypeToDBType(volType)
+	if err != nil {
+		return nil, err
+	}
+
+	arch, err := osarch.ArchitectureId(poolVol.Container.Architecture)
+	if err != nil {
+		return nil, err
+	}
+
+	inst, err := instanceCreateInternal(s, db.InstanceArgs{
+		Project:      projectName,
+		Architecture: arch,
+		BaseImage:    poolVol.Container.Config["volatile.base_image"],
+		Config:       poolVol.Container.Config,
+		CreationDate: poolVol.Container.CreatedAt,
+		Type:         instType,
+		Description:  poolVol.Container.Description,
+		Devices:      deviceConfig.NewDevices(internalRecoverRootDevice(poolVol.Container.Devices, pool.Name())),
+		Ephemeral:    poolVol.Container.Ephemeral,
+		LastUsedDate: poolVol.Container.LastUsedAt,
+		Name:         poolVol.Container.Name,
+		Profiles:     poolVol.Container.Profiles,
+		Stateful:     poolVol.Container.Stateful,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Create instance")
+	}
+
+	// The snapshot records are removed along with the ones of the instance.
+	cleanup := func() {
+		s.Cluster.DeleteInstance(projectName, inst.Name())
+		s.Cluster.RemoveStoragePoolVolume(projectName, inst.Name(), volDBType, pool.ID())
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+	revert.Add(cleanup)
+
+	// Restore the volume config recorded in the backup file.
+	if poolVol.Volume != nil && poolVol.Volume.Config != nil {
+		err = s.Cluster.UpdateStoragePoolVolume(projectName, inst.Name(), volDBType, pool.ID(), poolVol.Volume.Description, poolVol.Volume.Config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, snap := range poolVol.Snapshots {
+		arch, err := osarch.ArchitectureId(snap.Architecture)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = instanceCreateInternal(s, db.InstanceArgs{
+			Project:      projectName,
+			Architecture: arch,
+			BaseImage:    snap.Config["volatile.base_image"],
+			Config:       snap.Config,
+			CreationDate: snap.CreatedAt,
+			Type:         instType,
+			Snapshot:     true,
+			Devices:      deviceConfig.NewDevices(internalRecoverRootDevice(snap.Devices, pool.Name())),
+			Ephemeral:    snap.Ephemeral,
+			LastUsedDate: snap.LastUsedAt,
+			Name:         snap.Name,
+			Profiles:     snap.Profiles,
+			Stateful:     snap.Stateful,
+			ExpiryDate:   snap.ExpiresAt,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "Create snapshot %q", snap.Name)
+		}
+	}
+
+	err = pool.ImportInstance(inst, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	revert.Success()
+	return cleanup, nil
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"time"
 
 	"context"
 
+	"github.com/pborman/uuid"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
 
@@ -22,6 +24,7 @@ import (
 	"github.com/lxc/lxd/lxd/revert"
 	"github.com/lxc/lxd/lxd/state"
 	storagePools "github.com/lxc/lxd/lxd/storage"
+	storageDrivers "github.com/lxc/lxd/lxd/storage/drivers"
 	"github.com/lxc/lxd/lxd/task"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
@@ -32,8 +35,9 @@ import (
 	"github.com/lxc/lxd/shared/logging"
 )
 
-// Create a new backup.
-func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.Instance) error {
+// Create a new backup. The allowPlaintext argument must be set to back up an instance on an encrypted volume, as
+// the backup isn't encrypted.
+func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.Instance, allowPlaintext bool) error {
 	logger := logging.AddContext(logger.Log, log.Ctx{"project": sourceInst.Project(), "instance": sourceInst.Name(), "name": args.Name})
 	logger.Debug("Instance backup started")
 	defer logger.Debug("Instance backup finished")
@@ -52,6 +56,43 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 		args.OptimizedStorage = false
 	}
 
+	backupArgs := storageDrivers.BackupArgs{
+		UUID:           uuid.NewRandom().String(),
+		AllowPlaintext: allowPlaintext,
+	}
+
+	args.UUID = backupArgs.UUID
+
+	// Load the parent of incremental backups.
+	if args.Parent != "" {
+		parent, err := instance.BackupLoadByName(s, sourceInst.Project(), args.Parent)
+		if err != nil {
+			return errors.Wrap(err, "Load parent backup")
+		}
+
+		if parent.UUID() == "" {
+			return fmt.Errorf("Backup %q predates incremental backups and cannot be used as a parent", args.Parent)
+		}
+
+		if parent.OptimizedStorage() != args.OptimizedStorage {
+			return fmt.Errorf("Incremental backups must use the same storage format as their parent")
+		}
+
+		args.ParentUUID = parent.UUID()
+		backupArgs.ParentUUID = parent.UUID()
+
+		// Non-optimized incremental backups are computed against the tarball of their parent.
+		if !args.OptimizedStorage {
+			parentData, err := os.Open(shared.VarPath("backups", project.Instance(sourceInst.Project(), parent.Name())))
+			if err != nil {
+				return errors.Wrap(err, "Error opening parent backup tarball")
+			}
+			defer parentData.Close()
+
+			backupArgs.ParentData = parentData
+		}
+	}
+
 	// Create the database entry.
 	err = s.Cluster.CreateInstanceBackup(args)
 	if err != nil {
@@ -141,7 +182,7 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 
 	// Write index file.
 	logger.Debug("Adding backup index file")
-	err = backupWriteIndex(sourceInst, pool, b.OptimizedStorage(), !b.InstanceOnly(), tarWriter)
+	err = backupWriteIndex(sourceInst, pool, b.OptimizedStorage(), !b.InstanceOnly(), b.UUID(), b.ParentUUID(), tarWriter)
 
 	// Check compression errors.
 	if compressErr != nil {
@@ -153,11 +194,13 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 		return errors.Wrapf(err, "Error writing backup index file")
 	}
 
-	err = pool.BackupInstance(sourceInst, tarWriter, b.OptimizedStorage(), !b.InstanceOnly(), nil)
+	err = pool.BackupInstance(sourceInst, tarWriter, b.OptimizedStorage(), !b.InstanceOnly(), backupArgs, nil)
 	if err != nil {
 		return errors.Wrap(err, "Backup create")
 	}
 
+	revert.Add(func() { pool.DeleteInstanceBackupBase(sourceInst, b.UUID(), nil) })
+
 	// Close off the tarball file.
 	err = tarWriter.Close()
 	if err != nil {
@@ -180,7 +223,7 @@ func backupCreate(s *state.State, args db.InstanceBackup, sourceInst instance.In
 }
 
 // backupWriteIndex generates an index.yaml file and then writes it to the root of the backup tarball.
-func backupWriteIndex(sourceInst instance.Instance, pool storagePools.Pool, optimized bool, snapshots bool, tarWriter *instancewriter.InstanceTarWriter) error {
+func backupWriteIndex(sourceInst instance.Instance, pool storagePools.Pool, optimized bool, snapshots bool, uuid string, parentUUID string, tarWriter *instancewriter.InstanceTarWriter) error {
 	// Indicate whether the driver will include a driver-specific optimized header.
 	poolDriverOptimizedHeader := false
 	if optimized {
@@ -195,6 +238,8 @@ func backupWriteIndex(sourceInst instance.Instance, pool storagePools.Pool, opti
 		Type:             api.InstanceType(sourceInst.Type().String()),
 		OptimizedStorage: &optimized,
 		OptimizedHeader:  &poolDriverOptimizedHeader,
+		UUID:             uuid,
+		Parent:           parentUUID,
 	}
 
 	if snapshots {
@@ -276,12 +321,25 @@ func pruneExpiredContainerBackups(ctx context.Context, d *Daemon) error {
 		return errors.Wrap(err, "Unable to retrieve the list of expired instance backups")
 	}
 
+	// The backups are sorted most recent first, so that expired incremental backups are removed before their parent.
 	for _, b := range backups {
 		inst, err := instance.LoadByID(d.State(), b.InstanceID)
 		if err != nil {
 			return errors.Wrapf(err, "Error deleting instance backup %s", b.Name)
 		}
 
+		// Keep the backups which are still needed to import their incremental backups.
+		err = backupCheckNoChildren(d.State(), inst, b.Name, b.UUID)
+		if err != nil {
+			logger.Debug("Skipping expired instance backup", log.Ctx{"backup": b.Name, "err": err})
+			continue
+		}
+
+		err = backupDeleteBase(d.State(), inst, b.UUID)
+		if err != nil {
+			return errors.Wrapf(err, "Error deleting instance backup %s", b.Name)
+		}
+
 		err = backup.DoBackupDelete(d.State(), inst.Project(), b.Name, inst.Name())
 		if err != nil {
 			return errors.Wrapf(err, "Error deleting instance backup %s", b.Name)
@@ -290,3 +348,414 @@ func pruneExpiredContainerBackups(ctx context.Context, d *Daemon) error {
 
 	return nil
 }
+
+// backupCheckNoChildren returns an error if some backups of the instance are incremental to the backup with the
+// given UUID, as they can't be imported anymore once it's deleted.
+func backupCheckNoChildren(s *state.State, inst instance.Instance, backupName string, backupUUID string) error {
+	children, err := s.Cluster.GetInstanceBackupChildren(inst.ID(), backupUUID)
+	if err != nil {
+		return errors.Wrap(err, "Load incremental backups")
+	}
+
+	if len(children) > 0 {
+		return fmt.Errorf("Backup %q is the parent of incremental backups %q and must be deleted after them", backupName, children)
+	}
+
+	return nil
+}
+
+// backupDeleteBase removes the base kept on storage for incremental backups of the instance based on the backup
+// with the given UUID. Backups predating incremental backups don't have a UUID, and so don't have a base.
+func backupDeleteBase(s *state.State, inst instance.Instance, backupUUID string) error {
+	if backupUUID == "" {
+		return nil
+	}
+
+	pool, err := storagePools.GetPoolByInstance(s, inst)
+	if err != nil {
+		return errors.Wrap(err, "Load instance storage pool")
+	}
+
+	return pool.DeleteInstanceBackupBase(inst, backupUUID, nil)
+}
+
+// Create a new custom volume backup. The allowPlaintext argument must be set to back up an encrypted volume, as
+// the backup isn't encrypted.
+func volumeBackupCreate(s *state.State, args db.StoragePoolVolumeBackup, projectName string, poolName string, volumeName string, allowPlaintext bool) error {
+	logger := logging.AddContext(logger.Log, log.Ctx{"project": projectName, "pool": poolName, "volume": volumeName, "name": args.Name})
+	logger.Debug("Volume backup started")
+	defer logger.Debug("Volume backup finished")
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Get storage pool.
+	pool, err := storagePools.GetPoolByName(s, poolName)
+	if err != nil {
+		return errors.Wrap(err, "Load storage pool")
+	}
+
+	// Ignore requests for optimized backups when pool driver doesn't support it.
+	if args.OptimizedStorage && !pool.Driver().Info().OptimizedBackups {
+		args.OptimizedStorage = false
+	}
+
+	backupArgs := storageDrivers.BackupArgs{
+		UUID:           uuid.NewRandom().String(),
+		AllowPlaintext: allowPlaintext,
+	}
+
+	args.UUID = backupArgs.UUID
+
+	// Load the parent of incremental backups.
+	if args.Parent != "" {
+		parent, err := backup.VolumeBackupLoadByName(s, args.VolumeID, args.Parent)
+		if err != nil {
+			return errors.Wrap(err, "Load parent backup")
+		}
+
+		if parent.UUID() == "" {
+			return fmt.Errorf("Backup %q predates incremental backups and cannot be used as a parent", args.Parent)
+		}
+
+		if parent.OptimizedStorage() != args.OptimizedStorage {
+			return fmt.Errorf("Incremental backups must use the same storage format as their parent")
+		}
+
+		args.ParentUUID = parent.UUID()
+		backupArgs.ParentUUID = parent.UUID()
+
+		// Non-optimized incremental backups are computed against the tarball of their parent.
+		if !args.OptimizedStorage {
+			parentData, err := os.Open(parent.Path())
+			if err != nil {
+				return errors.Wrap(err, "Error opening parent backup tarball")
+			}
+			defer parentData.Close()
+
+			backupArgs.ParentData = parentData
+		}
+	}
+
+	// Create the database entry.
+	err = s.Cluster.CreateStoragePoolVolumeBackup(args)
+	if err != nil {
+		if err == db.ErrAlreadyDefined {
+			return fmt.Errorf("Backup %q already exists", args.Name)
+		}
+
+		return errors.Wrap(err, "Insert backup info into database")
+	}
+
+	revert.Add(func() { s.Cluster.DeleteStoragePoolVolumeBackup(args.VolumeID, args.Name) })
+
+	// Get the backup struct.
+	b, err := backup.VolumeBackupLoadByName(s, args.VolumeID, args.Name)
+	if err != nil {
+		return errors.Wrap(err, "Load backup object")
+	}
+
+	// Detect compression method.
+	var compress string
+	b.SetCompressionAlgorithm(args.CompressionAlgorithm)
+	if b.CompressionAlgorithm() != "" {
+		compress = b.CompressionAlgorithm()
+	} else {
+		compress, err = cluster.ConfigGetString(s.Cluster, "backups.compression_algorithm")
+		if err != nil {
+			return err
+		}
+	}
+
+	// Create the target path if needed.
+	backupsPath := backup.VolumeBackupsPath(projectName, poolName, volumeName)
+	if !shared.PathExists(backupsPath) {
+		err := os.MkdirAll(backupsPath, 0700)
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() { os.Remove(backupsPath) })
+	}
+
+	target := b.Path()
+
+	// Setup the tarball writer.
+	logger.Debug("Opening backup tarball for writing", log.Ctx{"path": target})
+	tarFileWriter, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "Error opening backup tarball for writing %q", target)
+	}
+	defer tarFileWriter.Close()
+	revert.Add(func() { os.Remove(target) })
+
+	// Create the tarball.
+	tarPipeReader, tarPipeWriter := io.Pipe()
+	defer tarPipeWriter.Close() // Ensure that go routine below always ends.
+	tarWriter := instancewriter.NewInstanceTarWriter(tarPipeWriter, nil)
+
+	// Setup tar writer go routine, with optional compression.
+	tarWriterRes := make(chan error, 1)
+
+	go func(resCh chan<- error) {
+		logger.Debug("Started backup tarball writer")
+		defer logger.Debug("Finished backup tarball writer")
+
+		var err error
+		if compress != "none" {
+			err = compressFile(compress, tarPipeReader, tarFileWriter)
+
+			// If a compression error occurred, close the tarPipeWriter to end the export.
+			if err != nil {
+				tarPipeWriter.Close()
+			}
+		} else {
+			_, err = io.Copy(tarFileWriter, tarPipeReader)
+		}
+		resCh <- err
+	}(tarWriterRes)
+
+	// writeErr ends the tarball writer and returns its error if it failed, as that is what caused the write
+	// to fail, or the write error otherwise.
+	writeErr := func(err error) error {
+		tarPipeWriter.Close()
+
+		resErr := <-tarWriterRes
+		if resErr != nil {
+			return errors.Wrap(resErr, "Error writing tarball")
+		}
+
+		return err
+	}
+
+	// Write index file.
+	logger.Debug("Adding backup index file")
+	err = volumeBackupWriteIndex(s, projectName, volumeName, pool, b.OptimizedStorage(), !b.VolumeOnly(), b.UUID(), b.ParentUUID(), tarWriter)
+	if err != nil {
+		return writeErr(errors.Wrapf(err, "Error writing backup index file"))
+	}
+
+	err = pool.BackupCustomVolume(projectName, volumeName, tarWriter, b.OptimizedStorage(), !b.VolumeOnly(), backupArgs, nil)
+	if err != nil {
+		return writeErr(errors.Wrap(err, "Backup create"))
+	}
+
+	revert.Add(func() { pool.DeleteCustomVolumeBackupBase(projectName, volumeName, b.UUID(), nil) })
+
+	// Close off the tarball file.
+	err = tarWriter.Close()
+	if err != nil {
+		return errors.Wrap(err, "Error closing tarball writer")
+	}
+
+	// Close off the tarball pipe writer (this will end the go routine above).
+	err = tarPipeWriter.Close()
+	if err != nil {
+		return errors.Wrap(err, "Error closing tarball pipe writer")
+	}
+
+	err = <-tarWriterRes
+	if err != nil {
+		return errors.Wrap(err, "Error writing tarball")
+	}
+
+	revert.Success()
+	return nil
+}
+
+// volumeBackupWriteIndex generates an index.yaml file, including the volume and snapshots config, and then
+// writes it to the root of the backup tarball.
+func volumeBackupWriteIndex(s *state.State, projectName string, volumeName string, pool storagePools.Pool, optimized bool, snapshots bool, uuid string, parentUUID string, tarWriter *instancewriter.InstanceTarWriter) error {
+	// Indicate whether the driver will include a driver-specific optimized header.
+	poolDriverOptimizedHeader := false
+	if optimized {
+		poolDriverOptimizedHeader = pool.Driver().Info().OptimizedBackupHeader
+	}
+
+	_, volume, err := s.Cluster.GetLocalStoragePoolVolume(projectName, volumeName, db.StoragePoolVolumeTypeCustom, pool.ID())
+	if err != nil {
+		return err
+	}
+
+	indexInfo := backup.Info{
+		Name:             volumeName,
+		Pool:             pool.Name(),
+		Snapshots:        []string{},
+		Backend:          pool.Driver().Info().Name,
+		Type:             backup.TypeCustom,
+		OptimizedStorage: &optimized,
+		OptimizedHeader:  &poolDriverOptimizedHeader,
+		UUID:             uuid,
+		Parent:           parentUUID,
+		Volume:           volume,
+	}
+
+	if snapshots {
+		snaps, err := storagePools.VolumeSnapshotsGet(s, projectName, pool.Name(), volumeName, db.StoragePoolVolumeTypeCustom)
+		if err != nil {
+			return err
+		}
+
+		for _, snap := range snaps {
+			_, snapName, _ := shared.InstanceGetParentAndSnapshotName(snap.Name)
+
+			snapID, snapVolume, err := s.Cluster.GetLocalStoragePoolVolume(projectName, snap.Name, db.StoragePoolVolumeTypeCustom, pool.ID())
+			if err != nil {
+				return err
+			}
+
+			expiryDate, err := s.Cluster.GetStorageVolumeSnapshotExpiry(snapID)
+			if err != nil {
+				return err
+			}
+
+			volumeSnapshot := &api.StorageVolumeSnapshot{
+				StorageVolumeSnapshotPut: api.StorageVolumeSnapshotPut{
+					Description: snapVolume.Description,
+				},
+				Name:        snapName,
+				Config:      snapVolume.Config,
+				ContentType: snapVolume.ContentType,
+			}
+
+			// Since zero time causes some issues due to timezones, we check the
+			// unix timestamp instead of IsZero().
+			if expiryDate.Unix() > 0 {
+				volumeSnapshot.ExpiresAt = &expiryDate
+			}
+
+			indexInfo.Snapshots = append(indexInfo.Snapshots, snapName)
+			indexInfo.VolumeSnapshots = append(indexInfo.VolumeSnapshots, volumeSnapshot)
+		}
+	}
+
+	// Convert to YAML.
+	indexData, err := yaml.Marshal(&indexInfo)
+	if err != nil {
+		return err
+	}
+	r := bytes.NewReader(indexData)
+
+	indexFileInfo := instancewriter.FileInfo{
+		FileName:    "backup/index.yaml",
+		FileSize:    int64(len(indexData)),
+		FileMode:    0644,
+		FileModTime: time.Now(),
+	}
+
+	// Write to tarball.
+	err = tarWriter.WriteFileFromReader(r, &indexFileInfo)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func pruneExpiredStorageVolumeBackupsTask(d *Daemon) (task.Func, task.Schedule) {
+	f := func(ctx context.Context) {
+		opRun := func(op *operations.Operation) error {
+			return pruneExpiredStorageVolumeBackups(ctx, d)
+		}
+
+		op, err := operations.OperationCreate(d.State(), "", operations.OperationClassTask, db.OperationCustomVolumeBackupsExpire, nil, nil, opRun, nil, nil)
+		if err != nil {
+			logger.Error("Failed to start expired custom volume backups operation", log.Ctx{"err": err})
+			return
+		}
+
+		logger.Info("Pruning expired custom volume backups")
+		_, err = op.Run()
+		if err != nil {
+			logger.Error("Failed to expire custom volume backups", log.Ctx{"err": err})
+		}
+		logger.Info("Done pruning expired custom volume backups")
+	}
+
+	f(context.Background())
+
+	first := true
+	schedule := func() (time.Duration, error) {
+		interval := time.Hour
+
+		if first {
+			first = false
+			return interval, task.ErrSkip
+		}
+
+		return interval, nil
+	}
+
+	return f, schedule
+}
+
+func pruneExpiredStorageVolumeBackups(ctx context.Context, d *Daemon) error {
+	// Get the list of expired backups.
+	backups, err := d.cluster.GetExpiredStoragePoolVolumeBackups()
+	if err != nil {
+		return errors.Wrap(err, "Unable to retrieve the list of expired custom volume backups")
+	}
+
+	// Go through the backups most recent first, so that expired incremental backups are removed before their parent.
+	for i := len(backups) - 1; i >= 0; i-- {
+		args := backups[i]
+
+		// Keep the backups which are still needed to import their incremental backups.
+		err = volumeBackupCheckNoChildren(d.State(), args.VolumeID, args.Name, args.UUID)
+		if err != nil {
+			logger.Debug("Skipping expired custom volume backup", log.Ctx{"backup": args.Name, "err": err})
+			continue
+		}
+
+		b := backup.NewVolumeBackup(d.State(), args.ProjectName, args.PoolName, args.VolumeID, args.ID, args.Name, args.CreationDate, args.ExpiryDate, args.VolumeOnly, args.OptimizedStorage, args.UUID, args.ParentUUID, args.Parent)
+
+		err = volumeBackupDeleteBase(d.State(), args.ProjectName, args.PoolName, b)
+		if err != nil {
+			return errors.Wrapf(err, "Error deleting custom volume backup %s", args.Name)
+		}
+
+		err = b.Delete()
+		if err != nil {
+			return errors.Wrapf(err, "Error deleting custom volume backup %s", args.Name)
+		}
+	}
+
+	return nil
+}
+
+// volumeBackupCheckNoChildren returns an error if some backups of the custom volume are incremental to the backup
+// with the given UUID, as they can't be imported anymore once it's deleted.
+func volumeBackupCheckNoChildren(s *state.State, volumeID int64, backupName string, backupUUID string) error {
+	children, err := s.Cluster.GetStoragePoolVolumeBackupChildren(volumeID, backupUUID)
+	if err != nil {
+		return errors.Wrap(err, "Load incremental backups")
+	}
+
+	if len(children) > 0 {
+		names := make([]string, 0, len(children))
+		for _, child := range children {
+			names = append(names, child.Name)
+		}
+
+		return fmt.Errorf("Backup %q is the parent of incremental backups %q and must be deleted after them", backupName, names)
+	}
+
+	return nil
+}
+
+// volumeBackupDeleteBase removes the base kept on storage for incremental backups of the custom volume based on
+// the backup. Backups predating incremental backups don't have a UUID, and so don't have a base.
+func volumeBackupDeleteBase(s *state.State, projectName string, poolName string, b *backup.VolumeBackup) error {
+	if b.UUID() == "" {
+		return nil
+	}
+
+	pool, err := storagePools.GetPoolByName(s, poolName)
+	if err != nil {
+		return errors.Wrap(err, "Load storage pool")
+	}
+
+	volumeName := strings.SplitN(b.Name(), shared.SnapshotDelimiter, 2)[0]
+
+	return pool.DeleteCustomVolumeBackupBase(projectName, volumeName, b.UUID(), nil)
+}
@@ -17,6 +17,9 @@ import (
 	"github.com/lxc/lxd/shared/api"
 )
 
+// TypeCustom is the backup type used in the index of custom volume backups.
+const TypeCustom = api.InstanceType("custom")
+
 // Instance represents the backup relevant subset of a LXD instance.
 // This is used rather than instance.Instance to avoid import loops.
 type Instance interface {
@@ -34,6 +37,12 @@ type Info struct {
 	OptimizedStorage *bool            `json:"optimized,omitempty" yaml:"optimized,omitempty"`               // Optional field to handle older optimized backups that don't have this field.
 	OptimizedHeader  *bool            `json:"optimized_header,omitempty" yaml:"optimized_header,omitempty"` // Optional field to handle older optimized backups that don't have this field.
 	Type             api.InstanceType `json:"type" yaml:"type"`
+	UUID             string           `json:"uuid,omitempty" yaml:"uuid,omitempty"`     // Optional field to handle backups that predate incremental backups.
+	Parent           string           `json:"parent,omitempty" yaml:"parent,omitempty"` // UUID of the parent backup, only set for incremental backups.
+
+	// Only set for custom volume backups.
+	Volume          *api.StorageVolume           `json:"volume,omitempty" yaml:"volume,omitempty"`
+	VolumeSnapshots []*api.StorageVolumeSnapshot `json:"volume_snapshots,omitempty" yaml:"volume_snapshots,omitempty"`
 }
 
 // GetInfo extracts backup information from a given ReadSeeker.
@@ -134,10 +143,14 @@ type Backup struct {
 	instanceOnly         bool
 	optimizedStorage     bool
 	compressionAlgorithm string
+	uuid                 string
+	parentUUID           string
+	parent               string
 }
 
 // New instantiates a new Backup struct.
-func New(state *state.State, inst Instance, ID int, name string, creationDate, expiryDate time.Time, instanceOnly, optimizedStorage bool) *Backup {
+// The parent is the name of the backup this backup is incremental to, if it still exists.
+func New(state *state.State, inst Instance, ID int, name string, creationDate, expiryDate time.Time, instanceOnly, optimizedStorage bool, uuid string, parentUUID string, parent string) *Backup {
 	return &Backup{
 		state:            state,
 		instance:         inst,
@@ -147,6 +160,9 @@ func New(state *state.State, inst Instance, ID int, name string, creationDate, e
 		expiryDate:       expiryDate,
 		instanceOnly:     instanceOnly,
 		optimizedStorage: optimizedStorage,
+		uuid:             uuid,
+		parentUUID:       parentUUID,
+		parent:           parent,
 	}
 }
 
@@ -176,6 +192,16 @@ func (b *Backup) OptimizedStorage() bool {
 	return b.optimizedStorage
 }
 
+// UUID returns the UUID of the backup.
+func (b *Backup) UUID() string {
+	return b.uuid
+}
+
+// ParentUUID returns the UUID of the backup this backup is incremental to.
+func (b *Backup) ParentUUID() string {
+	return b.parentUUID
+}
+
 // Rename renames a container backup
 func (b *Backup) Rename(newName string) error {
 	oldBackupPath := shared.VarPath("backups", project.Instance(b.instance.Project(), b.name))
@@ -228,9 +254,19 @@ func (b *Backup) Render() *api.InstanceBackup {
 		InstanceOnly:     b.instanceOnly,
 		ContainerOnly:    b.instanceOnly,
 		OptimizedStorage: b.optimizedStorage,
+		Parent:           parentName(b.parent),
 	}
 }
 
+// parentName returns the name of a parent backup without the instance or volume name prefix.
+func parentName(parent string) string {
+	if parent == "" {
+		return ""
+	}
+
+	return strings.SplitN(parent, "/", 2)[1]
+}
+
 // DoBackupDelete deletes a backup.
 func DoBackupDelete(s *state.State, projectName, backupName, containerName string) error {
 	backupPath := shared.VarPath("backups", project.Instance(projectName, backupName))
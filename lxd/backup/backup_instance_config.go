@@ -14,11 +14,13 @@ import (
 )
 
 // InstanceConfig represents the config of an instance that can be stored in a backup.yaml file.
+// It is also used to describe custom volumes found on storage, in which case Container is nil.
 type InstanceConfig struct {
-	Container *api.Instance           `yaml:"container"`
-	Snapshots []*api.InstanceSnapshot `yaml:"snapshots"`
-	Pool      *api.StoragePool        `yaml:"pool"`
-	Volume    *api.StorageVolume      `yaml:"volume"`
+	Container       *api.Instance                `yaml:"container"`
+	Snapshots       []*api.InstanceSnapshot      `yaml:"snapshots"`
+	Pool            *api.StoragePool             `yaml:"pool"`
+	Volume          *api.StorageVolume           `yaml:"volume"`
+	VolumeSnapshots []*api.StorageVolumeSnapshot `yaml:"volume_snapshots,omitempty"`
 }
 
 // ParseInstanceConfigYamlFile decodes the YAML file at path specified into an InstanceConfig.
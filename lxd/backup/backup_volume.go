@@ -0,0 +1,213 @@
+package backup
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// VolumeBackupsPath returns the path of the directory holding the backups of a custom volume.
+func VolumeBackupsPath(projectName string, poolName string, volumeName string) string {
+	return shared.VarPath("backups", "custom", poolName, project.StorageVolume(projectName, volumeName))
+}
+
+// VolumeBackup represents a custom volume backup.
+type VolumeBackup struct {
+	state       *state.State
+	projectName string
+	poolName    string
+	volumeID    int64
+
+	// Properties
+	id                   int
+	name                 string
+	creationDate         time.Time
+	expiryDate           time.Time
+	volumeOnly           bool
+	optimizedStorage     bool
+	compressionAlgorithm string
+	uuid                 string
+	parentUUID           string
+	parent               string
+}
+
+// NewVolumeBackup instantiates a new VolumeBackup struct.
+// The parent is the name of the backup this backup is incremental to, if it still exists.
+func NewVolumeBackup(state *state.State, projectName string, poolName string, volumeID int64, ID int, name string, creationDate, expiryDate time.Time, volumeOnly, optimizedStorage bool, uuid string, parentUUID string, parent string) *VolumeBackup {
+	return &VolumeBackup{
+		state:            state,
+		projectName:      projectName,
+		poolName:         poolName,
+		volumeID:         volumeID,
+		id:               ID,
+		name:             name,
+		creationDate:     creationDate,
+		expiryDate:       expiryDate,
+		volumeOnly:       volumeOnly,
+		optimizedStorage: optimizedStorage,
+		uuid:             uuid,
+		parentUUID:       parentUUID,
+		parent:           parent,
+	}
+}
+
+// VolumeBackupLoadByName loads the named backup of the custom volume with the given ID.
+func VolumeBackupLoadByName(s *state.State, volumeID int64, name string) (*VolumeBackup, error) {
+	args, err := s.Cluster.GetStoragePoolVolumeBackup(volumeID, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "Load backup from database")
+	}
+
+	return NewVolumeBackup(s, args.ProjectName, args.PoolName, args.VolumeID, args.ID, args.Name, args.CreationDate, args.ExpiryDate, args.VolumeOnly, args.OptimizedStorage, args.UUID, args.ParentUUID, args.Parent), nil
+}
+
+// VolumeBackupsLoad loads all the backups of the custom volume with the given ID.
+func VolumeBackupsLoad(s *state.State, volumeID int64) ([]*VolumeBackup, error) {
+	backups, err := s.Cluster.GetStoragePoolVolumeBackups(volumeID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Load backups from database")
+	}
+
+	result := make([]*VolumeBackup, 0, len(backups))
+	for _, args := range backups {
+		result = append(result, NewVolumeBackup(s, args.ProjectName, args.PoolName, args.VolumeID, args.ID, args.Name, args.CreationDate, args.ExpiryDate, args.VolumeOnly, args.OptimizedStorage, args.UUID, args.ParentUUID, args.Parent))
+	}
+
+	return result, nil
+}
+
+// CompressionAlgorithm returns the compression used for the tarball.
+func (b *VolumeBackup) CompressionAlgorithm() string {
+	return b.compressionAlgorithm
+}
+
+// SetCompressionAlgorithm sets the tarball compression.
+func (b *VolumeBackup) SetCompressionAlgorithm(compression string) {
+	b.compressionAlgorithm = compression
+}
+
+// VolumeOnly returns whether only the volume itself is to be backed up.
+func (b *VolumeBackup) VolumeOnly() bool {
+	return b.volumeOnly
+}
+
+// Name returns the name of the backup.
+func (b *VolumeBackup) Name() string {
+	return b.name
+}
+
+// OptimizedStorage returns whether the backup is to be performed using
+// optimization supported by the storage driver.
+func (b *VolumeBackup) OptimizedStorage() bool {
+	return b.optimizedStorage
+}
+
+// UUID returns the UUID of the backup.
+func (b *VolumeBackup) UUID() string {
+	return b.uuid
+}
+
+// ParentUUID returns the UUID of the backup this backup is incremental to.
+func (b *VolumeBackup) ParentUUID() string {
+	return b.parentUUID
+}
+
+// Path returns the path of the backup tarball.
+func (b *VolumeBackup) Path() string {
+	return shared.VarPath("backups", "custom", b.poolName, project.StorageVolume(b.projectName, b.name))
+}
+
+// volumeName returns the name of the volume the backup belongs to.
+func (b *VolumeBackup) volumeName() string {
+	return strings.SplitN(b.name, "/", 2)[0]
+}
+
+// Rename renames a custom volume backup.
+func (b *VolumeBackup) Rename(newName string) error {
+	oldBackupPath := b.Path()
+	newBackupPath := shared.VarPath("backups", "custom", b.poolName, project.StorageVolume(b.projectName, newName))
+
+	// Create the new backup path.
+	newBackupsPath := VolumeBackupsPath(b.projectName, b.poolName, strings.SplitN(newName, "/", 2)[0])
+	if !shared.PathExists(newBackupsPath) {
+		err := os.MkdirAll(newBackupsPath, 0700)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Rename the backup tarball.
+	err := os.Rename(oldBackupPath, newBackupPath)
+	if err != nil {
+		return err
+	}
+
+	// Check if we can remove the old volume directory.
+	backupsPath := VolumeBackupsPath(b.projectName, b.poolName, b.volumeName())
+	empty, _ := shared.PathIsEmpty(backupsPath)
+	if empty {
+		err := os.Remove(backupsPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Rename the database record.
+	err = b.state.Cluster.RenameStoragePoolVolumeBackup(b.volumeID, b.name, newName)
+	if err != nil {
+		return err
+	}
+
+	b.name = newName
+
+	return nil
+}
+
+// Delete removes a custom volume backup.
+func (b *VolumeBackup) Delete() error {
+	// Delete the on-disk data.
+	backupPath := b.Path()
+	if shared.PathExists(backupPath) {
+		err := os.RemoveAll(backupPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Check if we can remove the volume directory.
+	backupsPath := VolumeBackupsPath(b.projectName, b.poolName, b.volumeName())
+	empty, _ := shared.PathIsEmpty(backupsPath)
+	if empty {
+		err := os.Remove(backupsPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Remove the database record.
+	err := b.state.Cluster.DeleteStoragePoolVolumeBackup(b.volumeID, b.name)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Render returns a StoragePoolVolumeBackup struct of the backup.
+func (b *VolumeBackup) Render() *api.StoragePoolVolumeBackup {
+	return &api.StoragePoolVolumeBackup{
+		Name:             strings.SplitN(b.name, "/", 2)[1],
+		CreatedAt:        b.creationDate,
+		ExpiresAt:        b.expiryDate,
+		VolumeOnly:       b.volumeOnly,
+		OptimizedStorage: b.optimizedStorage,
+		Parent:           parentName(b.parent),
+	}
+}
@@ -126,6 +126,27 @@ func ConnectIfVolumeIsRemote(cluster *db.Cluster, poolID int64, volumeName strin
 	return Connect(address, cert, false)
 }
 
+// ConnectIfBucketIsRemote is a convenience around Connect that returns a client connected to the node
+// holding the storage bucket with the given pool ID, project and name. If the bucket is held by this node,
+// nil is returned.
+func ConnectIfBucketIsRemote(cluster *db.Cluster, poolID int64, projectName string, bucketName string, cert *shared.CertInfo) (lxd.InstanceServer, error) {
+	var address string
+	err := cluster.Transaction(func(tx *db.ClusterTx) error {
+		var err error
+		address, err = tx.GetStorageBucketNodeAddress(poolID, projectName, bucketName)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if address == "" {
+		return nil, nil
+	}
+
+	return Connect(address, cert, false)
+}
+
 // SetupTrust is a convenience around InstanceServer.CreateCertificate that
 // adds the given client certificate to the trusted pool of the cluster at the
 // given address, using the given password.
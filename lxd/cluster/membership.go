@@ -12,6 +12,7 @@ import (
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/db/cluster"
 	"github.com/lxc/lxd/lxd/node"
+	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
@@ -407,22 +408,30 @@ func Join(state *state.State, gateway *Gateway, cert *shared.CertInfo, name stri
 		}
 
 		// Networks.
-		ids, err = tx.GetNonPendingNetworkIDs()
+		networkIDs, err := tx.GetNonPendingNetworkIDs()
 		if err != nil {
 			return errors.Wrap(err, "failed to get cluster network IDs")
 		}
-		for name, id := range ids {
-			config, ok := networks[name]
-			if !ok {
-				return fmt.Errorf("joining node has no config for network %s", name)
-			}
-			err := tx.NetworkNodeJoin(id, node.ID)
-			if err != nil {
-				return errors.Wrap(err, "failed to add joining node's to the network")
-			}
-			err = tx.CreateNetworkConfig(id, node.ID, config)
-			if err != nil {
-				return errors.Wrap(err, "failed to add joining node's network config")
+		for projectName, ids := range networkIDs {
+			for name, id := range ids {
+				err := tx.NetworkNodeJoin(id, node.ID)
+				if err != nil {
+					return errors.Wrap(err, "failed to add joining node's to the network")
+				}
+
+				// Only networks in the default project have node-specific config.
+				if projectName != project.Default {
+					continue
+				}
+
+				config, ok := networks[name]
+				if !ok {
+					return fmt.Errorf("joining node has no config for network %s", name)
+				}
+				err = tx.CreateNetworkConfig(id, node.ID, config)
+				if err != nil {
+					return errors.Wrap(err, "failed to add joining node's network config")
+				}
 			}
 		}
 
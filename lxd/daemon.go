@@ -33,6 +33,7 @@ import (
 	"github.com/lxc/lxd/lxd/daemon"
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/device"
+	"github.com/lxc/lxd/lxd/dns"
 	"github.com/lxc/lxd/lxd/endpoints"
 	"github.com/lxc/lxd/lxd/events"
 	"github.com/lxc/lxd/lxd/firewall"
@@ -88,6 +89,7 @@ type Daemon struct {
 	endpoints *endpoints.Endpoints
 	gateway   *cluster.Gateway
 	seccomp   *seccomp.Server
+	dns       *dns.Server
 
 	proxy func(req *http.Request) (*url.URL, error)
 
@@ -157,7 +159,7 @@ func newDaemon(config *DaemonConfig, os *sys.OS) *Daemon {
 	devlxdEvents := events.NewServer(daemon.Debug, daemon.Verbose)
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &Daemon{
+	d := &Daemon{
 		config:       config,
 		devlxdEvents: devlxdEvents,
 		events:       lxdEvents,
@@ -168,6 +170,14 @@ func newDaemon(config *DaemonConfig, os *sys.OS) *Daemon {
 		ctx:          ctx,
 		cancel:       cancel,
 	}
+
+	// The DNS server is only started once the daemon is initialized, but the networks and devices need it
+	// beforehand to invalidate its cached zones.
+	d.dns = dns.NewServer(func(name string) (*dns.Zone, error) {
+		return networkZoneRecords(d.State(), name)
+	})
+
+	return d
 }
 
 // defaultDaemonConfig returns a DaemonConfig object with default values.
@@ -364,7 +374,7 @@ func (d *Daemon) State() *state.State {
 	// If the daemon is shutting down, the context will be cancelled.
 	// This information will be available throughout the code, and can be used to prevent new
 	// operations from starting during shutdown.
-	return state.NewState(d.ctx, d.db, d.cluster, d.maas, d.os, d.endpoints, d.events, d.devlxdEvents, d.firewall, d.proxy)
+	return state.NewState(d.ctx, d.db, d.cluster, d.maas, d.os, d.endpoints, d.events, d.devlxdEvents, d.firewall, d.dns, d.proxy)
 }
 
 // UnixSocket returns the full path to the unix.socket file that this daemon is
@@ -804,17 +814,24 @@ func (d *Daemon) init() error {
 		return errors.Wrap(err, "Failed to fetch debug address")
 	}
 
+	storageBucketsAddress, err := node.StorageBucketsAddress(d.db)
+	if err != nil {
+		return errors.Wrap(err, "Failed to fetch storage buckets address")
+	}
+
 	/* Setup the web server */
 	config := &endpoints.Config{
-		Dir:                  d.os.VarDir,
-		UnixSocket:           d.UnixSocket(),
-		Cert:                 certInfo,
-		RestServer:           restServer(d),
-		DevLxdServer:         devLxdServer(d),
-		LocalUnixSocketGroup: d.config.Group,
-		NetworkAddress:       address,
-		ClusterAddress:       clusterAddress,
-		DebugAddress:         debugAddress,
+		Dir:                   d.os.VarDir,
+		UnixSocket:            d.UnixSocket(),
+		Cert:                  certInfo,
+		RestServer:            restServer(d),
+		DevLxdServer:          devLxdServer(d),
+		LocalUnixSocketGroup:  d.config.Group,
+		NetworkAddress:        address,
+		ClusterAddress:        clusterAddress,
+		DebugAddress:          debugAddress,
+		StorageBucketsAddress: storageBucketsAddress,
+		StorageBucketsServer:  storageBucketsServer(d),
 	}
 	d.endpoints, err = endpoints.Up(config)
 	if err != nil {
@@ -947,6 +964,22 @@ func (d *Daemon) init() error {
 		return err
 	}
 
+	// Setup the DNS server serving the network zones.
+	err = networkZonesReload(d)
+	if err != nil {
+		return err
+	}
+
+	dnsAddress, err := node.DNSAddress(d.db)
+	if err != nil {
+		return errors.Wrap(err, "Failed to fetch DNS address")
+	}
+
+	err = d.dns.Start(dnsAddress)
+	if err != nil {
+		logger.Error("Failed to start DNS server", log.Ctx{"err": err})
+	}
+
 	// Cleanup leftover images.
 	pruneLeftoverImages(d)
 
@@ -1126,6 +1159,9 @@ func (d *Daemon) Ready() error {
 		// Remove expired container backups (hourly)
 		d.tasks.Add(pruneExpiredContainerBackupsTask(d))
 
+		// Remove expired custom volume backups (hourly)
+		d.tasks.Add(pruneExpiredStorageVolumeBackupsTask(d))
+
 		// Take snapshot of containers (minutely check of configurable cron expression)
 		d.tasks.Add(autoCreateContainerSnapshotsTask(d))
 
@@ -1206,6 +1242,15 @@ func (d *Daemon) Stop() error {
 	trackError(d.tasks.Stop(3*time.Second), "Stop tasks")                // Give tasks a bit of time to cleanup.
 	trackError(d.clusterTasks.Stop(3*time.Second), "Stop cluster tasks") // Give tasks a bit of time to cleanup.
 
+	// Load the storage buckets to unmount while the database is still available, and unmount them once the
+	// S3 endpoint is down.
+	var unmountBuckets func() error
+	if d.cluster != nil {
+		var err error
+		unmountBuckets, err = storageBucketsUnmountFunc(d.State())
+		trackError(err, "Load storage buckets")
+	}
+
 	shouldUnmount := false
 	if d.cluster != nil {
 		// It might be that database nodes are all down, in that case
@@ -1251,6 +1296,10 @@ func (d *Daemon) Stop() error {
 		trackError(d.endpoints.Down(), "Shutdown endpoints")
 	}
 
+	if unmountBuckets != nil {
+		trackError(unmountBuckets(), "Unmount storage buckets")
+	}
+
 	if shouldUnmount {
 		logger.Infof("Unmounting temporary filesystems")
 
@@ -1267,6 +1316,10 @@ func (d *Daemon) Stop() error {
 		trackError(d.seccomp.Stop(), "Stop seccomp")
 	}
 
+	if d.dns != nil {
+		trackError(d.dns.Stop(), "Stop DNS server")
+	}
+
 	var err error
 	if n := len(errs); n > 0 {
 		format := "%v"
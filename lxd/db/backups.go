@@ -22,6 +22,9 @@ type InstanceBackup struct {
 	InstanceOnly         bool
 	OptimizedStorage     bool
 	CompressionAlgorithm string
+	UUID                 string
+	ParentUUID           string
+	Parent               string // Name of the parent backup, only set if it still exists.
 }
 
 // Returns the ID of the instance backup with the given name.
@@ -48,15 +51,19 @@ func (c *Cluster) GetInstanceBackup(project, name string) (InstanceBackup, error
 	q := `
 SELECT instances_backups.id, instances_backups.instance_id,
        instances_backups.creation_date, instances_backups.expiry_date,
-       instances_backups.container_only, instances_backups.optimized_storage
+       instances_backups.container_only, instances_backups.optimized_storage,
+       instances_backups.uuid, instances_backups.parent_uuid, COALESCE(parents.name, '')
     FROM instances_backups
     JOIN instances ON instances.id=instances_backups.instance_id
     JOIN projects ON projects.id=instances.project_id
+    LEFT JOIN instances_backups AS parents
+      ON parents.instance_id=instances_backups.instance_id AND parents.uuid=instances_backups.parent_uuid AND parents.uuid != ''
     WHERE projects.name=? AND instances_backups.name=?
 `
 	arg1 := []interface{}{project, name}
 	arg2 := []interface{}{&args.ID, &args.InstanceID, &args.CreationDate,
-		&args.ExpiryDate, &instanceOnlyInt, &optimizedStorageInt}
+		&args.ExpiryDate, &instanceOnlyInt, &optimizedStorageInt,
+		&args.UUID, &args.ParentUUID, &args.Parent}
 	err := dbQueryRowScan(c, q, arg1, arg2)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -100,6 +107,31 @@ WHERE projects.name=? AND instances.name=?`
 	return result, nil
 }
 
+// GetInstanceBackupChildren returns the names of the backups of the instance with the given ID which are
+// incremental to the backup with the given UUID.
+func (c *Cluster) GetInstanceBackupChildren(instanceID int, uuid string) ([]string, error) {
+	// Backups predating incremental backups don't have a UUID, and so can't have children.
+	if uuid == "" {
+		return nil, nil
+	}
+
+	var result []string
+
+	q := `SELECT name FROM instances_backups WHERE instance_id=? AND parent_uuid=? ORDER BY id`
+	inargs := []interface{}{instanceID, uuid}
+	outfmt := []interface{}{""}
+	dbResults, err := queryScan(c, q, inargs, outfmt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range dbResults {
+		result = append(result, r[0].(string))
+	}
+
+	return result, nil
+}
+
 // CreateInstanceBackup creates a new backup.
 func (c *Cluster) CreateInstanceBackup(args InstanceBackup) error {
 	_, err := c.getInstanceBackupID(args.Name)
@@ -118,7 +150,7 @@ func (c *Cluster) CreateInstanceBackup(args InstanceBackup) error {
 			optimizedStorageInt = 1
 		}
 
-		str := fmt.Sprintf("INSERT INTO instances_backups (instance_id, name, creation_date, expiry_date, container_only, optimized_storage) VALUES (?, ?, ?, ?, ?, ?)")
+		str := fmt.Sprintf("INSERT INTO instances_backups (instance_id, name, creation_date, expiry_date, container_only, optimized_storage, uuid, parent_uuid) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
 		stmt, err := tx.tx.Prepare(str)
 		if err != nil {
 			return err
@@ -126,7 +158,7 @@ func (c *Cluster) CreateInstanceBackup(args InstanceBackup) error {
 		defer stmt.Close()
 		result, err := stmt.Exec(args.InstanceID, args.Name,
 			args.CreationDate.Unix(), args.ExpiryDate.Unix(), instanceOnlyInt,
-			optimizedStorageInt)
+			optimizedStorageInt, args.UUID, args.ParentUUID)
 		if err != nil {
 			return err
 		}
@@ -183,15 +215,16 @@ func (c *Cluster) RenameInstanceBackup(oldName, newName string) error {
 	return err
 }
 
-// GetExpiredInstanceBackups returns a list of expired instance backups.
+// GetExpiredInstanceBackups returns a list of expired instance backups, most recent first.
 func (c *Cluster) GetExpiredInstanceBackups() ([]InstanceBackup, error) {
 	var result []InstanceBackup
 	var name string
 	var expiryDate string
 	var instanceID int
+	var uuid string
 
-	q := `SELECT instances_backups.name, instances_backups.expiry_date, instances_backups.instance_id FROM instances_backups`
-	outfmt := []interface{}{name, expiryDate, instanceID}
+	q := `SELECT instances_backups.name, instances_backups.expiry_date, instances_backups.instance_id, instances_backups.uuid FROM instances_backups ORDER BY instances_backups.id DESC`
+	outfmt := []interface{}{name, expiryDate, instanceID, uuid}
 	dbResults, err := queryScan(c, q, nil, outfmt)
 	if err != nil {
 		return nil, err
@@ -219,9 +252,162 @@ func (c *Cluster) GetExpiredInstanceBackups() ([]InstanceBackup, error) {
 				Name:       r[0].(string),
 				InstanceID: r[2].(int),
 				ExpiryDate: backupExpiry,
+				UUID:       r[3].(string),
 			})
 		}
 	}
 
 	return result, nil
 }
+
+// StoragePoolVolumeBackup is a value object holding all db-related details about a custom volume backup.
+type StoragePoolVolumeBackup struct {
+	ID                   int
+	VolumeID             int64
+	ProjectName          string
+	PoolName             string
+	Name                 string
+	CreationDate         time.Time
+	ExpiryDate           time.Time
+	VolumeOnly           bool
+	OptimizedStorage     bool
+	CompressionAlgorithm string
+	UUID                 string
+	ParentUUID           string
+	Parent               string // Name of the parent backup, only set if it still exists.
+}
+
+// storagePoolVolumeBackupsGet returns the volume backups matching the given filter.
+func (c *Cluster) storagePoolVolumeBackupsGet(where string, args ...interface{}) ([]StoragePoolVolumeBackup, error) {
+	backups := []StoragePoolVolumeBackup{}
+
+	q := fmt.Sprintf(`
+SELECT storage_volumes_backups.id, storage_volumes_backups.storage_volume_id,
+       projects.name, storage_pools.name, storage_volumes_backups.name,
+       storage_volumes_backups.creation_date, storage_volumes_backups.expiry_date,
+       storage_volumes_backups.volume_only, storage_volumes_backups.optimized_storage,
+       storage_volumes_backups.uuid, storage_volumes_backups.parent_uuid, COALESCE(parents.name, '')
+    FROM storage_volumes_backups
+    JOIN storage_volumes ON storage_volumes.id=storage_volumes_backups.storage_volume_id
+    JOIN projects ON projects.id=storage_volumes.project_id
+    JOIN storage_pools ON storage_pools.id=storage_volumes.storage_pool_id
+    LEFT JOIN storage_volumes_backups AS parents
+      ON parents.storage_volume_id=storage_volumes_backups.storage_volume_id AND parents.uuid=storage_volumes_backups.parent_uuid AND parents.uuid != ''
+    WHERE %s
+    ORDER BY storage_volumes_backups.id
+`, where)
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		rows, err := tx.tx.Query(q, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			backup := StoragePoolVolumeBackup{}
+			err := rows.Scan(&backup.ID, &backup.VolumeID, &backup.ProjectName, &backup.PoolName, &backup.Name,
+				&backup.CreationDate, &backup.ExpiryDate, &backup.VolumeOnly, &backup.OptimizedStorage,
+				&backup.UUID, &backup.ParentUUID, &backup.Parent)
+			if err != nil {
+				return err
+			}
+
+			backups = append(backups, backup)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return backups, nil
+}
+
+// GetStoragePoolVolumeBackups returns all the backups of the custom volume with the given ID.
+func (c *Cluster) GetStoragePoolVolumeBackups(volumeID int64) ([]StoragePoolVolumeBackup, error) {
+	return c.storagePoolVolumeBackupsGet("storage_volumes_backups.storage_volume_id=?", volumeID)
+}
+
+// GetStoragePoolVolumeBackup returns the backup with the given name of the custom volume with the given ID.
+func (c *Cluster) GetStoragePoolVolumeBackup(volumeID int64, name string) (StoragePoolVolumeBackup, error) {
+	backups, err := c.storagePoolVolumeBackupsGet("storage_volumes_backups.storage_volume_id=? AND storage_volumes_backups.name=?", volumeID, name)
+	if err != nil {
+		return StoragePoolVolumeBackup{}, err
+	}
+
+	if len(backups) != 1 {
+		return StoragePoolVolumeBackup{}, ErrNoSuchObject
+	}
+
+	return backups[0], nil
+}
+
+// GetStoragePoolVolumeBackupChildren returns the backups of the custom volume with the given ID which are
+// incremental to the backup with the given UUID.
+func (c *Cluster) GetStoragePoolVolumeBackupChildren(volumeID int64, uuid string) ([]StoragePoolVolumeBackup, error) {
+	// Backups predating incremental backups don't have a UUID, and so can't have children.
+	if uuid == "" {
+		return nil, nil
+	}
+
+	return c.storagePoolVolumeBackupsGet("storage_volumes_backups.storage_volume_id=? AND storage_volumes_backups.parent_uuid=?", volumeID, uuid)
+}
+
+// CreateStoragePoolVolumeBackup creates a new custom volume backup.
+func (c *Cluster) CreateStoragePoolVolumeBackup(args StoragePoolVolumeBackup) error {
+	_, err := c.GetStoragePoolVolumeBackup(args.VolumeID, args.Name)
+	if err == nil {
+		return ErrAlreadyDefined
+	}
+
+	if err != ErrNoSuchObject {
+		return err
+	}
+
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("INSERT INTO storage_volumes_backups (storage_volume_id, name, creation_date, expiry_date, volume_only, optimized_storage, uuid, parent_uuid) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			args.VolumeID, args.Name, args.CreationDate.Unix(), args.ExpiryDate.Unix(), args.VolumeOnly, args.OptimizedStorage, args.UUID, args.ParentUUID)
+		if err != nil {
+			return fmt.Errorf("Error inserting %q into database: %v", args.Name, err)
+		}
+
+		return nil
+	})
+}
+
+// DeleteStoragePoolVolumeBackup removes the custom volume backup with the given name from the database.
+func (c *Cluster) DeleteStoragePoolVolumeBackup(volumeID int64, name string) error {
+	return exec(c, "DELETE FROM storage_volumes_backups WHERE storage_volume_id=? AND name=?", volumeID, name)
+}
+
+// RenameStoragePoolVolumeBackup renames a custom volume backup from the given current name to the new one.
+func (c *Cluster) RenameStoragePoolVolumeBackup(volumeID int64, oldName string, newName string) error {
+	return exec(c, "UPDATE storage_volumes_backups SET name=? WHERE storage_volume_id=? AND name=?", newName, volumeID, oldName)
+}
+
+// GetExpiredStoragePoolVolumeBackups returns a list of expired custom volume backups stored on this node.
+func (c *Cluster) GetExpiredStoragePoolVolumeBackups() ([]StoragePoolVolumeBackup, error) {
+	backups, err := c.storagePoolVolumeBackupsGet("storage_volumes.node_id=?", c.nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []StoragePoolVolumeBackup{}
+	for _, backup := range backups {
+		// Since zero time causes some issues due to timezones, we check the
+		// unix timestamp instead of IsZero().
+		if backup.ExpiryDate.Unix() <= 0 {
+			// Backup doesn't expire
+			continue
+		}
+
+		// Backup has expired
+		if time.Now().Unix()-backup.ExpiryDate.Unix() >= 0 {
+			result = append(result, backup)
+		}
+	}
+
+	return result, nil
+}
@@ -107,6 +107,8 @@ CREATE TABLE "instances_backups" (
     expiry_date DATETIME,
     container_only INTEGER NOT NULL default 0,
     optimized_storage INTEGER NOT NULL default 0,
+    uuid TEXT NOT NULL DEFAULT '',
+    parent_uuid TEXT NOT NULL DEFAULT '',
     FOREIGN KEY (instance_id) REFERENCES "instances" (id) ON DELETE CASCADE,
     UNIQUE (instance_id, name)
 );
@@ -276,14 +278,32 @@ CREATE VIEW instances_snapshots_devices_ref (
      JOIN instances ON instances.id=instances_snapshots.instance_id
      JOIN projects ON projects.id=instances.project_id
      JOIN instances_snapshots ON instances_snapshots.id=instances_snapshots_devices.instance_snapshot_id;
-CREATE TABLE networks (
+CREATE TABLE "networks" (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    project_id INTEGER NOT NULL,
     name TEXT NOT NULL,
     description TEXT,
     state INTEGER NOT NULL DEFAULT 0,
     type INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (project_id, name),
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+CREATE TABLE networks_acls (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    ingress TEXT NOT NULL,
+    egress TEXT NOT NULL,
     UNIQUE (name)
 );
+CREATE TABLE networks_acls_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_acl_id INTEGER NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    value TEXT,
+    UNIQUE (network_acl_id, key),
+    FOREIGN KEY (network_acl_id) REFERENCES networks_acls (id) ON DELETE CASCADE
+);
 CREATE TABLE networks_config (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     network_id INTEGER NOT NULL,
@@ -294,6 +314,51 @@ CREATE TABLE networks_config (
     FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE,
     FOREIGN KEY (node_id) REFERENCES nodes (id) ON DELETE CASCADE
 );
+CREATE TABLE networks_forwards (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_id INTEGER NOT NULL,
+    listen_address TEXT NOT NULL,
+    description TEXT NOT NULL,
+    ports TEXT NOT NULL,
+    UNIQUE (network_id, listen_address),
+    FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
+);
+CREATE TABLE networks_forwards_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_forward_id INTEGER NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    value TEXT,
+    UNIQUE (network_forward_id, key),
+    FOREIGN KEY (network_forward_id) REFERENCES networks_forwards (id) ON DELETE CASCADE
+);
+CREATE TABLE networks_leases (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_id INTEGER NOT NULL,
+    hwaddr TEXT NOT NULL,
+    hostname TEXT NOT NULL,
+    ipv4_address TEXT NOT NULL,
+    ipv6_address TEXT NOT NULL,
+    UNIQUE (network_id, hwaddr),
+    FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
+);
+CREATE TABLE networks_load_balancers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_id INTEGER NOT NULL,
+    listen_address TEXT NOT NULL,
+    description TEXT NOT NULL,
+    backends TEXT NOT NULL,
+    ports TEXT NOT NULL,
+    UNIQUE (network_id, listen_address),
+    FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
+);
+CREATE TABLE networks_load_balancers_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_load_balancer_id INTEGER NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    value TEXT,
+    UNIQUE (network_load_balancer_id, key),
+    FOREIGN KEY (network_load_balancer_id) REFERENCES networks_load_balancers (id) ON DELETE CASCADE
+);
 CREATE TABLE networks_nodes (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     network_id INTEGER NOT NULL,
@@ -302,6 +367,40 @@ CREATE TABLE networks_nodes (
     FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE,
     FOREIGN KEY (node_id) REFERENCES nodes (id) ON DELETE CASCADE
 );
+CREATE TABLE networks_peers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    target_network_project TEXT NOT NULL,
+    target_network_name TEXT NOT NULL,
+    target_network_id INTEGER,
+    UNIQUE (network_id, name),
+    UNIQUE (network_id, target_network_project, target_network_name),
+    FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
+);
+CREATE TABLE networks_peers_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_peer_id INTEGER NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    value TEXT,
+    UNIQUE (network_peer_id, key),
+    FOREIGN KEY (network_peer_id) REFERENCES networks_peers (id) ON DELETE CASCADE
+);
+CREATE TABLE networks_zones (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    UNIQUE (name)
+);
+CREATE TABLE networks_zones_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_zone_id INTEGER NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    value TEXT,
+    UNIQUE (network_zone_id, key),
+    FOREIGN KEY (network_zone_id) REFERENCES networks_zones (id) ON DELETE CASCADE
+);
 CREATE TABLE nodes (
     id INTEGER PRIMARY KEY,
     name TEXT NOT NULL,
@@ -460,7 +559,51 @@ CREATE VIEW projects_used_by_ref (name,
     printf('/1.0/profiles/%s?project=%s',
     profiles.name,
     projects.name)
-    FROM profiles JOIN projects ON project_id=projects.id;
+    FROM profiles JOIN projects ON project_id=projects.id UNION
+  SELECT projects.name,
+    printf('/1.0/networks/%s?project=%s',
+    networks.name,
+    projects.name)
+    FROM networks JOIN projects ON project_id=projects.id UNION
+  SELECT projects.name,
+    printf('/1.0/storage-pools/%s/buckets/%s?project=%s&target=%s',
+    storage_pools.name,
+    storage_buckets.name,
+    projects.name,
+    nodes.name)
+    FROM storage_buckets JOIN storage_pools ON storage_pool_id=storage_pools.id JOIN nodes ON node_id=nodes.id JOIN projects ON project_id=projects.id;
+CREATE TABLE storage_buckets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name TEXT NOT NULL,
+    storage_pool_id INTEGER NOT NULL,
+    node_id INTEGER NOT NULL,
+    description TEXT NOT NULL,
+    project_id INTEGER NOT NULL,
+    UNIQUE (name),
+    FOREIGN KEY (storage_pool_id) REFERENCES storage_pools (id) ON DELETE CASCADE,
+    FOREIGN KEY (node_id) REFERENCES nodes (id) ON DELETE CASCADE,
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+CREATE TABLE storage_buckets_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    storage_bucket_id INTEGER NOT NULL,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    UNIQUE (storage_bucket_id, key),
+    FOREIGN KEY (storage_bucket_id) REFERENCES storage_buckets (id) ON DELETE CASCADE
+);
+CREATE TABLE storage_buckets_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    storage_bucket_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    role TEXT NOT NULL,
+    access_key TEXT NOT NULL,
+    secret_key TEXT NOT NULL,
+    UNIQUE (storage_bucket_id, name),
+    UNIQUE (access_key),
+    FOREIGN KEY (storage_bucket_id) REFERENCES storage_buckets (id) ON DELETE CASCADE
+);
 CREATE TABLE storage_pools (
     id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
     name TEXT NOT NULL,
@@ -531,6 +674,19 @@ CREATE VIEW storage_volumes_all (
          storage_volumes.content_type
     FROM storage_volumes
     JOIN storage_volumes_snapshots ON storage_volumes.id = storage_volumes_snapshots.storage_volume_id;
+CREATE TABLE storage_volumes_backups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    storage_volume_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    creation_date DATETIME,
+    expiry_date DATETIME,
+    volume_only INTEGER NOT NULL default 0,
+    optimized_storage INTEGER NOT NULL default 0,
+    uuid TEXT NOT NULL DEFAULT '',
+    parent_uuid TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (storage_volume_id) REFERENCES "storage_volumes" (id) ON DELETE CASCADE,
+    UNIQUE (storage_volume_id, name)
+);
 CREATE TRIGGER storage_volumes_check_id
   BEFORE INSERT ON storage_volumes
   WHEN NEW.id IN (SELECT id FROM storage_volumes_snapshots)
@@ -572,5 +728,5 @@ CREATE TABLE storage_volumes_snapshots_config (
     UNIQUE (storage_volume_snapshot_id, key)
 );
 
-INSERT INTO schema (version, updated_at) VALUES (33, strftime("%s"))
+INSERT INTO schema (version, updated_at) VALUES (45, strftime("%s"))
 `
@@ -70,6 +70,449 @@ var updates = map[int]schema.Update{
 	31: updateFromV30,
 	32: updateFromV31,
 	33: updateFromV32,
+	34: updateFromV33,
+	35: updateFromV34,
+	36: updateFromV35,
+	37: updateFromV36,
+	38: updateFromV37,
+	39: updateFromV38,
+	40: updateFromV39,
+	41: updateFromV40,
+	42: updateFromV41,
+	43: updateFromV42,
+	44: updateFromV43,
+	45: updateFromV44,
+}
+
+// Drop storage_volumes_keys table, encryption keys are only kept in the local keyfiles of the storage pools.
+func updateFromV44(tx *sql.Tx) error {
+	_, err := tx.Exec("DROP TABLE storage_volumes_keys;")
+	if err != nil {
+		return errors.Wrap(err, "Failed to drop storage_volumes_keys table")
+	}
+
+	return nil
+}
+
+// Add uuid and parent_uuid columns to instances_backups and storage_volumes_backups for incremental backups.
+func updateFromV43(tx *sql.Tx) error {
+	stmts := `
+ALTER TABLE instances_backups ADD COLUMN uuid TEXT NOT NULL DEFAULT '';
+ALTER TABLE instances_backups ADD COLUMN parent_uuid TEXT NOT NULL DEFAULT '';
+ALTER TABLE storage_volumes_backups ADD COLUMN uuid TEXT NOT NULL DEFAULT '';
+ALTER TABLE storage_volumes_backups ADD COLUMN parent_uuid TEXT NOT NULL DEFAULT '';
+`
+	_, err := tx.Exec(stmts)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add uuid columns to backups tables")
+	}
+
+	return nil
+}
+
+// Add storage_buckets, storage_buckets_config and storage_buckets_keys tables and reference buckets from projects.
+func updateFromV42(tx *sql.Tx) error {
+	stmt := `
+CREATE TABLE storage_buckets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name TEXT NOT NULL,
+    storage_pool_id INTEGER NOT NULL,
+    node_id INTEGER NOT NULL,
+    description TEXT NOT NULL,
+    project_id INTEGER NOT NULL,
+    UNIQUE (name),
+    FOREIGN KEY (storage_pool_id) REFERENCES storage_pools (id) ON DELETE CASCADE,
+    FOREIGN KEY (node_id) REFERENCES nodes (id) ON DELETE CASCADE,
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+CREATE TABLE storage_buckets_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    storage_bucket_id INTEGER NOT NULL,
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    UNIQUE (storage_bucket_id, key),
+    FOREIGN KEY (storage_bucket_id) REFERENCES storage_buckets (id) ON DELETE CASCADE
+);
+CREATE TABLE storage_buckets_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    storage_bucket_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    role TEXT NOT NULL,
+    access_key TEXT NOT NULL,
+    secret_key TEXT NOT NULL,
+    UNIQUE (storage_bucket_id, name),
+    UNIQUE (access_key),
+    FOREIGN KEY (storage_bucket_id) REFERENCES storage_buckets (id) ON DELETE CASCADE
+);
+`
+	_, err := tx.Exec(stmt)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add storage buckets tables")
+	}
+
+	// Add storage buckets to projects references.
+	stmt = `
+DROP VIEW projects_used_by_ref;
+CREATE VIEW projects_used_by_ref (name,
+    value) AS
+  SELECT projects.name,
+    printf('/1.0/instances/%s?project=%s',
+    "instances".name,
+    projects.name)
+    FROM "instances" JOIN projects ON project_id=projects.id UNION
+  SELECT projects.name,
+    printf('/1.0/images/%s?project=%s',
+    images.fingerprint,
+    projects.name)
+    FROM images JOIN projects ON project_id=projects.id UNION
+  SELECT projects.name,
+    printf('/1.0/storage-pools/%s/volumes/custom/%s?project=%s&target=%s',
+    storage_pools.name,
+    storage_volumes.name,
+    projects.name,
+    nodes.name)
+    FROM storage_volumes JOIN storage_pools ON storage_pool_id=storage_pools.id JOIN nodes ON node_id=nodes.id JOIN projects ON project_id=projects.id WHERE storage_volumes.type=2 UNION
+  SELECT projects.name,
+    printf('/1.0/profiles/%s?project=%s',
+    profiles.name,
+    projects.name)
+    FROM profiles JOIN projects ON project_id=projects.id UNION
+  SELECT projects.name,
+    printf('/1.0/networks/%s?project=%s',
+    networks.name,
+    projects.name)
+    FROM networks JOIN projects ON project_id=projects.id UNION
+  SELECT projects.name,
+    printf('/1.0/storage-pools/%s/buckets/%s?project=%s&target=%s',
+    storage_pools.name,
+    storage_buckets.name,
+    projects.name,
+    nodes.name)
+    FROM storage_buckets JOIN storage_pools ON storage_pool_id=storage_pools.id JOIN nodes ON node_id=nodes.id JOIN projects ON project_id=projects.id;
+`
+	_, err = tx.Exec(stmt)
+	if err != nil {
+		return errors.Wrap(err, "Failed to update projects_used_by_ref view")
+	}
+
+	return nil
+}
+
+// Add storage_volumes_keys table.
+func updateFromV41(tx *sql.Tx) error {
+	stmt := `
+CREATE TABLE storage_volumes_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    storage_volume_id INTEGER NOT NULL,
+    key TEXT NOT NULL,
+    FOREIGN KEY (storage_volume_id) REFERENCES "storage_volumes" (id) ON DELETE CASCADE,
+    UNIQUE (storage_volume_id)
+);
+`
+	_, err := tx.Exec(stmt)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add storage_volumes_keys table")
+	}
+
+	return nil
+}
+
+// Add storage_volumes_backups table.
+func updateFromV40(tx *sql.Tx) error {
+	stmt := `
+CREATE TABLE storage_volumes_backups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    storage_volume_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    creation_date DATETIME,
+    expiry_date DATETIME,
+    volume_only INTEGER NOT NULL default 0,
+    optimized_storage INTEGER NOT NULL default 0,
+    FOREIGN KEY (storage_volume_id) REFERENCES "storage_volumes" (id) ON DELETE CASCADE,
+    UNIQUE (storage_volume_id, name)
+);
+`
+	_, err := tx.Exec(stmt)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add storage_volumes_backups table")
+	}
+
+	return nil
+}
+
+// Add networks_load_balancers and networks_load_balancers_config tables.
+func updateFromV39(tx *sql.Tx) error {
+	stmts := `
+CREATE TABLE networks_load_balancers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_id INTEGER NOT NULL,
+    listen_address TEXT NOT NULL,
+    description TEXT NOT NULL,
+    backends TEXT NOT NULL,
+    ports TEXT NOT NULL,
+    UNIQUE (network_id, listen_address),
+    FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
+);
+CREATE TABLE networks_load_balancers_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_load_balancer_id INTEGER NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    value TEXT,
+    UNIQUE (network_load_balancer_id, key),
+    FOREIGN KEY (network_load_balancer_id) REFERENCES networks_load_balancers (id) ON DELETE CASCADE
+);
+`
+	_, err := tx.Exec(stmts)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add networks_load_balancers tables")
+	}
+
+	return nil
+}
+
+// Add networks_zones and networks_zones_config tables.
+func updateFromV38(tx *sql.Tx) error {
+	stmts := `
+CREATE TABLE networks_zones (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    UNIQUE (name)
+);
+CREATE TABLE networks_zones_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_zone_id INTEGER NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    value TEXT,
+    UNIQUE (network_zone_id, key),
+    FOREIGN KEY (network_zone_id) REFERENCES networks_zones (id) ON DELETE CASCADE
+);
+`
+	_, err := tx.Exec(stmts)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add networks_zones tables")
+	}
+
+	return nil
+}
+
+// Add networks_leases table.
+func updateFromV37(tx *sql.Tx) error {
+	stmts := `
+CREATE TABLE networks_leases (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_id INTEGER NOT NULL,
+    hwaddr TEXT NOT NULL,
+    hostname TEXT NOT NULL,
+    ipv4_address TEXT NOT NULL,
+    ipv6_address TEXT NOT NULL,
+    UNIQUE (network_id, hwaddr),
+    FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
+);
+`
+	_, err := tx.Exec(stmts)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add networks_leases table")
+	}
+
+	return nil
+}
+
+// Add networks_peers and networks_peers_config tables.
+func updateFromV36(tx *sql.Tx) error {
+	stmts := `
+CREATE TABLE networks_peers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    target_network_project TEXT NOT NULL,
+    target_network_name TEXT NOT NULL,
+    target_network_id INTEGER,
+    UNIQUE (network_id, name),
+    UNIQUE (network_id, target_network_project, target_network_name),
+    FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
+);
+CREATE TABLE networks_peers_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_peer_id INTEGER NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    value TEXT,
+    UNIQUE (network_peer_id, key),
+    FOREIGN KEY (network_peer_id) REFERENCES networks_peers (id) ON DELETE CASCADE
+);
+`
+	_, err := tx.Exec(stmts)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add networks_peers tables")
+	}
+
+	return nil
+}
+
+// Add project_id field to networks, add networks to projects references and make network names unique per project.
+func updateFromV35(tx *sql.Tx) error {
+	stmts := `
+DROP VIEW projects_used_by_ref;
+
+CREATE TABLE networks_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    project_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT,
+    state INTEGER NOT NULL DEFAULT 0,
+    type INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (project_id, name),
+    FOREIGN KEY (project_id) REFERENCES projects (id) ON DELETE CASCADE
+);
+
+CREATE TABLE networks_config_copy (
+    id INTEGER NOT NULL,
+    network_id INTEGER NOT NULL,
+    node_id INTEGER,
+    key TEXT NOT NULL,
+    value TEXT
+);
+INSERT INTO networks_config_copy SELECT * FROM networks_config;
+
+CREATE TABLE networks_nodes_copy (
+    id INTEGER NOT NULL,
+    network_id INTEGER NOT NULL,
+    node_id INTEGER NOT NULL
+);
+INSERT INTO networks_nodes_copy SELECT * FROM networks_nodes;
+
+CREATE TABLE networks_forwards_copy (
+    id INTEGER NOT NULL,
+    network_id INTEGER NOT NULL,
+    listen_address TEXT NOT NULL,
+    description TEXT NOT NULL,
+    ports TEXT NOT NULL
+);
+INSERT INTO networks_forwards_copy SELECT * FROM networks_forwards;
+
+CREATE TABLE networks_forwards_config_copy (
+    id INTEGER NOT NULL,
+    network_forward_id INTEGER NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    value TEXT
+);
+INSERT INTO networks_forwards_config_copy SELECT * FROM networks_forwards_config;
+
+-- Existing networks are moved into the default project.
+INSERT INTO networks_new (id, project_id, name, description, state, type)
+    SELECT id, (SELECT id FROM projects WHERE name = 'default'), name, description, state, type FROM networks;
+
+-- Drop the old table and rename the new one. This will trigger cascading deletes on all tables that have direct
+-- or indirect references to the old table, but we have a copy of them that we will use for restoring.
+DROP TABLE networks;
+ALTER TABLE networks_new RENAME TO networks;
+
+-- Restore the content of the tables with direct or indirect references.
+INSERT INTO networks_config SELECT * FROM networks_config_copy;
+INSERT INTO networks_nodes SELECT * FROM networks_nodes_copy;
+INSERT INTO networks_forwards SELECT * FROM networks_forwards_copy;
+INSERT INTO networks_forwards_config SELECT * FROM networks_forwards_config_copy;
+
+-- Drop the copies.
+DROP TABLE networks_config_copy;
+DROP TABLE networks_nodes_copy;
+DROP TABLE networks_forwards_copy;
+DROP TABLE networks_forwards_config_copy;
+
+CREATE VIEW projects_used_by_ref (name,
+    value) AS
+  SELECT projects.name,
+    printf('/1.0/instances/%s?project=%s',
+    "instances".name,
+    projects.name)
+    FROM "instances" JOIN projects ON project_id=projects.id UNION
+  SELECT projects.name,
+    printf('/1.0/images/%s?project=%s',
+    images.fingerprint,
+    projects.name)
+    FROM images JOIN projects ON project_id=projects.id UNION
+  SELECT projects.name,
+    printf('/1.0/storage-pools/%s/volumes/custom/%s?project=%s&target=%s',
+    storage_pools.name,
+    storage_volumes.name,
+    projects.name,
+    nodes.name)
+    FROM storage_volumes JOIN storage_pools ON storage_pool_id=storage_pools.id JOIN nodes ON node_id=nodes.id JOIN projects ON project_id=projects.id WHERE storage_volumes.type=2 UNION
+  SELECT projects.name,
+    printf('/1.0/profiles/%s?project=%s',
+    profiles.name,
+    projects.name)
+    FROM profiles JOIN projects ON project_id=projects.id UNION
+  SELECT projects.name,
+    printf('/1.0/networks/%s?project=%s',
+    networks.name,
+    projects.name)
+    FROM networks JOIN projects ON project_id=projects.id;
+`
+	_, err := tx.Exec(stmts)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add project_id column to networks table")
+	}
+
+	return nil
+}
+
+// Add networks_acls and networks_acls_config tables.
+func updateFromV34(tx *sql.Tx) error {
+	stmts := `
+CREATE TABLE networks_forwards (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_id INTEGER NOT NULL,
+    listen_address TEXT NOT NULL,
+    description TEXT NOT NULL,
+    ports TEXT NOT NULL,
+    UNIQUE (network_id, listen_address),
+    FOREIGN KEY (network_id) REFERENCES networks (id) ON DELETE CASCADE
+);
+CREATE TABLE networks_forwards_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_forward_id INTEGER NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    value TEXT,
+    UNIQUE (network_forward_id, key),
+    FOREIGN KEY (network_forward_id) REFERENCES networks_forwards (id) ON DELETE CASCADE
+);
+`
+	_, err := tx.Exec(stmts)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add networks_forwards tables")
+	}
+
+	return nil
+}
+
+func updateFromV33(tx *sql.Tx) error {
+	stmts := `
+CREATE TABLE networks_acls (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL,
+    ingress TEXT NOT NULL,
+    egress TEXT NOT NULL,
+    UNIQUE (name)
+);
+CREATE TABLE networks_acls_config (
+    id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+    network_acl_id INTEGER NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    value TEXT,
+    UNIQUE (network_acl_id, key),
+    FOREIGN KEY (network_acl_id) REFERENCES networks_acls (id) ON DELETE CASCADE
+);
+`
+	_, err := tx.Exec(stmts)
+	if err != nil {
+		return errors.Wrap(err, "Failed to add networks_acls tables")
+	}
+
+	return nil
 }
 
 // Add type field to networks.
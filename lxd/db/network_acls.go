@@ -0,0 +1,190 @@
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetNetworkACLs returns the names of existing Network ACLs.
+func (c *Cluster) GetNetworkACLs() ([]string, error) {
+	var names []string
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		var err error
+		names, err = query.SelectStrings(tx.tx, "SELECT name FROM networks_acls ORDER BY id")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// GetNetworkACL returns the Network ACL with the given name.
+func (c *Cluster) GetNetworkACL(name string) (int64, *api.NetworkACL, error) {
+	id := int64(-1)
+	var ingressJSON string
+	var egressJSON string
+
+	acl := api.NetworkACL{
+		NetworkACLPost: api.NetworkACLPost{
+			Name: name,
+		},
+	}
+
+	q := "SELECT id, description, ingress, egress FROM networks_acls WHERE name=? LIMIT 1"
+	arg1 := []interface{}{name}
+	arg2 := []interface{}{&id, &acl.Description, &ingressJSON, &egressJSON}
+
+	err := dbQueryRowScan(c, q, arg1, arg2)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return -1, nil, ErrNoSuchObject
+		}
+
+		return -1, nil, err
+	}
+
+	acl.Ingress = []api.NetworkACLRule{}
+	if ingressJSON != "" {
+		err = json.Unmarshal([]byte(ingressJSON), &acl.Ingress)
+		if err != nil {
+			return -1, nil, fmt.Errorf("Failed unmarshalling ingress rules: %v", err)
+		}
+	}
+
+	acl.Egress = []api.NetworkACLRule{}
+	if egressJSON != "" {
+		err = json.Unmarshal([]byte(egressJSON), &acl.Egress)
+		if err != nil {
+			return -1, nil, fmt.Errorf("Failed unmarshalling egress rules: %v", err)
+		}
+	}
+
+	err = c.Transaction(func(tx *ClusterTx) error {
+		acl.Config, err = query.SelectConfig(tx.tx, "networks_acls_config", "network_acl_id=?", id)
+		return err
+	})
+	if err != nil {
+		return -1, nil, fmt.Errorf("Failed loading config: %v", err)
+	}
+
+	return id, &acl, nil
+}
+
+// CreateNetworkACL creates a new Network ACL.
+func (c *Cluster) CreateNetworkACL(info *api.NetworkACLsPost) (int64, error) {
+	var id int64
+
+	ingressJSON, err := json.Marshal(info.Ingress)
+	if err != nil {
+		return -1, fmt.Errorf("Failed marshalling ingress rules: %v", err)
+	}
+
+	egressJSON, err := json.Marshal(info.Egress)
+	if err != nil {
+		return -1, fmt.Errorf("Failed marshalling egress rules: %v", err)
+	}
+
+	err = c.Transaction(func(tx *ClusterTx) error {
+		result, err := tx.tx.Exec("INSERT INTO networks_acls (name, description, ingress, egress) VALUES (?, ?, ?, ?)", info.Name, info.Description, string(ingressJSON), string(egressJSON))
+		if err != nil {
+			return err
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		err = networkACLConfigAdd(tx.tx, id, info.Config)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		id = -1
+	}
+
+	return id, err
+}
+
+// networkACLConfigAdd inserts Network ACL config keys.
+func networkACLConfigAdd(tx *sql.Tx, id int64, config map[string]string) error {
+	q := "INSERT INTO networks_acls_config (network_acl_id, key, value) VALUES(?, ?, ?)"
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for k, v := range config {
+		if v == "" {
+			continue
+		}
+
+		_, err = stmt.Exec(id, k, v)
+		if err != nil {
+			return fmt.Errorf("Failed inserting config: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// UpdateNetworkACL updates the Network ACL with the given ID.
+func (c *Cluster) UpdateNetworkACL(id int64, config *api.NetworkACLPut) error {
+	ingressJSON, err := json.Marshal(config.Ingress)
+	if err != nil {
+		return fmt.Errorf("Failed marshalling ingress rules: %v", err)
+	}
+
+	egressJSON, err := json.Marshal(config.Egress)
+	if err != nil {
+		return fmt.Errorf("Failed marshalling egress rules: %v", err)
+	}
+
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("UPDATE networks_acls SET description=?, ingress = ?, egress = ? WHERE id=?", config.Description, string(ingressJSON), string(egressJSON), id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.tx.Exec("DELETE FROM networks_acls_config WHERE network_acl_id=?", id)
+		if err != nil {
+			return err
+		}
+
+		err = networkACLConfigAdd(tx.tx, id, config.Config)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// RenameNetworkACL renames a Network ACL.
+func (c *Cluster) RenameNetworkACL(id int64, newName string) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("UPDATE networks_acls SET name=? WHERE id=?", newName, id)
+		return err
+	})
+}
+
+// DeleteNetworkACL deletes the Network ACL.
+func (c *Cluster) DeleteNetworkACL(id int64) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("DELETE FROM networks_acls WHERE id=?", id)
+		return err
+	})
+}
@@ -0,0 +1,204 @@
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetNetworkForwards returns the address forwards of the network with the given ID.
+func (c *Cluster) GetNetworkForwards(networkID int64) ([]api.NetworkForward, error) {
+	var listenAddresses []string
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		var err error
+		listenAddresses, err = query.SelectStrings(tx.tx, "SELECT listen_address FROM networks_forwards WHERE network_id=? ORDER BY id", networkID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	forwards := make([]api.NetworkForward, 0, len(listenAddresses))
+	for _, listenAddress := range listenAddresses {
+		_, forward, err := c.GetNetworkForward(networkID, listenAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		forwards = append(forwards, *forward)
+	}
+
+	return forwards, nil
+}
+
+// GetNetworkForwardListenAddresses returns the listen addresses of the address forwards of all networks, keyed on
+// network ID.
+func (c *Cluster) GetNetworkForwardListenAddresses() (map[int64][]string, error) {
+	listenAddresses := map[int64][]string{}
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		rows, err := tx.tx.Query("SELECT network_id, listen_address FROM networks_forwards")
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var networkID int64
+			var listenAddress string
+
+			err = rows.Scan(&networkID, &listenAddress)
+			if err != nil {
+				return err
+			}
+
+			listenAddresses[networkID] = append(listenAddresses[networkID], listenAddress)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return listenAddresses, nil
+}
+
+// GetNetworkForward returns the address forward of the network with the given listen address.
+func (c *Cluster) GetNetworkForward(networkID int64, listenAddress string) (int64, *api.NetworkForward, error) {
+	id := int64(-1)
+	var portsJSON string
+
+	forward := api.NetworkForward{
+		ListenAddress: listenAddress,
+	}
+
+	q := "SELECT id, description, ports FROM networks_forwards WHERE network_id=? AND listen_address=? LIMIT 1"
+	arg1 := []interface{}{networkID, listenAddress}
+	arg2 := []interface{}{&id, &forward.Description, &portsJSON}
+
+	err := dbQueryRowScan(c, q, arg1, arg2)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return -1, nil, ErrNoSuchObject
+		}
+
+		return -1, nil, err
+	}
+
+	forward.Ports = []api.NetworkForwardPort{}
+	if portsJSON != "" {
+		err = json.Unmarshal([]byte(portsJSON), &forward.Ports)
+		if err != nil {
+			return -1, nil, fmt.Errorf("Failed unmarshalling ports: %v", err)
+		}
+	}
+
+	err = c.Transaction(func(tx *ClusterTx) error {
+		forward.Config, err = query.SelectConfig(tx.tx, "networks_forwards_config", "network_forward_id=?", id)
+		return err
+	})
+	if err != nil {
+		return -1, nil, fmt.Errorf("Failed loading config: %v", err)
+	}
+
+	return id, &forward, nil
+}
+
+// CreateNetworkForward creates a new address forward for the network with the given ID.
+func (c *Cluster) CreateNetworkForward(networkID int64, info *api.NetworkForwardsPost) (int64, error) {
+	var id int64
+
+	portsJSON, err := json.Marshal(info.Ports)
+	if err != nil {
+		return -1, fmt.Errorf("Failed marshalling ports: %v", err)
+	}
+
+	err = c.Transaction(func(tx *ClusterTx) error {
+		result, err := tx.tx.Exec("INSERT INTO networks_forwards (network_id, listen_address, description, ports) VALUES (?, ?, ?, ?)", networkID, info.ListenAddress, info.Description, string(portsJSON))
+		if err != nil {
+			return err
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		err = networkForwardConfigAdd(tx.tx, id, info.Config)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		id = -1
+	}
+
+	return id, err
+}
+
+// networkForwardConfigAdd inserts network address forward config keys.
+func networkForwardConfigAdd(tx *sql.Tx, id int64, config map[string]string) error {
+	q := "INSERT INTO networks_forwards_config (network_forward_id, key, value) VALUES(?, ?, ?)"
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for k, v := range config {
+		if v == "" {
+			continue
+		}
+
+		_, err = stmt.Exec(id, k, v)
+		if err != nil {
+			return fmt.Errorf("Failed inserting config: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// UpdateNetworkForward updates the network address forward with the given ID.
+func (c *Cluster) UpdateNetworkForward(id int64, info *api.NetworkForwardPut) error {
+	portsJSON, err := json.Marshal(info.Ports)
+	if err != nil {
+		return fmt.Errorf("Failed marshalling ports: %v", err)
+	}
+
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("UPDATE networks_forwards SET description=?, ports=? WHERE id=?", info.Description, string(portsJSON), id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.tx.Exec("DELETE FROM networks_forwards_config WHERE network_forward_id=?", id)
+		if err != nil {
+			return err
+		}
+
+		err = networkForwardConfigAdd(tx.tx, id, info.Config)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// DeleteNetworkForward deletes the network address forward with the given ID.
+func (c *Cluster) DeleteNetworkForward(id int64) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("DELETE FROM networks_forwards WHERE id=?", id)
+		return err
+	})
+}
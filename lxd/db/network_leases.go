@@ -0,0 +1,92 @@
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"database/sql"
+
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetNetworkLeaseReservations returns the DHCP reservations of the network with the given ID.
+func (c *Cluster) GetNetworkLeaseReservations(networkID int64) ([]api.NetworkLeasesPost, error) {
+	reservations := []api.NetworkLeasesPost{}
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		rows, err := tx.tx.Query("SELECT hwaddr, hostname, ipv4_address, ipv6_address FROM networks_leases WHERE network_id=? ORDER BY id", networkID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			reservation := api.NetworkLeasesPost{}
+
+			err = rows.Scan(&reservation.Hwaddr, &reservation.Hostname, &reservation.IPv4Address, &reservation.IPv6Address)
+			if err != nil {
+				return err
+			}
+
+			reservations = append(reservations, reservation)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+// GetNetworkLeaseReservation returns the DHCP reservation of the network with the given MAC address.
+func (c *Cluster) GetNetworkLeaseReservation(networkID int64, hwaddr string) (int64, *api.NetworkLeasesPost, error) {
+	id := int64(-1)
+
+	reservation := api.NetworkLeasesPost{
+		Hwaddr: hwaddr,
+	}
+
+	q := "SELECT id, hostname, ipv4_address, ipv6_address FROM networks_leases WHERE network_id=? AND hwaddr=? LIMIT 1"
+	arg1 := []interface{}{networkID, hwaddr}
+	arg2 := []interface{}{&id, &reservation.Hostname, &reservation.IPv4Address, &reservation.IPv6Address}
+
+	err := dbQueryRowScan(c, q, arg1, arg2)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return -1, nil, ErrNoSuchObject
+		}
+
+		return -1, nil, err
+	}
+
+	return id, &reservation, nil
+}
+
+// CreateNetworkLeaseReservation creates a new DHCP reservation for the network with the given ID.
+func (c *Cluster) CreateNetworkLeaseReservation(networkID int64, info *api.NetworkLeasesPost) (int64, error) {
+	var id int64
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		result, err := tx.tx.Exec("INSERT INTO networks_leases (network_id, hwaddr, hostname, ipv4_address, ipv6_address) VALUES (?, ?, ?, ?, ?)", networkID, info.Hwaddr, info.Hostname, info.IPv4Address, info.IPv6Address)
+		if err != nil {
+			return err
+		}
+
+		id, err = result.LastInsertId()
+		return err
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+// DeleteNetworkLeaseReservation deletes the DHCP reservation with the given ID.
+func (c *Cluster) DeleteNetworkLeaseReservation(id int64) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("DELETE FROM networks_leases WHERE id=?", id)
+		return err
+	})
+}
@@ -0,0 +1,223 @@
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetNetworkLoadBalancers returns the load balancers of the network with the given ID.
+func (c *Cluster) GetNetworkLoadBalancers(networkID int64) ([]api.NetworkLoadBalancer, error) {
+	var listenAddresses []string
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		var err error
+		listenAddresses, err = query.SelectStrings(tx.tx, "SELECT listen_address FROM networks_load_balancers WHERE network_id=? ORDER BY id", networkID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	loadBalancers := make([]api.NetworkLoadBalancer, 0, len(listenAddresses))
+	for _, listenAddress := range listenAddresses {
+		_, loadBalancer, err := c.GetNetworkLoadBalancer(networkID, listenAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		loadBalancers = append(loadBalancers, *loadBalancer)
+	}
+
+	return loadBalancers, nil
+}
+
+// GetNetworkLoadBalancerListenAddresses returns the listen addresses of the load balancers of all networks, keyed on
+// network ID.
+func (c *Cluster) GetNetworkLoadBalancerListenAddresses() (map[int64][]string, error) {
+	listenAddresses := map[int64][]string{}
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		rows, err := tx.tx.Query("SELECT network_id, listen_address FROM networks_load_balancers")
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var networkID int64
+			var listenAddress string
+
+			err = rows.Scan(&networkID, &listenAddress)
+			if err != nil {
+				return err
+			}
+
+			listenAddresses[networkID] = append(listenAddresses[networkID], listenAddress)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return listenAddresses, nil
+}
+
+// GetNetworkLoadBalancer returns the load balancer of the network with the given listen address.
+func (c *Cluster) GetNetworkLoadBalancer(networkID int64, listenAddress string) (int64, *api.NetworkLoadBalancer, error) {
+	id := int64(-1)
+	var backendsJSON string
+	var portsJSON string
+
+	loadBalancer := api.NetworkLoadBalancer{
+		ListenAddress: listenAddress,
+	}
+
+	q := "SELECT id, description, backends, ports FROM networks_load_balancers WHERE network_id=? AND listen_address=? LIMIT 1"
+	arg1 := []interface{}{networkID, listenAddress}
+	arg2 := []interface{}{&id, &loadBalancer.Description, &backendsJSON, &portsJSON}
+
+	err := dbQueryRowScan(c, q, arg1, arg2)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return -1, nil, ErrNoSuchObject
+		}
+
+		return -1, nil, err
+	}
+
+	loadBalancer.Backends = []api.NetworkLoadBalancerBackend{}
+	if backendsJSON != "" {
+		err = json.Unmarshal([]byte(backendsJSON), &loadBalancer.Backends)
+		if err != nil {
+			return -1, nil, fmt.Errorf("Failed unmarshalling backends: %v", err)
+		}
+	}
+
+	loadBalancer.Ports = []api.NetworkLoadBalancerPort{}
+	if portsJSON != "" {
+		err = json.Unmarshal([]byte(portsJSON), &loadBalancer.Ports)
+		if err != nil {
+			return -1, nil, fmt.Errorf("Failed unmarshalling ports: %v", err)
+		}
+	}
+
+	err = c.Transaction(func(tx *ClusterTx) error {
+		loadBalancer.Config, err = query.SelectConfig(tx.tx, "networks_load_balancers_config", "network_load_balancer_id=?", id)
+		return err
+	})
+	if err != nil {
+		return -1, nil, fmt.Errorf("Failed loading config: %v", err)
+	}
+
+	return id, &loadBalancer, nil
+}
+
+// CreateNetworkLoadBalancer creates a new load balancer for the network with the given ID.
+func (c *Cluster) CreateNetworkLoadBalancer(networkID int64, info *api.NetworkLoadBalancersPost) (int64, error) {
+	var id int64
+
+	backendsJSON, err := json.Marshal(info.Backends)
+	if err != nil {
+		return -1, fmt.Errorf("Failed marshalling backends: %v", err)
+	}
+
+	portsJSON, err := json.Marshal(info.Ports)
+	if err != nil {
+		return -1, fmt.Errorf("Failed marshalling ports: %v", err)
+	}
+
+	err = c.Transaction(func(tx *ClusterTx) error {
+		result, err := tx.tx.Exec("INSERT INTO networks_load_balancers (network_id, listen_address, description, backends, ports) VALUES (?, ?, ?, ?, ?)", networkID, info.ListenAddress, info.Description, string(backendsJSON), string(portsJSON))
+		if err != nil {
+			return err
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		err = networkLoadBalancerConfigAdd(tx.tx, id, info.Config)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		id = -1
+	}
+
+	return id, err
+}
+
+// networkLoadBalancerConfigAdd inserts network load balancer config keys.
+func networkLoadBalancerConfigAdd(tx *sql.Tx, id int64, config map[string]string) error {
+	q := "INSERT INTO networks_load_balancers_config (network_load_balancer_id, key, value) VALUES(?, ?, ?)"
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for k, v := range config {
+		if v == "" {
+			continue
+		}
+
+		_, err = stmt.Exec(id, k, v)
+		if err != nil {
+			return fmt.Errorf("Failed inserting config: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// UpdateNetworkLoadBalancer updates the network load balancer with the given ID.
+func (c *Cluster) UpdateNetworkLoadBalancer(id int64, info *api.NetworkLoadBalancerPut) error {
+	backendsJSON, err := json.Marshal(info.Backends)
+	if err != nil {
+		return fmt.Errorf("Failed marshalling backends: %v", err)
+	}
+
+	portsJSON, err := json.Marshal(info.Ports)
+	if err != nil {
+		return fmt.Errorf("Failed marshalling ports: %v", err)
+	}
+
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("UPDATE networks_load_balancers SET description=?, backends=?, ports=? WHERE id=?", info.Description, string(backendsJSON), string(portsJSON), id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.tx.Exec("DELETE FROM networks_load_balancers_config WHERE network_load_balancer_id=?", id)
+		if err != nil {
+			return err
+		}
+
+		err = networkLoadBalancerConfigAdd(tx.tx, id, info.Config)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// DeleteNetworkLoadBalancer deletes the network load balancer with the given ID.
+func (c *Cluster) DeleteNetworkLoadBalancer(id int64) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("DELETE FROM networks_load_balancers WHERE id=?", id)
+		return err
+	})
+}
@@ -0,0 +1,255 @@
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetNetworkPeers returns the peerings of the network with the given ID.
+func (c *Cluster) GetNetworkPeers(networkID int64) ([]api.NetworkPeer, error) {
+	var names []string
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		var err error
+		names, err = query.SelectStrings(tx.tx, "SELECT name FROM networks_peers WHERE network_id=? ORDER BY id", networkID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]api.NetworkPeer, 0, len(names))
+	for _, name := range names {
+		_, peer, err := c.GetNetworkPeer(networkID, name)
+		if err != nil {
+			return nil, err
+		}
+
+		peers = append(peers, *peer)
+	}
+
+	return peers, nil
+}
+
+// GetNetworkPeer returns the peering of the network with the given name.
+func (c *Cluster) GetNetworkPeer(networkID int64, name string) (int64, *api.NetworkPeer, error) {
+	id := int64(-1)
+	var targetNetworkID sql.NullInt64
+
+	peer := api.NetworkPeer{
+		Name: name,
+	}
+
+	q := "SELECT id, description, target_network_project, target_network_name, target_network_id FROM networks_peers WHERE network_id=? AND name=? LIMIT 1"
+	arg1 := []interface{}{networkID, name}
+	arg2 := []interface{}{&id, &peer.Description, &peer.TargetProject, &peer.TargetNetwork, &targetNetworkID}
+
+	err := dbQueryRowScan(c, q, arg1, arg2)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return -1, nil, ErrNoSuchObject
+		}
+
+		return -1, nil, err
+	}
+
+	// The peering is only created once the target network has a mutual peering back to this network.
+	peer.Status = api.NetworkStatusPending
+	if targetNetworkID.Valid {
+		peer.Status = api.NetworkStatusCreated
+	}
+
+	err = c.Transaction(func(tx *ClusterTx) error {
+		peer.Config, err = query.SelectConfig(tx.tx, "networks_peers_config", "network_peer_id=?", id)
+		return err
+	})
+	if err != nil {
+		return -1, nil, fmt.Errorf("Failed loading config: %v", err)
+	}
+
+	return id, &peer, nil
+}
+
+// CreateNetworkPeer creates a new peering for the network with the given ID.
+//
+// If the target network already has a peering back to this network, both peerings are linked to each other's
+// network and the target network's ID is returned, otherwise the returned target network ID is -1.
+func (c *Cluster) CreateNetworkPeer(networkID int64, info *api.NetworkPeersPost) (int64, int64, error) {
+	id := int64(-1)
+	targetNetworkID := int64(-1)
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		result, err := tx.tx.Exec("INSERT INTO networks_peers (network_id, name, description, target_network_project, target_network_name) VALUES (?, ?, ?, ?, ?)", networkID, info.Name, info.Description, info.TargetProject, info.TargetNetwork)
+		if err != nil {
+			return err
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		err = networkPeerConfigAdd(tx.tx, id, info.Config)
+		if err != nil {
+			return err
+		}
+
+		// Look for a mutual peering on the target network pointing back to this network.
+		q := `
+SELECT networks_peers.id, networks_peers.network_id FROM networks_peers
+  JOIN networks ON networks.id = networks_peers.network_id
+  JOIN projects ON projects.id = networks.project_id
+  JOIN networks AS local_networks ON local_networks.id = ?
+  JOIN projects AS local_projects ON local_projects.id = local_networks.project_id
+WHERE projects.name = ? AND networks.name = ?
+  AND networks_peers.target_network_project = local_projects.name
+  AND networks_peers.target_network_name = local_networks.name
+LIMIT 1
+`
+		var targetPeerID int64
+		err = tx.tx.QueryRow(q, networkID, info.TargetProject, info.TargetNetwork).Scan(&targetPeerID, &targetNetworkID)
+		if err == sql.ErrNoRows {
+			targetNetworkID = -1
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		// Link both peerings to each other's network.
+		_, err = tx.tx.Exec("UPDATE networks_peers SET target_network_id=? WHERE id=?", targetNetworkID, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.tx.Exec("UPDATE networks_peers SET target_network_id=? WHERE id=?", networkID, targetPeerID)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return -1, -1, err
+	}
+
+	return id, targetNetworkID, nil
+}
+
+// networkPeerConfigAdd inserts network peering config keys.
+func networkPeerConfigAdd(tx *sql.Tx, id int64, config map[string]string) error {
+	q := "INSERT INTO networks_peers_config (network_peer_id, key, value) VALUES(?, ?, ?)"
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for k, v := range config {
+		if v == "" {
+			continue
+		}
+
+		_, err = stmt.Exec(id, k, v)
+		if err != nil {
+			return fmt.Errorf("Failed inserting config: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// GetNetworkPeerTargetNetworkID returns the ID of the network the peering with the given ID is linked to, or -1
+// if the peering is still pending.
+func (c *Cluster) GetNetworkPeerTargetNetworkID(id int64) (int64, error) {
+	var targetNetworkID sql.NullInt64
+
+	err := dbQueryRowScan(c, "SELECT target_network_id FROM networks_peers WHERE id=?", []interface{}{id}, []interface{}{&targetNetworkID})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return -1, ErrNoSuchObject
+		}
+
+		return -1, err
+	}
+
+	if !targetNetworkID.Valid {
+		return -1, nil
+	}
+
+	return targetNetworkID.Int64, nil
+}
+
+// GetNetworkPeersTargetingNetwork returns the IDs of the networks which have a created peering linked to the
+// network with the given ID.
+func (c *Cluster) GetNetworkPeersTargetingNetwork(networkID int64) ([]int64, error) {
+	var ids []int
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		var err error
+		ids, err = query.SelectIntegers(tx.tx, "SELECT network_id FROM networks_peers WHERE target_network_id=?", networkID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	networkIDs := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		networkIDs = append(networkIDs, int64(id))
+	}
+
+	return networkIDs, nil
+}
+
+// UpdateNetworkPeer updates the network peering with the given ID.
+func (c *Cluster) UpdateNetworkPeer(id int64, info *api.NetworkPeerPut) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("UPDATE networks_peers SET description=? WHERE id=?", info.Description, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.tx.Exec("DELETE FROM networks_peers_config WHERE network_peer_id=?", id)
+		if err != nil {
+			return err
+		}
+
+		err = networkPeerConfigAdd(tx.tx, id, info.Config)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// DeleteNetworkPeer deletes the network peering with the given ID from the network with the given ID.
+//
+// Any mutual peering on the target network is unlinked and goes back to pending.
+func (c *Cluster) DeleteNetworkPeer(networkID int64, id int64) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec(`
+UPDATE networks_peers SET target_network_id=NULL
+  WHERE target_network_id=? AND network_id=(SELECT target_network_id FROM networks_peers WHERE id=?)
+`, networkID, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.tx.Exec("DELETE FROM networks_peers WHERE id=?", id)
+		return err
+	})
+}
+
+// UnlinkNetworkPeers unlinks all peerings linked to the network with the given ID, so they go back to pending.
+func (c *Cluster) UnlinkNetworkPeers(networkID int64) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("UPDATE networks_peers SET target_network_id=NULL WHERE target_network_id=?", networkID)
+		return err
+	})
+}
@@ -0,0 +1,141 @@
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// GetNetworkZones returns the names of existing Network zones.
+func (c *Cluster) GetNetworkZones() ([]string, error) {
+	var names []string
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		var err error
+		names, err = query.SelectStrings(tx.tx, "SELECT name FROM networks_zones ORDER BY id")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// GetNetworkZone returns the Network zone with the given name.
+func (c *Cluster) GetNetworkZone(name string) (int64, *api.NetworkZone, error) {
+	id := int64(-1)
+
+	zone := api.NetworkZone{
+		Name: name,
+	}
+
+	q := "SELECT id, description FROM networks_zones WHERE name=? LIMIT 1"
+	arg1 := []interface{}{name}
+	arg2 := []interface{}{&id, &zone.Description}
+
+	err := dbQueryRowScan(c, q, arg1, arg2)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return -1, nil, ErrNoSuchObject
+		}
+
+		return -1, nil, err
+	}
+
+	err = c.Transaction(func(tx *ClusterTx) error {
+		zone.Config, err = query.SelectConfig(tx.tx, "networks_zones_config", "network_zone_id=?", id)
+		return err
+	})
+	if err != nil {
+		return -1, nil, fmt.Errorf("Failed loading config: %v", err)
+	}
+
+	return id, &zone, nil
+}
+
+// CreateNetworkZone creates a new Network zone.
+func (c *Cluster) CreateNetworkZone(info *api.NetworkZonesPost) (int64, error) {
+	var id int64
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		result, err := tx.tx.Exec("INSERT INTO networks_zones (name, description) VALUES (?, ?)", info.Name, info.Description)
+		if err != nil {
+			return err
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		err = networkZoneConfigAdd(tx.tx, id, info.Config)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		id = -1
+	}
+
+	return id, err
+}
+
+// networkZoneConfigAdd inserts Network zone config keys.
+func networkZoneConfigAdd(tx *sql.Tx, id int64, config map[string]string) error {
+	q := "INSERT INTO networks_zones_config (network_zone_id, key, value) VALUES(?, ?, ?)"
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for k, v := range config {
+		if v == "" {
+			continue
+		}
+
+		_, err = stmt.Exec(id, k, v)
+		if err != nil {
+			return fmt.Errorf("Failed inserting config: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// UpdateNetworkZone updates the Network zone with the given ID.
+func (c *Cluster) UpdateNetworkZone(id int64, config *api.NetworkZonePut) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("UPDATE networks_zones SET description=? WHERE id=?", config.Description, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.tx.Exec("DELETE FROM networks_zones_config WHERE network_zone_id=?", id)
+		if err != nil {
+			return err
+		}
+
+		err = networkZoneConfigAdd(tx.tx, id, config.Config)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// DeleteNetworkZone deletes the Network zone.
+func (c *Cluster) DeleteNetworkZone(id int64) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("DELETE FROM networks_zones WHERE id=?", id)
+		return err
+	})
+}
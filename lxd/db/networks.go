@@ -15,17 +15,19 @@ import (
 // GetNetworksLocalConfig returns a map associating each network name to its
 // node-specific config values on the local node (i.e. the ones where node_id
 // equals the ID of the local node).
+//
+// Only networks in the default project have node-specific config.
 func (c *ClusterTx) GetNetworksLocalConfig() (map[string]map[string]string, error) {
-	names, err := query.SelectStrings(c.tx, "SELECT name FROM networks")
+	names, err := query.SelectStrings(c.tx, "SELECT networks.name FROM networks JOIN projects ON projects.id=networks.project_id WHERE projects.name=?", "default")
 	if err != nil {
 		return nil, err
 	}
 	networks := make(map[string]map[string]string, len(names))
 	for _, name := range names {
-		table := "networks_config JOIN networks ON networks.id=networks_config.network_id"
+		table := "networks_config JOIN networks ON networks.id=networks_config.network_id JOIN projects ON projects.id=networks.project_id"
 		config, err := query.SelectConfig(
-			c.tx, table, "networks.name=? AND networks_config.node_id=?",
-			name, c.nodeID)
+			c.tx, table, "projects.name=? AND networks.name=? AND networks_config.node_id=?",
+			"default", name, c.nodeID)
 		if err != nil {
 			return nil, err
 		}
@@ -34,23 +36,25 @@ func (c *ClusterTx) GetNetworksLocalConfig() (map[string]map[string]string, erro
 	return networks, nil
 }
 
-// GetNonPendingNetworkIDs returns a map associating each network name to its ID.
+// GetNonPendingNetworkIDs returns a map associating each network name to its ID, grouped by project name.
 //
 // Pending networks are skipped.
-func (c *ClusterTx) GetNonPendingNetworkIDs() (map[string]int64, error) {
+func (c *ClusterTx) GetNonPendingNetworkIDs() (map[string]map[string]int64, error) {
 	networks := []struct {
-		id   int64
-		name string
+		id          int64
+		name        string
+		projectName string
 	}{}
 	dest := func(i int) []interface{} {
 		networks = append(networks, struct {
-			id   int64
-			name string
+			id          int64
+			name        string
+			projectName string
 		}{})
-		return []interface{}{&networks[i].id, &networks[i].name}
+		return []interface{}{&networks[i].id, &networks[i].name, &networks[i].projectName}
 
 	}
-	stmt, err := c.tx.Prepare("SELECT id, name FROM networks WHERE NOT state=?")
+	stmt, err := c.tx.Prepare("SELECT networks.id, networks.name, projects.name FROM networks JOIN projects ON projects.id=networks.project_id WHERE NOT networks.state=?")
 	if err != nil {
 		return nil, err
 	}
@@ -59,9 +63,13 @@ func (c *ClusterTx) GetNonPendingNetworkIDs() (map[string]int64, error) {
 	if err != nil {
 		return nil, err
 	}
-	ids := map[string]int64{}
+	ids := map[string]map[string]int64{}
 	for _, network := range networks {
-		ids[network.name] = network.id
+		if ids[network.projectName] == nil {
+			ids[network.projectName] = map[string]int64{}
+		}
+
+		ids[network.projectName][network.name] = network.id
 	}
 	return ids, nil
 }
@@ -127,10 +135,10 @@ func (c *ClusterTx) GetNonPendingNetworks() (map[int64]api.Network, error) {
 	return networks, nil
 }
 
-// GetNetworkID returns the ID of the network with the given name.
-func (c *ClusterTx) GetNetworkID(name string) (int64, error) {
-	stmt := "SELECT id FROM networks WHERE name=?"
-	ids, err := query.SelectIntegers(c.tx, stmt, name)
+// GetNetworkID returns the ID of the network with the given name in the given project.
+func (c *ClusterTx) GetNetworkID(project string, name string) (int64, error) {
+	stmt := "SELECT networks.id FROM networks JOIN projects ON projects.id=networks.project_id WHERE projects.name=? AND networks.name=?"
+	ids, err := query.SelectIntegers(c.tx, stmt, project, name)
 	if err != nil {
 		return -1, err
 	}
@@ -144,6 +152,12 @@ func (c *ClusterTx) GetNetworkID(name string) (int64, error) {
 	}
 }
 
+// GetNetworksInProject returns the names of the networks in the given project.
+func (c *ClusterTx) GetNetworksInProject(project string) ([]string, error) {
+	stmt := "SELECT networks.name FROM networks JOIN projects ON projects.id=networks.project_id WHERE projects.name=?"
+	return query.SelectStrings(c.tx, stmt, project)
+}
+
 // CreateNetworkConfig adds a new entry in the networks_config table
 func (c *ClusterTx) CreateNetworkConfig(networkID, nodeID int64, config map[string]string) error {
 	return networkConfigAdd(c.tx, networkID, nodeID, config)
@@ -198,7 +212,7 @@ WHERE networks.id = ? AND networks.state = ?
 
 	configs := map[string]map[string]string{}
 	for _, node := range nodes {
-		config, err := query.SelectConfig(c.tx, "networks_config", "node_id=?", node.ID)
+		config, err := query.SelectConfig(c.tx, "networks_config", "network_id=? AND node_id=?", networkID, node.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -208,8 +222,8 @@ WHERE networks.id = ? AND networks.state = ?
 	return configs, nil
 }
 
-// CreatePendingNetwork creates a new pending network on the node with the given name.
-func (c *ClusterTx) CreatePendingNetwork(node, name string, netType NetworkType, conf map[string]string) error {
+// CreatePendingNetwork creates a new pending network in the given project on the node with the given name.
+func (c *ClusterTx) CreatePendingNetwork(node, project, name string, netType NetworkType, conf map[string]string) error {
 	// First check if a network with the given name exists, and, if so, that it's in the pending state.
 	network := struct {
 		id      int64
@@ -226,13 +240,13 @@ func (c *ClusterTx) CreatePendingNetwork(node, name string, netType NetworkType,
 		return []interface{}{&network.id, &network.state, &network.netType}
 	}
 
-	stmt, err := c.tx.Prepare("SELECT id, state, type FROM networks WHERE name=?")
+	stmt, err := c.tx.Prepare("SELECT networks.id, networks.state, networks.type FROM networks JOIN projects ON projects.id=networks.project_id WHERE projects.name=? AND networks.name=?")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	err = query.SelectObjects(stmt, dest, name)
+	err = query.SelectObjects(stmt, dest, project, name)
 	if err != nil {
 		return err
 	}
@@ -244,8 +258,13 @@ func (c *ClusterTx) CreatePendingNetwork(node, name string, netType NetworkType,
 	var networkID = network.id
 	if networkID == 0 {
 		// No existing network with the given name was found, let's create one.
-		columns := []string{"name", "type", "description"}
-		values := []interface{}{name, netType, ""}
+		projectID, err := c.GetProjectID(project)
+		if err != nil {
+			return err
+		}
+
+		columns := []string{"project_id", "name", "type", "description"}
+		values := []interface{}{projectID, name, netType, ""}
 		networkID, err = query.UpsertObject(c.tx, "networks", columns, values)
 		if err != nil {
 			return err
@@ -294,18 +313,18 @@ func (c *ClusterTx) CreatePendingNetwork(node, name string, netType NetworkType,
 }
 
 // NetworkCreated sets the state of the given network to "Created".
-func (c *ClusterTx) NetworkCreated(name string) error {
-	return c.networkState(name, networkCreated)
+func (c *ClusterTx) NetworkCreated(project string, name string) error {
+	return c.networkState(project, name, networkCreated)
 }
 
 // NetworkErrored sets the state of the given network to "Errored".
-func (c *ClusterTx) NetworkErrored(name string) error {
-	return c.networkState(name, networkErrored)
+func (c *ClusterTx) NetworkErrored(project string, name string) error {
+	return c.networkState(project, name, networkErrored)
 }
 
-func (c *ClusterTx) networkState(name string, state int) error {
-	stmt := "UPDATE networks SET state=? WHERE name=?"
-	result, err := c.tx.Exec(stmt, state, name)
+func (c *ClusterTx) networkState(project string, name string, state int) error {
+	stmt := "UPDATE networks SET state=? WHERE project_id = (SELECT id FROM projects WHERE name=?) AND name=?"
+	result, err := c.tx.Exec(stmt, state, project, name)
 	if err != nil {
 		return err
 	}
@@ -355,19 +374,24 @@ func (c *ClusterTx) networkNodes(networkID int64) ([]string, error) {
 	return nodes, nil
 }
 
-// GetNetworks returns the names of existing networks.
-func (c *Cluster) GetNetworks() ([]string, error) {
-	return c.networks("")
+// GetNetworks returns the names of existing networks in the given project.
+func (c *Cluster) GetNetworks(project string) ([]string, error) {
+	networks, err := c.networks("projects.name=?", project)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return networks[project], nil
 }
 
-// GetNonPendingNetworks returns the names of all networks that are not pending.
-func (c *Cluster) GetNonPendingNetworks() ([]string, error) {
-	return c.networks("NOT state=?", networkPending)
+// GetNonPendingNetworks returns the names of all networks that are not pending, grouped by project name.
+func (c *Cluster) GetNonPendingNetworks() (map[string][]string, error) {
+	return c.networks("NOT networks.state=?", networkPending)
 }
 
-// Get all networks matching the given WHERE filter (if given).
-func (c *Cluster) networks(where string, args ...interface{}) ([]string, error) {
-	q := "SELECT name FROM networks"
+// Get all networks matching the given WHERE filter (if given), grouped by project name.
+func (c *Cluster) networks(where string, args ...interface{}) (map[string][]string, error) {
+	q := "SELECT projects.name, networks.name FROM networks JOIN projects ON projects.id=networks.project_id"
 	inargs := []interface{}{}
 
 	if where != "" {
@@ -377,16 +401,18 @@ func (c *Cluster) networks(where string, args ...interface{}) ([]string, error)
 		}
 	}
 
+	var projectName string
 	var name string
-	outfmt := []interface{}{name}
+	outfmt := []interface{}{projectName, name}
 	result, err := queryScan(c, q, inargs, outfmt)
 	if err != nil {
-		return []string{}, err
+		return nil, err
 	}
 
-	response := []string{}
+	response := map[string][]string{}
 	for _, r := range result {
-		response = append(response, r[0].(string))
+		projectName := r[0].(string)
+		response[projectName] = append(response[projectName], r[1].(string))
 	}
 
 	return response, nil
@@ -404,32 +430,51 @@ type NetworkType int
 
 // Network types.
 const (
-	NetworkTypeBridge  NetworkType = iota // Network type bridge.
-	NetworkTypeMacvlan                    // Network type macvlan.
-	NetworkTypeSriov                      // Network type sriov.
-	NetworkTypeOVN                        // Network type ovn.
+	NetworkTypeBridge   NetworkType = iota // Network type bridge.
+	NetworkTypeMacvlan                     // Network type macvlan.
+	NetworkTypeSriov                       // Network type sriov.
+	NetworkTypeOVN                         // Network type ovn.
+	NetworkTypePhysical                    // Network type physical.
 )
 
-// GetNetworkInAnyState returns the network with the given name.
+// GetNetworkNameAndProjectWithID returns the name and project of the network with the given ID.
+func (c *Cluster) GetNetworkNameAndProjectWithID(networkID int64) (string, string, error) {
+	var networkName string
+	var projectName string
+
+	q := "SELECT networks.name, projects.name FROM networks JOIN projects ON projects.id=networks.project_id WHERE networks.id=?"
+	err := dbQueryRowScan(c, q, []interface{}{networkID}, []interface{}{&networkName, &projectName})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", "", ErrNoSuchObject
+		}
+
+		return "", "", err
+	}
+
+	return networkName, projectName, nil
+}
+
+// GetNetworkInAnyState returns the network with the given name in the given project.
 //
 // The network can be in any state.
-func (c *Cluster) GetNetworkInAnyState(name string) (int64, *api.Network, error) {
-	return c.getNetwork(name, false)
+func (c *Cluster) GetNetworkInAnyState(project string, name string) (int64, *api.Network, error) {
+	return c.getNetwork(project, name, false)
 }
 
-// Get the network with the given name. If onlyCreated is true, only return
+// Get the network with the given name in the given project. If onlyCreated is true, only return
 // networks in the created state.
-func (c *Cluster) getNetwork(name string, onlyCreated bool) (int64, *api.Network, error) {
+func (c *Cluster) getNetwork(project string, name string, onlyCreated bool) (int64, *api.Network, error) {
 	description := sql.NullString{}
 	id := int64(-1)
 	state := 0
 	var netType NetworkType
 
-	q := "SELECT id, description, state, type FROM networks WHERE name=?"
-	arg1 := []interface{}{name}
+	q := "SELECT networks.id, networks.description, networks.state, networks.type FROM networks JOIN projects ON projects.id=networks.project_id WHERE projects.name=? AND networks.name=?"
+	arg1 := []interface{}{project, name}
 	arg2 := []interface{}{&id, &description, &state, &netType}
 	if onlyCreated {
-		q += " AND state=?"
+		q += " AND networks.state=?"
 		arg1 = append(arg1, networkCreated)
 	}
 	err := dbQueryRowScan(c, q, arg1, arg2)
@@ -489,6 +534,8 @@ func networkFillType(network *api.Network, netType NetworkType) {
 		network.Type = "sriov"
 	case NetworkTypeOVN:
 		network.Type = "ovn"
+	case NetworkTypePhysical:
+		network.Type = "physical"
 	default:
 		network.Type = "" // Unknown
 	}
@@ -604,11 +651,16 @@ func (c *Cluster) getNetworkConfig(id int64) (map[string]string, error) {
 	return config, nil
 }
 
-// CreateNetwork creates a new network.
-func (c *Cluster) CreateNetwork(name, description string, netType NetworkType, config map[string]string) (int64, error) {
+// CreateNetwork creates a new network in the given project.
+func (c *Cluster) CreateNetwork(project, name, description string, netType NetworkType, config map[string]string) (int64, error) {
 	var id int64
 	err := c.Transaction(func(tx *ClusterTx) error {
-		result, err := tx.tx.Exec("INSERT INTO networks (name, description, state, type) VALUES (?, ?, ?, ?)", name, description, networkCreated, netType)
+		projectID, err := tx.GetProjectID(project)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.tx.Exec("INSERT INTO networks (project_id, name, description, state, type) VALUES (?, ?, ?, ?, ?)", projectID, name, description, networkCreated, netType)
 		if err != nil {
 			return err
 		}
@@ -639,9 +691,9 @@ func (c *Cluster) CreateNetwork(name, description string, netType NetworkType, c
 	return id, err
 }
 
-// UpdateNetwork updates the network with the given name.
-func (c *Cluster) UpdateNetwork(name, description string, config map[string]string) error {
-	id, netInfo, err := c.GetNetworkInAnyState(name)
+// UpdateNetwork updates the network with the given name in the given project.
+func (c *Cluster) UpdateNetwork(project, name, description string, config map[string]string) error {
+	id, netInfo, err := c.GetNetworkInAnyState(project, name)
 	if err != nil {
 		return err
 	}
@@ -654,7 +706,7 @@ func (c *Cluster) UpdateNetwork(name, description string, config map[string]stri
 
 		// Update network status if change applied successfully.
 		if netInfo.Status == api.NetworkStatusErrored {
-			err = tx.NetworkCreated(name)
+			err = tx.NetworkCreated(project, name)
 			if err != nil {
 				return err
 			}
@@ -666,6 +718,36 @@ func (c *Cluster) UpdateNetwork(name, description string, config map[string]stri
 	return err
 }
 
+// UpdateNetworkLocalConfig sets the internal node-local config keys of the network with the given ID for this
+// node, removing the keys with an empty value. The rest of the network config is left untouched.
+func (c *Cluster) UpdateNetworkLocalConfig(id int64, config map[string]string) error {
+	for key := range config {
+		if !shared.StringInSlice(key, nodeLocalNetworkConfig) {
+			return fmt.Errorf("Config key %q isn't a node-local network config key", key)
+		}
+	}
+
+	return c.Transaction(func(tx *ClusterTx) error {
+		for key, value := range config {
+			_, err := tx.tx.Exec("DELETE FROM networks_config WHERE network_id=? AND node_id=? AND key=?", id, c.nodeID, key)
+			if err != nil {
+				return err
+			}
+
+			if value == "" {
+				continue
+			}
+
+			_, err = tx.tx.Exec("INSERT INTO networks_config (network_id, node_id, key, value) VALUES(?, ?, ?, ?)", id, c.nodeID, key, value)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
 // Update the description of the network with the given ID.
 func updateNetworkDescription(tx *sql.Tx, id int64, description string) error {
 	_, err := tx.Exec("UPDATE networks SET description=? WHERE id=?", description, id)
@@ -673,19 +755,27 @@ func updateNetworkDescription(tx *sql.Tx, id int64, description string) error {
 }
 
 func networkConfigAdd(tx *sql.Tx, networkID, nodeID int64, config map[string]string) error {
+	// Networks outside of the default project are defined cluster-wide and have no node-specific config.
+	projects, err := query.SelectStrings(tx, "SELECT projects.name FROM networks JOIN projects ON projects.id=networks.project_id WHERE networks.id=?", networkID)
+	if err != nil {
+		return err
+	}
+
+	nodeSpecific := len(projects) == 1 && projects[0] == "default"
+
 	str := fmt.Sprintf("INSERT INTO networks_config (network_id, node_id, key, value) VALUES(?, ?, ?, ?)")
 	stmt, err := tx.Prepare(str)
-	defer stmt.Close()
 	if err != nil {
 		return err
 	}
+	defer stmt.Close()
 
 	for k, v := range config {
 		if v == "" {
 			continue
 		}
 		var nodeIDValue interface{}
-		if !shared.StringInSlice(k, NodeSpecificNetworkConfig) {
+		if !nodeSpecific || (!shared.StringInSlice(k, NodeSpecificNetworkConfig) && !shared.StringInSlice(k, nodeLocalNetworkConfig)) {
 			nodeIDValue = nil
 		} else {
 			nodeIDValue = nodeID
@@ -713,9 +803,9 @@ func clearNetworkConfig(tx *sql.Tx, networkID, nodeID int64) error {
 	return nil
 }
 
-// DeleteNetwork deletes the network with the given name.
-func (c *Cluster) DeleteNetwork(name string) error {
-	id, _, err := c.GetNetworkInAnyState(name)
+// DeleteNetwork deletes the network with the given name in the given project.
+func (c *Cluster) DeleteNetwork(project string, name string) error {
+	id, _, err := c.GetNetworkInAnyState(project, name)
 	if err != nil {
 		return err
 	}
@@ -728,9 +818,9 @@ func (c *Cluster) DeleteNetwork(name string) error {
 	return nil
 }
 
-// RenameNetwork renames a network.
-func (c *Cluster) RenameNetwork(oldName string, newName string) error {
-	id, _, err := c.GetNetworkInAnyState(oldName)
+// RenameNetwork renames a network in the given project.
+func (c *Cluster) RenameNetwork(project string, oldName string, newName string) error {
+	id, _, err := c.GetNetworkInAnyState(project, oldName)
 	if err != nil {
 		return err
 	}
@@ -744,7 +834,14 @@ func (c *Cluster) RenameNetwork(oldName string, newName string) error {
 }
 
 // NodeSpecificNetworkConfig lists all network config keys which are node-specific.
+// Networks outside of the default project don't have node-specific config.
 var NodeSpecificNetworkConfig = []string{
 	"bridge.external_interfaces",
 	"parent",
 }
+
+// nodeLocalNetworkConfig lists the internal network config keys which each node records for itself.
+// Unlike NodeSpecificNetworkConfig, these can't be set per node through the API.
+var nodeLocalNetworkConfig = []string{
+	"volatile.last_state.created",
+}
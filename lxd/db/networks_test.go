@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/shared/api"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -15,7 +16,7 @@ func TestGetNetworksLocalConfigs(t *testing.T) {
 	cluster, cleanup := db.NewTestCluster(t)
 	defer cleanup()
 
-	_, err := cluster.CreateNetwork("lxdbr0", "", db.NetworkTypeBridge, map[string]string{
+	_, err := cluster.CreateNetwork("default", "lxdbr0", "", db.NetworkTypeBridge, map[string]string{
 		"dns.mode":                   "none",
 		"bridge.external_interfaces": "vlan0",
 	})
@@ -35,6 +36,46 @@ func TestGetNetworksLocalConfigs(t *testing.T) {
 	})
 }
 
+// Networks with the same name can exist in different projects.
+func TestCreateNetwork_DifferentProjects(t *testing.T) {
+	cluster, cleanup := db.NewTestCluster(t)
+	defer cleanup()
+
+	err := cluster.Transaction(func(tx *db.ClusterTx) error {
+		_, err := tx.CreateProject(api.ProjectsPost{Name: "test"})
+		return err
+	})
+	require.NoError(t, err)
+
+	id1, err := cluster.CreateNetwork("default", "internal", "", db.NetworkTypeOVN, map[string]string{"parent": "lxdbr0"})
+	require.NoError(t, err)
+
+	id2, err := cluster.CreateNetwork("test", "internal", "", db.NetworkTypeOVN, map[string]string{"parent": "lxdbr0"})
+	require.NoError(t, err)
+	assert.NotEqual(t, id1, id2)
+
+	_, err = cluster.CreateNetwork("test", "internal", "", db.NetworkTypeOVN, nil)
+	require.Error(t, err)
+
+	networks, err := cluster.GetNetworks("test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"internal"}, networks)
+
+	id, network, err := cluster.GetNetworkInAnyState("test", "internal")
+	require.NoError(t, err)
+	assert.Equal(t, id2, id)
+	assert.Equal(t, "lxdbr0", network.Config["parent"])
+
+	// Only networks in the default project have node-specific config.
+	err = cluster.Transaction(func(tx *db.ClusterTx) error {
+		config, err := tx.GetNetworksLocalConfig()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]map[string]string{"internal": {"parent": "lxdbr0"}}, config)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
 func TestCreatePendingNetwork(t *testing.T) {
 	tx, cleanup := db.NewTestClusterTx(t)
 	defer cleanup()
@@ -45,15 +86,15 @@ func TestCreatePendingNetwork(t *testing.T) {
 	require.NoError(t, err)
 
 	config := map[string]string{"bridge.external_interfaces": "foo"}
-	err = tx.CreatePendingNetwork("buzz", "network1", db.NetworkTypeBridge, config)
+	err = tx.CreatePendingNetwork("buzz", "default", "network1", db.NetworkTypeBridge, config)
 	require.NoError(t, err)
 
-	networkID, err := tx.GetNetworkID("network1")
+	networkID, err := tx.GetNetworkID("default", "network1")
 	require.NoError(t, err)
 	assert.True(t, networkID > 0)
 
 	config = map[string]string{"bridge.external_interfaces": "bar"}
-	err = tx.CreatePendingNetwork("rusp", "network1", db.NetworkTypeBridge, config)
+	err = tx.CreatePendingNetwork("rusp", "default", "network1", db.NetworkTypeBridge, config)
 	require.NoError(t, err)
 
 	// The initial node (whose name is 'none' by default) is missing.
@@ -61,7 +102,7 @@ func TestCreatePendingNetwork(t *testing.T) {
 	require.EqualError(t, err, "Network not defined on nodes: none")
 
 	config = map[string]string{"bridge.external_interfaces": "egg"}
-	err = tx.CreatePendingNetwork("none", "network1", db.NetworkTypeBridge, config)
+	err = tx.CreatePendingNetwork("none", "default", "network1", db.NetworkTypeBridge, config)
 	require.NoError(t, err)
 
 	// Now the storage is defined on all nodes.
@@ -82,10 +123,10 @@ func TestNetworksCreatePending_AlreadyDefined(t *testing.T) {
 	_, err := tx.CreateNode("buzz", "1.2.3.4:666")
 	require.NoError(t, err)
 
-	err = tx.CreatePendingNetwork("buzz", "network1", db.NetworkTypeBridge, map[string]string{})
+	err = tx.CreatePendingNetwork("buzz", "default", "network1", db.NetworkTypeBridge, map[string]string{})
 	require.NoError(t, err)
 
-	err = tx.CreatePendingNetwork("buzz", "network1", db.NetworkTypeBridge, map[string]string{})
+	err = tx.CreatePendingNetwork("buzz", "default", "network1", db.NetworkTypeBridge, map[string]string{})
 	require.Equal(t, db.ErrAlreadyDefined, err)
 }
 
@@ -94,6 +135,6 @@ func TestNetworksCreatePending_NonExistingNode(t *testing.T) {
 	tx, cleanup := db.NewTestClusterTx(t)
 	defer cleanup()
 
-	err := tx.CreatePendingNetwork("buzz", "network1", db.NetworkTypeBridge, map[string]string{})
+	err := tx.CreatePendingNetwork("buzz", "default", "network1", db.NetworkTypeBridge, map[string]string{})
 	require.Equal(t, db.ErrNoSuchObject, err)
 }
@@ -56,6 +56,11 @@ const (
 	OperationBackupsExpire
 	OperationSnapshotsExpire
 	OperationCustomVolumeSnapshotsExpire
+	OperationCustomVolumeBackupCreate
+	OperationCustomVolumeBackupRemove
+	OperationCustomVolumeBackupRename
+	OperationCustomVolumeBackupRestore
+	OperationCustomVolumeBackupsExpire
 )
 
 // Description return a human-readable description of the operation type.
@@ -153,6 +158,16 @@ func (t OperationType) Description() string {
 		return "Cleaning up expired instance snapshots"
 	case OperationCustomVolumeSnapshotsExpire:
 		return "Cleaning up expired volume snapshots"
+	case OperationCustomVolumeBackupCreate:
+		return "Creating custom volume backup"
+	case OperationCustomVolumeBackupRemove:
+		return "Deleting custom volume backup"
+	case OperationCustomVolumeBackupRename:
+		return "Renaming custom volume backup"
+	case OperationCustomVolumeBackupRestore:
+		return "Restoring custom volume backup"
+	case OperationCustomVolumeBackupsExpire:
+		return "Cleaning up expired volume backups"
 	default:
 		return "Executing operation"
 	}
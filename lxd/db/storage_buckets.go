@@ -0,0 +1,354 @@
+// +build linux,cgo,!agent
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lxc/lxd/lxd/db/query"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// StorageBucket represents a database storage bucket record.
+type StorageBucket struct {
+	api.StorageBucket
+
+	ID          int64
+	PoolName    string
+	ProjectName string
+}
+
+// GetStorageBuckets returns the names of the storage buckets of the given pool and project.
+func (c *Cluster) GetStorageBuckets(poolID int64, projectName string) ([]string, error) {
+	var names []string
+
+	q := `
+SELECT storage_buckets.name FROM storage_buckets
+  JOIN projects ON projects.id = storage_buckets.project_id
+ WHERE storage_buckets.storage_pool_id=? AND projects.name=?
+ ORDER BY storage_buckets.id
+`
+	err := c.Transaction(func(tx *ClusterTx) error {
+		var err error
+		names, err = query.SelectStrings(tx.tx, q, poolID, projectName)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// GetLocalStorageBuckets returns the storage buckets held by this cluster member.
+func (c *Cluster) GetLocalStorageBuckets() ([]StorageBucket, error) {
+	buckets := []StorageBucket{}
+
+	q := `
+SELECT storage_buckets.id, storage_buckets.name, storage_pools.name, projects.name
+  FROM storage_buckets
+  JOIN storage_pools ON storage_pools.id = storage_buckets.storage_pool_id
+  JOIN projects ON projects.id = storage_buckets.project_id
+ WHERE storage_buckets.node_id=?
+ ORDER BY storage_buckets.id
+`
+	err := c.Transaction(func(tx *ClusterTx) error {
+		rows, err := tx.tx.Query(q, c.nodeID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			bucket := StorageBucket{}
+			err := rows.Scan(&bucket.ID, &bucket.Name, &bucket.PoolName, &bucket.ProjectName)
+			if err != nil {
+				return err
+			}
+
+			buckets = append(buckets, bucket)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return buckets, nil
+}
+
+// StorageBucketExists returns whether a storage bucket with the given name exists in any pool and project.
+func (c *Cluster) StorageBucketExists(name string) (bool, error) {
+	var count int
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		var err error
+		count, err = query.Count(tx.tx, "storage_buckets", "name=?", name)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// GetStorageBucket returns the storage bucket with the given name in the given pool and project.
+func (c *Cluster) GetStorageBucket(poolID int64, projectName string, name string) (*StorageBucket, error) {
+	return c.getStorageBucket("storage_buckets.storage_pool_id=? AND projects.name=? AND storage_buckets.name=?", poolID, projectName, name)
+}
+
+// GetLocalStorageBucket returns the storage bucket with the given name if it is held by this cluster member.
+func (c *Cluster) GetLocalStorageBucket(name string) (*StorageBucket, error) {
+	return c.getStorageBucket("storage_buckets.node_id=? AND storage_buckets.name=?", c.nodeID, name)
+}
+
+func (c *Cluster) getStorageBucket(where string, args ...interface{}) (*StorageBucket, error) {
+	bucket := StorageBucket{}
+
+	q := fmt.Sprintf(`
+SELECT storage_buckets.id, storage_buckets.name, storage_buckets.description, storage_pools.name, projects.name, nodes.name
+  FROM storage_buckets
+  JOIN storage_pools ON storage_pools.id = storage_buckets.storage_pool_id
+  JOIN projects ON projects.id = storage_buckets.project_id
+  JOIN nodes ON nodes.id = storage_buckets.node_id
+ WHERE %s LIMIT 1
+`, where)
+	outargs := []interface{}{&bucket.ID, &bucket.Name, &bucket.Description, &bucket.PoolName, &bucket.ProjectName, &bucket.Location}
+
+	err := dbQueryRowScan(c, q, args, outargs)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNoSuchObject
+		}
+
+		return nil, err
+	}
+
+	err = c.Transaction(func(tx *ClusterTx) error {
+		bucket.Config, err = query.SelectConfig(tx.tx, "storage_buckets_config", "storage_bucket_id=?", bucket.ID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed loading config: %v", err)
+	}
+
+	return &bucket, nil
+}
+
+// GetStorageBucketNodeAddress returns the address of the cluster member holding the storage bucket with the
+// given name in the given pool and project. The address is empty if the bucket is held by this member.
+func (c *ClusterTx) GetStorageBucketNodeAddress(poolID int64, projectName string, name string) (string, error) {
+	var nodeID int64
+	var address string
+
+	q := `
+SELECT nodes.id, nodes.address FROM nodes
+  JOIN storage_buckets ON storage_buckets.node_id = nodes.id
+  JOIN projects ON projects.id = storage_buckets.project_id
+ WHERE storage_buckets.storage_pool_id=? AND projects.name=? AND storage_buckets.name=?
+`
+	err := c.tx.QueryRow(q, poolID, projectName, name).Scan(&nodeID, &address)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrNoSuchObject
+		}
+
+		return "", err
+	}
+
+	if nodeID == c.nodeID {
+		return "", nil
+	}
+
+	return address, nil
+}
+
+// CreateStorageBucket creates a new storage bucket held by this cluster member.
+func (c *Cluster) CreateStorageBucket(poolID int64, projectName string, info *api.StorageBucketsPost) (int64, error) {
+	var id int64
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		result, err := tx.tx.Exec(`
+INSERT INTO storage_buckets (storage_pool_id, node_id, name, description, project_id)
+ VALUES (?, ?, ?, ?, (SELECT id FROM projects WHERE name = ?))
+`, poolID, c.nodeID, info.Name, info.Description, projectName)
+		if err != nil {
+			return err
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		err = storageBucketConfigAdd(tx.tx, id, info.Config)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		id = -1
+	}
+
+	return id, err
+}
+
+// storageBucketConfigAdd inserts storage bucket config keys.
+func storageBucketConfigAdd(tx *sql.Tx, id int64, config map[string]string) error {
+	q := "INSERT INTO storage_buckets_config (storage_bucket_id, key, value) VALUES(?, ?, ?)"
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for k, v := range config {
+		if v == "" {
+			continue
+		}
+
+		_, err = stmt.Exec(id, k, v)
+		if err != nil {
+			return fmt.Errorf("Failed inserting config: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// UpdateStorageBucket updates the storage bucket with the given ID.
+func (c *Cluster) UpdateStorageBucket(id int64, info *api.StorageBucketPut) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("UPDATE storage_buckets SET description=? WHERE id=?", info.Description, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.tx.Exec("DELETE FROM storage_buckets_config WHERE storage_bucket_id=?", id)
+		if err != nil {
+			return err
+		}
+
+		err = storageBucketConfigAdd(tx.tx, id, info.Config)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// DeleteStorageBucket deletes the storage bucket with the given ID along with its keys.
+func (c *Cluster) DeleteStorageBucket(id int64) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("DELETE FROM storage_buckets WHERE id=?", id)
+		return err
+	})
+}
+
+// GetStorageBucketKeys returns the names of the keys of the storage bucket with the given ID.
+func (c *Cluster) GetStorageBucketKeys(bucketID int64) ([]string, error) {
+	var names []string
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		var err error
+		names, err = query.SelectStrings(tx.tx, "SELECT name FROM storage_buckets_keys WHERE storage_bucket_id=? ORDER BY id", bucketID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// GetStorageBucketKey returns the key with the given name of the storage bucket with the given ID.
+func (c *Cluster) GetStorageBucketKey(bucketID int64, name string) (int64, *api.StorageBucketKey, error) {
+	id := int64(-1)
+	key := api.StorageBucketKey{
+		Name: name,
+	}
+
+	q := "SELECT id, description, role, access_key, secret_key FROM storage_buckets_keys WHERE storage_bucket_id=? AND name=? LIMIT 1"
+	inargs := []interface{}{bucketID, name}
+	outargs := []interface{}{&id, &key.Description, &key.Role, &key.AccessKey, &key.SecretKey}
+
+	err := dbQueryRowScan(c, q, inargs, outargs)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return -1, nil, ErrNoSuchObject
+		}
+
+		return -1, nil, err
+	}
+
+	return id, &key, nil
+}
+
+// GetLocalStorageBucketKeyByAccessKey returns the key with the given access key along with the name of its
+// storage bucket, as long as the bucket is held by this cluster member.
+func (c *Cluster) GetLocalStorageBucketKeyByAccessKey(accessKey string) (string, *api.StorageBucketKey, error) {
+	var bucketName string
+	key := api.StorageBucketKey{}
+
+	q := `
+SELECT storage_buckets.name, storage_buckets_keys.name, storage_buckets_keys.description, storage_buckets_keys.role, storage_buckets_keys.access_key, storage_buckets_keys.secret_key
+  FROM storage_buckets_keys
+  JOIN storage_buckets ON storage_buckets.id = storage_buckets_keys.storage_bucket_id
+ WHERE storage_buckets.node_id=? AND storage_buckets_keys.access_key=? LIMIT 1
+`
+	inargs := []interface{}{c.nodeID, accessKey}
+	outargs := []interface{}{&bucketName, &key.Name, &key.Description, &key.Role, &key.AccessKey, &key.SecretKey}
+
+	err := dbQueryRowScan(c, q, inargs, outargs)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil, ErrNoSuchObject
+		}
+
+		return "", nil, err
+	}
+
+	return bucketName, &key, nil
+}
+
+// CreateStorageBucketKey creates a new key for the storage bucket with the given ID.
+func (c *Cluster) CreateStorageBucketKey(bucketID int64, info *api.StorageBucketKeysPost) (int64, error) {
+	var id int64
+
+	err := c.Transaction(func(tx *ClusterTx) error {
+		result, err := tx.tx.Exec("INSERT INTO storage_buckets_keys (storage_bucket_id, name, description, role, access_key, secret_key) VALUES (?, ?, ?, ?, ?, ?)", bucketID, info.Name, info.Description, info.Role, info.AccessKey, info.SecretKey)
+		if err != nil {
+			return err
+		}
+
+		id, err = result.LastInsertId()
+		return err
+	})
+	if err != nil {
+		id = -1
+	}
+
+	return id, err
+}
+
+// UpdateStorageBucketKey updates the storage bucket key with the given ID.
+func (c *Cluster) UpdateStorageBucketKey(id int64, info *api.StorageBucketKeyPut) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("UPDATE storage_buckets_keys SET description=?, role=?, access_key=?, secret_key=? WHERE id=?", info.Description, info.Role, info.AccessKey, info.SecretKey, id)
+		return err
+	})
+}
+
+// DeleteStorageBucketKey deletes the storage bucket key with the given ID.
+func (c *Cluster) DeleteStorageBucketKey(id int64) error {
+	return c.Transaction(func(tx *ClusterTx) error {
+		_, err := tx.tx.Exec("DELETE FROM storage_buckets_keys WHERE id=?", id)
+		return err
+	})
+}
@@ -133,6 +133,38 @@ func (c *ClusterTx) GetStoragePoolUsedBy(name string) ([]string, error) {
 		}
 	}
 
+	// Get all the storage buckets of the storage pool.
+	buckets := []struct {
+		bucketName  string
+		projectName string
+	}{}
+	dest = func(i int) []interface{} {
+		buckets = append(buckets, struct {
+			bucketName  string
+			projectName string
+		}{})
+
+		return []interface{}{&buckets[i].bucketName, &buckets[i].projectName}
+	}
+
+	stmt, err = c.tx.Prepare("SELECT storage_buckets.name, projects.name FROM storage_buckets JOIN projects ON projects.id=storage_buckets.project_id WHERE storage_pool_id=? ORDER BY projects.name ASC, storage_buckets.name ASC")
+	if err != nil {
+		return nil, err
+	}
+
+	err = query.SelectObjects(stmt, dest, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range buckets {
+		if r.projectName == "default" {
+			usedby = append(usedby, fmt.Sprintf("/1.0/storage-pools/%s/buckets/%s", name, r.bucketName))
+		} else {
+			usedby = append(usedby, fmt.Sprintf("/1.0/storage-pools/%s/buckets/%s?project=%s", name, r.bucketName, r.projectName))
+		}
+	}
+
 	// Get all the profiles using the storage pool.
 	profiles, err := c.GetProfiles(ProfileFilter{})
 	if err != nil {
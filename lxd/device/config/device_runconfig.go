@@ -33,6 +33,19 @@ type RootFSEntryItem struct {
 	Opts []string // Describes the mount options associated with the filesystem.
 }
 
+// USBDeviceItem represents a single USB device matched by a device.
+type USBDeviceItem struct {
+	DeviceName     string // The internal name for the USB device.
+	HostDevicePath string // Path to the USB device on the host, empty when the device is being removed.
+}
+
+// UnixDeviceItem represents a single host unix device passed through to a VM.
+type UnixDeviceItem struct {
+	DeviceName     string // The internal name for the device.
+	Type           string // Device type, either "unix-char" or "unix-block".
+	HostDevicePath string // Path to the device on the host, empty when the device is being removed.
+}
+
 // RunConfig represents LXD defined run-time config used for device setup/cleanup.
 type RunConfig struct {
 	RootFS           RootFSEntryItem  // RootFS to setup.
@@ -42,4 +55,7 @@ type RunConfig struct {
 	Uevents          [][]string       // Uevents to inject.
 	PostHooks        []func() error   // Functions to be run after device attach/detach.
 	GPUDevice        []RunConfigItem  // GPU device configuration settings.
+	USBDevice        []USBDeviceItem  // USB devices to attach/detach.
+	TPMDevice        []RunConfigItem  // TPM device configuration settings.
+	UnixDevice       []UnixDeviceItem // Unix devices to attach/detach.
 }
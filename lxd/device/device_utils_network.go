@@ -338,7 +338,7 @@ func networkCreateTap(hostName string, m deviceConfig.Device) error {
 // networkSetupHostVethRoutes configures a nic device's host side veth routes.
 // Accepts an optional oldDevice that will have its old host routes removed before adding the new device routes.
 // This allows live update of a veth device.
-func networkSetupHostVethRoutes(s *state.State, device deviceConfig.Device, oldDevice deviceConfig.Device, v map[string]string) error {
+func networkSetupHostVethRoutes(s *state.State, projectName string, device deviceConfig.Device, oldDevice deviceConfig.Device, v map[string]string) error {
 	// Check whether host device resolution succeeded.
 	if device["host_name"] == "" {
 		return fmt.Errorf("Failed to find host side veth name for device %q", device["name"])
@@ -347,11 +347,11 @@ func networkSetupHostVethRoutes(s *state.State, device deviceConfig.Device, oldD
 	// If oldDevice provided, remove old routes if any remain.
 	if oldDevice != nil {
 		networkVethFillFromVolatile(oldDevice, v)
-		networkRemoveVethRoutes(s, oldDevice)
+		networkRemoveVethRoutes(s, projectName, oldDevice)
 	}
 
 	// Setup static routes to container.
-	err := networkSetVethRoutes(s, device)
+	err := networkSetVethRoutes(s, projectName, device)
 	if err != nil {
 		return err
 	}
@@ -373,11 +373,11 @@ func networkVethFillFromVolatile(device deviceConfig.Device, volatile map[string
 }
 
 // networkSetVethRoutes applies any static routes configured from the host to the container nic.
-func networkSetVethRoutes(s *state.State, m deviceConfig.Device) error {
+func networkSetVethRoutes(s *state.State, projectName string, m deviceConfig.Device) error {
 	// Decide whether the route should point to the veth parent or the bridge parent.
 	routeDev := m["host_name"]
 
-	nicType, err := nictype.NICType(s, m)
+	nicType, err := nictype.NICType(s, projectName, m)
 	if err != nil {
 		return err
 	}
@@ -417,10 +417,10 @@ func networkSetVethRoutes(s *state.State, m deviceConfig.Device) error {
 
 // networkRemoveVethRoutes removes any routes created for this device on the host that were first added
 // with networkSetVethRoutes(). Expects to be passed the device config from the oldExpandedDevices.
-func networkRemoveVethRoutes(s *state.State, m deviceConfig.Device) {
+func networkRemoveVethRoutes(s *state.State, projectName string, m deviceConfig.Device) {
 	// Decide whether the route should point to the veth parent or the bridge parent
 	routeDev := m["host_name"]
-	nicType, err := nictype.NICType(s, m)
+	nicType, err := nictype.NICType(s, projectName, m)
 	if err != nil {
 		logger.Errorf("Failed to get NIC type for %q", m["name"])
 		return
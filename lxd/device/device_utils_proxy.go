@@ -2,11 +2,20 @@ package device
 
 import (
 	"fmt"
+	"io"
 	"net"
+	"os"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
 
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/logger"
 	"github.com/lxc/lxd/shared/validate"
 )
 
@@ -66,3 +75,275 @@ func ProxyParseAddr(addr string) (*deviceConfig.ProxyAddress, error) {
 
 	return newProxyAddr, nil
 }
+
+// proxyUDPTimeout is how long a UDP session relayed to a VM is kept without receiving a reply.
+const proxyUDPTimeout = 30 * time.Second
+
+// proxyRelay represents the host side listeners and connections of a proxy device relayed to a VM.
+type proxyRelay struct {
+	dial          func(connectAddr string) (*websocket.Conn, error)
+	proxyProtocol bool
+
+	mu        sync.Mutex
+	stopped   bool
+	listeners []io.Closer
+	conns     map[io.Closer]struct{}
+}
+
+// proxyRelays stores the active VM proxy relays.
+var proxyRelays = map[string]*proxyRelay{}
+
+// proxyRelaysMutex controls access to the proxyRelays map.
+var proxyRelaysMutex sync.Mutex
+
+// proxyRelayStart starts listening on the listen addresses and relays each connection to the matching
+// connect address using the dial function. Any existing relay for the device is replaced.
+func proxyRelayStart(inst instance.Instance, deviceName string, listenAddr *deviceConfig.ProxyAddress, connectAddr *deviceConfig.ProxyAddress, dial func(connectAddr string) (*websocket.Conn, error), proxyProtocol bool) error {
+	proxyRelaysMutex.Lock()
+	defer proxyRelaysMutex.Unlock()
+
+	// Null delimited string of project name, instance name and device name.
+	key := fmt.Sprintf("%s\000%s\000%s", inst.Project(), inst.Name(), deviceName)
+
+	// Stop any existing relay so its listen addresses can be reused.
+	oldRelay, ok := proxyRelays[key]
+	if ok {
+		oldRelay.stop()
+		delete(proxyRelays, key)
+	}
+
+	r := &proxyRelay{
+		dial:          dial,
+		proxyProtocol: proxyProtocol && connectAddr.ConnType == "tcp",
+		conns:         map[io.Closer]struct{}{},
+	}
+
+	for i, addr := range listenAddr.Addr {
+		// Single or multiple port -> single port, or multiple port -> multiple port.
+		target := connectAddr.Addr[0]
+		if listenAddr.ConnType != "unix" && connectAddr.ConnType != "unix" && len(connectAddr.Addr) > 1 {
+			target = connectAddr.Addr[i]
+		}
+
+		target = fmt.Sprintf("%s:%s", connectAddr.ConnType, target)
+
+		if listenAddr.ConnType == "udp" {
+			pc, err := net.ListenPacket("udp", addr)
+			if err != nil {
+				r.stop()
+				return errors.Wrapf(err, "Failed to listen on %s", addr)
+			}
+
+			r.listeners = append(r.listeners, pc)
+			go r.serveUDP(pc, target)
+			continue
+		}
+
+		// Remove any stale unix socket left behind.
+		if listenAddr.ConnType == "unix" && !listenAddr.Abstract {
+			err := os.Remove(addr)
+			if err != nil && !os.IsNotExist(err) {
+				r.stop()
+				return err
+			}
+		}
+
+		l, err := net.Listen(listenAddr.ConnType, addr)
+		if err != nil {
+			r.stop()
+			return errors.Wrapf(err, "Failed to listen on %s", addr)
+		}
+
+		r.listeners = append(r.listeners, l)
+		go r.serveStream(l, target)
+	}
+
+	proxyRelays[key] = r
+
+	return nil
+}
+
+// proxyRelayStop stops the relay of a device, closing its listeners and any active connections.
+func proxyRelayStop(inst instance.Instance, deviceName string) {
+	proxyRelaysMutex.Lock()
+	defer proxyRelaysMutex.Unlock()
+
+	// Null delimited string of project name, instance name and device name.
+	key := fmt.Sprintf("%s\000%s\000%s", inst.Project(), inst.Name(), deviceName)
+	r, ok := proxyRelays[key]
+	if !ok {
+		return
+	}
+
+	r.stop()
+	delete(proxyRelays, key)
+}
+
+// stop closes the relay's listeners and active connections.
+func (r *proxyRelay) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stopped = true
+
+	for _, l := range r.listeners {
+		l.Close()
+	}
+
+	for conn := range r.conns {
+		conn.Close()
+	}
+}
+
+// track records connections as active so they are closed when the relay is stopped.
+// Returns false if the relay has already been stopped.
+func (r *proxyRelay) track(conns ...io.Closer) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		return false
+	}
+
+	for _, conn := range conns {
+		r.conns[conn] = struct{}{}
+	}
+
+	return true
+}
+
+// untrack closes connections and removes them from the active list.
+func (r *proxyRelay) untrack(conns ...io.Closer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+		delete(r.conns, conn)
+	}
+}
+
+// serveStream accepts connections on a stream listener and relays them to the target.
+func (r *proxyRelay) serveStream(l net.Listener, target string) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+
+		go r.relayStream(conn, target)
+	}
+}
+
+// relayStream relays a single stream connection to the target.
+func (r *proxyRelay) relayStream(conn net.Conn, target string) {
+	ws, err := r.dial(target)
+	if err != nil {
+		logger.Warnf("Failed to connect proxy to %s: %v", target, err)
+		conn.Close()
+		return
+	}
+
+	if !r.track(conn, ws) {
+		ws.Close()
+		conn.Close()
+		return
+	}
+	defer r.untrack(conn, ws)
+
+	if r.proxyProtocol {
+		err = ws.WriteMessage(websocket.BinaryMessage, proxyProtocolHeader(conn))
+		if err != nil {
+			return
+		}
+	}
+
+	readDone, writeDone := shared.WebsocketMirror(ws, conn, conn, nil, nil)
+	<-readDone
+	<-writeDone
+}
+
+// serveUDP relays datagrams received on the listener to the target, using one session per client.
+func (r *proxyRelay) serveUDP(pc net.PacketConn, target string) {
+	sessions := map[string]*websocket.Conn{}
+	sessionsMu := sync.Mutex{}
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, client, err := pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		sessionsMu.Lock()
+		ws, ok := sessions[client.String()]
+		sessionsMu.Unlock()
+
+		if !ok {
+			ws, err = r.dial(target)
+			if err != nil {
+				logger.Warnf("Failed to connect proxy to %s: %v", target, err)
+				continue
+			}
+
+			if !r.track(ws) {
+				ws.Close()
+				return
+			}
+
+			sessionsMu.Lock()
+			sessions[client.String()] = ws
+			sessionsMu.Unlock()
+
+			// Relay the replies back to the client until the session becomes idle.
+			go func(client net.Addr, ws *websocket.Conn) {
+				defer func() {
+					sessionsMu.Lock()
+					delete(sessions, client.String())
+					sessionsMu.Unlock()
+
+					r.untrack(ws)
+				}()
+
+				for {
+					ws.SetReadDeadline(time.Now().Add(proxyUDPTimeout))
+
+					mt, data, err := ws.ReadMessage()
+					if err != nil || mt != websocket.BinaryMessage {
+						return
+					}
+
+					_, err = pc.WriteTo(data, client)
+					if err != nil {
+						return
+					}
+				}
+			}(client, ws)
+		}
+
+		err = ws.WriteMessage(websocket.BinaryMessage, buf[:n])
+		if err != nil {
+			r.untrack(ws)
+		}
+	}
+}
+
+// proxyProtocolHeader returns the PROXY protocol (version 1) header describing the client connection.
+func proxyProtocolHeader(conn net.Conn) []byte {
+	cHost, cPort, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return []byte("PROXY UNKNOWN\r\n")
+	}
+
+	dHost, dPort, err := net.SplitHostPort(conn.LocalAddr().String())
+	if err != nil {
+		return []byte("PROXY UNKNOWN\r\n")
+	}
+
+	proto := "TCP4"
+	if strings.Contains(cHost, ":") {
+		proto = "TCP6"
+	}
+
+	return []byte(fmt.Sprintf("PROXY %s %s %s %s %s\r\n", proto, cHost, dHost, cPort, dPort))
+}
@@ -315,6 +315,21 @@ func unixDeviceSetup(s *state.State, devicesPath string, typePrefix string, devi
 	return nil
 }
 
+// unixVMDeviceCreate creates a UNIX device on the host for a VM and returns the item used to pass it to QEMU.
+// As the device isn't mounted anywhere, the uid, gid and mode settings only apply to the host side file.
+func unixVMDeviceCreate(s *state.State, devicesPath string, deviceName string, m deviceConfig.Device) (*deviceConfig.UnixDeviceItem, error) {
+	dev, err := UnixDeviceCreate(s, nil, devicesPath, deviceJoinPath("unix", deviceName), m, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &deviceConfig.UnixDeviceItem{
+		DeviceName:     deviceName,
+		Type:           m["type"],
+		HostDevicePath: dev.HostPath,
+	}, nil
+}
+
 // unixDeviceSetupCharNum calls unixDeviceSetup and overrides the supplied device config with the
 // type as "unix-char" and the supplied major and minor numbers. This function can be used when you
 // already know the device's major and minor numbers to avoid unixDeviceSetup() having to stat the
@@ -85,6 +85,7 @@ func (d *disk) validateConfig(instConf instance.ConfigReader) error {
 		"limits.write":      validate.IsAny,
 		"limits.max":        validate.IsAny,
 		"size":              validate.IsAny,
+		"size.state":        validate.IsAny,
 		"pool":              validate.IsAny,
 		"propagation":       validatePropagation,
 		"raw.mount.options": validate.IsAny,
@@ -119,6 +120,10 @@ func (d *disk) validateConfig(instConf instance.ConfigReader) error {
 		return fmt.Errorf("Only the root disk may have a size quota")
 	}
 
+	if d.config["size.state"] != "" && d.config["path"] != "/" {
+		return fmt.Errorf("Only the root disk may have a state size quota")
+	}
+
 	if d.config["recursive"] != "" && (d.config["path"] == "/" || !shared.IsDir(shared.HostPath(d.config["source"]))) {
 		return fmt.Errorf("The recursive option is only supported for additional bind-mounted paths")
 	}
@@ -232,7 +237,7 @@ func (d *disk) validateEnvironment() error {
 // CanHotPlug returns whether the device can be managed whilst the instance is running, it also
 // returns a list of fields that can be updated without triggering a device remove & add.
 func (d *disk) CanHotPlug() (bool, []string) {
-	return true, []string{"limits.max", "limits.read", "limits.write", "size"}
+	return true, []string{"limits.max", "limits.read", "limits.write", "size", "size.state"}
 }
 
 // Start is run when the device is added to the instance.
@@ -287,7 +292,7 @@ func (d *disk) startContainer() (*deviceConfig.RunConfig, error) {
 
 		// Handle previous requests for setting new quotas.
 		if v["apply_quota"] != "" {
-			err := d.applyQuota(v["apply_quota"])
+			err := d.applyQuota(v["apply_quota"], "")
 			if err != nil {
 				return nil, err
 			}
@@ -398,6 +403,28 @@ func (d *disk) startVM() (*deviceConfig.RunConfig, error) {
 	isRequired := d.isRequired(d.config)
 
 	if shared.IsRootDiskDevice(d.config) {
+		v := d.volatileGet()
+
+		// Handle previous requests for setting new quotas.
+		if v["apply_quota"] != "" || v["apply_quota.state"] != "" {
+			// Keep the current size if only the state quota is pending.
+			size := v["apply_quota"]
+			if size == "" {
+				size = d.config["size"]
+			}
+
+			err := d.applyQuota(size, v["apply_quota.state"])
+			if err != nil {
+				return nil, err
+			}
+
+			// Remove volatile apply_quota keys if successful.
+			err = d.volatileSet(map[string]string{"apply_quota": "", "apply_quota.state": ""})
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		runConf.Mounts = []deviceConfig.MountEntryItem{
 			{
 				TargetPath: d.config["path"], // Indicator used that this is the root device.
@@ -531,6 +558,8 @@ func (d *disk) startVM() (*deviceConfig.RunConfig, error) {
 						time.Sleep(50 * time.Millisecond)
 					}
 				}
+			} else if shared.IsTrue(d.config["readonly"]) {
+				mount.Opts = append(mount.Opts, "ro")
 			}
 
 			runConf.Mounts = []deviceConfig.MountEntryItem{mount}
@@ -589,13 +618,20 @@ func (d *disk) Update(oldDevices deviceConfig.Devices, isRunning bool) error {
 		// Deal with quota changes.
 		oldRootDiskDeviceSize := oldDevices[oldRootDiskDeviceKey]["size"]
 		newRootDiskDeviceSize := expandedDevices[newRootDiskDeviceKey]["size"]
+		oldRootDiskDeviceStateSize := oldDevices[oldRootDiskDeviceKey]["size.state"]
+		newRootDiskDeviceStateSize := expandedDevices[newRootDiskDeviceKey]["size.state"]
+
+		// Only resize the state volume if its quota changed.
+		if newRootDiskDeviceStateSize == oldRootDiskDeviceStateSize {
+			newRootDiskDeviceStateSize = ""
+		}
 
 		// Apply disk quota changes.
-		if newRootDiskDeviceSize != oldRootDiskDeviceSize {
-			err := d.applyQuota(newRootDiskDeviceSize)
+		if newRootDiskDeviceSize != oldRootDiskDeviceSize || newRootDiskDeviceStateSize != "" {
+			err := d.applyQuota(newRootDiskDeviceSize, newRootDiskDeviceStateSize)
 			if err == storagePools.ErrRunningQuotaResizeNotSupported {
-				// Save volatile apply_quota key for next boot if cannot apply now.
-				err = d.volatileSet(map[string]string{"apply_quota": newRootDiskDeviceSize})
+				// Save volatile apply_quota keys for next boot if cannot apply now.
+				err = d.volatileSet(map[string]string{"apply_quota": newRootDiskDeviceSize, "apply_quota.state": newRootDiskDeviceStateSize})
 				if err != nil {
 					return err
 				}
@@ -622,13 +658,13 @@ func (d *disk) Update(oldDevices deviceConfig.Devices, isRunning bool) error {
 	return nil
 }
 
-func (d *disk) applyQuota(newSize string) error {
+func (d *disk) applyQuota(newSize string, newStateSize string) error {
 	pool, err := storagePools.GetPoolByInstance(d.state, d.inst)
 	if err != nil {
 		return err
 	}
 
-	err = pool.SetInstanceQuota(d.inst, newSize, nil)
+	err = pool.SetInstanceQuota(d.inst, newSize, newStateSize, nil)
 	if err != nil {
 		return err
 	}
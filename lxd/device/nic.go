@@ -21,6 +21,7 @@ func nicValidationRules(requiredFields []string, optionalFields []string) map[st
 		"security.mac_filtering":  validate.IsAny,
 		"security.ipv4_filtering": validate.IsAny,
 		"security.ipv6_filtering": validate.IsAny,
+		"security.acls":           validate.IsAny,
 		"maas.subnet.ipv4":        validate.IsAny,
 		"maas.subnet.ipv6":        validate.IsAny,
 		"ipv4.address":            validate.Optional(validate.IsNetworkAddressV4),
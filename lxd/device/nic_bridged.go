@@ -23,7 +23,9 @@ import (
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/network/acl"
 	"github.com/lxc/lxd/lxd/network/openvswitch"
+	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/revert"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
@@ -61,6 +63,7 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 		"security.mac_filtering",
 		"security.ipv4_filtering",
 		"security.ipv6_filtering",
+		"security.acls",
 		"maas.subnet.ipv4",
 		"maas.subnet.ipv6",
 		"boot.priority",
@@ -79,7 +82,13 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 		}
 
 		// If network property is specified, lookup network settings and apply them to the device's config.
-		n, err := network.LoadByName(d.state, d.config["network"])
+		// The network is looked up in the effective network project of the instance's project.
+		networkProjectName, err := project.NetworkProject(d.state.Cluster, instConf.Project())
+		if err != nil {
+			return errors.Wrapf(err, "Failed loading network project name")
+		}
+
+		n, err := network.LoadByName(d.state, networkProjectName, d.config["network"])
 		if err != nil {
 			return errors.Wrapf(err, "Error loading network config for %q", d.config["network"])
 		}
@@ -130,6 +139,26 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 			}
 		}
 
+		// Check the static IPs supplied aren't reserved for another MAC address on the linked network.
+		if d.config["ipv4.address"] != "" || d.config["ipv6.address"] != "" {
+			reservations, err := d.state.Cluster.GetNetworkLeaseReservations(n.ID())
+			if err != nil {
+				return errors.Wrapf(err, "Failed loading DHCP reservations of network %q", d.config["network"])
+			}
+
+			ipv4 := net.ParseIP(d.config["ipv4.address"])
+			ipv6 := net.ParseIP(d.config["ipv6.address"])
+			for _, reservation := range reservations {
+				if d.config["hwaddr"] != "" && strings.EqualFold(reservation.Hwaddr, d.config["hwaddr"]) {
+					continue
+				}
+
+				if (ipv4 != nil && ipv4.Equal(net.ParseIP(reservation.IPv4Address))) || (ipv6 != nil && ipv6.Equal(net.ParseIP(reservation.IPv6Address))) {
+					return fmt.Errorf("Device IP address already reserved for %q on network %q", reservation.Hwaddr, d.config["network"])
+				}
+			}
+		}
+
 		// Link device to network bridge.
 		d.config["parent"] = d.config["network"]
 
@@ -159,6 +188,15 @@ func (d *nicBridged) validateConfig(instConf instance.ConfigReader) error {
 
 	rules := nicValidationRules(requiredFields, optionalFields)
 
+	// Check the referenced network ACLs exist.
+	rules["security.acls"] = func(value string) error {
+		if value == "" {
+			return nil
+		}
+
+		return acl.ValidateNames(d.state, value)
+	}
+
 	// Add bridge specific vlan validation.
 	rules["vlan"] = func(value string) error {
 		if value == "" || value == "none" {
@@ -215,7 +253,7 @@ func (d *nicBridged) validateEnvironment() error {
 // CanHotPlug returns whether the device can be managed whilst the instance is running, it also
 // returns a list of fields that can be updated without triggering a device remove & add.
 func (d *nicBridged) CanHotPlug() (bool, []string) {
-	return true, []string{"limits.ingress", "limits.egress", "limits.max", "ipv4.routes", "ipv6.routes", "ipv4.address", "ipv6.address", "security.mac_filtering", "security.ipv4_filtering", "security.ipv6_filtering"}
+	return true, []string{"limits.ingress", "limits.egress", "limits.max", "ipv4.routes", "ipv6.routes", "ipv4.address", "ipv6.address", "security.mac_filtering", "security.ipv4_filtering", "security.ipv6_filtering", "security.acls"}
 }
 
 // Add is run when a device is added to an instance whether or not the instance is running.
@@ -226,6 +264,9 @@ func (d *nicBridged) Add() error {
 		return err
 	}
 
+	// Regenerate the network zones with the NIC's addresses.
+	d.state.DNS.InvalidateZones()
+
 	return nil
 }
 
@@ -268,7 +309,7 @@ func (d *nicBridged) Start() (*deviceConfig.RunConfig, error) {
 	networkVethFillFromVolatile(d.config, saveData)
 
 	// Apply host-side routes.
-	err = networkSetupHostVethRoutes(d.state, d.config, nil, saveData)
+	err = networkSetupHostVethRoutes(d.state, d.inst.Project(), d.config, nil, saveData)
 	if err != nil {
 		return nil, err
 	}
@@ -293,6 +334,19 @@ func (d *nicBridged) Start() (*deviceConfig.RunConfig, error) {
 	}
 	revert.Add(func() { d.removeFilters(d.config) })
 
+	// Apply network ACLs.
+	err = d.setupACLs(nil)
+	if err != nil {
+		return nil, err
+	}
+	revert.Add(func() { d.state.Firewall.InstanceClearACLRules(d.inst.Project(), d.inst.Name(), d.name) })
+
+	// Add the NIC's addresses to the rules using its ACLs as subjects.
+	err = acl.FirewallApplySubjectChanges(d.state, d.config["parent"], acl.ParseNames(d.config["security.acls"]))
+	if err != nil {
+		return nil, err
+	}
+
 	// Attach host side veth interface to bridge.
 	err = network.AttachInterface(d.config["parent"], saveData["host_name"])
 	if err != nil {
@@ -370,7 +424,7 @@ func (d *nicBridged) Update(oldDevices deviceConfig.Devices, isRunning bool) err
 		}
 
 		// Apply host-side routes.
-		err = networkSetupHostVethRoutes(d.state, d.config, oldConfig, v)
+		err = networkSetupHostVethRoutes(d.state, d.inst.Project(), d.config, oldConfig, v)
 		if err != nil {
 			return err
 		}
@@ -386,6 +440,12 @@ func (d *nicBridged) Update(oldDevices deviceConfig.Devices, isRunning bool) err
 		if err != nil {
 			return err
 		}
+
+		// Apply network ACLs.
+		err = d.setupACLs(oldConfig)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Rebuild dnsmasq entry if needed and reload.
@@ -394,6 +454,17 @@ func (d *nicBridged) Update(oldDevices deviceConfig.Devices, isRunning bool) err
 		return err
 	}
 
+	d.state.DNS.InvalidateZones()
+
+	// Update the rules using the old and new ACLs of the NIC as subjects with its current addresses.
+	if isRunning {
+		aclNames := append(acl.ParseNames(d.config["security.acls"]), acl.ParseNames(oldConfig["security.acls"])...)
+		err = acl.FirewallApplySubjectChanges(d.state, d.config["parent"], aclNames)
+		if err != nil {
+			return err
+		}
+	}
+
 	// If an IPv6 address has changed, if the instance is running we should bounce the host-side
 	// veth interface to give the instance a chance to detect the change and re-apply for an
 	// updated lease with new IP address.
@@ -444,9 +515,22 @@ func (d *nicBridged) postStop() error {
 		}
 	}
 
-	networkRemoveVethRoutes(d.state, d.config)
+	networkRemoveVethRoutes(d.state, d.inst.Project(), d.config)
 	d.removeFilters(d.config)
 
+	if d.config["security.acls"] != "" {
+		err := d.state.Firewall.InstanceClearACLRules(d.inst.Project(), d.inst.Name(), d.name)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Remove the NIC's addresses from the rules using its ACLs as subjects.
+	err := acl.FirewallApplySubjectChanges(d.state, d.config["parent"], acl.ParseNames(d.config["security.acls"]))
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -457,6 +541,8 @@ func (d *nicBridged) Remove() error {
 		return err
 	}
 
+	d.state.DNS.InvalidateZones()
+
 	if d.config["parent"] != "" {
 		dnsmasq.ConfigMutex.Lock()
 		defer dnsmasq.ConfigMutex.Unlock()
@@ -489,7 +575,7 @@ func (d *nicBridged) rebuildDnsmasqEntry() error {
 	dnsmasq.ConfigMutex.Lock()
 	defer dnsmasq.ConfigMutex.Unlock()
 
-	_, dbInfo, err := d.state.Cluster.GetNetworkInAnyState(d.config["parent"])
+	_, dbInfo, err := d.state.Cluster.GetNetworkInAnyState(project.Default, d.config["parent"])
 	if err != nil {
 		return err
 	}
@@ -547,6 +633,30 @@ func (d *nicBridged) setupHostFilters(oldConfig deviceConfig.Device) error {
 	return nil
 }
 
+// setupACLs applies the network ACLs listed in security.acls to the host side interface, or removes the existing
+// ones if the setting has been cleared as part of an update.
+func (d *nicBridged) setupACLs(oldConfig deviceConfig.Device) error {
+	if d.config["security.acls"] == "" {
+		if oldConfig != nil && oldConfig["security.acls"] != "" {
+			return d.state.Firewall.InstanceClearACLRules(d.inst.Project(), d.inst.Name(), d.name)
+		}
+
+		return nil
+	}
+
+	rules, err := acl.FirewallRules(d.state, acl.ParseNames(d.config["security.acls"]))
+	if err != nil {
+		return err
+	}
+
+	err = d.state.Firewall.InstanceSetupACLRules(d.inst.Project(), d.inst.Name(), d.name, d.config["host_name"], rules)
+	if err != nil {
+		return errors.Wrapf(err, "Failed applying network ACLs")
+	}
+
+	return nil
+}
+
 // removeFilters removes any network level filters defined for the instance.
 func (d *nicBridged) removeFilters(m deviceConfig.Device) {
 	if m["hwaddr"] == "" {
@@ -646,7 +756,7 @@ func (d *nicBridged) setFilters() (err error) {
 	IPv6 := net.ParseIP(d.config["ipv6.address"])
 
 	// Check if the parent is managed and load config. If parent is unmanaged continue anyway.
-	n, err := network.LoadByName(d.state, d.config["parent"])
+	n, err := network.LoadByName(d.state, project.Default, d.config["parent"])
 	if err != nil && err != db.ErrNoSuchObject {
 		return err
 	}
@@ -2,13 +2,17 @@ package device
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net"
 	"strings"
 
+	"github.com/pkg/errors"
+
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/revert"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/validate"
@@ -27,7 +31,7 @@ func (d *nicIPVLAN) CanHotPlug() (bool, []string) {
 
 // validateConfig checks the supplied config for correctness.
 func (d *nicIPVLAN) validateConfig(instConf instance.ConfigReader) error {
-	if !instanceSupported(instConf.Type(), instancetype.Container) {
+	if !instanceSupported(instConf.Type(), instancetype.Container, instancetype.VM) {
 		return ErrUnsupportedDevType
 	}
 
@@ -138,8 +142,9 @@ func (d *nicIPVLAN) validateEnvironment() error {
 		return fmt.Errorf("Requires name property to start")
 	}
 
+	// VMs have their IPVTAP device and proxy neighbour entries setup by LXD rather than liblxc.
 	extensions := d.state.OS.LXCFeatures
-	if !extensions["network_ipvlan"] || !extensions["network_l2proxy"] || !extensions["network_gateway_device_route"] {
+	if d.inst.Type() == instancetype.Container && (!extensions["network_ipvlan"] || !extensions["network_l2proxy"] || !extensions["network_gateway_device_route"]) {
 		return fmt.Errorf("Requires liblxc has following API extensions: network_ipvlan, network_l2proxy, network_gateway_device_route")
 	}
 
@@ -215,6 +220,9 @@ func (d *nicIPVLAN) Start() (*deviceConfig.RunConfig, error) {
 	networkCreateSharedDeviceLock.Lock()
 	defer networkCreateSharedDeviceLock.Unlock()
 
+	revert := revert.New()
+	defer revert.Fail()
+
 	saveData := make(map[string]string)
 
 	// Decide which parent we should use based on VLAN setting.
@@ -228,6 +236,12 @@ func (d *nicIPVLAN) Start() (*deviceConfig.RunConfig, error) {
 	// Record whether we created this device or not so it can be removed on stop.
 	saveData["last_state.created"] = fmt.Sprintf("%t", statusDev != "existing")
 
+	if shared.IsTrue(saveData["last_state.created"]) {
+		revert.Add(func() {
+			networkRemoveInterfaceIfNeeded(d.state, parentName, d.inst, d.config["parent"], d.config["vlan"])
+		})
+	}
+
 	mode := d.mode()
 
 	// If we created a VLAN interface, we need to setup the sysctls on that interface for l3s mode l2proxy.
@@ -238,12 +252,66 @@ func (d *nicIPVLAN) Start() (*deviceConfig.RunConfig, error) {
 		}
 	}
 
+	// VMs are connected using an IPVTAP device, which QEMU uses in the same way as a MACVTAP device.
+	var hwaddr string
+	if d.inst.Type() == instancetype.VM {
+		saveData["host_name"] = networkRandomDevName("ipvt")
+
+		_, err = shared.RunCommand("ip", "link", "add", "dev", saveData["host_name"], "link", parentName, "type", "ipvtap", "mode", mode, "bridge")
+		if err != nil {
+			return nil, err
+		}
+
+		revert.Add(func() { NetworkRemoveInterface(saveData["host_name"]) })
+
+		if d.config["mtu"] != "" {
+			_, err := shared.RunCommand("ip", "link", "set", "dev", saveData["host_name"], "mtu", d.config["mtu"])
+			if err != nil {
+				return nil, fmt.Errorf("Failed to set the MTU: %s", err)
+			}
+		}
+
+		_, err = shared.RunCommand("ip", "link", "set", "dev", saveData["host_name"], "up")
+		if err != nil {
+			return nil, fmt.Errorf("Failed to bring up interface %s: %v", saveData["host_name"], err)
+		}
+
+		err = d.setupVMAddresses(saveData["host_name"])
+		if err != nil {
+			return nil, err
+		}
+
+		// IPVLAN devices share the MAC address of their parent, which the VM must use to receive traffic.
+		content, err := ioutil.ReadFile(fmt.Sprintf("/sys/class/net/%s/address", saveData["host_name"]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed getting MAC address of %q", saveData["host_name"])
+		}
+
+		hwaddr = strings.TrimSpace(string(content))
+	}
+
 	err = d.volatileSet(saveData)
 	if err != nil {
 		return nil, err
 	}
 
 	runConf := deviceConfig.RunConfig{}
+
+	if d.inst.Type() == instancetype.VM {
+		runConf.NetworkInterface = []deviceConfig.RunConfigItem{
+			{Key: "name", Value: d.config["name"]},
+			{Key: "type", Value: "phys"},
+			{Key: "flags", Value: "up"},
+			{Key: "link", Value: saveData["host_name"]},
+			{Key: "devName", Value: d.name},
+			{Key: "hwaddr", Value: hwaddr},
+		}
+
+		runConf.PostHooks = append(runConf.PostHooks, d.postStart)
+		revert.Success()
+		return &runConf, nil
+	}
+
 	nic := []deviceConfig.RunConfigItem{
 		{Key: "name", Value: d.config["name"]},
 		{Key: "type", Value: "ipvlan"},
@@ -312,9 +380,88 @@ func (d *nicIPVLAN) Start() (*deviceConfig.RunConfig, error) {
 
 	runConf.NetworkInterface = nic
 	runConf.PostHooks = append(runConf.PostHooks, d.postStart)
+	revert.Success()
 	return &runConf, nil
 }
 
+// vmAddresses returns the IPs of the given family configured on the NIC without their subnet (if any).
+func (d *nicIPVLAN) vmAddresses(ipFamily string) []string {
+	addresses := []string{}
+
+	value := d.config[fmt.Sprintf("ipv%s.address", ipFamily)]
+	if value == "" {
+		return addresses
+	}
+
+	for _, addr := range strings.Split(value, ",") {
+		addr = strings.TrimSpace(addr)
+
+		ip, _, err := net.ParseCIDR(addr)
+		if err == nil {
+			addr = ip.String()
+		}
+
+		addresses = append(addresses, addr)
+	}
+
+	return addresses
+}
+
+// setupVMAddresses registers the IPs of a VM on its IPVTAP device, as IPVLAN only delivers traffic to the IPs
+// configured on its devices, and for l3s mode adds the proxy ARP/NDP entries advertising them on the parent
+// interface (which liblxc sets up for containers). The local routes the host adds for the IPs are removed, so
+// that the host reaches them through the VM rather than considering them as its own.
+func (d *nicIPVLAN) setupVMAddresses(hostName string) error {
+	parentName := network.GetHostDevice(d.config["parent"], d.config["vlan"])
+
+	for _, ipFamily := range []string{"4", "6"} {
+		prefix := "32"
+		addArgs := []string{}
+		if ipFamily == "6" {
+			prefix = "128"
+			addArgs = append(addArgs, "nodad")
+		}
+
+		for _, addr := range d.vmAddresses(ipFamily) {
+			hostAddr := fmt.Sprintf("%s/%s", addr, prefix)
+
+			_, err := shared.RunCommand("ip", append([]string{fmt.Sprintf("-%s", ipFamily), "address", "add", hostAddr, "dev", hostName}, addArgs...)...)
+			if err != nil {
+				return err
+			}
+
+			_, err = shared.RunCommand("ip", fmt.Sprintf("-%s", ipFamily), "route", "delete", "table", "local", hostAddr, "dev", hostName)
+			if err != nil {
+				return err
+			}
+
+			if d.mode() == ipvlanModeL3S {
+				_, err = shared.RunCommand("ip", fmt.Sprintf("-%s", ipFamily), "neigh", "add", "proxy", addr, "dev", parentName)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeVMProxyNeighbours removes the proxy ARP/NDP entries added to the parent interface for a VM.
+func (d *nicIPVLAN) removeVMProxyNeighbours() {
+	if d.mode() != ipvlanModeL3S {
+		return
+	}
+
+	parentName := network.GetHostDevice(d.config["parent"], d.config["vlan"])
+	for _, ipFamily := range []string{"4", "6"} {
+		for _, addr := range d.vmAddresses(ipFamily) {
+			// Ignore errors as the parent may have been removed already.
+			shared.RunCommand("ip", fmt.Sprintf("-%s", ipFamily), "neigh", "delete", "proxy", addr, "dev", parentName)
+		}
+	}
+}
+
 // setupParentSysctls configures the required sysctls on the parent to allow l2proxy to work.
 // Because of our policy not to modify sysctls on existing interfaces, this should only be called
 // if we created the parent interface.
@@ -391,11 +538,24 @@ func (d *nicIPVLAN) Stop() (*deviceConfig.RunConfig, error) {
 // postStop is run after the device is removed from the instance.
 func (d *nicIPVLAN) postStop() error {
 	defer d.volatileSet(map[string]string{
+		"host_name":          "",
 		"last_state.created": "",
 	})
 
 	v := d.volatileGet()
 
+	// For VMs, remove the proxy neighbour entries and the IPVTAP device (which removes its addresses too).
+	if d.inst.Type() == instancetype.VM {
+		d.removeVMProxyNeighbours()
+
+		if v["host_name"] != "" && shared.PathExists(fmt.Sprintf("/sys/class/net/%s", v["host_name"])) {
+			err := NetworkRemoveInterface(v["host_name"])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	if d.config["ipv4.address"] != "" {
 		// Remove static routes to instance IPs to custom routing tables if specified.
 		if d.config["ipv4.host_table"] != "" {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -13,7 +14,9 @@ import (
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
 	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/network/acl"
 	"github.com/lxc/lxd/lxd/network/openvswitch"
+	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/revert"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
@@ -54,11 +57,20 @@ func (d *nicOVN) validateConfig(instConf instance.ConfigReader) error {
 		"mtu",
 		"ipv4.address",
 		"ipv6.address",
+		"ipv4.routes",
+		"ipv6.routes",
 		"boot.priority",
+		"security.acls",
 	}
 
 	// Lookup network settings and apply them to the device's config.
-	n, err := network.LoadByName(d.state, d.config["network"])
+	// The network is looked up in the effective network project of the instance's project.
+	networkProjectName, err := project.NetworkProject(d.state.Cluster, instConf.Project())
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading network project name")
+	}
+
+	n, err := network.LoadByName(d.state, networkProjectName, d.config["network"])
 	if err != nil {
 		return errors.Wrapf(err, "Error loading network config for %q", d.config["network"])
 	}
@@ -127,15 +139,64 @@ func (d *nicOVN) validateConfig(instConf instance.ConfigReader) error {
 
 	rules := nicValidationRules(requiredFields, optionalFields)
 
+	// Check the referenced network ACLs exist.
+	rules["security.acls"] = func(value string) error {
+		return acl.ValidateNames(d.state, value)
+	}
+
 	// Now run normal validation.
 	err = d.config.Validate(rules)
 	if err != nil {
 		return err
 	}
 
+	// Check the internal routes don't overlap with the network's own subnets.
+	internalRoutes, err := d.internalRoutes()
+	if err != nil {
+		return err
+	}
+
+	for _, route := range internalRoutes {
+		keyPrefix := "ipv4"
+		if route.IP.To4() == nil {
+			keyPrefix = "ipv6"
+		}
+
+		_, netSubnet, err := net.ParseCIDR(netConfig[fmt.Sprintf("%s.address", keyPrefix)])
+		if err != nil {
+			return fmt.Errorf("Cannot specify %q when %q isn't set on network %q", fmt.Sprintf("%s.routes", keyPrefix), fmt.Sprintf("%s.address", keyPrefix), d.config["network"])
+		}
+
+		if netSubnet.Contains(route.IP) || route.Contains(netSubnet.IP) {
+			return fmt.Errorf("Route %q overlaps with network %q subnet %q", route.String(), d.config["network"], netSubnet.String())
+		}
+	}
+
 	return nil
 }
 
+// internalRoutes returns the parsed subnets of the ipv4.routes and ipv6.routes settings.
+func (d *nicOVN) internalRoutes() ([]*net.IPNet, error) {
+	routes := []*net.IPNet{}
+
+	for _, key := range []string{"ipv4.routes", "ipv6.routes"} {
+		if d.config[key] == "" {
+			continue
+		}
+
+		for _, route := range strings.Split(d.config[key], ",") {
+			_, subnet, err := net.ParseCIDR(strings.TrimSpace(route))
+			if err != nil {
+				return nil, errors.Wrapf(err, "Invalid %s value %q", key, route)
+			}
+
+			routes = append(routes, subnet)
+		}
+	}
+
+	return routes, nil
+}
+
 // validateEnvironment checks the runtime environment for correctness.
 func (d *nicOVN) validateEnvironment() error {
 	if d.inst.Type() == instancetype.Container && d.config["name"] == "" {
@@ -162,6 +223,9 @@ func (d *nicOVN) CanHotPlug() (bool, []string) {
 
 // Add is run when a device is added to an instance whether or not the instance is running.
 func (d *nicOVN) Add() error {
+	// Regenerate the network zones with the NIC's addresses.
+	d.state.DNS.InvalidateZones()
+
 	return nil
 }
 
@@ -232,13 +296,18 @@ func (d *nicOVN) Start() (*deviceConfig.RunConfig, error) {
 		}
 	}
 
+	internalRoutes, err := d.internalRoutes()
+	if err != nil {
+		return nil, err
+	}
+
 	// Add new OVN logical switch port for instance.
-	logicalPortName, err := network.OVNInstanceDevicePortAdd(d.network, d.inst.ID(), d.name, mac, ips)
+	logicalPortName, err := network.OVNInstanceDevicePortAdd(d.network, d.inst.ID(), d.name, mac, ips, acl.ParseNames(d.config["security.acls"]), internalRoutes)
 	if err != nil {
 		return nil, err
 	}
 
-	revert.Add(func() { network.OVNInstanceDevicePortDelete(d.network, d.inst.ID(), d.name) })
+	revert.Add(func() { network.OVNInstanceDevicePortDelete(d.network, d.inst.ID(), d.name, internalRoutes) })
 
 	// Attach host side veth interface to bridge.
 	integrationBridge, err := d.getIntegrationBridgeName()
@@ -293,6 +362,9 @@ func (d *nicOVN) Start() (*deviceConfig.RunConfig, error) {
 			}...)
 	}
 
+	// Regenerate the network zones with the NIC's dynamic addresses.
+	d.state.DNS.InvalidateZones()
+
 	revert.Success()
 	return &runConf, nil
 }
@@ -335,6 +407,8 @@ func (d *nicOVN) Update(oldDevices deviceConfig.Devices, isRunning bool) error {
 		}
 	}
 
+	d.state.DNS.InvalidateZones()
+
 	return nil
 }
 
@@ -344,7 +418,12 @@ func (d *nicOVN) Stop() (*deviceConfig.RunConfig, error) {
 		PostHooks: []func() error{d.postStop},
 	}
 
-	err := network.OVNInstanceDevicePortDelete(d.network, d.inst.ID(), d.name)
+	internalRoutes, err := d.internalRoutes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = network.OVNInstanceDevicePortDelete(d.network, d.inst.ID(), d.name, internalRoutes)
 	if err != nil {
 		// Don't fail here as we still want the postStop hook to run to clean up the local veth pair.
 		d.logger.Error("Failed to remove OVN device port", log.Ctx{"err": err})
@@ -384,10 +463,14 @@ func (d *nicOVN) postStop() error {
 		}
 	}
 
+	d.state.DNS.InvalidateZones()
+
 	return nil
 }
 
 // Remove is run when the device is removed from the instance or the instance is deleted.
 func (d *nicOVN) Remove() error {
+	d.state.DNS.InvalidateZones()
+
 	return nil
 }
@@ -29,7 +29,7 @@ func (d *nicRouted) CanHotPlug() (bool, []string) {
 
 // validateConfig checks the supplied config for correctness.
 func (d *nicRouted) validateConfig(instConf instance.ConfigReader) error {
-	if !instanceSupported(instConf.Type(), instancetype.Container) {
+	if !instanceSupported(instConf.Type(), instancetype.Container, instancetype.VM) {
 		return ErrUnsupportedDevType
 	}
 
@@ -70,8 +70,9 @@ func (d *nicRouted) validateEnvironment() error {
 		return fmt.Errorf("Requires name property to start")
 	}
 
+	// VMs have their host side routes and proxy neighbour entries setup by LXD rather than liblxc.
 	extensions := d.state.OS.LXCFeatures
-	if !extensions["network_veth_router"] || !extensions["network_l2proxy"] {
+	if d.inst.Type() == instancetype.Container && (!extensions["network_veth_router"] || !extensions["network_l2proxy"]) {
 		return fmt.Errorf("Requires liblxc has following API extensions: network_veth_router, network_l2proxy")
 	}
 
@@ -193,16 +194,46 @@ func (d *nicRouted) Start() (*deviceConfig.RunConfig, error) {
 
 	hostName := d.config["host_name"]
 	if hostName == "" {
-		hostName = networkRandomDevName("veth")
+		if d.inst.Type() == instancetype.VM {
+			hostName = networkRandomDevName("tap")
+		} else {
+			hostName = networkRandomDevName("veth")
+		}
 	}
 	saveData["host_name"] = hostName
 
+	// VMs are connected using a TAP device, with the routing setup done by postStart.
+	if d.inst.Type() == instancetype.VM {
+		err = networkCreateTap(hostName, d.config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	err = d.volatileSet(saveData)
 	if err != nil {
 		return nil, err
 	}
 
 	runConf := deviceConfig.RunConfig{}
+
+	if d.inst.Type() == instancetype.VM {
+		runConf.NetworkInterface = []deviceConfig.RunConfigItem{
+			{Key: "name", Value: d.config["name"]},
+			{Key: "type", Value: "phys"},
+			{Key: "flags", Value: "up"},
+			{Key: "link", Value: hostName},
+			{Key: "devName", Value: d.name},
+			{Key: "hwaddr", Value: d.config["hwaddr"]},
+		}
+
+		// The addresses and gateways are configured inside the VM using the generated cloud-init network config.
+		runConf.NetworkInterface = append(runConf.NetworkInterface, d.addressRunConfig()...)
+
+		runConf.PostHooks = append(runConf.PostHooks, d.postStart)
+		return &runConf, nil
+	}
+
 	nic := []deviceConfig.RunConfigItem{
 		{Key: "name", Value: d.config["name"]},
 		{Key: "type", Value: "veth"},
@@ -224,33 +255,42 @@ func (d *nicRouted) Start() (*deviceConfig.RunConfig, error) {
 		nic = append(nic, deviceConfig.RunConfigItem{Key: "mtu", Value: d.config["mtu"]})
 	}
 
+	nic = append(nic, d.addressRunConfig()...)
+
+	runConf.NetworkInterface = nic
+	runConf.PostHooks = append(runConf.PostHooks, d.postStart)
+	return &runConf, nil
+}
+
+// addressRunConfig returns the run config items of the instance IPs and of the default gateways.
+func (d *nicRouted) addressRunConfig() []deviceConfig.RunConfigItem {
+	items := []deviceConfig.RunConfigItem{}
+
 	if d.config["ipv4.address"] != "" {
 		for _, addr := range strings.Split(d.config["ipv4.address"], ",") {
 			addr = strings.TrimSpace(addr)
-			nic = append(nic, deviceConfig.RunConfigItem{Key: "ipv4.address", Value: fmt.Sprintf("%s/32", addr)})
+			items = append(items, deviceConfig.RunConfigItem{Key: "ipv4.address", Value: fmt.Sprintf("%s/32", addr)})
 		}
 
 		if nicHasAutoGateway(d.config["ipv4.gateway"]) {
 			// Use a fixed link-local address as the next-hop default gateway.
-			nic = append(nic, deviceConfig.RunConfigItem{Key: "ipv4.gateway", Value: d.ipv4HostAddress()})
+			items = append(items, deviceConfig.RunConfigItem{Key: "ipv4.gateway", Value: d.ipv4HostAddress()})
 		}
 	}
 
 	if d.config["ipv6.address"] != "" {
 		for _, addr := range strings.Split(d.config["ipv6.address"], ",") {
 			addr = strings.TrimSpace(addr)
-			nic = append(nic, deviceConfig.RunConfigItem{Key: "ipv6.address", Value: fmt.Sprintf("%s/128", addr)})
+			items = append(items, deviceConfig.RunConfigItem{Key: "ipv6.address", Value: fmt.Sprintf("%s/128", addr)})
 		}
 
 		if nicHasAutoGateway(d.config["ipv6.gateway"]) {
 			// Use a fixed link-local address as the next-hop default gateway.
-			nic = append(nic, deviceConfig.RunConfigItem{Key: "ipv6.gateway", Value: d.ipv6HostAddress()})
+			items = append(items, deviceConfig.RunConfigItem{Key: "ipv6.gateway", Value: d.ipv6HostAddress()})
 		}
 	}
 
-	runConf.NetworkInterface = nic
-	runConf.PostHooks = append(runConf.PostHooks, d.postStart)
-	return &runConf, nil
+	return items
 }
 
 // setupParentSysctls configures the required sysctls on the parent to allow l2proxy to work.
@@ -339,6 +379,15 @@ func (d *nicRouted) postStart() error {
 		return errors.Wrapf(err, "Error setting up reverse path filter")
 	}
 
+	// For VMs, add the routes to the instance IPs and the proxy neighbour entries on the parent interface,
+	// which liblxc sets up for containers.
+	if d.inst.Type() == instancetype.VM {
+		err = d.setupVMRoutes()
+		if err != nil {
+			return err
+		}
+	}
+
 	if d.config["ipv4.address"] != "" {
 		// Add dummy link-local gateway IPs to the host end of the veth pair. This ensures that
 		// liveness detection of the gateways inside the instance work and ensure that traffic
@@ -410,6 +459,18 @@ func (d *nicRouted) postStop() error {
 
 	errs := []error{}
 
+	// For VMs, remove the proxy neighbour entries and the TAP device (which removes its routes too).
+	if d.inst.Type() == instancetype.VM {
+		d.removeVMProxyNeighbours()
+
+		if v["host_name"] != "" && shared.PathExists(fmt.Sprintf("/sys/class/net/%s", v["host_name"])) {
+			err := NetworkRemoveInterface(v["host_name"])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("Failed to remove interface %s: %s", v["host_name"], err))
+			}
+		}
+	}
+
 	// This will delete the parent interface if we created it for VLAN parent.
 	if shared.IsTrue(v["last_state.created"]) {
 		parentName := network.GetHostDevice(d.config["parent"], d.config["vlan"])
@@ -447,3 +508,66 @@ func (d *nicRouted) ipv6HostAddress() string {
 
 	return nicRoutedIPv6GW
 }
+
+// addresses returns the IPs of the given family configured on the NIC along with their host route prefix size.
+func (d *nicRouted) addresses(ipFamily string) ([]string, string) {
+	addresses := []string{}
+	prefix := "32"
+	if ipFamily == "6" {
+		prefix = "128"
+	}
+
+	if d.config[fmt.Sprintf("ipv%s.address", ipFamily)] == "" {
+		return addresses, prefix
+	}
+
+	for _, addr := range strings.Split(d.config[fmt.Sprintf("ipv%s.address", ipFamily)], ",") {
+		addresses = append(addresses, strings.TrimSpace(addr))
+	}
+
+	return addresses, prefix
+}
+
+// setupVMRoutes adds the static routes to the instance IPs via the TAP device and, when a parent is specified,
+// the proxy ARP/NDP entries advertising the instance IPs on the parent interface.
+func (d *nicRouted) setupVMRoutes() error {
+	parentName := ""
+	if d.config["parent"] != "" {
+		parentName = network.GetHostDevice(d.config["parent"], d.config["vlan"])
+	}
+
+	for _, ipFamily := range []string{"4", "6"} {
+		addresses, prefix := d.addresses(ipFamily)
+		for _, addr := range addresses {
+			_, err := shared.RunCommand("ip", fmt.Sprintf("-%s", ipFamily), "route", "add", fmt.Sprintf("%s/%s", addr, prefix), "dev", d.config["host_name"])
+			if err != nil {
+				return err
+			}
+
+			if parentName != "" {
+				_, err := shared.RunCommand("ip", fmt.Sprintf("-%s", ipFamily), "neigh", "add", "proxy", addr, "dev", parentName)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeVMProxyNeighbours removes the proxy ARP/NDP entries added to the parent interface for a VM.
+func (d *nicRouted) removeVMProxyNeighbours() {
+	if d.config["parent"] == "" {
+		return
+	}
+
+	parentName := network.GetHostDevice(d.config["parent"], d.config["vlan"])
+	for _, ipFamily := range []string{"4", "6"} {
+		addresses, _ := d.addresses(ipFamily)
+		for _, addr := range addresses {
+			// Ignore errors as the parent may have been removed already.
+			shared.RunCommand("ip", fmt.Sprintf("-%s", ipFamily), "neigh", "delete", "proxy", addr, "dev", parentName)
+		}
+	}
+}
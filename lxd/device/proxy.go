@@ -47,7 +47,7 @@ type proxyProcInfo struct {
 
 // validateConfig checks the supplied config for correctness.
 func (d *proxy) validateConfig(instConf instance.ConfigReader) error {
-	if !instanceSupported(instConf.Type(), instancetype.Container) {
+	if !instanceSupported(instConf.Type(), instancetype.Container, instancetype.VM) {
 		return ErrUnsupportedDevType
 	}
 
@@ -108,6 +108,21 @@ func (d *proxy) validateConfig(instConf instance.ConfigReader) error {
 		return fmt.Errorf("Only proxy devices for non-abstract unix sockets can carry uid, gid, or mode properties")
 	}
 
+	// VM proxies that don't use NAT are relayed through the lxd-agent, which connects from inside the VM.
+	if instConf.Type() == instancetype.VM && !shared.IsTrue(d.config["nat"]) {
+		if d.config["bind"] != "" && d.config["bind"] != "host" {
+			return fmt.Errorf("Only host-bound proxies are supported for virtual machines")
+		}
+
+		if d.config["security.uid"] != "" || d.config["security.gid"] != "" {
+			return fmt.Errorf("The security.uid and security.gid properties are not supported for virtual machines")
+		}
+
+		if (listenAddr.ConnType == "udp" || connectAddr.ConnType == "udp") && listenAddr.ConnType != connectAddr.ConnType {
+			return fmt.Errorf("Proxying %s <-> %s is not supported for virtual machines", listenAddr.ConnType, connectAddr.ConnType)
+		}
+	}
+
 	if shared.IsTrue(d.config["nat"]) {
 		if d.config["bind"] != "" && d.config["bind"] != "host" {
 			return fmt.Errorf("Only host-bound proxies can use NAT")
@@ -168,15 +183,102 @@ func (d *proxy) validateEnvironment() error {
 	return nil
 }
 
-// Start is run when the device is added to the container.
+// Register is run after the device is started or when LXD starts.
+func (d *proxy) Register() error {
+	// Only VM proxies that don't use NAT need LXD to relay their connections.
+	if d.inst.Type() != instancetype.VM || shared.IsTrue(d.config["nat"]) {
+		return nil
+	}
+
+	vm, ok := d.inst.(instance.VM)
+	if !ok {
+		return fmt.Errorf("Instance is not a virtual machine")
+	}
+
+	listenAddr, err := ProxyParseAddr(d.rewriteHostAddr(d.config["listen"]))
+	if err != nil {
+		return err
+	}
+
+	connectAddr, err := ProxyParseAddr(d.config["connect"])
+	if err != nil {
+		return err
+	}
+
+	err = proxyRelayStart(d.inst, d.name, listenAddr, connectAddr, vm.ConnectAgentProxy, shared.IsTrue(d.config["proxy_protocol"]))
+	if err != nil {
+		return err
+	}
+
+	if listenAddr.ConnType == "unix" && !listenAddr.Abstract {
+		err = d.setupUnixListener(listenAddr.Addr[0])
+		if err != nil {
+			proxyRelayStop(d.inst, d.name)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setupUnixListener applies the configured ownership and mode to a unix socket the host is listening on.
+func (d *proxy) setupUnixListener(path string) error {
+	uid := -1
+	if d.config["uid"] != "" {
+		tmp, err := strconv.Atoi(d.config["uid"])
+		if err != nil {
+			return err
+		}
+
+		uid = tmp
+	}
+
+	gid := -1
+	if d.config["gid"] != "" {
+		tmp, err := strconv.Atoi(d.config["gid"])
+		if err != nil {
+			return err
+		}
+
+		gid = tmp
+	}
+
+	if uid != -1 || gid != -1 {
+		err := os.Chown(path, uid, gid)
+		if err != nil {
+			return err
+		}
+	}
+
+	mode := "0644"
+	if d.config["mode"] != "" {
+		mode = d.config["mode"]
+	}
+
+	tmp, err := strconv.ParseUint(mode, 8, 0)
+	if err != nil {
+		return err
+	}
+
+	return os.Chmod(path, os.FileMode(tmp))
+}
+
+// Start is run when the device is added to the instance.
 func (d *proxy) Start() (*deviceConfig.RunConfig, error) {
 	err := d.validateEnvironment()
 	if err != nil {
 		return nil, err
 	}
 
-	// Proxy devices have to be setup once the container is running.
+	// Proxy devices have to be setup once the instance is running.
 	runConf := deviceConfig.RunConfig{}
+
+	// VM proxies that don't use NAT are relayed by LXD itself, see Register().
+	if d.inst.Type() == instancetype.VM && !shared.IsTrue(d.config["nat"]) {
+		runConf.PostHooks = []func() error{d.Register}
+		return &runConf, nil
+	}
+
 	runConf.PostHooks = []func() error{
 		func() error {
 			if shared.IsTrue(d.config["nat"]) {
@@ -296,6 +398,9 @@ func (d *proxy) Stop() (*deviceConfig.RunConfig, error) {
 		logger.Errorf("Failed to remove proxy NAT filters: %v", err)
 	}
 
+	// Stop relaying any VM proxy connections.
+	proxyRelayStop(d.inst, d.name)
+
 	devFileName := fmt.Sprintf("proxy.%s", d.name)
 	devPath := filepath.Join(d.inst.DevicesPath(), devFileName)
 
@@ -341,7 +446,7 @@ func (d *proxy) setupNAT() error {
 			continue
 		}
 
-		nicType, err := nictype.NICType(d.state, devConfig)
+		nicType, err := nictype.NICType(d.state, d.inst.Project(), devConfig)
 		if err != nil {
 			return err
 		}
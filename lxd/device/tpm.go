@@ -0,0 +1,195 @@
+package device
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/subprocess"
+)
+
+type tpm struct {
+	deviceCommon
+}
+
+// validateConfig checks the supplied config for correctness.
+func (d *tpm) validateConfig(instConf instance.ConfigReader) error {
+	if !instanceSupported(instConf.Type(), instancetype.VM) {
+		return ErrUnsupportedDevType
+	}
+
+	rules := map[string]func(string) error{} // No fields allowed.
+	err := d.config.Validate(rules)
+	if err != nil {
+		return err
+	}
+
+	// QEMU only supports a single TPM per VM.
+	for devName, devConfig := range instConf.ExpandedDevices() {
+		if devConfig["type"] == "tpm" && devName != d.name {
+			return fmt.Errorf("Only one TPM device is allowed per instance, %q is already defined", devName)
+		}
+	}
+
+	return nil
+}
+
+// validateEnvironment checks the runtime environment for correctness.
+func (d *tpm) validateEnvironment() error {
+	_, err := exec.LookPath("swtpm")
+	if err != nil {
+		return fmt.Errorf("Required tool '%s' is missing", "swtpm")
+	}
+
+	return nil
+}
+
+// CanHotPlug returns whether the device can be managed whilst the instance is running.
+func (d *tpm) CanHotPlug() (bool, []string) {
+	return false, []string{}
+}
+
+// statePath returns the path of the TPM state directory. It lives on the instance volume so that it
+// follows the instance through snapshots, backups and copies.
+func (d *tpm) statePath() string {
+	return filepath.Join(d.inst.Path(), fmt.Sprintf("tpm.%s", d.name))
+}
+
+// pidPath returns the path of the swtpm process file.
+func (d *tpm) pidPath() string {
+	return filepath.Join(d.inst.DevicesPath(), fmt.Sprintf("tpm.%s", d.name))
+}
+
+// socketPath returns the path of the swtpm control socket.
+func (d *tpm) socketPath() string {
+	return filepath.Join(d.inst.DevicesPath(), fmt.Sprintf("tpm.%s.sock", d.name))
+}
+
+// Start is run when the device is added to the instance.
+func (d *tpm) Start() (*deviceConfig.RunConfig, error) {
+	err := d.validateEnvironment()
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(d.statePath(), 0700)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create TPM state directory %q: %v", d.statePath(), err)
+	}
+
+	// Stop any leftover swtpm process from a previous run.
+	err = d.killSwtpm()
+	if err != nil {
+		return nil, err
+	}
+
+	socketPath := d.socketPath()
+	os.Remove(socketPath)
+
+	logPath := filepath.Join(d.inst.LogPath(), fmt.Sprintf("tpm.%s.log", d.name))
+
+	args := []string{"socket",
+		"--tpm2",
+		"--tpmstate", fmt.Sprintf("dir=%s", d.statePath()),
+		"--ctrl", fmt.Sprintf("type=unixio,path=%s", socketPath),
+	}
+
+	p, err := subprocess.NewProcess("swtpm", args, logPath, logPath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create subprocess: %v", err)
+	}
+
+	err = p.Start()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to start swtpm for device %q: %v", d.name, err)
+	}
+
+	err = p.Save(d.pidPath())
+	if err != nil {
+		p.Stop()
+		return nil, fmt.Errorf("Failed to save subprocess details: %v", err)
+	}
+
+	// Wait for swtpm to be ready to accept connections from QEMU.
+	for i := 0; i < 50 && !shared.PathExists(socketPath); i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if !shared.PathExists(socketPath) {
+		d.killSwtpm()
+		return nil, fmt.Errorf("Failed to start swtpm for device %q, please look in %s", d.name, logPath)
+	}
+
+	runConf := deviceConfig.RunConfig{}
+	runConf.TPMDevice = []deviceConfig.RunConfigItem{
+		{Key: "devName", Value: d.name},
+		{Key: "path", Value: socketPath},
+	}
+
+	return &runConf, nil
+}
+
+// Stop is run when the device is removed from the instance.
+func (d *tpm) Stop() (*deviceConfig.RunConfig, error) {
+	err := d.killSwtpm()
+	if err != nil {
+		return nil, err
+	}
+
+	os.Remove(d.socketPath())
+
+	return nil, nil
+}
+
+// Remove is run when the device is removed from the instance or the instance is deleted.
+func (d *tpm) Remove() error {
+	// The TPM state lives on the instance volume, so make sure it is mounted.
+	pool, err := storagePools.GetPoolByInstance(d.state, d.inst)
+	if err != nil {
+		return err
+	}
+
+	ourMount, err := pool.MountInstance(d.inst, nil)
+	if err != nil {
+		return err
+	}
+
+	if ourMount {
+		defer pool.UnmountInstance(d.inst, nil)
+	}
+
+	err = os.RemoveAll(d.statePath())
+	if err != nil {
+		return fmt.Errorf("Failed to remove TPM state directory %q: %v", d.statePath(), err)
+	}
+
+	return nil
+}
+
+// killSwtpm stops the swtpm process of the device if it is running.
+func (d *tpm) killSwtpm() error {
+	// If the pid file doesn't exist, there is no process to kill.
+	if !shared.PathExists(d.pidPath()) {
+		return nil
+	}
+
+	p, err := subprocess.ImportProcess(d.pidPath())
+	if err != nil {
+		return fmt.Errorf("Could not read pid file: %v", err)
+	}
+
+	err = p.Stop()
+	if err != nil && err != subprocess.ErrNotRunning {
+		return fmt.Errorf("Unable to kill swtpm: %v", err)
+	}
+
+	os.Remove(d.pidPath())
+	return nil
+}
@@ -42,7 +42,7 @@ func (d *unixCommon) isRequired() bool {
 
 // validateConfig checks the supplied config for correctness.
 func (d *unixCommon) validateConfig(instConf instance.ConfigReader) error {
-	if !instanceSupported(instConf.Type(), instancetype.Container) {
+	if !instanceSupported(instConf.Type(), instancetype.Container, instancetype.VM) {
 		return ErrUnsupportedDevType
 	}
 
@@ -82,6 +82,7 @@ func (d *unixCommon) Register() error {
 	devConfig := d.config
 	deviceName := d.name
 	state := d.state
+	instType := d.inst.Type()
 
 	// Handler for when a Unix event occurs.
 	f := func(e UnixEvent) (*deviceConfig.RunConfig, error) {
@@ -114,9 +115,18 @@ func (d *unixCommon) Register() error {
 				return nil, fmt.Errorf("Path specified is not a %s device", d.config["type"])
 			}
 
-			err = unixDeviceSetup(state, devicesPath, "unix", deviceName, devConfig, true, &runConf)
-			if err != nil {
-				return nil, err
+			if instType == instancetype.VM {
+				unixDev, err := unixVMDeviceCreate(state, devicesPath, deviceName, devConfig)
+				if err != nil {
+					return nil, err
+				}
+
+				runConf.UnixDevice = append(runConf.UnixDevice, *unixDev)
+			} else {
+				err = unixDeviceSetup(state, devicesPath, "unix", deviceName, devConfig, true, &runConf)
+				if err != nil {
+					return nil, err
+				}
 			}
 		} else if e.Action == "remove" {
 			// Skip if host side instance device file doesn't exist.
@@ -124,9 +134,16 @@ func (d *unixCommon) Register() error {
 				return nil, nil
 			}
 
-			err := unixDeviceRemove(devicesPath, "unix", deviceName, relativeDestPath, &runConf)
-			if err != nil {
-				return nil, err
+			if instType == instancetype.VM {
+				runConf.UnixDevice = append(runConf.UnixDevice, deviceConfig.UnixDeviceItem{
+					DeviceName: deviceName,
+					Type:       devConfig["type"],
+				})
+			} else {
+				err := unixDeviceRemove(devicesPath, "unix", deviceName, relativeDestPath, &runConf)
+				if err != nil {
+					return nil, err
+				}
 			}
 
 			// Add a post hook function to remove the specific USB device file after unmount.
@@ -153,7 +170,7 @@ func (d *unixCommon) Register() error {
 	return nil
 }
 
-// Start is run when the device is added to the container.
+// Start is run when the device is added to the instance.
 func (d *unixCommon) Start() (*deviceConfig.RunConfig, error) {
 	runConf := deviceConfig.RunConfig{}
 	runConf.PostHooks = []func() error{d.Register}
@@ -167,7 +184,7 @@ func (d *unixCommon) Start() (*deviceConfig.RunConfig, error) {
 			return nil, fmt.Errorf("Path specified is not a %s device", d.config["type"])
 		}
 
-		err = unixDeviceSetup(d.state, d.inst.DevicesPath(), "unix", d.name, d.config, true, &runConf)
+		err = d.setupDevice(&runConf)
 		if err != nil {
 			return nil, err
 		}
@@ -175,7 +192,7 @@ func (d *unixCommon) Start() (*deviceConfig.RunConfig, error) {
 		// If the device file doesn't exist on the system, but major & minor numbers have
 		// been provided in the config then we can go ahead and create the device anyway.
 		if d.config["major"] != "" && d.config["minor"] != "" {
-			err := unixDeviceSetup(d.state, d.inst.DevicesPath(), "unix", d.name, d.config, true, &runConf)
+			err := d.setupDevice(&runConf)
 			if err != nil {
 				return nil, err
 			}
@@ -188,6 +205,21 @@ func (d *unixCommon) Start() (*deviceConfig.RunConfig, error) {
 	return &runConf, nil
 }
 
+// setupDevice creates the device on the host and configures the RunConfig to pass it to the instance.
+func (d *unixCommon) setupDevice(runConf *deviceConfig.RunConfig) error {
+	if d.inst.Type() == instancetype.VM {
+		unixDev, err := unixVMDeviceCreate(d.state, d.inst.DevicesPath(), d.name, d.config)
+		if err != nil {
+			return err
+		}
+
+		runConf.UnixDevice = append(runConf.UnixDevice, *unixDev)
+		return nil
+	}
+
+	return unixDeviceSetup(d.state, d.inst.DevicesPath(), "unix", d.name, d.config, true, runConf)
+}
+
 // Stop is run when the device is removed from the instance.
 func (d *unixCommon) Stop() (*deviceConfig.RunConfig, error) {
 	// Unregister any Unix event handlers for this device.
@@ -200,6 +232,20 @@ func (d *unixCommon) Stop() (*deviceConfig.RunConfig, error) {
 		PostHooks: []func() error{d.postStop},
 	}
 
+	if d.inst.Type() == instancetype.VM {
+		// Only ask for the device to be removed from the VM if it was passed through.
+		relativeDestPath := strings.TrimPrefix(unixDeviceDestPath(d.config), "/")
+		devName := storageDrivers.PathNameEncode(deviceJoinPath("unix", d.name, relativeDestPath))
+		if shared.PathExists(filepath.Join(d.inst.DevicesPath(), devName)) {
+			runConf.UnixDevice = append(runConf.UnixDevice, deviceConfig.UnixDeviceItem{
+				DeviceName: d.name,
+				Type:       d.config["type"],
+			})
+		}
+
+		return &runConf, nil
+	}
+
 	err = unixDeviceRemove(d.inst.DevicesPath(), "unix", d.name, "", &runConf)
 	if err != nil {
 		return nil, err
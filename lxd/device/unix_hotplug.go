@@ -4,6 +4,8 @@ package device
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"strings"
 
 	udev "github.com/farjump/go-libudev"
@@ -11,6 +13,8 @@ import (
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/state"
+	storageDrivers "github.com/lxc/lxd/lxd/storage/drivers"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/validate"
 )
@@ -27,6 +31,39 @@ func unixHotplugIsOurDevice(config deviceConfig.Device, unixHotplug *UnixHotplug
 	return true
 }
 
+// unixHotplugVMDeviceName returns the name used for a matching device passed through to a VM.
+// As several host devices can match, the name is derived from the host device path.
+func unixHotplugVMDeviceName(deviceName string, devPath string) string {
+	return deviceJoinPath(deviceName, storageDrivers.PathNameEncode(strings.TrimPrefix(devPath, "/")))
+}
+
+// unixHotplugVMDeviceCreate creates a matching device on the host for a VM and returns the item used to
+// pass it to QEMU.
+func unixHotplugVMDeviceCreate(s *state.State, devicesPath string, deviceName string, m deviceConfig.Device, subsystem string, major uint32, minor uint32, path string) (*deviceConfig.UnixDeviceItem, error) {
+	configCopy := deviceConfig.Device{}
+	for k, v := range m {
+		configCopy[k] = v
+	}
+
+	configCopy["type"] = "unix-char"
+	if subsystem == "block" {
+		configCopy["type"] = "unix-block"
+	}
+
+	configCopy["major"] = fmt.Sprintf("%d", major)
+	configCopy["minor"] = fmt.Sprintf("%d", minor)
+	configCopy["path"] = path
+
+	unixDev, err := unixVMDeviceCreate(s, devicesPath, deviceName, configCopy)
+	if err != nil {
+		return nil, err
+	}
+
+	unixDev.DeviceName = unixHotplugVMDeviceName(deviceName, path)
+
+	return unixDev, nil
+}
+
 type unixHotplug struct {
 	deviceCommon
 }
@@ -43,7 +80,7 @@ func (d *unixHotplug) isRequired() bool {
 
 // validateConfig checks the supplied config for correctness.
 func (d *unixHotplug) validateConfig(instConf instance.ConfigReader) error {
-	if !instanceSupported(instConf.Type(), instancetype.Container) {
+	if !instanceSupported(instConf.Type(), instancetype.Container, instancetype.VM) {
 		return ErrUnsupportedDevType
 	}
 
@@ -76,11 +113,16 @@ func (d *unixHotplug) Register() error {
 	devConfig := d.config
 	deviceName := d.name
 	state := d.state
+	instType := d.inst.Type()
 
 	// Handler for when a UnixHotplug event occurs.
 	f := func(e UnixHotplugEvent) (*deviceConfig.RunConfig, error) {
 		runConf := deviceConfig.RunConfig{}
 
+		if instType == instancetype.VM {
+			return unixHotplugVMEvent(state, devicesPath, deviceName, devConfig, e)
+		}
+
 		if e.Action == "add" {
 			if !unixHotplugIsOurDevice(devConfig, &e) {
 				return nil, nil
@@ -142,6 +184,16 @@ func (d *unixHotplug) Start() (*deviceConfig.RunConfig, error) {
 	major := uint32(devnum.Major())
 	minor := uint32(devnum.Minor())
 
+	if d.inst.Type() == instancetype.VM {
+		unixDev, err := unixHotplugVMDeviceCreate(d.state, d.inst.DevicesPath(), d.name, d.config, device.Subsystem(), major, minor, device.Devnode())
+		if err != nil {
+			return nil, err
+		}
+
+		runConf.UnixDevice = append(runConf.UnixDevice, *unixDev)
+		return &runConf, nil
+	}
+
 	// setup device
 	var err error
 	if device.Subsystem() == "block" {
@@ -165,6 +217,16 @@ func (d *unixHotplug) Stop() (*deviceConfig.RunConfig, error) {
 		PostHooks: []func() error{d.postStop},
 	}
 
+	if d.inst.Type() == instancetype.VM {
+		unixDevs, err := d.vmDevices()
+		if err != nil {
+			return nil, err
+		}
+
+		runConf.UnixDevice = unixDevs
+		return &runConf, nil
+	}
+
 	err := unixDeviceRemove(d.inst.DevicesPath(), "unix", d.name, "", &runConf)
 	if err != nil {
 		return nil, err
@@ -173,6 +235,86 @@ func (d *unixHotplug) Stop() (*deviceConfig.RunConfig, error) {
 	return &runConf, nil
 }
 
+// vmDevices returns the items needed to remove the matching devices currently passed through to the VM.
+func (d *unixHotplug) vmDevices() ([]deviceConfig.UnixDeviceItem, error) {
+	dents, err := ioutil.ReadDir(d.inst.DevicesPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	unixDevs := []deviceConfig.UnixDeviceItem{}
+	prefix := fmt.Sprintf("%s.", storageDrivers.PathNameEncode(deviceJoinPath("unix", d.name)))
+	for _, ent := range dents {
+		if !strings.HasPrefix(ent.Name(), prefix) {
+			continue
+		}
+
+		devType := "unix-char"
+		if ent.Mode()&os.ModeDevice != 0 && ent.Mode()&os.ModeCharDevice == 0 {
+			devType = "unix-block"
+		}
+
+		devPath := storageDrivers.PathNameDecode(strings.TrimPrefix(ent.Name(), prefix))
+		unixDevs = append(unixDevs, deviceConfig.UnixDeviceItem{
+			DeviceName: unixHotplugVMDeviceName(d.name, devPath),
+			Type:       devType,
+		})
+	}
+
+	return unixDevs, nil
+}
+
+// unixHotplugVMEvent handles a unix hotplug event for a VM, passing matching devices through to it or
+// removing them from it.
+func unixHotplugVMEvent(s *state.State, devicesPath string, deviceName string, devConfig deviceConfig.Device, e UnixHotplugEvent) (*deviceConfig.RunConfig, error) {
+	runConf := deviceConfig.RunConfig{}
+
+	if e.Action == "add" {
+		if !unixHotplugIsOurDevice(devConfig, &e) {
+			return nil, nil
+		}
+
+		unixDev, err := unixHotplugVMDeviceCreate(s, devicesPath, deviceName, devConfig, e.Subsystem, e.Major, e.Minor, e.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		runConf.UnixDevice = append(runConf.UnixDevice, *unixDev)
+	} else if e.Action == "remove" {
+		// Skip devices which weren't passed through by this device.
+		if !UnixDeviceExists(devicesPath, deviceJoinPath("unix", deviceName), e.Path) {
+			return nil, nil
+		}
+
+		devType := "unix-char"
+		if e.Subsystem == "block" {
+			devType = "unix-block"
+		}
+
+		runConf.UnixDevice = append(runConf.UnixDevice, deviceConfig.UnixDeviceItem{
+			DeviceName: unixHotplugVMDeviceName(deviceName, e.Path),
+			Type:       devType,
+		})
+
+		// Remove the host side device file once the device is removed from the VM.
+		relativeTargetPath := strings.TrimPrefix(e.Path, "/")
+		runConf.PostHooks = []func() error{func() error {
+			err := unixDeviceDeleteFiles(s, devicesPath, "unix", deviceName, relativeTargetPath)
+			if err != nil {
+				return fmt.Errorf("Failed to delete files for device '%s': %v", deviceName, err)
+			}
+
+			return nil
+		}}
+	}
+
+	return &runConf, nil
+}
+
 // postStop is run after the device is removed from the instance
 func (d *unixHotplug) postStop() error {
 	err := unixDeviceDeleteFiles(d.state, d.inst.DevicesPath(), "unix", d.name, "")
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
@@ -29,6 +30,13 @@ func usbIsOurDevice(config deviceConfig.Device, usb *USBEvent) bool {
 	return true
 }
 
+// usbVMDeviceName returns the name used for a USB device passed through to a virtual machine.
+// It combines the device name with the host bus and device numbers so that each matching USB
+// device gets its own unique name.
+func usbVMDeviceName(deviceName string, devPath string) string {
+	return fmt.Sprintf("%s-%s-%s", deviceName, filepath.Base(filepath.Dir(devPath)), filepath.Base(devPath))
+}
+
 type usb struct {
 	deviceCommon
 }
@@ -45,7 +53,7 @@ func (d *usb) isRequired() bool {
 
 // validateConfig checks the supplied config for correctness.
 func (d *usb) validateConfig(instConf instance.ConfigReader) error {
-	if !instanceSupported(instConf.Type(), instancetype.Container) {
+	if !instanceSupported(instConf.Type(), instancetype.Container, instancetype.VM) {
 		return ErrUnsupportedDevType
 	}
 
@@ -75,6 +83,35 @@ func (d *usb) Register() error {
 	deviceName := d.name
 	state := d.state
 
+	if d.inst.Type() == instancetype.VM {
+		// Handler for when a USB event occurs for a VM, the instance passes the device
+		// through to (or removes it from) the running QEMU process.
+		f := func(e USBEvent) (*deviceConfig.RunConfig, error) {
+			if !usbIsOurDevice(devConfig, &e) {
+				return nil, nil
+			}
+
+			usbDev := deviceConfig.USBDeviceItem{
+				DeviceName: usbVMDeviceName(deviceName, e.Path),
+			}
+
+			if e.Action == "add" {
+				usbDev.HostDevicePath = e.Path
+			} else if e.Action != "remove" {
+				return nil, nil
+			}
+
+			runConf := deviceConfig.RunConfig{}
+			runConf.USBDevice = append(runConf.USBDevice, usbDev)
+
+			return &runConf, nil
+		}
+
+		usbRegisterHandler(d.inst, d.name, f)
+
+		return nil
+	}
+
 	// Handler for when a USB event occurs.
 	f := func(e USBEvent) (*deviceConfig.RunConfig, error) {
 		if !usbIsOurDevice(devConfig, &e) {
@@ -126,6 +163,25 @@ func (d *usb) Start() (*deviceConfig.RunConfig, error) {
 	runConf := deviceConfig.RunConfig{}
 	runConf.PostHooks = []func() error{d.Register}
 
+	if d.inst.Type() == instancetype.VM {
+		for _, usb := range usbs {
+			if !usbIsOurDevice(d.config, &usb) {
+				continue
+			}
+
+			runConf.USBDevice = append(runConf.USBDevice, deviceConfig.USBDeviceItem{
+				DeviceName:     usbVMDeviceName(d.name, usb.Path),
+				HostDevicePath: usb.Path,
+			})
+		}
+
+		if d.isRequired() && len(runConf.USBDevice) <= 0 {
+			return nil, fmt.Errorf("Required USB device not found")
+		}
+
+		return &runConf, nil
+	}
+
 	for _, usb := range usbs {
 		if !usbIsOurDevice(d.config, &usb) {
 			continue
@@ -149,6 +205,27 @@ func (d *usb) Stop() (*deviceConfig.RunConfig, error) {
 	// Unregister any USB event handlers for this device.
 	usbUnregisterHandler(d.inst, d.name)
 
+	if d.inst.Type() == instancetype.VM {
+		usbs, err := d.loadUsb()
+		if err != nil {
+			return nil, err
+		}
+
+		// Request removal of all matching USB devices from the VM.
+		runConf := deviceConfig.RunConfig{}
+		for _, usb := range usbs {
+			if !usbIsOurDevice(d.config, &usb) {
+				continue
+			}
+
+			runConf.USBDevice = append(runConf.USBDevice, deviceConfig.USBDeviceItem{
+				DeviceName: usbVMDeviceName(d.name, usb.Path),
+			})
+		}
+
+		return &runConf, nil
+	}
+
 	runConf := deviceConfig.RunConfig{
 		PostHooks: []func() error{d.postStop},
 	}
@@ -0,0 +1,186 @@
+package dns
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// transferBatchSize is the maximum number of records sent per message during zone transfers.
+const transferBatchSize = 100
+
+type dnsHandler struct {
+	server *Server
+}
+
+func (d dnsHandler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	msg := dns.Msg{}
+	msg.SetReply(r)
+	msg.Authoritative = true
+
+	// Only single question queries are supported.
+	if len(r.Question) != 1 {
+		msg.Rcode = dns.RcodeFormatError
+		w.WriteMsg(&msg)
+		return
+	}
+
+	question := r.Question[0]
+	if question.Qclass != dns.ClassINET {
+		msg.Rcode = dns.RcodeRefused
+		w.WriteMsg(&msg)
+		return
+	}
+
+	// Find the zone the question belongs to.
+	zone, err := d.findZone(question.Name)
+	if err != nil {
+		logger.Errorf("Failed loading DNS zone for %q: %v", question.Name, err)
+		msg.Rcode = dns.RcodeServerFailure
+		w.WriteMsg(&msg)
+		return
+	}
+
+	if zone == nil || len(zone.Records) == 0 {
+		msg.Rcode = dns.RcodeRefused
+		w.WriteMsg(&msg)
+		return
+	}
+
+	// Only answer the peers of the zone.
+	keyName, allowed := d.isAllowed(w, r, zone)
+	if !allowed {
+		msg.Rcode = dns.RcodeRefused
+		w.WriteMsg(&msg)
+		return
+	}
+
+	if question.Qtype == dns.TypeAXFR || question.Qtype == dns.TypeIXFR {
+		// Zone transfers are only supported over TCP. Incremental transfers are answered with a full transfer.
+		if w.LocalAddr().Network() != "tcp" {
+			msg.Rcode = dns.RcodeRefused
+			w.WriteMsg(&msg)
+			return
+		}
+
+		d.transfer(w, r, zone)
+		return
+	}
+
+	if keyName != "" {
+		msg.SetTsig(keyName, r.IsTsig().Algorithm, 300, time.Now().Unix())
+	}
+
+	name := strings.ToLower(question.Name)
+	nameFound := false
+	for _, rr := range zone.Records {
+		if strings.ToLower(rr.Header().Name) != name {
+			continue
+		}
+
+		nameFound = true
+		if question.Qtype == dns.TypeANY || rr.Header().Rrtype == question.Qtype {
+			msg.Answer = append(msg.Answer, rr)
+		}
+	}
+
+	// Include the SOA record in negative answers.
+	if len(msg.Answer) == 0 {
+		if !nameFound {
+			msg.Rcode = dns.RcodeNameError
+		}
+
+		msg.Ns = []dns.RR{zone.Records[0]}
+	}
+
+	w.WriteMsg(&msg)
+}
+
+// findZone returns the zone the given name belongs to, looking for the closest enclosing zone.
+func (d dnsHandler) findZone(name string) (*Zone, error) {
+	labels := dns.SplitDomainName(strings.ToLower(name))
+
+	for i := range labels {
+		zone, err := d.server.zone(strings.Join(labels[i:], "."))
+		if err != nil {
+			return nil, err
+		}
+
+		if zone != nil {
+			return zone, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// isAllowed checks whether the request comes from a peer of the zone and was signed with the peer's TSIG key
+// when it has one. Returns the name of the key used.
+func (d dnsHandler) isAllowed(w dns.ResponseWriter, r *dns.Msg, zone *Zone) (string, bool) {
+	remoteHost, _, err := net.SplitHostPort(w.RemoteAddr().String())
+	if err != nil {
+		return "", false
+	}
+
+	remoteIP := net.ParseIP(remoteHost)
+	tsig := r.IsTsig()
+
+	for _, peer := range zone.Peers {
+		if !net.ParseIP(peer.Address).Equal(remoteIP) {
+			continue
+		}
+
+		if peer.KeyName == "" {
+			return "", true
+		}
+
+		if tsig != nil && strings.EqualFold(tsig.Hdr.Name, peer.KeyName) && w.TsigStatus() == nil {
+			return tsig.Hdr.Name, true
+		}
+	}
+
+	return "", false
+}
+
+// transfer sends the whole zone to the peer, surrounded by its SOA record.
+func (d dnsHandler) transfer(w dns.ResponseWriter, r *dns.Msg, zone *Zone) {
+	records := append([]dns.RR{}, zone.Records...)
+	records = append(records, zone.Records[0])
+
+	ch := make(chan *dns.Envelope)
+	tr := new(dns.Transfer)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		err := tr.Out(w, r, ch)
+		if err != nil {
+			logger.Errorf("Failed transferring DNS zone %q: %v", zone.Name, err)
+
+			// Drain the remaining batches.
+			for range ch {
+			}
+		}
+	}()
+
+	// Send the records in batches to keep each message within the maximum DNS message size.
+	for len(records) > 0 {
+		batch := len(records)
+		if batch > transferBatchSize {
+			batch = transferBatchSize
+		}
+
+		ch <- &dns.Envelope{RR: records[:batch]}
+		records = records[batch:]
+	}
+
+	close(ch)
+	wg.Wait()
+}
@@ -0,0 +1,258 @@
+package dns
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// zoneCacheExpiry is how long a generated zone is served from the cache. Changes made on other cluster members,
+// such as their dynamic DHCP leases, aren't notified so zones are also generated again once they are this old.
+const zoneCacheExpiry = time.Minute
+
+// ZoneRetriever returns the named zone or nil if no such zone exists.
+type ZoneRetriever func(name string) (*Zone, error)
+
+// Zone represents a DNS zone along with the peers allowed to query it.
+type Zone struct {
+	Name string
+
+	// Records of the zone, starting with its SOA record.
+	Records []dns.RR
+
+	Peers []ZonePeer
+}
+
+// cachedZone represents a generated zone along with its SOA serial.
+type cachedZone struct {
+	zone      *Zone
+	serial    uint32
+	generated time.Time
+}
+
+// ZonePeer represents a peer allowed to query a zone.
+type ZonePeer struct {
+	Address string
+
+	// Name of the TSIG key the peer must sign its requests with, if any.
+	KeyName string
+}
+
+// Server represents a DNS server instance.
+type Server struct {
+	tcpDNS *dns.Server
+	udpDNS *dns.Server
+
+	address       string
+	tsigKeys      map[string]string
+	zoneRetriever ZoneRetriever
+
+	mu sync.Mutex
+
+	zones   map[string]*cachedZone
+	zonesMu sync.Mutex
+}
+
+// NewServer returns a new server instance.
+func NewServer(zoneRetriever ZoneRetriever) *Server {
+	return &Server{
+		tsigKeys:      map[string]string{},
+		zoneRetriever: zoneRetriever,
+		zones:         map[string]*cachedZone{},
+	}
+}
+
+// Start sets up the DNS listener on the given address, replacing any existing listener.
+func (s *Server) Start(address string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.start(address)
+}
+
+func (s *Server) start(address string) error {
+	// Stop any existing listener.
+	s.stop()
+
+	if address == "" {
+		return nil
+	}
+
+	// Use the default DNS port if none was provided.
+	_, _, err := net.SplitHostPort(address)
+	if err != nil {
+		address = net.JoinHostPort(strings.Trim(address, "[]"), "53")
+	}
+
+	handler := dnsHandler{server: s}
+
+	// Bind the listeners here so that errors are returned to the caller.
+	tcpListener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("Failed to bind TCP DNS listener on %q: %v", address, err)
+	}
+
+	udpConn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		tcpListener.Close()
+		return fmt.Errorf("Failed to bind UDP DNS listener on %q: %v", address, err)
+	}
+
+	s.tcpDNS = &dns.Server{Listener: tcpListener, Handler: handler, TsigSecret: s.tsigKeys}
+	s.udpDNS = &dns.Server{PacketConn: udpConn, Handler: handler, TsigSecret: s.tsigKeys}
+	s.address = address
+
+	for _, srv := range []*dns.Server{s.tcpDNS, s.udpDNS} {
+		started := make(chan struct{})
+		errCh := make(chan error, 1)
+		srv.NotifyStartedFunc = func() { close(started) }
+
+		go func(srv *dns.Server) {
+			err := srv.ActivateAndServe()
+			if err != nil {
+				logger.Errorf("DNS server on %q stopped: %v", address, err)
+			}
+
+			errCh <- err
+		}(srv)
+
+		// Wait for the server to be running so that it can be shut down.
+		select {
+		case <-started:
+		case err := <-errCh:
+			// Closing the sockets stops any server already running.
+			tcpListener.Close()
+			udpConn.Close()
+			s.tcpDNS = nil
+			s.udpDNS = nil
+			s.address = ""
+
+			return fmt.Errorf("Failed to start DNS server on %q: %v", address, err)
+		}
+	}
+
+	logger.Infof("Started DNS server on %q", address)
+
+	return nil
+}
+
+// Stop tears down the DNS listener.
+func (s *Server) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stop()
+}
+
+func (s *Server) stop() error {
+	if s.tcpDNS == nil && s.udpDNS == nil {
+		return nil
+	}
+
+	var errs []string
+	for _, srv := range []*dns.Server{s.tcpDNS, s.udpDNS} {
+		err := srv.Shutdown()
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	s.tcpDNS = nil
+	s.udpDNS = nil
+	s.address = ""
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Failed to stop DNS server: %s", strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// UpdateTSIG replaces the TSIG keys (key name to base64 secret) used to authenticate peers.
+// The listener is restarted to apply the new keys.
+func (s *Server) UpdateTSIG(keys map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tsigKeys = keys
+
+	if s.address == "" {
+		return nil
+	}
+
+	return s.start(s.address)
+}
+
+// InvalidateZones marks the cached zones as stale so that they are generated again on their next query.
+// It does nothing on a nil server so that callers without a DNS server don't need to check for one.
+func (s *Server) InvalidateZones() {
+	if s == nil {
+		return
+	}
+
+	s.zonesMu.Lock()
+	defer s.zonesMu.Unlock()
+
+	for _, cached := range s.zones {
+		cached.generated = time.Time{}
+	}
+}
+
+// zone returns the named zone from the cache, generating it if it is missing or stale. The SOA serial of the
+// zone is only incremented when its records change, so that peers only transfer the zone when needed.
+func (s *Server) zone(name string) (*Zone, error) {
+	s.zonesMu.Lock()
+	defer s.zonesMu.Unlock()
+
+	cached := s.zones[name]
+	if cached != nil && time.Since(cached.generated) < zoneCacheExpiry {
+		return cached.zone, nil
+	}
+
+	zone, err := s.zoneRetriever(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached == nil {
+		cached = &cachedZone{serial: uint32(time.Now().Unix())}
+		s.zones[name] = cached
+	}
+
+	if zone != nil && len(zone.Records) > 0 {
+		soa, ok := zone.Records[0].(*dns.SOA)
+		if ok {
+			soa.Serial = cached.serial
+			if cached.zone == nil || !sameRecords(cached.zone.Records, zone.Records) {
+				cached.serial++
+				soa.Serial = cached.serial
+			}
+		}
+	}
+
+	cached.zone = zone
+	cached.generated = time.Now()
+
+	return zone, nil
+}
+
+// sameRecords returns whether both lists contain the same records in the same order.
+func sameRecords(a []dns.RR, b []dns.RR) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].String() != b[i].String() {
+			return false
+		}
+	}
+
+	return true
+}
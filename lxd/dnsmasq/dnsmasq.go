@@ -66,6 +66,38 @@ func RemoveStaticEntry(network string, projectName string, instanceName string)
 	return nil
 }
 
+// UpdateReservationEntry writes a single dhcp-host line for a DHCP reservation on a network.
+// The entry is named after the MAC address, which can't clash with instance entries as instance names can't
+// contain colons.
+func UpdateReservationEntry(network string, netConfig map[string]string, hwaddr string, hostname string, ipv4Address string, ipv6Address string) error {
+	hwaddr = strings.ToLower(hwaddr)
+	line := hwaddr
+
+	// Generate the dhcp-host line
+	if ipv4Address != "" {
+		line += fmt.Sprintf(",%s", ipv4Address)
+	}
+
+	if ipv6Address != "" {
+		line += fmt.Sprintf(",[%s]", ipv6Address)
+	}
+
+	if hostname != "" && (netConfig["dns.mode"] == "" || netConfig["dns.mode"] == "managed") {
+		line += fmt.Sprintf(",%s", hostname)
+	}
+
+	if line == hwaddr {
+		return nil
+	}
+
+	err := ioutil.WriteFile(shared.VarPath("networks", network, "dnsmasq.hosts", hwaddr), []byte(line+"\n"), 0644)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // Kill kills dnsmasq for a particular network (or optionally reloads it).
 func Kill(name string, reload bool) error {
 	pidPath := shared.VarPath("networks", name, "dnsmasq.pid")
@@ -55,6 +55,14 @@ type Config struct {
 	//
 	// It can be updated after the endpoints are up using UpdateDebugAddress().
 	DebugAddress string
+
+	// StorageBucketsAddress sets the address for the S3 endpoint serving storage buckets.
+	//
+	// It can be updated after the endpoints are up using StorageBucketsUpdateAddress().
+	StorageBucketsAddress string
+
+	// HTTP server handling S3 requests for storage buckets.
+	StorageBucketsServer *http.Server
 }
 
 // Up brings up all applicable LXD endpoints and starts accepting HTTP
@@ -117,6 +125,9 @@ func Up(config *Config) (*Endpoints, error) {
 	if config.Cert == nil {
 		return nil, fmt.Errorf("No TLS certificate configured")
 	}
+	if config.StorageBucketsAddress != "" && config.StorageBucketsServer == nil {
+		return nil, fmt.Errorf("No storage buckets server configured")
+	}
 
 	endpoints := &Endpoints{
 		systemdListenFDsStart: util.SystemdListenFDsStart,
@@ -158,6 +169,8 @@ func (e *Endpoints) up(config *Config) error {
 		network: config.RestServer,
 		cluster: config.RestServer,
 		pprof:   pprofCreateServer(),
+
+		storageBuckets: config.StorageBucketsServer,
 	}
 	e.cert = config.Cert
 	e.inherited = map[kind]bool{}
@@ -233,6 +246,17 @@ func (e *Endpoints) up(config *Config) error {
 		e.serveHTTP(pprof)
 	}
 
+	if config.StorageBucketsAddress != "" {
+		// Errors here are not fatal and are just logged.
+		e.listeners[storageBuckets], err = networkCreateListener(config.StorageBucketsAddress, e.cert)
+		if err != nil {
+			logger.Error("Cannot listen on storage buckets socket, skipping...", log.Ctx{"err": err})
+		} else {
+			logger.Infof("Starting storage buckets handler:")
+			e.serveHTTP(storageBuckets)
+		}
+	}
+
 	logger.Infof("Starting /dev/lxd handler:")
 	e.serveHTTP(devlxd)
 
@@ -285,6 +309,14 @@ func (e *Endpoints) Down() error {
 		}
 	}
 
+	if e.listeners[storageBuckets] != nil {
+		logger.Infof("Stopping storage buckets handler:")
+		err := e.closeListener(storageBuckets)
+		if err != nil {
+			return err
+		}
+	}
+
 	if e.tomb != nil {
 		e.tomb.Kill(nil)
 		e.tomb.Wait()
@@ -367,6 +399,7 @@ const (
 	network
 	pprof
 	cluster
+	storageBuckets
 )
 
 // Human-readable descriptions of the various kinds of endpoints.
@@ -376,4 +409,6 @@ var descriptions = map[kind]string{
 	network: "TCP socket",
 	pprof:   "pprof socket",
 	cluster: "cluster socket",
+
+	storageBuckets: "storage buckets socket",
 }
@@ -137,17 +137,21 @@ func (e *Endpoints) NetworkUpdateCert(cert *shared.CertInfo) {
 	defer e.mu.Unlock()
 	e.cert = cert
 	listener, ok := e.listeners[network]
-	if !ok {
-		return
+	if ok {
+		listener.(*networkListener).Config(cert)
 	}
-	listener.(*networkListener).Config(cert)
 
 	// Update the cluster listener too, if enabled.
 	listener, ok = e.listeners[cluster]
-	if !ok {
-		return
+	if ok {
+		listener.(*networkListener).Config(cert)
+	}
+
+	// Update the storage buckets listener too, if enabled.
+	listener, ok = e.listeners[storageBuckets]
+	if ok {
+		listener.(*networkListener).Config(cert)
 	}
-	listener.(*networkListener).Config(cert)
 }
 
 // Create a new net.Listener bound to the tcp socket of the network endpoint.
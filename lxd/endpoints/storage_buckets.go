@@ -0,0 +1,90 @@
+package endpoints
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// StorageBucketsAddress returns the network addresss of the storage buckets endpoint, or an empty string if
+// there's no storage buckets endpoint.
+func (e *Endpoints) StorageBucketsAddress() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	listener := e.listeners[storageBuckets]
+	if listener == nil {
+		return ""
+	}
+
+	return listener.Addr().String()
+}
+
+// StorageBucketsUpdateAddress updates the address for the storage buckets endpoint, shutting it down and
+// restarting it.
+func (e *Endpoints) StorageBucketsUpdateAddress(address string) error {
+	if address != "" {
+		address = util.CanonicalNetworkAddress(address)
+	}
+
+	oldAddress := e.StorageBucketsAddress()
+	if address == oldAddress {
+		return nil
+	}
+
+	logger.Infof("Update storage buckets address")
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// Close the previous socket
+	e.closeListener(storageBuckets)
+
+	// If turning off listening, we're done
+	if address == "" {
+		return nil
+	}
+
+	// Attempt to setup the new listening socket
+	getListener := func(address string) (*net.Listener, error) {
+		var err error
+		var listener net.Listener
+
+		for i := 0; i < 10; i++ { // Ten retries over a second seems reasonable.
+			listener, err = net.Listen("tcp", address)
+			if err == nil {
+				break
+			}
+
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Cannot listen on storage buckets socket: %v", err)
+		}
+
+		return &listener, nil
+	}
+
+	listener, err := getListener(address)
+	if err != nil {
+		// Attempt to revert to the previous address
+		if oldAddress != "" {
+			listener, err1 := getListener(oldAddress)
+			if err1 == nil {
+				e.listeners[storageBuckets] = networkTLSListener(*listener, e.cert)
+				e.serveHTTP(storageBuckets)
+			}
+		}
+
+		return err
+	}
+
+	e.listeners[storageBuckets] = networkTLSListener(*listener, e.cert)
+	e.serveHTTP(storageBuckets)
+
+	return nil
+}
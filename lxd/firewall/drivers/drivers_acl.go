@@ -0,0 +1,88 @@
+package drivers
+
+import (
+	"net"
+	"strings"
+)
+
+// ACLRule represents an ACL rule that can be added to a firewall.
+type ACLRule struct {
+	Direction       string // Either "ingress" or "egress".
+	Action          string // Either "allow", "drop" or "reject".
+	Source          string // Comma separated list of IPs and subnets.
+	Destination     string // Comma separated list of IPs and subnets.
+	Protocol        string // Either "", "tcp", "udp", "icmp4" or "icmp6".
+	SourcePort      string // Comma separated list of ports and port ranges.
+	DestinationPort string // Comma separated list of ports and port ranges.
+	ICMPType        string
+	ICMPCode        string
+}
+
+// aclRuleSubjects splits a comma separated list of IPs and subnets into IPv4 and IPv6 lists.
+func aclRuleSubjects(subjects string) ([]string, []string) {
+	var ipv4, ipv6 []string
+
+	for _, subject := range strings.Split(subjects, ",") {
+		subject = strings.TrimSpace(subject)
+		if subject == "" {
+			continue
+		}
+
+		ip := net.ParseIP(strings.SplitN(subject, "/", 2)[0])
+		if ip == nil {
+			continue
+		}
+
+		if ip.To4() != nil {
+			ipv4 = append(ipv4, subject)
+		} else {
+			ipv6 = append(ipv6, subject)
+		}
+	}
+
+	return ipv4, ipv6
+}
+
+// aclRuleFamilySubjects returns the source and destination subjects of the rule for the specified IP version,
+// and whether the rule applies to that IP version at all.
+func aclRuleFamilySubjects(rule ACLRule, ipVersion uint) ([]string, []string, bool) {
+	if (rule.Protocol == "icmp4" && ipVersion != 4) || (rule.Protocol == "icmp6" && ipVersion != 6) {
+		return nil, nil, false
+	}
+
+	srcIPv4, srcIPv6 := aclRuleSubjects(rule.Source)
+	dstIPv4, dstIPv6 := aclRuleSubjects(rule.Destination)
+
+	src, dst := srcIPv4, dstIPv4
+	if ipVersion == 6 {
+		src, dst = srcIPv6, dstIPv6
+	}
+
+	// If a source or destination was specified but none of its entries are of this IP version then the rule
+	// cannot match any traffic of this IP version.
+	if (rule.Source != "" && len(src) == 0) || (rule.Destination != "" && len(dst) == 0) {
+		return nil, nil, false
+	}
+
+	return src, dst, true
+}
+
+// aclRulesOrdered returns the rules with drop and reject rules placed before allow rules, so that they take
+// precedence regardless of the order they were defined in.
+func aclRulesOrdered(rules []ACLRule) []ACLRule {
+	ordered := make([]ACLRule, 0, len(rules))
+
+	for _, rule := range rules {
+		if rule.Action != "allow" {
+			ordered = append(ordered, rule)
+		}
+	}
+
+	for _, rule := range rules {
+		if rule.Action == "allow" {
+			ordered = append(ordered, rule)
+		}
+	}
+
+	return ordered
+}
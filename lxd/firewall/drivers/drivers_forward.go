@@ -0,0 +1,93 @@
+package drivers
+
+import (
+	"net"
+)
+
+// AddressForward represents a NAT address forward that can be added to a firewall.
+type AddressForward struct {
+	ListenAddress net.IP
+	Protocol      string   // Either "tcp" or "udp", or empty when forwarding all traffic.
+	ListenPorts   []uint64 // Empty when forwarding all traffic.
+	TargetAddress net.IP
+	TargetPorts   []uint64 // Either empty (same as listen ports), a single port or one port per listen port.
+}
+
+// addressForwardTargetPort returns the target port for the listen port at the given index of the forward.
+func addressForwardTargetPort(forward AddressForward, index int) uint64 {
+	switch len(forward.TargetPorts) {
+	case 0:
+		return forward.ListenPorts[index]
+	case 1:
+		return forward.TargetPorts[0]
+	}
+
+	return forward.TargetPorts[index]
+}
+
+// addressForwardPortRange represents a range of listen ports of an address forward and the target ports they
+// are forwarded to.
+type addressForwardPortRange struct {
+	listenStart uint64
+	listenEnd   uint64
+	targetStart uint64
+	targetEnd   uint64
+}
+
+// addressForwardPortRanges groups the ports of the forward into as few ranges as possible. Consecutive listen
+// ports are grouped when they all forward to the same single target port or to the same ports on the target.
+// DNAT to a port range doesn't preserve the offset of the listen port within its range, so listen ports that
+// are forwarded to different target ports each get their own range.
+func addressForwardPortRanges(forward AddressForward) []addressForwardPortRange {
+	ranges := []addressForwardPortRange{}
+
+	for i, listenPort := range forward.ListenPorts {
+		targetPort := addressForwardTargetPort(forward, i)
+
+		if len(ranges) > 0 {
+			last := &ranges[len(ranges)-1]
+
+			if listenPort == last.listenEnd+1 {
+				if len(forward.TargetPorts) == 1 && targetPort == last.targetEnd {
+					last.listenEnd = listenPort
+					continue
+				}
+
+				if listenPort == targetPort && last.listenStart == last.targetStart && targetPort == last.targetEnd+1 {
+					last.listenEnd = listenPort
+					last.targetEnd = targetPort
+					continue
+				}
+			}
+		}
+
+		ranges = append(ranges, addressForwardPortRange{
+			listenStart: listenPort,
+			listenEnd:   listenPort,
+			targetStart: targetPort,
+			targetEnd:   targetPort,
+		})
+	}
+
+	return ranges
+}
+
+// addressForwardsOrdered returns the forwards with port specific forwards placed before those forwarding all
+// traffic, so that they take precedence.
+func addressForwardsOrdered(forwards []AddressForward) []AddressForward {
+	ordered := make([]AddressForward, 0, len(forwards))
+
+	for _, forward := range forwards {
+		if len(forward.ListenPorts) > 0 {
+			ordered = append(ordered, forward)
+		}
+	}
+
+	for _, forward := range forwards {
+		if len(forward.ListenPorts) == 0 {
+			ordered = append(ordered, forward)
+		}
+	}
+
+	return ordered
+}
@@ -274,6 +274,279 @@ func (d Nftables) NetworkClear(networkName string, ipVersion uint) error {
 	return nil
 }
 
+// NetworkApplyACLRules applies the ACL rules to traffic forwarded to and from the network, to traffic between
+// the network and the host and to traffic bridged between the instances connected to the network. Traffic not
+// matching any of the rules is rejected, except for the DHCP, DNS and IPv6 neighbour discovery traffic the network
+// needs to operate. Existing ACL rules for the network are replaced.
+func (d Nftables) NetworkApplyACLRules(networkName string, rules []ACLRule) error {
+	err := d.NetworkClearACLRules(networkName)
+	if err != nil {
+		return err
+	}
+
+	egressMatch := fmt.Sprintf(`iifname "%s"`, networkName)
+	ingressMatch := fmt.Sprintf(`oifname "%s"`, networkName)
+	bridgeMatch := fmt.Sprintf(`meta ibrname "%s"`, networkName)
+
+	fwdRules := []string{
+		fmt.Sprintf("%s ct state established,related accept", egressMatch),
+		fmt.Sprintf("%s ct state established,related accept", ingressMatch),
+	}
+
+	inRules := []string{
+		fmt.Sprintf("%s ct state established,related accept", egressMatch),
+		fmt.Sprintf("%s udp dport {67, 547} accept", egressMatch),
+		fmt.Sprintf("%s udp dport 53 accept", egressMatch),
+		fmt.Sprintf("%s tcp dport 53 accept", egressMatch),
+		fmt.Sprintf("%s icmpv6 type {nd-router-solicit, nd-neighbor-solicit, nd-neighbor-advert} accept", egressMatch),
+	}
+
+	outRules := []string{
+		fmt.Sprintf("%s ct state established,related accept", ingressMatch),
+		fmt.Sprintf("%s udp sport {67, 547} accept", ingressMatch),
+		fmt.Sprintf("%s icmpv6 type {nd-router-advert, nd-neighbor-solicit, nd-neighbor-advert} accept", ingressMatch),
+	}
+
+	bridgeRules := []string{
+		fmt.Sprintf("%s ct state established,related accept", bridgeMatch),
+		fmt.Sprintf("%s icmpv6 type {nd-neighbor-solicit, nd-neighbor-advert} accept", bridgeMatch),
+	}
+
+	for _, rule := range aclRulesOrdered(rules) {
+		if rule.Direction == "ingress" {
+			fwdRules = append(fwdRules, d.aclRule(ingressMatch, rule)...)
+			outRules = append(outRules, d.aclRule(ingressMatch, rule)...)
+		} else {
+			fwdRules = append(fwdRules, d.aclRule(egressMatch, rule)...)
+			inRules = append(inRules, d.aclRule(egressMatch, rule)...)
+		}
+
+		// Traffic between instances of the network is both egress and ingress traffic.
+		bridgeRules = append(bridgeRules, d.aclRule(bridgeMatch, rule)...)
+	}
+
+	fwdRules = append(fwdRules, fmt.Sprintf("%s reject", egressMatch), fmt.Sprintf("%s reject", ingressMatch))
+	inRules = append(inRules, fmt.Sprintf("%s reject", egressMatch))
+	outRules = append(outRules, fmt.Sprintf("%s reject", ingressMatch))
+
+	// Only reject IP traffic between instances, leaving ARP and other non-IP traffic alone.
+	bridgeRules = append(bridgeRules, fmt.Sprintf("%s ether type {ip, ip6} reject", bridgeMatch))
+
+	tplFields := map[string]interface{}{
+		"namespace":      nftablesNamespace,
+		"chainSeparator": nftablesChainSeparator,
+		"networkName":    networkName,
+		"family":         "inet",
+		"fwdRules":       fwdRules,
+		"inRules":        inRules,
+		"outRules":       outRules,
+	}
+
+	err = d.applyNftConfig(nftablesNetACLRules, tplFields)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding ACL rules for network %q", networkName)
+	}
+
+	tplFields = map[string]interface{}{
+		"namespace":      nftablesNamespace,
+		"chainSeparator": nftablesChainSeparator,
+		"networkName":    networkName,
+		"family":         "bridge",
+		"rules":          bridgeRules,
+	}
+
+	err = d.applyNftConfig(nftablesNetACLBridgeRules, tplFields)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding bridge ACL rules for network %q", networkName)
+	}
+
+	return nil
+}
+
+// NetworkClearACLRules removes the ACL rules of the network.
+func (d Nftables) NetworkClearACLRules(networkName string) error {
+	err := d.removeChains([]string{"inet"}, networkName, "aclfwd", "aclin", "aclout")
+	if err != nil {
+		return errors.Wrapf(err, "Failed clearing ACL rules for network %q", networkName)
+	}
+
+	err = d.removeChains([]string{"bridge"}, networkName, "aclbr")
+	if err != nil {
+		return errors.Wrapf(err, "Failed clearing bridge ACL rules for network %q", networkName)
+	}
+
+	return nil
+}
+
+// aclRule converts an ACL rule into nftables rules, each starting with the supplied interface match. A rule with
+// IPv4 and IPv6 subjects results in a rule for each IP family.
+func (d Nftables) aclRule(ifMatch string, rule ACLRule) []string {
+	action := rule.Action
+	if action == "allow" {
+		action = "accept"
+	}
+
+	// Rules without any IP specific criteria can be matched against both IP families at once.
+	ipVersions := []uint{4, 6}
+	if rule.Source == "" && rule.Destination == "" && !shared.StringInSlice(rule.Protocol, []string{"icmp4", "icmp6"}) {
+		ipVersions = []uint{0}
+	}
+
+	nftRules := []string{}
+	for _, ipVersion := range ipVersions {
+		args := []string{ifMatch}
+
+		if ipVersion != 0 {
+			src, dst, ok := aclRuleFamilySubjects(rule, ipVersion)
+			if !ok {
+				continue
+			}
+
+			family := "ip"
+			if ipVersion == 6 {
+				family = "ip6"
+			}
+
+			if len(src) > 0 {
+				args = append(args, fmt.Sprintf("%s saddr {%s}", family, strings.Join(src, ", ")))
+			}
+
+			if len(dst) > 0 {
+				args = append(args, fmt.Sprintf("%s daddr {%s}", family, strings.Join(dst, ", ")))
+			}
+		}
+
+		switch rule.Protocol {
+		case "tcp", "udp":
+			if rule.SourcePort == "" && rule.DestinationPort == "" {
+				args = append(args, fmt.Sprintf("meta l4proto %s", rule.Protocol))
+			}
+
+			if rule.SourcePort != "" {
+				args = append(args, fmt.Sprintf("%s sport {%s}", rule.Protocol, d.aclPorts(rule.SourcePort)))
+			}
+
+			if rule.DestinationPort != "" {
+				args = append(args, fmt.Sprintf("%s dport {%s}", rule.Protocol, d.aclPorts(rule.DestinationPort)))
+			}
+		case "icmp4", "icmp6":
+			icmp, l4proto := "icmp", "icmp"
+			if rule.Protocol == "icmp6" {
+				icmp, l4proto = "icmpv6", "ipv6-icmp"
+			}
+
+			if rule.ICMPType == "" {
+				args = append(args, fmt.Sprintf("meta l4proto %s", l4proto))
+			} else {
+				args = append(args, fmt.Sprintf("%s type %s", icmp, rule.ICMPType))
+			}
+
+			if rule.ICMPCode != "" {
+				args = append(args, fmt.Sprintf("%s code %s", icmp, rule.ICMPCode))
+			}
+		}
+
+		args = append(args, action)
+		nftRules = append(nftRules, strings.Join(args, " "))
+	}
+
+	return nftRules
+}
+
+// aclPorts converts a comma separated list of ports and port ranges into nftables set elements.
+func (d Nftables) aclPorts(ports string) string {
+	elements := []string{}
+	for _, port := range strings.Split(ports, ",") {
+		elements = append(elements, strings.TrimSpace(port))
+	}
+
+	return strings.Join(elements, ", ")
+}
+
+// NetworkApplyForwards applies the address forwards of the network, replacing any existing ones.
+func (d Nftables) NetworkApplyForwards(networkName string, forwards []AddressForward) error {
+	err := d.NetworkClearForwards(networkName)
+	if err != nil {
+		return err
+	}
+
+	for _, family := range []string{"ip", "ip6"} {
+		var dnatRules, snatRules []string
+
+		for _, forward := range addressForwardsOrdered(forwards) {
+			forwardFamily := "ip"
+			if forward.ListenAddress.To4() == nil {
+				forwardFamily = "ip6"
+			}
+
+			if forwardFamily != family {
+				continue
+			}
+
+			// Generate the target address in the format nftables expects when combined with a port.
+			targetHost := forward.TargetAddress.String()
+			if family == "ip6" {
+				targetHost = fmt.Sprintf("[%s]", targetHost)
+			}
+
+			if len(forward.ListenPorts) == 0 {
+				dnatRules = append(dnatRules, fmt.Sprintf("%s daddr %s dnat to %s", family, forward.ListenAddress, forward.TargetAddress))
+
+				// Allow the target to reach itself using the listen address.
+				snatRules = append(snatRules, fmt.Sprintf("%s saddr %s %s daddr %s masquerade", family, forward.TargetAddress, family, forward.TargetAddress))
+				continue
+			}
+
+			for _, portRange := range addressForwardPortRanges(forward) {
+				listenPorts := d.forwardPortRange(portRange.listenStart, portRange.listenEnd)
+				targetPorts := d.forwardPortRange(portRange.targetStart, portRange.targetEnd)
+
+				dnatRules = append(dnatRules, fmt.Sprintf("%s daddr %s %s dport %s dnat to %s:%s", family, forward.ListenAddress, forward.Protocol, listenPorts, targetHost, targetPorts))
+				snatRules = append(snatRules, fmt.Sprintf("%s saddr %s %s daddr %s %s dport %s masquerade", family, forward.TargetAddress, family, forward.TargetAddress, forward.Protocol, targetPorts))
+			}
+		}
+
+		if len(dnatRules) == 0 {
+			continue
+		}
+
+		tplFields := map[string]interface{}{
+			"namespace":      nftablesNamespace,
+			"chainSeparator": nftablesChainSeparator,
+			"networkName":    networkName,
+			"family":         family,
+			"dnatRules":      dnatRules,
+			"snatRules":      snatRules,
+		}
+
+		err = d.applyNftConfig(nftablesNetForwards, tplFields)
+		if err != nil {
+			return errors.Wrapf(err, "Failed adding address forwards for network %q (%s)", networkName, family)
+		}
+	}
+
+	return nil
+}
+
+// forwardPortRange returns the port range in nftables format.
+func (d Nftables) forwardPortRange(start uint64, end uint64) string {
+	if start == end {
+		return fmt.Sprintf("%d", start)
+	}
+
+	return fmt.Sprintf("%d-%d", start, end)
+}
+
+// NetworkClearForwards removes the address forwards of the network.
+func (d Nftables) NetworkClearForwards(networkName string) error {
+	err := d.removeChains([]string{"ip", "ip6"}, networkName, "fwdprert", "fwdout", "fwdpstrt")
+	if err != nil {
+		return errors.Wrapf(err, "Failed clearing address forwards for network %q", networkName)
+	}
+
+	return nil
+}
+
 //instanceDeviceLabel returns the unique label used for instance device chains.
 func (d Nftables) instanceDeviceLabel(projectName, instanceName, deviceName string) string {
 	return fmt.Sprintf("%s%s%s", project.Instance(projectName, instanceName), nftablesChainSeparator, deviceName)
@@ -342,6 +615,90 @@ func (d Nftables) InstanceClearBridgeFilter(projectName string, instanceName str
 	return nil
 }
 
+// InstanceSetupACLRules applies the ACL rules to the traffic of a bridged instance device, identified by its host
+// side interface. Traffic not matching any of the rules is rejected, except for the DHCP, DNS and IPv6 neighbour
+// discovery traffic the instance needs to reach the network. Existing ACL rules for the device are replaced.
+func (d Nftables) InstanceSetupACLRules(projectName string, instanceName string, deviceName string, hostName string, rules []ACLRule) error {
+	deviceLabel := d.instanceDeviceLabel(projectName, instanceName, deviceName)
+
+	err := d.InstanceClearACLRules(projectName, instanceName, deviceName)
+	if err != nil {
+		return err
+	}
+
+	egressMatch := fmt.Sprintf(`iifname "%s"`, hostName)
+	ingressMatch := fmt.Sprintf(`oifname "%s"`, hostName)
+
+	inRules := []string{
+		fmt.Sprintf("%s ct state established,related accept", egressMatch),
+		fmt.Sprintf("%s udp dport {67, 547} accept", egressMatch),
+		fmt.Sprintf("%s udp dport 53 accept", egressMatch),
+		fmt.Sprintf("%s tcp dport 53 accept", egressMatch),
+		fmt.Sprintf("%s icmpv6 type {nd-router-solicit, nd-neighbor-solicit, nd-neighbor-advert} accept", egressMatch),
+	}
+
+	fwdRules := []string{
+		fmt.Sprintf("%s ct state established,related accept", egressMatch),
+		fmt.Sprintf("%s ct state established,related accept", ingressMatch),
+		fmt.Sprintf("%s icmpv6 type {nd-neighbor-solicit, nd-neighbor-advert} accept", egressMatch),
+		fmt.Sprintf("%s icmpv6 type {nd-neighbor-solicit, nd-neighbor-advert} accept", ingressMatch),
+	}
+
+	outRules := []string{
+		fmt.Sprintf("%s ct state established,related accept", ingressMatch),
+		fmt.Sprintf("%s udp sport {67, 547} accept", ingressMatch),
+		fmt.Sprintf("%s icmpv6 type {nd-router-advert, nd-neighbor-solicit, nd-neighbor-advert} accept", ingressMatch),
+	}
+
+	for _, rule := range aclRulesOrdered(rules) {
+		if rule.Direction == "ingress" {
+			fwdRules = append(fwdRules, d.aclRule(ingressMatch, rule)...)
+			outRules = append(outRules, d.aclRule(ingressMatch, rule)...)
+		} else {
+			inRules = append(inRules, d.aclRule(egressMatch, rule)...)
+			fwdRules = append(fwdRules, d.aclRule(egressMatch, rule)...)
+		}
+	}
+
+	// Only filter IP traffic, leaving ARP and other non-IP traffic alone. The bridge family doesn't support
+	// rejecting traffic in the output hook, so it is dropped there instead.
+	inRules = append(inRules, fmt.Sprintf("%s ether type {ip, ip6} reject", egressMatch))
+	fwdRules = append(fwdRules,
+		fmt.Sprintf("%s ether type {ip, ip6} reject", egressMatch),
+		fmt.Sprintf("%s ether type {ip, ip6} reject", ingressMatch),
+	)
+	outRules = append(outRules, fmt.Sprintf("%s ether type {ip, ip6} drop", ingressMatch))
+
+	tplFields := map[string]interface{}{
+		"namespace":      nftablesNamespace,
+		"chainSeparator": nftablesChainSeparator,
+		"family":         "bridge",
+		"deviceLabel":    deviceLabel,
+		"inRules":        inRules,
+		"fwdRules":       fwdRules,
+		"outRules":       outRules,
+	}
+
+	err = d.applyNftConfig(nftablesInstanceACLRules, tplFields)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding ACL rules for instance device %q", deviceLabel)
+	}
+
+	return nil
+}
+
+// InstanceClearACLRules removes the ACL rules of a bridged instance device.
+func (d Nftables) InstanceClearACLRules(projectName string, instanceName string, deviceName string) error {
+	deviceLabel := d.instanceDeviceLabel(projectName, instanceName, deviceName)
+
+	err := d.removeChains([]string{"bridge"}, deviceLabel, "aclin", "aclfwd", "aclout")
+	if err != nil {
+		return errors.Wrapf(err, "Failed clearing ACL rules for instance device %q", deviceLabel)
+	}
+
+	return nil
+}
+
 // InstanceSetupProxyNAT creates DNAT rules for proxy devices.
 func (d Nftables) InstanceSetupProxyNAT(projectName string, instanceName string, deviceName string, listen, connect *deviceConfig.ProxyAddress) error {
 	connectAddrCount := len(connect.Addr)
@@ -53,6 +53,90 @@ chain out{{.chainSeparator}}{{.networkName}} {
 }
 `))
 
+// nftablesNetACLRules defines the rules used to apply network ACLs to traffic forwarded to and from a network and
+// to traffic between the network and the host.
+var nftablesNetACLRules = template.Must(template.New("nftablesNetACLRules").Parse(`
+chain aclfwd{{.chainSeparator}}{{.networkName}} {
+	type filter hook forward priority 0; policy accept;
+	{{- range .fwdRules}}
+	{{.}}
+	{{- end}}
+}
+
+chain aclin{{.chainSeparator}}{{.networkName}} {
+	type filter hook input priority 0; policy accept;
+	{{- range .inRules}}
+	{{.}}
+	{{- end}}
+}
+
+chain aclout{{.chainSeparator}}{{.networkName}} {
+	type filter hook output priority 0; policy accept;
+	{{- range .outRules}}
+	{{.}}
+	{{- end}}
+}
+`))
+
+// nftablesNetACLBridgeRules defines the rules used to apply network ACLs to traffic bridged between the instances
+// connected to a network.
+var nftablesNetACLBridgeRules = template.Must(template.New("nftablesNetACLBridgeRules").Parse(`
+chain aclbr{{.chainSeparator}}{{.networkName}} {
+	type filter hook forward priority 0; policy accept;
+	{{- range .rules}}
+	{{.}}
+	{{- end}}
+}
+`))
+
+// nftablesNetForwards defines the rules used to apply address forwards of a network.
+var nftablesNetForwards = template.Must(template.New("nftablesNetForwards").Parse(`
+chain fwdprert{{.chainSeparator}}{{.networkName}} {
+	type nat hook prerouting priority -100; policy accept;
+	{{- range .dnatRules}}
+	{{.}}
+	{{- end}}
+}
+
+chain fwdout{{.chainSeparator}}{{.networkName}} {
+	type nat hook output priority -100; policy accept;
+	{{- range .dnatRules}}
+	{{.}}
+	{{- end}}
+}
+
+chain fwdpstrt{{.chainSeparator}}{{.networkName}} {
+	type nat hook postrouting priority 100; policy accept;
+	{{- range .snatRules}}
+	{{.}}
+	{{- end}}
+}
+`))
+
+// nftablesInstanceACLRules defines the rules used to apply network ACLs to the traffic of a bridged instance device.
+var nftablesInstanceACLRules = template.Must(template.New("nftablesInstanceACLRules").Parse(`
+chain aclin{{.chainSeparator}}{{.deviceLabel}} {
+	type filter hook input priority 0; policy accept;
+	{{- range .inRules}}
+	{{.}}
+	{{- end}}
+}
+
+chain aclfwd{{.chainSeparator}}{{.deviceLabel}} {
+	type filter hook forward priority 0; policy accept;
+	{{- range .fwdRules}}
+	{{.}}
+	{{- end}}
+}
+
+chain aclout{{.chainSeparator}}{{.deviceLabel}} {
+	type filter hook output priority 0; policy accept;
+	{{- range .outRules}}
+	{{.}}
+	{{- end}}
+}
+`))
+
 var nftablesNetProxyNAT = template.Must(template.New("nftablesNetProxyNAT").Parse(`
 chain prert{{.chainSeparator}}{{.deviceLabel}} {
 	type nat hook prerouting priority -100; policy accept;
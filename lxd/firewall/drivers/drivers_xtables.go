@@ -10,9 +10,12 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/pkg/errors"
+
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/logger"
 )
@@ -258,6 +261,280 @@ func (d Xtables) NetworkClear(networkName string, ipVersion uint) error {
 	return nil
 }
 
+// networkACLIPTablesComment returns the iptables comment that is added to each network ACL rule.
+func (d Xtables) networkACLIPTablesComment(networkName string) string {
+	return fmt.Sprintf("%s ACL", d.networkIPTablesComment(networkName))
+}
+
+// networkForwardIPTablesComment returns the iptables comment that is added to each network address forward rule.
+func (d Xtables) networkForwardIPTablesComment(networkName string) string {
+	return fmt.Sprintf("%s forward", d.networkIPTablesComment(networkName))
+}
+
+// NetworkApplyACLRules applies the ACL rules to traffic forwarded to and from the network and to traffic between
+// the network and the host. Traffic bridged between the instances connected to the network goes through the
+// FORWARD chain too, which requires br_netfilter to be enabled. Traffic not matching any of the rules is rejected,
+// except for the DHCP, DNS and IPv6 neighbour discovery traffic the network needs to operate. Existing ACL rules
+// for the network are replaced.
+func (d Xtables) NetworkApplyACLRules(networkName string, rules []ACLRule) error {
+	// Check br_netfilter kernel module is loaded and enabled. We won't try to load it as its default mode
+	// can cause unwanted traffic blocking.
+	for _, sysctlPath := range []string{"net/bridge/bridge-nf-call-iptables", "net/bridge/bridge-nf-call-ip6tables"} {
+		sysctlVal, err := util.SysctlGet(sysctlPath)
+		if err != nil {
+			return errors.Wrapf(err, "Network ACLs require br_netfilter be loaded")
+		}
+
+		if sysctlVal != "1\n" {
+			return fmt.Errorf("Network ACLs require br_netfilter sysctl net.bridge.%s=1", strings.TrimPrefix(sysctlPath, "net/bridge/"))
+		}
+	}
+
+	err := d.NetworkClearACLRules(networkName)
+	if err != nil {
+		return err
+	}
+
+	comment := d.networkACLIPTablesComment(networkName)
+
+	for _, ipVersion := range []uint{4, 6} {
+		dhcpPort := "67"
+		if ipVersion == 6 {
+			dhcpPort = "547"
+		}
+
+		// Build the rules in the order they should appear at the top of each chain.
+		chainRules := map[string][][]string{
+			"FORWARD": {
+				{"-i", networkName, "-m", "conntrack", "--ctstate", "ESTABLISHED,RELATED", "-j", "ACCEPT"},
+				{"-o", networkName, "-m", "conntrack", "--ctstate", "ESTABLISHED,RELATED", "-j", "ACCEPT"},
+			},
+			"INPUT": {
+				{"-i", networkName, "-m", "conntrack", "--ctstate", "ESTABLISHED,RELATED", "-j", "ACCEPT"},
+				{"-i", networkName, "-p", "udp", "--dport", dhcpPort, "-j", "ACCEPT"},
+				{"-i", networkName, "-p", "udp", "--dport", "53", "-j", "ACCEPT"},
+				{"-i", networkName, "-p", "tcp", "--dport", "53", "-j", "ACCEPT"},
+			},
+			"OUTPUT": {
+				{"-o", networkName, "-m", "conntrack", "--ctstate", "ESTABLISHED,RELATED", "-j", "ACCEPT"},
+				{"-o", networkName, "-p", "udp", "--sport", dhcpPort, "-j", "ACCEPT"},
+			},
+		}
+
+		if ipVersion == 6 {
+			for _, icmpType := range []string{"router-solicitation", "neighbour-solicitation", "neighbour-advertisement"} {
+				chainRules["INPUT"] = append(chainRules["INPUT"], []string{"-i", networkName, "-p", "ipv6-icmp", "--icmpv6-type", icmpType, "-j", "ACCEPT"})
+			}
+
+			for _, icmpType := range []string{"router-advertisement", "neighbour-solicitation", "neighbour-advertisement"} {
+				chainRules["OUTPUT"] = append(chainRules["OUTPUT"], []string{"-o", networkName, "-p", "ipv6-icmp", "--icmpv6-type", icmpType, "-j", "ACCEPT"})
+			}
+		}
+
+		for _, rule := range aclRulesOrdered(rules) {
+			args, ok := d.aclRuleArgs(networkName, rule, ipVersion)
+			if !ok {
+				continue
+			}
+
+			chainRules["FORWARD"] = append(chainRules["FORWARD"], args)
+
+			if rule.Direction == "ingress" {
+				chainRules["OUTPUT"] = append(chainRules["OUTPUT"], args)
+			} else {
+				chainRules["INPUT"] = append(chainRules["INPUT"], args)
+			}
+		}
+
+		chainRules["FORWARD"] = append(chainRules["FORWARD"],
+			[]string{"-i", networkName, "-j", "REJECT"},
+			[]string{"-o", networkName, "-j", "REJECT"},
+		)
+
+		chainRules["INPUT"] = append(chainRules["INPUT"], []string{"-i", networkName, "-j", "REJECT"})
+		chainRules["OUTPUT"] = append(chainRules["OUTPUT"], []string{"-o", networkName, "-j", "REJECT"})
+
+		// Prepend in reverse order so that the rules end up in the order defined above and ahead of the
+		// network's other rules.
+		for _, chain := range []string{"FORWARD", "INPUT", "OUTPUT"} {
+			for i := len(chainRules[chain]) - 1; i >= 0; i-- {
+				err = d.iptablesPrepend(ipVersion, comment, "filter", chain, chainRules[chain][i]...)
+				if err != nil {
+					return errors.Wrapf(err, "Failed adding ACL rules for network %q (IPv%d)", networkName, ipVersion)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// NetworkClearACLRules removes the ACL rules of the network.
+func (d Xtables) NetworkClearACLRules(networkName string) error {
+	comment := d.networkACLIPTablesComment(networkName)
+	for _, ipVersion := range []uint{4, 6} {
+		err := d.iptablesClear(ipVersion, comment, "filter")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// aclRuleArgs converts an ACL rule into iptables arguments for the specified IP version.
+// Returns false if the rule doesn't apply to the IP version.
+func (d Xtables) aclRuleArgs(networkName string, rule ACLRule, ipVersion uint) ([]string, bool) {
+	src, dst, ok := aclRuleFamilySubjects(rule, ipVersion)
+	if !ok {
+		return nil, false
+	}
+
+	args := []string{"-i", networkName}
+	if rule.Direction == "ingress" {
+		args = []string{"-o", networkName}
+	}
+
+	if len(src) > 0 {
+		args = append(args, "-s", strings.Join(src, ","))
+	}
+
+	if len(dst) > 0 {
+		args = append(args, "-d", strings.Join(dst, ","))
+	}
+
+	switch rule.Protocol {
+	case "tcp", "udp":
+		args = append(args, "-p", rule.Protocol)
+
+		// Each multiport match can only hold one of --sports and --dports.
+		if rule.SourcePort != "" {
+			args = append(args, "-m", "multiport", "--sports", d.aclPorts(rule.SourcePort))
+		}
+
+		if rule.DestinationPort != "" {
+			args = append(args, "-m", "multiport", "--dports", d.aclPorts(rule.DestinationPort))
+		}
+	case "icmp4", "icmp6":
+		protocol, typeFlag := "icmp", "--icmp-type"
+		if rule.Protocol == "icmp6" {
+			protocol, typeFlag = "ipv6-icmp", "--icmpv6-type"
+		}
+
+		args = append(args, "-p", protocol)
+
+		if rule.ICMPType != "" {
+			icmpType := rule.ICMPType
+			if rule.ICMPCode != "" {
+				icmpType = fmt.Sprintf("%s/%s", icmpType, rule.ICMPCode)
+			}
+
+			args = append(args, typeFlag, icmpType)
+		}
+	}
+
+	switch rule.Action {
+	case "allow":
+		args = append(args, "-j", "ACCEPT")
+	case "drop":
+		args = append(args, "-j", "DROP")
+	default:
+		args = append(args, "-j", "REJECT")
+	}
+
+	return args, true
+}
+
+// aclPorts converts a comma separated list of ports and port ranges into multiport match format.
+func (d Xtables) aclPorts(ports string) string {
+	elements := []string{}
+	for _, port := range strings.Split(ports, ",") {
+		elements = append(elements, strings.Replace(strings.TrimSpace(port), "-", ":", 1))
+	}
+
+	return strings.Join(elements, ",")
+}
+
+// NetworkApplyForwards applies the address forwards of the network, replacing any existing ones.
+func (d Xtables) NetworkApplyForwards(networkName string, forwards []AddressForward) error {
+	err := d.NetworkClearForwards(networkName)
+	if err != nil {
+		return err
+	}
+
+	comment := d.networkForwardIPTablesComment(networkName)
+
+	for _, ipVersion := range []uint{4, 6} {
+		// Build the rules in the order they should appear at the top of each chain.
+		var dnatRules, snatRules [][]string
+
+		for _, forward := range addressForwardsOrdered(forwards) {
+			if (forward.ListenAddress.To4() != nil) != (ipVersion == 4) {
+				continue
+			}
+
+			if len(forward.ListenPorts) == 0 {
+				dnatRules = append(dnatRules, []string{"--destination", forward.ListenAddress.String(), "-j", "DNAT", "--to-destination", forward.TargetAddress.String()})
+
+				// Allow the target to reach itself using the listen address.
+				snatRules = append(snatRules, []string{"--source", forward.TargetAddress.String(), "--destination", forward.TargetAddress.String(), "-j", "MASQUERADE"})
+				continue
+			}
+
+			targetHost := forward.TargetAddress.String()
+			if ipVersion == 6 {
+				targetHost = fmt.Sprintf("[%s]", targetHost)
+			}
+
+			for _, portRange := range addressForwardPortRanges(forward) {
+				listenPorts := d.forwardPortRange(portRange.listenStart, portRange.listenEnd, ":")
+				targetPorts := d.forwardPortRange(portRange.targetStart, portRange.targetEnd, ":")
+				targetPortsDNAT := d.forwardPortRange(portRange.targetStart, portRange.targetEnd, "-")
+
+				dnatRules = append(dnatRules, []string{"-p", forward.Protocol, "--destination", forward.ListenAddress.String(), "--dport", listenPorts, "-j", "DNAT", "--to-destination", fmt.Sprintf("%s:%s", targetHost, targetPortsDNAT)})
+				snatRules = append(snatRules, []string{"-p", forward.Protocol, "--source", forward.TargetAddress.String(), "--destination", forward.TargetAddress.String(), "--dport", targetPorts, "-j", "MASQUERADE"})
+			}
+		}
+
+		// Prepend in reverse order so that the rules end up in the order defined above.
+		for _, chain := range []struct {
+			name  string
+			rules [][]string
+		}{{"PREROUTING", dnatRules}, {"OUTPUT", dnatRules}, {"POSTROUTING", snatRules}} {
+			for i := len(chain.rules) - 1; i >= 0; i-- {
+				err = d.iptablesPrepend(ipVersion, comment, "nat", chain.name, chain.rules[i]...)
+				if err != nil {
+					return errors.Wrapf(err, "Failed adding address forwards for network %q (IPv%d)", networkName, ipVersion)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// forwardPortRange returns the port range in iptables format, using the separator between the start and end.
+func (d Xtables) forwardPortRange(start uint64, end uint64, separator string) string {
+	if start == end {
+		return fmt.Sprintf("%d", start)
+	}
+
+	return fmt.Sprintf("%d%s%d", start, separator, end)
+}
+
+// NetworkClearForwards removes the address forwards of the network.
+func (d Xtables) NetworkClearForwards(networkName string) error {
+	comment := d.networkForwardIPTablesComment(networkName)
+	for _, ipVersion := range []uint{4, 6} {
+		err := d.iptablesClear(ipVersion, comment, "nat")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 //instanceDeviceIPTablesComment returns the iptables comment that is added to each instance device related rule.
 func (d Xtables) instanceDeviceIPTablesComment(projectName string, instanceName string, deviceName string) string {
 	return fmt.Sprintf("LXD container %s (%s)", project.Instance(projectName, instanceName), deviceName)
@@ -348,6 +625,16 @@ func (d Xtables) InstanceClearBridgeFilter(projectName string, instanceName stri
 	return nil
 }
 
+// InstanceSetupACLRules isn't supported with xtables, as the traffic routed to a bridge port cannot be matched.
+func (d Xtables) InstanceSetupACLRules(projectName string, instanceName string, deviceName string, hostName string, rules []ACLRule) error {
+	return fmt.Errorf("Network ACLs on bridged instance devices require the nftables firewall driver")
+}
+
+// InstanceClearACLRules does nothing with xtables, as no instance device ACL rules can have been added.
+func (d Xtables) InstanceClearACLRules(projectName string, instanceName string, deviceName string) error {
+	return nil
+}
+
 // InstanceSetupProxyNAT creates DNAT rules for proxy devices.
 func (d Xtables) InstanceSetupProxyNAT(projectName string, instanceName string, deviceName string, listen *deviceConfig.ProxyAddress, connect *deviceConfig.ProxyAddress) error {
 	connectAddrCount := len(connect.Addr)
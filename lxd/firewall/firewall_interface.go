@@ -4,6 +4,7 @@ import (
 	"net"
 
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
+	"github.com/lxc/lxd/lxd/firewall/drivers"
 )
 
 // Firewall represents an LXD firewall.
@@ -16,9 +17,15 @@ type Firewall interface {
 	NetworkSetupDHCPDNSAccess(networkName string, ipVersion uint) error
 	NetworkSetupDHCPv4Checksum(networkName string) error
 	NetworkClear(networkName string, ipVersion uint) error
+	NetworkApplyACLRules(networkName string, rules []drivers.ACLRule) error
+	NetworkClearACLRules(networkName string) error
+	NetworkApplyForwards(networkName string, forwards []drivers.AddressForward) error
+	NetworkClearForwards(networkName string) error
 
 	InstanceSetupBridgeFilter(projectName string, instanceName string, deviceName string, parentName string, hostName string, hwAddr string, IPv4 net.IP, IPv6 net.IP) error
 	InstanceClearBridgeFilter(projectName string, instanceName string, deviceName string, parentName string, hostName string, hwAddr string, IPv4 net.IP, IPv6 net.IP) error
+	InstanceSetupACLRules(projectName string, instanceName string, deviceName string, hostName string, rules []drivers.ACLRule) error
+	InstanceClearACLRules(projectName string, instanceName string, deviceName string) error
 
 	InstanceSetupProxyNAT(projectName string, instanceName string, deviceName string, listen *deviceConfig.ProxyAddress, connect *deviceConfig.ProxyAddress) error
 	InstanceClearProxyNAT(projectName string, instanceName string, deviceName string) error
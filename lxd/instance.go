@@ -339,7 +339,26 @@ func instanceCreateAsSnapshot(s *state.State, args db.InstanceArgs, sourceInstan
 	}
 
 	// Deal with state.
-	if args.Stateful {
+	if args.Stateful && sourceInstance.Type() == instancetype.VM {
+		if !sourceInstance.IsRunning() {
+			return nil, fmt.Errorf("Unable to create a stateful snapshot. The instance isn't running")
+		}
+
+		vm, ok := sourceInstance.(instance.VM)
+		if !ok {
+			return nil, fmt.Errorf("Unable to create a stateful snapshot. The instance isn't a virtual machine")
+		}
+
+		// The VM stays paused from saving its state until the snapshot is taken, so that the snapshotted
+		// disks match the saved state. The state file is then carried by the snapshot's config volume.
+		err := vm.SaveState()
+		if err != nil {
+			return nil, err
+		}
+
+		defer os.Remove(sourceInstance.StatePath())
+		defer sourceInstance.Unfreeze()
+	} else if args.Stateful {
 		if !sourceInstance.IsRunning() {
 			return nil, fmt.Errorf("Unable to create a stateful snapshot. The instance isn't running")
 		}
@@ -418,7 +437,7 @@ func instanceCreateAsSnapshot(s *state.State, args db.InstanceArgs, sourceInstan
 	}
 
 	// Once we're done, remove the state directory.
-	if args.Stateful {
+	if args.Stateful && sourceInstance.Type() != instancetype.VM {
 		os.RemoveAll(sourceInstance.StatePath())
 	}
 
@@ -476,7 +495,7 @@ func instanceCreateInternal(s *state.State, args db.InstanceArgs) (instance.Inst
 	}
 
 	// Validate container devices with the supplied container name and devices.
-	err = instance.ValidDevices(s, s.Cluster, args.Type, args.Devices, false)
+	err = instance.ValidDevices(s, s.Cluster, args.Project, args.Type, args.Devices, false)
 	if err != nil {
 		return nil, errors.Wrap(err, "Invalid devices")
 	}
@@ -654,12 +673,15 @@ func instanceConfigureInternal(state *state.State, c instance.Instance) error {
 		return errors.Wrap(err, "Load instance storage pool")
 	}
 
-	if rootDiskDevice["size"] != "" {
-		err = pool.SetInstanceQuota(c, rootDiskDevice["size"], nil)
+	if rootDiskDevice["size"] != "" || rootDiskDevice["size.state"] != "" {
+		err = pool.SetInstanceQuota(c, rootDiskDevice["size"], rootDiskDevice["size.state"], nil)
 
 		// If the storage driver can't set the quota now, store in volatile.
 		if err == storagePools.ErrRunningQuotaResizeNotSupported {
-			err = c.VolatileSet(map[string]string{fmt.Sprintf("volatile.%s.apply_quota", rootDiskDeviceKey): rootDiskDevice["size"]})
+			err = c.VolatileSet(map[string]string{
+				fmt.Sprintf("volatile.%s.apply_quota", rootDiskDeviceKey):       rootDiskDevice["size"],
+				fmt.Sprintf("volatile.%s.apply_quota.state", rootDiskDeviceKey): rootDiskDevice["size.state"],
+			})
 			if err != nil {
 				return err
 			}
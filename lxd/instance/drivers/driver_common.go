@@ -1,6 +1,8 @@
 package drivers
 
 import (
+	"fmt"
+
 	"github.com/lxc/lxd/lxd/db"
 	deviceConfig "github.com/lxc/lxd/lxd/device/config"
 	"github.com/lxc/lxd/lxd/instance/instancetype"
@@ -20,6 +22,11 @@ type common struct {
 	state           *state.State
 }
 
+// Project returns the instance's project.
+func (c *common) Project() string {
+	return c.project
+}
+
 // Type returns the instance's type.
 func (c *common) Type() instancetype.Type {
 	return c.dbType
@@ -72,3 +79,20 @@ func (c *common) expandDevices(profiles []api.Profile) error {
 
 	return nil
 }
+
+// keepBackupUUID checks that a user requested update doesn't change the volatile.backup.uuid key, which records
+// the backup the instance was restored from and is only set by LXD itself, and keeps its current value if omitted.
+func keepBackupUUID(curConfig map[string]string, newConfig map[string]string) error {
+	curUUID := curConfig["volatile.backup.uuid"]
+
+	newUUID, ok := newConfig["volatile.backup.uuid"]
+	if ok && newUUID != curUUID {
+		return fmt.Errorf("Config key %q is read-only", "volatile.backup.uuid")
+	}
+
+	if curUUID != "" {
+		newConfig["volatile.backup.uuid"] = curUUID
+	}
+
+	return nil
+}
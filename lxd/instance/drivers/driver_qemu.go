@@ -1,13 +1,18 @@
 package drivers
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
+	"image"
+	"image/png"
 	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -17,6 +22,7 @@ import (
 	"sync"
 	"text/template"
 	"time"
+	"unsafe"
 
 	"github.com/flosch/pongo2"
 	"github.com/gorilla/websocket"
@@ -67,6 +73,32 @@ const qemuUnsafeIO = "unsafeio"
 // qemuSerialChardevName is used to communicate state via qmp between Qemu and LXD.
 const qemuSerialChardevName = "qemu_serial-chardev"
 
+// qemuSCSILun is the LUN used by all the disks, each disk gets its own SCSI target ID.
+const qemuSCSILun = "1"
+
+// qemuSCSIMaxID is the number of SCSI target IDs available on the virtio-scsi controller.
+const qemuSCSIMaxID = 256
+
+// qemuPCIeHotplugPorts is the number of spare PCIe root ports reserved at startup for hotplugging devices.
+const qemuPCIeHotplugPorts = 4
+
+// qemuMaxCPUs is the highest number of vCPUs a VM with limits.cpu.hotplug can be resized to while running.
+// The limit is further capped to the number of CPUs of the host.
+const qemuMaxCPUs = 64
+
+// qemuMaxMemory is the highest amount of memory (in MiB) a VM with limits.memory.hotplug can be resized to
+// while running. The limit is further capped to the memory of the host.
+const qemuMaxMemory = 64 * 1024
+
+// qemuMemoryHotplugSlots is the number of memory devices which can be hotplugged into a running VM.
+const qemuMemoryHotplugSlots = 16
+
+// qemuMemoryHotplugAlign is the size (in bytes) hotplugged memory is aligned to, matching the guest's memory blocks.
+const qemuMemoryHotplugAlign = 128 * 1024 * 1024
+
+// qemuStateOverhead is the space (in bytes) reserved on top of the VM memory for the device state in the state file.
+const qemuStateOverhead = 128 * 1024 * 1024
+
 var errQemuAgentOffline = fmt.Errorf("LXD VM agent isn't currently running")
 
 var vmConsole = map[int]bool{}
@@ -219,7 +251,7 @@ func qemuCreate(s *state.State, args db.InstanceArgs) (instance.Instance, error)
 		return nil, err
 	}
 
-	err = instance.ValidDevices(s, s.Cluster, vm.Type(), vm.expandedDevices, true)
+	err = instance.ValidDevices(s, s.Cluster, vm.Project(), vm.Type(), vm.expandedDevices, true)
 	if err != nil {
 		logger.Error("Failed creating instance", ctxMap)
 		return nil, errors.Wrap(err, "Invalid devices")
@@ -315,6 +347,10 @@ type qemu struct {
 	agentClient      *http.Client
 	storagePool      storagePools.Pool
 	architectureName string
+
+	// Agent connection shared by the proxy device relays.
+	agentProxy   lxdClient.InstanceServer
+	agentProxyMu sync.Mutex
 }
 
 // getAgentClient returns the current agent client handle. To avoid TLS setup each time this
@@ -603,6 +639,12 @@ func (vm *qemu) ovmfPath() string {
 
 // Start starts the instance.
 func (vm *qemu) Start(stateful bool) error {
+	return vm.start(stateful, nil)
+}
+
+// start starts the instance. When stateful, the VM memory and device state is restored from the compressed
+// stream in migrationState if provided, or from the instance's state file otherwise.
+func (vm *qemu) start(stateful bool, migrationState io.Reader) error {
 	// Ensure the correct vhost_vsock kernel module is loaded before establishing the vsock.
 	err := util.LoadModule("vhost_vsock")
 	if err != nil {
@@ -632,6 +674,22 @@ func (vm *qemu) Start(stateful bool) error {
 
 	revert.Add(func() { vm.unmount() })
 
+	// Check the state file is usable if doing a stateful start, otherwise clear any leftover state.
+	if stateful {
+		if migrationState == nil && !shared.PathExists(vm.StatePath()) {
+			err = fmt.Errorf("Instance has no existing state to restore")
+			op.Done(err)
+			return err
+		}
+	} else if vm.stateful || shared.PathExists(vm.StatePath()) {
+		// Stateless start required when we have state, let's delete it.
+		err = vm.clearState()
+		if err != nil {
+			op.Done(err)
+			return err
+		}
+	}
+
 	err = vm.generateConfigShare()
 	if err != nil {
 		op.Done(err)
@@ -700,6 +758,13 @@ func (vm *qemu) Start(stateful bool) error {
 		devConfs = append(devConfs, runConf)
 	}
 
+	// Configure the NIC addresses set by the devices inside the VM.
+	err = vm.generateNetworkConfig(devConfs)
+	if err != nil {
+		op.Done(err)
+		return err
+	}
+
 	// Get qemu configuration.
 	qemuBinary, qemuBus, err := vm.qemuArchConfig()
 	if err != nil {
@@ -743,6 +808,11 @@ func (vm *qemu) Start(stateful bool) error {
 		"-chroot", vm.Path(),
 	}
 
+	// Wait for the saved state to be fed in through the QMP monitor if doing a stateful start.
+	if stateful {
+		qemuCmd = append(qemuCmd, "-incoming", "defer")
+	}
+
 	// SMBIOS only on x86_64 and aarch64.
 	if shared.IntInSlice(vm.architecture, []int{osarch.ARCH_64BIT_INTEL_X86, osarch.ARCH_64BIT_ARMV8_LITTLE_ENDIAN}) {
 		qemuCmd = append(qemuCmd, "-smbios", "type=2,manufacturer=Canonical Ltd.,product=LXD")
@@ -891,40 +961,40 @@ func (vm *qemu) Start(stateful bool) error {
 	}
 
 	// Apply CPU pinning.
-	cpuLimit, ok := vm.expandedConfig["limits.cpu"]
-	if ok && cpuLimit != "" {
-		_, err := strconv.Atoi(cpuLimit)
-		if err != nil {
-			// Expand to a set of CPU identifiers and get the pinning map.
-			_, _, _, pins, _, err := vm.cpuTopology(cpuLimit)
-			if err != nil {
-				op.Done(err)
-				return err
-			}
+	err = vm.setCPUAffinity(monitor)
+	if err != nil {
+		op.Done(err)
+		return err
+	}
 
-			// Get the list of PIDs from the VM.
-			pids, err := monitor.GetCPUs()
+	// Restore the VM memory and device state.
+	if stateful {
+		if migrationState == nil {
+			stateFile, err := os.Open(vm.StatePath())
 			if err != nil {
+				err = errors.Wrap(err, "Failed opening state file")
 				op.Done(err)
 				return err
 			}
+			defer stateFile.Close()
 
-			// Confirm nothing weird is going on.
-			if len(pins) != len(pids) {
-				return fmt.Errorf("QEMU has less vCPUs than configured")
-			}
+			migrationState = stateFile
+		}
 
-			for i, pid := range pids {
-				set := unix.CPUSet{}
-				set.Set(int(pins[uint64(i)]))
+		err = vm.restoreState(monitor, migrationState)
+		if err != nil {
+			op.Done(err)
+			return err
+		}
+	}
 
-				// Apply the pin.
-				err := unix.SchedSetaffinity(pid, &set)
-				if err != nil {
-					op.Done(err)
-					return err
-				}
-			}
+	// Run any post start hooks requested by the devices (such as registering for USB events) before the guest
+	// starts running, so that a failing hook is reverted along with the rest of the start.
+	for _, runConf := range devConfs {
+		err = vm.runHooks(runConf.PostHooks)
+		if err != nil {
+			op.Done(err)
+			return err
 		}
 	}
 
@@ -935,6 +1005,15 @@ func (vm *qemu) Start(stateful bool) error {
 		return err
 	}
 
+	// The state has been consumed, so remove it.
+	if stateful {
+		err = vm.clearState()
+		if err != nil {
+			op.Done(err)
+			return err
+		}
+	}
+
 	// Database updates
 	err = vm.state.Cluster.Transaction(func(tx *db.ClusterTx) error {
 		// Record current state
@@ -980,6 +1059,189 @@ func (vm *qemu) openUnixSocket(sockPath string) (*net.UnixConn, error) {
 	return c, nil
 }
 
+// saveState dumps the VM memory and device state into the compressed state file.
+// This leaves the VM paused, so the caller is expected to either resume or stop it afterwards.
+func (vm *qemu) saveState(monitor *qmp.Monitor) error {
+	os.Remove(vm.StatePath())
+
+	// Check the state will fit before touching the VM, so that it isn't left paused by a failed save.
+	err := vm.checkStateSpace(monitor)
+	if err != nil {
+		return err
+	}
+
+	// Prepare the state file.
+	stateFile, err := os.OpenFile(vm.StatePath(), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrap(err, "Failed creating state file")
+	}
+	defer stateFile.Close()
+
+	err = vm.migrateState(monitor, stateFile)
+	if err != nil {
+		os.Remove(vm.StatePath())
+		return err
+	}
+
+	return stateFile.Close()
+}
+
+// checkStateSpace checks that the filesystem holding the state file has room for the whole VM memory and
+// device state. The state is compressed, but incompressible memory can take up its full size.
+func (vm *qemu) checkStateSpace(monitor *qmp.Monitor) error {
+	baseMemory, pluggedMemory, err := monitor.MemorySizeSummary()
+	if err != nil {
+		return err
+	}
+
+	stat := unix.Statfs_t{}
+	err = unix.Statfs(vm.Path(), &stat)
+	if err != nil {
+		return errors.Wrap(err, "Failed checking space available for the instance state")
+	}
+
+	required := baseMemory + pluggedMemory + qemuStateOverhead
+	available := int64(stat.Bavail) * int64(stat.Bsize)
+	if available < required {
+		return fmt.Errorf("Not enough space to save the instance state (%s required, %s available), increase the root disk %q", units.GetByteSizeString(required, 2), units.GetByteSizeString(available, 2), "size.state")
+	}
+
+	return nil
+}
+
+// SaveState saves the memory and device state of the running VM into its state file, so that it is included in
+// a stateful snapshot. This leaves the VM paused, so the caller is expected to resume it once the snapshot is taken.
+func (vm *qemu) SaveState() error {
+	if !vm.IsRunning() {
+		return fmt.Errorf("The instance isn't running")
+	}
+
+	monitor, err := qmp.Connect(vm.monitorPath(), qemuSerialChardevName, vm.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	return vm.saveState(monitor)
+}
+
+// migrateState streams the compressed VM memory and device state into w.
+// This leaves the VM paused, so the caller is expected to either resume or stop it afterwards.
+func (vm *qemu) migrateState(monitor *qmp.Monitor, w io.Writer) error {
+	// The state can only be restored into a VM started with the same resources.
+	err := vm.checkNotResized(monitor)
+	if err != nil {
+		return err
+	}
+
+	compressedState, err := gzip.NewWriterLevel(w, gzip.BestSpeed)
+	if err != nil {
+		return err
+	}
+
+	pipeRead, pipeWrite, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer pipeRead.Close()
+	defer pipeWrite.Close()
+
+	// Compress the migration stream as it comes from QEMU.
+	chCopy := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(compressedState, pipeRead)
+		chCopy <- err
+	}()
+
+	// Send the write end of the pipe to QEMU.
+	err = monitor.SendFile("migration", pipeWrite)
+	if err != nil {
+		return errors.Wrap(err, "Failed sending state file descriptor")
+	}
+
+	// Issue the migration command and wait for QEMU to finish writing.
+	err = monitor.Migrate("fd:migration")
+	if err != nil {
+		return errors.Wrap(err, "Failed saving instance state")
+	}
+
+	// Close our copy of the write end so the copy sees EOF.
+	pipeWrite.Close()
+	err = <-chCopy
+	if err != nil {
+		return errors.Wrap(err, "Failed writing instance state")
+	}
+
+	err = compressedState.Close()
+	if err != nil {
+		return errors.Wrap(err, "Failed writing instance state")
+	}
+
+	return nil
+}
+
+// restoreState feeds the compressed state from r into a QEMU process started with "-incoming defer".
+func (vm *qemu) restoreState(monitor *qmp.Monitor, r io.Reader) error {
+	uncompressedState, err := gzip.NewReader(r)
+	if err != nil {
+		return errors.Wrap(err, "Failed reading instance state")
+	}
+	defer uncompressedState.Close()
+
+	pipeRead, pipeWrite, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer pipeRead.Close()
+
+	// Decompress the state into the migration stream read by QEMU.
+	chCopy := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(pipeWrite, uncompressedState)
+		pipeWrite.Close()
+		chCopy <- err
+	}()
+
+	// Send the read end of the pipe to QEMU.
+	err = monitor.SendFile("migration", pipeRead)
+	if err != nil {
+		return errors.Wrap(err, "Failed sending state file descriptor")
+	}
+
+	// Issue the incoming migration command and wait for QEMU to load the state.
+	err = monitor.MigrateIncoming("fd:migration")
+	if err != nil {
+		return errors.Wrap(err, "Failed restoring instance state")
+	}
+
+	// Make sure the whole state was read, a corrupted or truncated state must not be resumed.
+	err = <-chCopy
+	if err != nil {
+		return errors.Wrap(err, "Failed reading instance state")
+	}
+
+	return nil
+}
+
+// clearState removes any saved state and resets the stateful flag.
+func (vm *qemu) clearState() error {
+	err := os.Remove(vm.StatePath())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if !vm.stateful {
+		return nil
+	}
+
+	vm.stateful = false
+	err = vm.state.Cluster.UpdateInstanceStatefulFlag(vm.id, false)
+	if err != nil {
+		return errors.Wrap(err, "Persist stateful flag")
+	}
+
+	return nil
+}
+
 func (vm *qemu) setupNvram() error {
 	// UEFI only on x86_64 and aarch64.
 	if !shared.IntInSlice(vm.architecture, []int{osarch.ARCH_64BIT_INTEL_X86, osarch.ARCH_64BIT_ARMV8_LITTLE_ENDIAN}) {
@@ -1056,9 +1318,27 @@ func (vm *qemu) deviceVolatileSetFunc(devName string) func(save map[string]strin
 	}
 }
 
-// RegisterDevices is not used by VMs.
+// RegisterDevices calls the Register() function on all of the instance's devices.
 func (vm *qemu) RegisterDevices() {
-	return
+	devices := vm.ExpandedDevices()
+	for _, dev := range devices.Sorted() {
+		d, _, err := vm.deviceLoad(dev.Name, dev.Config)
+		if err == device.ErrUnsupportedDevType {
+			continue
+		}
+
+		if err != nil {
+			logger.Error("Failed to load device to register", log.Ctx{"err": err, "instance": vm.Name(), "device": dev.Name})
+			continue
+		}
+
+		// Check whether device wants to register for any events.
+		err = d.Register()
+		if err != nil {
+			logger.Error("Failed to register device", log.Ctx{"err": err, "instance": vm.Name(), "device": dev.Name})
+			continue
+		}
+	}
 }
 
 // SaveConfigFile is not used by VMs.
@@ -1111,6 +1391,35 @@ func (vm *qemu) deviceStart(deviceName string, rawConfig deviceConfig.Device, is
 		return nil, err
 	}
 
+	// If the instance is running, hotplug the device into QEMU.
+	if isRunning && runConf != nil {
+		revert := revert.New()
+		defer revert.Fail()
+
+		// Remove whatever got hotplugged and stop the device again on failure.
+		revert.Add(func() {
+			vm.deviceDetach(deviceName, rawConfig)
+
+			stopRunConf, _ := d.Stop()
+			if stopRunConf != nil {
+				vm.deviceDetachHostDevices(stopRunConf.USBDevice, stopRunConf.UnixDevice)
+				vm.runHooks(stopRunConf.PostHooks)
+			}
+		})
+
+		err = vm.deviceAttach(runConf)
+		if err != nil {
+			return nil, err
+		}
+
+		err = vm.runHooks(runConf.PostHooks)
+		if err != nil {
+			return nil, err
+		}
+
+		revert.Success()
+	}
+
 	return runConf, nil
 }
 
@@ -1140,8 +1449,16 @@ func (vm *qemu) deviceStop(deviceName string, rawConfig deviceConfig.Device) err
 
 	canHotPlug, _ := d.CanHotPlug()
 
-	if vm.IsRunning() && !canHotPlug {
-		return fmt.Errorf("Device cannot be stopped when instance is running")
+	if vm.IsRunning() {
+		if !canHotPlug {
+			return fmt.Errorf("Device cannot be stopped when instance is running")
+		}
+
+		// Remove the device from QEMU before stopping it.
+		err = vm.deviceDetach(deviceName, rawConfig)
+		if err != nil {
+			return err
+		}
 	}
 
 	runConf, err := d.Stop()
@@ -1150,6 +1467,14 @@ func (vm *qemu) deviceStop(deviceName string, rawConfig deviceConfig.Device) err
 	}
 
 	if runConf != nil {
+		// Remove any USB or unix devices the device had passed through to the running VM.
+		if vm.IsRunning() {
+			err = vm.deviceDetachHostDevices(runConf.USBDevice, runConf.UnixDevice)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Run post stop hooks irrespective of run state of instance.
 		err = vm.runHooks(runConf.PostHooks)
 		if err != nil {
@@ -1160,64 +1485,626 @@ func (vm *qemu) deviceStop(deviceName string, rawConfig deviceConfig.Device) err
 	return nil
 }
 
-// runHooks executes the callback functions returned from a function.
-func (vm *qemu) runHooks(hooks []func() error) error {
-	// Run any post start hooks.
-	if len(hooks) > 0 {
-		for _, hook := range hooks {
-			err := hook()
-			if err != nil {
-				return err
-			}
-		}
+// deviceAttach hotplugs the drives, network interfaces, USB and unix devices of a started device into the
+// running QEMU process.
+func (vm *qemu) deviceAttach(runConf *deviceConfig.RunConfig) error {
+	if len(runConf.GPUDevice) > 0 {
+		return fmt.Errorf("GPU devices cannot be hotplugged")
 	}
 
-	return nil
-}
-
-func (vm *qemu) monitorPath() string {
-	return filepath.Join(vm.LogPath(), "qemu.monitor")
-}
-
-func (vm *qemu) nvramPath() string {
-	return filepath.Join(vm.Path(), "qemu.nvram")
-}
-
-func (vm *qemu) spicePath() string {
-	return filepath.Join(vm.LogPath(), "qemu.spice")
-}
-
-// generateConfigShare generates the config share directory that will be exported to the VM via
-// a 9P share. Due to the unknown size of templates inside the images this directory is created
-// inside the VM's config volume so that it can be restricted by quota.
-func (vm *qemu) generateConfigShare() error {
-	// Mount the instance's config volume if needed.
-	ourMount, err := vm.mount()
-	if err != nil {
-		return err
+	if len(runConf.TPMDevice) > 0 {
+		return fmt.Errorf("TPM devices cannot be hotplugged")
 	}
 
-	if ourMount {
-		defer vm.unmount()
+	if len(runConf.Mounts) == 0 && len(runConf.NetworkInterface) == 0 && len(runConf.USBDevice) == 0 && len(runConf.UnixDevice) == 0 {
+		return nil
 	}
 
-	configDrivePath := filepath.Join(vm.Path(), "config")
-
-	// Create config drive dir.
-	os.RemoveAll(configDrivePath)
-	err = os.MkdirAll(configDrivePath, 0500)
+	// Connect to the monitor.
+	monitor, err := qmp.Connect(vm.monitorPath(), qemuSerialChardevName, vm.getMonitorEventHandler())
 	if err != nil {
 		return err
 	}
 
-	// Generate the cloud-init config.
-	err = os.MkdirAll(filepath.Join(configDrivePath, "cloud-init"), 0500)
-	if err != nil {
-		return err
+	for _, drive := range runConf.Mounts {
+		err = vm.deviceAttachDrive(monitor, drive)
+		if err != nil {
+			return err
+		}
 	}
 
-	if vm.ExpandedConfig()["user.user-data"] != "" {
-		err = ioutil.WriteFile(filepath.Join(configDrivePath, "cloud-init", "user-data"), []byte(vm.ExpandedConfig()["user.user-data"]), 0400)
+	if len(runConf.NetworkInterface) > 0 {
+		err = vm.deviceAttachNIC(monitor, runConf.NetworkInterface)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, usbDev := range runConf.USBDevice {
+		err = vm.deviceAttachUSB(monitor, usbDev)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, unixDev := range runConf.UnixDevice {
+		err = vm.deviceAttachUnix(monitor, unixDev)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deviceDetachHostDevices removes USB and unix devices passed through from the host from the running QEMU
+// process.
+func (vm *qemu) deviceDetachHostDevices(usbDevs []deviceConfig.USBDeviceItem, unixDevs []deviceConfig.UnixDeviceItem) error {
+	if len(usbDevs) == 0 && len(unixDevs) == 0 {
+		return nil
+	}
+
+	// Connect to the monitor.
+	monitor, err := qmp.Connect(vm.monitorPath(), qemuSerialChardevName, vm.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	for _, usbDev := range usbDevs {
+		err = monitor.RemoveDevice(fmt.Sprintf("dev-lxd_%s", usbDev.DeviceName))
+		if err != nil {
+			return errors.Wrapf(err, "Failed removing USB device %q", usbDev.DeviceName)
+		}
+	}
+
+	for _, unixDev := range unixDevs {
+		qemuName := fmt.Sprintf("lxd_%s", unixDev.DeviceName)
+
+		err = monitor.RemoveDevice(fmt.Sprintf("dev-%s", qemuName))
+		if err != nil {
+			return errors.Wrapf(err, "Failed removing device %q", unixDev.DeviceName)
+		}
+
+		if unixDev.Type == "unix-block" {
+			err = monitor.RemoveBlockDevice(qemuName)
+		} else {
+			err = monitor.RemoveCharDevice(qemuName)
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "Failed removing backend of device %q", unixDev.DeviceName)
+		}
+	}
+
+	return nil
+}
+
+// deviceAttachUnix hotplugs a host unix device into the running QEMU process.
+func (vm *qemu) deviceAttachUnix(monitor *qmp.Monitor, unixDev deviceConfig.UnixDeviceItem) error {
+	qemuName := fmt.Sprintf("lxd_%s", unixDev.DeviceName)
+	qemuDevName := fmt.Sprintf("dev-%s", qemuName)
+
+	if unixDev.Type == "unix-block" {
+		return vm.deviceAttachDrive(monitor, deviceConfig.MountEntryItem{
+			DevName: unixDev.DeviceName,
+			DevPath: unixDev.HostDevicePath,
+		})
+	}
+
+	// QEMU runs unprivileged, so pass it an already opened file descriptor.
+	f, err := os.OpenFile(unixDev.HostDevicePath, os.O_RDWR, 0)
+	if err != nil {
+		return errors.Wrapf(err, "Failed opening %q", unixDev.HostDevicePath)
+	}
+	defer f.Close()
+
+	fdsetID, err := monitor.AddFdSet(qemuName, f)
+	if err != nil {
+		return errors.Wrapf(err, "Failed sending file descriptor of %q", unixDev.HostDevicePath)
+	}
+	defer monitor.RemoveFdSet(fdsetID)
+
+	charDev := map[string]interface{}{
+		"id": qemuName,
+		"backend": map[string]interface{}{
+			"type": "serial",
+			"data": map[string]string{
+				"device": fmt.Sprintf("/dev/fdset/%d", fdsetID),
+			},
+		},
+	}
+
+	device := map[string]string{
+		"driver":  "virtserialport",
+		"id":      qemuDevName,
+		"name":    unixDev.DeviceName,
+		"chardev": qemuName,
+		"bus":     "dev-qemu_serial.0",
+	}
+
+	err = monitor.AddCharDevice(charDev, device)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding character device %q", unixDev.DeviceName)
+	}
+
+	return nil
+}
+
+// deviceAttachUSB hotplugs a host USB device into the running QEMU process.
+func (vm *qemu) deviceAttachUSB(monitor *qmp.Monitor, usbDev deviceConfig.USBDeviceItem) error {
+	qemuName := fmt.Sprintf("lxd_%s", usbDev.DeviceName)
+	qemuDevName := fmt.Sprintf("dev-%s", qemuName)
+
+	if vm.architecture == osarch.ARCH_64BIT_S390_BIG_ENDIAN {
+		return fmt.Errorf("USB devices are not supported on this architecture")
+	}
+
+	// QEMU runs unprivileged, so pass it an already opened file descriptor.
+	f, err := os.OpenFile(usbDev.HostDevicePath, os.O_RDWR, 0)
+	if err != nil {
+		return errors.Wrapf(err, "Failed opening USB device %q", usbDev.HostDevicePath)
+	}
+	defer f.Close()
+
+	fdsetID, err := monitor.AddFdSet(qemuName, f)
+	if err != nil {
+		return errors.Wrapf(err, "Failed passing USB device %q to QEMU", usbDev.HostDevicePath)
+	}
+	defer monitor.RemoveFdSet(fdsetID)
+
+	err = monitor.AddDevice(map[string]string{
+		"driver":     "usb-host",
+		"id":         qemuDevName,
+		"bus":        "qemu_usb.0",
+		"hostdevice": fmt.Sprintf("/dev/fdset/%d", fdsetID),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding USB device %q", usbDev.DeviceName)
+	}
+
+	return nil
+}
+
+// deviceAttachDrive hotplugs a drive into the running QEMU process using blockdev-add and device_add.
+func (vm *qemu) deviceAttachDrive(monitor *qmp.Monitor, driveConf deviceConfig.MountEntryItem) error {
+	if driveConf.TargetPath == "/" {
+		return fmt.Errorf("The root disk cannot be hotplugged")
+	}
+
+	if driveConf.FSType == "9p" {
+		return fmt.Errorf("Shared directories cannot be hotplugged")
+	}
+
+	if strings.HasPrefix(driveConf.DevPath, "rbd:") {
+		return fmt.Errorf("Ceph RBD disks cannot be hotplugged")
+	}
+
+	aioMode, cacheMode, err := vm.driveIOModes(driveConf)
+	if err != nil {
+		return err
+	}
+
+	readonly := shared.StringInSlice("ro", driveConf.Opts)
+
+	// QEMU is chrooted and unprivileged once started, so open the disk here and pass the file descriptor.
+	openMode := os.O_RDWR
+	if readonly {
+		openMode = os.O_RDONLY
+	}
+
+	f, err := os.OpenFile(driveConf.DevPath, openMode, 0)
+	if err != nil {
+		return errors.Wrapf(err, "Failed opening %q", driveConf.DevPath)
+	}
+	defer f.Close()
+
+	scsiID, err := vm.freeSCSIID(monitor)
+	if err != nil {
+		return err
+	}
+
+	nodeName := fmt.Sprintf("lxd_%s", driveConf.DevName)
+	fdsetID, err := monitor.AddFdSet(nodeName, f)
+	if err != nil {
+		return errors.Wrapf(err, "Failed sending file descriptor of %q", driveConf.DevPath)
+	}
+
+	// QEMU keeps its own copy of the file descriptor once the block device is opened, so the fdset can go.
+	defer monitor.RemoveFdSet(fdsetID)
+
+	fileDriver := "file"
+	if shared.IsBlockdevPath(driveConf.DevPath) {
+		fileDriver = "host_device"
+	}
+
+	blockDev := map[string]interface{}{
+		"driver":    "raw",
+		"node-name": nodeName,
+		"discard":   "unmap",
+		"read-only": readonly,
+		"cache": map[string]interface{}{
+			"direct":   cacheMode == "none",
+			"no-flush": cacheMode == "unsafe",
+		},
+		"file": map[string]interface{}{
+			"driver":    fileDriver,
+			"filename":  fmt.Sprintf("/dev/fdset/%d", fdsetID),
+			"aio":       aioMode,
+			"locking":   "off",
+			"read-only": readonly,
+		},
+	}
+
+	device := map[string]string{
+		"id":      fmt.Sprintf("dev-%s", nodeName),
+		"driver":  "scsi-hd",
+		"bus":     "qemu_scsi.0",
+		"channel": "0",
+		"scsi-id": strconv.Itoa(scsiID),
+		"lun":     qemuSCSILun,
+		"drive":   nodeName,
+	}
+
+	err = monitor.AddBlockDevice(blockDev, device)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding block device for disk %q", driveConf.DevName)
+	}
+
+	return nil
+}
+
+// deviceAttachNIC hotplugs a network interface into the running QEMU process using netdev_add and device_add.
+func (vm *qemu) deviceAttachNIC(monitor *qmp.Monitor, nicConfig []deviceConfig.RunConfigItem) error {
+	var devName, nicName, devHwaddr, pciSlotName string
+	for _, nicItem := range nicConfig {
+		if nicItem.Key == "devName" {
+			devName = nicItem.Value
+		} else if nicItem.Key == "link" {
+			nicName = nicItem.Value
+		} else if nicItem.Key == "hwaddr" {
+			devHwaddr = nicItem.Value
+		} else if nicItem.Key == "pciSlotName" {
+			pciSlotName = nicItem.Value
+		}
+	}
+
+	if pciSlotName != "" {
+		return fmt.Errorf("Physical network interfaces cannot be hotplugged")
+	}
+
+	_, busName, err := vm.qemuArchConfig()
+	if err != nil {
+		return err
+	}
+
+	// QEMU is chrooted and unprivileged once started, so open the tap device here and pass the file descriptor.
+	var tapFile *os.File
+	if shared.PathExists(fmt.Sprintf("/sys/class/net/%s/macvtap", nicName)) || shared.PathExists(fmt.Sprintf("/sys/class/net/%s/ipvtap", nicName)) {
+		content, err := ioutil.ReadFile(fmt.Sprintf("/sys/class/net/%s/ifindex", nicName))
+		if err != nil {
+			return errors.Wrapf(err, "Error getting tap device ifindex")
+		}
+
+		ifindex, err := strconv.Atoi(strings.TrimSpace(string(content)))
+		if err != nil {
+			return errors.Wrapf(err, "Error parsing tap device ifindex")
+		}
+
+		tapFile, err = os.OpenFile(fmt.Sprintf("/dev/tap%d", ifindex), os.O_RDWR, 0)
+		if err != nil {
+			return errors.Wrapf(err, "Failed opening tap device")
+		}
+	} else if shared.PathExists(fmt.Sprintf("/sys/class/net/%s/tun_flags", nicName)) {
+		tapFile, err = qemuOpenTap(nicName)
+		if err != nil {
+			return err
+		}
+	} else {
+		return fmt.Errorf("Unrecognised device type")
+	}
+	defer tapFile.Close()
+
+	vhostFile, err := os.OpenFile("/dev/vhost-net", os.O_RDWR, 0)
+	if err != nil {
+		return errors.Wrapf(err, "Failed opening vhost-net device")
+	}
+	defer vhostFile.Close()
+
+	netDevID := fmt.Sprintf("lxd_%s", devName)
+	tapFDName := fmt.Sprintf("%s.tap", netDevID)
+	vhostFDName := fmt.Sprintf("%s.vhost", netDevID)
+
+	err = monitor.SendFile(tapFDName, tapFile)
+	if err != nil {
+		return errors.Wrapf(err, "Failed sending tap file descriptor")
+	}
+
+	err = monitor.SendFile(vhostFDName, vhostFile)
+	if err != nil {
+		return errors.Wrapf(err, "Failed sending vhost-net file descriptor")
+	}
+
+	netDev := map[string]interface{}{
+		"type":    "tap",
+		"id":      netDevID,
+		"fd":      tapFDName,
+		"vhost":   true,
+		"vhostfd": vhostFDName,
+	}
+
+	device := map[string]string{
+		"id":     fmt.Sprintf("dev-%s", netDevID),
+		"netdev": netDevID,
+		"mac":    devHwaddr,
+	}
+
+	if busName == "ccw" {
+		device["driver"] = "virtio-net-ccw"
+	} else {
+		device["driver"] = "virtio-net-pci"
+
+		if busName == "pcie" {
+			// PCIe devices can only be hotplugged into an empty root port.
+			port, err := vm.freePCIePort(monitor)
+			if err != nil {
+				return err
+			}
+
+			device["bus"] = port
+			device["addr"] = "00.0"
+		}
+	}
+
+	err = monitor.AddNIC(netDev, device)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding network interface %q", devName)
+	}
+
+	return nil
+}
+
+// deviceDetach hot-unplugs the drives and network interfaces of a device from the running QEMU process.
+func (vm *qemu) deviceDetach(deviceName string, rawConfig deviceConfig.Device) error {
+	if rawConfig["type"] != "disk" && rawConfig["type"] != "nic" {
+		return nil
+	}
+
+	if shared.IsRootDiskDevice(rawConfig) {
+		return fmt.Errorf("The root disk cannot be removed from a running instance")
+	}
+
+	// Connect to the monitor.
+	monitor, err := qmp.Connect(vm.monitorPath(), qemuSerialChardevName, vm.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	qemuName := fmt.Sprintf("lxd_%s", deviceName)
+	qemuDevName := fmt.Sprintf("dev-%s", qemuName)
+
+	driver, err := monitor.DeviceDriver(qemuDevName)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(driver, "virtio-9p") {
+		return fmt.Errorf("Shared directories cannot be removed from a running instance")
+	}
+
+	if driver == "vfio-pci" || driver == "vfio-ccw" {
+		return fmt.Errorf("Physical network interfaces cannot be removed from a running instance")
+	}
+
+	err = monitor.RemoveDevice(qemuDevName)
+	if err != nil {
+		return errors.Wrapf(err, "Failed removing device %q", deviceName)
+	}
+
+	if rawConfig["type"] == "disk" {
+		err = monitor.RemoveBlockDevice(qemuName)
+		if err != nil {
+			return errors.Wrapf(err, "Failed removing block device for disk %q", deviceName)
+		}
+	} else if driver != "" {
+		err = monitor.RemoveNIC(qemuName)
+		if err != nil {
+			return errors.Wrapf(err, "Failed removing network backend for interface %q", deviceName)
+		}
+	}
+
+	return nil
+}
+
+// freeSCSIID returns a SCSI target ID which isn't used by any of the disks of the running VM.
+func (vm *qemu) freeSCSIID(monitor *qmp.Monitor) (int, error) {
+	addresses, err := monitor.SCSIAddresses()
+	if err != nil {
+		return -1, err
+	}
+
+	usedIDs := map[int]bool{}
+	for _, address := range addresses {
+		usedIDs[address.ID] = true
+	}
+
+	for scsiID := 0; scsiID < qemuSCSIMaxID; scsiID++ {
+		if !usedIDs[scsiID] {
+			return scsiID, nil
+		}
+	}
+
+	return -1, fmt.Errorf("No free SCSI ID available for hotplugging")
+}
+
+// freePCIePort returns the ID of a PCIe root port with no device plugged into it.
+func (vm *qemu) freePCIePort(monitor *qmp.Monitor) (string, error) {
+	devices, err := monitor.QueryPCI()
+	if err != nil {
+		return "", err
+	}
+
+	for _, dev := range devices {
+		if strings.HasPrefix(dev.DevID, "qemu_pcie") && dev.Bridge != nil && len(dev.Bridge.Devices) == 0 {
+			return dev.DevID, nil
+		}
+	}
+
+	return "", fmt.Errorf("No free PCIe port available for hotplugging")
+}
+
+// qemuOpenTap attaches to an existing TAP interface through the TUN driver and returns its file.
+func qemuOpenTap(ifName string) (*os.File, error) {
+	f, err := os.OpenFile("/dev/net/tun", os.O_RDWR, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed opening TUN device")
+	}
+
+	// struct ifreq with the interface name and flags.
+	var ifr struct {
+		name  [unix.IFNAMSIZ]byte
+		flags uint16
+		_     [22]byte
+	}
+
+	copy(ifr.name[:], ifName)
+	ifr.flags = unix.IFF_TAP | unix.IFF_NO_PI | unix.IFF_VNET_HDR
+
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), unix.TUNSETIFF, uintptr(unsafe.Pointer(&ifr)))
+	if errno != 0 {
+		f.Close()
+		return nil, errors.Wrapf(errno, "Failed attaching to TAP interface %q", ifName)
+	}
+
+	return f, nil
+}
+
+// runHooks executes the callback functions returned from a function.
+func (vm *qemu) runHooks(hooks []func() error) error {
+	// Run any post start hooks.
+	if len(hooks) > 0 {
+		for _, hook := range hooks {
+			err := hook()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// generateNetworkConfig writes a cloud-init network config into the config share when any NIC has its addresses
+// set by its device (such as routed NICs), configuring those addresses and the default gateways. The NICs are
+// matched using their MAC address, and the NICs without addresses use DHCP. Nothing is written when the
+// user.network-config key is set.
+func (vm *qemu) generateNetworkConfig(devConfs []*deviceConfig.RunConfig) error {
+	if vm.ExpandedConfig()["user.network-config"] != "" {
+		return nil
+	}
+
+	ethernets := map[string]interface{}{}
+	hasAddresses := false
+
+	for _, runConf := range devConfs {
+		var devName, hwaddr string
+		addresses := []string{}
+		routes := []map[string]interface{}{}
+
+		for _, nicItem := range runConf.NetworkInterface {
+			switch nicItem.Key {
+			case "devName":
+				devName = nicItem.Value
+			case "hwaddr":
+				hwaddr = nicItem.Value
+			case "ipv4.address", "ipv6.address":
+				addresses = append(addresses, nicItem.Value)
+			case "ipv4.gateway":
+				routes = append(routes,
+					map[string]interface{}{"to": fmt.Sprintf("%s/32", nicItem.Value), "scope": "link"},
+					map[string]interface{}{"to": "0.0.0.0/0", "via": nicItem.Value, "on-link": true},
+				)
+			case "ipv6.gateway":
+				routes = append(routes, map[string]interface{}{"to": "::/0", "via": nicItem.Value, "on-link": true})
+			}
+		}
+
+		if devName == "" || hwaddr == "" {
+			continue
+		}
+
+		ethernet := map[string]interface{}{
+			"match": map[string]string{"macaddress": hwaddr},
+		}
+
+		if len(addresses) > 0 {
+			hasAddresses = true
+			ethernet["addresses"] = addresses
+
+			if len(routes) > 0 {
+				ethernet["routes"] = routes
+			}
+		} else {
+			ethernet["dhcp4"] = true
+		}
+
+		ethernets[devName] = ethernet
+	}
+
+	if !hasAddresses {
+		return nil
+	}
+
+	content, err := yaml.Marshal(map[string]interface{}{"version": 2, "ethernets": ethernets})
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(vm.Path(), "config", "cloud-init", "network-config"), content, 0400)
+}
+
+func (vm *qemu) monitorPath() string {
+	return filepath.Join(vm.LogPath(), "qemu.monitor")
+}
+
+func (vm *qemu) nvramPath() string {
+	return filepath.Join(vm.Path(), "qemu.nvram")
+}
+
+func (vm *qemu) spicePath() string {
+	return filepath.Join(vm.LogPath(), "qemu.spice")
+}
+
+// generateConfigShare generates the config share directory that will be exported to the VM via
+// a 9P share. Due to the unknown size of templates inside the images this directory is created
+// inside the VM's config volume so that it can be restricted by quota.
+func (vm *qemu) generateConfigShare() error {
+	// Mount the instance's config volume if needed.
+	ourMount, err := vm.mount()
+	if err != nil {
+		return err
+	}
+
+	if ourMount {
+		defer vm.unmount()
+	}
+
+	configDrivePath := filepath.Join(vm.Path(), "config")
+
+	// Create config drive dir.
+	os.RemoveAll(configDrivePath)
+	err = os.MkdirAll(configDrivePath, 0500)
+	if err != nil {
+		return err
+	}
+
+	// Generate the cloud-init config.
+	err = os.MkdirAll(filepath.Join(configDrivePath, "cloud-init"), 0500)
+	if err != nil {
+		return err
+	}
+
+	if vm.ExpandedConfig()["user.user-data"] != "" {
+		err = ioutil.WriteFile(filepath.Join(configDrivePath, "cloud-init", "user-data"), []byte(vm.ExpandedConfig()["user.user-data"]), 0400)
 		if err != nil {
 			return err
 		}
@@ -1553,7 +2440,7 @@ func (vm *qemu) deviceBootPriorities() (map[string]int, error) {
 	devices := []devicePrios{}
 
 	for devName, devConf := range vm.expandedDevices {
-		if devConf["type"] != "disk" && devConf["type"] != "nic" {
+		if !shared.StringInSlice(devConf["type"], []string{"disk", "nic", "unix-block"}) {
 			continue
 		}
 
@@ -1696,6 +2583,19 @@ func (vm *qemu) generateQemuConfigFile(busName string, devConfs []*deviceConfig.
 		return "", err
 	}
 
+	// USB controller (not available on s390x).
+	if bus.name != "ccw" {
+		devBus, devAddr, multi = bus.allocate(busFunctionGroupGeneric)
+		err = qemuUSB.Execute(sb, map[string]interface{}{
+			"devBus":        devBus,
+			"devAddr":       devAddr,
+			"multifunction": multi,
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
 	devBus, devAddr, multi = bus.allocate(busFunctionGroupNone)
 	err = qemuSCSI.Execute(sb, map[string]interface{}{
 		"bus":           bus.name,
@@ -1778,6 +2678,37 @@ func (vm *qemu) generateQemuConfigFile(busName string, devConfs []*deviceConfig.
 				return "", err
 			}
 		}
+
+		// Add USB devices.
+		for _, usbDev := range runConf.USBDevice {
+			err = vm.addUSBDeviceConfig(sb, bus, usbDev)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		// Add unix devices.
+		for _, unixDev := range runConf.UnixDevice {
+			err = vm.addUnixDeviceConfig(sb, bootIndexes, unixDev)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		// Add TPM device.
+		if len(runConf.TPMDevice) > 0 {
+			err = vm.addTPMDeviceConfig(sb, runConf.TPMDevice)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	// Reserve spare PCIe root ports so that devices can be hotplugged later on.
+	if bus.name == "pcie" {
+		for i := 0; i < qemuPCIeHotplugPorts; i++ {
+			bus.allocate(busFunctionGroupNone)
+		}
 	}
 
 	// Write the agent mount config.
@@ -1813,12 +2744,73 @@ func (vm *qemu) addCPUMemoryConfig(sb *strings.Builder) error {
 	hostNodes := []uint64{}
 	if err == nil {
 		// If not pinning, default to exposing cores.
+		cpuCores := cpuCount
 		ctx["cpuCount"] = cpuCount
 		ctx["cpuSockets"] = 1
-		ctx["cpuCores"] = cpuCount
 		ctx["cpuThreads"] = 1
 		hostNodes = []uint64{0}
+
+		// Allow vCPUs to be hotplugged later on, up to the number of host CPUs.
+		if shared.IsTrue(vm.expandedConfig["limits.cpu.hotplug"]) {
+			if vm.architecture != osarch.ARCH_64BIT_INTEL_X86 {
+				return fmt.Errorf("limits.cpu.hotplug isn't supported on this architecture")
+			}
+
+			cpuMaxCount, err := vm.hotplugMaxCPUs()
+			if err != nil {
+				return err
+			}
+
+			if cpuCount < cpuMaxCount {
+				ctx["cpuMaxCount"] = cpuMaxCount
+				cpuCores = cpuMaxCount
+			}
+		}
+
+		ctx["cpuCores"] = cpuCores
+
+		// Spread the vCPUs and memory over the selected host NUMA nodes.
+		numaHostNodes, _, err := vm.cpuNUMANodes()
+		if err != nil {
+			return err
+		}
+
+		if len(numaHostNodes) > 0 {
+			if vm.architecture != osarch.ARCH_64BIT_INTEL_X86 {
+				return fmt.Errorf("limits.cpu.nodes isn't supported on this architecture")
+			}
+
+			hostNodes = numaHostNodes
+
+			numaIDs := []uint64{}
+			for numaNode := range hostNodes {
+				numaIDs = append(numaIDs, uint64(numaNode))
+			}
+
+			// Assign the vCPUs (including those which may be hotplugged) to the nodes in turn.
+			numa := []map[string]uint64{}
+			for core := 0; core < cpuCores; core++ {
+				numa = append(numa, map[string]uint64{
+					"node":   uint64(core % len(hostNodes)),
+					"socket": 0,
+					"core":   uint64(core),
+					"thread": 0,
+				})
+			}
+
+			ctx["cpuNumaNodes"] = numaIDs
+			ctx["cpuNumaMapping"] = numa
+			ctx["cpuNumaHostNodes"] = hostNodes
+		}
 	} else {
+		if vm.expandedConfig["limits.cpu.nodes"] != "" {
+			return fmt.Errorf("limits.cpu.nodes can't be used with CPU pinning, memory is placed on the NUMA nodes of the pinned CPUs")
+		}
+
+		if shared.IsTrue(vm.expandedConfig["limits.cpu.hotplug"]) {
+			return fmt.Errorf("limits.cpu.hotplug can't be used with CPU pinning")
+		}
+
 		// Expand to a set of CPU identifiers and get the pinning map.
 		nrSockets, nrCores, nrThreads, vcpus, numaNodes, err := vm.cpuTopology(cpus)
 		if err != nil {
@@ -1841,24 +2833,25 @@ func (vm *qemu) addCPUMemoryConfig(sb *strings.Builder) error {
 			}
 		}
 
-		// Prepare the NUMA map.
-		numa := []map[string]uint64{}
-		numaIDs := []uint64{}
-		numaNode := uint64(0)
-		for hostNode, entry := range numaNodes {
+		// Prepare the NUMA map, guest NUMA nodes follow the order of the host NUMA nodes.
+		for hostNode := range numaNodes {
 			hostNodes = append(hostNodes, hostNode)
+		}
 
-			numaIDs = append(numaIDs, numaNode)
-			for _, vcpu := range entry {
+		sort.Slice(hostNodes, func(i, j int) bool { return hostNodes[i] < hostNodes[j] })
+
+		numa := []map[string]uint64{}
+		numaIDs := []uint64{}
+		for numaNode, hostNode := range hostNodes {
+			numaIDs = append(numaIDs, uint64(numaNode))
+			for _, vcpu := range numaNodes[hostNode] {
 				numa = append(numa, map[string]uint64{
-					"node":   numaNode,
+					"node":   uint64(numaNode),
 					"socket": vcpuSocket[vcpu],
 					"core":   vcpuCore[vcpu],
 					"thread": vcpuThread[vcpu],
 				})
 			}
-
-			numaNode++
 		}
 
 		// Prepare context.
@@ -1898,9 +2891,36 @@ func (vm *qemu) addCPUMemoryConfig(sb *strings.Builder) error {
 	memSizeBytes = nodeMemory * int64(len(hostNodes))
 	ctx["memory"] = nodeMemory
 
+	// Make sure that the memory bound to each host NUMA node fits in it.
+	if vm.architecture == osarch.ARCH_64BIT_INTEL_X86 && ctx["cpuNumaHostNodes"] != nil {
+		err = vm.checkNUMAMemory(hostNodes, nodeMemory*1024*1024)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Allow memory to be hotplugged later on, up to the memory of the host.
+	memMaxSizeBytes := int64(0)
+	if shared.IsTrue(vm.expandedConfig["limits.memory.hotplug"]) {
+		if vm.architecture != osarch.ARCH_64BIT_INTEL_X86 {
+			return fmt.Errorf("limits.memory.hotplug isn't supported on this architecture")
+		}
+
+		memMaxSize, err := vm.hotplugMaxMemory()
+		if err != nil {
+			return err
+		}
+
+		if memSizeBytes < memMaxSize {
+			memMaxSizeBytes = memMaxSize
+		}
+	}
+
 	err = qemuMemory.Execute(sb, map[string]interface{}{
-		"architecture": vm.architectureName,
-		"memSizeBytes": memSizeBytes,
+		"architecture":    vm.architectureName,
+		"memSizeBytes":    memSizeBytes,
+		"memMaxSizeBytes": memMaxSizeBytes,
+		"memSlots":        qemuMemoryHotplugSlots,
 	})
 	if err != nil {
 		return err
@@ -2009,6 +3029,25 @@ func (vm *qemu) addDriveDirConfig(sb *strings.Builder, bus *qemuBus, fdFiles *[]
 
 // addDriveConfig adds the qemu config required for adding a supplementary drive.
 func (vm *qemu) addDriveConfig(sb *strings.Builder, bootIndexes map[string]int, driveConf deviceConfig.MountEntryItem) error {
+	aioMode, cacheMode, err := vm.driveIOModes(driveConf)
+	if err != nil {
+		return err
+	}
+
+	return qemuDrive.Execute(sb, map[string]interface{}{
+		"devName":   driveConf.DevName,
+		"devPath":   driveConf.DevPath,
+		"bootIndex": bootIndexes[driveConf.DevName],
+		"lun":       qemuSCSILun,
+		"cacheMode": cacheMode,
+		"aioMode":   aioMode,
+		"shared":    driveConf.TargetPath != "/" && !strings.HasPrefix(driveConf.DevPath, "rbd:"),
+		"readonly":  shared.StringInSlice("ro", driveConf.Opts),
+	})
+}
+
+// driveIOModes returns the qemu aio and cache modes to use for a drive.
+func (vm *qemu) driveIOModes(driveConf deviceConfig.MountEntryItem) (string, string, error) {
 	// Use native kernel async IO and O_DIRECT by default.
 	aioMode := "native"
 	cacheMode := "none" // Bypass host cache, use O_DIRECT semantics.
@@ -2022,7 +3061,7 @@ func (vm *qemu) addDriveConfig(sb *strings.Builder, bootIndexes map[string]int,
 		// Disk dev path is a file, check whether it is located on a ZFS filesystem.
 		fsType, err := util.FilesystemDetect(driveConf.DevPath)
 		if err != nil {
-			return errors.Wrapf(err, "Failed detecting filesystem type of %q", driveConf.DevPath)
+			return "", "", errors.Wrapf(err, "Failed detecting filesystem type of %q", driveConf.DevPath)
 		}
 
 		// If FS is ZFS, avoid using direct I/O and use host page cache only.
@@ -2035,14 +3074,7 @@ func (vm *qemu) addDriveConfig(sb *strings.Builder, bootIndexes map[string]int,
 		}
 	}
 
-	return qemuDrive.Execute(sb, map[string]interface{}{
-		"devName":   driveConf.DevName,
-		"devPath":   driveConf.DevPath,
-		"bootIndex": bootIndexes[driveConf.DevName],
-		"cacheMode": cacheMode,
-		"aioMode":   aioMode,
-		"shared":    driveConf.TargetPath != "/" && !strings.HasPrefix(driveConf.DevPath, "rbd:"),
-	})
+	return aioMode, cacheMode, nil
 }
 
 // addNetDevConfig adds the qemu config required for adding a network device.
@@ -2068,9 +3100,9 @@ func (vm *qemu) addNetDevConfig(sb *strings.Builder, bus *qemuBus, bootIndexes m
 		"bootIndex": bootIndexes[devName],
 	}
 
-	// Detect MACVTAP interface types and figure out which tap device is being used.
+	// Detect MACVTAP and IPVTAP interface types and figure out which tap device is being used.
 	// This is so we can open a file handle to the tap device and pass it to the qemu process.
-	if shared.PathExists(fmt.Sprintf("/sys/class/net/%s/macvtap", nicName)) {
+	if shared.PathExists(fmt.Sprintf("/sys/class/net/%s/macvtap", nicName)) || shared.PathExists(fmt.Sprintf("/sys/class/net/%s/ipvtap", nicName)) {
 		content, err := ioutil.ReadFile(fmt.Sprintf("/sys/class/net/%s/ifindex", nicName))
 		if err != nil {
 			return errors.Wrapf(err, "Error getting tap device ifindex")
@@ -2185,6 +3217,51 @@ func (vm *qemu) addGPUDevConfig(sb *strings.Builder, bus *qemuBus, gpuConfig []d
 	return nil
 }
 
+// addUSBDeviceConfig adds the qemu config required for passing through a host USB device.
+func (vm *qemu) addUSBDeviceConfig(sb *strings.Builder, bus *qemuBus, usbDev deviceConfig.USBDeviceItem) error {
+	if bus.name == "ccw" {
+		return fmt.Errorf("USB devices are not supported on this architecture")
+	}
+
+	return qemuUSBDev.Execute(sb, map[string]interface{}{
+		"devName":    usbDev.DeviceName,
+		"hostDevice": usbDev.HostDevicePath,
+	})
+}
+
+// addUnixDeviceConfig adds the qemu config required for passing through a host unix device.
+// Block devices are added as SCSI disks and character devices as virtio serial ports.
+func (vm *qemu) addUnixDeviceConfig(sb *strings.Builder, bootIndexes map[string]int, unixDev deviceConfig.UnixDeviceItem) error {
+	if unixDev.Type == "unix-block" {
+		return vm.addDriveConfig(sb, bootIndexes, deviceConfig.MountEntryItem{
+			DevName: unixDev.DeviceName,
+			DevPath: unixDev.HostDevicePath,
+		})
+	}
+
+	return qemuUnixChar.Execute(sb, map[string]interface{}{
+		"devName": unixDev.DeviceName,
+		"devPath": unixDev.HostDevicePath,
+	})
+}
+
+// addTPMDeviceConfig adds the qemu config required for connecting to the device's swtpm process.
+func (vm *qemu) addTPMDeviceConfig(sb *strings.Builder, tpmConfig []deviceConfig.RunConfigItem) error {
+	if vm.architecture == osarch.ARCH_64BIT_S390_BIG_ENDIAN {
+		return fmt.Errorf("TPM devices are not supported on this architecture")
+	}
+
+	tplFields := map[string]interface{}{
+		"architecture": vm.architectureName,
+	}
+
+	for _, item := range tpmConfig {
+		tplFields[item.Key] = item.Value
+	}
+
+	return qemuTPM.Execute(sb, tplFields)
+}
+
 // pidFilePath returns the path where the qemu process should write its PID.
 func (vm *qemu) pidFilePath() string {
 	return filepath.Join(vm.LogPath(), "qemu.pid")
@@ -2216,11 +3293,6 @@ func (vm *qemu) Stop(stateful bool) error {
 		return fmt.Errorf("The instance is already stopped")
 	}
 
-	// Check that no stateful stop was requested.
-	if stateful {
-		return fmt.Errorf("Stateful stop isn't supported for VMs at this time")
-	}
-
 	// Setup a new operation.
 	op, err := operationlock.Create(vm.id, "stop", false, true)
 	if err != nil {
@@ -2231,6 +3303,12 @@ func (vm *qemu) Stop(stateful bool) error {
 	monitor, err := qmp.Connect(vm.monitorPath(), qemuSerialChardevName, vm.getMonitorEventHandler())
 	if err != nil {
 		// If we fail to connect, it's most likely because the VM is already off.
+		if stateful {
+			err = errors.Wrap(err, "Failed connecting to the QMP monitor")
+			op.Done(err)
+			return err
+		}
+
 		op.Done(nil)
 		return nil
 	}
@@ -2238,7 +3316,7 @@ func (vm *qemu) Stop(stateful bool) error {
 	// Get the wait channel.
 	chDisconnect, err := monitor.Wait()
 	if err != nil {
-		if err == qmp.ErrMonitorDisconnect {
+		if err == qmp.ErrMonitorDisconnect && !stateful {
 			op.Done(nil)
 			return nil
 		}
@@ -2247,6 +3325,23 @@ func (vm *qemu) Stop(stateful bool) error {
 		return err
 	}
 
+	// Handle stateful stop.
+	if stateful {
+		// Dump the VM memory and device state into the state file.
+		err = vm.saveState(monitor)
+		if err != nil {
+			op.Done(err)
+			return err
+		}
+
+		vm.stateful = true
+		err = vm.state.Cluster.UpdateInstanceStatefulFlag(vm.id, true)
+		if err != nil {
+			op.Done(err)
+			return errors.Wrap(err, "Persist stateful flag")
+		}
+	}
+
 	// Send the quit command.
 	err = monitor.Quit()
 	if err != nil {
@@ -2296,12 +3391,13 @@ func (vm *qemu) IsPrivileged() bool {
 
 // Restore restores an instance snapshot.
 func (vm *qemu) Restore(source instance.Instance, stateful bool) error {
-	if stateful {
-		return fmt.Errorf("Stateful snapshots of VMs aren't supported yet")
-	}
-
 	var ctxMap log.Ctx
 
+	// Check the snapshot has a saved state to resume from before touching the instance.
+	if stateful && !source.IsStateful() {
+		return fmt.Errorf("Stateful snapshot restore requested by snapshot is stateless")
+	}
+
 	// Load the storage driver.
 	pool, err := storagePools.GetPoolByInstance(vm.state, vm)
 	if err != nil {
@@ -2401,6 +3497,19 @@ func (vm *qemu) Restore(source instance.Instance, stateful bool) error {
 
 	vm.state.Events.SendLifecycle(vm.project, "virtual-machine-snapshot-restored", fmt.Sprintf("/1.0/virtual-machines/%s", vm.name), map[string]interface{}{"snapshot_name": vm.name})
 
+	// If the snapshot carries a saved state, resume from it.
+	if stateful {
+		logger.Debug("Performing stateful restore", ctxMap)
+		vm.stateful = true
+		err = vm.state.Cluster.UpdateInstanceStatefulFlag(vm.id, true)
+		if err != nil {
+			return errors.Wrap(err, "Persist stateful flag")
+		}
+
+		logger.Info("Restored instance", ctxMap)
+		return vm.Start(true)
+	}
+
 	// Restart the insance.
 	if wasRunning {
 		logger.Info("Restored instance", ctxMap)
@@ -2602,132 +3711,6 @@ func (vm *qemu) Rename(newName string) error {
 
 // Update the instance config.
 func (vm *qemu) Update(args db.InstanceArgs, userRequested bool) error {
-	// Only user.* keys can be changed on a running VM
-	if vm.IsRunning() {
-		if args.Config == nil {
-			args.Config = map[string]string{}
-		}
-
-		if userRequested {
-			// Validate the new config.
-			err := instance.ValidConfig(vm.state.OS, args.Config, false, false)
-			if err != nil {
-				return errors.Wrap(err, "Invalid config")
-			}
-		}
-
-		oldExpandedConfig := map[string]string{}
-		err := shared.DeepCopy(&vm.expandedConfig, &oldExpandedConfig)
-		if err != nil {
-			return err
-		}
-
-		oldLocalConfig := map[string]string{}
-		err = shared.DeepCopy(&vm.localConfig, &oldLocalConfig)
-		if err != nil {
-			return err
-		}
-
-		undoChanges := true
-		defer func() {
-			if undoChanges {
-				vm.expandedConfig = oldExpandedConfig
-				vm.localConfig = oldLocalConfig
-			}
-		}()
-
-		vm.localConfig = args.Config
-
-		// Expand the config and refresh the LXC config.
-		err = vm.expandConfig(nil)
-		if err != nil {
-			return errors.Wrap(err, "Expand config")
-		}
-
-		// Diff the configurations.
-		changedConfig := []string{}
-		for key := range oldExpandedConfig {
-			if oldExpandedConfig[key] != vm.expandedConfig[key] {
-				if !shared.StringInSlice(key, changedConfig) {
-					changedConfig = append(changedConfig, key)
-				}
-			}
-		}
-
-		for key := range vm.expandedConfig {
-			if oldExpandedConfig[key] != vm.expandedConfig[key] {
-				if !shared.StringInSlice(key, changedConfig) {
-					changedConfig = append(changedConfig, key)
-				}
-			}
-		}
-
-		for _, key := range changedConfig {
-			if !strings.HasPrefix(key, "user.") {
-				return fmt.Errorf("Only user.* keys can be updated on running VMs")
-			}
-		}
-
-		if userRequested {
-			// Do some validation of the config diff.
-			err = instance.ValidConfig(vm.state.OS, vm.expandedConfig, false, true)
-			if err != nil {
-				return errors.Wrap(err, "Invalid expanded config")
-			}
-		}
-
-		err = vm.state.Cluster.Transaction(func(tx *db.ClusterTx) error {
-			object, err := tx.GetInstance(vm.project, vm.name)
-			if err != nil {
-				return err
-			}
-
-			object.Config = vm.localConfig
-
-			return tx.UpdateInstance(vm.project, vm.name, *object)
-		})
-		if err != nil {
-			return errors.Wrap(err, "Failed to update database")
-		}
-
-		err = vm.UpdateBackupFile()
-		if err != nil && !os.IsNotExist(err) {
-			return errors.Wrap(err, "Failed to write backup file")
-		}
-
-		// Success, update the closure to mark that the changes should be kept.
-		undoChanges = false
-
-		err = vm.writeInstanceData()
-		if err != nil {
-			return errors.Wrap(err, "Failed to write instance-data file")
-		}
-
-		// Send devlxd notifications only for user.* key changes
-		for _, key := range changedConfig {
-			if !strings.HasPrefix(key, "user.") {
-				continue
-			}
-
-			msg := map[string]string{
-				"key":       key,
-				"old_value": oldExpandedConfig[key],
-				"value":     vm.expandedConfig[key],
-			}
-
-			err = vm.devlxdEventSend("config", msg)
-			if err != nil {
-				return err
-			}
-		}
-
-		endpoint := fmt.Sprintf("/1.0/virtual-machines/%s", vm.name)
-
-		vm.state.Events.SendLifecycle(vm.project, "virtual-machine-updated", endpoint, nil)
-
-		return nil
-	}
-
 	// Set sane defaults for unset keys.
 	if args.Project == "" {
 		args.Project = project.Default
@@ -2750,14 +3733,19 @@ func (vm *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 	}
 
 	if userRequested {
+		err := keepBackupUUID(vm.localConfig, args.Config)
+		if err != nil {
+			return err
+		}
+
 		// Validate the new config.
-		err := instance.ValidConfig(vm.state.OS, args.Config, false, false)
+		err = instance.ValidConfig(vm.state.OS, args.Config, false, false)
 		if err != nil {
 			return errors.Wrap(err, "Invalid config")
 		}
 
 		// Validate the new devices without using expanded devices validation (expensive checks disabled).
-		err = instance.ValidDevices(vm.state, vm.state.Cluster, vm.Type(), args.Devices, false)
+		err = instance.ValidDevices(vm.state, vm.state.Cluster, vm.Project(), vm.Type(), args.Devices, false)
 		if err != nil {
 			return errors.Wrap(err, "Invalid devices")
 		}
@@ -2893,18 +3881,29 @@ func (vm *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 		}
 	}
 
+	// Only user.* keys and the CPU and memory limits can be changed on a running VM, devices get
+	// hotplugged below.
+	isRunning := vm.IsRunning()
+	if isRunning {
+		for _, key := range changedConfig {
+			if !strings.HasPrefix(key, "user.") && !shared.StringInSlice(key, []string{"limits.cpu", "limits.memory"}) {
+				return fmt.Errorf("Only user.*, limits.cpu and limits.memory keys can be updated on running VMs")
+			}
+		}
+	}
+
 	// Diff the devices.
 	removeDevices, addDevices, updateDevices, updateDiff := oldExpandedDevices.Update(vm.expandedDevices, func(oldDevice deviceConfig.Device, newDevice deviceConfig.Device) []string {
 		// This function needs to return a list of fields that are excluded from differences
 		// between oldDevice and newDevice. The result of this is that as long as the
 		// devices are otherwise identical except for the fields returned here, then the
 		// device is considered to be being "updated" rather than "added & removed".
-		oldNICType, err := nictype.NICType(vm.state, newDevice)
+		oldNICType, err := nictype.NICType(vm.state, vm.Project(), newDevice)
 		if err != nil {
 			return []string{} // Cannot hot-update due to config error.
 		}
 
-		newNICType, err := nictype.NICType(vm.state, oldDevice)
+		newNICType, err := nictype.NICType(vm.state, vm.Project(), oldDevice)
 		if err != nil {
 			return []string{} // Cannot hot-update due to config error.
 		}
@@ -2930,12 +3929,40 @@ func (vm *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 		}
 
 		// Do full expanded validation of the devices diff.
-		err = instance.ValidDevices(vm.state, vm.state.Cluster, vm.Type(), vm.expandedDevices, true)
+		err = instance.ValidDevices(vm.state, vm.state.Cluster, vm.Project(), vm.Type(), vm.expandedDevices, true)
 		if err != nil {
 			return errors.Wrap(err, "Invalid expanded devices")
 		}
 	}
 
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Resize the running VM, restoring its previous size if the update fails later on.
+	if isRunning {
+		oldCPULimit := oldExpandedConfig["limits.cpu"]
+		newCPULimit := vm.expandedConfig["limits.cpu"]
+		if shared.StringInSlice("limits.cpu", changedConfig) {
+			err = vm.updateCPULimit(oldCPULimit, newCPULimit)
+			if err != nil {
+				return errors.Wrap(err, "Failed updating CPU limit")
+			}
+
+			revert.Add(func() { vm.updateCPULimit(newCPULimit, oldCPULimit) })
+		}
+
+		oldMemoryLimit := oldExpandedConfig["limits.memory"]
+		newMemoryLimit := vm.expandedConfig["limits.memory"]
+		if shared.StringInSlice("limits.memory", changedConfig) {
+			err = vm.updateMemoryLimit(newMemoryLimit)
+			if err != nil {
+				return errors.Wrap(err, "Failed updating memory limit")
+			}
+
+			revert.Add(func() { vm.updateMemoryLimit(oldMemoryLimit) })
+		}
+	}
+
 	// Use the device interface to apply update changes.
 	err = vm.updateDevices(removeDevices, addDevices, updateDevices, oldExpandedDevices)
 	if err != nil {
@@ -2999,18 +4026,246 @@ func (vm *qemu) Update(args db.InstanceArgs, userRequested bool) error {
 
 	// Success, update the closure to mark that the changes should be kept.
 	undoChanges = false
+	revert.Success()
+
+	if isRunning {
+		err = vm.writeInstanceData()
+		if err != nil {
+			return errors.Wrap(err, "Failed to write instance-data file")
+		}
+
+		// Send devlxd notifications only for user.* key changes
+		for _, key := range changedConfig {
+			if !strings.HasPrefix(key, "user.") {
+				continue
+			}
+
+			msg := map[string]string{
+				"key":       key,
+				"old_value": oldExpandedConfig[key],
+				"value":     vm.expandedConfig[key],
+			}
+
+			err = vm.devlxdEventSend("config", msg)
+			if err != nil {
+				return err
+			}
+		}
+	}
 
 	var endpoint string
 
-	if vm.IsSnapshot() {
-		parentName, snapName, _ := shared.InstanceGetParentAndSnapshotName(vm.name)
-		endpoint = fmt.Sprintf("/1.0/virtual-machines/%s/snapshots/%s", parentName, snapName)
-	} else {
-		endpoint = fmt.Sprintf("/1.0/virtual-machines/%s", vm.name)
+	if vm.IsSnapshot() {
+		parentName, snapName, _ := shared.InstanceGetParentAndSnapshotName(vm.name)
+		endpoint = fmt.Sprintf("/1.0/virtual-machines/%s/snapshots/%s", parentName, snapName)
+	} else {
+		endpoint = fmt.Sprintf("/1.0/virtual-machines/%s", vm.name)
+	}
+
+	vm.state.Events.SendLifecycle(vm.project, "virtual-machine-updated", endpoint, nil)
+	return nil
+}
+
+// checkNotResized returns an error if the running VM has been resized since it was started.
+func (vm *qemu) checkNotResized(monitor *qmp.Monitor) error {
+	errResized := fmt.Errorf("The VM must be restarted after being resized before its state can be saved")
+
+	baseMemory, pluggedMemory, err := monitor.MemorySizeSummary()
+	if err != nil {
+		return err
+	}
+
+	balloonMemory, err := monitor.QueryBalloon()
+	if err != nil {
+		return err
+	}
+
+	if pluggedMemory > 0 || balloonMemory != baseMemory {
+		return errResized
+	}
+
+	cpus, err := monitor.QueryHotpluggableCPUs()
+	if err != nil {
+		return err
+	}
+
+	for _, cpu := range cpus {
+		if strings.HasPrefix(cpu.QOMPath, "/machine/peripheral/") {
+			return errResized
+		}
+	}
+
+	return nil
+}
+
+// updateCPULimit hotplugs or unplugs vCPUs so that the running VM has the number of vCPUs in the new limit.
+func (vm *qemu) updateCPULimit(oldLimit string, newLimit string) error {
+	// Default to a single core.
+	if oldLimit == "" {
+		oldLimit = "1"
+	}
+
+	if newLimit == "" {
+		newLimit = "1"
+	}
+
+	_, errOld := strconv.Atoi(oldLimit)
+	cpuCount, errNew := strconv.Atoi(newLimit)
+	if errOld != nil || errNew != nil {
+		return fmt.Errorf("CPU pinning can't be changed on a running VM")
+	}
+
+	if vm.architecture != osarch.ARCH_64BIT_INTEL_X86 {
+		return fmt.Errorf("CPU hotplug isn't supported on this architecture")
+	}
+
+	// The VM only has room for more vCPUs if started with limits.cpu.hotplug, which can't change while running.
+	if !shared.IsTrue(vm.expandedConfig["limits.cpu.hotplug"]) {
+		return fmt.Errorf("The number of vCPUs can only be changed on a running VM with limits.cpu.hotplug enabled")
+	}
+
+	// Connect to the monitor.
+	monitor, err := qmp.Connect(vm.monitorPath(), qemuSerialChardevName, vm.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	cpus, err := monitor.QueryHotpluggableCPUs()
+	if err != nil {
+		return err
+	}
+
+	if cpuCount > len(cpus) {
+		return fmt.Errorf("The VM can't have more than %d vCPUs without being restarted", len(cpus))
+	}
+
+	plugged := []qmp.HotpluggableCPU{}
+	unplugged := []qmp.HotpluggableCPU{}
+	for _, cpu := range cpus {
+		if cpu.QOMPath != "" {
+			plugged = append(plugged, cpu)
+		} else {
+			unplugged = append(unplugged, cpu)
+		}
+	}
+
+	// QEMU lists the slots from the last vCPU to the first, so add from the end and remove from the start.
+	for i := 0; len(plugged)+i < cpuCount; i++ {
+		cpu := unplugged[len(unplugged)-1-i]
+
+		err = monitor.AddCPU(fmt.Sprintf("qemu_cpu%d", len(plugged)+i), cpu)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Keep new vCPUs on the NUMA node they were placed on.
+	if len(plugged) < cpuCount {
+		err = vm.setCPUAffinity(monitor)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := 0; len(plugged)-i > cpuCount; i++ {
+		err = monitor.RemoveCPU(plugged[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateMemoryLimit resizes the memory of the running VM to the new limit. Memory is handed back and forth
+// using the balloon device, after hotplugging whatever the VM is missing to reach the limit.
+func (vm *qemu) updateMemoryLimit(newLimit string) error {
+	if newLimit == "" {
+		newLimit = "1GiB" // Default to 1GiB if no memory limit specified.
+	}
+
+	memSizeBytes, err := units.ParseByteSizeString(newLimit)
+	if err != nil {
+		return fmt.Errorf("limits.memory invalid: %v", err)
+	}
+
+	// Connect to the monitor.
+	monitor, err := qmp.Connect(vm.monitorPath(), qemuSerialChardevName, vm.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	baseMemory, pluggedMemory, err := monitor.MemorySizeSummary()
+	if err != nil {
+		return err
+	}
+
+	if memSizeBytes > baseMemory+pluggedMemory {
+		if vm.architecture != osarch.ARCH_64BIT_INTEL_X86 {
+			return fmt.Errorf("Memory can't be increased beyond %s on this architecture without restarting the VM", units.GetByteSizeString(baseMemory+pluggedMemory, 2))
+		}
+
+		if !shared.IsTrue(vm.expandedConfig["limits.memory.hotplug"]) {
+			return fmt.Errorf("Memory can't be increased beyond %s without restarting the VM unless limits.memory.hotplug is enabled", units.GetByteSizeString(baseMemory+pluggedMemory, 2))
+		}
+
+		devices, err := monitor.MemoryDevices()
+		if err != nil {
+			return err
+		}
+
+		// Hotplug the missing memory as a new memory device.
+		sizeBytes := memSizeBytes - baseMemory - pluggedMemory
+		if sizeBytes%qemuMemoryHotplugAlign != 0 {
+			sizeBytes = (sizeBytes/qemuMemoryHotplugAlign + 1) * qemuMemoryHotplugAlign
+		}
+
+		memBackendID := fmt.Sprintf("qemu_mem%d", len(devices))
+		memBackend := map[string]interface{}{
+			"qom-type": "memory-backend-ram",
+			"id":       memBackendID,
+			"size":     sizeBytes,
+		}
+
+		// QEMU can't open the hugepages mount from its chroot, so use an anonymous hugetlbfs file instead.
+		if shared.IsTrue(vm.expandedConfig["limits.memory.hugepages"]) {
+			memBackend["qom-type"] = "memory-backend-memfd"
+			memBackend["hugetlb"] = true
+		}
+
+		device := map[string]string{
+			"driver": "pc-dimm",
+			"id":     fmt.Sprintf("dev-%s", memBackendID),
+			"memdev": memBackendID,
+			"node":   "0",
+		}
+
+		// Place the new memory on the selected host NUMA nodes in turn.
+		hostNodes, _, err := vm.cpuNUMANodes()
+		if err != nil {
+			return err
+		}
+
+		if len(hostNodes) > 0 {
+			numaNode := len(devices) % len(hostNodes)
+
+			err = vm.checkNUMAMemory([]uint64{hostNodes[numaNode]}, sizeBytes)
+			if err != nil {
+				return err
+			}
+
+			memBackend["host-nodes"] = []uint64{hostNodes[numaNode]}
+			memBackend["policy"] = "bind"
+			device["node"] = strconv.Itoa(numaNode)
+		}
+
+		err = monitor.AddMemory(memBackend, device)
+		if err != nil {
+			return err
+		}
 	}
 
-	vm.state.Events.SendLifecycle(vm.project, "virtual-machine-updated", endpoint, nil)
-	return nil
+	return monitor.Balloon(memSizeBytes)
 }
 
 func (vm *qemu) updateDevices(removeDevices deviceConfig.Devices, addDevices deviceConfig.Devices, updateDevices deviceConfig.Devices, oldExpandedDevices deviceConfig.Devices) error {
@@ -3089,12 +4344,12 @@ func (vm *qemu) deviceResetVolatile(devName string, oldConfig, newConfig deviceC
 	volatileClear := make(map[string]string)
 	devicePrefix := fmt.Sprintf("volatile.%s.", devName)
 
-	newNICType, err := nictype.NICType(vm.state, newConfig)
+	newNICType, err := nictype.NICType(vm.state, vm.Project(), newConfig)
 	if err != nil {
 		return err
 	}
 
-	oldNICType, err := nictype.NICType(vm.state, oldConfig)
+	oldNICType, err := nictype.NICType(vm.state, vm.Project(), oldConfig)
 	if err != nil {
 		return err
 	}
@@ -3613,6 +4868,68 @@ func (vm *qemu) Migrate(args *instance.CriuMigrationArgs) error {
 	return instance.ErrNotImplemented
 }
 
+// MigrateSend migrates the memory and device state of the running VM into w.
+// On success the VM is left paused, so the caller can either stop it (migration succeeded) or resume it with
+// Unfreeze (migration failed).
+func (vm *qemu) MigrateSend(w io.Writer) error {
+	if !vm.IsRunning() {
+		return fmt.Errorf("The instance isn't running")
+	}
+
+	// Connect to the monitor.
+	monitor, err := qmp.Connect(vm.monitorPath(), qemuSerialChardevName, vm.getMonitorEventHandler())
+	if err != nil {
+		return err
+	}
+
+	return vm.migrateState(monitor, w)
+}
+
+// MigrateReceive starts the VM from the compressed memory and device state streamed from r by MigrateSend on
+// the source, without storing it first.
+func (vm *qemu) MigrateReceive(r io.Reader) error {
+	return vm.start(true, r)
+}
+
+// ConnectAgentProxy returns a websocket connection through the lxd-agent to the given address inside the VM.
+// The address is in the same "<type>:<address>" format as used by the proxy device. The agent connection is
+// set up on first use and then reused for subsequent connections.
+func (vm *qemu) ConnectAgentProxy(connectAddr string) (*websocket.Conn, error) {
+	if !vm.IsRunning() {
+		return nil, fmt.Errorf("The instance isn't running")
+	}
+
+	vm.agentProxyMu.Lock()
+	defer vm.agentProxyMu.Unlock()
+
+	if vm.agentProxy == nil {
+		client, err := vm.getAgentClient()
+		if err != nil {
+			return nil, err
+		}
+
+		agent, err := lxdClient.ConnectLXDHTTP(&lxdClient.ConnectionArgs{SkipGetServer: true}, client)
+		if err != nil {
+			logger.Errorf("Failed to connect to lxd-agent on %s: %v", vm.Name(), err)
+			return nil, fmt.Errorf("Failed to connect to lxd-agent")
+		}
+
+		vm.agentProxy = agent
+	}
+
+	values := url.Values{}
+	values.Set("connect", connectAddr)
+
+	conn, err := vm.agentProxy.RawWebsocket(fmt.Sprintf("/proxy?%s", values.Encode()))
+	if err != nil {
+		// Set up a new agent connection next time in case the agent was restarted.
+		vm.agentProxy = nil
+		return nil, err
+	}
+
+	return conn, nil
+}
+
 // CGroupSet is not implemented for VMs.
 func (vm *qemu) CGroupSet(key string, value string) error {
 	return instance.ErrNotImplemented
@@ -3844,6 +5161,133 @@ func (vm *qemu) vga() (*os.File, chan error, error) {
 	return file, nil, nil
 }
 
+// ConsoleScreenshot captures the VGA console of the running VM and writes it to w as a PNG image.
+func (vm *qemu) ConsoleScreenshot(w io.Writer) error {
+	monitor, err := qmp.Connect(vm.monitorPath(), qemuSerialChardevName, vm.getMonitorEventHandler())
+	if err != nil {
+		return err // The VM isn't running as no monitor socket available.
+	}
+
+	// QEMU is confined to the instance directory, so have it write to a file descriptor we pass it.
+	screenshotPath := filepath.Join(vm.LogPath(), "screenshot.ppm")
+	f, err := os.OpenFile(screenshotPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "Failed creating screenshot file %q", screenshotPath)
+	}
+	defer os.Remove(screenshotPath)
+	defer f.Close()
+
+	fdsetID, err := monitor.AddFdSet("qemu_screenshot", f)
+	if err != nil {
+		return errors.Wrap(err, "Failed passing screenshot file to QEMU")
+	}
+	defer monitor.RemoveFdSet(fdsetID)
+
+	err = monitor.Screendump(fmt.Sprintf("/dev/fdset/%d", fdsetID))
+	if err != nil {
+		return errors.Wrap(err, "Failed capturing screenshot")
+	}
+
+	ppm, err := os.Open(screenshotPath)
+	if err != nil {
+		return err
+	}
+	defer ppm.Close()
+
+	img, err := qemuDecodePPM(bufio.NewReader(ppm))
+	if err != nil {
+		return errors.Wrap(err, "Failed decoding screenshot")
+	}
+
+	return png.Encode(w, img)
+}
+
+// ConsoleSendKeys presses the given keys at the same time on the VGA console of the running VM.
+// Keys are QEMU key codes such as "ctrl", "alt", "delete", "ret" or "a".
+func (vm *qemu) ConsoleSendKeys(keys []string, holdTime int) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("No keys specified")
+	}
+
+	monitor, err := qmp.Connect(vm.monitorPath(), qemuSerialChardevName, vm.getMonitorEventHandler())
+	if err != nil {
+		return err // The VM isn't running as no monitor socket available.
+	}
+
+	err = monitor.SendKey(keys, holdTime)
+	if err != nil {
+		return errors.Wrap(err, "Failed sending keys")
+	}
+
+	return nil
+}
+
+// qemuDecodePPM decodes the binary PPM (P6) image produced by QEMU's screendump command.
+func qemuDecodePPM(r *bufio.Reader) (image.Image, error) {
+	// The header is made of the magic number, width, height and maximum colour value, separated by whitespace.
+	header := []int{}
+	magic := ""
+	for len(header) < 3 {
+		var field string
+		_, err := fmt.Fscan(r, &field)
+		if err != nil {
+			return nil, errors.Wrap(err, "Invalid PPM header")
+		}
+
+		if strings.HasPrefix(field, "#") {
+			_, err = r.ReadString('\n')
+			if err != nil {
+				return nil, errors.Wrap(err, "Invalid PPM header")
+			}
+
+			continue
+		}
+
+		if magic == "" {
+			magic = field
+			if magic != "P6" {
+				return nil, fmt.Errorf("Unsupported PPM format %q", magic)
+			}
+
+			continue
+		}
+
+		value, err := strconv.Atoi(field)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("Invalid PPM header value %q", field)
+		}
+
+		header = append(header, value)
+	}
+
+	width, height, maxValue := header[0], header[1], header[2]
+	if maxValue > 255 {
+		return nil, fmt.Errorf("Unsupported PPM maximum value %d", maxValue)
+	}
+
+	// A single whitespace character separates the header from the pixel data.
+	_, err := r.ReadByte()
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid PPM header")
+	}
+
+	pixels := make([]byte, width*height*3)
+	_, err = io.ReadFull(r, pixels)
+	if err != nil {
+		return nil, errors.Wrap(err, "Truncated PPM data")
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < width*height; i++ {
+		img.Pix[i*4] = uint8(int(pixels[i*3]) * 255 / maxValue)
+		img.Pix[i*4+1] = uint8(int(pixels[i*3+1]) * 255 / maxValue)
+		img.Pix[i*4+2] = uint8(int(pixels[i*3+2]) * 255 / maxValue)
+		img.Pix[i*4+3] = 0xff
+	}
+
+	return img, nil
+}
+
 // Exec a command inside the instance.
 func (vm *qemu) Exec(req api.InstanceExecPost, stdin *os.File, stdout *os.File, stderr *os.File) (instance.Cmd, error) {
 	revert := revert.New()
@@ -4043,7 +5487,13 @@ func (vm *qemu) RenderFull() (*api.InstanceFull, interface{}, error) {
 
 // RenderState returns just state info about the instance.
 func (vm *qemu) RenderState() (*api.InstanceState, error) {
-	statusCode := vm.statusCode()
+	// Connect to the monitor once, it's used for both the status and the resources of the running VM.
+	statusCode := api.Stopped
+	monitor, err := qmp.Connect(vm.monitorPath(), qemuSerialChardevName, vm.getMonitorEventHandler())
+	if err == nil {
+		statusCode = vm.monitorStatusCode(monitor)
+	}
+
 	pid, _ := vm.pid()
 
 	if statusCode == api.Running {
@@ -4059,7 +5509,7 @@ func (vm *qemu) RenderState() (*api.InstanceState, error) {
 			status.Processes = -1
 			networks := map[string]api.InstanceStateNetwork{}
 			for k, m := range vm.ExpandedDevices() {
-				nicType, err := nictype.NICType(vm.state, m)
+				nicType, err := nictype.NICType(vm.state, vm.Project(), m)
 				if err != nil {
 					return nil, err
 				}
@@ -4145,6 +5595,21 @@ func (vm *qemu) RenderState() (*api.InstanceState, error) {
 			}
 		}
 
+		// Report the vCPUs and memory currently available to the guest.
+		memory, err := monitor.QueryBalloon()
+		if err == nil {
+			status.Memory.Total = memory
+		}
+
+		cpus, err := monitor.QueryHotpluggableCPUs()
+		if err == nil {
+			for _, cpu := range cpus {
+				if cpu.QOMPath != "" {
+					status.CPU.Count++
+				}
+			}
+		}
+
 		status.Pid = int64(pid)
 		status.Status = statusCode.String()
 		status.StatusCode = statusCode
@@ -4248,7 +5713,48 @@ func (vm *qemu) IsStateful() bool {
 
 // DeviceEventHandler handles events occurring on the instance's devices.
 func (vm *qemu) DeviceEventHandler(runConf *deviceConfig.RunConfig) error {
-	return fmt.Errorf("DeviceEventHandler Not implemented")
+	// Device events can only be processed when the VM is running.
+	if !vm.IsRunning() {
+		return nil
+	}
+
+	if runConf == nil {
+		return nil
+	}
+
+	// Device events can both add and remove USB and unix devices, with removed ones having no host path.
+	attachConf := deviceConfig.RunConfig{Mounts: runConf.Mounts, NetworkInterface: runConf.NetworkInterface}
+	detachUSB := []deviceConfig.USBDeviceItem{}
+	detachUnix := []deviceConfig.UnixDeviceItem{}
+
+	for _, usbDev := range runConf.USBDevice {
+		if usbDev.HostDevicePath == "" {
+			detachUSB = append(detachUSB, usbDev)
+		} else {
+			attachConf.USBDevice = append(attachConf.USBDevice, usbDev)
+		}
+	}
+
+	for _, unixDev := range runConf.UnixDevice {
+		if unixDev.HostDevicePath == "" {
+			detachUnix = append(detachUnix, unixDev)
+		} else {
+			attachConf.UnixDevice = append(attachConf.UnixDevice, unixDev)
+		}
+	}
+
+	err := vm.deviceDetachHostDevices(detachUSB, detachUnix)
+	if err != nil {
+		return err
+	}
+
+	err = vm.deviceAttach(&attachConf)
+	if err != nil {
+		return err
+	}
+
+	// Run any post hooks requested by the device.
+	return vm.runHooks(runConf.PostHooks)
 }
 
 // ID returns the instance's ID.
@@ -4315,6 +5821,11 @@ func (vm *qemu) statusCode() api.StatusCode {
 		return api.Stopped
 	}
 
+	return vm.monitorStatusCode(monitor)
+}
+
+// monitorStatusCode returns the instance's state code as reported by its monitor.
+func (vm *qemu) monitorStatusCode(monitor *qmp.Monitor) api.StatusCode {
 	status, err := monitor.Status()
 	if err != nil {
 		if err == qmp.ErrMonitorDisconnect {
@@ -4392,6 +5903,7 @@ func (vm *qemu) TemplatesPath() string {
 }
 
 // StatePath returns the instance's state path.
+// For VMs this is a single compressed file holding the QEMU migration stream.
 func (vm *qemu) StatePath() string {
 	return filepath.Join(vm.Path(), "state")
 }
@@ -4457,7 +5969,7 @@ func (vm *qemu) FillNetworkDevice(name string, m deviceConfig.Device) (deviceCon
 		return nil
 	}
 
-	nicType, err := nictype.NICType(vm.state, m)
+	nicType, err := nictype.NICType(vm.state, vm.Project(), m)
 	if err != nil {
 		return nil, err
 	}
@@ -4683,6 +6195,190 @@ func (vm *qemu) UpdateBackupFile() error {
 	return pool.UpdateInstanceBackupFile(vm, nil)
 }
 
+// cpuNUMANodes returns the host NUMA nodes selected through limits.cpu.nodes in ascending order, along with
+// the host CPU threads of every NUMA node. No NUMA nodes are returned if limits.cpu.nodes isn't set.
+func (vm *qemu) cpuNUMANodes() ([]uint64, map[uint64][]uint64, error) {
+	nodesLimit := vm.expandedConfig["limits.cpu.nodes"]
+	if nodesLimit == "" {
+		return nil, nil, nil
+	}
+
+	nodeIDs, err := resources.ParseCpuset(nodesLimit)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Invalid limits.cpu.nodes")
+	}
+
+	cpus, err := resources.GetCPU()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nodeCPUs := map[uint64][]uint64{}
+	for _, cpu := range cpus.Sockets {
+		for _, core := range cpu.Cores {
+			for _, thread := range core.Threads {
+				if !thread.Online {
+					continue
+				}
+
+				nodeCPUs[thread.NUMANode] = append(nodeCPUs[thread.NUMANode], uint64(thread.ID))
+			}
+		}
+	}
+
+	hostNodes := []uint64{}
+	for _, nodeID := range nodeIDs {
+		hostNode := uint64(nodeID)
+		if len(nodeCPUs[hostNode]) == 0 {
+			return nil, nil, fmt.Errorf("NUMA node %d doesn't exist or has no online CPUs", hostNode)
+		}
+
+		if !shared.Uint64InSlice(hostNode, hostNodes) {
+			hostNodes = append(hostNodes, hostNode)
+		}
+	}
+
+	sort.Slice(hostNodes, func(i, j int) bool { return hostNodes[i] < hostNodes[j] })
+
+	return hostNodes, nodeCPUs, nil
+}
+
+// hotplugMaxCPUs returns the number of vCPUs a VM with limits.cpu.hotplug can be resized to while running.
+func (vm *qemu) hotplugMaxCPUs() (int, error) {
+	cpus, err := resources.GetCPU()
+	if err != nil {
+		return -1, err
+	}
+
+	if cpus.Total < qemuMaxCPUs {
+		return int(cpus.Total), nil
+	}
+
+	return qemuMaxCPUs, nil
+}
+
+// hotplugMaxMemory returns the amount of memory (in MiB) a VM with limits.memory.hotplug can be resized to while
+// running.
+func (vm *qemu) hotplugMaxMemory() (int64, error) {
+	memory, err := resources.GetMemory()
+	if err != nil {
+		return -1, err
+	}
+
+	hostMemory := int64(memory.Total / 1024 / 1024)
+	if hostMemory < qemuMaxMemory {
+		return hostMemory, nil
+	}
+
+	return qemuMaxMemory, nil
+}
+
+// checkNUMAMemory checks that each of the host NUMA nodes has room for the given amount of memory in bytes.
+func (vm *qemu) checkNUMAMemory(hostNodes []uint64, nodeMemoryBytes int64) error {
+	memory, err := resources.GetMemory()
+	if err != nil {
+		return err
+	}
+
+	// Skip the check on systems which don't report NUMA memory information.
+	if len(memory.Nodes) == 0 {
+		return nil
+	}
+
+	for _, hostNode := range hostNodes {
+		found := false
+		for _, node := range memory.Nodes {
+			if node.NUMANode != hostNode {
+				continue
+			}
+
+			found = true
+			if uint64(nodeMemoryBytes) > node.Total {
+				return fmt.Errorf("NUMA node %d doesn't have enough memory for %s", hostNode, units.GetByteSizeString(nodeMemoryBytes, 2))
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("NUMA node %d doesn't have any memory", hostNode)
+		}
+	}
+
+	return nil
+}
+
+// setCPUAffinity pins the vCPU threads of the running VM to the host CPUs selected through limits.cpu.
+// When limits.cpu.nodes is set instead, each vCPU thread is restricted to the host CPUs of its NUMA node.
+func (vm *qemu) setCPUAffinity(monitor *qmp.Monitor) error {
+	cpuLimit := vm.expandedConfig["limits.cpu"]
+	_, err := strconv.Atoi(cpuLimit)
+	if cpuLimit != "" && err != nil {
+		// Expand to a set of CPU identifiers and get the pinning map.
+		_, _, _, pins, _, err := vm.cpuTopology(cpuLimit)
+		if err != nil {
+			return err
+		}
+
+		// Get the thread of each vCPU from the VM.
+		threads, err := monitor.GetCPUs()
+		if err != nil {
+			return err
+		}
+
+		// Confirm nothing weird is going on.
+		if len(pins) != len(threads) {
+			return fmt.Errorf("QEMU has less vCPUs than configured")
+		}
+
+		for cpu, pid := range threads {
+			pin, ok := pins[uint64(cpu)]
+			if !ok {
+				return fmt.Errorf("QEMU vCPU %d isn't in the configured CPU set", cpu)
+			}
+
+			set := unix.CPUSet{}
+			set.Set(int(pin))
+
+			// Apply the pin.
+			err := unix.SchedSetaffinity(pid, &set)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	hostNodes, nodeCPUs, err := vm.cpuNUMANodes()
+	if err != nil {
+		return err
+	}
+
+	if len(hostNodes) == 0 {
+		return nil
+	}
+
+	// Get the thread of each vCPU from the VM.
+	threads, err := monitor.GetCPUs()
+	if err != nil {
+		return err
+	}
+
+	// vCPUs are assigned to the NUMA nodes in turn, matching the layout from addCPUMemoryConfig.
+	for vcpu, pid := range threads {
+		set := unix.CPUSet{}
+		for _, cpu := range nodeCPUs[hostNodes[vcpu%len(hostNodes)]] {
+			set.Set(int(cpu))
+		}
+
+		err := unix.SchedSetaffinity(pid, &set)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // cpuTopology takes a user cpu range and returns the number of sockets, cores and threads to configure
 // as well as a map of vcpu to threadid for pinning and a map of numa nodes to vcpus for NUMA layout.
 func (vm *qemu) cpuTopology(limit string) (int, int, int, map[uint64]uint64, map[uint64][]uint64, error) {
@@ -4733,7 +6429,7 @@ func (vm *qemu) cpuTopology(limit string) (int, int, int, map[uint64]uint64, map
 						}
 
 						// Record NUMA node for thread.
-						_, ok = cores[core.Core]
+						_, ok = numaNodes[thread.NUMANode]
 						if !ok {
 							numaNodes[thread.NUMANode] = []uint64{}
 						}
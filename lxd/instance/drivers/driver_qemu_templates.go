@@ -56,6 +56,10 @@ var qemuMemory = template.Must(template.New("qemuMemory").Parse(`
 # Memory
 [memory]
 size = "{{.memSizeBytes}}M"
+{{if .memMaxSizeBytes -}}
+maxmem = "{{.memMaxSizeBytes}}M"
+slots = "{{.memSlots}}"
+{{end -}}
 `))
 
 var qemuSerial = template.Must(template.New("qemuSerial").Parse(`
@@ -240,10 +244,24 @@ multifunction = "on"
 {{- end }}
 `))
 
+var qemuUSB = template.Must(template.New("qemuUSB").Parse(`
+# USB controller
+[device "qemu_usb"]
+driver = "qemu-xhci"
+bus = "{{.devBus}}"
+addr = "{{.devAddr}}"
+{{if .multifunction -}}
+multifunction = "on"
+{{- end }}
+`))
+
 var qemuCPU = template.Must(template.New("qemuCPU").Parse(`
 # CPU
 [smp-opts]
 cpus = "{{.cpuCount}}"
+{{if .cpuMaxCount -}}
+maxcpus = "{{.cpuMaxCount}}"
+{{end -}}
 sockets = "{{.cpuSockets}}"
 cores = "{{.cpuCores}}"
 threads = "{{.cpuThreads}}"
@@ -268,8 +286,8 @@ policy = "bind"
 
 [numa]
 type = "node"
-nodeid = "{{$element}}"
-memdev = "mem{{$element}}"
+nodeid = "{{$index}}"
+memdev = "mem{{$index}}"
 {{end}}
 {{else}}
 [object "mem0"]
@@ -403,13 +421,16 @@ discard = "on"
 {{if .shared -}}
 file.locking = "off"
 {{- end }}
+{{if .readonly -}}
+readonly = "on"
+{{- end }}
 
 [device "dev-lxd_{{.devName}}"]
 driver = "scsi-hd"
 bus = "qemu_scsi.0"
 channel = "0"
 scsi-id = "{{.bootIndex}}"
-lun = "1"
+lun = "{{.lun}}"
 drive = "lxd_{{.devName}}"
 bootindex = "{{.bootIndex}}"
 {{if .multifunction -}}
@@ -497,3 +518,50 @@ x-vga = "on"
 multifunction = "on"
 {{- end }}
 `))
+
+// Devices use "lxd_" prefix indicating that this is a user named device.
+var qemuUnixChar = template.Must(template.New("qemuUnixChar").Parse(`
+# Unix character device ("{{.devName}}" device)
+[chardev "lxd_{{.devName}}"]
+backend = "serial"
+path = "{{.devPath}}"
+
+[device "dev-lxd_{{.devName}}"]
+driver = "virtserialport"
+name = "{{.devName}}"
+chardev = "lxd_{{.devName}}"
+bus = "dev-qemu_serial.0"
+`))
+
+// Devices use "lxd_" prefix indicating that this is a user named device.
+var qemuUSBDev = template.Must(template.New("qemuUSBDev").Parse(`
+# USB host device ("{{.devName}}" device)
+[device "dev-lxd_{{.devName}}"]
+driver = "usb-host"
+bus = "qemu_usb.0"
+hostdevice = "{{.hostDevice}}"
+`))
+
+// Devices use "lxd_" prefix indicating that this is a user named device.
+var qemuTPM = template.Must(template.New("qemuTPM").Parse(`
+# TPM ("{{.devName}}" device)
+[chardev "qemu_tpm-chardev_{{.devName}}"]
+backend = "socket"
+path = "{{.path}}"
+
+[tpmdev "qemu_tpm-tpmdev_{{.devName}}"]
+type = "emulator"
+chardev = "qemu_tpm-chardev_{{.devName}}"
+
+[device "dev-lxd_{{.devName}}"]
+{{- if eq .architecture "x86_64"}}
+driver = "tpm-crb"
+{{- end}}
+{{- if eq .architecture "aarch64"}}
+driver = "tpm-tis-device"
+{{- end}}
+{{- if eq .architecture "ppc64le"}}
+driver = "tpm-spapr"
+{{- end}}
+tpmdev = "qemu_tpm-tpmdev_{{.devName}}"
+`))
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -246,6 +247,66 @@ func (m *Monitor) Console(target string) (*os.File, error) {
 	return nil, ErrMonitorBadConsole
 }
 
+// ping checks that the QMP monitor is still responding, disconnecting if not.
+func (m *Monitor) ping() error {
+	// Check if disconnected
+	if m.disconnected {
+		return ErrMonitorDisconnect
+	}
+
+	// Query the status.
+	_, err := m.qmp.Run([]byte("{'execute': 'query-status'}"))
+	if err != nil {
+		m.Disconnect()
+		return ErrMonitorDisconnect
+	}
+
+	return nil
+}
+
+// execute runs a command with the supplied arguments and decodes the returned data into resp (if not nil).
+func (m *Monitor) execute(cmd string, args interface{}, resp interface{}) error {
+	// Check if disconnected
+	if m.disconnected {
+		return ErrMonitorDisconnect
+	}
+
+	// Prepare the request.
+	req := map[string]interface{}{"execute": cmd}
+	if args != nil {
+		req["arguments"] = args
+	}
+
+	reqJSON, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	// Run the command.
+	respRaw, err := m.qmp.Run(reqJSON)
+	if err != nil {
+		// Confirm that QEMU is still alive, otherwise return the command error.
+		errPing := m.ping()
+		if errPing != nil {
+			return errPing
+		}
+
+		return err
+	}
+
+	// Process the response.
+	if resp == nil {
+		return nil
+	}
+
+	err = json.Unmarshal(respRaw, resp)
+	if err != nil {
+		return ErrMonitorBadReturn
+	}
+
+	return nil
+}
+
 func (m *Monitor) runCmd(cmd string) error {
 	// Check if disconnected
 	if m.disconnected {
@@ -287,38 +348,565 @@ func (m *Monitor) AgentReady() bool {
 	return m.agentReady
 }
 
-// GetCPUs fetches the vCPU information for pinning.
-func (m *Monitor) GetCPUs() ([]int, error) {
+// GetCPUs fetches the vCPU information for pinning, returning the host thread ID of each vCPU index.
+func (m *Monitor) GetCPUs() (map[int]int, error) {
+	var resp struct {
+		Return []struct {
+			CPUIndex int `json:"cpu-index"`
+			ThreadID int `json:"thread-id"`
+		} `json:"return"`
+	}
+
+	err := m.execute("query-cpus-fast", nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	threads := make(map[int]int, len(resp.Return))
+	for _, cpu := range resp.Return {
+		threads[cpu.CPUIndex] = cpu.ThreadID
+	}
+
+	return threads, nil
+}
+
+// executeWithFile runs a command with the supplied arguments, passing the file descriptor alongside it.
+// The returned data is decoded into resp (if not nil).
+func (m *Monitor) executeWithFile(cmd string, args interface{}, file *os.File, resp interface{}) error {
 	// Check if disconnected
 	if m.disconnected {
-		return nil, ErrMonitorDisconnect
+		return ErrMonitorDisconnect
 	}
 
-	// Query the consoles.
-	respRaw, err := m.qmp.Run([]byte("{'execute': 'query-cpus'}"))
+	// Prepare the request.
+	req := map[string]interface{}{"execute": cmd}
+	if args != nil {
+		req["arguments"] = args
+	}
+
+	reqJSON, err := json.Marshal(req)
 	if err != nil {
-		m.Disconnect()
-		return nil, ErrMonitorDisconnect
+		return err
+	}
+
+	// Send the file descriptor alongside the command.
+	respRaw, err := m.qmp.RunWithFile(reqJSON, file)
+	if err != nil {
+		// Confirm that QEMU is still alive, otherwise return the command error.
+		errPing := m.ping()
+		if errPing != nil {
+			return errPing
+		}
+
+		return err
 	}
 
 	// Process the response.
-	var respDecoded struct {
+	if resp == nil {
+		return nil
+	}
+
+	err = json.Unmarshal(respRaw, resp)
+	if err != nil {
+		return ErrMonitorBadReturn
+	}
+
+	return nil
+}
+
+// SendFile passes a file descriptor to QEMU, registering it under the supplied name.
+func (m *Monitor) SendFile(name string, file *os.File) error {
+	return m.executeWithFile("getfd", map[string]string{"fdname": name}, file, nil)
+}
+
+// AddFdSet passes a file descriptor to QEMU as part of a new fdset and returns the fdset ID.
+// The file can then be referred to as "/dev/fdset/<ID>" by block devices.
+func (m *Monitor) AddFdSet(name string, file *os.File) (int, error) {
+	var resp struct {
+		Return struct {
+			FdsetID int `json:"fdset-id"`
+		} `json:"return"`
+	}
+
+	err := m.executeWithFile("add-fd", map[string]string{"opaque": name}, file, &resp)
+	if err != nil {
+		return -1, err
+	}
+
+	return resp.Return.FdsetID, nil
+}
+
+// RemoveFdSet removes an fdset and closes any file descriptors left in it that are not in use.
+func (m *Monitor) RemoveFdSet(fdsetID int) error {
+	return m.execute("remove-fd", map[string]int{"fdset-id": fdsetID}, nil)
+}
+
+// Migrate starts an outgoing migration stream to the URI and waits for it to complete.
+func (m *Monitor) Migrate(uri string) error {
+	err := m.execute("migrate", map[string]string{"uri": uri}, nil)
+	if err != nil {
+		return err
+	}
+
+	return m.migrateWait()
+}
+
+// MigrateIncoming starts receiving a migration stream from the URI and waits for it to complete.
+// QEMU must have been started with "-incoming defer" for this to work.
+func (m *Monitor) MigrateIncoming(uri string) error {
+	err := m.execute("migrate-incoming", map[string]string{"uri": uri}, nil)
+	if err != nil {
+		return err
+	}
+
+	return m.migrateWait()
+}
+
+// migrateWait polls the migration status until it has either completed or failed.
+func (m *Monitor) migrateWait() error {
+	for {
+		var resp struct {
+			Return struct {
+				Status    string `json:"status"`
+				ErrorDesc string `json:"error-desc"`
+			} `json:"return"`
+		}
+
+		err := m.execute("query-migrate", nil, &resp)
+		if err != nil {
+			return err
+		}
+
+		switch resp.Return.Status {
+		case "completed":
+			return nil
+		case "failed", "cancelled":
+			if resp.Return.ErrorDesc != "" {
+				return fmt.Errorf("Migration %s: %s", resp.Return.Status, resp.Return.ErrorDesc)
+			}
+
+			return fmt.Errorf("Migration %s", resp.Return.Status)
+		}
+
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// PCIDevice represents a device on a PCI bus, along with the devices behind it if it's a bridge.
+type PCIDevice struct {
+	DevID  string     `json:"qdev_id"`
+	Bridge *PCIBridge `json:"pci_bridge"`
+}
+
+// PCIBridge represents the bus behind a PCI bridge (or PCIe root port).
+type PCIBridge struct {
+	Devices []PCIDevice `json:"devices"`
+}
+
+// QueryPCI returns the devices present on the root PCI buses.
+func (m *Monitor) QueryPCI() ([]PCIDevice, error) {
+	var resp struct {
 		Return []struct {
-			CPU int `json:"CPU"`
-			PID int `json:"thread_id"`
+			Devices []PCIDevice `json:"devices"`
 		} `json:"return"`
 	}
 
-	err = json.Unmarshal(respRaw, &respDecoded)
+	err := m.execute("query-pci", nil, &resp)
 	if err != nil {
-		return nil, ErrMonitorBadReturn
+		return nil, err
+	}
+
+	devices := []PCIDevice{}
+	for _, bus := range resp.Return {
+		devices = append(devices, bus.Devices...)
+	}
+
+	return devices, nil
+}
+
+// AddDevice adds a new device.
+func (m *Monitor) AddDevice(device map[string]string) error {
+	return m.execute("device_add", device, nil)
+}
+
+// RemoveDevice removes a device and waits for the guest to release it.
+// Removing a device which doesn't exist isn't considered an error.
+func (m *Monitor) RemoveDevice(deviceID string) error {
+	driver, err := m.DeviceDriver(deviceID)
+	if err != nil {
+		return err
+	}
+
+	if driver == "" {
+		return nil
+	}
+
+	err = m.execute("device_del", map[string]string{"id": deviceID}, nil)
+	if err != nil {
+		return err
+	}
+
+	// The removal is only complete once the guest has acknowledged it.
+	for i := 0; i < 100; i++ {
+		driver, err := m.DeviceDriver(deviceID)
+		if err != nil {
+			return err
+		}
+
+		if driver == "" {
+			return nil
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return fmt.Errorf("Timed out waiting for the guest to release device %q", deviceID)
+}
+
+// DeviceDriver returns the driver name of a user created device, or an empty string if the device doesn't exist.
+func (m *Monitor) DeviceDriver(deviceID string) (string, error) {
+	var resp struct {
+		Return []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"return"`
+	}
+
+	err := m.execute("qom-list", map[string]string{"path": "/machine/peripheral"}, &resp)
+	if err != nil {
+		return "", err
+	}
+
+	for _, entry := range resp.Return {
+		if entry.Name == deviceID {
+			// Devices are listed as child properties, e.g. "child<scsi-hd>".
+			return strings.TrimSuffix(strings.TrimPrefix(entry.Type, "child<"), ">"), nil
+		}
+	}
+
+	return "", nil
+}
+
+// SCSIAddress represents the target ID and LUN of a SCSI device.
+type SCSIAddress struct {
+	ID  int
+	LUN int
+}
+
+// SCSIAddresses returns the addresses used by the user created SCSI disks.
+func (m *Monitor) SCSIAddresses() ([]SCSIAddress, error) {
+	var resp struct {
+		Return []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"return"`
+	}
+
+	err := m.execute("qom-list", map[string]string{"path": "/machine/peripheral"}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := []SCSIAddress{}
+	for _, entry := range resp.Return {
+		if entry.Type != "child<scsi-hd>" && entry.Type != "child<scsi-cd>" {
+			continue
+		}
+
+		address := SCSIAddress{}
+		for prop, value := range map[string]*int{"scsi-id": &address.ID, "lun": &address.LUN} {
+			var propResp struct {
+				Return int `json:"return"`
+			}
+
+			args := map[string]string{"path": fmt.Sprintf("/machine/peripheral/%s", entry.Name), "property": prop}
+			err = m.execute("qom-get", args, &propResp)
+			if err != nil {
+				return nil, err
+			}
+
+			*value = propResp.Return
+		}
+
+		addresses = append(addresses, address)
+	}
+
+	return addresses, nil
+}
+
+// AddBlockDevice adds a block device node and then the device using it.
+// If the device can't be added, the block device node is removed again.
+func (m *Monitor) AddBlockDevice(blockDev map[string]interface{}, device map[string]string) error {
+	nodeName, ok := blockDev["node-name"].(string)
+	if !ok {
+		return fmt.Errorf("Block device node name is required")
+	}
+
+	err := m.execute("blockdev-add", blockDev, nil)
+	if err != nil {
+		return err
+	}
+
+	err = m.AddDevice(device)
+	if err != nil {
+		m.execute("blockdev-del", map[string]string{"node-name": nodeName}, nil)
+		return err
+	}
+
+	return nil
+}
+
+// RemoveBlockDevice removes a block device node. Removing a node which doesn't exist isn't considered an error,
+// as drives configured at startup are removed by QEMU along with the device using them.
+func (m *Monitor) RemoveBlockDevice(nodeName string) error {
+	var resp struct {
+		Return []struct {
+			NodeName string `json:"node-name"`
+		} `json:"return"`
+	}
+
+	err := m.execute("query-named-block-nodes", nil, &resp)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range resp.Return {
+		if node.NodeName == nodeName {
+			return m.execute("blockdev-del", map[string]string{"node-name": nodeName}, nil)
+		}
+	}
+
+	return nil
+}
+
+// AddNIC adds a network backend and then the network card using it.
+// If the network card can't be added, the network backend is removed again.
+func (m *Monitor) AddNIC(netDev map[string]interface{}, device map[string]string) error {
+	netDevID, ok := netDev["id"].(string)
+	if !ok {
+		return fmt.Errorf("Network backend ID is required")
+	}
+
+	err := m.execute("netdev_add", netDev, nil)
+	if err != nil {
+		return err
+	}
+
+	err = m.AddDevice(device)
+	if err != nil {
+		m.execute("netdev_del", map[string]string{"id": netDevID}, nil)
+		return err
+	}
+
+	return nil
+}
+
+// RemoveNIC removes a network backend.
+func (m *Monitor) RemoveNIC(netDevID string) error {
+	return m.execute("netdev_del", map[string]string{"id": netDevID}, nil)
+}
+
+// AddCharDevice adds a character device backend and then the device using it.
+// If the device can't be added, the character device backend is removed again.
+func (m *Monitor) AddCharDevice(charDev map[string]interface{}, device map[string]string) error {
+	charDevID, ok := charDev["id"].(string)
+	if !ok {
+		return fmt.Errorf("Character device ID is required")
+	}
+
+	err := m.execute("chardev-add", charDev, nil)
+	if err != nil {
+		return err
+	}
+
+	err = m.AddDevice(device)
+	if err != nil {
+		m.RemoveCharDevice(charDevID)
+		return err
+	}
+
+	return nil
+}
+
+// RemoveCharDevice removes a character device backend.
+func (m *Monitor) RemoveCharDevice(charDevID string) error {
+	return m.execute("chardev-remove", map[string]string{"id": charDevID}, nil)
+}
+
+// HotpluggableCPU represents a vCPU slot which can be hotplugged.
+type HotpluggableCPU struct {
+	Type    string         `json:"type"`
+	QOMPath string         `json:"qom-path"`
+	Props   map[string]int `json:"props"`
+}
+
+// QueryHotpluggableCPUs returns the vCPU slots of the VM. Slots which currently have a vCPU plugged in have
+// their QOMPath set.
+func (m *Monitor) QueryHotpluggableCPUs() ([]HotpluggableCPU, error) {
+	var resp struct {
+		Return []HotpluggableCPU `json:"return"`
+	}
+
+	err := m.execute("query-hotpluggable-cpus", nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Return, nil
+}
+
+// AddCPU plugs a vCPU into an empty slot.
+func (m *Monitor) AddCPU(deviceID string, cpu HotpluggableCPU) error {
+	device := map[string]string{
+		"driver": cpu.Type,
+		"id":     deviceID,
+	}
+
+	for key, value := range cpu.Props {
+		device[key] = strconv.Itoa(value)
+	}
+
+	return m.AddDevice(device)
+}
+
+// RemoveCPU unplugs the vCPU in the slot and waits for the guest to release it.
+func (m *Monitor) RemoveCPU(cpu HotpluggableCPU) error {
+	// The QOM path can be used in place of the device ID, which also covers vCPUs added at startup.
+	err := m.execute("device_del", map[string]string{"id": cpu.QOMPath}, nil)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < 100; i++ {
+		cpus, err := m.QueryHotpluggableCPUs()
+		if err != nil {
+			return err
+		}
+
+		removed := true
+		for _, entry := range cpus {
+			if entry.QOMPath == cpu.QOMPath {
+				removed = false
+				break
+			}
+		}
+
+		if removed {
+			return nil
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return fmt.Errorf("Timed out waiting for the guest to release vCPU %q", cpu.QOMPath)
+}
+
+// Balloon sets the amount of memory the balloon device should leave to the guest.
+func (m *Monitor) Balloon(sizeBytes int64) error {
+	return m.execute("balloon", map[string]int64{"value": sizeBytes}, nil)
+}
+
+// QueryBalloon returns the amount of memory currently available to the guest according to the balloon device.
+func (m *Monitor) QueryBalloon() (int64, error) {
+	var resp struct {
+		Return struct {
+			Actual int64 `json:"actual"`
+		} `json:"return"`
+	}
+
+	err := m.execute("query-balloon", nil, &resp)
+	if err != nil {
+		return -1, err
+	}
+
+	return resp.Return.Actual, nil
+}
+
+// MemorySizeSummary returns the memory the VM was started with and the memory hotplugged since, in bytes.
+func (m *Monitor) MemorySizeSummary() (int64, int64, error) {
+	var resp struct {
+		Return struct {
+			BaseMemory    int64 `json:"base-memory"`
+			PluggedMemory int64 `json:"plugged-memory"`
+		} `json:"return"`
+	}
+
+	err := m.execute("query-memory-size-summary", nil, &resp)
+	if err != nil {
+		return -1, -1, err
+	}
+
+	return resp.Return.BaseMemory, resp.Return.PluggedMemory, nil
+}
+
+// MemoryDevices returns the IDs of the hotplugged memory devices.
+func (m *Monitor) MemoryDevices() ([]string, error) {
+	var resp struct {
+		Return []struct {
+			Data struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		} `json:"return"`
+	}
+
+	err := m.execute("query-memory-devices", nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, dev := range resp.Return {
+		ids = append(ids, dev.Data.ID)
+	}
+
+	return ids, nil
+}
+
+// AddMemory adds a memory backend object and then the memory device using it.
+// If the memory device can't be added, the memory backend object is removed again.
+func (m *Monitor) AddMemory(memBackend map[string]interface{}, device map[string]string) error {
+	memBackendID, ok := memBackend["id"].(string)
+	if !ok {
+		return fmt.Errorf("Memory backend ID is required")
+	}
+
+	err := m.execute("object-add", memBackend, nil)
+	if err != nil {
+		return err
+	}
+
+	err = m.AddDevice(device)
+	if err != nil {
+		m.execute("object-del", map[string]string{"id": memBackendID}, nil)
+		return err
+	}
+
+	return nil
+}
+
+// Screendump writes a PPM image of the primary display to the given file.
+func (m *Monitor) Screendump(filename string) error {
+	return m.execute("screendump", map[string]string{"filename": filename}, nil)
+}
+
+// SendKey presses the given QEMU key codes at the same time and releases them after holdTime milliseconds.
+// A holdTime of zero uses QEMU's default.
+func (m *Monitor) SendKey(keys []string, holdTime int) error {
+	type keyValue struct {
+		Type string `json:"type"`
+		Data string `json:"data"`
+	}
+
+	args := struct {
+		Keys     []keyValue `json:"keys"`
+		HoldTime int        `json:"hold-time,omitempty"`
+	}{
+		HoldTime: holdTime,
 	}
 
-	// Make a slice of PIDs.
-	pids := []int{}
-	for _, cpu := range respDecoded.Return {
-		pids = append(pids, cpu.PID)
+	for _, key := range keys {
+		args.Keys = append(args.Keys, keyValue{Type: "qcode", Data: key})
 	}
 
-	return pids, nil
+	return m.execute("send-key", args, nil)
 }
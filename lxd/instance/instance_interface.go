@@ -5,6 +5,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/gorilla/websocket"
 	liblxc "gopkg.in/lxc/go-lxc.v2"
 
 	"github.com/lxc/lxd/lxd/backup"
@@ -33,6 +34,7 @@ const (
 
 // ConfigReader is used to read instance config.
 type ConfigReader interface {
+	Project() string
 	Type() instancetype.Type
 	ExpandedConfig() map[string]string
 	ExpandedDevices() deviceConfig.Devices
@@ -99,7 +101,6 @@ type Instance interface {
 	// Properties.
 	ID() int
 	Location() string
-	Project() string
 	Name() string
 	Description() string
 	Architecture() int
@@ -150,6 +151,18 @@ type Container interface {
 	DevptsFd() (*os.File, error)
 }
 
+// VM interface is for VM specific functions.
+type VM interface {
+	Instance
+
+	SaveState() error
+	MigrateSend(w io.Writer) error
+	MigrateReceive(r io.Reader) error
+	ConnectAgentProxy(connectAddr string) (*websocket.Conn, error)
+	ConsoleScreenshot(w io.Writer) error
+	ConsoleSendKeys(keys []string, holdTime int) error
+}
+
 // CriuMigrationArgs arguments for CRIU migration.
 type CriuMigrationArgs struct {
 	Cmd          uint
@@ -159,6 +159,17 @@ func containerBackupsPost(d *Daemon, r *http.Request) response.Response {
 	fullName := name + shared.SnapshotDelimiter + req.Name
 	instanceOnly := req.InstanceOnly || req.ContainerOnly
 
+	// Incremental backups never include snapshots.
+	parentName := ""
+	if req.Parent != "" {
+		if strings.Contains(req.Parent, "/") {
+			return response.BadRequest(fmt.Errorf("Backup names may not contain slashes"))
+		}
+
+		parentName = name + shared.SnapshotDelimiter + req.Parent
+		instanceOnly = true
+	}
+
 	backup := func(op *operations.Operation) error {
 		args := db.InstanceBackup{
 			Name:                 fullName,
@@ -168,9 +179,10 @@ func containerBackupsPost(d *Daemon, r *http.Request) response.Response {
 			InstanceOnly:         instanceOnly,
 			OptimizedStorage:     req.OptimizedStorage,
 			CompressionAlgorithm: req.CompressionAlgorithm,
+			Parent:               parentName,
 		}
 
-		err := backupCreate(d.State(), args, inst)
+		err := backupCreate(d.State(), args, inst, req.AllowPlaintext)
 		if err != nil {
 			return errors.Wrap(err, "Create backup")
 		}
@@ -304,8 +316,23 @@ func containerBackupDelete(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	inst, err := instance.LoadByProjectAndName(d.State(), project, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = backupCheckNoChildren(d.State(), inst, fullName, backup.UUID())
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
 	remove := func(op *operations.Operation) error {
-		err := backup.Delete()
+		err := backupDeleteBase(d.State(), inst, backup.UUID())
+		if err != nil {
+			return err
+		}
+
+		err = backup.Delete()
 		if err != nil {
 			return err
 		}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -471,6 +472,15 @@ func containerConsoleLogGet(d *Daemon, r *http.Request) response.Response {
 	project := projectParam(r)
 	name := mux.Vars(r)["name"]
 
+	// Screenshots of the VGA console require the same permission as interacting with the console.
+	consoleType := r.FormValue("type")
+	if consoleType == instance.ConsoleTypeVGA {
+		resp := allowProjectPermission("containers", "operate-containers")(d, r)
+		if resp != response.EmptySyncResponse {
+			return resp
+		}
+	}
+
 	// Forward the request if the container is remote.
 	resp, err := forwardedResponseIfInstanceIsRemote(d, r, project, name, instanceType)
 	if err != nil {
@@ -480,6 +490,12 @@ func containerConsoleLogGet(d *Daemon, r *http.Request) response.Response {
 		return resp
 	}
 
+	if consoleType == instance.ConsoleTypeVGA {
+		return instanceConsoleScreenshotGet(d, r, project, name)
+	} else if consoleType != "" && consoleType != instance.ConsoleTypeConsole {
+		return response.BadRequest(fmt.Errorf("Unknown console type %q", consoleType))
+	}
+
 	if !util.RuntimeLiblxcVersionAtLeast(3, 0, 0) {
 		return response.BadRequest(fmt.Errorf("Querying the console buffer requires liblxc >= 3.0"))
 	}
@@ -597,3 +613,98 @@ func containerConsoleLogDelete(d *Daemon, r *http.Request) response.Response {
 
 	return response.SmartError(nil)
 }
+
+// instanceConsoleScreenshotGet returns a screenshot of the VGA console of a running virtual machine.
+func instanceConsoleScreenshotGet(d *Daemon, r *http.Request, project string, name string) response.Response {
+	format := r.FormValue("format")
+	if format == "" {
+		format = "png"
+	}
+
+	if format != "png" {
+		return response.BadRequest(fmt.Errorf("Unsupported screenshot format %q", format))
+	}
+
+	inst, err := instance.LoadByProjectAndName(d.State(), project, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if inst.Type() != instancetype.VM {
+		return response.BadRequest(fmt.Errorf("VGA console is only supported by virtual machines"))
+	}
+
+	if !inst.IsRunning() {
+		return response.BadRequest(fmt.Errorf("Instance is not running"))
+	}
+
+	vm := inst.(instance.VM)
+
+	buf := bytes.Buffer{}
+	err = vm.ConsoleScreenshot(&buf)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	ent := response.FileResponseEntry{
+		Filename: fmt.Sprintf("%s.png", name),
+		Buffer:   buf.Bytes(),
+	}
+
+	return response.FileResponse(r, []response.FileResponseEntry{ent}, nil, false)
+}
+
+// instanceConsoleKeysPost presses keys on the VGA console of a running virtual machine.
+func instanceConsoleKeysPost(d *Daemon, r *http.Request) response.Response {
+	instanceType, err := urlInstanceTypeDetect(r)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	project := projectParam(r)
+	name := mux.Vars(r)["name"]
+
+	// Forward the request if the instance is remote.
+	resp, err := forwardedResponseIfInstanceIsRemote(d, r, project, name, instanceType)
+	if err != nil {
+		return response.SmartError(err)
+	}
+	if resp != nil {
+		return resp
+	}
+
+	req := api.InstanceConsoleKeysPost{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if len(req.Keys) == 0 {
+		return response.BadRequest(fmt.Errorf("No keys specified"))
+	}
+
+	if req.HoldTime < 0 {
+		return response.BadRequest(fmt.Errorf("Invalid hold time %d", req.HoldTime))
+	}
+
+	inst, err := instance.LoadByProjectAndName(d.State(), project, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if inst.Type() != instancetype.VM {
+		return response.BadRequest(fmt.Errorf("VGA console is only supported by virtual machines"))
+	}
+
+	if !inst.IsRunning() {
+		return response.BadRequest(fmt.Errorf("Instance is not running"))
+	}
+
+	vm := inst.(instance.VM)
+	err = vm.ConsoleSendKeys(req.Keys, req.HoldTime)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
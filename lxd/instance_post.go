@@ -20,6 +20,7 @@ import (
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/revert"
 	driver "github.com/lxc/lxd/lxd/storage"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
@@ -174,7 +175,8 @@ func containerPost(d *Daemon, r *http.Request) response.Response {
 	if req.Migration {
 		if targetNode != "" {
 			// Check whether the container is running.
-			if !sourceNodeOffline && inst.IsRunning() {
+			// Running VMs can be moved statefully, they get resumed from their saved state on the target.
+			if !sourceNodeOffline && inst.IsRunning() && (!stateful || inst.Type() != instancetype.VM) {
 				return response.BadRequest(fmt.Errorf("Container is running"))
 			}
 
@@ -315,6 +317,23 @@ func containerPostClusteringMigrate(d *Daemon, c instance.Instance, oldName, new
 		}
 		dest = dest.UseTarget(newNode)
 
+		revert := revert.New()
+		defer revert.Fail()
+
+		// Save the state of a running VM so that it can be resumed on the new node.
+		startStateful := false
+		if c.IsRunning() {
+			err = c.Stop(true)
+			if err != nil {
+				return errors.Wrap(err, "Failed to statefully stop instance")
+			}
+
+			startStateful = true
+
+			// Resume the instance from its saved state if it can't be moved.
+			revert.Add(func() { containerPostClusteringMigrateRestart(c) })
+		}
+
 		destName := newName
 		isSameName := false
 
@@ -358,6 +377,9 @@ func containerPostClusteringMigrate(d *Daemon, c instance.Instance, oldName, new
 			return errors.Wrap(err, "Delete instance operation failed")
 		}
 
+		// The original instance is gone, so it can't be restarted anymore.
+		revert.Success()
+
 		// If the destination name is not set, we have generated a random name for
 		// the new container, so we need to rename it.
 		if isSameName {
@@ -405,6 +427,25 @@ func containerPostClusteringMigrate(d *Daemon, c instance.Instance, oldName, new
 			return err
 		}
 
+		// Resume the instance on the new node from its saved state.
+		if startStateful {
+			req := api.InstanceStatePut{
+				Action:   "start",
+				Stateful: true,
+				Timeout:  -1,
+			}
+
+			op, err := dest.UseProject(project).UpdateInstanceState(destName, req, "")
+			if err != nil {
+				return errors.Wrap(err, "Failed to issue stateful start API request")
+			}
+
+			err = op.Wait()
+			if err != nil {
+				return errors.Wrap(err, "Stateful start operation failed")
+			}
+		}
+
 		return nil
 	}
 
@@ -418,6 +459,15 @@ func containerPostClusteringMigrate(d *Daemon, c instance.Instance, oldName, new
 	return operations.OperationResponse(op)
 }
 
+// containerPostClusteringMigrateRestart resumes an instance which was statefully stopped to be moved to another
+// cluster member, after the move failed.
+func containerPostClusteringMigrateRestart(inst instance.Instance) {
+	err := inst.Start(true)
+	if err != nil {
+		logger.Errorf("Failed to restart instance %q after failed move: %v", inst.Name(), err)
+	}
+}
+
 // Special case migrating a container backed by ceph across two cluster nodes.
 func containerPostClusteringMigrateWithCeph(d *Daemon, c instance.Instance, projectName, oldName, newName, newNode string, instanceType instancetype.Type) response.Response {
 	run := func(*operations.Operation) error {
@@ -438,6 +488,23 @@ func containerPostClusteringMigrateWithCeph(d *Daemon, c instance.Instance, proj
 			return fmt.Errorf("Source instance's storage pool is not of type ceph")
 		}
 
+		revert := revert.New()
+		defer revert.Fail()
+
+		// Save the state of a running VM so that it can be resumed on the new node.
+		startStateful := false
+		if c.IsRunning() {
+			err = c.Stop(true)
+			if err != nil {
+				return errors.Wrap(err, "Failed to statefully stop instance")
+			}
+
+			startStateful = true
+
+			// Resume the instance from its saved state if it can't be moved.
+			revert.Add(func() { containerPostClusteringMigrateRestart(c) })
+		}
+
 		args := migration.VolumeSourceArgs{
 			Data: project.Instance(projectName, newName),
 		}
@@ -448,6 +515,9 @@ func containerPostClusteringMigrateWithCeph(d *Daemon, c instance.Instance, proj
 			return errors.Wrap(err, "Failed to rename ceph RBD volume")
 		}
 
+		// The volume now belongs to the moved instance, so the original one can't be restarted anymore.
+		revert.Success()
+
 		// Re-link the database entries against the new node name.
 		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
 			err := tx.UpdateInstanceNode(projectName, oldName, newName, newNode)
@@ -484,6 +554,37 @@ func containerPostClusteringMigrateWithCeph(d *Daemon, c instance.Instance, proj
 			}
 		}
 
+		// Resume the instance on the new node from its saved state.
+		if startStateful {
+			if client == nil {
+				inst, err := instance.LoadByProjectAndName(d.State(), projectName, newName)
+				if err != nil {
+					return errors.Wrap(err, "Failed to load moved instance")
+				}
+
+				err = inst.Start(true)
+				if err != nil {
+					return errors.Wrap(err, "Failed to statefully start instance")
+				}
+			} else {
+				req := api.InstanceStatePut{
+					Action:   "start",
+					Stateful: true,
+					Timeout:  -1,
+				}
+
+				op, err := client.UseProject(projectName).UpdateInstanceState(newName, req, "")
+				if err != nil {
+					return errors.Wrap(err, "Failed to issue stateful start API request")
+				}
+
+				err = op.Wait()
+				if err != nil {
+					return errors.Wrap(err, "Stateful start operation failed")
+				}
+			}
+		}
+
 		return nil
 	}
 
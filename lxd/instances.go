@@ -108,6 +108,16 @@ var instanceConsoleCmd = APIEndpoint{
 	Delete: APIEndpointAction{Handler: containerConsoleLogDelete, AccessHandler: allowProjectPermission("containers", "operate-containers")},
 }
 
+var instanceConsoleKeysCmd = APIEndpoint{
+	Name: "instanceConsoleKeys",
+	Path: "instances/{name}/console/keys",
+	Aliases: []APIEndpointAlias{
+		{Name: "vmConsoleKeys", Path: "virtual-machines/{name}/console/keys"},
+	},
+
+	Post: APIEndpointAction{Handler: instanceConsoleKeysPost, AccessHandler: allowProjectPermission("containers", "operate-containers")},
+}
+
 var instanceExecCmd = APIEndpoint{
 	Name: "instanceExec",
 	Path: "instances/{name}/exec",
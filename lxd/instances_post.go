@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/dustinkirkland/golang-petname"
@@ -609,6 +610,34 @@ func createFromBackup(d *Daemon, project string, data io.Reader, pool string) re
 		bInfo.Pool = pool
 	}
 
+	// Incremental backups are applied onto the instance restored from their parent.
+	var inst instance.Instance
+	if bInfo.Parent != "" {
+		inst, err = instance.LoadByProjectAndName(d.State(), project, bInfo.Name)
+		if err != nil {
+			if errors.Cause(err) == db.ErrNoSuchObject {
+				return response.BadRequest(fmt.Errorf("The parent of the incremental backup must be restored first"))
+			}
+
+			return response.SmartError(err)
+		}
+
+		if inst.LocalConfig()["volatile.backup.uuid"] != bInfo.Parent {
+			return response.BadRequest(fmt.Errorf("Instance %q wasn't restored from the parent of the incremental backup", bInfo.Name))
+		}
+
+		instPool, err := inst.StoragePool()
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		if pool != "" && pool != instPool {
+			return response.BadRequest(fmt.Errorf("Incremental backups must be restored to the storage pool of the instance"))
+		}
+
+		bInfo.Pool = instPool
+	}
+
 	logger.Debug("Backup file info loaded", log.Ctx{
 		"type":      bInfo.Type,
 		"name":      bInfo.Name,
@@ -663,6 +692,16 @@ func createFromBackup(d *Daemon, project string, data io.Reader, pool string) re
 			return fmt.Errorf("Optimized backup storage driver %q differs from the target storage pool driver %q", bInfo.Backend, pool.Driver().Info().Name)
 		}
 
+		if bInfo.Parent != "" {
+			err = refreshFromBackup(inst, pool, bInfo, backupFile, op)
+			if err != nil {
+				return err
+			}
+
+			runRevert.Success()
+			return nil
+		}
+
 		// Dump tarball to storage. Because the backup file is unpacked and restored onto the storage
 		// device before the instance is created in the database it is necessary to return two functions;
 		// a post hook that can be run once the instance has been created in the database to run any
@@ -713,6 +752,14 @@ func createFromBackup(d *Daemon, project string, data io.Reader, pool string) re
 			}
 		}
 
+		// Record the backup the instance is restored from, so that its incremental backups can be applied later on.
+		if bInfo.UUID != "" {
+			err = inst.VolatileSet(map[string]string{"volatile.backup.uuid": bInfo.UUID})
+			if err != nil {
+				return errors.Wrap(err, "Set backup UUID")
+			}
+		}
+
 		runRevert.Success()
 		return nil
 	}
@@ -730,6 +777,61 @@ func createFromBackup(d *Daemon, project string, data io.Reader, pool string) re
 	return operations.OperationResponse(op)
 }
 
+// refreshFromBackup applies an incremental backup onto the instance restored from its parent, and then applies the
+// instance config contained in the backup.
+func refreshFromBackup(inst instance.Instance, pool storagePools.Pool, bInfo *backup.Info, srcData io.ReadSeeker, op *operations.Operation) error {
+	if string(bInfo.Type) != inst.Type().String() {
+		return fmt.Errorf("Instance type of the incremental backup differs from the one of the instance")
+	}
+
+	err := pool.RefreshInstanceFromBackup(inst, *bInfo, srcData, op)
+	if err != nil {
+		return errors.Wrap(err, "Refresh instance from incremental backup")
+	}
+
+	// Load the instance config from the refreshed backup.yaml file.
+	_, err = pool.MountInstance(inst, op)
+	if err != nil {
+		return errors.Wrap(err, "Mount instance")
+	}
+
+	backupConf, err := backup.ParseInstanceConfigYamlFile(filepath.Join(inst.Path(), "backup.yaml"))
+	pool.UnmountInstance(inst, op)
+	if err != nil {
+		return errors.Wrap(err, "Parse backup file")
+	}
+
+	arch, err := osarch.ArchitectureId(backupConf.Container.Architecture)
+	if err != nil {
+		return err
+	}
+
+	config := backupConf.Container.Config
+	if config == nil {
+		config = map[string]string{}
+	}
+
+	// Record the backup the instance is refreshed from, so that the next incremental backups can be applied.
+	config["volatile.backup.uuid"] = bInfo.UUID
+
+	args := db.InstanceArgs{
+		Architecture: arch,
+		Config:       config,
+		Description:  backupConf.Container.Description,
+		Devices:      deviceConfig.NewDevices(backupConf.Container.Devices),
+		Ephemeral:    backupConf.Container.Ephemeral,
+		Profiles:     backupConf.Container.Profiles,
+		Project:      inst.Project(),
+	}
+
+	err = inst.Update(args, false)
+	if err != nil {
+		return errors.Wrap(err, "Update instance")
+	}
+
+	return nil
+}
+
 func containersPost(d *Daemon, r *http.Request) response.Response {
 	project := projectParam(r)
 	logger.Debugf("Responding to instance create")
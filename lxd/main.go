@@ -189,6 +189,10 @@ func main() {
 	netcatCmd := cmdNetcat{global: &globalCmd}
 	app.AddCommand(netcatCmd.Command())
 
+	// recover sub-command
+	recoverCmd := cmdRecover{global: &globalCmd}
+	app.AddCommand(recoverCmd.Command())
+
 	// shutdown sub-command
 	shutdownCmd := cmdShutdown{global: &globalCmd}
 	app.AddCommand(shutdownCmd.Command())
@@ -31,6 +31,9 @@ func (c *cmdImport) Command() *cobra.Command {
   To do so, you must first mount your container storage at the expected
   path inside the storage-pools directory. Once that's in place,
   ` + "`lxd import`" + ` can be called for each individual container.
+
+  To recover all the instances and volumes of the storage pools at once,
+  use ` + "`lxd recover`" + ` instead.
 `
 	cmd.RunE = c.Run
 	cmd.Flags().BoolVarP(&c.flagForce, "force", "f", false, "Force the import (override existing data or partial restore)")
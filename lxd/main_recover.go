@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	lxd "github.com/lxc/lxd/client"
+	storageDrivers "github.com/lxc/lxd/lxd/storage/drivers"
+	"github.com/lxc/lxd/shared/api"
+	cli "github.com/lxc/lxd/shared/cmd"
+)
+
+type cmdRecover struct {
+	global *cmdGlobal
+}
+
+func (c *cmdRecover) Command() *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.Use = "recover"
+	cmd.Short = "Recover missing instances and volumes from existing and unknown storage pools"
+	cmd.Long = `Description:
+  Recover missing instances and volumes from existing and unknown storage pools
+
+  This command is mostly used for disaster recovery. It will ask you about unknown storage pools and attempt to
+  access them, along with existing storage pools, and identify any missing instances and volumes that exist on the
+  pools but are unknown to the LXD database. It then offers to recreate these database records.
+`
+	cmd.RunE = c.Run
+
+	return cmd
+}
+
+func (c *cmdRecover) Run(cmd *cobra.Command, args []string) error {
+	// Only root should run this.
+	if os.Geteuid() != 0 {
+		return fmt.Errorf("This must be run as root")
+	}
+
+	d, err := lxd.ConnectLXDUnix("", nil)
+	if err != nil {
+		return err
+	}
+
+	isClustered := d.IsClustered()
+
+	// Get the existing storage pools.
+	existingPools, err := d.GetStoragePools()
+	if err != nil {
+		return fmt.Errorf("Failed getting existing storage pools: %v", err)
+	}
+
+	fmt.Println("This LXD server currently has the following storage pools:")
+	for _, existingPool := range existingPools {
+		fmt.Printf(" - %s (backend=%q, source=%q)\n", existingPool.Name, existingPool.Driver, existingPool.Config["source"])
+	}
+
+	supportedDriverNames := storageDrivers.AllDriverNames()
+
+	unknownPools := make([]api.StoragePoolsPost, 0, len(existingPools))
+
+	// Unknown pools can only be recovered on standalone servers.
+	if !isClustered {
+		for {
+			if !cli.AskBool("Would you like to recover another storage pool? (yes/no) [default=no]: ", "no") {
+				break
+			}
+
+			// Ask for pool info.
+			unknownPool := api.StoragePoolsPost{
+				StoragePoolPut: api.StoragePoolPut{
+					Config: map[string]string{},
+				},
+			}
+
+			unknownPool.Name = cli.AskString("Name of the storage pool: ", "", func(value string) error {
+				for _, existingPool := range existingPools {
+					if value == existingPool.Name {
+						return fmt.Errorf("Storage pool %q already exists", value)
+					}
+				}
+
+				for _, pool := range unknownPools {
+					if value == pool.Name {
+						return fmt.Errorf("Storage pool %q already selected", value)
+					}
+				}
+
+				return nil
+			})
+
+			unknownPool.Driver = cli.AskChoice(fmt.Sprintf("Name of the storage backend (%s): ", strings.Join(supportedDriverNames, ", ")), supportedDriverNames, "")
+			unknownPool.Config["source"] = cli.AskString("Source of the storage pool (block device, volume group, dataset, path, ... as applicable): ", "", nil)
+
+			for {
+				value := cli.AskString("Additional storage pool configuration property (KEY=VALUE, empty when done): ", "", func(value string) error {
+					if value == "" {
+						return nil
+					}
+
+					parts := strings.SplitN(value, "=", 2)
+					if len(parts) < 2 {
+						return fmt.Errorf("Invalid KEY=VALUE format")
+					}
+
+					return nil
+				})
+
+				if value == "" {
+					break
+				}
+
+				parts := strings.SplitN(value, "=", 2)
+				unknownPool.Config[parts[0]] = parts[1]
+			}
+
+			unknownPools = append(unknownPools, unknownPool)
+		}
+	}
+
+	fmt.Println("The recovery process will be scanning the following storage pools:")
+	for _, p := range existingPools {
+		fmt.Printf(" - EXISTING: %q (backend=%q, source=%q)\n", p.Name, p.Driver, p.Config["source"])
+	}
+
+	for _, p := range unknownPools {
+		fmt.Printf(" - NEW: %q (backend=%q, source=%q)\n", p.Name, p.Driver, p.Config["source"])
+	}
+
+	if !cli.AskBool("Would you like to continue with scanning for lost volumes? (yes/no) [default=yes]: ", "yes") {
+		return nil
+	}
+
+	fmt.Println("Scanning for unknown volumes...")
+
+	// Send the existing and unknown pools to the server for scanning.
+	reqValidate := internalRecoverValidatePost{
+		Pools: make([]api.StoragePoolsPost, 0, len(existingPools)+len(unknownPools)),
+	}
+
+	for _, p := range existingPools {
+		reqValidate.Pools = append(reqValidate.Pools, api.StoragePoolsPost{
+			Name:           p.Name,
+			Driver:         p.Driver,
+			StoragePoolPut: p.StoragePoolPut,
+		})
+	}
+
+	reqValidate.Pools = append(reqValidate.Pools, unknownPools...)
+
+	for {
+		resp, _, err := d.RawQuery("POST", "/internal/recover/validate", reqValidate, "")
+		if err != nil {
+			return fmt.Errorf("Failed validation request: %v", err)
+		}
+
+		res := internalRecoverValidateResult{}
+		err = resp.MetadataAsStruct(&res)
+		if err != nil {
+			return fmt.Errorf("Failed parsing validation response: %v", err)
+		}
+
+		if len(unknownPools) > 0 {
+			fmt.Println("The following unknown storage pools have been found:")
+			for _, p := range unknownPools {
+				fmt.Printf(" - Storage pool %q of type %q\n", p.Name, p.Driver)
+			}
+		}
+
+		if len(res.UnknownVolumes) > 0 {
+			fmt.Println("The following unknown volumes have been found:")
+			for _, v := range res.UnknownVolumes {
+				fmt.Printf(" - %s %q on pool %q in project %q (includes %d snapshots)\n", strings.Title(v.Type), v.Name, v.Pool, v.Project, v.SnapshotCount)
+			}
+		}
+
+		if len(unknownPools)+len(res.UnknownVolumes) <= 0 {
+			fmt.Println("No unknown volumes found. Nothing to do.")
+			return nil
+		}
+
+		if len(res.DependencyErrors) > 0 {
+			fmt.Println("You are currently missing the following:")
+
+			for _, depErr := range res.DependencyErrors {
+				fmt.Printf(" - %s\n", depErr)
+			}
+
+			cli.AskString("Please create those missing entries and then hit ENTER: ", "", func(value string) error { return nil })
+		} else {
+			if !cli.AskBool("Would you like those to be recovered? (yes/no) [default=no]: ", "no") {
+				return nil
+			}
+
+			fmt.Println("Starting recovery...")
+			reqImport := internalRecoverImportPost{
+				Pools: reqValidate.Pools,
+			}
+
+			_, _, err := d.RawQuery("POST", "/internal/recover/import", reqImport, "")
+			if err != nil {
+				return fmt.Errorf("Failed import request: %v", err)
+			}
+
+			fmt.Println("All unknown volumes have been recovered. Recovered instances are left stopped.")
+
+			return nil
+		}
+	}
+}
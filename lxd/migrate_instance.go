@@ -47,13 +47,12 @@ func newMigrationSource(inst instance.Instance, stateful bool, instanceOnly bool
 	}
 
 	if stateful && inst.IsRunning() {
-		if inst.Type() == instancetype.VM {
-			return nil, errors.Wrap(storagePools.ErrNotImplemented, "Unable to perform VM live migration")
-		}
-
-		_, err := exec.LookPath("criu")
-		if err != nil {
-			return nil, fmt.Errorf("Unable to perform container live migration. CRIU isn't installed on the source server")
+		// VM state is transferred by QEMU itself, CRIU is only needed for containers.
+		if inst.Type() == instancetype.Container {
+			_, err := exec.LookPath("criu")
+			if err != nil {
+				return nil, fmt.Errorf("Unable to perform container live migration. CRIU isn't installed on the source server")
+			}
 		}
 
 		ret.live = true
@@ -348,7 +347,13 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 	// to false here. The migration source/sender doesn't need to care whether
 	// or not it's doing a refresh as the migration sink/receiver will know
 	// this, and adjust the migration types accordingly.
-	poolMigrationTypes = pool.MigrationTypes(storagePools.InstanceContentType(s.instance), false)
+	volType, err := storagePools.InstanceTypeToVolumeType(s.instance.Type())
+	if err != nil {
+		return err
+	}
+
+	// Instance volumes are encrypted based on the pool's config, so there is no need for the volume config.
+	poolMigrationTypes = storagePools.VolumeMigrationTypes(pool, volType, storagePools.InstanceContentType(s.instance), nil, false)
 	if len(poolMigrationTypes) < 0 {
 		return fmt.Errorf("No source migration types available")
 	}
@@ -364,6 +369,8 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 		if s.instance.IsRunning() {
 			criuType = migration.CRIUType_NONE.Enum()
 		}
+	} else if s.instance.Type() == instancetype.VM {
+		criuType = migration.CRIUType_VM_QEMU.Enum()
 	}
 	offerHeader.Criu = criuType
 
@@ -422,7 +429,7 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 	// Add predump info to source header.
 	offerUsePreDumps := false
 	maxDumpIterations := 0
-	if s.live {
+	if s.live && s.instance.Type() == instancetype.Container {
 		offerUsePreDumps, maxDumpIterations = s.checkForPreDumpSupport()
 	}
 
@@ -500,7 +507,44 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 	restoreSuccess := make(chan bool, 1)
 	dumpSuccess := make(chan error, 1)
 
-	if s.live {
+	if s.live && s.instance.Type() == instancetype.VM {
+		if respHeader.Criu == nil || *respHeader.Criu != migration.CRIUType_VM_QEMU {
+			return abort(fmt.Errorf("Target doesn't support VM stateful migration"))
+		}
+
+		// From here on the VM is paused, so make sure it is resumed if anything fails.
+		vmAbort := abort
+		abort = func(err error) error {
+			s.instance.Unfreeze()
+			return vmAbort(err)
+		}
+
+		err = s.instance.Freeze()
+		if err != nil {
+			return abort(errors.Wrap(err, "Failed pausing VM"))
+		}
+
+		// The target starts the VM straight from the state stream, so its storage must be complete first.
+		volSourceArgs.FinalSync = true
+		volSourceArgs.Snapshots = nil
+
+		err = pool.MigrateInstance(s.instance, &shared.WebsocketIO{Conn: s.fsConn}, volSourceArgs, migrateOp)
+		if err != nil {
+			return abort(err)
+		}
+
+		// Migrate the VM memory and device state over the state websocket into QEMU on the target.
+		stateConn := &shared.WebsocketIO{Conn: s.criuConn}
+		err = s.instance.(instance.VM).MigrateSend(stateConn)
+		if err != nil {
+			return abort(errors.Wrap(err, "Failed sending VM state"))
+		}
+
+		err = stateConn.Close()
+		if err != nil {
+			return abort(err)
+		}
+	} else if s.live {
 		if respHeader.Criu == nil {
 			return abort(fmt.Errorf("Got no CRIU socket type for live migration"))
 		} else if *respHeader.Criu != migration.CRIUType_CRIU_RSYNC {
@@ -675,8 +719,8 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 		}
 	}
 
-	// Perform final sync if in multi sync mode.
-	if volSourceArgs.MultiSync {
+	// Perform final sync if in multi sync mode (and not already done for a VM).
+	if volSourceArgs.MultiSync && !volSourceArgs.FinalSync {
 		// Indicate to the storage driver we are doing final sync and because of this don't send
 		// snapshots as they don't need to have a final sync as not being modified.
 		volSourceArgs.FinalSync = true
@@ -692,10 +736,29 @@ func (s *migrationSourceWs) Do(state *state.State, migrateOp *operations.Operati
 	err = s.recv(&msg)
 	if err != nil {
 		s.disconnect()
+
+		if s.live && s.instance.Type() == instancetype.VM {
+			s.instance.Unfreeze()
+		}
+
 		return err
 	}
 
-	if s.live {
+	if s.live && s.instance.Type() == instancetype.VM {
+		if !*msg.Success {
+			// The target failed to restore the state, resume the source VM.
+			err := s.instance.Unfreeze()
+			if err != nil {
+				logger.Errorf("Failed resuming VM after failed stateful migration: %v", err)
+			}
+		} else {
+			// The VM is now running on the target, stop the paused source VM.
+			err := s.instance.Stop(false)
+			if err != nil {
+				logger.Errorf("Failed stopping VM after stateful migration: %v", err)
+			}
+		}
+	} else if s.live {
 		restoreSuccess <- *msg.Success
 		err := <-dumpSuccess
 		if err != nil {
@@ -759,11 +822,14 @@ func newMigrationSink(args *MigrationSinkArgs) (*migrationSink, error) {
 		sink.src.live = ok
 	}
 
-	_, err = exec.LookPath("criu")
-	if sink.push && sink.dest.live && err != nil {
-		return nil, fmt.Errorf("Unable to perform container live migration. CRIU isn't installed on the destination server")
-	} else if sink.src.live && err != nil {
-		return nil, fmt.Errorf("Unable to perform container live migration. CRIU isn't installed on the destination server")
+	// VM state is restored by QEMU itself, CRIU is only needed for containers.
+	if args.Instance.Type() == instancetype.Container {
+		_, err = exec.LookPath("criu")
+		if sink.push && sink.dest.live && err != nil {
+			return nil, fmt.Errorf("Unable to perform container live migration. CRIU isn't installed on the destination server")
+		} else if sink.src.live && err != nil {
+			return nil, fmt.Errorf("Unable to perform container live migration. CRIU isn't installed on the destination server")
+		}
 	}
 
 	return &sink, nil
@@ -837,6 +903,8 @@ func (c *migrationSink) Do(state *state.State, migrateOp *operations.Operation)
 	} else {
 		if !live {
 			criuType = nil
+		} else if c.src.instance.Type() == instancetype.VM {
+			criuType = migration.CRIUType_VM_QEMU.Enum()
 		}
 	}
 
@@ -855,7 +923,12 @@ func (c *migrationSink) Do(state *state.State, migrateOp *operations.Operation)
 	// supported types and features. If a match is found the combined features list
 	// will be sent back to requester.
 	contentType := storagePools.InstanceContentType(c.src.instance)
-	respTypes, err := migration.MatchTypes(offerHeader, storagePools.FallbackMigrationType(contentType), pool.MigrationTypes(contentType, c.refresh))
+	volType, err := storagePools.InstanceTypeToVolumeType(c.src.instance.Type())
+	if err != nil {
+		return err
+	}
+
+	respTypes, err := migration.MatchTypes(offerHeader, storagePools.FallbackMigrationType(contentType), storagePools.VolumeMigrationTypes(pool, volType, contentType, nil, c.refresh))
 	if err != nil {
 		return err
 	}
@@ -1069,7 +1142,15 @@ func (c *migrationSink) Do(state *state.State, migrateOp *operations.Operation)
 			fsTransfer <- nil
 		}()
 
-		if live {
+		var criuConn *websocket.Conn
+		if c.push {
+			criuConn = c.dest.criuConn
+		} else {
+			criuConn = c.src.criuConn
+		}
+
+		// The VM state is only sent once the storage transfer is done, it is then streamed straight into QEMU.
+		if live && c.src.instance.Type() != instancetype.VM {
 			var err error
 			imagesDir, err = ioutil.TempDir("", "lxd_restore_")
 			if err != nil {
@@ -1079,13 +1160,6 @@ func (c *migrationSink) Do(state *state.State, migrateOp *operations.Operation)
 
 			defer os.RemoveAll(imagesDir)
 
-			var criuConn *websocket.Conn
-			if c.push {
-				criuConn = c.dest.criuConn
-			} else {
-				criuConn = c.src.criuConn
-			}
-
 			sync := &migration.MigrationSync{
 				FinalPreDump: proto.Bool(false),
 			}
@@ -1135,7 +1209,14 @@ func (c *migrationSink) Do(state *state.State, migrateOp *operations.Operation)
 			return
 		}
 
-		if live {
+		if live && c.src.instance.Type() == instancetype.VM {
+			// Start the VM from the state as it is received.
+			err = c.src.instance.(instance.VM).MigrateReceive(&shared.WebsocketIO{Conn: criuConn})
+			if err != nil {
+				restore <- errors.Wrap(err, "Failed restoring VM state")
+				return
+			}
+		} else if live {
 			criuMigrationArgs := instance.CriuMigrationArgs{
 				Cmd:          liblxc.MIGRATE_RESTORE,
 				StateDir:     imagesDir,
@@ -11,6 +11,7 @@ import (
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/state"
 	storagePools "github.com/lxc/lxd/lxd/storage"
+	storageDrivers "github.com/lxc/lxd/lxd/storage/drivers"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	"github.com/lxc/lxd/shared/logger"
@@ -67,7 +68,7 @@ func (s *migrationSourceWs) DoStorage(state *state.State, projectName string, po
 	// to false here. The migration source/sender doesn't need to care whether
 	// or not it's doing a refresh as the migration sink/receiver will know
 	// this, and adjust the migration types accordingly.
-	poolMigrationTypes = pool.MigrationTypes(volContentType, false)
+	poolMigrationTypes = storagePools.VolumeMigrationTypes(pool, storageDrivers.VolumeTypeCustom, volContentType, vol.Config, false)
 	if len(poolMigrationTypes) < 0 {
 		return fmt.Errorf("No source migration types available")
 	}
@@ -275,7 +276,7 @@ func (c *migrationSink) DoStorage(state *state.State, projectName string, poolNa
 	// Extract the source's migration type and then match it against our pool's
 	// supported types and features. If a match is found the combined features list
 	// will be sent back to requester.
-	respTypes, err := migration.MatchTypes(offerHeader, storagePools.FallbackMigrationType(contentType), pool.MigrationTypes(contentType, c.refresh))
+	respTypes, err := migration.MatchTypes(offerHeader, storagePools.FallbackMigrationType(contentType), storagePools.VolumeMigrationTypes(pool, storageDrivers.VolumeTypeCustom, contentType, req.Config, c.refresh))
 	if err != nil {
 		return err
 	}
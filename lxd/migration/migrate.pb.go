@@ -75,18 +75,21 @@ const (
 	CRIUType_CRIU_RSYNC CRIUType = 0
 	CRIUType_PHAUL      CRIUType = 1
 	CRIUType_NONE       CRIUType = 2
+	CRIUType_VM_QEMU    CRIUType = 3
 )
 
 var CRIUType_name = map[int32]string{
 	0: "CRIU_RSYNC",
 	1: "PHAUL",
 	2: "NONE",
+	3: "VM_QEMU",
 }
 
 var CRIUType_value = map[string]int32{
 	"CRIU_RSYNC": 0,
 	"PHAUL":      1,
 	"NONE":       2,
+	"VM_QEMU":    3,
 }
 
 func (x CRIUType) Enum() *CRIUType {
@@ -994,76 +997,76 @@ func init() {
 func init() { proto.RegisterFile("lxd/migration/migrate.proto", fileDescriptor_fe8772548dc4b615) }
 
 var fileDescriptor_fe8772548dc4b615 = []byte{
-	// 1128 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x55, 0xdd, 0x8e, 0xd3, 0x46,
-	0x14, 0x6e, 0x12, 0x67, 0x37, 0x3e, 0x49, 0x76, 0xc3, 0x80, 0x90, 0x05, 0x2d, 0x4d, 0x0d, 0x55,
-	0xc3, 0x56, 0x02, 0x1a, 0x54, 0x89, 0xab, 0x4a, 0x6c, 0xd2, 0x2d, 0xa8, 0x10, 0x56, 0x13, 0x50,
-	0xd5, 0xde, 0x58, 0xb3, 0xf6, 0x71, 0x32, 0xc2, 0x7f, 0x9a, 0xb1, 0x17, 0x92, 0x9b, 0xaa, 0x0f,
-	0xd3, 0xe7, 0xe9, 0x55, 0x1f, 0xa6, 0x77, 0xd5, 0xcc, 0xd8, 0xc6, 0xde, 0xad, 0xd4, 0xbb, 0x39,
-	0xdf, 0xf9, 0xfc, 0x9d, 0x33, 0xe7, 0x67, 0x0c, 0x77, 0xa3, 0x8f, 0xc1, 0xe3, 0x98, 0x6f, 0x04,
-	0xcb, 0x79, 0x9a, 0x94, 0x27, 0x7c, 0x94, 0x89, 0x34, 0x4f, 0x89, 0x5d, 0x3b, 0xdc, 0xdf, 0xc1,
-	0x7e, 0xb9, 0x7c, 0xcd, 0xb2, 0xb7, 0xbb, 0x0c, 0xc9, 0x2d, 0xe8, 0x73, 0x59, 0xf0, 0xc0, 0xe9,
-	0x4c, 0xbb, 0xb3, 0x01, 0x35, 0x86, 0x41, 0x37, 0x3c, 0x70, 0xba, 0x15, 0xba, 0xe1, 0x01, 0xb9,
-	0x0d, 0x07, 0xdb, 0x54, 0xe6, 0x3c, 0x70, 0x7a, 0xd3, 0xee, 0xac, 0x4f, 0x4b, 0x8b, 0x10, 0xb0,
-	0x12, 0xc9, 0x03, 0xc7, 0xd2, 0xa8, 0x3e, 0x93, 0x3b, 0x30, 0x88, 0x59, 0x26, 0x58, 0xb2, 0x41,
-	0xa7, 0xaf, 0xf1, 0xda, 0x76, 0x9f, 0xc0, 0xc1, 0x22, 0x4d, 0x42, 0xbe, 0x21, 0x13, 0xe8, 0xbd,
-	0xc7, 0x9d, 0x8e, 0x6d, 0x53, 0x75, 0x54, 0x91, 0x2f, 0x59, 0x54, 0xa0, 0x8e, 0x6c, 0x53, 0x63,
-	0xb8, 0x3f, 0xc1, 0xc1, 0x12, 0x2f, 0xb9, 0x8f, 0x3a, 0x16, 0x8b, 0xb1, 0xfc, 0x44, 0x9f, 0xc9,
-	0x43, 0x38, 0xf0, 0xb5, 0x9e, 0xd3, 0x9d, 0xf6, 0x66, 0xc3, 0xf9, 0x8d, 0x47, 0xf5, 0x65, 0x1f,
-	0x99, 0x40, 0xb4, 0x24, 0xb8, 0x7f, 0x75, 0x61, 0xb0, 0x4e, 0x58, 0x26, 0xb7, 0x69, 0xfe, 0x9f,
-	0x5a, 0x4f, 0x61, 0x18, 0xa5, 0x3e, 0x8b, 0x16, 0xff, 0x23, 0xd8, 0x64, 0xa9, 0xcb, 0x66, 0x22,
-	0x0d, 0x79, 0x84, 0xd2, 0xe9, 0x4d, 0x7b, 0x33, 0x9b, 0xd6, 0x36, 0xf9, 0x1c, 0x6c, 0xcc, 0xb6,
-	0x18, 0xa3, 0x60, 0x91, 0xae, 0xd0, 0x80, 0x7e, 0x02, 0xc8, 0xf7, 0x30, 0xd2, 0x42, 0xe6, 0x76,
-	0xd2, 0xe9, 0x5f, 0x8b, 0x67, 0x3c, 0xb4, 0x45, 0x23, 0x2e, 0x8c, 0x98, 0xf0, 0xb7, 0x3c, 0x47,
-	0x3f, 0x2f, 0x04, 0x3a, 0x07, 0xba, 0xc2, 0x2d, 0x4c, 0x25, 0x25, 0x73, 0x96, 0x63, 0x58, 0x44,
-	0xce, 0xa1, 0x8e, 0x5b, 0xdb, 0xe4, 0x3e, 0x8c, 0x7d, 0x81, 0x3a, 0x80, 0x17, 0xb0, 0x1c, 0x9d,
-	0xc1, 0xb4, 0x33, 0xeb, 0xd1, 0x51, 0x05, 0x2e, 0x59, 0x8e, 0xe4, 0x01, 0x1c, 0x45, 0x4c, 0xe6,
-	0x5e, 0x21, 0x31, 0x30, 0x2c, 0xdb, 0xb0, 0x14, 0xfa, 0x4e, 0x62, 0xa0, 0x58, 0xee, 0x1f, 0x1d,
-	0x18, 0x0b, 0xb9, 0x4b, 0xfc, 0x33, 0x64, 0x2a, 0xae, 0x54, 0x63, 0xf2, 0x91, 0xe5, 0xb9, 0x90,
-	0x4e, 0x67, 0xda, 0x99, 0x0d, 0x68, 0x69, 0x29, 0x3c, 0xc0, 0x08, 0x73, 0xd5, 0x5b, 0x8d, 0x1b,
-	0x4b, 0x25, 0xea, 0xa7, 0x71, 0x26, 0x50, 0xaa, 0xea, 0x29, 0x4f, 0x6d, 0x93, 0x07, 0x30, 0xbe,
-	0xe0, 0x01, 0x17, 0xe8, 0xab, 0xb4, 0x74, 0x05, 0x15, 0xa1, 0x0d, 0xba, 0x0f, 0x61, 0xb8, 0x0f,
-	0x65, 0x9d, 0x40, 0x53, 0xb0, 0xd3, 0x16, 0x74, 0x37, 0x30, 0xbe, 0xc8, 0x45, 0x83, 0xfc, 0x10,
-	0x26, 0x75, 0xb1, 0xbd, 0x2d, 0xb2, 0x00, 0x45, 0xf9, 0xd1, 0x71, 0x8d, 0xbf, 0xd0, 0x30, 0xf9,
-	0x16, 0x6e, 0x18, 0x82, 0x27, 0x8b, 0x8b, 0xcb, 0x34, 0x2a, 0x62, 0x94, 0xe5, 0x5d, 0x26, 0xc6,
-	0xb1, 0xae, 0x71, 0xf7, 0x9f, 0x1e, 0x1c, 0xbf, 0xbe, 0x22, 0x70, 0x02, 0xdd, 0x50, 0xea, 0x71,
-	0x3b, 0x9a, 0xdf, 0x69, 0xf4, 0xb8, 0xe6, 0x9d, 0xad, 0xd5, 0x52, 0xd2, 0x6e, 0x28, 0xc9, 0x37,
-	0x60, 0xf9, 0x82, 0x17, 0x5a, 0xff, 0x68, 0x7e, 0xb3, 0x39, 0x81, 0xf4, 0xe5, 0x3b, 0x4d, 0xd3,
-	0x04, 0x72, 0x02, 0x7d, 0x1e, 0xc4, 0x2c, 0xd3, 0x93, 0x37, 0x9c, 0xdf, 0x6a, 0x30, 0xeb, 0x35,
-	0xa7, 0x86, 0xa2, 0xca, 0x29, 0xcb, 0xe9, 0x5f, 0x31, 0x95, 0xbd, 0xa5, 0xa7, 0xb5, 0x0d, 0x92,
-	0xef, 0xc0, 0xae, 0x80, 0x6a, 0x22, 0x9b, 0xf1, 0xab, 0xfd, 0xa1, 0x9f, 0x58, 0xc4, 0x81, 0xc3,
-	0x4c, 0x60, 0x50, 0xc4, 0x99, 0x73, 0xa8, 0x0b, 0x52, 0x99, 0xe4, 0x87, 0x2b, 0xe3, 0xa1, 0x47,
-	0x6d, 0x38, 0x77, 0x1a, 0x82, 0x2d, 0x3f, 0xbd, 0x32, 0x4d, 0x0e, 0x1c, 0x0a, 0x0c, 0x05, 0xca,
-	0xad, 0x1e, 0xbf, 0x01, 0xad, 0x4c, 0xf2, 0xac, 0xd5, 0x75, 0x07, 0xb4, 0xee, 0xed, 0x86, 0x6e,
-	0xc3, 0x4b, 0x5b, 0x03, 0x72, 0x0f, 0xc0, 0xb4, 0x69, 0xcd, 0xf7, 0xe8, 0x0c, 0xf5, 0x54, 0x37,
-	0x10, 0x95, 0x73, 0x6b, 0x48, 0x9c, 0xd1, 0xb5, 0x9c, 0x5b, 0x7e, 0xda, 0xa6, 0xbb, 0x67, 0x30,
-	0xa9, 0x5b, 0xba, 0x48, 0x93, 0x5c, 0xa4, 0x91, 0xba, 0x87, 0x2c, 0x7c, 0xdf, 0xcc, 0xa4, 0xda,
-	0xc6, 0xca, 0x54, 0x9e, 0x18, 0xa5, 0x64, 0x1b, 0xb3, 0x18, 0x36, 0xad, 0x4c, 0xf7, 0x29, 0x8c,
-	0x6b, 0x9d, 0xf5, 0x2e, 0xf1, 0xd5, 0xde, 0x87, 0x3c, 0x61, 0xd1, 0xb9, 0xc0, 0xa5, 0xaa, 0xb5,
-	0x51, 0x6a, 0x61, 0xee, 0x9f, 0x3d, 0x98, 0xa8, 0xca, 0x7b, 0x6a, 0xdb, 0xa5, 0x87, 0x49, 0x2e,
-	0x76, 0x6a, 0xe1, 0x43, 0x81, 0xb8, 0xe7, 0xc9, 0xc6, 0xcb, 0x79, 0xf9, 0xe6, 0x8d, 0xe9, 0xa8,
-	0x02, 0xdf, 0xf2, 0x18, 0xc9, 0x97, 0x30, 0x0c, 0x45, 0xba, 0xc7, 0xc4, 0x50, 0xba, 0x9a, 0x02,
-	0x06, 0xd2, 0x84, 0xaf, 0x60, 0x14, 0x63, 0xac, 0xc5, 0x35, 0xa3, 0xa7, 0x19, 0xc3, 0x12, 0xd3,
-	0x94, 0xfb, 0x30, 0x8e, 0x31, 0xfe, 0x20, 0x78, 0x8e, 0x86, 0x63, 0x99, 0x40, 0x15, 0x58, 0x91,
-	0x32, 0xb6, 0x41, 0xe9, 0x49, 0x9f, 0x25, 0x09, 0x06, 0xfa, 0x0f, 0x61, 0xd1, 0x91, 0x06, 0xd7,
-	0x06, 0x23, 0x4f, 0xe0, 0x56, 0x49, 0x7a, 0xcf, 0xb3, 0x0c, 0x03, 0x2f, 0x63, 0x02, 0x93, 0x5c,
-	0xbf, 0x75, 0x16, 0x25, 0x86, 0x6b, 0x5c, 0xe7, 0xda, 0xf3, 0x49, 0x56, 0x45, 0xca, 0x31, 0xd1,
-	0xcf, 0x5e, 0x25, 0xfb, 0x8b, 0xc1, 0x14, 0x89, 0x8b, 0x98, 0x65, 0x9e, 0x40, 0x99, 0x46, 0x97,
-	0xe6, 0xe9, 0x1b, 0xd3, 0x91, 0x06, 0xa9, 0xc1, 0xc8, 0x17, 0x00, 0x46, 0x29, 0x62, 0xfb, 0x9d,
-	0x63, 0x6b, 0x19, 0x5b, 0x23, 0xaf, 0xd8, 0x7e, 0x57, 0xb9, 0xbd, 0x8c, 0x67, 0xe5, 0xe0, 0x95,
-	0xee, 0x73, 0x05, 0xa8, 0x87, 0xb3, 0x76, 0x7b, 0x17, 0x45, 0x28, 0xf5, 0x88, 0x95, 0x89, 0x28,
-	0xca, 0x69, 0x11, 0x4a, 0xf7, 0xef, 0x0e, 0xdc, 0x14, 0x28, 0xf3, 0x54, 0x60, 0xab, 0x55, 0x5f,
-	0x9b, 0xaf, 0xa5, 0xa7, 0xde, 0x2c, 0x26, 0xd0, 0xfc, 0x9a, 0x2d, 0x6a, 0xee, 0xb6, 0x28, 0x41,
-	0x72, 0x02, 0x37, 0xda, 0xe5, 0xf1, 0xd3, 0x0f, 0xba, 0x65, 0x16, 0x3d, 0x6e, 0xd6, 0x66, 0x91,
-	0x7e, 0x50, 0x7d, 0x0b, 0x53, 0xf1, 0xbe, 0x6e, 0x7e, 0xd9, 0xb7, 0x12, 0xab, 0x5a, 0x5b, 0x25,
-	0xd3, 0x68, 0xdb, 0xb0, 0xc4, 0x34, 0xa5, 0x4e, 0xac, 0x04, 0x55, 0xdb, 0x3a, 0x75, 0x62, 0xb4,
-	0x04, 0xdd, 0x8f, 0x30, 0x6c, 0x5e, 0xe7, 0x31, 0x58, 0x81, 0x19, 0x55, 0xb5, 0x42, 0x77, 0x1b,
-	0x2b, 0x74, 0x75, 0x48, 0xa9, 0x26, 0x92, 0x67, 0x6a, 0xe1, 0xb5, 0x96, 0x5e, 0x87, 0xe1, 0xfc,
-	0x5e, 0xf3, 0xa9, 0xb8, 0x5e, 0x30, 0x5a, 0xd1, 0x4f, 0x56, 0x8d, 0x17, 0xd7, 0xbc, 0xa4, 0xc4,
-	0x86, 0x3e, 0x5d, 0xff, 0xba, 0x5a, 0x4c, 0x3e, 0x53, 0xc7, 0xd3, 0xb7, 0xf4, 0x6c, 0x3d, 0xe9,
-	0x90, 0x43, 0xe8, 0xfd, 0x76, 0xb6, 0x9e, 0x74, 0xd5, 0x81, 0x9e, 0x2e, 0x27, 0x3d, 0x72, 0x13,
-	0x8e, 0x4f, 0x5f, 0xbd, 0x59, 0xfc, 0xec, 0x3d, 0x5f, 0x2d, 0x3d, 0xf3, 0x85, 0x75, 0xf2, 0x18,
-	0x06, 0xd5, 0x5b, 0x4b, 0x8e, 0x00, 0xd4, 0xd9, 0x6b, 0xa8, 0x9d, 0xbf, 0x78, 0xfe, 0xee, 0xd5,
-	0xa4, 0x43, 0x06, 0x60, 0xad, 0xde, 0xac, 0x7e, 0x9c, 0x74, 0xff, 0x0d, 0x00, 0x00, 0xff, 0xff,
-	0x51, 0xb3, 0x06, 0x24, 0x82, 0x09, 0x00, 0x00,
+	// 1122 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x85, 0x55, 0x5d, 0x6f, 0xe3, 0x44,
+	0x14, 0x25, 0xb1, 0xdb, 0x26, 0xd7, 0x69, 0x9b, 0x4e, 0xab, 0x55, 0xb4, 0x0b, 0xcb, 0x62, 0x40,
+	0xb4, 0x45, 0xea, 0x2e, 0x59, 0x21, 0xf1, 0x80, 0x90, 0xb6, 0xc9, 0x96, 0x5d, 0xd1, 0x66, 0xbb,
+	0x93, 0x16, 0x04, 0x2f, 0x96, 0x6b, 0x4f, 0x12, 0xab, 0x8e, 0x6d, 0x8d, 0xed, 0x7e, 0xbd, 0x20,
+	0x7e, 0x0c, 0xbf, 0x87, 0x27, 0x7e, 0x0c, 0x6f, 0xdc, 0xb9, 0x63, 0xbb, 0x76, 0x17, 0x89, 0xb7,
+	0xb9, 0xe7, 0x1e, 0x9f, 0x7b, 0xe7, 0x7e, 0x8c, 0xe1, 0x49, 0x78, 0xe3, 0x3f, 0x5f, 0x06, 0x73,
+	0xe9, 0x66, 0x41, 0x1c, 0x15, 0x27, 0x71, 0x90, 0xc8, 0x38, 0x8b, 0x59, 0xb7, 0x72, 0xd8, 0xbf,
+	0x43, 0xf7, 0xed, 0xf8, 0xc4, 0x4d, 0xce, 0x6e, 0x13, 0xc1, 0x76, 0x60, 0x25, 0x48, 0xf3, 0xc0,
+	0x1f, 0xb4, 0x9e, 0xb5, 0x77, 0x3b, 0x5c, 0x1b, 0x1a, 0x9d, 0x23, 0xda, 0x2e, 0x51, 0x34, 0xd8,
+	0x23, 0x58, 0x5d, 0xc4, 0x69, 0x86, 0xb0, 0x81, 0xf0, 0x0a, 0x2f, 0x2c, 0xc6, 0xc0, 0x8c, 0x52,
+	0x44, 0x4d, 0x42, 0xe9, 0xcc, 0x1e, 0x43, 0x67, 0xe9, 0x26, 0xd2, 0x8d, 0xe6, 0x62, 0xb0, 0x42,
+	0x78, 0x65, 0xdb, 0x2f, 0x60, 0x75, 0x14, 0x47, 0xb3, 0x60, 0xce, 0xfa, 0x60, 0x5c, 0x8a, 0x5b,
+	0x8a, 0xdd, 0xe5, 0xea, 0xa8, 0x22, 0x5f, 0xb9, 0x61, 0x2e, 0x28, 0x72, 0x97, 0x6b, 0xc3, 0xfe,
+	0x11, 0x56, 0xc7, 0xe2, 0x2a, 0xf0, 0x04, 0xc5, 0x72, 0x97, 0xa2, 0xf8, 0x84, 0xce, 0x6c, 0x0f,
+	0x56, 0x3d, 0xd2, 0xc3, 0x8f, 0x8c, 0x5d, 0x6b, 0xb8, 0x75, 0x50, 0x5d, 0xf6, 0x40, 0x07, 0xe2,
+	0x05, 0xc1, 0xfe, 0xab, 0x0d, 0x9d, 0x69, 0xe4, 0x26, 0xe9, 0x22, 0xce, 0xfe, 0x53, 0xeb, 0x25,
+	0x58, 0x61, 0xec, 0xb9, 0xe1, 0xe8, 0x7f, 0x04, 0xeb, 0x2c, 0x75, 0x59, 0xac, 0xf2, 0x2c, 0x08,
+	0x45, 0x8a, 0xa5, 0x31, 0x50, 0xac, 0xb2, 0xd9, 0xc7, 0xd0, 0x15, 0xc9, 0x42, 0x2c, 0x85, 0x74,
+	0x43, 0xaa, 0x50, 0x87, 0xdf, 0x03, 0xec, 0x5b, 0xe8, 0x91, 0x90, 0xbe, 0x5d, 0x8a, 0xa5, 0x7a,
+	0x18, 0x4f, 0x7b, 0x78, 0x83, 0xc6, 0x6c, 0xe8, 0xb9, 0xd2, 0x5b, 0x04, 0x99, 0xf0, 0xb2, 0x5c,
+	0x8a, 0xc1, 0x2a, 0x55, 0xb8, 0x81, 0xa9, 0xa4, 0xd2, 0x0c, 0x07, 0x60, 0x96, 0x87, 0x83, 0x35,
+	0x8a, 0x5b, 0xd9, 0xec, 0x73, 0x58, 0xf7, 0xa4, 0xa0, 0x00, 0x8e, 0x8f, 0xd8, 0xa0, 0xf3, 0xac,
+	0xb5, 0x6b, 0xf0, 0x5e, 0x09, 0x8e, 0x11, 0x63, 0x5f, 0xc0, 0x46, 0xe8, 0xa6, 0x99, 0x93, 0xa7,
+	0xc2, 0xd7, 0xac, 0xae, 0x66, 0x29, 0xf4, 0x1c, 0x41, 0xc5, 0xb2, 0xff, 0x68, 0xc1, 0xba, 0x4c,
+	0x6f, 0x23, 0xef, 0x08, 0x3f, 0xc5, 0xb8, 0xa9, 0x1a, 0x93, 0x1b, 0x37, 0xcb, 0x64, 0x8a, 0x85,
+	0x6d, 0x61, 0xd8, 0xc2, 0x52, 0xb8, 0x2f, 0x42, 0x91, 0xa9, 0xde, 0x12, 0xae, 0x2d, 0x95, 0xa8,
+	0x17, 0x2f, 0x13, 0xfc, 0x54, 0x55, 0x4f, 0x79, 0x2a, 0x1b, 0x73, 0x58, 0xbf, 0x08, 0xfc, 0x40,
+	0xe2, 0x9d, 0x30, 0x2d, 0xaa, 0xa0, 0x22, 0x34, 0x41, 0x7b, 0x0f, 0xac, 0xbb, 0x59, 0x5a, 0x25,
+	0x50, 0x17, 0x6c, 0x35, 0x05, 0xed, 0x39, 0x0a, 0x66, 0xb2, 0x46, 0xde, 0x83, 0x7e, 0x55, 0x6c,
+	0x67, 0x21, 0x5c, 0x5f, 0xc8, 0xe2, 0xa3, 0xcd, 0x0a, 0x7f, 0x43, 0x30, 0xfb, 0x1a, 0xb6, 0x34,
+	0xc1, 0x49, 0xf3, 0x8b, 0xab, 0x38, 0xcc, 0x97, 0xd8, 0x31, 0x7d, 0x97, 0xbe, 0x76, 0x4c, 0x2b,
+	0xdc, 0xfe, 0xc7, 0x80, 0xcd, 0x93, 0x07, 0x02, 0xfb, 0xd0, 0x9e, 0xa5, 0x34, 0x6e, 0x1b, 0xc3,
+	0xc7, 0xb5, 0x1e, 0x57, 0xbc, 0xa3, 0xa9, 0x5a, 0x4a, 0x8e, 0x2c, 0xf6, 0x15, 0x98, 0x9e, 0x0c,
+	0x72, 0xd2, 0xdf, 0x18, 0x6e, 0xd7, 0x27, 0x90, 0xbf, 0x3d, 0x27, 0x1a, 0x11, 0x50, 0x74, 0x25,
+	0xf0, 0x71, 0xb7, 0x68, 0xf2, 0xac, 0xe1, 0x4e, 0x8d, 0x59, 0xad, 0x39, 0xd7, 0x14, 0x55, 0xce,
+	0xb4, 0x98, 0xfe, 0x89, 0xab, 0xb2, 0x37, 0x69, 0x5a, 0x9b, 0x20, 0xfb, 0x06, 0xba, 0x25, 0x50,
+	0x4e, 0x64, 0x3d, 0x7e, 0xb9, 0x3f, 0xfc, 0x9e, 0xc5, 0x06, 0xb0, 0x86, 0xf5, 0xf5, 0xf3, 0x65,
+	0x82, 0xb3, 0xa6, 0x0a, 0x52, 0x9a, 0xec, 0x87, 0x07, 0xe3, 0x41, 0xa3, 0x66, 0x0d, 0x07, 0x35,
+	0xc1, 0x86, 0x9f, 0x3f, 0x98, 0x26, 0x54, 0x96, 0x62, 0x86, 0xa7, 0x05, 0x8d, 0x1f, 0x2a, 0x17,
+	0x26, 0xfb, 0xae, 0xd1, 0xf5, 0x01, 0x90, 0xee, 0xa3, 0x9a, 0x6e, 0xcd, 0xcb, 0x1b, 0x03, 0xf2,
+	0x14, 0x40, 0xb7, 0x69, 0x1a, 0xdc, 0x89, 0x81, 0x45, 0x53, 0x5d, 0x43, 0x54, 0xce, 0x8d, 0x21,
+	0x19, 0xf4, 0x3e, 0xc8, 0xb9, 0xe1, 0xe7, 0x4d, 0xba, 0x7d, 0x04, 0xfd, 0xaa, 0xa5, 0xf8, 0x44,
+	0x64, 0x32, 0x0e, 0xd5, 0x3d, 0xd2, 0xdc, 0xf3, 0xf4, 0x4c, 0xaa, 0x6d, 0x2c, 0x4d, 0xe5, 0xc1,
+	0xaa, 0xa7, 0xee, 0x5c, 0x2f, 0x46, 0x97, 0x97, 0xa6, 0xfd, 0x12, 0xd6, 0x2b, 0x9d, 0x29, 0x16,
+	0x45, 0xed, 0xfd, 0x2c, 0xc0, 0x89, 0x3f, 0x95, 0x62, 0xac, 0x6a, 0xad, 0x95, 0x1a, 0x98, 0xfd,
+	0xa7, 0x01, 0x7d, 0x55, 0x79, 0x47, 0x6d, 0x7b, 0xea, 0x08, 0x0c, 0x7f, 0xab, 0x16, 0x1e, 0x8b,
+	0x26, 0xee, 0x82, 0x68, 0xee, 0x64, 0x41, 0xf1, 0xe6, 0xad, 0xe3, 0x97, 0x05, 0x78, 0x86, 0x18,
+	0xfb, 0x14, 0xac, 0x99, 0x8c, 0xef, 0x44, 0xa4, 0x29, 0x6d, 0xa2, 0x80, 0x86, 0x88, 0xf0, 0x19,
+	0xf4, 0x96, 0x62, 0x49, 0xe2, 0xc4, 0x30, 0x88, 0x61, 0x15, 0x18, 0x51, 0x30, 0x10, 0x9a, 0xd7,
+	0x12, 0x9f, 0x21, 0xcd, 0x31, 0x75, 0xa0, 0x12, 0x2c, 0x49, 0x09, 0xde, 0x2f, 0x75, 0x52, 0xcf,
+	0x8d, 0x22, 0xe1, 0xd3, 0x1f, 0xc2, 0xe4, 0x3d, 0x02, 0xa7, 0x1a, 0x63, 0x2f, 0x60, 0xa7, 0x20,
+	0x5d, 0x06, 0x49, 0x82, 0x4f, 0x50, 0xe2, 0x4a, 0xbc, 0x0c, 0xbd, 0x75, 0x26, 0x67, 0x9a, 0xab,
+	0x5d, 0xa7, 0xe4, 0xb9, 0x97, 0x55, 0x91, 0x32, 0x11, 0xd1, 0xb3, 0x57, 0xca, 0xfe, 0xa2, 0x31,
+	0x45, 0x0a, 0x24, 0xee, 0x82, 0x83, 0x8d, 0x8a, 0xc3, 0x2b, 0xfd, 0xf4, 0x61, 0x82, 0x04, 0x72,
+	0x8d, 0xb1, 0x4f, 0x00, 0xb4, 0x52, 0xe8, 0xde, 0xdd, 0xe2, 0xdc, 0x29, 0x99, 0x2e, 0x21, 0xc7,
+	0x08, 0x94, 0x6e, 0x27, 0x09, 0x92, 0x62, 0xf0, 0x0a, 0xf7, 0xa9, 0x02, 0xd4, 0xc3, 0x59, 0xb9,
+	0x9d, 0x8b, 0x1c, 0x57, 0xde, 0x22, 0x4a, 0xaf, 0xa4, 0x1c, 0x22, 0x66, 0xff, 0xdd, 0x82, 0x6d,
+	0xcc, 0x21, 0x8b, 0xa5, 0x68, 0xb4, 0xea, 0x4b, 0xfd, 0x75, 0xea, 0xa8, 0x37, 0x0b, 0x2f, 0xa6,
+	0x7f, 0xcd, 0x26, 0xd7, 0x77, 0x1b, 0x15, 0x20, 0xae, 0xfd, 0x56, 0xb3, 0x3c, 0x5e, 0x7c, 0x4d,
+	0x2d, 0x33, 0xf9, 0x66, 0xbd, 0x36, 0xa3, 0xf8, 0x5a, 0xf5, 0x6d, 0x16, 0xcb, 0xcb, 0xaa, 0xf9,
+	0x45, 0xdf, 0x0a, 0xac, 0x6c, 0x6d, 0x99, 0x4c, 0xad, 0x6d, 0x56, 0x81, 0x11, 0xa5, 0x4a, 0xac,
+	0x00, 0x55, 0xdb, 0x5a, 0x55, 0x62, 0xbc, 0x00, 0xed, 0x1b, 0xb0, 0xea, 0xd7, 0x79, 0x0e, 0xa6,
+	0xaf, 0x47, 0x55, 0xad, 0xd0, 0x93, 0xda, 0x0a, 0x3d, 0x1c, 0x52, 0x4e, 0x44, 0x5c, 0xeb, 0xb5,
+	0x22, 0x00, 0xad, 0x83, 0x35, 0x7c, 0x5a, 0x7f, 0x2a, 0x3e, 0x2c, 0x18, 0x2f, 0xe9, 0xfb, 0x93,
+	0xda, 0x8b, 0xab, 0x5f, 0x52, 0xd6, 0x85, 0x15, 0x3e, 0xfd, 0x75, 0x32, 0xea, 0x7f, 0xa4, 0x8e,
+	0x87, 0x67, 0xfc, 0x68, 0xda, 0x6f, 0xb1, 0x35, 0x30, 0x7e, 0xc3, 0x43, 0x5b, 0x1d, 0xf8, 0xe1,
+	0xb8, 0x6f, 0xb0, 0x6d, 0xd8, 0x3c, 0x3c, 0x7e, 0x37, 0xfa, 0xc9, 0x79, 0x35, 0x19, 0x3b, 0xfa,
+	0x0b, 0x73, 0xff, 0x7b, 0xe8, 0x94, 0x6f, 0x2d, 0xdb, 0x00, 0x50, 0x67, 0xa7, 0xa6, 0x76, 0xfa,
+	0xe6, 0xd5, 0xf9, 0x31, 0xaa, 0x75, 0xc0, 0x9c, 0xbc, 0x9b, 0xbc, 0x46, 0x39, 0x0b, 0xd6, 0x7e,
+	0x3e, 0x71, 0xde, 0xbf, 0x3e, 0x39, 0xef, 0x1b, 0xff, 0x02, 0xf9, 0xf6, 0xa8, 0x05, 0x8f, 0x09,
+	0x00, 0x00,
 }
@@ -0,0 +1,454 @@
+package acl
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/network/openvswitch"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// nameRegex matches valid ACL names. Names must start with a letter so they cannot be confused with IPs or
+// subnets when used as a rule subject.
+var nameRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]*$`)
+
+// ValidName checks the ACL name is valid.
+func ValidName(name string) error {
+	if name == "" {
+		return fmt.Errorf("Name is required")
+	}
+
+	if len(name) > 63 {
+		return fmt.Errorf("Name must be 63 characters or less")
+	}
+
+	if !nameRegex.MatchString(name) {
+		return fmt.Errorf("Name must start with a letter and only contain letters, numbers and dashes")
+	}
+
+	return nil
+}
+
+// ParseNames splits a comma separated list of ACL names.
+func ParseNames(value string) []string {
+	return shared.SplitNTrimSpace(value, ",", -1, true)
+}
+
+// ValidateNames checks that a comma separated list of ACL names only refers to existing ACLs.
+func ValidateNames(s *state.State, value string) error {
+	existing, err := s.Cluster.GetNetworkACLs()
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading network ACLs")
+	}
+
+	for _, name := range ParseNames(value) {
+		if !shared.StringInSlice(name, existing) {
+			return fmt.Errorf("Network ACL %q does not exist", name)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the config and rules of the named ACL are valid.
+func Validate(s *state.State, name string, info *api.NetworkACLPut) error {
+	for k := range info.Config {
+		if !strings.HasPrefix(k, "user.") {
+			return fmt.Errorf("Invalid option %q", k)
+		}
+	}
+
+	aclNames, err := s.Cluster.GetNetworkACLs()
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading network ACLs")
+	}
+
+	// An ACL may refer to itself, even before it has been created.
+	if !shared.StringInSlice(name, aclNames) {
+		aclNames = append(aclNames, name)
+	}
+
+	for _, direction := range []struct {
+		name  string
+		rules []api.NetworkACLRule
+	}{{"ingress", info.Ingress}, {"egress", info.Egress}} {
+		for i, rule := range direction.rules {
+			err := validateRule(rule, aclNames)
+			if err != nil {
+				return errors.Wrapf(err, "Invalid %s rule %d", direction.name, i)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateRule checks a single ACL rule is valid.
+func validateRule(rule api.NetworkACLRule, aclNames []string) error {
+	if !shared.StringInSlice(rule.Action, []string{"allow", "drop", "reject"}) {
+		return fmt.Errorf("Action must be one of: allow, drop, reject")
+	}
+
+	if !shared.StringInSlice(rule.State, []string{"enabled", "disabled"}) {
+		return fmt.Errorf("State must be one of: enabled, disabled")
+	}
+
+	err := validateSubjects(rule.Source, aclNames)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid source")
+	}
+
+	err = validateSubjects(rule.Destination, aclNames)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid destination")
+	}
+
+	if !shared.StringInSlice(rule.Protocol, []string{"", "tcp", "udp", "icmp4", "icmp6"}) {
+		return fmt.Errorf("Protocol must be one of: tcp, udp, icmp4, icmp6")
+	}
+
+	if rule.SourcePort != "" || rule.DestinationPort != "" {
+		if !shared.StringInSlice(rule.Protocol, []string{"tcp", "udp"}) {
+			return fmt.Errorf("Ports can only be specified with the tcp or udp protocols")
+		}
+
+		err = validatePorts(rule.SourcePort)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid source port")
+		}
+
+		err = validatePorts(rule.DestinationPort)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid destination port")
+		}
+	}
+
+	if rule.ICMPType != "" || rule.ICMPCode != "" {
+		if !shared.StringInSlice(rule.Protocol, []string{"icmp4", "icmp6"}) {
+			return fmt.Errorf("ICMP type and code can only be specified with the icmp4 or icmp6 protocols")
+		}
+
+		if rule.ICMPType == "" {
+			return fmt.Errorf("ICMP code cannot be specified without an ICMP type")
+		}
+
+		_, err = strconv.ParseUint(rule.ICMPType, 10, 8)
+		if err != nil {
+			return fmt.Errorf("Invalid ICMP type %q", rule.ICMPType)
+		}
+
+		if rule.ICMPCode != "" {
+			_, err = strconv.ParseUint(rule.ICMPCode, 10, 8)
+			if err != nil {
+				return fmt.Errorf("Invalid ICMP code %q", rule.ICMPCode)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateSubjects checks a comma separated list of IPs, subnets and ACL names.
+func validateSubjects(value string, aclNames []string) error {
+	for _, subject := range shared.SplitNTrimSpace(value, ",", -1, true) {
+		if shared.StringInSlice(subject, aclNames) {
+			continue
+		}
+
+		if strings.Contains(subject, "/") {
+			_, _, err := net.ParseCIDR(subject)
+			if err == nil {
+				continue
+			}
+		} else if net.ParseIP(subject) != nil {
+			continue
+		}
+
+		return fmt.Errorf("Invalid subject %q, must be an IP, subnet or network ACL name", subject)
+	}
+
+	return nil
+}
+
+// validatePorts checks a comma separated list of ports and port ranges.
+func validatePorts(value string) error {
+	for _, port := range shared.SplitNTrimSpace(value, ",", -1, true) {
+		start, end, err := parsePortRange(port)
+		if err != nil || start > end {
+			return fmt.Errorf("Invalid port or port range %q", port)
+		}
+	}
+
+	return nil
+}
+
+// parsePortRange parses a port or port range in the form "start-end".
+func parsePortRange(value string) (uint64, uint64, error) {
+	parts := strings.SplitN(value, "-", 2)
+
+	start, err := strconv.ParseUint(parts[0], 10, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if len(parts) == 1 {
+		return start, start, nil
+	}
+
+	end, err := strconv.ParseUint(parts[1], 10, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return start, end, nil
+}
+
+// hasACLSubjects returns whether a comma separated list of subjects contains any ACL names.
+func hasACLSubjects(value string) bool {
+	for _, subject := range shared.SplitNTrimSpace(value, ",", -1, true) {
+		if isACLSubject(subject) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isACLSubject returns whether a subject refers to an ACL rather than an IP or subnet.
+func isACLSubject(subject string) bool {
+	return nameRegex.MatchString(subject)
+}
+
+// UsedBy returns the URLs of the networks, instances, profiles and other ACLs that use the named ACL.
+func UsedBy(s *state.State, aclName string) ([]string, error) {
+	usedBy := []string{}
+
+	// Look for networks.
+	projectNetworks, err := s.Cluster.GetNonPendingNetworks()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed loading networks")
+	}
+
+	for projectName, networks := range projectNetworks {
+		for _, netName := range networks {
+			_, netInfo, err := s.Cluster.GetNetworkInAnyState(projectName, netName)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed loading network %q in project %q", netName, projectName)
+			}
+
+			if !shared.StringInSlice(aclName, ParseNames(netInfo.Config["security.acls"])) {
+				continue
+			}
+
+			if projectName == project.Default {
+				usedBy = append(usedBy, fmt.Sprintf("/%s/networks/%s", version.APIVersion, netName))
+			} else {
+				usedBy = append(usedBy, fmt.Sprintf("/%s/networks/%s?project=%s", version.APIVersion, netName, projectName))
+			}
+		}
+	}
+
+	// Look at instances.
+	insts, err := instance.LoadFromAllProjects(s)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inst := range insts {
+		if !devicesUseACL(inst.ExpandedDevices().CloneNative(), aclName) {
+			continue
+		}
+
+		uri := fmt.Sprintf("/%s/instances/%s", version.APIVersion, inst.Name())
+		if inst.Project() != project.Default {
+			uri += fmt.Sprintf("?project=%s", inst.Project())
+		}
+
+		usedBy = append(usedBy, uri)
+	}
+
+	// Look for profiles.
+	var profiles []db.Profile
+	err = s.Cluster.Transaction(func(tx *db.ClusterTx) error {
+		profiles, err = tx.GetProfiles(db.ProfileFilter{})
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, profile := range profiles {
+		if !devicesUseACL(profile.Devices, aclName) {
+			continue
+		}
+
+		uri := fmt.Sprintf("/%s/profiles/%s", version.APIVersion, profile.Name)
+		if profile.Project != project.Default {
+			uri += fmt.Sprintf("?project=%s", profile.Project)
+		}
+
+		usedBy = append(usedBy, uri)
+	}
+
+	// Look for other ACLs referring to it in their rules.
+	aclNames, err := s.Cluster.GetNetworkACLs()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed loading network ACLs")
+	}
+
+	for _, name := range aclNames {
+		if name == aclName {
+			continue
+		}
+
+		_, acl, err := s.Cluster.GetNetworkACL(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed loading network ACL %q", name)
+		}
+
+		for _, rule := range append(acl.Ingress, acl.Egress...) {
+			if shared.StringInSlice(aclName, shared.SplitNTrimSpace(rule.Source, ",", -1, true)) || shared.StringInSlice(aclName, shared.SplitNTrimSpace(rule.Destination, ",", -1, true)) {
+				usedBy = append(usedBy, fmt.Sprintf("/%s/network-acls/%s", version.APIVersion, name))
+				break
+			}
+		}
+	}
+
+	return usedBy, nil
+}
+
+// devicesUseACL returns whether any of the NIC devices have the named ACL in their security.acls setting.
+func devicesUseACL(devices map[string]map[string]string, aclName string) bool {
+	for _, d := range devices {
+		if d["type"] != "nic" {
+			continue
+		}
+
+		if shared.StringInSlice(aclName, ParseNames(d["security.acls"])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ApplyChanges reapplies the rules of the named ACL wherever it is in use after it has been modified.
+// Firewall rules of bridge networks and bridged NICs are applied on the local node only. As the OVN northbound
+// database is shared by all nodes, OVN rules are only applied when the request isn't a cluster notification.
+func ApplyChanges(s *state.State, aclName string, clusterNotification bool) error {
+	projectNetworks, err := s.Cluster.GetNonPendingNetworks()
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading networks")
+	}
+
+	ovnNetworks := map[int64][]string{}
+	haveOVN := false
+
+	for projectName, networks := range projectNetworks {
+		for _, netName := range networks {
+			netID, netInfo, err := s.Cluster.GetNetworkInAnyState(projectName, netName)
+			if err != nil {
+				return errors.Wrapf(err, "Failed loading network %q in project %q", netName, projectName)
+			}
+
+			netACLNames := ParseNames(netInfo.Config["security.acls"])
+
+			switch netInfo.Type {
+			case "ovn":
+				haveOVN = true
+
+				if shared.StringInSlice(aclName, netACLNames) {
+					ovnNetworks[netID] = netACLNames
+				}
+			}
+		}
+	}
+
+	err = firewallApplyChanges(s, aclName)
+	if err != nil {
+		return err
+	}
+
+	if clusterNotification || !haveOVN {
+		return nil
+	}
+
+	client, err := ovnClient(s)
+	if err != nil {
+		return err
+	}
+
+	ensured := map[string]openvswitch.OVNPortGroup{}
+
+	for netID, netACLNames := range ovnNetworks {
+		err = ovnApplyNetworkRules(s, client, netID, netACLNames, ensured)
+		if err != nil {
+			return errors.Wrapf(err, "Failed applying network ACLs to OVN network")
+		}
+	}
+
+	// Refresh the ACL's own port group if it exists, as used by instance NICs.
+	aclID, _, err := s.Cluster.GetNetworkACL(aclName)
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading network ACL %q", aclName)
+	}
+
+	exists, err := client.PortGroupExists(OVNACLPortGroupName(aclID))
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		_, err = ovnEnsureACL(s, client, aclName, ensured)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete removes any runtime state of the ACL with the specified ID. The ACL must not be in use.
+func Delete(s *state.State, aclID int64) error {
+	projectNetworks, err := s.Cluster.GetNonPendingNetworks()
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading networks")
+	}
+
+	for projectName, networks := range projectNetworks {
+		for _, netName := range networks {
+			_, netInfo, err := s.Cluster.GetNetworkInAnyState(projectName, netName)
+			if err != nil {
+				return errors.Wrapf(err, "Failed loading network %q in project %q", netName, projectName)
+			}
+
+			if netInfo.Type != "ovn" {
+				continue
+			}
+
+			client, err := ovnClient(s)
+			if err != nil {
+				return err
+			}
+
+			return client.PortGroupDelete(OVNACLPortGroupName(aclID))
+		}
+	}
+
+	return nil
+}
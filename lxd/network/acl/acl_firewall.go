@@ -0,0 +1,374 @@
+package acl
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/device/nictype"
+	"github.com/lxc/lxd/lxd/dnsmasq"
+	firewallDrivers "github.com/lxc/lxd/lxd/firewall/drivers"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/instance/instancetype"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// FirewallRules returns the firewall rules for the specified ACLs.
+// ACL names used as rule subjects are resolved to the addresses of the bridged NICs the named ACLs apply to.
+// Rules whose source or destination resolves to no addresses are left out as they cannot match any traffic.
+func FirewallRules(s *state.State, aclNames []string) ([]firewallDrivers.ACLRule, error) {
+	rules := []firewallDrivers.ACLRule{}
+
+	// Only look up the NIC addresses if any of the rules use ACL names as subjects.
+	var subjectAddresses map[string][]string
+	resolveSubjects := func(subjects string) (string, bool, error) {
+		if !hasACLSubjects(subjects) {
+			return subjects, true, nil
+		}
+
+		if subjectAddresses == nil {
+			var err error
+			subjectAddresses, err = firewallSubjectAddresses(s)
+			if err != nil {
+				return "", false, err
+			}
+		}
+
+		resolved := []string{}
+		for _, subject := range shared.SplitNTrimSpace(subjects, ",", -1, true) {
+			if isACLSubject(subject) {
+				resolved = append(resolved, subjectAddresses[subject]...)
+			} else {
+				resolved = append(resolved, subject)
+			}
+		}
+
+		return strings.Join(resolved, ","), len(resolved) > 0, nil
+	}
+
+	for _, aclName := range aclNames {
+		_, acl, err := s.Cluster.GetNetworkACL(aclName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed loading network ACL %q", aclName)
+		}
+
+		for _, direction := range []struct {
+			name  string
+			rules []api.NetworkACLRule
+		}{{"ingress", acl.Ingress}, {"egress", acl.Egress}} {
+			for _, rule := range direction.rules {
+				if rule.State == "disabled" {
+					continue
+				}
+
+				source, ok, err := resolveSubjects(rule.Source)
+				if err != nil {
+					return nil, err
+				}
+
+				if !ok {
+					continue
+				}
+
+				destination, ok, err := resolveSubjects(rule.Destination)
+				if err != nil {
+					return nil, err
+				}
+
+				if !ok {
+					continue
+				}
+
+				rules = append(rules, firewallDrivers.ACLRule{
+					Direction:       direction.name,
+					Action:          rule.Action,
+					Source:          source,
+					Destination:     destination,
+					Protocol:        rule.Protocol,
+					SourcePort:      rule.SourcePort,
+					DestinationPort: rule.DestinationPort,
+					ICMPType:        rule.ICMPType,
+					ICMPCode:        rule.ICMPCode,
+				})
+			}
+		}
+	}
+
+	return rules, nil
+}
+
+// FirewallApplyNetworkRules applies the rules of the specified ACLs to the bridge network using the firewall.
+// Once any ACLs are applied, traffic not matching an allow rule is rejected. If no ACLs are specified then any
+// existing ACL rules are removed.
+func FirewallApplyNetworkRules(s *state.State, networkName string, aclNames []string) error {
+	if len(aclNames) == 0 {
+		return s.Firewall.NetworkClearACLRules(networkName)
+	}
+
+	rules, err := FirewallRules(s, aclNames)
+	if err != nil {
+		return err
+	}
+
+	return s.Firewall.NetworkApplyACLRules(networkName, rules)
+}
+
+// firewallSubjectAddresses returns the addresses of the bridged NICs of all instances, keyed by the names of the
+// ACLs that apply to them, either directly or through the bridge network they are connected to. Addresses come
+// from the static ipv4.address and ipv6.address settings and from the dynamic IPv4 leases of the local dnsmasq.
+func firewallSubjectAddresses(s *state.State) (map[string][]string, error) {
+	// Bridge networks only exist in the default project.
+	networkACLs := map[string][]string{}
+	projectNetworks, err := s.Cluster.GetNonPendingNetworks()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed loading networks")
+	}
+
+	for _, netName := range projectNetworks[project.Default] {
+		_, netInfo, err := s.Cluster.GetNetworkInAnyState(project.Default, netName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed loading network %q", netName)
+		}
+
+		if netInfo.Type == "bridge" {
+			networkACLs[netName] = ParseNames(netInfo.Config["security.acls"])
+		}
+	}
+
+	// Dynamic IPv4 leases of each bridge network, keyed by MAC address.
+	networkLeases := map[string]map[string][]string{}
+	leases := func(netName string) (map[string][]string, error) {
+		if networkLeases[netName] == nil {
+			networkLeases[netName] = map[string][]string{}
+
+			ipv4Allocations, _, err := dnsmasq.DHCPAllAllocations(netName)
+			if err != nil && !os.IsNotExist(err) {
+				return nil, errors.Wrapf(err, "Failed loading DHCP leases of network %q", netName)
+			}
+
+			for _, allocation := range ipv4Allocations {
+				if !allocation.Static && allocation.MAC != nil {
+					mac := allocation.MAC.String()
+					networkLeases[netName][mac] = append(networkLeases[netName][mac], allocation.IP.String())
+				}
+			}
+		}
+
+		return networkLeases[netName], nil
+	}
+
+	insts, err := instance.LoadFromAllProjects(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed loading instances")
+	}
+
+	addresses := map[string][]string{}
+	for _, inst := range insts {
+		for devName, dev := range inst.ExpandedDevices() {
+			if dev["type"] != "nic" {
+				continue
+			}
+
+			nicType, err := nictype.NICType(s, inst.Project(), dev)
+			if err != nil || nicType != "bridged" {
+				continue
+			}
+
+			parent := dev["parent"]
+			if dev["network"] != "" {
+				parent = dev["network"]
+			}
+
+			nicACLNames := append(ParseNames(dev["security.acls"]), networkACLs[parent]...)
+			if len(nicACLNames) == 0 {
+				continue
+			}
+
+			nicAddresses := []string{}
+			for _, address := range []string{dev["ipv4.address"], dev["ipv6.address"]} {
+				if net.ParseIP(address) != nil {
+					nicAddresses = append(nicAddresses, address)
+				}
+			}
+
+			hwaddr := dev["hwaddr"]
+			if hwaddr == "" {
+				hwaddr = inst.LocalConfig()[fmt.Sprintf("volatile.%s.hwaddr", devName)]
+			}
+
+			_, managed := networkACLs[parent]
+			if managed && hwaddr != "" && dev["ipv4.address"] == "" {
+				netLeases, err := leases(parent)
+				if err != nil {
+					return nil, err
+				}
+
+				nicAddresses = append(nicAddresses, netLeases[strings.ToLower(hwaddr)]...)
+			}
+
+			for _, aclName := range nicACLNames {
+				for _, address := range nicAddresses {
+					if !shared.StringInSlice(address, addresses[aclName]) {
+						addresses[aclName] = append(addresses[aclName], address)
+					}
+				}
+			}
+		}
+	}
+
+	return addresses, nil
+}
+
+// FirewallApplySubjectChanges reapplies the firewall rules of the ACLs that use any of the named ACLs as rule
+// subjects, after the bridged NICs the named ACLs apply to or their addresses have changed. The ACLs of the
+// bridge network a NIC is connected to also apply to it, so they are included when its parent is specified.
+func FirewallApplySubjectChanges(s *state.State, parent string, aclNames []string) error {
+	if parent != "" {
+		_, netInfo, err := s.Cluster.GetNetworkInAnyState(project.Default, parent)
+		if err != nil && err != db.ErrNoSuchObject {
+			return errors.Wrapf(err, "Failed loading network %q", parent)
+		}
+
+		if err == nil && netInfo.Type == "bridge" {
+			aclNames = append(aclNames, ParseNames(netInfo.Config["security.acls"])...)
+		}
+	}
+
+	if len(aclNames) == 0 {
+		return nil
+	}
+
+	allACLNames, err := s.Cluster.GetNetworkACLs()
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading network ACLs")
+	}
+
+	for _, name := range allACLNames {
+		_, acl, err := s.Cluster.GetNetworkACL(name)
+		if err != nil {
+			return errors.Wrapf(err, "Failed loading network ACL %q", name)
+		}
+
+		usesSubjects := false
+		for _, rule := range append(acl.Ingress, acl.Egress...) {
+			for _, subject := range append(shared.SplitNTrimSpace(rule.Source, ",", -1, true), shared.SplitNTrimSpace(rule.Destination, ",", -1, true)...) {
+				if shared.StringInSlice(subject, aclNames) {
+					usesSubjects = true
+				}
+			}
+		}
+
+		if !usesSubjects {
+			continue
+		}
+
+		err = firewallApplyChanges(s, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// firewallApplyChanges reapplies the rules of the named ACL to the bridge networks and the bridged NICs of the
+// running instances on the local node that use it.
+func firewallApplyChanges(s *state.State, aclName string) error {
+	projectNetworks, err := s.Cluster.GetNonPendingNetworks()
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading networks")
+	}
+
+	for _, netName := range projectNetworks[project.Default] {
+		_, netInfo, err := s.Cluster.GetNetworkInAnyState(project.Default, netName)
+		if err != nil {
+			return errors.Wrapf(err, "Failed loading network %q", netName)
+		}
+
+		netACLNames := ParseNames(netInfo.Config["security.acls"])
+		if netInfo.Type != "bridge" || !shared.StringInSlice(aclName, netACLNames) {
+			continue
+		}
+
+		// Skip bridges that aren't running on this node.
+		if !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", netName)) {
+			continue
+		}
+
+		err = FirewallApplyNetworkRules(s, netName, netACLNames)
+		if err != nil {
+			return errors.Wrapf(err, "Failed applying network ACLs to network %q", netName)
+		}
+	}
+
+	return firewallApplyInstanceChanges(s, aclName)
+}
+
+// bridgedDevicesUseACL returns the names of the bridged NIC devices of the instance that have the named ACL in
+// their security.acls setting.
+func bridgedDevicesUseACL(s *state.State, inst instance.Instance, aclName string) ([]string, error) {
+	devNames := []string{}
+
+	for devName, dev := range inst.ExpandedDevices() {
+		if dev["type"] != "nic" || !shared.StringInSlice(aclName, ParseNames(dev["security.acls"])) {
+			continue
+		}
+
+		nicType, err := nictype.NICType(s, inst.Project(), dev)
+		if err != nil {
+			return nil, err
+		}
+
+		if nicType == "bridged" {
+			devNames = append(devNames, devName)
+		}
+	}
+
+	return devNames, nil
+}
+
+// firewallApplyInstanceChanges reapplies the rules of the named ACL to the bridged NICs of the instances running
+// on the local node that use it.
+func firewallApplyInstanceChanges(s *state.State, aclName string) error {
+	insts, err := instance.LoadNodeAll(s, instancetype.Any)
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading instances")
+	}
+
+	for _, inst := range insts {
+		if !inst.IsRunning() {
+			continue
+		}
+
+		devNames, err := bridgedDevicesUseACL(s, inst, aclName)
+		if err != nil {
+			return err
+		}
+
+		for _, devName := range devNames {
+			hostName := inst.LocalConfig()[fmt.Sprintf("volatile.%s.host_name", devName)]
+			if hostName == "" {
+				continue
+			}
+
+			rules, err := FirewallRules(s, ParseNames(inst.ExpandedDevices()[devName]["security.acls"]))
+			if err != nil {
+				return err
+			}
+
+			err = s.Firewall.InstanceSetupACLRules(inst.Project(), inst.Name(), devName, hostName, rules)
+			if err != nil {
+				return errors.Wrapf(err, "Failed applying network ACLs to device %q of instance %q in project %q", devName, inst.Name(), inst.Project())
+			}
+		}
+	}
+
+	return nil
+}
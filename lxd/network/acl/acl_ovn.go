@@ -0,0 +1,328 @@
+package acl
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/network/openvswitch"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+)
+
+// OVN ACL rule priorities. Drop and reject rules take precedence over allow rules, and the baseline rules take
+// precedence over all others so that ACLs cannot break basic network operation.
+const (
+	ovnACLPriorityDefault  = 100
+	ovnACLPriorityAllow    = 200
+	ovnACLPriorityDrop     = 300
+	ovnACLPriorityBaseline = 400
+)
+
+// ovnClient initialises the OVN client.
+func ovnClient(s *state.State) (*openvswitch.OVN, error) {
+	nbConnection, err := cluster.ConfigGetString(s.Cluster, "network.ovn.northbound_connection")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get OVN northbound connection string")
+	}
+
+	client := openvswitch.NewOVN()
+	client.SetDatabaseAddress(nbConnection)
+
+	return client, nil
+}
+
+// OVNACLPortGroupName returns the port group name for a network ACL ID.
+func OVNACLPortGroupName(aclID int64) openvswitch.OVNPortGroup {
+	return openvswitch.OVNPortGroup(fmt.Sprintf("lxd_acl%d", aclID))
+}
+
+// OVNNetworkPortGroupName returns the port group name containing all instance ports of an OVN network ID.
+func OVNNetworkPortGroupName(networkID int64) openvswitch.OVNPortGroup {
+	return openvswitch.OVNPortGroup(fmt.Sprintf("lxd_net%d", networkID))
+}
+
+// OVNEnsureACLs ensures the port groups of the specified ACLs (and of any ACLs their rules refer to) exist and
+// contain the current rules. Returns the port group names of the specified ACLs.
+func OVNEnsureACLs(s *state.State, client *openvswitch.OVN, aclNames []string) ([]openvswitch.OVNPortGroup, error) {
+	ensured := map[string]openvswitch.OVNPortGroup{}
+	portGroups := make([]openvswitch.OVNPortGroup, 0, len(aclNames))
+
+	for _, aclName := range aclNames {
+		portGroup, err := ovnEnsureACL(s, client, aclName, ensured)
+		if err != nil {
+			return nil, err
+		}
+
+		portGroups = append(portGroups, portGroup)
+	}
+
+	return portGroups, nil
+}
+
+// OVNApplyNetworkRules sets the rules of the network's port group to those of the specified ACLs.
+// The port group is created if needed. If no ACLs are specified then the port group has no rules.
+func OVNApplyNetworkRules(s *state.State, client *openvswitch.OVN, networkID int64, aclNames []string) error {
+	return ovnApplyNetworkRules(s, client, networkID, aclNames, map[string]openvswitch.OVNPortGroup{})
+}
+
+// ovnApplyNetworkRules sets the rules of the network's port group, skipping already ensured referenced ACLs.
+func ovnApplyNetworkRules(s *state.State, client *openvswitch.OVN, networkID int64, aclNames []string, ensured map[string]openvswitch.OVNPortGroup) error {
+	portGroup := OVNNetworkPortGroupName(networkID)
+
+	err := client.PortGroupAdd(portGroup, true)
+	if err != nil {
+		return errors.Wrapf(err, "Failed adding port group %q", portGroup)
+	}
+
+	acls := make([]*api.NetworkACL, 0, len(aclNames))
+	for _, aclName := range aclNames {
+		_, acl, err := s.Cluster.GetNetworkACL(aclName)
+		if err != nil {
+			return errors.Wrapf(err, "Failed loading network ACL %q", aclName)
+		}
+
+		acls = append(acls, acl)
+	}
+
+	rules, err := ovnPortGroupRules(s, client, portGroup, acls, ensured)
+	if err != nil {
+		return err
+	}
+
+	err = client.PortGroupSetACLRules(portGroup, rules...)
+	if err != nil {
+		return errors.Wrapf(err, "Failed applying rules to port group %q", portGroup)
+	}
+
+	return nil
+}
+
+// ovnEnsureACL ensures the ACL's port group exists and contains the current rules. ACLs referred to by its rules
+// are ensured too. The ensured map records the ACLs already handled, which also prevents loops between ACLs
+// referring to each other.
+func ovnEnsureACL(s *state.State, client *openvswitch.OVN, aclName string, ensured map[string]openvswitch.OVNPortGroup) (openvswitch.OVNPortGroup, error) {
+	portGroup, found := ensured[aclName]
+	if found {
+		return portGroup, nil
+	}
+
+	aclID, acl, err := s.Cluster.GetNetworkACL(aclName)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed loading network ACL %q", aclName)
+	}
+
+	portGroup = OVNACLPortGroupName(aclID)
+	ensured[aclName] = portGroup
+
+	err = client.PortGroupAdd(portGroup, true)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed adding port group %q", portGroup)
+	}
+
+	rules, err := ovnPortGroupRules(s, client, portGroup, []*api.NetworkACL{acl}, ensured)
+	if err != nil {
+		return "", err
+	}
+
+	err = client.PortGroupSetACLRules(portGroup, rules...)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed applying rules to port group %q", portGroup)
+	}
+
+	return portGroup, nil
+}
+
+// ovnPortGroupRules returns the OVN rules needed to apply the ACLs to the members of the port group.
+// As well as the ACLs' own rules this includes baseline rules allowing ARP, neighbour discovery and DHCP, and
+// default rules rejecting all other traffic. If no ACLs are specified then no rules are returned.
+func ovnPortGroupRules(s *state.State, client *openvswitch.OVN, portGroup openvswitch.OVNPortGroup, acls []*api.NetworkACL, ensured map[string]openvswitch.OVNPortGroup) ([]openvswitch.OVNACLRule, error) {
+	if len(acls) == 0 {
+		return nil, nil
+	}
+
+	portMatch := map[string]string{
+		"egress":  fmt.Sprintf("inport == @%s", portGroup),
+		"ingress": fmt.Sprintf("outport == @%s", portGroup),
+	}
+
+	portDirection := map[string]string{
+		"egress":  "from-lport",
+		"ingress": "to-lport",
+	}
+
+	rules := []openvswitch.OVNACLRule{
+		{
+			Direction: portDirection["egress"],
+			Action:    "allow",
+			Priority:  ovnACLPriorityBaseline,
+			Match:     fmt.Sprintf("%s && (arp || nd || nd_rs || (udp && (udp.dst == 67 || udp.dst == 547)))", portMatch["egress"]),
+		},
+		{
+			Direction: portDirection["ingress"],
+			Action:    "allow",
+			Priority:  ovnACLPriorityBaseline,
+			Match:     fmt.Sprintf("%s && (arp || nd || nd_ra || (udp && (udp.src == 67 || udp.src == 547)))", portMatch["ingress"]),
+		},
+		{
+			Direction: portDirection["egress"],
+			Action:    "reject",
+			Priority:  ovnACLPriorityDefault,
+			Match:     portMatch["egress"],
+		},
+		{
+			Direction: portDirection["ingress"],
+			Action:    "reject",
+			Priority:  ovnACLPriorityDefault,
+			Match:     portMatch["ingress"],
+		},
+	}
+
+	for _, acl := range acls {
+		for _, direction := range []struct {
+			name  string
+			rules []api.NetworkACLRule
+		}{{"ingress", acl.Ingress}, {"egress", acl.Egress}} {
+			for _, rule := range direction.rules {
+				if rule.State == "disabled" {
+					continue
+				}
+
+				match, err := ovnRuleMatch(s, client, portMatch[direction.name], rule, ensured)
+				if err != nil {
+					return nil, errors.Wrapf(err, "Failed converting rule of network ACL %q", acl.Name)
+				}
+
+				ovnRule := openvswitch.OVNACLRule{
+					Direction: portDirection[direction.name],
+					Action:    rule.Action,
+					Priority:  ovnACLPriorityDrop,
+					Match:     match,
+				}
+
+				if rule.Action == "allow" {
+					ovnRule.Action = "allow-related"
+					ovnRule.Priority = ovnACLPriorityAllow
+				}
+
+				rules = append(rules, ovnRule)
+			}
+		}
+	}
+
+	return rules, nil
+}
+
+// ovnRuleMatch returns the OVN match criteria for an ACL rule applied to the ports matched by portMatch.
+func ovnRuleMatch(s *state.State, client *openvswitch.OVN, portMatch string, rule api.NetworkACLRule, ensured map[string]openvswitch.OVNPortGroup) (string, error) {
+	parts := []string{portMatch}
+
+	for _, subject := range []struct {
+		field  string
+		values string
+	}{{"src", rule.Source}, {"dst", rule.Destination}} {
+		if subject.values == "" {
+			continue
+		}
+
+		match, err := ovnSubjectMatch(s, client, subject.field, subject.values, ensured)
+		if err != nil {
+			return "", err
+		}
+
+		parts = append(parts, match)
+	}
+
+	switch rule.Protocol {
+	case "tcp", "udp":
+		parts = append(parts, rule.Protocol)
+
+		if rule.SourcePort != "" {
+			parts = append(parts, ovnPortMatch(fmt.Sprintf("%s.src", rule.Protocol), rule.SourcePort))
+		}
+
+		if rule.DestinationPort != "" {
+			parts = append(parts, ovnPortMatch(fmt.Sprintf("%s.dst", rule.Protocol), rule.DestinationPort))
+		}
+	case "icmp4", "icmp6":
+		parts = append(parts, rule.Protocol)
+
+		if rule.ICMPType != "" {
+			parts = append(parts, fmt.Sprintf("%s.type == %s", rule.Protocol, rule.ICMPType))
+		}
+
+		if rule.ICMPCode != "" {
+			parts = append(parts, fmt.Sprintf("%s.code == %s", rule.Protocol, rule.ICMPCode))
+		}
+	}
+
+	return strings.Join(parts, " && "), nil
+}
+
+// ovnSubjectMatch returns the OVN match criteria for a comma separated list of IPs, subnets and ACL names.
+// ACL names are matched using the address sets OVN maintains for the ACL's port group, so the port groups of the
+// referenced ACLs are ensured.
+func ovnSubjectMatch(s *state.State, client *openvswitch.OVN, field string, subjects string, ensured map[string]openvswitch.OVNPortGroup) (string, error) {
+	var ipv4, ipv6, matches []string
+
+	for _, subject := range shared.SplitNTrimSpace(subjects, ",", -1, true) {
+		if isACLSubject(subject) {
+			portGroup, err := ovnEnsureACL(s, client, subject, ensured)
+			if err != nil {
+				return "", err
+			}
+
+			matches = append(matches,
+				fmt.Sprintf("ip4.%s == $%s_ip4", field, portGroup),
+				fmt.Sprintf("ip6.%s == $%s_ip6", field, portGroup),
+			)
+
+			continue
+		}
+
+		ip := net.ParseIP(strings.SplitN(subject, "/", 2)[0])
+		if ip != nil && ip.To4() != nil {
+			ipv4 = append(ipv4, subject)
+		} else {
+			ipv6 = append(ipv6, subject)
+		}
+	}
+
+	if len(ipv4) > 0 {
+		matches = append(matches, fmt.Sprintf("ip4.%s == {%s}", field, strings.Join(ipv4, ", ")))
+	}
+
+	if len(ipv6) > 0 {
+		matches = append(matches, fmt.Sprintf("ip6.%s == {%s}", field, strings.Join(ipv6, ", ")))
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(matches, " || ")), nil
+}
+
+// ovnPortMatch returns the OVN match criteria for a comma separated list of ports and port ranges.
+func ovnPortMatch(field string, ports string) string {
+	var single, matches []string
+
+	for _, port := range shared.SplitNTrimSpace(ports, ",", -1, true) {
+		start, end, err := parsePortRange(port)
+		if err != nil {
+			continue
+		}
+
+		if start == end {
+			single = append(single, fmt.Sprintf("%d", start))
+		} else {
+			matches = append(matches, fmt.Sprintf("(%s >= %d && %s <= %d)", field, start, field, end))
+		}
+	}
+
+	if len(single) > 0 {
+		matches = append([]string{fmt.Sprintf("%s == {%s}", field, strings.Join(single, ", "))}, matches...)
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(matches, " || "))
+}
@@ -18,13 +18,19 @@ import (
 
 	"github.com/pkg/errors"
 
+	lxd "github.com/lxc/lxd/client"
 	"github.com/lxc/lxd/lxd/apparmor"
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/daemon"
+	"github.com/lxc/lxd/lxd/device/nictype"
 	"github.com/lxc/lxd/lxd/dnsmasq"
 	"github.com/lxc/lxd/lxd/dnsmasq/dhcpalloc"
+	firewallDrivers "github.com/lxc/lxd/lxd/firewall/drivers"
+	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/network/acl"
 	"github.com/lxc/lxd/lxd/network/openvswitch"
 	"github.com/lxc/lxd/lxd/node"
+	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/revert"
 	"github.com/lxc/lxd/lxd/util"
 	"github.com/lxc/lxd/shared"
@@ -223,11 +229,18 @@ func (n *bridge) Validate(config map[string]string) error {
 		"dns.mode": func(value string) error {
 			return validate.IsOneOf(value, []string{"dynamic", "managed", "none"})
 		},
+		"dns.zone.forward":      validate.Optional(n.validateZone),
+		"dns.zone.reverse.ipv4": validate.Optional(n.validateZone),
+		"dns.zone.reverse.ipv6": validate.Optional(n.validateZone),
 
 		"raw.dnsmasq": validate.IsAny,
 
 		"maas.subnet.ipv4": validate.IsAny,
 		"maas.subnet.ipv6": validate.IsAny,
+
+		"security.acls": func(value string) error {
+			return acl.ValidateNames(n.state, value)
+		},
 	}
 
 	// Add dynamic validation rules.
@@ -1273,6 +1286,29 @@ func (n *bridge) setup(oldConfig map[string]string) error {
 		}
 	}
 
+	// Apply network ACLs (or remove them if no longer set).
+	if n.config["security.acls"] != "" || oldConfig["security.acls"] != "" {
+		err = acl.FirewallApplyNetworkRules(n.state, n.name, acl.ParseNames(n.config["security.acls"]))
+		if err != nil {
+			return errors.Wrapf(err, "Failed applying network ACLs")
+		}
+	}
+
+	// The network's ACLs apply to its NICs, so update the rules using the old and new ACLs as subjects.
+	if oldConfig != nil && n.config["security.acls"] != oldConfig["security.acls"] {
+		aclNames := append(acl.ParseNames(n.config["security.acls"]), acl.ParseNames(oldConfig["security.acls"])...)
+		err = acl.FirewallApplySubjectChanges(n.state, "", aclNames)
+		if err != nil {
+			return errors.Wrapf(err, "Failed applying network ACLs")
+		}
+	}
+
+	// Apply network address forwards.
+	err = n.forwardSetupFirewall()
+	if err != nil {
+		return err
+	}
+
 	// Generate and load apparmor profiles.
 	err = apparmor.NetworkLoad(n.state, n)
 	if err != nil {
@@ -1453,8 +1489,20 @@ func (n *bridge) Stop() error {
 		}
 	}
 
+	if n.config["security.acls"] != "" {
+		err := n.state.Firewall.NetworkClearACLRules(n.name)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := n.state.Firewall.NetworkClearForwards(n.name)
+	if err != nil {
+		return err
+	}
+
 	// Kill any existing dnsmasq and forkdns daemon for this network
-	err := dnsmasq.Kill(n.name, false)
+	err = dnsmasq.Kill(n.name, false)
 	if err != nil {
 		return err
 	}
@@ -1933,3 +1981,509 @@ func (n *bridge) DHCPv6Subnet() *net.IPNet {
 
 	return subnet
 }
+
+// ForwardCreate creates a network address forward.
+func (n *bridge) ForwardCreate(forward api.NetworkForwardsPost, clusterNotification bool) error {
+	if !clusterNotification {
+		_, err := n.forwardValidate(net.ParseIP(forward.ListenAddress), &forward.NetworkForwardPut)
+		if err != nil {
+			return err
+		}
+
+		revert := revert.New()
+		defer revert.Fail()
+
+		forwardID, err := n.state.Cluster.CreateNetworkForward(n.id, &forward)
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() {
+			n.state.Cluster.DeleteNetworkForward(forwardID)
+			n.forwardSetupFirewall()
+		})
+
+		err = n.forwardSetupFirewall()
+		if err != nil {
+			return err
+		}
+
+		// Notify all other nodes to apply the forward to their local bridge.
+		notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), cluster.NotifyAll)
+		if err != nil {
+			return err
+		}
+
+		err = notifier(func(client lxd.InstanceServer) error {
+			return client.CreateNetworkForward(n.name, forward)
+		})
+		if err != nil {
+			return err
+		}
+
+		revert.Success()
+		return nil
+	}
+
+	return n.forwardSetupFirewall()
+}
+
+// ForwardUpdate updates a network address forward.
+func (n *bridge) ForwardUpdate(listenAddress string, newForward api.NetworkForwardPut, clusterNotification bool) error {
+	if !clusterNotification {
+		forwardID, curForward, err := n.state.Cluster.GetNetworkForward(n.id, listenAddress)
+		if err != nil {
+			return err
+		}
+
+		_, err = n.forwardValidate(net.ParseIP(curForward.ListenAddress), &newForward)
+		if err != nil {
+			return err
+		}
+
+		revert := revert.New()
+		defer revert.Fail()
+
+		err = n.state.Cluster.UpdateNetworkForward(forwardID, &newForward)
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() {
+			oldForward := curForward.Writable()
+			n.state.Cluster.UpdateNetworkForward(forwardID, &oldForward)
+			n.forwardSetupFirewall()
+		})
+
+		err = n.forwardSetupFirewall()
+		if err != nil {
+			return err
+		}
+
+		// Notify all other nodes to apply the change to their local bridge.
+		notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), cluster.NotifyAll)
+		if err != nil {
+			return err
+		}
+
+		err = notifier(func(client lxd.InstanceServer) error {
+			return client.UpdateNetworkForward(n.name, curForward.ListenAddress, newForward, "")
+		})
+		if err != nil {
+			return err
+		}
+
+		revert.Success()
+		return nil
+	}
+
+	return n.forwardSetupFirewall()
+}
+
+// ForwardDelete deletes a network address forward.
+func (n *bridge) ForwardDelete(listenAddress string, clusterNotification bool) error {
+	if !clusterNotification {
+		forwardID, forward, err := n.state.Cluster.GetNetworkForward(n.id, listenAddress)
+		if err != nil {
+			return err
+		}
+
+		// Notify all other nodes to remove the forward from their local bridge once it's been deleted.
+		notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), cluster.NotifyAll)
+		if err != nil {
+			return err
+		}
+
+		err = n.state.Cluster.DeleteNetworkForward(forwardID)
+		if err != nil {
+			return err
+		}
+
+		err = n.forwardSetupFirewall()
+		if err != nil {
+			return err
+		}
+
+		err = notifier(func(client lxd.InstanceServer) error {
+			return client.DeleteNetworkForward(n.name, forward.ListenAddress)
+		})
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	return n.forwardSetupFirewall()
+}
+
+// forwardSetupFirewall applies all of the network's address forwards to the firewall.
+func (n *bridge) forwardSetupFirewall() error {
+	if !n.isRunning() {
+		return nil
+	}
+
+	forwards, err := n.state.Cluster.GetNetworkForwards(n.id)
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading network forwards")
+	}
+
+	if len(forwards) == 0 {
+		return n.state.Firewall.NetworkClearForwards(n.name)
+	}
+
+	fwForwards := []firewallDrivers.AddressForward{}
+	for _, forward := range forwards {
+		listenAddress := net.ParseIP(forward.ListenAddress)
+
+		portMaps, err := n.forwardValidate(listenAddress, &forward.NetworkForwardPut)
+		if err != nil {
+			return errors.Wrapf(err, "Failed validating network forward %q", forward.ListenAddress)
+		}
+
+		for _, portMap := range portMaps {
+			fwForwards = append(fwForwards, firewallDrivers.AddressForward{
+				ListenAddress: listenAddress,
+				Protocol:      portMap.protocol,
+				ListenPorts:   portMap.listenPorts,
+				TargetAddress: portMap.targetAddress,
+				TargetPorts:   portMap.targetPorts,
+			})
+		}
+
+		if forward.Config["target_address"] != "" {
+			fwForwards = append(fwForwards, firewallDrivers.AddressForward{
+				ListenAddress: listenAddress,
+				TargetAddress: net.ParseIP(forward.Config["target_address"]),
+			})
+		}
+	}
+
+	err = n.state.Firewall.NetworkApplyForwards(n.name, fwForwards)
+	if err != nil {
+		return errors.Wrapf(err, "Failed applying network forwards")
+	}
+
+	return nil
+}
+
+// leaseReservationValidate checks the DHCP reservation is valid for the network and doesn't conflict with
+// existing reservations or static allocations.
+func (n *bridge) leaseReservationValidate(reservation *api.NetworkLeasesPost) error {
+	mac, err := net.ParseMAC(reservation.Hwaddr)
+	if err != nil {
+		return fmt.Errorf("Invalid MAC address %q", reservation.Hwaddr)
+	}
+
+	if reservation.Hostname != "" {
+		err = shared.ValidHostname(reservation.Hostname)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid hostname %q", reservation.Hostname)
+		}
+	}
+
+	if reservation.IPv4Address == "" && reservation.IPv6Address == "" {
+		return fmt.Errorf("At least one of %q or %q must be specified", "ipv4_address", "ipv6_address")
+	}
+
+	var ipv4, ipv6 net.IP
+
+	if reservation.IPv4Address != "" {
+		// Check that DHCPv4 is enabled on the network (needed to use reserved IPs).
+		subnet := n.DHCPv4Subnet()
+		if subnet == nil {
+			return fmt.Errorf("Cannot reserve an IPv4 address when DHCP is disabled on network %q", n.name)
+		}
+
+		ipv4 = net.ParseIP(reservation.IPv4Address)
+		if ipv4 == nil || ipv4.To4() == nil {
+			return fmt.Errorf("Invalid IPv4 address %q", reservation.IPv4Address)
+		}
+
+		// The reserved IP should be part of the network's subnet, but not necessarily part of the dynamic
+		// allocation ranges.
+		routerIP, _, _ := net.ParseCIDR(n.config["ipv4.address"])
+		if !dhcpalloc.DHCPValidIP(subnet, nil, ipv4) || ipv4.Equal(routerIP) {
+			return fmt.Errorf("IPv4 address %q not within network %q subnet", reservation.IPv4Address, n.name)
+		}
+	}
+
+	if reservation.IPv6Address != "" {
+		// Check that stateful DHCPv6 is enabled on the network (needed to use reserved IPs).
+		subnet := n.DHCPv6Subnet()
+		if subnet == nil || !shared.IsTrue(n.config["ipv6.dhcp.stateful"]) {
+			return fmt.Errorf("Cannot reserve an IPv6 address when DHCP or %q are disabled on network %q", "ipv6.dhcp.stateful", n.name)
+		}
+
+		ipv6 = net.ParseIP(reservation.IPv6Address)
+		if ipv6 == nil || ipv6.To4() != nil {
+			return fmt.Errorf("Invalid IPv6 address %q", reservation.IPv6Address)
+		}
+
+		routerIP, _, _ := net.ParseCIDR(n.config["ipv6.address"])
+		if !dhcpalloc.DHCPValidIP(subnet, nil, ipv6) || ipv6.Equal(routerIP) {
+			return fmt.Errorf("IPv6 address %q not within network %q subnet", reservation.IPv6Address, n.name)
+		}
+	}
+
+	// Check the addresses aren't reserved for another MAC address.
+	reservations, err := n.state.Cluster.GetNetworkLeaseReservations(n.id)
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading DHCP reservations")
+	}
+
+	for _, other := range reservations {
+		if other.Hwaddr == mac.String() {
+			continue
+		}
+
+		if (ipv4 != nil && ipv4.Equal(net.ParseIP(other.IPv4Address))) || (ipv6 != nil && ipv6.Equal(net.ParseIP(other.IPv6Address))) {
+			return fmt.Errorf("Address already reserved for %q", other.Hwaddr)
+		}
+	}
+
+	// Check the MAC and addresses aren't statically allocated to a local instance.
+	files, err := ioutil.ReadDir(shared.VarPath("networks", n.name, "dnsmasq.hosts"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, entry := range files {
+		// Skip reservation entries, as those are named after their MAC address.
+		if strings.Contains(entry.Name(), ":") {
+			continue
+		}
+
+		projectName, instanceName := project.InstanceParts(entry.Name())
+		allocMAC, allocIPv4, allocIPv6, err := dnsmasq.DHCPStaticAllocation(n.name, projectName, instanceName)
+		if err != nil {
+			return err
+		}
+
+		if allocMAC.String() == mac.String() {
+			return fmt.Errorf("MAC address %q already used by instance %q in project %q", mac.String(), instanceName, projectName)
+		}
+
+		if (ipv4 != nil && ipv4.Equal(allocIPv4.IP)) || (ipv6 != nil && ipv6.Equal(allocIPv6.IP)) {
+			return fmt.Errorf("Address already allocated to instance %q in project %q", instanceName, projectName)
+		}
+	}
+
+	reservation.Hwaddr = mac.String()
+
+	return nil
+}
+
+// LeaseReservationCreate creates a DHCP reservation and writes it into the dnsmasq host files.
+func (n *bridge) LeaseReservationCreate(reservation api.NetworkLeasesPost, clusterNotification bool) error {
+	if !clusterNotification {
+		err := n.leaseReservationValidate(&reservation)
+		if err != nil {
+			return err
+		}
+
+		revert := revert.New()
+		defer revert.Fail()
+
+		reservationID, err := n.state.Cluster.CreateNetworkLeaseReservation(n.id, &reservation)
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() {
+			n.state.Cluster.DeleteNetworkLeaseReservation(reservationID)
+			UpdateDNSMasqStatic(n.state, n.name)
+		})
+
+		err = UpdateDNSMasqStatic(n.state, n.name)
+		if err != nil {
+			return err
+		}
+
+		// Notify all other nodes to apply the reservation to their local dnsmasq.
+		notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), cluster.NotifyAll)
+		if err != nil {
+			return err
+		}
+
+		err = notifier(func(client lxd.InstanceServer) error {
+			return client.CreateNetworkLease(n.name, reservation)
+		})
+		if err != nil {
+			return err
+		}
+
+		revert.Success()
+		return nil
+	}
+
+	return UpdateDNSMasqStatic(n.state, n.name)
+}
+
+// LeaseReservationDelete deletes a DHCP reservation and removes it from the dnsmasq host files.
+func (n *bridge) LeaseReservationDelete(hwaddr string, clusterNotification bool) error {
+	if !clusterNotification {
+		reservationID, reservation, err := n.state.Cluster.GetNetworkLeaseReservation(n.id, hwaddr)
+		if err != nil {
+			return err
+		}
+
+		// Notify all other nodes to remove the reservation from their local dnsmasq once it's been deleted.
+		notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), cluster.NotifyAll)
+		if err != nil {
+			return err
+		}
+
+		err = n.state.Cluster.DeleteNetworkLeaseReservation(reservationID)
+		if err != nil {
+			return err
+		}
+
+		err = UpdateDNSMasqStatic(n.state, n.name)
+		if err != nil {
+			return err
+		}
+
+		err = notifier(func(client lxd.InstanceServer) error {
+			return client.DeleteNetworkLease(n.name, reservation.Hwaddr)
+		})
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	return UpdateDNSMasqStatic(n.state, n.name)
+}
+
+// ZoneAddresses returns the addresses of the instances and DHCP reservations on the network. Static addresses
+// come from the NIC config and the reservations, dynamic ones from the DHCP leases of all cluster members.
+func (n *bridge) ZoneAddresses() ([]ZoneAddress, error) {
+	addresses := []ZoneAddress{}
+
+	// Hostname and project of the known MAC addresses.
+	knownMACs := map[string]ZoneAddress{}
+
+	insts, err := instance.LoadFromAllProjects(n.state)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, inst := range insts {
+		for devName, dev := range inst.ExpandedDevices() {
+			if dev["type"] != "nic" {
+				continue
+			}
+
+			nicType, err := nictype.NICType(n.state, inst.Project(), dev)
+			if err != nil || nicType != "bridged" {
+				continue
+			}
+
+			parent := dev["parent"]
+			if dev["network"] != "" {
+				parent = dev["network"]
+			}
+
+			if parent != n.name {
+				continue
+			}
+
+			hwaddr := dev["hwaddr"]
+			if hwaddr == "" {
+				hwaddr = inst.LocalConfig()[fmt.Sprintf("volatile.%s.hwaddr", devName)]
+			}
+
+			if hwaddr != "" {
+				knownMACs[strings.ToLower(hwaddr)] = ZoneAddress{Hostname: inst.Name(), Project: inst.Project()}
+			}
+
+			for _, address := range []string{dev["ipv4.address"], dev["ipv6.address"]} {
+				if address != "" {
+					addresses = append(addresses, ZoneAddress{Hostname: inst.Name(), Project: inst.Project(), Address: address})
+				}
+			}
+		}
+	}
+
+	reservations, err := n.state.Cluster.GetNetworkLeaseReservations(n.id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, reservation := range reservations {
+		knownMACs[strings.ToLower(reservation.Hwaddr)] = ZoneAddress{Hostname: reservation.Hostname, Project: n.project}
+
+		for _, address := range []string{reservation.IPv4Address, reservation.IPv6Address} {
+			if address != "" {
+				addresses = append(addresses, ZoneAddress{Hostname: reservation.Hostname, Project: n.project, Address: address})
+			}
+		}
+	}
+
+	// Get the dynamic leases of all cluster members.
+	leases := []api.NetworkLease{}
+	leaseFile := shared.VarPath("networks", n.name, "dnsmasq.leases")
+	if shared.PathExists(leaseFile) {
+		content, err := ioutil.ReadFile(leaseFile)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, lease := range strings.Split(string(content), "\n") {
+			fields := strings.Fields(lease)
+			if len(fields) < 5 {
+				continue
+			}
+
+			macStr := strings.Join(GetMACSlice(fields[1]), ":")
+			if len(macStr) < 17 && fields[4] != "" {
+				macStr = fields[4][len(fields[4])-17:]
+			}
+
+			leases = append(leases, api.NetworkLease{Address: fields[2], Hwaddr: macStr})
+		}
+	}
+
+	notifier, err := cluster.NewNotifier(n.state, n.state.Endpoints.NetworkCert(), cluster.NotifyAlive)
+	if err != nil {
+		return nil, err
+	}
+
+	// The members are queried concurrently.
+	leasesMu := sync.Mutex{}
+	err = notifier(func(client lxd.InstanceServer) error {
+		memberLeases, err := client.GetNetworkLeases(n.name)
+		if err != nil {
+			return err
+		}
+
+		leasesMu.Lock()
+		leases = append(leases, memberLeases...)
+		leasesMu.Unlock()
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the dynamic leases of known MAC addresses are used, named after their instance or reservation as the
+	// hostname in the lease is provided by the instance.
+	for _, lease := range leases {
+		if lease.Type == "static" {
+			continue
+		}
+
+		known, ok := knownMACs[strings.ToLower(lease.Hwaddr)]
+		if !ok {
+			continue
+		}
+
+		known.Address = lease.Address
+		addresses = append(addresses, known)
+	}
+
+	return addresses, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -12,6 +13,8 @@ import (
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/instance"
+	"github.com/lxc/lxd/lxd/network/zone"
+	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
@@ -26,6 +29,7 @@ type common struct {
 	logger      logger.Logger
 	state       *state.State
 	id          int64
+	project     string
 	name        string
 	netType     string
 	description string
@@ -34,9 +38,10 @@ type common struct {
 }
 
 // init initialise internal variables.
-func (n *common) init(state *state.State, id int64, name string, netType string, description string, config map[string]string, status string) {
-	n.logger = logging.AddContext(logger.Log, log.Ctx{"driver": netType, "network": name})
+func (n *common) init(state *state.State, id int64, projectName string, name string, netType string, description string, config map[string]string, status string) {
+	n.logger = logging.AddContext(logger.Log, log.Ctx{"project": projectName, "driver": netType, "network": name})
 	n.id = id
+	n.project = projectName
 	n.name = name
 	n.netType = netType
 	n.config = config
@@ -55,6 +60,11 @@ func (n *common) validationRules() map[string]func(string) error {
 	return map[string]func(string) error{}
 }
 
+// validateZone checks the named zone exists and isn't linked to another network.
+func (n *common) validateZone(zoneName string) error {
+	return zone.ValidateNetwork(n.state, zoneName, n.project, n.name)
+}
+
 // validate a network config against common rules and optional driver specific rules.
 func (n *common) validate(config map[string]string, driverRules map[string]func(value string) error) error {
 	checkedFields := map[string]struct{}{}
@@ -109,6 +119,11 @@ func (n *common) Name() string {
 	return n.name
 }
 
+// Project returns the network project.
+func (n *common) Project() string {
+	return n.project
+}
+
 // Status returns the network status.
 func (n *common) Status() string {
 	return n.status
@@ -133,7 +148,7 @@ func (n *common) IsUsed() (bool, error) {
 	}
 
 	for _, inst := range insts {
-		inUse, err := IsInUseByInstance(n.state, inst, n.name)
+		inUse, err := IsInUseByInstance(n.state, inst, n.project, n.name)
 		if err != nil {
 			return false, err
 		}
@@ -158,7 +173,7 @@ func (n *common) IsUsed() (bool, error) {
 	}
 
 	for _, profile := range profiles {
-		inUse, err := IsInUseByProfile(n.state, *db.ProfileToAPI(&profile), n.name)
+		inUse, err := IsInUseByProfile(n.state, profile.Project, *db.ProfileToAPI(&profile), n.project, n.name)
 		if err != nil {
 			return false, err
 		}
@@ -225,7 +240,10 @@ func (n *common) DHCPv6Ranges() []shared.IPRange {
 func (n *common) update(applyNetwork api.NetworkPut, targetNode string, clusterNotification bool) error {
 	// Update internal config before database has been updated (so that if update is a notification we apply
 	// the config being supplied and not that in the database).
-	n.init(n.state, n.id, n.name, n.netType, applyNetwork.Description, applyNetwork.Config, n.status)
+	n.init(n.state, n.id, n.project, n.name, n.netType, applyNetwork.Description, applyNetwork.Config, n.status)
+
+	// Regenerate the network zones as the network may have been linked to or unlinked from them.
+	n.state.DNS.InvalidateZones()
 
 	// If this update isn't coming via a cluster notification itself, then notify all nodes of change and then
 	// update the database.
@@ -241,7 +259,7 @@ func (n *common) update(applyNetwork api.NetworkPut, targetNode string, clusterN
 			sendNetwork.Config = make(map[string]string)
 			for k, v := range applyNetwork.Config {
 				// Don't forward node specific keys (these will be merged in on recipient node).
-				if shared.StringInSlice(k, db.NodeSpecificNetworkConfig) {
+				if n.project == project.Default && shared.StringInSlice(k, db.NodeSpecificNetworkConfig) {
 					continue
 				}
 
@@ -249,7 +267,7 @@ func (n *common) update(applyNetwork api.NetworkPut, targetNode string, clusterN
 			}
 
 			err = notifier(func(client lxd.InstanceServer) error {
-				return client.UpdateNetwork(n.name, sendNetwork, "")
+				return client.UseProject(n.project).UpdateNetwork(n.name, sendNetwork, "")
 			})
 			if err != nil {
 				return err
@@ -257,7 +275,7 @@ func (n *common) update(applyNetwork api.NetworkPut, targetNode string, clusterN
 		}
 
 		// Update the database.
-		err := n.state.Cluster.UpdateNetwork(n.name, applyNetwork.Description, applyNetwork.Config)
+		err := n.state.Cluster.UpdateNetwork(n.project, n.name, applyNetwork.Description, applyNetwork.Config)
 		if err != nil {
 			return err
 		}
@@ -316,27 +334,32 @@ func (n *common) configChanged(newNetwork api.NetworkPut) (bool, []string, api.N
 
 // rename the network directory, update database record and update internal variables.
 func (n *common) rename(newName string) error {
-	// Clear new directory if exists.
-	if shared.PathExists(shared.VarPath("networks", newName)) {
-		os.RemoveAll(shared.VarPath("networks", newName))
-	}
+	// Only networks in the default project have a network directory.
+	if n.project == project.Default {
+		// Clear new directory if exists.
+		if shared.PathExists(shared.VarPath("networks", newName)) {
+			os.RemoveAll(shared.VarPath("networks", newName))
+		}
 
-	// Rename directory to new name.
-	if shared.PathExists(shared.VarPath("networks", n.name)) {
-		err := os.Rename(shared.VarPath("networks", n.name), shared.VarPath("networks", newName))
-		if err != nil {
-			return err
+		// Rename directory to new name.
+		if shared.PathExists(shared.VarPath("networks", n.name)) {
+			err := os.Rename(shared.VarPath("networks", n.name), shared.VarPath("networks", newName))
+			if err != nil {
+				return err
+			}
 		}
 	}
 
 	// Rename the database entry.
-	err := n.state.Cluster.RenameNetwork(n.name, newName)
+	err := n.state.Cluster.RenameNetwork(n.project, n.name, newName)
 	if err != nil {
 		return err
 	}
 
 	// Reinitialise internal name variable and logger context with new name.
-	n.init(n.state, n.id, newName, n.netType, n.description, n.config, n.status)
+	n.init(n.state, n.id, n.project, newName, n.netType, n.description, n.config, n.status)
+
+	n.state.DNS.InvalidateZones()
 
 	return nil
 }
@@ -351,24 +374,26 @@ func (n *common) delete(clusterNotification bool) error {
 			return err
 		}
 		err = notifier(func(client lxd.InstanceServer) error {
-			return client.DeleteNetwork(n.name)
+			return client.UseProject(n.project).DeleteNetwork(n.name)
 		})
 		if err != nil {
 			return err
 		}
 
 		// Remove the network from the database.
-		err = n.state.Cluster.DeleteNetwork(n.name)
+		err = n.state.Cluster.DeleteNetwork(n.project, n.name)
 		if err != nil {
 			return err
 		}
 	}
 
-	// Cleanup storage.
-	if shared.PathExists(shared.VarPath("networks", n.name)) {
+	// Cleanup storage (only networks in the default project have a network directory).
+	if n.project == project.Default && shared.PathExists(shared.VarPath("networks", n.name)) {
 		os.RemoveAll(shared.VarPath("networks", n.name))
 	}
 
+	n.state.DNS.InvalidateZones()
+
 	return nil
 }
 
@@ -381,3 +406,197 @@ func (n *common) Create(clusterNotification bool) error {
 func (n *common) HandleHeartbeat(heartbeatData *cluster.APIHeartbeat) error {
 	return nil
 }
+
+// ForwardCreate returns ErrNotImplemented for drivers that do not support address forwards.
+func (n *common) ForwardCreate(forward api.NetworkForwardsPost, clusterNotification bool) error {
+	return ErrNotImplemented
+}
+
+// ForwardUpdate returns ErrNotImplemented for drivers that do not support address forwards.
+func (n *common) ForwardUpdate(listenAddress string, newForward api.NetworkForwardPut, clusterNotification bool) error {
+	return ErrNotImplemented
+}
+
+// ForwardDelete returns ErrNotImplemented for drivers that do not support address forwards.
+func (n *common) ForwardDelete(listenAddress string, clusterNotification bool) error {
+	return ErrNotImplemented
+}
+
+// LoadBalancerCreate returns ErrNotImplemented for drivers that do not support load balancers.
+func (n *common) LoadBalancerCreate(loadBalancer api.NetworkLoadBalancersPost, clusterNotification bool) (net.IP, error) {
+	return nil, ErrNotImplemented
+}
+
+// LoadBalancerUpdate returns ErrNotImplemented for drivers that do not support load balancers.
+func (n *common) LoadBalancerUpdate(listenAddress string, newLoadBalancer api.NetworkLoadBalancerPut, clusterNotification bool) error {
+	return ErrNotImplemented
+}
+
+// LoadBalancerDelete returns ErrNotImplemented for drivers that do not support load balancers.
+func (n *common) LoadBalancerDelete(listenAddress string, clusterNotification bool) error {
+	return ErrNotImplemented
+}
+
+// PeerCreate returns ErrNotImplemented for drivers that do not support peerings.
+func (n *common) PeerCreate(peer api.NetworkPeersPost) error {
+	return ErrNotImplemented
+}
+
+// PeerUpdate returns ErrNotImplemented for drivers that do not support peerings.
+func (n *common) PeerUpdate(peerName string, newPeer api.NetworkPeerPut) error {
+	return ErrNotImplemented
+}
+
+// PeerDelete returns ErrNotImplemented for drivers that do not support peerings.
+func (n *common) PeerDelete(peerName string) error {
+	return ErrNotImplemented
+}
+
+// ZoneAddresses returns ErrNotImplemented for drivers that do not support network zones.
+func (n *common) ZoneAddresses() ([]ZoneAddress, error) {
+	return nil, ErrNotImplemented
+}
+
+// LeaseReservationCreate returns ErrNotImplemented for drivers that do not support DHCP reservations.
+func (n *common) LeaseReservationCreate(reservation api.NetworkLeasesPost, clusterNotification bool) error {
+	return ErrNotImplemented
+}
+
+// LeaseReservationDelete returns ErrNotImplemented for drivers that do not support DHCP reservations.
+func (n *common) LeaseReservationDelete(hwaddr string, clusterNotification bool) error {
+	return ErrNotImplemented
+}
+
+// forwardPortMap represents a port specification of an address forward with its ports expanded.
+type forwardPortMap struct {
+	protocol      string
+	listenPorts   []uint64
+	targetAddress net.IP
+	targetPorts   []uint64
+}
+
+// forwardValidate checks the address forward is valid for the network and returns its port specifications.
+// Target addresses must be within the network's subnet of the same IP family as the listen address.
+func (n *common) forwardValidate(listenAddress net.IP, forward *api.NetworkForwardPut) ([]*forwardPortMap, error) {
+	if listenAddress == nil {
+		return nil, fmt.Errorf("Invalid listen address")
+	}
+
+	// Get the network's subnet of the same IP family as the listen address.
+	subnetKey := "ipv4.address"
+	if listenAddress.To4() == nil {
+		subnetKey = "ipv6.address"
+	}
+
+	_, subnet, err := net.ParseCIDR(n.config[subnetKey])
+	if err != nil {
+		return nil, fmt.Errorf("Network has no %q set so cannot forward to %s targets", subnetKey, strings.SplitN(subnetKey, ".", 2)[0])
+	}
+
+	if subnet.Contains(listenAddress) {
+		return nil, fmt.Errorf("Listen address %q cannot be within the network's subnet", listenAddress.String())
+	}
+
+	validateTarget := func(value string) (net.IP, error) {
+		targetAddress := net.ParseIP(value)
+		if targetAddress == nil {
+			return nil, fmt.Errorf("Invalid target address %q", value)
+		}
+
+		if !subnet.Contains(targetAddress) {
+			return nil, fmt.Errorf("Target address %q is not within the network's subnet %q", value, subnet.String())
+		}
+
+		return targetAddress, nil
+	}
+
+	for k, v := range forward.Config {
+		switch {
+		case k == "target_address":
+			_, err := validateTarget(v)
+			if err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(k, "user."):
+		default:
+			return nil, fmt.Errorf("Invalid option %q", k)
+		}
+	}
+
+	portMaps := make([]*forwardPortMap, 0, len(forward.Ports))
+	usedPorts := map[string][]uint64{}
+
+	for i, port := range forward.Ports {
+		if !shared.StringInSlice(port.Protocol, []string{"tcp", "udp"}) {
+			return nil, fmt.Errorf("Invalid protocol %q for port specification %d, must be one of: tcp, udp", port.Protocol, i)
+		}
+
+		portMap := &forwardPortMap{protocol: port.Protocol}
+
+		portMap.targetAddress, err = validateTarget(port.TargetAddress)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid port specification %d", i)
+		}
+
+		portMap.listenPorts, err = forwardParsePorts(port.ListenPort)
+		if err != nil || len(portMap.listenPorts) == 0 {
+			return nil, fmt.Errorf("Invalid listen port %q for port specification %d", port.ListenPort, i)
+		}
+
+		for _, listenPort := range portMap.listenPorts {
+			if shared.Uint64InSlice(listenPort, usedPorts[port.Protocol]) {
+				return nil, fmt.Errorf("Listen port %d (%s) is used by more than one port specification", listenPort, port.Protocol)
+			}
+
+			usedPorts[port.Protocol] = append(usedPorts[port.Protocol], listenPort)
+		}
+
+		if port.TargetPort != "" {
+			portMap.targetPorts, err = forwardParsePorts(port.TargetPort)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid target port %q for port specification %d", port.TargetPort, i)
+			}
+
+			if len(portMap.targetPorts) != 1 && len(portMap.targetPorts) != len(portMap.listenPorts) {
+				return nil, fmt.Errorf("Target port %q for port specification %d must be a single port or have the same number of ports as the listen port", port.TargetPort, i)
+			}
+		}
+
+		portMaps = append(portMaps, portMap)
+	}
+
+	return portMaps, nil
+}
+
+// forwardParsePorts parses a comma separated list of ports and port ranges and returns the individual ports.
+func forwardParsePorts(value string) ([]uint64, error) {
+	ports := []uint64{}
+
+	for _, portRange := range strings.Split(value, ",") {
+		portRange = strings.TrimSpace(portRange)
+		parts := strings.SplitN(portRange, "-", 2)
+
+		start, err := strconv.ParseUint(parts[0], 10, 16)
+		if err != nil {
+			return nil, err
+		}
+
+		end := start
+		if len(parts) == 2 {
+			end, err = strconv.ParseUint(parts[1], 10, 16)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if start == 0 || start > end {
+			return nil, fmt.Errorf("Invalid port range %q", portRange)
+		}
+
+		for port := start; port <= end; port++ {
+			ports = append(ports, port)
+		}
+	}
+
+	return ports, nil
+}
@@ -17,8 +17,11 @@ import (
 
 	"github.com/lxc/lxd/lxd/cluster"
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/device/nictype"
 	"github.com/lxc/lxd/lxd/dnsmasq"
+	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/locking"
+	"github.com/lxc/lxd/lxd/network/acl"
 	"github.com/lxc/lxd/lxd/network/openvswitch"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/revert"
@@ -90,8 +93,20 @@ func (n *ovn) Validate(config map[string]string) error {
 
 			return validate.Optional(validate.IsNetworkAddressCIDRV6)(value)
 		},
-		"dns.domain": validate.IsAny,
-		"dns.search": validate.IsAny,
+		"ipv4.nat":           validate.Optional(validate.IsBool),
+		"ipv4.dhcp":          validate.Optional(validate.IsBool),
+		"ipv6.nat":           validate.Optional(validate.IsBool),
+		"ipv6.dhcp":          validate.Optional(validate.IsBool),
+		"ipv6.dhcp.stateful": validate.Optional(validate.IsBool),
+		"dns.domain":         validate.IsAny,
+		"dns.search":         validate.IsAny,
+
+		"dns.zone.forward":      validate.Optional(n.validateZone),
+		"dns.zone.reverse.ipv4": validate.Optional(n.validateZone),
+		"dns.zone.reverse.ipv6": validate.Optional(n.validateZone),
+		"security.acls": func(value string) error {
+			return acl.ValidateNames(n.state, value)
+		},
 
 		// Volatile keys populated automatically as needed.
 		ovnVolatileParentIPv4: validate.Optional(validate.IsNetworkAddressV4),
@@ -103,6 +118,11 @@ func (n *ovn) Validate(config map[string]string) error {
 		return err
 	}
 
+	// Stateful DHCPv6 requires DHCPv6 (an empty ipv6.dhcp setting indicates enabled by default).
+	if shared.IsTrue(config["ipv6.dhcp.stateful"]) && config["ipv6.dhcp"] != "" && !shared.IsTrue(config["ipv6.dhcp"]) {
+		return fmt.Errorf(`"ipv6.dhcp.stateful" cannot be enabled when "ipv6.dhcp" is disabled`)
+	}
+
 	return nil
 }
 
@@ -263,7 +283,7 @@ func (n *ovn) getIntSwitchInstancePortPrefix() string {
 // setupParentPort initialises the parent uplink connection. Returns the derived ovnParentVars settings used
 // during the initial creation of the logical network.
 func (n *ovn) setupParentPort(routerMAC net.HardwareAddr) (*ovnParentVars, error) {
-	parentNet, err := LoadByName(n.state, n.config["parent"])
+	parentNet, err := LoadByName(n.state, project.Default, n.config["parent"])
 	if err != nil {
 		return nil, errors.Wrapf(err, "Failed loading parent network")
 	}
@@ -271,6 +291,8 @@ func (n *ovn) setupParentPort(routerMAC net.HardwareAddr) (*ovnParentVars, error
 	switch parentNet.Type() {
 	case "bridge":
 		return n.setupParentPortBridge(parentNet, routerMAC)
+	case "physical":
+		return n.setupParentPortPhysical(parentNet, routerMAC)
 	}
 
 	return nil, fmt.Errorf("Network type %q unsupported as OVN parent", parentNet.Type())
@@ -309,6 +331,65 @@ func (n *ovn) setupParentPortBridge(parentNet Network, routerMAC net.HardwareAdd
 		v.routerExtGwIPv6 = parentIPv6
 	}
 
+	err = n.allocateParentPortIPs(parentNet, v, routerMAC, parentIPv4Net, parentIPv6Net)
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// setupParentPortPhysical uses the gateways and OVN ranges declared on the physical parent network.
+// Returns the derived ovnParentVars settings.
+func (n *ovn) setupParentPortPhysical(parentNet Network, routerMAC net.HardwareAddr) (*ovnParentVars, error) {
+	v := &ovnParentVars{}
+
+	parentNetConf := parentNet.Config()
+
+	// Parent derived settings.
+	v.extSwitchProviderName = parentNet.Name()
+
+	// Optional parent values.
+	parentIPv4, parentIPv4Net, err := net.ParseCIDR(parentNetConf["ipv4.gateway"])
+	if err == nil {
+		v.routerExtGwIPv4 = parentIPv4
+	}
+
+	parentIPv6, parentIPv6Net, err := net.ParseCIDR(parentNetConf["ipv6.gateway"])
+	if err == nil {
+		v.routerExtGwIPv6 = parentIPv6
+	}
+
+	// Use the nameservers declared on the parent network, falling back to the gateways.
+	v.dnsIPv4 = v.routerExtGwIPv4
+	v.dnsIPv6 = v.routerExtGwIPv6
+	for _, nameserver := range strings.Split(parentNetConf["dns.nameservers"], ",") {
+		nsIP := net.ParseIP(strings.TrimSpace(nameserver))
+		if nsIP == nil {
+			continue
+		}
+
+		if nsIP.To4() != nil {
+			v.dnsIPv4 = nsIP
+		} else {
+			v.dnsIPv6 = nsIP
+		}
+	}
+
+	err = n.allocateParentPortIPs(parentNet, v, routerMAC, parentIPv4Net, parentIPv6Net)
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// allocateParentPortIPs allocates external IPs for the router's parent port from the parent network's OVN ranges
+// (if not already allocated), stores them in this network's volatile config keys and populates the router's
+// external port settings in the supplied ovnParentVars.
+func (n *ovn) allocateParentPortIPs(parentNet Network, v *ovnParentVars, routerMAC net.HardwareAddr, parentIPv4Net *net.IPNet, parentIPv6Net *net.IPNet) error {
+	parentNetConf := parentNet.Config()
+
 	// Parse existing allocated IPs for this network on the parent network (if not set yet, will be nil).
 	routerExtPortIPv4 := net.ParseIP(n.config[ovnVolatileParentIPv4])
 	routerExtPortIPv6 := net.ParseIP(n.config[ovnVolatileParentIPv6])
@@ -326,7 +407,7 @@ func (n *ovn) setupParentPortBridge(parentNet Network, routerMAC net.HardwareAdd
 					return fmt.Errorf(`Missing required "ipv4.ovn.ranges" config key on parent network`)
 				}
 
-				ipRanges, err := parseIPRanges(parentNetConf["ipv4.ovn.ranges"], parentNet.DHCPv4Subnet())
+				ipRanges, err := parseIPRanges(parentNetConf["ipv4.ovn.ranges"], parentIPv4Net)
 				if err != nil {
 					return errors.Wrapf(err, "Failed to parse parent IPv4 OVN ranges")
 				}
@@ -342,7 +423,7 @@ func (n *ovn) setupParentPortBridge(parentNet Network, routerMAC net.HardwareAdd
 			if parentIPv6Net != nil && routerExtPortIPv6 == nil {
 				// If IPv6 OVN ranges are specified by the parent, allocate from them.
 				if parentNetConf["ipv6.ovn.ranges"] != "" {
-					ipRanges, err := parseIPRanges(parentNetConf["ipv6.ovn.ranges"], parentNet.DHCPv6Subnet())
+					ipRanges, err := parseIPRanges(parentNetConf["ipv6.ovn.ranges"], parentIPv6Net)
 					if err != nil {
 						return errors.Wrapf(err, "Failed to parse parent IPv6 OVN ranges")
 					}
@@ -363,7 +444,7 @@ func (n *ovn) setupParentPortBridge(parentNet Network, routerMAC net.HardwareAdd
 				n.config[ovnVolatileParentIPv6] = routerExtPortIPv6.String()
 			}
 
-			networkID, err := tx.GetNetworkID(n.name)
+			networkID, err := tx.GetNetworkID(n.project, n.name)
 			if err != nil {
 				return errors.Wrapf(err, "Failed to get network ID for network %q", n.name)
 			}
@@ -376,7 +457,7 @@ func (n *ovn) setupParentPortBridge(parentNet Network, routerMAC net.HardwareAdd
 			return nil
 		})
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
@@ -397,7 +478,7 @@ func (n *ovn) setupParentPortBridge(parentNet Network, routerMAC net.HardwareAdd
 		v.routerExtPortIPv6Net = routerExtPortIPv6Net.String()
 	}
 
-	return v, nil
+	return nil
 }
 
 // parentAllAllocatedIPs gets a list of all IPv4 and IPv6 addresses allocated to OVN networks connected to parent.
@@ -486,7 +567,7 @@ func (n *ovn) parentAllocateIP(ipRanges []*shared.IPRange, allAllocated []net.IP
 
 // startParentPort performs any network start up logic needed to connect the parent uplink connection to OVN.
 func (n *ovn) startParentPort() error {
-	parentNet, err := LoadByName(n.state, n.config["parent"])
+	parentNet, err := LoadByName(n.state, project.Default, n.config["parent"])
 	if err != nil {
 		return errors.Wrapf(err, "Failed loading parent network")
 	}
@@ -494,6 +575,8 @@ func (n *ovn) startParentPort() error {
 	switch parentNet.Type() {
 	case "bridge":
 		return n.startParentPortBridge(parentNet)
+	case "physical":
+		return n.startParentPortPhysical(parentNet)
 	}
 
 	return fmt.Errorf("Network type %q unsupported as OVN parent", parentNet.Type())
@@ -585,9 +668,71 @@ func (n *ovn) startParentPortBridge(parentNet Network) error {
 	return nil
 }
 
+// startParentPortPhysical creates the parent OVS bridge (if doesn't exist) and connects the physical parent
+// network's host interface to it.
+func (n *ovn) startParentPortPhysical(parentNet Network) error {
+	parentNetConf := parentNet.Config()
+	parentHostName := GetHostDevice(parentNetConf["parent"], parentNetConf["vlan"])
+
+	if !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", parentHostName)) {
+		return fmt.Errorf("Cannot find parent network interface %q", parentHostName)
+	}
+
+	// Uses the same OVS bridge name as bridge parents, as they are derived from the parent network's ID.
+	vars := n.parentPortBridgeVars(parentNet)
+
+	// Lock parent network so that if multiple OVN networks are trying to connect to the same parent we don't
+	// race each other setting up the connection.
+	unlock := locking.Lock(n.parentOperationLockName(parentNet))
+	defer unlock()
+
+	// Do this after gaining lock so that on failure we revert before release locking.
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Create parent OVS bridge if needed. It may already be in use by other OVN networks, so only remove it
+	// (along with the parent host interface connection) on failure if created here.
+	ovs := openvswitch.NewOVS()
+	bridgeCreated := false
+	if !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", vars.ovsBridge)) {
+		err := ovs.BridgeAdd(vars.ovsBridge, true)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to create parent uplink OVS bridge %q", vars.ovsBridge)
+		}
+
+		bridgeCreated = true
+		revert.Add(func() { ovs.BridgeDelete(vars.ovsBridge) })
+	}
+
+	// Connect parent host interface to OVS bridge.
+	err := ovs.BridgePortAdd(vars.ovsBridge, parentHostName, true)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to connect parent interface %q to parent OVS bridge %q", parentHostName, vars.ovsBridge)
+	}
+
+	if bridgeCreated {
+		revert.Add(func() { ovs.BridgePortDelete(vars.ovsBridge, parentHostName) })
+	}
+
+	// Associate OVS bridge to logical OVN provider.
+	err = ovs.OVNBridgeMappingAdd(vars.ovsBridge, parentNet.Name())
+	if err != nil {
+		return errors.Wrapf(err, "Failed to associate parent OVS bridge %q to OVN provider %q", vars.ovsBridge, parentNet.Name())
+	}
+
+	// Ensure parent host interface is up.
+	_, err = shared.RunCommand("ip", "link", "set", "dev", parentHostName, "up")
+	if err != nil {
+		return errors.Wrapf(err, "Failed to bring up parent interface %q", parentHostName)
+	}
+
+	revert.Success()
+	return nil
+}
+
 // deleteParentPort deletes the parent uplink connection.
 func (n *ovn) deleteParentPort() error {
-	parentNet, err := LoadByName(n.state, n.config["parent"])
+	parentNet, err := LoadByName(n.state, project.Default, n.config["parent"])
 	if err != nil {
 		return errors.Wrapf(err, "Failed loading parent network")
 	}
@@ -595,6 +740,8 @@ func (n *ovn) deleteParentPort() error {
 	switch parentNet.Type() {
 	case "bridge":
 		return n.deleteParentPortBridge(parentNet)
+	case "physical":
+		return n.deleteParentPortPhysical(parentNet)
 	}
 
 	return fmt.Errorf("Network type %q unsupported as OVN parent", parentNet.Type())
@@ -665,12 +812,49 @@ func (n *ovn) deleteParentPortBridge(parentNet Network) error {
 	return nil
 }
 
+// deleteParentPortPhysical removes the parent uplink OVS bridge if not in use by other OVN networks.
+func (n *ovn) deleteParentPortPhysical(parentNet Network) error {
+	// Lock parent network so we don't race each other networks using the OVS uplink bridge.
+	unlock := locking.Lock(n.parentOperationLockName(parentNet))
+	defer unlock()
+
+	vars := n.parentPortBridgeVars(parentNet)
+	if !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", vars.ovsBridge)) {
+		return nil
+	}
+
+	ovs := openvswitch.NewOVS()
+	ports, err := ovs.BridgePortList(vars.ovsBridge)
+	if err != nil {
+		return err
+	}
+
+	// If the OVS bridge has only 1 port (the parent host interface) or fewer connected then we can delete it.
+	if len(ports) <= 1 {
+		err = ovs.OVNBridgeMappingDelete(vars.ovsBridge, parentNet.Name())
+		if err != nil {
+			return err
+		}
+
+		err = ovs.BridgeDelete(vars.ovsBridge)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // fillConfig fills requested config with any default values.
 func (n *ovn) fillConfig(config map[string]string) error {
 	if config["ipv4.address"] == "" {
 		config["ipv4.address"] = "auto"
 	}
 
+	if config["ipv4.address"] == "auto" && config["ipv4.nat"] == "" {
+		config["ipv4.nat"] = "true"
+	}
+
 	if config["ipv6.address"] == "" {
 		content, err := ioutil.ReadFile("/proc/sys/net/ipv6/conf/default/disable_ipv6")
 		if err == nil && string(content) == "0\n" {
@@ -678,6 +862,10 @@ func (n *ovn) fillConfig(config map[string]string) error {
 		}
 	}
 
+	if config["ipv6.address"] == "auto" && config["ipv6.nat"] == "" {
+		config["ipv6.nat"] = "true"
+	}
+
 	// Now populate "auto" values where needed.
 	if config["ipv4.address"] == "auto" {
 		subnet, err := randomSubnetV4()
@@ -797,7 +985,14 @@ func (n *ovn) setup(update bool) error {
 	}
 
 	// Create logical router.
+	var existingRoutes []openvswitch.OVNRouterRoute
 	if update {
+		// Keep the routes to instance NICs so they can be restored on the new router.
+		existingRoutes, err = client.LogicalRouterRoutes(n.getRouterName())
+		if err != nil {
+			return errors.Wrapf(err, "Failed getting existing router routes")
+		}
+
 		client.LogicalRouterDelete(n.getRouterName())
 	}
 
@@ -824,15 +1019,28 @@ func (n *ovn) setup(update bool) error {
 		}
 	}
 
+	// Restore routes to instance NICs (default routes have been added above).
+	for _, route := range existingRoutes {
+		ones, _ := route.Prefix.Mask.Size()
+		if ones == 0 {
+			continue
+		}
+
+		err = client.LogicalRouterRouteAdd(n.getRouterName(), route.Prefix, route.NextHop)
+		if err != nil {
+			return errors.Wrapf(err, "Failed restoring route %q", route.Prefix.String())
+		}
+	}
+
 	// Add SNAT rules.
-	if routerIntPortIPv4Net != nil {
+	if shared.IsTrue(n.config["ipv4.nat"]) && routerIntPortIPv4Net != nil && routerExtPortIPv4 != nil {
 		err = client.LogicalRouterSNATAdd(n.getRouterName(), routerIntPortIPv4Net, routerExtPortIPv4)
 		if err != nil {
 			return err
 		}
 	}
 
-	if routerIntPortIPv6Net != nil {
+	if shared.IsTrue(n.config["ipv6.nat"]) && routerIntPortIPv6Net != nil && routerExtPortIPv6 != nil {
 		err = client.LogicalRouterSNATAdd(n.getRouterName(), routerIntPortIPv6Net, routerExtPortIPv6)
 		if err != nil {
 			return err
@@ -944,28 +1152,43 @@ func (n *ovn) setup(update bool) error {
 		}
 	}
 
-	// Create DHCPv4 options for internal switch.
-	err = client.LogicalSwitchDHCPv4OptionsSet(n.getIntSwitchName(), dhcpv4UUID, routerIntPortIPv4Net, &openvswitch.OVNDHCPv4Opts{
-		ServerID:           routerIntPortIPv4,
-		ServerMAC:          routerMAC,
-		Router:             routerIntPortIPv4,
-		RecursiveDNSServer: parent.dnsIPv4,
-		DomainName:         n.getDomainName(),
-		LeaseTime:          time.Duration(time.Hour * 1),
-		MTU:                n.getBridgeMTU(),
-	})
-	if err != nil {
-		return errors.Wrapf(err, "Failed adding DHCPv4 settings for internal switch")
+	// Create DHCPv4 options for internal switch, or remove them if DHCPv4 has been disabled.
+	if n.DHCPv4Subnet() != nil {
+		err = client.LogicalSwitchDHCPv4OptionsSet(n.getIntSwitchName(), dhcpv4UUID, routerIntPortIPv4Net, &openvswitch.OVNDHCPv4Opts{
+			ServerID:           routerIntPortIPv4,
+			ServerMAC:          routerMAC,
+			Router:             routerIntPortIPv4,
+			RecursiveDNSServer: parent.dnsIPv4,
+			DomainName:         n.getDomainName(),
+			LeaseTime:          time.Duration(time.Hour * 1),
+			MTU:                n.getBridgeMTU(),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "Failed adding DHCPv4 settings for internal switch")
+		}
+	} else if dhcpv4UUID != "" {
+		err = client.LogicalSwitchDHCPOptionsDelete(n.getIntSwitchName(), dhcpv4UUID)
+		if err != nil {
+			return errors.Wrapf(err, "Failed removing DHCPv4 settings for internal switch")
+		}
 	}
 
-	// Create DHCPv6 options for internal switch.
-	err = client.LogicalSwitchDHCPv6OptionsSet(n.getIntSwitchName(), dhcpv6UUID, routerIntPortIPv6Net, &openvswitch.OVNDHCPv6Opts{
-		ServerID:           routerMAC,
-		RecursiveDNSServer: parent.dnsIPv6,
-		DNSSearchList:      n.getDNSSearchList(),
-	})
-	if err != nil {
-		return errors.Wrapf(err, "Failed adding DHCPv6 settings for internal switch")
+	// Create DHCPv6 options for internal switch, or remove them if DHCPv6 has been disabled.
+	if n.DHCPv6Subnet() != nil {
+		err = client.LogicalSwitchDHCPv6OptionsSet(n.getIntSwitchName(), dhcpv6UUID, routerIntPortIPv6Net, &openvswitch.OVNDHCPv6Opts{
+			ServerID:           routerMAC,
+			RecursiveDNSServer: parent.dnsIPv6,
+			DNSSearchList:      n.getDNSSearchList(),
+			Stateless:          !shared.IsTrue(n.config["ipv6.dhcp.stateful"]),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "Failed adding DHCPv6 settings for internal switch")
+		}
+	} else if dhcpv6UUID != "" {
+		err = client.LogicalSwitchDHCPOptionsDelete(n.getIntSwitchName(), dhcpv6UUID)
+		if err != nil {
+			return errors.Wrapf(err, "Failed removing DHCPv6 settings for internal switch")
+		}
 	}
 
 	// Generate internal router port IPs (in CIDR format).
@@ -993,8 +1216,18 @@ func (n *ovn) setup(update bool) error {
 
 	// Set IPv6 router advertisement settings.
 	if routerIntPortIPv6Net != nil {
+		// Advertise the address mode that matches the DHCPv6 settings.
+		raAddressMode := openvswitch.OVNIPv6AddressModeSLAAC
+		if n.DHCPv6Subnet() != nil {
+			if shared.IsTrue(n.config["ipv6.dhcp.stateful"]) {
+				raAddressMode = openvswitch.OVNIPv6AddressModeDHCPStateful
+			} else {
+				raAddressMode = openvswitch.OVNIPv6AddressModeDHCPStateless
+			}
+		}
+
 		err = client.LogicalRouterPortSetIPv6Advertisements(n.getRouterIntPortName(), &openvswitch.OVNIPv6RAOpts{
-			AddressMode:        openvswitch.OVNIPv6AddressModeSLAAC,
+			AddressMode:        raAddressMode,
 			SendPeriodic:       true,
 			DNSSearchList:      n.getDNSSearchList(),
 			RecursiveDNSServer: parent.dnsIPv6,
@@ -1022,6 +1255,38 @@ func (n *ovn) setup(update bool) error {
 		return errors.Wrapf(err, "Failed linking internal router port to internal switch port")
 	}
 
+	// Create the port group containing all instance ports and apply the network ACLs to it.
+	err = acl.OVNApplyNetworkRules(n.state, client, n.id, acl.ParseNames(n.config["security.acls"]))
+	if err != nil {
+		return errors.Wrapf(err, "Failed applying network ACLs")
+	}
+
+	// Apply network address forwards (the logical router may have been recreated).
+	forwards, err := n.state.Cluster.GetNetworkForwards(n.id)
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading network forwards")
+	}
+
+	for _, forward := range forwards {
+		err = n.forwardApply(client, forward.ListenAddress, &forward.NetworkForwardPut)
+		if err != nil {
+			return errors.Wrapf(err, "Failed applying network forward %q", forward.ListenAddress)
+		}
+	}
+
+	// Apply network load balancers.
+	loadBalancers, err := n.state.Cluster.GetNetworkLoadBalancers(n.id)
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading network load balancers")
+	}
+
+	for _, loadBalancer := range loadBalancers {
+		err = n.loadBalancerApply(client, loadBalancer.ListenAddress, &loadBalancer.NetworkLoadBalancerPut)
+		if err != nil {
+			return errors.Wrapf(err, "Failed applying network load balancer %q", loadBalancer.ListenAddress)
+		}
+	}
+
 	revert.Success()
 	return nil
 }
@@ -1036,6 +1301,24 @@ func (n *ovn) Delete(clusterNotification bool) error {
 			return err
 		}
 
+		// Remove the peerings from the peered networks' routers, and set their peerings back to pending.
+		peerings, err := n.peerings()
+		if err != nil {
+			return err
+		}
+
+		for _, peering := range peerings {
+			err = client.LogicalRouterPeeringDelete(*peering)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = n.state.Cluster.UnlinkNetworkPeers(n.id)
+		if err != nil {
+			return err
+		}
+
 		err = client.LogicalRouterDelete(n.getRouterName())
 		if err != nil {
 			return err
@@ -1081,6 +1364,36 @@ func (n *ovn) Delete(clusterNotification bool) error {
 		if err != nil {
 			return err
 		}
+
+		err = client.PortGroupDelete(acl.OVNNetworkPortGroupName(n.id))
+		if err != nil {
+			return err
+		}
+
+		// Load balancers aren't removed along with the logical router.
+		forwards, err := n.state.Cluster.GetNetworkForwards(n.id)
+		if err != nil {
+			return err
+		}
+
+		for _, forward := range forwards {
+			err = client.LoadBalancerDelete(n.getForwardLoadBalancerNames(net.ParseIP(forward.ListenAddress))...)
+			if err != nil {
+				return err
+			}
+		}
+
+		loadBalancers, err := n.state.Cluster.GetNetworkLoadBalancers(n.id)
+		if err != nil {
+			return err
+		}
+
+		for _, loadBalancer := range loadBalancers {
+			err = client.LoadBalancerDelete(n.getLoadBalancerNames(net.ParseIP(loadBalancer.ListenAddress))...)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	// Delete local parent uplink port.
@@ -1154,6 +1467,15 @@ func (n *ovn) Update(newNetwork api.NetworkPut, targetNode string, clusterNotifi
 		return nil // Nothing changed.
 	}
 
+	// Get the current peerings if the router's addresses are changing, so they can be replaced.
+	var oldPeerings []*openvswitch.OVNRouterPeering
+	if !clusterNotification && (shared.StringInSlice("ipv4.address", changedKeys) || shared.StringInSlice("ipv6.address", changedKeys) || shared.StringInSlice("bridge.hwaddr", changedKeys)) {
+		oldPeerings, err = n.peerings()
+		if err != nil {
+			return err
+		}
+	}
+
 	revert := revert.New()
 	defer revert.Fail()
 
@@ -1165,6 +1487,7 @@ func (n *ovn) Update(newNetwork api.NetworkPut, targetNode string, clusterNotifi
 		// Reset any change that was made to logical network.
 		if !clusterNotification {
 			n.setup(true)
+			n.peeringsReplace(oldPeerings)
 		}
 	})
 
@@ -1182,17 +1505,57 @@ func (n *ovn) Update(newNetwork api.NetworkPut, targetNode string, clusterNotifi
 		}
 	}
 
+	// Replace the peerings using the new router addresses.
+	if len(oldPeerings) > 0 {
+		err = n.peeringsReplace(oldPeerings)
+		if err != nil {
+			return err
+		}
+	}
+
 	revert.Success()
 	return nil
 }
 
+// DHCPv4Subnet returns the DHCPv4 subnet (if DHCP is enabled on network).
+func (n *ovn) DHCPv4Subnet() *net.IPNet {
+	// DHCP is disabled on this network (an empty ipv4.dhcp setting indicates enabled by default).
+	if n.config["ipv4.dhcp"] != "" && !shared.IsTrue(n.config["ipv4.dhcp"]) {
+		return nil
+	}
+
+	_, subnet, err := net.ParseCIDR(n.getRouterIntPortIPv4Net())
+	if err != nil {
+		return nil
+	}
+
+	return subnet
+}
+
+// DHCPv6Subnet returns the DHCPv6 subnet (if DHCP is enabled on network).
+func (n *ovn) DHCPv6Subnet() *net.IPNet {
+	// DHCP is disabled on this network (an empty ipv6.dhcp setting indicates enabled by default).
+	if n.config["ipv6.dhcp"] != "" && !shared.IsTrue(n.config["ipv6.dhcp"]) {
+		return nil
+	}
+
+	_, subnet, err := net.ParseCIDR(n.getRouterIntPortIPv6Net())
+	if err != nil {
+		return nil
+	}
+
+	return subnet
+}
+
 // getInstanceDevicePortName returns the switch port name to use for an instance device.
 func (n *ovn) getInstanceDevicePortName(instanceID int, deviceName string) openvswitch.OVNSwitchPort {
 	return openvswitch.OVNSwitchPort(fmt.Sprintf("%s-%d-%s", n.getIntSwitchInstancePortPrefix(), instanceID, deviceName))
 }
 
 // instanceDevicePortAdd adds an instance device port to the internal logical switch and returns the port name.
-func (n *ovn) instanceDevicePortAdd(instanceID int, deviceName string, mac net.HardwareAddr, ips []net.IP) (openvswitch.OVNSwitchPort, error) {
+// The port is added to the network's port group and to the port groups of the specified ACLs. Static routes
+// for the internal routes are added to the logical router using the port's IPs as next hop.
+func (n *ovn) instanceDevicePortAdd(instanceID int, deviceName string, mac net.HardwareAddr, ips []net.IP, aclNames []string, internalRoutes []*net.IPNet) (openvswitch.OVNSwitchPort, error) {
 	var dhcpV4ID, dhcpv6ID string
 
 	revert := revert.New()
@@ -1204,25 +1567,17 @@ func (n *ovn) instanceDevicePortAdd(instanceID int, deviceName string, mac net.H
 	}
 
 	// Get DHCP options IDs.
-	if n.getRouterIntPortIPv4Net() != "" {
-		_, routerIntPortIPv4Net, err := net.ParseCIDR(n.getRouterIntPortIPv4Net())
-		if err != nil {
-			return "", err
-		}
-
-		dhcpV4ID, err = client.LogicalSwitchDHCPOptionsGetID(n.getIntSwitchName(), routerIntPortIPv4Net)
+	dhcpv4Subnet := n.DHCPv4Subnet()
+	if dhcpv4Subnet != nil {
+		dhcpV4ID, err = client.LogicalSwitchDHCPOptionsGetID(n.getIntSwitchName(), dhcpv4Subnet)
 		if err != nil {
 			return "", err
 		}
 	}
 
-	if n.getRouterIntPortIPv6Net() != "" {
-		_, routerIntPortIPv6Net, err := net.ParseCIDR(n.getRouterIntPortIPv6Net())
-		if err != nil {
-			return "", err
-		}
-
-		dhcpv6ID, err = client.LogicalSwitchDHCPOptionsGetID(n.getIntSwitchName(), routerIntPortIPv6Net)
+	dhcpv6Subnet := n.DHCPv6Subnet()
+	if dhcpv6Subnet != nil {
+		dhcpv6ID, err = client.LogicalSwitchDHCPOptionsGetID(n.getIntSwitchName(), dhcpv6Subnet)
 		if err != nil {
 			return "", err
 		}
@@ -1251,23 +1606,1164 @@ func (n *ovn) instanceDevicePortAdd(instanceID int, deviceName string, mac net.H
 		return "", err
 	}
 
-	revert.Success()
-	return instancePortName, nil
-}
-
-// instanceDevicePortDelete deletes an instance device port from the internal logical switch.
-func (n *ovn) instanceDevicePortDelete(instanceID int, deviceName string) error {
-	instancePortName := n.getInstanceDevicePortName(instanceID, deviceName)
+	// Add the port to the network's port group so that network ACLs apply to it.
+	netPortGroup := acl.OVNNetworkPortGroupName(n.id)
+	err = client.PortGroupAdd(netPortGroup, true)
+	if err != nil {
+		return "", err
+	}
 
-	client, err := n.getClient()
+	err = client.PortGroupPortAdd(netPortGroup, instancePortName)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	err = client.LogicalSwitchPortDelete(instancePortName)
+	// Add the port to the port groups of the instance device's ACLs.
+	aclPortGroups, err := acl.OVNEnsureACLs(n.state, client, aclNames)
 	if err != nil {
-		return err
+		return "", errors.Wrapf(err, "Failed applying network ACLs")
 	}
 
-	return nil
+	for _, aclPortGroup := range aclPortGroups {
+		err = client.PortGroupPortAdd(aclPortGroup, instancePortName)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if len(internalRoutes) > 0 {
+		// Use the static IPs as next hops if specified, otherwise wait for OVN to allocate dynamic IPs.
+		nextHopIPs := ips
+		if len(nextHopIPs) <= 0 {
+			for i := 0; i < 10; i++ {
+				nextHopIPs, err = client.LogicalSwitchPortDynamicIPs(instancePortName)
+				if err != nil {
+					return "", errors.Wrapf(err, "Failed getting dynamic IPs of port %q", instancePortName)
+				}
+
+				if len(nextHopIPs) > 0 {
+					break
+				}
+
+				time.Sleep(500 * time.Millisecond)
+			}
+		}
+
+		// Remove any stale routes left behind by a previous start before adding them.
+		err = client.LogicalRouterRouteDelete(n.getRouterName(), internalRoutes...)
+		if err != nil {
+			return "", err
+		}
+
+		for _, internalRoute := range internalRoutes {
+			var nextHop net.IP
+			for _, ip := range nextHopIPs {
+				if (ip.To4() == nil) == (internalRoute.IP.To4() == nil) {
+					nextHop = ip
+					break
+				}
+			}
+
+			if nextHop == nil {
+				return "", fmt.Errorf("Port %q has no IP of the same family to route %q to", instancePortName, internalRoute.String())
+			}
+
+			err = client.LogicalRouterRouteAdd(n.getRouterName(), internalRoute, nextHop)
+			if err != nil {
+				return "", errors.Wrapf(err, "Failed adding route %q", internalRoute.String())
+			}
+
+			// Capture route for use in revert function.
+			route := internalRoute
+			revert.Add(func() { client.LogicalRouterRouteDelete(n.getRouterName(), route) })
+		}
+	}
+
+	// Refresh the health checks of the load balancers with a backend on the port so that it gets checked.
+	portIPs := ips
+	if len(portIPs) <= 0 {
+		portIPs, err = client.LogicalSwitchPortDynamicIPs(instancePortName)
+		if err != nil {
+			return "", errors.Wrapf(err, "Failed getting dynamic IPs of port %q", instancePortName)
+		}
+	}
+
+	err = n.loadBalancerHealthChecksRefresh(client, portIPs)
+	if err != nil {
+		n.logger.Warn("Failed refreshing load balancer health checks", log.Ctx{"port": instancePortName, "err": err})
+	}
+
+	revert.Success()
+	return instancePortName, nil
+}
+
+// instanceDevicePortDelete deletes an instance device port from the internal logical switch along with the
+// static routes for its internal routes.
+func (n *ovn) instanceDevicePortDelete(instanceID int, deviceName string, internalRoutes []*net.IPNet) error {
+	instancePortName := n.getInstanceDevicePortName(instanceID, deviceName)
+
+	client, err := n.getClient()
+	if err != nil {
+		return err
+	}
+
+	err = client.LogicalRouterRouteDelete(n.getRouterName(), internalRoutes...)
+	if err != nil {
+		return err
+	}
+
+	err = client.LogicalSwitchPortDelete(instancePortName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// getForwardLoadBalancerName returns the load balancer name used for a forward's listen address and protocol.
+func (n *ovn) getForwardLoadBalancerName(listenAddress net.IP, protocol string) openvswitch.OVNLoadBalancer {
+	return openvswitch.OVNLoadBalancer(fmt.Sprintf("%s-lb-%s-%s", n.getNetworkPrefix(), listenAddress.String(), protocol))
+}
+
+// getForwardLoadBalancerNames returns the load balancer names used for a forward's listen address.
+func (n *ovn) getForwardLoadBalancerNames(listenAddress net.IP) []openvswitch.OVNLoadBalancer {
+	return []openvswitch.OVNLoadBalancer{
+		n.getForwardLoadBalancerName(listenAddress, "tcp"),
+		n.getForwardLoadBalancerName(listenAddress, "udp"),
+	}
+}
+
+// forwardApply applies a network address forward to the logical router. Port specifications are implemented
+// using a load balancer per protocol, and the default target address using a DNAT and SNAT rule.
+func (n *ovn) forwardApply(client *openvswitch.OVN, listenAddress string, forward *api.NetworkForwardPut) error {
+	listenIP := net.ParseIP(listenAddress)
+
+	portMaps, err := n.forwardValidate(listenIP, forward)
+	if err != nil {
+		return err
+	}
+
+	vips := map[string][]openvswitch.OVNLoadBalancerVIP{}
+	for _, portMap := range portMaps {
+		for i, listenPort := range portMap.listenPorts {
+			targetPort := listenPort
+			if len(portMap.targetPorts) == 1 {
+				targetPort = portMap.targetPorts[0]
+			} else if len(portMap.targetPorts) > 1 {
+				targetPort = portMap.targetPorts[i]
+			}
+
+			vips[portMap.protocol] = append(vips[portMap.protocol], openvswitch.OVNLoadBalancerVIP{
+				ListenAddress: listenIP,
+				ListenPort:    listenPort,
+				Targets: []openvswitch.OVNLoadBalancerTarget{
+					{Address: portMap.targetAddress, Port: targetPort},
+				},
+			})
+		}
+	}
+
+	for _, protocol := range []string{"tcp", "udp"} {
+		err = client.LoadBalancerApply(n.getForwardLoadBalancerName(listenIP, protocol), n.getRouterName(), n.getIntSwitchName(), protocol, vips[protocol]...)
+		if err != nil {
+			return errors.Wrapf(err, "Failed applying %s load balancer", protocol)
+		}
+	}
+
+	err = client.LogicalRouterDNATSNATDelete(n.getRouterName(), listenIP)
+	if err != nil {
+		return err
+	}
+
+	if forward.Config["target_address"] != "" {
+		err = client.LogicalRouterDNATSNATAdd(n.getRouterName(), listenIP, net.ParseIP(forward.Config["target_address"]), false)
+		if err != nil {
+			return errors.Wrapf(err, "Failed adding DNAT rule for default target address")
+		}
+	}
+
+	return nil
+}
+
+// forwardRemove removes a network address forward from the logical router.
+func (n *ovn) forwardRemove(client *openvswitch.OVN, listenAddress string) error {
+	listenIP := net.ParseIP(listenAddress)
+
+	err := client.LoadBalancerDelete(n.getForwardLoadBalancerNames(listenIP)...)
+	if err != nil {
+		return err
+	}
+
+	return client.LogicalRouterDNATSNATDelete(n.getRouterName(), listenIP)
+}
+
+// ForwardCreate creates a network address forward.
+// The OVN northbound database is shared by all nodes, so cluster notifications are not needed.
+func (n *ovn) ForwardCreate(forward api.NetworkForwardsPost, clusterNotification bool) error {
+	if clusterNotification {
+		return nil
+	}
+
+	_, err := n.forwardValidate(net.ParseIP(forward.ListenAddress), &forward.NetworkForwardPut)
+	if err != nil {
+		return err
+	}
+
+	client, err := n.getClient()
+	if err != nil {
+		return err
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	forwardID, err := n.state.Cluster.CreateNetworkForward(n.id, &forward)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		n.state.Cluster.DeleteNetworkForward(forwardID)
+		n.forwardRemove(client, forward.ListenAddress)
+	})
+
+	err = n.forwardApply(client, forward.ListenAddress, &forward.NetworkForwardPut)
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// ForwardUpdate updates a network address forward.
+func (n *ovn) ForwardUpdate(listenAddress string, newForward api.NetworkForwardPut, clusterNotification bool) error {
+	if clusterNotification {
+		return nil
+	}
+
+	forwardID, curForward, err := n.state.Cluster.GetNetworkForward(n.id, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	_, err = n.forwardValidate(net.ParseIP(curForward.ListenAddress), &newForward)
+	if err != nil {
+		return err
+	}
+
+	client, err := n.getClient()
+	if err != nil {
+		return err
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	err = n.state.Cluster.UpdateNetworkForward(forwardID, &newForward)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		oldForward := curForward.Writable()
+		n.state.Cluster.UpdateNetworkForward(forwardID, &oldForward)
+		n.forwardApply(client, curForward.ListenAddress, &oldForward)
+	})
+
+	err = n.forwardApply(client, curForward.ListenAddress, &newForward)
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// ForwardDelete deletes a network address forward.
+func (n *ovn) ForwardDelete(listenAddress string, clusterNotification bool) error {
+	if clusterNotification {
+		return nil
+	}
+
+	forwardID, forward, err := n.state.Cluster.GetNetworkForward(n.id, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	client, err := n.getClient()
+	if err != nil {
+		return err
+	}
+
+	err = n.forwardRemove(client, forward.ListenAddress)
+	if err != nil {
+		return err
+	}
+
+	return n.state.Cluster.DeleteNetworkForward(forwardID)
+}
+
+// ovnLoadBalancerTarget represents a backend target of a load balancer with its ports expanded.
+type ovnLoadBalancerTarget struct {
+	address net.IP
+	ports   []uint64
+}
+
+// ovnLoadBalancerPortMap represents a port specification of a load balancer with its ports and backends expanded.
+type ovnLoadBalancerPortMap struct {
+	protocol    string
+	listenPorts []uint64
+	targets     []ovnLoadBalancerTarget
+}
+
+// loadBalancerValidate checks the load balancer is valid for the network and returns its port specifications.
+// Backend target addresses must be within the network's subnet of the same IP family as the listen address.
+func (n *ovn) loadBalancerValidate(listenAddress net.IP, loadBalancer *api.NetworkLoadBalancerPut) ([]*ovnLoadBalancerPortMap, error) {
+	if listenAddress == nil {
+		return nil, fmt.Errorf("Invalid listen address")
+	}
+
+	// Get the network's subnet of the same IP family as the listen address.
+	subnetKey := "ipv4.address"
+	if listenAddress.To4() == nil {
+		subnetKey = "ipv6.address"
+	}
+
+	_, subnet, err := net.ParseCIDR(n.config[subnetKey])
+	if err != nil {
+		return nil, fmt.Errorf("Network has no %q set so cannot load balance to %s backends", subnetKey, strings.SplitN(subnetKey, ".", 2)[0])
+	}
+
+	if subnet.Contains(listenAddress) {
+		return nil, fmt.Errorf("Listen address %q cannot be within the network's subnet", listenAddress.String())
+	}
+
+	rules := map[string]func(value string) error{
+		"healthcheck":               validate.Optional(validate.IsBool),
+		"healthcheck.interval":      validate.Optional(validate.IsUint32),
+		"healthcheck.timeout":       validate.Optional(validate.IsUint32),
+		"healthcheck.failure_count": validate.Optional(validate.IsUint32),
+		"healthcheck.success_count": validate.Optional(validate.IsUint32),
+	}
+
+	for k, v := range loadBalancer.Config {
+		if strings.HasPrefix(k, "user.") {
+			continue
+		}
+
+		validator, found := rules[k]
+		if !found {
+			return nil, fmt.Errorf("Invalid option %q", k)
+		}
+
+		err := validator(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid value for option %q", k)
+		}
+	}
+
+	// OVN only supports health checking IPv4 backends.
+	if shared.IsTrue(loadBalancer.Config["healthcheck"]) && listenAddress.To4() == nil {
+		return nil, fmt.Errorf("Health checks are only supported on IPv4 load balancers")
+	}
+
+	backends := make(map[string]ovnLoadBalancerTarget, len(loadBalancer.Backends))
+	for i, backend := range loadBalancer.Backends {
+		if backend.Name == "" {
+			return nil, fmt.Errorf("Name is required for backend specification %d", i)
+		}
+
+		_, found := backends[backend.Name]
+		if found {
+			return nil, fmt.Errorf("Backend name %q is used by more than one backend specification", backend.Name)
+		}
+
+		target := ovnLoadBalancerTarget{address: net.ParseIP(backend.TargetAddress)}
+		if target.address == nil {
+			return nil, fmt.Errorf("Invalid target address %q for backend %q", backend.TargetAddress, backend.Name)
+		}
+
+		if !subnet.Contains(target.address) {
+			return nil, fmt.Errorf("Target address %q of backend %q is not within the network's subnet %q", backend.TargetAddress, backend.Name, subnet.String())
+		}
+
+		if backend.TargetPort != "" {
+			target.ports, err = forwardParsePorts(backend.TargetPort)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid target port %q for backend %q", backend.TargetPort, backend.Name)
+			}
+		}
+
+		backends[backend.Name] = target
+	}
+
+	portMaps := make([]*ovnLoadBalancerPortMap, 0, len(loadBalancer.Ports))
+	usedPorts := map[string][]uint64{}
+
+	for i, port := range loadBalancer.Ports {
+		if !shared.StringInSlice(port.Protocol, []string{"tcp", "udp"}) {
+			return nil, fmt.Errorf("Invalid protocol %q for port specification %d, must be one of: tcp, udp", port.Protocol, i)
+		}
+
+		portMap := &ovnLoadBalancerPortMap{protocol: port.Protocol}
+
+		portMap.listenPorts, err = forwardParsePorts(port.ListenPort)
+		if err != nil || len(portMap.listenPorts) == 0 {
+			return nil, fmt.Errorf("Invalid listen port %q for port specification %d", port.ListenPort, i)
+		}
+
+		for _, listenPort := range portMap.listenPorts {
+			if shared.Uint64InSlice(listenPort, usedPorts[port.Protocol]) {
+				return nil, fmt.Errorf("Listen port %d (%s) is used by more than one port specification", listenPort, port.Protocol)
+			}
+
+			usedPorts[port.Protocol] = append(usedPorts[port.Protocol], listenPort)
+		}
+
+		if len(port.TargetBackend) == 0 {
+			return nil, fmt.Errorf("At least one target backend is required for port specification %d", i)
+		}
+
+		for _, backendName := range port.TargetBackend {
+			target, found := backends[backendName]
+			if !found {
+				return nil, fmt.Errorf("Unknown target backend %q for port specification %d", backendName, i)
+			}
+
+			if len(target.ports) > 1 && len(target.ports) != len(portMap.listenPorts) {
+				return nil, fmt.Errorf("Target port of backend %q must be a single port or have the same number of ports as the listen port of port specification %d", backendName, i)
+			}
+
+			portMap.targets = append(portMap.targets, target)
+		}
+
+		portMaps = append(portMaps, portMap)
+	}
+
+	return portMaps, nil
+}
+
+// getLoadBalancerName returns the OVN load balancer name used for a load balancer's listen address and protocol.
+func (n *ovn) getLoadBalancerName(listenAddress net.IP, protocol string) openvswitch.OVNLoadBalancer {
+	return openvswitch.OVNLoadBalancer(fmt.Sprintf("%s-lb-backends-%s-%s", n.getNetworkPrefix(), listenAddress.String(), protocol))
+}
+
+// getLoadBalancerNames returns the OVN load balancer names used for a load balancer's listen address.
+func (n *ovn) getLoadBalancerNames(listenAddress net.IP) []openvswitch.OVNLoadBalancer {
+	return []openvswitch.OVNLoadBalancer{
+		n.getLoadBalancerName(listenAddress, "tcp"),
+		n.getLoadBalancerName(listenAddress, "udp"),
+	}
+}
+
+// loadBalancerVIPs returns the OVN load balancer virtual IPs of each protocol for a load balancer.
+func (n *ovn) loadBalancerVIPs(listenAddress string, loadBalancer *api.NetworkLoadBalancerPut) (map[string][]openvswitch.OVNLoadBalancerVIP, error) {
+	listenIP := net.ParseIP(listenAddress)
+
+	portMaps, err := n.loadBalancerValidate(listenIP, loadBalancer)
+	if err != nil {
+		return nil, err
+	}
+
+	vips := map[string][]openvswitch.OVNLoadBalancerVIP{}
+	for _, portMap := range portMaps {
+		for i, listenPort := range portMap.listenPorts {
+			vip := openvswitch.OVNLoadBalancerVIP{
+				ListenAddress: listenIP,
+				ListenPort:    listenPort,
+			}
+
+			for _, target := range portMap.targets {
+				targetPort := listenPort
+				if len(target.ports) == 1 {
+					targetPort = target.ports[0]
+				} else if len(target.ports) > 1 {
+					targetPort = target.ports[i]
+				}
+
+				vip.Targets = append(vip.Targets, openvswitch.OVNLoadBalancerTarget{
+					Address: target.address,
+					Port:    targetPort,
+				})
+			}
+
+			vips[portMap.protocol] = append(vips[portMap.protocol], vip)
+		}
+	}
+
+	return vips, nil
+}
+
+// loadBalancerHealthCheck returns the health check settings of a load balancer, or nil if health checks are
+// disabled. The backends are checked through the switch ports that currently have their target addresses.
+func (n *ovn) loadBalancerHealthCheck(client *openvswitch.OVN, loadBalancer *api.NetworkLoadBalancerPut) (*openvswitch.OVNLoadBalancerHealthCheck, error) {
+	if !shared.IsTrue(loadBalancer.Config["healthcheck"]) {
+		return nil, nil
+	}
+
+	routerIntPortIPv4, _, err := net.ParseCIDR(n.getRouterIntPortIPv4Net())
+	if err != nil {
+		return nil, fmt.Errorf("Network has no IPv4 address to send health checks from")
+	}
+
+	healthCheck := &openvswitch.OVNLoadBalancerHealthCheck{
+		Interval:      5,
+		Timeout:       20,
+		FailureCount:  3,
+		SuccessCount:  3,
+		TargetPorts:   map[string]openvswitch.OVNSwitchPort{},
+		SourceAddress: routerIntPortIPv4,
+	}
+
+	for k, setting := range map[string]*uint64{
+		"healthcheck.interval":      &healthCheck.Interval,
+		"healthcheck.timeout":       &healthCheck.Timeout,
+		"healthcheck.failure_count": &healthCheck.FailureCount,
+		"healthcheck.success_count": &healthCheck.SuccessCount,
+	} {
+		if loadBalancer.Config[k] == "" {
+			continue
+		}
+
+		*setting, err = strconv.ParseUint(loadBalancer.Config[k], 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid value for option %q", k)
+		}
+	}
+
+	portIPs, err := client.LogicalSwitchPortIPs(fmt.Sprintf("%s-", n.getIntSwitchInstancePortPrefix()))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed getting instance port IPs")
+	}
+
+	for _, backend := range loadBalancer.Backends {
+		targetAddress := net.ParseIP(backend.TargetAddress)
+
+		for portName, ips := range portIPs {
+			for _, ip := range ips {
+				if ip.Equal(targetAddress) {
+					healthCheck.TargetPorts[targetAddress.String()] = portName
+				}
+			}
+		}
+	}
+
+	return healthCheck, nil
+}
+
+// loadBalancerApply applies a network load balancer to the logical router using an OVN load balancer per
+// protocol, along with its health checks.
+func (n *ovn) loadBalancerApply(client *openvswitch.OVN, listenAddress string, loadBalancer *api.NetworkLoadBalancerPut) error {
+	listenIP := net.ParseIP(listenAddress)
+
+	vips, err := n.loadBalancerVIPs(listenAddress, loadBalancer)
+	if err != nil {
+		return err
+	}
+
+	healthCheck, err := n.loadBalancerHealthCheck(client, loadBalancer)
+	if err != nil {
+		return err
+	}
+
+	for _, protocol := range []string{"tcp", "udp"} {
+		err = client.LoadBalancerApply(n.getLoadBalancerName(listenIP, protocol), n.getRouterName(), n.getIntSwitchName(), protocol, vips[protocol]...)
+		if err != nil {
+			return errors.Wrapf(err, "Failed applying %s load balancer", protocol)
+		}
+
+		if healthCheck != nil && len(vips[protocol]) > 0 {
+			err = client.LoadBalancerHealthCheckApply(n.getLoadBalancerName(listenIP, protocol), healthCheck, vips[protocol]...)
+			if err != nil {
+				return errors.Wrapf(err, "Failed applying %s load balancer health checks", protocol)
+			}
+		}
+	}
+
+	return nil
+}
+
+// loadBalancerHealthChecksRefresh re-applies the health checks of the network's load balancers that have a
+// backend on one of the supplied IPs, so that the instance port that was added with them gets checked.
+func (n *ovn) loadBalancerHealthChecksRefresh(client *openvswitch.OVN, ips []net.IP) error {
+	loadBalancers, err := n.state.Cluster.GetNetworkLoadBalancers(n.id)
+	if err != nil {
+		return err
+	}
+
+	for _, loadBalancer := range loadBalancers {
+		if !shared.IsTrue(loadBalancer.Config["healthcheck"]) {
+			continue
+		}
+
+		hasBackend := false
+		for _, backend := range loadBalancer.Backends {
+			for _, ip := range ips {
+				if ip.Equal(net.ParseIP(backend.TargetAddress)) {
+					hasBackend = true
+				}
+			}
+		}
+
+		if !hasBackend {
+			continue
+		}
+
+		listenIP := net.ParseIP(loadBalancer.ListenAddress)
+
+		vips, err := n.loadBalancerVIPs(loadBalancer.ListenAddress, &loadBalancer.NetworkLoadBalancerPut)
+		if err != nil {
+			return err
+		}
+
+		healthCheck, err := n.loadBalancerHealthCheck(client, &loadBalancer.NetworkLoadBalancerPut)
+		if err != nil {
+			return err
+		}
+
+		for _, protocol := range []string{"tcp", "udp"} {
+			if len(vips[protocol]) == 0 {
+				continue
+			}
+
+			err = client.LoadBalancerHealthCheckApply(n.getLoadBalancerName(listenIP, protocol), healthCheck, vips[protocol]...)
+			if err != nil {
+				return errors.Wrapf(err, "Failed applying %s load balancer health checks", protocol)
+			}
+		}
+	}
+
+	return nil
+}
+
+// loadBalancerAllocateListenAddress allocates a free IPv4 listen address from the parent network's OVN ranges.
+// Addresses used by the routers, forwards and load balancers of any network are considered allocated.
+func (n *ovn) loadBalancerAllocateListenAddress() (net.IP, error) {
+	parentNet, err := LoadByName(n.state, project.Default, n.config["parent"])
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed loading parent network")
+	}
+
+	parentNetConf := parentNet.Config()
+	if parentNetConf["ipv4.ovn.ranges"] == "" {
+		return nil, fmt.Errorf(`Missing required "ipv4.ovn.ranges" config key on parent network`)
+	}
+
+	// The OVN ranges are within the subnet of a parent bridge or the gateway subnet of a parent physical network.
+	parentSubnetKey := "ipv4.address"
+	if parentNet.Type() == "physical" {
+		parentSubnetKey = "ipv4.gateway"
+	}
+
+	_, parentIPv4Net, err := net.ParseCIDR(parentNetConf[parentSubnetKey])
+	if err != nil {
+		return nil, fmt.Errorf("Missing required %q config key on parent network", parentSubnetKey)
+	}
+
+	ipRanges, err := parseIPRanges(parentNetConf["ipv4.ovn.ranges"], parentIPv4Net)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse parent IPv4 OVN ranges")
+	}
+
+	var allAllocated []net.IP
+	err = n.state.Cluster.Transaction(func(tx *db.ClusterTx) error {
+		allAllocated, _, err = n.parentAllAllocatedIPs(tx, parentNet.Name())
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get all allocated IPs for parent")
+	}
+
+	forwardListenAddresses, err := n.state.Cluster.GetNetworkForwardListenAddresses()
+	if err != nil {
+		return nil, err
+	}
+
+	loadBalancerListenAddresses, err := n.state.Cluster.GetNetworkLoadBalancerListenAddresses()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, networkListenAddresses := range []map[int64][]string{forwardListenAddresses, loadBalancerListenAddresses} {
+		for _, listenAddresses := range networkListenAddresses {
+			for _, listenAddress := range listenAddresses {
+				allAllocated = append(allAllocated, net.ParseIP(listenAddress))
+			}
+		}
+	}
+
+	listenIP, err := n.parentAllocateIP(ipRanges, allAllocated)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to allocate parent IPv4 address")
+	}
+
+	return listenIP, nil
+}
+
+// LoadBalancerCreate creates a network load balancer and returns its listen address. If no listen address is
+// specified, one is allocated from the parent network's OVN ranges.
+// The OVN northbound database is shared by all nodes, so cluster notifications are not needed.
+func (n *ovn) LoadBalancerCreate(loadBalancer api.NetworkLoadBalancersPost, clusterNotification bool) (net.IP, error) {
+	if clusterNotification {
+		return nil, nil
+	}
+
+	var listenIP net.IP
+	if loadBalancer.ListenAddress == "" {
+		var err error
+		listenIP, err = n.loadBalancerAllocateListenAddress()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		listenIP = net.ParseIP(loadBalancer.ListenAddress)
+		if listenIP == nil {
+			return nil, fmt.Errorf("Invalid listen address %q", loadBalancer.ListenAddress)
+		}
+	}
+
+	// Store the listen address in its canonical form so it can be used in URLs.
+	loadBalancer.ListenAddress = listenIP.String()
+
+	_, err := n.loadBalancerValidate(listenIP, &loadBalancer.NetworkLoadBalancerPut)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := n.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	loadBalancerID, err := n.state.Cluster.CreateNetworkLoadBalancer(n.id, &loadBalancer)
+	if err != nil {
+		return nil, err
+	}
+
+	revert.Add(func() {
+		n.state.Cluster.DeleteNetworkLoadBalancer(loadBalancerID)
+		client.LoadBalancerDelete(n.getLoadBalancerNames(listenIP)...)
+	})
+
+	err = n.loadBalancerApply(client, loadBalancer.ListenAddress, &loadBalancer.NetworkLoadBalancerPut)
+	if err != nil {
+		return nil, err
+	}
+
+	revert.Success()
+	return listenIP, nil
+}
+
+// LoadBalancerUpdate updates a network load balancer.
+func (n *ovn) LoadBalancerUpdate(listenAddress string, newLoadBalancer api.NetworkLoadBalancerPut, clusterNotification bool) error {
+	if clusterNotification {
+		return nil
+	}
+
+	loadBalancerID, curLoadBalancer, err := n.state.Cluster.GetNetworkLoadBalancer(n.id, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	_, err = n.loadBalancerValidate(net.ParseIP(curLoadBalancer.ListenAddress), &newLoadBalancer)
+	if err != nil {
+		return err
+	}
+
+	client, err := n.getClient()
+	if err != nil {
+		return err
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	err = n.state.Cluster.UpdateNetworkLoadBalancer(loadBalancerID, &newLoadBalancer)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() {
+		oldLoadBalancer := curLoadBalancer.Writable()
+		n.state.Cluster.UpdateNetworkLoadBalancer(loadBalancerID, &oldLoadBalancer)
+		n.loadBalancerApply(client, curLoadBalancer.ListenAddress, &oldLoadBalancer)
+	})
+
+	err = n.loadBalancerApply(client, curLoadBalancer.ListenAddress, &newLoadBalancer)
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// LoadBalancerDelete deletes a network load balancer.
+func (n *ovn) LoadBalancerDelete(listenAddress string, clusterNotification bool) error {
+	if clusterNotification {
+		return nil
+	}
+
+	loadBalancerID, loadBalancer, err := n.state.Cluster.GetNetworkLoadBalancer(n.id, listenAddress)
+	if err != nil {
+		return err
+	}
+
+	client, err := n.getClient()
+	if err != nil {
+		return err
+	}
+
+	err = client.LoadBalancerDelete(n.getLoadBalancerNames(net.ParseIP(loadBalancer.ListenAddress))...)
+	if err != nil {
+		return err
+	}
+
+	return n.state.Cluster.DeleteNetworkLoadBalancer(loadBalancerID)
+}
+
+// getRouterPeerPortName returns the name of the router port used to peer with the network with the given ID.
+func (n *ovn) getRouterPeerPortName(peerNetworkID int64) openvswitch.OVNRouterPort {
+	return openvswitch.OVNRouterPort(fmt.Sprintf("%s-lrp-peer-net%d", n.getRouterName(), peerNetworkID))
+}
+
+// peerRouterPortIPs returns the router's internal port addresses, which are also used on the peered router ports.
+func (n *ovn) peerRouterPortIPs() []*net.IPNet {
+	ips := []*net.IPNet{}
+
+	for _, key := range []string{"ipv4.address", "ipv6.address"} {
+		ip, subnet, err := net.ParseCIDR(n.config[key])
+		if err != nil {
+			continue
+		}
+
+		ips = append(ips, &net.IPNet{IP: ip, Mask: subnet.Mask})
+	}
+
+	return ips
+}
+
+// peerSubnets returns the network's subnets, which are routed to it by its peered networks.
+func (n *ovn) peerSubnets() []*net.IPNet {
+	subnets := []*net.IPNet{}
+
+	for _, key := range []string{"ipv4.address", "ipv6.address"} {
+		_, subnet, err := net.ParseCIDR(n.config[key])
+		if err != nil {
+			continue
+		}
+
+		subnets = append(subnets, subnet)
+	}
+
+	return subnets
+}
+
+// peeringOpts returns the settings of the router peering between this network and the target network.
+func (n *ovn) peeringOpts(targetNet *ovn) (*openvswitch.OVNRouterPeering, error) {
+	routerMAC, err := n.getRouterMAC()
+	if err != nil {
+		return nil, err
+	}
+
+	targetRouterMAC, err := targetNet.getRouterMAC()
+	if err != nil {
+		return nil, err
+	}
+
+	return &openvswitch.OVNRouterPeering{
+		LocalRouterName:    n.getRouterName(),
+		LocalRouterPort:    n.getRouterPeerPortName(targetNet.ID()),
+		LocalRouterPortMAC: routerMAC,
+		LocalRouterPortIPs: n.peerRouterPortIPs(),
+		LocalRouterRoutes:  targetNet.peerSubnets(),
+
+		TargetRouterName:    targetNet.getRouterName(),
+		TargetRouterPort:    targetNet.getRouterPeerPortName(n.ID()),
+		TargetRouterPortMAC: targetRouterMAC,
+		TargetRouterPortIPs: targetNet.peerRouterPortIPs(),
+		TargetRouterRoutes:  n.peerSubnets(),
+	}, nil
+}
+
+// peerTargetNetwork loads the OVN network with the given ID.
+func (n *ovn) peerTargetNetwork(networkID int64) (*ovn, error) {
+	networkName, projectName, err := n.state.Cluster.GetNetworkNameAndProjectWithID(networkID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed loading peered network")
+	}
+
+	targetNet, err := LoadByName(n.state, projectName, networkName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed loading peered network %q in project %q", networkName, projectName)
+	}
+
+	ovnNet, ok := targetNet.(*ovn)
+	if !ok {
+		return nil, fmt.Errorf("Peered network %q in project %q is not an OVN network", networkName, projectName)
+	}
+
+	return ovnNet, nil
+}
+
+// peerings returns the settings of the router peerings between this network and the networks it is peered with.
+func (n *ovn) peerings() ([]*openvswitch.OVNRouterPeering, error) {
+	networkIDs, err := n.state.Cluster.GetNetworkPeersTargetingNetwork(n.id)
+	if err != nil {
+		return nil, err
+	}
+
+	peerings := make([]*openvswitch.OVNRouterPeering, 0, len(networkIDs))
+	for _, networkID := range networkIDs {
+		targetNet, err := n.peerTargetNetwork(networkID)
+		if err != nil {
+			return nil, err
+		}
+
+		opts, err := n.peeringOpts(targetNet)
+		if err != nil {
+			return nil, err
+		}
+
+		peerings = append(peerings, opts)
+	}
+
+	return peerings, nil
+}
+
+// peeringsReplace removes the supplied router peerings and applies the current ones.
+func (n *ovn) peeringsReplace(oldPeerings []*openvswitch.OVNRouterPeering) error {
+	client, err := n.getClient()
+	if err != nil {
+		return err
+	}
+
+	for _, peering := range oldPeerings {
+		err = client.LogicalRouterPeeringDelete(*peering)
+		if err != nil {
+			return err
+		}
+	}
+
+	peerings, err := n.peerings()
+	if err != nil {
+		return err
+	}
+
+	for _, peering := range peerings {
+		err = client.LogicalRouterPeeringApply(*peering)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// peerValidate checks the peering's modifiable fields are valid.
+func (n *ovn) peerValidate(peer *api.NetworkPeerPut) error {
+	for k := range peer.Config {
+		if !strings.HasPrefix(k, "user.") {
+			return fmt.Errorf("Invalid option %q", k)
+		}
+	}
+
+	return nil
+}
+
+// PeerCreate creates a network peering. The routers of both networks are linked once the target network has a
+// mutual peering back to this network.
+func (n *ovn) PeerCreate(peer api.NetworkPeersPost) error {
+	err := n.peerValidate(&peer.NetworkPeerPut)
+	if err != nil {
+		return err
+	}
+
+	if peer.Name == "" {
+		return fmt.Errorf("Peering name is required")
+	}
+
+	if peer.TargetProject == n.project && peer.TargetNetwork == n.name {
+		return fmt.Errorf("A network cannot be peered with itself")
+	}
+
+	targetNet, err := LoadByName(n.state, peer.TargetProject, peer.TargetNetwork)
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading target network %q in project %q", peer.TargetNetwork, peer.TargetProject)
+	}
+
+	targetOVNNet, ok := targetNet.(*ovn)
+	if !ok {
+		return fmt.Errorf("Target network %q in project %q is not an OVN network", peer.TargetNetwork, peer.TargetProject)
+	}
+
+	// Instances on both networks communicate directly, so their subnets must not overlap.
+	for _, subnet := range n.peerSubnets() {
+		for _, targetSubnet := range targetOVNNet.peerSubnets() {
+			if subnet.Contains(targetSubnet.IP) || targetSubnet.Contains(subnet.IP) {
+				return fmt.Errorf("Target network subnet %q overlaps with network subnet %q", targetSubnet.String(), subnet.String())
+			}
+		}
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	peerID, targetNetworkID, err := n.state.Cluster.CreateNetworkPeer(n.id, &peer)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { n.state.Cluster.DeleteNetworkPeer(n.id, peerID) })
+
+	// Link the routers if the target network has a mutual peering.
+	if targetNetworkID > -1 {
+		opts, err := n.peeringOpts(targetOVNNet)
+		if err != nil {
+			return err
+		}
+
+		client, err := n.getClient()
+		if err != nil {
+			return err
+		}
+
+		err = client.LogicalRouterPeeringApply(*opts)
+		if err != nil {
+			return errors.Wrapf(err, "Failed applying OVN router peering")
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// PeerUpdate updates a network peering.
+func (n *ovn) PeerUpdate(peerName string, newPeer api.NetworkPeerPut) error {
+	err := n.peerValidate(&newPeer)
+	if err != nil {
+		return err
+	}
+
+	peerID, _, err := n.state.Cluster.GetNetworkPeer(n.id, peerName)
+	if err != nil {
+		return err
+	}
+
+	return n.state.Cluster.UpdateNetworkPeer(peerID, &newPeer)
+}
+
+// PeerDelete deletes a network peering. The routers of both networks are unlinked and the mutual peering on the
+// target network goes back to pending.
+func (n *ovn) PeerDelete(peerName string) error {
+	peerID, _, err := n.state.Cluster.GetNetworkPeer(n.id, peerName)
+	if err != nil {
+		return err
+	}
+
+	targetNetworkID, err := n.state.Cluster.GetNetworkPeerTargetNetworkID(peerID)
+	if err != nil {
+		return err
+	}
+
+	if targetNetworkID > -1 {
+		targetNet, err := n.peerTargetNetwork(targetNetworkID)
+		if err != nil {
+			return err
+		}
+
+		opts, err := n.peeringOpts(targetNet)
+		if err != nil {
+			return err
+		}
+
+		client, err := n.getClient()
+		if err != nil {
+			return err
+		}
+
+		err = client.LogicalRouterPeeringDelete(*opts)
+		if err != nil {
+			return errors.Wrapf(err, "Failed removing OVN router peering")
+		}
+	}
+
+	return n.state.Cluster.DeleteNetworkPeer(n.id, peerID)
+}
+
+// ZoneAddresses returns the addresses of the instance NICs connected to the network. Static addresses come from
+// the NIC config and dynamic ones from the OVN logical switch ports, which are shared by all cluster members.
+func (n *ovn) ZoneAddresses() ([]ZoneAddress, error) {
+	insts, err := instance.LoadFromAllProjects(n.state)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := n.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := []ZoneAddress{}
+	networkProjects := map[string]string{}
+
+	for _, inst := range insts {
+		// Only instances in projects using the network's project can be connected to the network.
+		networkProject, found := networkProjects[inst.Project()]
+		if !found {
+			networkProject, err = project.NetworkProject(n.state.Cluster, inst.Project())
+			if err != nil {
+				return nil, err
+			}
+
+			networkProjects[inst.Project()] = networkProject
+		}
+
+		if networkProject != n.project {
+			continue
+		}
+
+		for devName, dev := range inst.ExpandedDevices() {
+			if dev["type"] != "nic" || dev["network"] != n.name {
+				continue
+			}
+
+			nicType, err := nictype.NICType(n.state, inst.Project(), dev)
+			if err != nil || nicType != "ovn" {
+				continue
+			}
+
+			// Add the static addresses.
+			hasStatic := map[bool]bool{}
+			for _, address := range []string{dev["ipv4.address"], dev["ipv6.address"]} {
+				ip := net.ParseIP(address)
+				if ip == nil {
+					continue
+				}
+
+				hasStatic[ip.To4() != nil] = true
+				addresses = append(addresses, ZoneAddress{Hostname: inst.Name(), Project: inst.Project(), Address: ip.String()})
+			}
+
+			// Add the dynamic addresses of running NICs for the IP families without a static address.
+			dynamicIPs, err := client.LogicalSwitchPortDynamicIPs(n.getInstanceDevicePortName(inst.ID(), devName))
+			if err != nil {
+				continue
+			}
+
+			for _, ip := range dynamicIPs {
+				if hasStatic[ip.To4() != nil] {
+					continue
+				}
+
+				addresses = append(addresses, ZoneAddress{Hostname: inst.Name(), Project: inst.Project(), Address: ip.String()})
+			}
+		}
+	}
+
+	return addresses, nil
 }
@@ -0,0 +1,259 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/validate"
+)
+
+// physical represents a LXD physical network.
+type physical struct {
+	common
+}
+
+// Validate network config.
+func (n *physical) Validate(config map[string]string) error {
+	rules := map[string]func(value string) error{
+		"parent":          validate.Required(validInterfaceName),
+		"mtu":             validate.Optional(validate.IsNetworkMTU),
+		"vlan":            validate.Optional(validate.IsNetworkVLAN),
+		"ipv4.gateway":    validate.Optional(validate.IsNetworkAddressCIDRV4),
+		"ipv6.gateway":    validate.Optional(validate.IsNetworkAddressCIDRV6),
+		"ipv4.ovn.ranges": validate.Optional(validate.IsNetworkRangeV4List),
+		"ipv6.ovn.ranges": validate.Optional(validate.IsNetworkRangeV6List),
+		"dns.nameservers": validate.Optional(func(value string) error {
+			// Nameservers can be a mix of IPv4 and IPv6 addresses.
+			for _, nameserver := range strings.Split(value, ",") {
+				err := validate.IsNetworkAddress(strings.TrimSpace(nameserver))
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}),
+
+		// Volatile keys populated automatically as needed.
+		"volatile.last_state.created": validate.Optional(validate.IsBool),
+	}
+
+	err := n.validate(config, rules)
+	if err != nil {
+		return err
+	}
+
+	// Check the OVN ranges are within the gateway subnets.
+	for _, ipVersion := range []string{"ipv4", "ipv6"} {
+		rangesKey := fmt.Sprintf("%s.ovn.ranges", ipVersion)
+		if config[rangesKey] == "" {
+			continue
+		}
+
+		gatewayKey := fmt.Sprintf("%s.gateway", ipVersion)
+		_, gatewayNet, err := net.ParseCIDR(config[gatewayKey])
+		if err != nil {
+			return fmt.Errorf("%q must be used in conjunction with %q", rangesKey, gatewayKey)
+		}
+
+		_, err = parseIPRanges(config[rangesKey], gatewayNet)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete deletes a network.
+func (n *physical) Delete(clusterNotification bool) error {
+	n.logger.Debug("Delete", log.Ctx{"clusterNotification": clusterNotification})
+
+	err := n.Stop()
+	if err != nil {
+		return err
+	}
+
+	return n.common.delete(clusterNotification)
+}
+
+// Rename renames a network.
+func (n *physical) Rename(newName string) error {
+	n.logger.Debug("Rename", log.Ctx{"newName": newName})
+
+	// Sanity checks.
+	inUse, err := n.IsUsed()
+	if err != nil {
+		return err
+	}
+
+	if inUse {
+		return fmt.Errorf("The network is currently in use")
+	}
+
+	// Rename common steps.
+	err = n.common.rename(newName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Start creates the VLAN interface on top of the parent interface if needed and applies the MTU.
+func (n *physical) Start() error {
+	if n.status == api.NetworkStatusPending {
+		return fmt.Errorf("Cannot start pending network")
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	hostName := GetHostDevice(n.config["parent"], n.config["vlan"])
+	created := false
+
+	if !shared.PathExists(fmt.Sprintf("/sys/class/net/%s", hostName)) {
+		if n.config["vlan"] == "" {
+			return fmt.Errorf("Parent interface %q not found", n.config["parent"])
+		}
+
+		// Bring the parent interface up so we can add a VLAN to it.
+		_, err := shared.RunCommand("ip", "link", "set", "dev", n.config["parent"], "up")
+		if err != nil {
+			return errors.Wrapf(err, "Failed to bring up parent interface %q", n.config["parent"])
+		}
+
+		// Add VLAN interface on top of parent.
+		_, err = shared.RunCommand("ip", "link", "add", "link", n.config["parent"], "name", hostName, "up", "type", "vlan", "id", n.config["vlan"])
+		if err != nil {
+			return errors.Wrapf(err, "Failed to create VLAN interface %q", hostName)
+		}
+
+		created = true
+		revert.Add(func() { shared.RunCommand("ip", "link", "delete", "dev", hostName) })
+	}
+
+	if n.config["mtu"] != "" {
+		_, err := shared.RunCommand("ip", "link", "set", "dev", hostName, "mtu", n.config["mtu"])
+		if err != nil {
+			return errors.Wrapf(err, "Failed setting MTU %q on %q", n.config["mtu"], hostName)
+		}
+	}
+
+	// Record that we created the VLAN interface so that it is removed on stop. This is a node-local key,
+	// and it is not cleared if already set so that a previously created interface is still removed after a
+	// LXD restart.
+	if created && !shared.IsTrue(n.config["volatile.last_state.created"]) {
+		err := n.state.Cluster.UpdateNetworkLocalConfig(n.id, map[string]string{"volatile.last_state.created": "true"})
+		if err != nil {
+			return errors.Wrapf(err, "Failed saving volatile config")
+		}
+
+		n.config["volatile.last_state.created"] = "true"
+	}
+
+	revert.Success()
+	return nil
+}
+
+// Stop removes the VLAN interface if it was created by Start.
+func (n *physical) Stop() error {
+	if !shared.IsTrue(n.config["volatile.last_state.created"]) {
+		return nil
+	}
+
+	hostName := GetHostDevice(n.config["parent"], n.config["vlan"])
+	if shared.PathExists(fmt.Sprintf("/sys/class/net/%s", hostName)) {
+		_, err := shared.RunCommand("ip", "link", "delete", "dev", hostName)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to delete VLAN interface %q", hostName)
+		}
+	}
+
+	err := n.state.Cluster.UpdateNetworkLocalConfig(n.id, map[string]string{"volatile.last_state.created": ""})
+	if err != nil {
+		return errors.Wrapf(err, "Failed saving volatile config")
+	}
+
+	delete(n.config, "volatile.last_state.created")
+
+	return nil
+}
+
+// Update updates the network. Accepts notification boolean indicating if this update request is coming from a
+// cluster notification, in which case do not update the database, just apply local changes needed.
+func (n *physical) Update(newNetwork api.NetworkPut, targetNode string, clusterNotification bool) error {
+	n.logger.Debug("Update", log.Ctx{"clusterNotification": clusterNotification, "newNetwork": newNetwork})
+
+	// Keep the volatile record of whether we created the VLAN interface, as it is not part of the request.
+	if n.config["volatile.last_state.created"] != "" {
+		if newNetwork.Config == nil {
+			newNetwork.Config = map[string]string{}
+		}
+
+		newNetwork.Config["volatile.last_state.created"] = n.config["volatile.last_state.created"]
+	}
+
+	dbUpdateNeeeded, changedKeys, oldNetwork, err := n.common.configChanged(newNetwork)
+	if err != nil {
+		return err
+	}
+
+	if !dbUpdateNeeeded {
+		return nil // Nothing changed.
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Stop the network with the old config if the host interface settings are changing.
+	hostChanged := false
+	for _, k := range []string{"parent", "vlan", "mtu"} {
+		if shared.StringInSlice(k, changedKeys) {
+			hostChanged = true
+			break
+		}
+	}
+
+	if hostChanged {
+		err = n.Stop()
+		if err != nil {
+			return err
+		}
+
+		delete(newNetwork.Config, "volatile.last_state.created")
+	}
+
+	// Define a function which reverts everything.
+	revert.Add(func() {
+		// Reset changes to all nodes and database.
+		n.common.update(oldNetwork, targetNode, clusterNotification)
+
+		if hostChanged {
+			n.Start()
+		}
+	})
+
+	// Apply changes to database.
+	err = n.common.update(newNetwork, targetNode, clusterNotification)
+	if err != nil {
+		return err
+	}
+
+	if hostChanged {
+		err = n.Start()
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+	return nil
+}
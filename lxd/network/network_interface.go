@@ -9,10 +9,17 @@ import (
 	"github.com/lxc/lxd/shared/api"
 )
 
+// ZoneAddress represents an address of an instance or DHCP reservation published in the network zones.
+type ZoneAddress struct {
+	Hostname string
+	Project  string
+	Address  string
+}
+
 // Network represents a LXD network.
 type Network interface {
 	// Load.
-	init(state *state.State, id int64, name string, netType string, description string, config map[string]string, status string)
+	init(state *state.State, id int64, projectName string, name string, netType string, description string, config map[string]string, status string)
 	fillConfig(config map[string]string) error
 
 	// Config.
@@ -20,6 +27,7 @@ type Network interface {
 	Validate(config map[string]string) error
 	ID() int64
 	Name() string
+	Project() string
 	Type() string
 	Status() string
 	Config() map[string]string
@@ -37,4 +45,26 @@ type Network interface {
 	Update(newNetwork api.NetworkPut, targetNode string, clusterNotification bool) error
 	HandleHeartbeat(heartbeatData *cluster.APIHeartbeat) error
 	Delete(clusterNotification bool) error
+
+	// Address Forwards.
+	ForwardCreate(forward api.NetworkForwardsPost, clusterNotification bool) error
+	ForwardUpdate(listenAddress string, newForward api.NetworkForwardPut, clusterNotification bool) error
+	ForwardDelete(listenAddress string, clusterNotification bool) error
+
+	// Load Balancers.
+	LoadBalancerCreate(loadBalancer api.NetworkLoadBalancersPost, clusterNotification bool) (net.IP, error)
+	LoadBalancerUpdate(listenAddress string, newLoadBalancer api.NetworkLoadBalancerPut, clusterNotification bool) error
+	LoadBalancerDelete(listenAddress string, clusterNotification bool) error
+
+	// Peerings.
+	PeerCreate(peer api.NetworkPeersPost) error
+	PeerUpdate(peerName string, newPeer api.NetworkPeerPut) error
+	PeerDelete(peerName string) error
+
+	// Network zones.
+	ZoneAddresses() ([]ZoneAddress, error)
+
+	// DHCP Reservations.
+	LeaseReservationCreate(reservation api.NetworkLeasesPost, clusterNotification bool) error
+	LeaseReservationDelete(hwaddr string, clusterNotification bool) error
 }
@@ -69,24 +69,30 @@ func networkValidPort(value string) error {
 }
 
 // IsInUseByInstance indicates if network is referenced by an instance's NIC devices.
-// Checks if the device's parent or network properties match the network name.
-func IsInUseByInstance(s *state.State, c instance.Instance, networkName string) (bool, error) {
-	return isInUseByDevices(s, c.ExpandedDevices(), networkName)
+// Checks if the device's parent or network properties match the network project and name.
+func IsInUseByInstance(s *state.State, c instance.Instance, networkProjectName string, networkName string) (bool, error) {
+	return isInUseByDevices(s, c.Project(), c.ExpandedDevices(), networkProjectName, networkName)
 }
 
 // IsInUseByProfile indicates if network is referenced by a profile's NIC devices.
-// Checks if the device's parent or network properties match the network name.
-func IsInUseByProfile(s *state.State, profile api.Profile, networkName string) (bool, error) {
-	return isInUseByDevices(s, deviceConfig.NewDevices(profile.Devices), networkName)
+// Checks if the device's parent or network properties match the network project and name.
+func IsInUseByProfile(s *state.State, profileProjectName string, profile api.Profile, networkProjectName string, networkName string) (bool, error) {
+	return isInUseByDevices(s, profileProjectName, deviceConfig.NewDevices(profile.Devices), networkProjectName, networkName)
 }
 
-func isInUseByDevices(s *state.State, devices deviceConfig.Devices, networkName string) (bool, error) {
+func isInUseByDevices(s *state.State, projectName string, devices deviceConfig.Devices, networkProjectName string, networkName string) (bool, error) {
+	// Managed networks referenced by the "network" property are looked up in the effective network project.
+	devNetworkProjectName, err := project.NetworkProject(s.Cluster, projectName)
+	if err != nil {
+		return false, err
+	}
+
 	for _, d := range devices {
 		if d["type"] != "nic" {
 			continue
 		}
 
-		nicType, err := nictype.NICType(s, d)
+		nicType, err := nictype.NICType(s, projectName, d)
 		if err != nil {
 			return false, err
 		}
@@ -95,7 +101,7 @@ func isInUseByDevices(s *state.State, devices deviceConfig.Devices, networkName
 			continue
 		}
 
-		if d["network"] != "" && d["network"] == networkName {
+		if d["network"] != "" && d["network"] == networkName && devNetworkProjectName == networkProjectName {
 			return true, nil
 		}
 
@@ -103,7 +109,8 @@ func isInUseByDevices(s *state.State, devices deviceConfig.Devices, networkName
 			continue
 		}
 
-		if GetHostDevice(d["parent"], d["vlan"]) == networkName {
+		// Host interfaces referenced by the "parent" property can only be networks in the default project.
+		if networkProjectName == project.Default && GetHostDevice(d["parent"], d["vlan"]) == networkName {
 			return true, nil
 		}
 	}
@@ -239,11 +246,14 @@ func UpdateDNSMasqStatic(s *state.State, networkName string) error {
 	dnsmasq.ConfigMutex.Lock()
 	defer dnsmasq.ConfigMutex.Unlock()
 
+	// Regenerate the network zones as the static allocations and instance names they come from have changed.
+	s.DNS.InvalidateZones()
+
 	// Get all the networks.
 	var networks []string
 	if networkName == "" {
 		var err error
-		networks, err = s.Cluster.GetNetworks()
+		networks, err = s.Cluster.GetNetworks(project.Default)
 		if err != nil {
 			return err
 		}
@@ -267,7 +277,7 @@ func UpdateDNSMasqStatic(s *state.State, networkName string) error {
 				continue
 			}
 
-			nicType, err := nictype.NICType(s, d)
+			nicType, err := nictype.NICType(s, inst.Project(), d)
 			if err != nil || nicType != "bridged" {
 				continue
 			}
@@ -322,7 +332,7 @@ func UpdateDNSMasqStatic(s *state.State, networkName string) error {
 			continue
 		}
 
-		n, err := LoadByName(s, network)
+		n, err := LoadByName(s, project.Default, network)
 		if err != nil {
 			return err
 		}
@@ -390,6 +400,33 @@ func UpdateDNSMasqStatic(s *state.State, networkName string) error {
 			}
 		}
 
+		// Add the DHCP reservations.
+		reservations, err := s.Cluster.GetNetworkLeaseReservations(n.ID())
+		if err != nil {
+			return err
+		}
+
+		for _, reservation := range reservations {
+			// Instance entries take precedence over reservations using the same MAC.
+			duplicate := false
+			for _, entry := range entries {
+				if strings.EqualFold(entry[0], reservation.Hwaddr) {
+					logger.Errorf("Duplicate MAC detected: %s and DHCP reservation", project.Instance(entry[1], entry[2]))
+					duplicate = true
+					break
+				}
+			}
+
+			if duplicate {
+				continue
+			}
+
+			err = dnsmasq.UpdateReservationEntry(network, config, reservation.Hwaddr, reservation.Hostname, reservation.IPv4Address, reservation.IPv6Address)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Signal dnsmasq.
 		err = dnsmasq.Kill(network, true)
 		if err != nil {
@@ -692,7 +729,7 @@ func GetLeaseAddresses(s *state.State, networkName string, hwaddr string) ([]api
 		return addresses, nil
 	}
 
-	dbInfo, err := LoadByName(s, networkName)
+	dbInfo, err := LoadByName(s, project.Default, networkName)
 	if err != nil {
 		return nil, err
 	}
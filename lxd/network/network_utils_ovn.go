@@ -8,26 +8,28 @@ import (
 )
 
 // OVNInstanceDevicePortAdd adds a logical port to the OVN network's internal switch and returns the logical
-// port name for use linking an OVS port on the integration bridge to the logical switch port.
-func OVNInstanceDevicePortAdd(network Network, instanceID int, deviceName string, mac net.HardwareAddr, ips []net.IP) (openvswitch.OVNSwitchPort, error) {
+// port name for use linking an OVS port on the integration bridge to the logical switch port. The rules of the
+// specified network ACLs are applied to the port and the internal routes are routed to it.
+func OVNInstanceDevicePortAdd(network Network, instanceID int, deviceName string, mac net.HardwareAddr, ips []net.IP, aclNames []string, internalRoutes []*net.IPNet) (openvswitch.OVNSwitchPort, error) {
 	// Check network is of type OVN.
 	n, ok := network.(*ovn)
 	if !ok {
 		return "", fmt.Errorf("Network is not OVN type")
 	}
 
-	return n.instanceDevicePortAdd(instanceID, deviceName, mac, ips)
+	return n.instanceDevicePortAdd(instanceID, deviceName, mac, ips, aclNames, internalRoutes)
 }
 
-// OVNInstanceDevicePortDelete deletes a logical port from the OVN network's internal switch.
-func OVNInstanceDevicePortDelete(network Network, instanceID int, deviceName string) error {
+// OVNInstanceDevicePortDelete deletes a logical port from the OVN network's internal switch along with the
+// routes to its internal routes.
+func OVNInstanceDevicePortDelete(network Network, instanceID int, deviceName string, internalRoutes []*net.IPNet) error {
 	// Check network is of type OVN.
 	n, ok := network.(*ovn)
 	if !ok {
 		return fmt.Errorf("Network is not OVN type")
 	}
 
-	return n.instanceDevicePortDelete(instanceID, deviceName)
+	return n.instanceDevicePortDelete(instanceID, deviceName, internalRoutes)
 }
 
 // OVNInstanceDeviceMTU returns the MTU that should be used for an OVN instance device.
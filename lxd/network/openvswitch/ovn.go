@@ -3,6 +3,7 @@ package openvswitch
 import (
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"time"
 
@@ -24,6 +25,68 @@ type OVNSwitchPort string
 // OVNChassisGroup OVN HA chassis group name.
 type OVNChassisGroup string
 
+// OVNPortGroup OVN port group name.
+type OVNPortGroup string
+
+// OVNLoadBalancer OVN load balancer name.
+type OVNLoadBalancer string
+
+// OVNLoadBalancerTarget represents a target of a load balancer virtual IP.
+type OVNLoadBalancerTarget struct {
+	Address net.IP
+	Port    uint64 // Zero to forward all ports.
+}
+
+// OVNLoadBalancerVIP represents a load balancer virtual IP and the targets it spreads traffic across.
+type OVNLoadBalancerVIP struct {
+	ListenAddress net.IP
+	ListenPort    uint64 // Zero to forward all ports.
+	Targets       []OVNLoadBalancerTarget
+}
+
+// OVNLoadBalancerHealthCheck represents the health check settings of a load balancer.
+type OVNLoadBalancerHealthCheck struct {
+	Interval     uint64 // Seconds between checks.
+	Timeout      uint64 // Seconds to wait for a response.
+	FailureCount uint64 // Failed checks before a target is considered offline.
+	SuccessCount uint64 // Successful checks before a target is considered online.
+
+	// Logical switch port of each checked target address, targets without a port are not checked.
+	TargetPorts map[string]OVNSwitchPort
+
+	// Address the health checks are sent from, must be in the same subnet as the targets.
+	SourceAddress net.IP
+}
+
+// OVNRouterRoute represents a static route on a logical router.
+type OVNRouterRoute struct {
+	Prefix  *net.IPNet
+	NextHop net.IP
+}
+
+// OVNRouterPeering represents the configuration of a peering connection between two logical routers.
+type OVNRouterPeering struct {
+	LocalRouterName    OVNRouter
+	LocalRouterPort    OVNRouterPort
+	LocalRouterPortMAC net.HardwareAddr
+	LocalRouterPortIPs []*net.IPNet
+	LocalRouterRoutes  []*net.IPNet // Routed to the target router.
+
+	TargetRouterName    OVNRouter
+	TargetRouterPort    OVNRouterPort
+	TargetRouterPortMAC net.HardwareAddr
+	TargetRouterPortIPs []*net.IPNet
+	TargetRouterRoutes  []*net.IPNet // Routed to the local router.
+}
+
+// OVNACLRule represents an ACL rule that can be added to a port group.
+type OVNACLRule struct {
+	Direction string // Either "from-lport" or "to-lport".
+	Action    string // Either "allow-related", "allow", "drop" or "reject".
+	Match     string // Match criteria.
+	Priority  int    // Priority (between 0 and 32767, inclusive). Higher values take precedence.
+}
+
 // OVNIPAllocationOpts defines IP allocation settings that can be applied to a logical switch.
 type OVNIPAllocationOpts struct {
 	PrefixIPv4  *net.IPNet
@@ -38,10 +101,10 @@ type OVNIPv6AddressMode string
 const OVNIPv6AddressModeSLAAC OVNIPv6AddressMode = "slaac"
 
 // OVNIPv6AddressModeDHCPStateful IPv6 DHCPv6 stateful mode.
-const OVNIPv6AddressModeDHCPStateful OVNIPv6AddressMode = "dhcp_stateful"
+const OVNIPv6AddressModeDHCPStateful OVNIPv6AddressMode = "dhcpv6_stateful"
 
 // OVNIPv6AddressModeDHCPStateless IPv6 DHCPv6 stateless mode.
-const OVNIPv6AddressModeDHCPStateless OVNIPv6AddressMode = "dhcp_stateless"
+const OVNIPv6AddressModeDHCPStateless OVNIPv6AddressMode = "dhcpv6_stateless"
 
 // OVNIPv6RAOpts IPv6 router advertisements options that can be applied to a router.
 type OVNIPv6RAOpts struct {
@@ -76,6 +139,7 @@ type OVNDHCPv6Opts struct {
 	ServerID           net.HardwareAddr
 	RecursiveDNSServer net.IP
 	DNSSearchList      []string
+	Stateless          bool // If true, only provide configuration and not addresses.
 }
 
 // OVNSwitchPortOpts options that can be applied to a swich port.
@@ -150,6 +214,47 @@ func (o *OVN) LogicalRouterSNATAdd(routerName OVNRouter, intNet *net.IPNet, extI
 	return nil
 }
 
+// LogicalRouterDNATSNATAdd adds a DNAT and SNAT rule to a logical router to translate packets between extIP
+// and intIP in both directions.
+func (o *OVN) LogicalRouterDNATSNATAdd(routerName OVNRouter, extIP net.IP, intIP net.IP, mayExist bool) error {
+	args := []string{}
+
+	if mayExist {
+		args = append(args, "--may-exist")
+	}
+
+	_, err := o.nbctl(append(args, "lr-nat-add", string(routerName), "dnat_and_snat", extIP.String(), intIP.String())...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LogicalRouterDNATSNATDelete deletes the DNAT and SNAT rules for the external IPs from a logical router.
+func (o *OVN) LogicalRouterDNATSNATDelete(routerName OVNRouter, extIPs ...net.IP) error {
+	args := []string{}
+
+	for _, extIP := range extIPs {
+		if len(args) > 0 {
+			args = append(args, "--")
+		}
+
+		args = append(args, "--if-exists", "lr-nat-del", string(routerName), "dnat_and_snat", extIP.String())
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	_, err := o.nbctl(args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // LogicalRouterRouteAdd adds a static route to the logical router.
 func (o *OVN) LogicalRouterRouteAdd(routerName OVNRouter, destination *net.IPNet, nextHop net.IP) error {
 	_, err := o.nbctl("lr-route-add", string(routerName), destination.String(), nextHop.String())
@@ -160,6 +265,146 @@ func (o *OVN) LogicalRouterRouteAdd(routerName OVNRouter, destination *net.IPNet
 	return nil
 }
 
+// LogicalRouterRoutes returns the static routes of the logical router.
+func (o *OVN) LogicalRouterRoutes(routerName OVNRouter) ([]OVNRouterRoute, error) {
+	output, err := o.nbctl("lr-route-list", string(routerName))
+	if err != nil {
+		return nil, err
+	}
+
+	routes := []OVNRouterRoute{}
+	for _, line := range strings.Split(output, "\n") {
+		// Skip the per IP family headings and any other lines that are not routes.
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		_, prefix, err := net.ParseCIDR(fields[0])
+		if err != nil {
+			continue
+		}
+
+		nextHop := net.ParseIP(fields[1])
+		if nextHop == nil {
+			continue
+		}
+
+		routes = append(routes, OVNRouterRoute{Prefix: prefix, NextHop: nextHop})
+	}
+
+	return routes, nil
+}
+
+// LogicalRouterRouteDelete deletes the static routes for the destinations from the logical router.
+func (o *OVN) LogicalRouterRouteDelete(routerName OVNRouter, destinations ...*net.IPNet) error {
+	args := []string{}
+
+	for _, destination := range destinations {
+		if len(args) > 0 {
+			args = append(args, "--")
+		}
+
+		args = append(args, "--if-exists", "lr-route-del", string(routerName), destination.String())
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	_, err := o.nbctl(args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LogicalRouterPeeringApply links the two logical routers using a pair of peered router ports, and adds static
+// routes on each router towards the other router. Any existing peering between the routers is replaced.
+func (o *OVN) LogicalRouterPeeringApply(opts OVNRouterPeering) error {
+	args := o.logicalRouterPeeringDeleteArgs(opts)
+
+	// Add the peered router ports.
+	for _, port := range []struct {
+		routerName OVNRouter
+		portName   OVNRouterPort
+		mac        net.HardwareAddr
+		ips        []*net.IPNet
+		peerName   OVNRouterPort
+	}{
+		{opts.LocalRouterName, opts.LocalRouterPort, opts.LocalRouterPortMAC, opts.LocalRouterPortIPs, opts.TargetRouterPort},
+		{opts.TargetRouterName, opts.TargetRouterPort, opts.TargetRouterPortMAC, opts.TargetRouterPortIPs, opts.LocalRouterPort},
+	} {
+		args = append(args, "--", "lrp-add", string(port.routerName), string(port.portName), port.mac.String())
+		for _, ipNet := range port.ips {
+			args = append(args, ipNet.String())
+		}
+
+		args = append(args, fmt.Sprintf("peer=%s", port.peerName))
+	}
+
+	// Add the routes via the peer router port's address of the same IP family.
+	args = append(args, routerPeeringRouteAddArgs(opts.LocalRouterName, opts.LocalRouterPort, opts.LocalRouterRoutes, opts.TargetRouterPortIPs)...)
+	args = append(args, routerPeeringRouteAddArgs(opts.TargetRouterName, opts.TargetRouterPort, opts.TargetRouterRoutes, opts.LocalRouterPortIPs)...)
+
+	_, err := o.nbctl(args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LogicalRouterPeeringDelete removes the peered router ports and static routes between the two logical routers.
+func (o *OVN) LogicalRouterPeeringDelete(opts OVNRouterPeering) error {
+	_, err := o.nbctl(o.logicalRouterPeeringDeleteArgs(opts)...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// logicalRouterPeeringDeleteArgs returns the arguments to remove the peered router ports and static routes.
+func (o *OVN) logicalRouterPeeringDeleteArgs(opts OVNRouterPeering) []string {
+	args := []string{
+		"--if-exists", "lrp-del", string(opts.LocalRouterPort), "--",
+		"--if-exists", "lrp-del", string(opts.TargetRouterPort),
+	}
+
+	for _, route := range opts.LocalRouterRoutes {
+		args = append(args, "--", "--if-exists", "lr-route-del", string(opts.LocalRouterName), route.String())
+	}
+
+	for _, route := range opts.TargetRouterRoutes {
+		args = append(args, "--", "--if-exists", "lr-route-del", string(opts.TargetRouterName), route.String())
+	}
+
+	return args
+}
+
+// routerPeeringRouteAddArgs returns the arguments to add the static routes out of the router port via the peer
+// router port address of the same IP family. Routes without a peer address of the same IP family are skipped.
+func routerPeeringRouteAddArgs(routerName OVNRouter, portName OVNRouterPort, routes []*net.IPNet, peerIPs []*net.IPNet) []string {
+	args := []string{}
+
+	for _, route := range routes {
+		routeIsIPv4 := route.IP.To4() != nil
+
+		for _, peerIP := range peerIPs {
+			if (peerIP.IP.To4() != nil) != routeIsIPv4 {
+				continue
+			}
+
+			args = append(args, "--", "lr-route-add", string(routerName), route.String(), peerIP.IP.String(), string(portName))
+			break
+		}
+	}
+
+	return args
+}
+
 // LogicalRouterPortAdd adds a named logical router port to a logical router.
 func (o *OVN) LogicalRouterPortAdd(routerName OVNRouter, portName OVNRouterPort, mac net.HardwareAddr, ipAddr ...*net.IPNet) error {
 	args := []string{"lrp-add", string(routerName), string(portName), mac.String()}
@@ -408,6 +653,10 @@ func (o *OVN) LogicalSwitchDHCPv6OptionsSet(switchName OVNSwitch, uuid string, s
 		args = append(args, fmt.Sprintf("dns_server=%s", opts.RecursiveDNSServer.String()))
 	}
 
+	if opts.Stateless {
+		args = append(args, "dhcpv6_stateless=true")
+	}
+
 	_, err = o.nbctl(args...)
 	if err != nil {
 		return err
@@ -463,6 +712,18 @@ func (o *OVN) LogicalSwitchDHCPOptionsGet(switchName OVNSwitch) ([]OVNDHCPOptsSe
 	return dhcpOpts, nil
 }
 
+// LogicalSwitchDHCPOptionsDelete deletes the specified DHCP options sets defined for a switch.
+func (o *OVN) LogicalSwitchDHCPOptionsDelete(switchName OVNSwitch, uuids ...string) error {
+	for _, uuid := range uuids {
+		_, err := o.nbctl("--if-exists", "destroy", "dhcp_options", uuid)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // logicalSwitchDHCPOptionsDelete deletes any DHCP options defined for a switch.
 func (o *OVN) logicalSwitchDHCPOptionsDelete(switchName OVNSwitch) error {
 	existingOpts, err := o.nbctl("--format=csv", "--no-headings", "--data=bare", "--colum=_uuid", "find", "dhcp_options",
@@ -542,6 +803,73 @@ func (o *OVN) LogicalSwitchPortSet(portName OVNSwitchPort, opts *OVNSwitchPortOp
 	return nil
 }
 
+// LogicalSwitchPortDynamicIPs returns the IPs dynamically allocated to a logical switch port (if any).
+func (o *OVN) LogicalSwitchPortDynamicIPs(portName OVNSwitchPort) ([]net.IP, error) {
+	dynamicAddresses, err := o.nbctl("get", "logical_switch_port", string(portName), "dynamic_addresses")
+	if err != nil {
+		return nil, err
+	}
+
+	dynamicAddresses = strings.TrimSpace(dynamicAddresses)
+	if dynamicAddresses == "[]" {
+		return []net.IP{}, nil
+	}
+
+	// Dynamic addresses are returned quoted in the form "<MAC> [<IPv4>] [<IPv6>]".
+	dynamicAddresses, err = strconv.Unquote(dynamicAddresses)
+	if err != nil {
+		return nil, err
+	}
+
+	dynamicIPs := []net.IP{}
+	for _, address := range strings.Fields(dynamicAddresses) {
+		ip := net.ParseIP(address)
+		if ip != nil {
+			dynamicIPs = append(dynamicIPs, ip)
+		}
+	}
+
+	return dynamicIPs, nil
+}
+
+// LogicalSwitchPortIPs returns the static and dynamic IPs of the logical switch ports whose names start with the
+// given prefix, keyed on port name.
+func (o *OVN) LogicalSwitchPortIPs(portPrefix string) (map[OVNSwitchPort][]net.IP, error) {
+	output, err := o.nbctl("--format=csv", "--no-headings", "--data=bare", "--colum=name,addresses,dynamic_addresses", "find", "logical_switch_port")
+	if err != nil {
+		return nil, err
+	}
+
+	colCount := 3
+	portIPs := map[OVNSwitchPort][]net.IP{}
+	output = strings.TrimSpace(output)
+	if output != "" {
+		for _, row := range strings.Split(output, "\n") {
+			rowParts := strings.SplitN(row, ",", colCount)
+			if len(rowParts) < colCount {
+				return nil, fmt.Errorf("Too few columns in output")
+			}
+
+			if !strings.HasPrefix(rowParts[0], portPrefix) {
+				continue
+			}
+
+			// Addresses are in the form "<MAC> [<IP>...]" or "dynamic".
+			ips := []net.IP{}
+			for _, address := range strings.Fields(rowParts[1] + " " + rowParts[2]) {
+				ip := net.ParseIP(address)
+				if ip != nil {
+					ips = append(ips, ip)
+				}
+			}
+
+			portIPs[OVNSwitchPort(rowParts[0])] = ips
+		}
+	}
+
+	return portIPs, nil
+}
+
 // LogicalSwitchPortDelete deletes a named logical switch port.
 func (o *OVN) LogicalSwitchPortDelete(portName OVNSwitchPort) error {
 	_, err := o.nbctl("--if-exists", "lsp-del", string(portName))
@@ -644,3 +972,196 @@ func (o *OVN) ChassisGroupChassisAdd(haChassisGroupName OVNChassisGroup, chassis
 
 	return nil
 }
+
+// PortGroupExists returns whether the named port group exists.
+func (o *OVN) PortGroupExists(portGroupName OVNPortGroup) (bool, error) {
+	existing, err := o.nbctl("--no-headings", "--data=bare", "--colum=name", "find", "port_group", fmt.Sprintf("name=%s", string(portGroupName)))
+	if err != nil {
+		return false, err
+	}
+
+	return strings.TrimSpace(existing) != "", nil
+}
+
+// PortGroupAdd adds a new port group.
+// If mayExist is true, then an existing resource of the same name is not treated as an error.
+func (o *OVN) PortGroupAdd(portGroupName OVNPortGroup, mayExist bool) error {
+	if mayExist {
+		// Check if it exists (sadly pg-add doesn't provide --may-exist option).
+		exists, err := o.PortGroupExists(portGroupName)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			return nil
+		}
+	}
+
+	_, err := o.nbctl("pg-add", string(portGroupName))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PortGroupDelete deletes a port group along with its ACL rules.
+func (o *OVN) PortGroupDelete(portGroupName OVNPortGroup) error {
+	// ovn-nbctl doesn't provide an "--if-exists" option for removing port groups.
+	exists, err := o.PortGroupExists(portGroupName)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		_, err = o.nbctl("pg-del", string(portGroupName))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PortGroupPortAdd adds a logical switch port to a port group.
+// Ports are removed from the port group automatically when the logical switch port is deleted.
+func (o *OVN) PortGroupPortAdd(portGroupName OVNPortGroup, portName OVNSwitchPort) error {
+	portUUID, err := o.nbctl("--no-headings", "--data=bare", "--colum=_uuid", "find", "logical_switch_port", fmt.Sprintf("name=%s", string(portName)))
+	if err != nil {
+		return err
+	}
+
+	portUUID = strings.TrimSpace(portUUID)
+	if portUUID == "" {
+		return fmt.Errorf("Logical switch port %q not found", portName)
+	}
+
+	_, err = o.nbctl("add", "port_group", string(portGroupName), "ports", portUUID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PortGroupSetACLRules replaces the ACL rules of a port group with the supplied rules in a single transaction.
+func (o *OVN) PortGroupSetACLRules(portGroupName OVNPortGroup, rules ...OVNACLRule) error {
+	args := []string{"--type=port-group", "acl-del", string(portGroupName)}
+
+	for _, rule := range rules {
+		args = append(args, "--", "--type=port-group", "acl-add", string(portGroupName), rule.Direction, fmt.Sprintf("%d", rule.Priority), rule.Match, rule.Action)
+	}
+
+	_, err := o.nbctl(args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// loadBalancerFormatAddress formats an IP and optional port for use in load balancer settings.
+func loadBalancerFormatAddress(ip net.IP, port uint64) string {
+	if port == 0 {
+		return ip.String()
+	}
+
+	if ip.To4() == nil {
+		return fmt.Sprintf("[%s]:%d", ip.String(), port)
+	}
+
+	return fmt.Sprintf("%s:%d", ip.String(), port)
+}
+
+// LoadBalancerApply replaces the virtual IPs of a load balancer with the supplied ones and associates it with
+// the logical router and switch. The load balancer is removed if no virtual IPs are supplied.
+func (o *OVN) LoadBalancerApply(loadBalancerName OVNLoadBalancer, routerName OVNRouter, switchName OVNSwitch, protocol string, vips ...OVNLoadBalancerVIP) error {
+	args := []string{"--if-exists", "lb-del", string(loadBalancerName)}
+
+	for _, vip := range vips {
+		targets := make([]string, 0, len(vip.Targets))
+		for _, target := range vip.Targets {
+			targets = append(targets, loadBalancerFormatAddress(target.Address, target.Port))
+		}
+
+		args = append(args, "--", "lb-add", string(loadBalancerName), loadBalancerFormatAddress(vip.ListenAddress, vip.ListenPort), strings.Join(targets, ","), protocol)
+	}
+
+	if len(vips) > 0 {
+		args = append(args,
+			"--", "lr-lb-add", string(routerName), string(loadBalancerName),
+			"--", "ls-lb-add", string(switchName), string(loadBalancerName),
+		)
+	}
+
+	_, err := o.nbctl(args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LoadBalancerHealthCheckApply replaces the health checks of a load balancer with one per supplied virtual IP.
+// The health checks are removed if no settings are supplied. Virtual IPs must have a listen port.
+func (o *OVN) LoadBalancerHealthCheckApply(loadBalancerName OVNLoadBalancer, healthCheck *OVNLoadBalancerHealthCheck, vips ...OVNLoadBalancerVIP) error {
+	args := []string{
+		"clear", "load_balancer", string(loadBalancerName), "health_check",
+		"--", "clear", "load_balancer", string(loadBalancerName), "ip_port_mappings",
+	}
+
+	if healthCheck != nil {
+		for i, vip := range vips {
+			healthCheckID := fmt.Sprintf("@hc%d", i)
+
+			args = append(args,
+				"--", fmt.Sprintf("--id=%s", healthCheckID), "create", "load_balancer_health_check",
+				fmt.Sprintf(`vip="%s"`, loadBalancerFormatAddress(vip.ListenAddress, vip.ListenPort)),
+				fmt.Sprintf("options:interval=%d", healthCheck.Interval),
+				fmt.Sprintf("options:timeout=%d", healthCheck.Timeout),
+				fmt.Sprintf("options:failure_count=%d", healthCheck.FailureCount),
+				fmt.Sprintf("options:success_count=%d", healthCheck.SuccessCount),
+				"--", "add", "load_balancer", string(loadBalancerName), "health_check", healthCheckID,
+			)
+		}
+
+		// OVN needs to know the logical port of each target to send the health checks to.
+		for targetAddress, portName := range healthCheck.TargetPorts {
+			args = append(args, "--", "set", "load_balancer", string(loadBalancerName),
+				fmt.Sprintf(`ip_port_mappings:"%s"="%s:%s"`, targetAddress, portName, healthCheck.SourceAddress.String()),
+			)
+		}
+	}
+
+	_, err := o.nbctl(args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LoadBalancerDelete deletes the load balancers.
+func (o *OVN) LoadBalancerDelete(loadBalancerNames ...OVNLoadBalancer) error {
+	args := []string{}
+
+	for _, loadBalancerName := range loadBalancerNames {
+		if len(args) > 0 {
+			args = append(args, "--")
+		}
+
+		args = append(args, "--if-exists", "lb-del", string(loadBalancerName))
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	_, err := o.nbctl(args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package zone
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// labelRegex matches valid DNS labels. Labels may start with a number so that reverse zones can be used.
+var labelRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// NetworkKeys are the network config keys that link a network to a zone.
+var NetworkKeys = []string{"dns.zone.forward", "dns.zone.reverse.ipv4", "dns.zone.reverse.ipv6"}
+
+// NetworkLink represents a network using a zone.
+type NetworkLink struct {
+	Project string
+	Network string
+	Key     string
+}
+
+// ValidName checks the zone name is a valid DNS domain name.
+func ValidName(name string) error {
+	if name == "" {
+		return fmt.Errorf("Name is required")
+	}
+
+	if strings.HasSuffix(name, ".") {
+		return fmt.Errorf("Name must not end with a dot")
+	}
+
+	if len(name) > 253 {
+		return fmt.Errorf("Name must be 253 characters or less")
+	}
+
+	for _, label := range strings.Split(name, ".") {
+		if !labelRegex.MatchString(label) {
+			return fmt.Errorf("Invalid label %q, labels must be 1-63 lowercase letters, numbers or dashes and must not start or end with a dash", label)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the config of a zone is valid.
+func Validate(info *api.NetworkZonePut) error {
+	for k, v := range info.Config {
+		if strings.HasPrefix(k, "user.") {
+			continue
+		}
+
+		if k == "dns.nameservers" {
+			for _, nameserver := range shared.SplitNTrimSpace(v, ",", -1, true) {
+				err := ValidName(strings.TrimSuffix(nameserver, "."))
+				if err != nil {
+					return errors.Wrapf(err, "Invalid nameserver %q", nameserver)
+				}
+			}
+
+			continue
+		}
+
+		// Peer keys have the peer name in their name, in the form "peers.NAME.address" or "peers.NAME.key".
+		if strings.HasPrefix(k, "peers.") {
+			fields := strings.Split(k, ".")
+			if len(fields) != 3 || fields[1] == "" {
+				return fmt.Errorf("Invalid option %q", k)
+			}
+
+			switch fields[2] {
+			case "address":
+				if net.ParseIP(v) == nil {
+					return fmt.Errorf("Invalid IP address %q for %q", v, k)
+				}
+
+			case "key":
+				_, err := base64.StdEncoding.DecodeString(v)
+				if err != nil {
+					return fmt.Errorf("Invalid base64 key for %q", k)
+				}
+
+			default:
+				return fmt.Errorf("Invalid option %q", k)
+			}
+
+			continue
+		}
+
+		return fmt.Errorf("Invalid option %q", k)
+	}
+
+	return nil
+}
+
+// Nameservers returns the nameservers listed in the zone config.
+func Nameservers(config map[string]string) []string {
+	return shared.SplitNTrimSpace(config["dns.nameservers"], ",", -1, true)
+}
+
+// Peers returns the peers of the zone config, keyed by peer name, with the address and key of each peer.
+func Peers(config map[string]string) map[string]map[string]string {
+	peers := map[string]map[string]string{}
+
+	for k, v := range config {
+		fields := strings.Split(k, ".")
+		if len(fields) != 3 || fields[0] != "peers" {
+			continue
+		}
+
+		if peers[fields[1]] == nil {
+			peers[fields[1]] = map[string]string{}
+		}
+
+		peers[fields[1]][fields[2]] = v
+	}
+
+	return peers
+}
+
+// TSIGKeyName returns the TSIG key name used by a peer of the zone.
+func TSIGKeyName(zoneName string, peerName string) string {
+	return fmt.Sprintf("%s_%s.", zoneName, peerName)
+}
+
+// Networks returns the networks linked to the named zone.
+func Networks(s *state.State, zoneName string) ([]NetworkLink, error) {
+	links := []NetworkLink{}
+
+	projectNetworks, err := s.Cluster.GetNonPendingNetworks()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed loading networks")
+	}
+
+	for projectName, networks := range projectNetworks {
+		for _, netName := range networks {
+			_, netInfo, err := s.Cluster.GetNetworkInAnyState(projectName, netName)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed loading network %q in project %q", netName, projectName)
+			}
+
+			for _, key := range NetworkKeys {
+				if netInfo.Config[key] == zoneName {
+					links = append(links, NetworkLink{Project: projectName, Network: netName, Key: key})
+				}
+			}
+		}
+	}
+
+	return links, nil
+}
+
+// ValidateNetwork checks that the named zone exists and isn't linked to a network other than the one specified.
+func ValidateNetwork(s *state.State, zoneName string, projectName string, networkName string) error {
+	_, _, err := s.Cluster.GetNetworkZone(zoneName)
+	if err != nil {
+		if err == db.ErrNoSuchObject {
+			return fmt.Errorf("Network zone %q does not exist", zoneName)
+		}
+
+		return errors.Wrapf(err, "Failed loading network zone %q", zoneName)
+	}
+
+	links, err := Networks(s, zoneName)
+	if err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		if link.Project != projectName || link.Network != networkName {
+			return fmt.Errorf("Network zone %q is already used by network %q in project %q", zoneName, link.Network, link.Project)
+		}
+	}
+
+	return nil
+}
+
+// UsedBy returns the URLs of the networks that use the named zone.
+func UsedBy(s *state.State, zoneName string) ([]string, error) {
+	usedBy := []string{}
+
+	links, err := Networks(s, zoneName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, link := range links {
+		uri := fmt.Sprintf("/%s/networks/%s", version.APIVersion, link.Network)
+		if link.Project != project.Default {
+			uri += fmt.Sprintf("?project=%s", link.Project)
+		}
+
+		if !shared.StringInSlice(uri, usedBy) {
+			usedBy = append(usedBy, uri)
+		}
+	}
+
+	return usedBy, nil
+}
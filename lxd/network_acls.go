@@ -0,0 +1,314 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/network/acl"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/revert"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var networkACLsCmd = APIEndpoint{
+	Path: "network-acls",
+
+	Get:  APIEndpointAction{Handler: networkACLsGet, AccessHandler: allowAuthenticated},
+	Post: APIEndpointAction{Handler: networkACLsPost},
+}
+
+var networkACLCmd = APIEndpoint{
+	Path: "network-acls/{name}",
+
+	Delete: APIEndpointAction{Handler: networkACLDelete},
+	Get:    APIEndpointAction{Handler: networkACLGet, AccessHandler: allowAuthenticated},
+	Patch:  APIEndpointAction{Handler: networkACLPut},
+	Post:   APIEndpointAction{Handler: networkACLPost},
+	Put:    APIEndpointAction{Handler: networkACLPut},
+}
+
+// API endpoints
+func networkACLsGet(d *Daemon, r *http.Request) response.Response {
+	recursion := util.IsRecursionRequest(r)
+
+	names, err := d.cluster.GetNetworkACLs()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resultString := []string{}
+	resultMap := []api.NetworkACL{}
+	for _, name := range names {
+		if !recursion {
+			resultString = append(resultString, fmt.Sprintf("/%s/network-acls/%s", version.APIVersion, name))
+		} else {
+			aclInfo, err := doNetworkACLGet(d, name)
+			if err != nil {
+				continue
+			}
+
+			resultMap = append(resultMap, *aclInfo)
+		}
+	}
+
+	if !recursion {
+		return response.SyncResponse(true, resultString)
+	}
+
+	return response.SyncResponse(true, resultMap)
+}
+
+func networkACLsPost(d *Daemon, r *http.Request) response.Response {
+	req := api.NetworkACLsPost{}
+
+	// Parse the request.
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = acl.ValidName(req.Name)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	names, err := d.cluster.GetNetworkACLs()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if shared.StringInSlice(req.Name, names) {
+		return response.Conflict(fmt.Errorf("Network ACL %q already exists", req.Name))
+	}
+
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	err = acl.Validate(d.State(), req.Name, &req.NetworkACLPut)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	_, err = d.cluster.CreateNetworkACL(&req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/network-acls/%s", version.APIVersion, req.Name))
+}
+
+func networkACLGet(d *Daemon, r *http.Request) response.Response {
+	name := mux.Vars(r)["name"]
+
+	aclInfo, err := doNetworkACLGet(d, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	etag := []interface{}{aclInfo.Name, aclInfo.Description, aclInfo.Ingress, aclInfo.Egress, aclInfo.Config}
+
+	return response.SyncResponseETag(true, aclInfo, etag)
+}
+
+func doNetworkACLGet(d *Daemon, name string) (*api.NetworkACL, error) {
+	_, aclInfo, err := d.cluster.GetNetworkACL(name)
+	if err != nil {
+		return nil, err
+	}
+
+	aclInfo.UsedBy, err = acl.UsedBy(d.State(), name)
+	if err != nil {
+		return nil, err
+	}
+
+	return aclInfo, nil
+}
+
+func networkACLPut(d *Daemon, r *http.Request) response.Response {
+	name := mux.Vars(r)["name"]
+	clusterNotification := isClusterNotification(r)
+
+	// Get the existing ACL.
+	id, aclInfo, err := d.cluster.GetNetworkACL(name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Other cluster members only need to apply the already stored changes to their local networks.
+	if clusterNotification {
+		err = acl.ApplyChanges(d.State(), name, true)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		return response.EmptySyncResponse
+	}
+
+	// Validate the ETag.
+	etag := []interface{}{aclInfo.Name, aclInfo.Description, aclInfo.Ingress, aclInfo.Egress, aclInfo.Config}
+	err = util.EtagCheck(r, etag)
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	// Decode the request.
+	req := api.NetworkACLPut{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if r.Method == http.MethodPatch {
+		// Only replace the fields that were provided.
+		if req.Description == "" {
+			req.Description = aclInfo.Description
+		}
+
+		if req.Ingress == nil {
+			req.Ingress = aclInfo.Ingress
+		}
+
+		if req.Egress == nil {
+			req.Egress = aclInfo.Egress
+		}
+
+		if req.Config == nil {
+			req.Config = map[string]string{}
+		}
+
+		for k, v := range aclInfo.Config {
+			_, ok := req.Config[k]
+			if !ok {
+				req.Config[k] = v
+			}
+		}
+	}
+
+	err = acl.Validate(d.State(), name, &req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	err = d.cluster.UpdateNetworkACL(id, &req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	revert.Add(func() {
+		oldInfo := aclInfo.Writable()
+		d.cluster.UpdateNetworkACL(id, &oldInfo)
+		acl.ApplyChanges(d.State(), name, false)
+	})
+
+	err = acl.ApplyChanges(d.State(), name, false)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Apply the changes to the local networks of the other cluster members.
+	notifier, err := cluster.NewNotifier(d.State(), d.endpoints.NetworkCert(), cluster.NotifyAll)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = notifier(func(client lxd.InstanceServer) error {
+		return client.UpdateNetworkACL(name, req, "")
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	revert.Success()
+	return response.EmptySyncResponse
+}
+
+func networkACLPost(d *Daemon, r *http.Request) response.Response {
+	name := mux.Vars(r)["name"]
+	req := api.NetworkACLPost{}
+
+	// Parse the request.
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Get the existing ACL.
+	id, _, err := d.cluster.GetNetworkACL(name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = acl.ValidName(req.Name)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	names, err := d.cluster.GetNetworkACLs()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if shared.StringInSlice(req.Name, names) {
+		return response.Conflict(fmt.Errorf("Network ACL %q already exists", req.Name))
+	}
+
+	// ACLs are referred to by name, so they cannot be renamed whilst in use.
+	usedBy, err := acl.UsedBy(d.State(), name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if len(usedBy) > 0 {
+		return response.BadRequest(fmt.Errorf("Cannot rename network ACL %q as it is in use", name))
+	}
+
+	err = d.cluster.RenameNetworkACL(id, req.Name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/network-acls/%s", version.APIVersion, req.Name))
+}
+
+func networkACLDelete(d *Daemon, r *http.Request) response.Response {
+	name := mux.Vars(r)["name"]
+
+	// Get the existing ACL.
+	id, _, err := d.cluster.GetNetworkACL(name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	usedBy, err := acl.UsedBy(d.State(), name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if len(usedBy) > 0 {
+		return response.BadRequest(fmt.Errorf("Cannot delete network ACL %q as it is in use", name))
+	}
+
+	err = acl.Delete(d.State(), id)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = d.cluster.DeleteNetworkACL(id)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var networkForwardsCmd = APIEndpoint{
+	Path: "networks/{networkName}/forwards",
+
+	Get:  APIEndpointAction{Handler: networkForwardsGet, AccessHandler: allowAuthenticated},
+	Post: APIEndpointAction{Handler: networkForwardsPost},
+}
+
+var networkForwardCmd = APIEndpoint{
+	Path: "networks/{networkName}/forwards/{listenAddress}",
+
+	Delete: APIEndpointAction{Handler: networkForwardDelete},
+	Get:    APIEndpointAction{Handler: networkForwardGet, AccessHandler: allowAuthenticated},
+	Patch:  APIEndpointAction{Handler: networkForwardPut},
+	Put:    APIEndpointAction{Handler: networkForwardPut},
+}
+
+// networkForwardResponse converts a network driver error into a response.
+func networkForwardResponse(n network.Network, err error) response.Response {
+	if err == network.ErrNotImplemented {
+		return response.BadRequest(fmt.Errorf("Network driver %q does not support forwards", n.Type()))
+	}
+
+	return response.SmartError(err)
+}
+
+// networkListenAddressUsedBy returns the type of entity ("forward" or "load balancer") using the listen address
+// on any network, or an empty string if the address is unused.
+func networkListenAddressUsedBy(d *Daemon, listenAddress net.IP) (string, error) {
+	forwardListenAddresses, err := d.cluster.GetNetworkForwardListenAddresses()
+	if err != nil {
+		return "", err
+	}
+
+	loadBalancerListenAddresses, err := d.cluster.GetNetworkLoadBalancerListenAddresses()
+	if err != nil {
+		return "", err
+	}
+
+	for usedBy, networkListenAddresses := range map[string]map[int64][]string{
+		"forward":       forwardListenAddresses,
+		"load balancer": loadBalancerListenAddresses,
+	} {
+		for _, listenAddresses := range networkListenAddresses {
+			for _, existing := range listenAddresses {
+				if net.ParseIP(existing).Equal(listenAddress) {
+					return usedBy, nil
+				}
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// API endpoints
+func networkForwardsGet(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	recursion := util.IsRecursionRequest(r)
+	networkName := mux.Vars(r)["networkName"]
+
+	networkID, _, err := d.cluster.GetNetworkInAnyState(projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	forwards, err := d.cluster.GetNetworkForwards(networkID)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !recursion {
+		resultString := []string{}
+		for _, forward := range forwards {
+			resultString = append(resultString, fmt.Sprintf("/%s/networks/%s/forwards/%s", version.APIVersion, networkName, forward.ListenAddress))
+		}
+
+		return response.SyncResponse(true, resultString)
+	}
+
+	return response.SyncResponse(true, forwards)
+}
+
+func networkForwardsPost(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	networkName := mux.Vars(r)["networkName"]
+	clusterNotification := isClusterNotification(r)
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.NetworkForwardsPost{}
+
+	// Parse the request.
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	listenAddress := net.ParseIP(req.ListenAddress)
+	if listenAddress == nil {
+		return response.BadRequest(fmt.Errorf("Invalid listen address %q", req.ListenAddress))
+	}
+
+	// Store the listen address in its canonical form so it can be used in URLs.
+	req.ListenAddress = listenAddress.String()
+
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	if !clusterNotification {
+		// Check the listen address isn't used by a forward or load balancer on any network.
+		usedBy, err := networkListenAddressUsedBy(d, listenAddress)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		if usedBy != "" {
+			return response.Conflict(fmt.Errorf("A network %s for %q already exists", usedBy, req.ListenAddress))
+		}
+	}
+
+	err = n.ForwardCreate(req, clusterNotification)
+	if err != nil {
+		return networkForwardResponse(n, err)
+	}
+
+	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/networks/%s/forwards/%s", version.APIVersion, networkName, req.ListenAddress))
+}
+
+func networkForwardGet(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	networkName := mux.Vars(r)["networkName"]
+	listenAddress := mux.Vars(r)["listenAddress"]
+
+	networkID, _, err := d.cluster.GetNetworkInAnyState(projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	_, forward, err := d.cluster.GetNetworkForward(networkID, listenAddress)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	etag := []interface{}{forward.ListenAddress, forward.Description, forward.Config, forward.Ports}
+
+	return response.SyncResponseETag(true, forward, etag)
+}
+
+func networkForwardPut(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	networkName := mux.Vars(r)["networkName"]
+	listenAddress := mux.Vars(r)["listenAddress"]
+	clusterNotification := isClusterNotification(r)
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Get the existing forward.
+	_, forward, err := d.cluster.GetNetworkForward(n.ID(), listenAddress)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Validate the ETag.
+	etag := []interface{}{forward.ListenAddress, forward.Description, forward.Config, forward.Ports}
+	err = util.EtagCheck(r, etag)
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	// Decode the request.
+	req := api.NetworkForwardPut{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if r.Method == http.MethodPatch {
+		// Only replace the fields that were provided.
+		if req.Description == "" {
+			req.Description = forward.Description
+		}
+
+		if req.Ports == nil {
+			req.Ports = forward.Ports
+		}
+
+		if req.Config == nil {
+			req.Config = map[string]string{}
+		}
+
+		for k, v := range forward.Config {
+			_, ok := req.Config[k]
+			if !ok {
+				req.Config[k] = v
+			}
+		}
+	}
+
+	err = n.ForwardUpdate(listenAddress, req, clusterNotification)
+	if err != nil {
+		return networkForwardResponse(n, err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+func networkForwardDelete(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	networkName := mux.Vars(r)["networkName"]
+	listenAddress := mux.Vars(r)["listenAddress"]
+	clusterNotification := isClusterNotification(r)
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = n.ForwardDelete(listenAddress, clusterNotification)
+	if err != nil {
+		return networkForwardResponse(n, err)
+	}
+
+	return response.EmptySyncResponse
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var networkLoadBalancersCmd = APIEndpoint{
+	Path: "networks/{networkName}/load-balancers",
+
+	Get:  APIEndpointAction{Handler: networkLoadBalancersGet, AccessHandler: allowAuthenticated},
+	Post: APIEndpointAction{Handler: networkLoadBalancersPost},
+}
+
+var networkLoadBalancerCmd = APIEndpoint{
+	Path: "networks/{networkName}/load-balancers/{listenAddress}",
+
+	Delete: APIEndpointAction{Handler: networkLoadBalancerDelete},
+	Get:    APIEndpointAction{Handler: networkLoadBalancerGet, AccessHandler: allowAuthenticated},
+	Patch:  APIEndpointAction{Handler: networkLoadBalancerPut},
+	Put:    APIEndpointAction{Handler: networkLoadBalancerPut},
+}
+
+// networkLoadBalancerResponse converts a network driver error into a response.
+func networkLoadBalancerResponse(n network.Network, err error) response.Response {
+	if err == network.ErrNotImplemented {
+		return response.BadRequest(fmt.Errorf("Network driver %q does not support load balancers", n.Type()))
+	}
+
+	return response.SmartError(err)
+}
+
+// API endpoints
+func networkLoadBalancersGet(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	recursion := util.IsRecursionRequest(r)
+	networkName := mux.Vars(r)["networkName"]
+
+	networkID, _, err := d.cluster.GetNetworkInAnyState(projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	loadBalancers, err := d.cluster.GetNetworkLoadBalancers(networkID)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !recursion {
+		resultString := []string{}
+		for _, loadBalancer := range loadBalancers {
+			resultString = append(resultString, fmt.Sprintf("/%s/networks/%s/load-balancers/%s", version.APIVersion, networkName, loadBalancer.ListenAddress))
+		}
+
+		return response.SyncResponse(true, resultString)
+	}
+
+	return response.SyncResponse(true, loadBalancers)
+}
+
+func networkLoadBalancersPost(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	networkName := mux.Vars(r)["networkName"]
+	clusterNotification := isClusterNotification(r)
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.NetworkLoadBalancersPost{}
+
+	// Parse the request.
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// The listen address is allocated by the network if not specified.
+	if req.ListenAddress != "" {
+		listenAddress := net.ParseIP(req.ListenAddress)
+		if listenAddress == nil {
+			return response.BadRequest(fmt.Errorf("Invalid listen address %q", req.ListenAddress))
+		}
+
+		// Store the listen address in its canonical form so it can be used in URLs.
+		req.ListenAddress = listenAddress.String()
+
+		if !clusterNotification {
+			// Check the listen address isn't used by a forward or load balancer on any network.
+			usedBy, err := networkListenAddressUsedBy(d, listenAddress)
+			if err != nil {
+				return response.SmartError(err)
+			}
+
+			if usedBy != "" {
+				return response.Conflict(fmt.Errorf("A network %s for %q already exists", usedBy, req.ListenAddress))
+			}
+		}
+	}
+
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	listenAddress, err := n.LoadBalancerCreate(req, clusterNotification)
+	if err != nil {
+		return networkLoadBalancerResponse(n, err)
+	}
+
+	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/networks/%s/load-balancers/%s", version.APIVersion, networkName, listenAddress.String()))
+}
+
+func networkLoadBalancerGet(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	networkName := mux.Vars(r)["networkName"]
+	listenAddress := mux.Vars(r)["listenAddress"]
+
+	networkID, _, err := d.cluster.GetNetworkInAnyState(projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	_, loadBalancer, err := d.cluster.GetNetworkLoadBalancer(networkID, listenAddress)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	etag := []interface{}{loadBalancer.ListenAddress, loadBalancer.Description, loadBalancer.Config, loadBalancer.Backends, loadBalancer.Ports}
+
+	return response.SyncResponseETag(true, loadBalancer, etag)
+}
+
+func networkLoadBalancerPut(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	networkName := mux.Vars(r)["networkName"]
+	listenAddress := mux.Vars(r)["listenAddress"]
+	clusterNotification := isClusterNotification(r)
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Get the existing load balancer.
+	_, loadBalancer, err := d.cluster.GetNetworkLoadBalancer(n.ID(), listenAddress)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Validate the ETag.
+	etag := []interface{}{loadBalancer.ListenAddress, loadBalancer.Description, loadBalancer.Config, loadBalancer.Backends, loadBalancer.Ports}
+	err = util.EtagCheck(r, etag)
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	// Decode the request.
+	req := api.NetworkLoadBalancerPut{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if r.Method == http.MethodPatch {
+		// Only replace the fields that were provided.
+		if req.Description == "" {
+			req.Description = loadBalancer.Description
+		}
+
+		if req.Backends == nil {
+			req.Backends = loadBalancer.Backends
+		}
+
+		if req.Ports == nil {
+			req.Ports = loadBalancer.Ports
+		}
+
+		if req.Config == nil {
+			req.Config = map[string]string{}
+		}
+
+		for k, v := range loadBalancer.Config {
+			_, ok := req.Config[k]
+			if !ok {
+				req.Config[k] = v
+			}
+		}
+	}
+
+	err = n.LoadBalancerUpdate(listenAddress, req, clusterNotification)
+	if err != nil {
+		return networkLoadBalancerResponse(n, err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+func networkLoadBalancerDelete(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	networkName := mux.Vars(r)["networkName"]
+	listenAddress := mux.Vars(r)["listenAddress"]
+	clusterNotification := isClusterNotification(r)
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = n.LoadBalancerDelete(listenAddress, clusterNotification)
+	if err != nil {
+		return networkLoadBalancerResponse(n, err)
+	}
+
+	return response.EmptySyncResponse
+}
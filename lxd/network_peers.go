@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/validate"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var networkPeersCmd = APIEndpoint{
+	Path: "networks/{networkName}/peers",
+
+	Get:  APIEndpointAction{Handler: networkPeersGet, AccessHandler: allowAuthenticated},
+	Post: APIEndpointAction{Handler: networkPeersPost},
+}
+
+var networkPeerCmd = APIEndpoint{
+	Path: "networks/{networkName}/peers/{peerName}",
+
+	Delete: APIEndpointAction{Handler: networkPeerDelete},
+	Get:    APIEndpointAction{Handler: networkPeerGet, AccessHandler: allowAuthenticated},
+	Patch:  APIEndpointAction{Handler: networkPeerPut},
+	Put:    APIEndpointAction{Handler: networkPeerPut},
+}
+
+// networkPeerResponse converts a network driver error into a response.
+func networkPeerResponse(n network.Network, err error) response.Response {
+	if err == network.ErrNotImplemented {
+		return response.BadRequest(fmt.Errorf("Network driver %q does not support peering", n.Type()))
+	}
+
+	return response.SmartError(err)
+}
+
+// API endpoints
+func networkPeersGet(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	recursion := util.IsRecursionRequest(r)
+	networkName := mux.Vars(r)["networkName"]
+
+	networkID, _, err := d.cluster.GetNetworkInAnyState(projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	peers, err := d.cluster.GetNetworkPeers(networkID)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if !recursion {
+		resultString := []string{}
+		for _, peer := range peers {
+			resultString = append(resultString, fmt.Sprintf("/%s/networks/%s/peers/%s", version.APIVersion, networkName, peer.Name))
+		}
+
+		return response.SyncResponse(true, resultString)
+	}
+
+	return response.SyncResponse(true, peers)
+}
+
+func networkPeersPost(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	networkName := mux.Vars(r)["networkName"]
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.NetworkPeersPost{}
+
+	// Parse the request.
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = validate.IsURLSegmentSafe(req.Name)
+	if err != nil {
+		return response.BadRequest(fmt.Errorf("Invalid peering name %q: %v", req.Name, err))
+	}
+
+	if req.TargetNetwork == "" {
+		return response.BadRequest(fmt.Errorf("Target network is required"))
+	}
+
+	// The target network is looked up in the effective network project of the target project, which defaults
+	// to the project of the network being peered.
+	if req.TargetProject == "" {
+		req.TargetProject = projectName
+	} else {
+		req.TargetProject, err = project.NetworkProject(d.State().Cluster, req.TargetProject)
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	_, _, err = d.cluster.GetNetworkPeer(n.ID(), req.Name)
+	if err == nil {
+		return response.Conflict(fmt.Errorf("A network peering named %q already exists", req.Name))
+	} else if err != db.ErrNoSuchObject {
+		return response.SmartError(err)
+	}
+
+	err = n.PeerCreate(req)
+	if err != nil {
+		return networkPeerResponse(n, err)
+	}
+
+	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/networks/%s/peers/%s", version.APIVersion, networkName, req.Name))
+}
+
+func networkPeerGet(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	networkName := mux.Vars(r)["networkName"]
+	peerName := mux.Vars(r)["peerName"]
+
+	networkID, _, err := d.cluster.GetNetworkInAnyState(projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	_, peer, err := d.cluster.GetNetworkPeer(networkID, peerName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	etag := []interface{}{peer.Name, peer.Description, peer.Config, peer.TargetProject, peer.TargetNetwork}
+
+	return response.SyncResponseETag(true, peer, etag)
+}
+
+func networkPeerPut(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	networkName := mux.Vars(r)["networkName"]
+	peerName := mux.Vars(r)["peerName"]
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Get the existing peering.
+	_, peer, err := d.cluster.GetNetworkPeer(n.ID(), peerName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Validate the ETag.
+	etag := []interface{}{peer.Name, peer.Description, peer.Config, peer.TargetProject, peer.TargetNetwork}
+	err = util.EtagCheck(r, etag)
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	// Decode the request.
+	req := api.NetworkPeerPut{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if r.Method == http.MethodPatch {
+		// Only replace the fields that were provided.
+		if req.Description == "" {
+			req.Description = peer.Description
+		}
+
+		if req.Config == nil {
+			req.Config = map[string]string{}
+		}
+
+		for k, v := range peer.Config {
+			_, ok := req.Config[k]
+			if !ok {
+				req.Config[k] = v
+			}
+		}
+	}
+
+	err = n.PeerUpdate(peerName, req)
+	if err != nil {
+		return networkPeerResponse(n, err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+func networkPeerDelete(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	networkName := mux.Vars(r)["networkName"]
+	peerName := mux.Vars(r)["peerName"]
+
+	n, err := network.LoadByName(d.State(), projectName, networkName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = n.PeerDelete(peerName)
+	if err != nil {
+		return networkPeerResponse(n, err)
+	}
+
+	return response.EmptySyncResponse
+}
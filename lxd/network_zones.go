@@ -0,0 +1,436 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/miekg/dns"
+
+	lxd "github.com/lxc/lxd/client"
+	"github.com/lxc/lxd/lxd/cluster"
+	"github.com/lxc/lxd/lxd/db"
+	lxdDNS "github.com/lxc/lxd/lxd/dns"
+	"github.com/lxc/lxd/lxd/network"
+	"github.com/lxc/lxd/lxd/network/zone"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/state"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// networkZoneTTL is the TTL of the records served for network zones.
+const networkZoneTTL = 300
+
+var networkZonesCmd = APIEndpoint{
+	Path: "network-zones",
+
+	Get:  APIEndpointAction{Handler: networkZonesGet, AccessHandler: allowAuthenticated},
+	Post: APIEndpointAction{Handler: networkZonesPost},
+}
+
+var networkZoneCmd = APIEndpoint{
+	Path: "network-zones/{name}",
+
+	Delete: APIEndpointAction{Handler: networkZoneDelete},
+	Get:    APIEndpointAction{Handler: networkZoneGet, AccessHandler: allowAuthenticated},
+	Patch:  APIEndpointAction{Handler: networkZonePut},
+	Put:    APIEndpointAction{Handler: networkZonePut},
+}
+
+// API endpoints
+func networkZonesGet(d *Daemon, r *http.Request) response.Response {
+	recursion := util.IsRecursionRequest(r)
+
+	names, err := d.cluster.GetNetworkZones()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resultString := []string{}
+	resultMap := []api.NetworkZone{}
+	for _, name := range names {
+		if !recursion {
+			resultString = append(resultString, fmt.Sprintf("/%s/network-zones/%s", version.APIVersion, name))
+		} else {
+			zoneInfo, err := doNetworkZoneGet(d, name)
+			if err != nil {
+				continue
+			}
+
+			resultMap = append(resultMap, *zoneInfo)
+		}
+	}
+
+	if !recursion {
+		return response.SyncResponse(true, resultString)
+	}
+
+	return response.SyncResponse(true, resultMap)
+}
+
+func networkZonesPost(d *Daemon, r *http.Request) response.Response {
+	// Other cluster members only need to reload the peer keys and zones of their DNS server.
+	if isClusterNotification(r) {
+		err := networkZonesReload(d)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		return response.EmptySyncResponse
+	}
+
+	req := api.NetworkZonesPost{}
+
+	// Parse the request.
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = zone.ValidName(req.Name)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	names, err := d.cluster.GetNetworkZones()
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if shared.StringInSlice(req.Name, names) {
+		return response.Conflict(fmt.Errorf("Network zone %q already exists", req.Name))
+	}
+
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	err = zone.Validate(&req.NetworkZonePut)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	_, err = d.cluster.CreateNetworkZone(&req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = networkZonesNotify(d, func(client lxd.InstanceServer) error {
+		return client.CreateNetworkZone(req)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/network-zones/%s", version.APIVersion, req.Name))
+}
+
+func networkZoneGet(d *Daemon, r *http.Request) response.Response {
+	name := mux.Vars(r)["name"]
+
+	zoneInfo, err := doNetworkZoneGet(d, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	etag := []interface{}{zoneInfo.Name, zoneInfo.Description, zoneInfo.Config}
+
+	return response.SyncResponseETag(true, zoneInfo, etag)
+}
+
+func doNetworkZoneGet(d *Daemon, name string) (*api.NetworkZone, error) {
+	_, zoneInfo, err := d.cluster.GetNetworkZone(name)
+	if err != nil {
+		return nil, err
+	}
+
+	zoneInfo.UsedBy, err = zone.UsedBy(d.State(), name)
+	if err != nil {
+		return nil, err
+	}
+
+	return zoneInfo, nil
+}
+
+func networkZonePut(d *Daemon, r *http.Request) response.Response {
+	name := mux.Vars(r)["name"]
+
+	// Other cluster members only need to reload the peer keys and zones of their DNS server.
+	if isClusterNotification(r) {
+		err := networkZonesReload(d)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		return response.EmptySyncResponse
+	}
+
+	// Get the existing zone.
+	id, zoneInfo, err := d.cluster.GetNetworkZone(name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Validate the ETag.
+	etag := []interface{}{zoneInfo.Name, zoneInfo.Description, zoneInfo.Config}
+	err = util.EtagCheck(r, etag)
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	// Decode the request.
+	req := api.NetworkZonePut{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if r.Method == http.MethodPatch {
+		// Only replace the fields that were provided.
+		if req.Description == "" {
+			req.Description = zoneInfo.Description
+		}
+
+		if req.Config == nil {
+			req.Config = map[string]string{}
+		}
+
+		for k, v := range zoneInfo.Config {
+			_, ok := req.Config[k]
+			if !ok {
+				req.Config[k] = v
+			}
+		}
+	}
+
+	err = zone.Validate(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = d.cluster.UpdateNetworkZone(id, &req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = networkZonesNotify(d, func(client lxd.InstanceServer) error {
+		return client.UpdateNetworkZone(name, req, "")
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+func networkZoneDelete(d *Daemon, r *http.Request) response.Response {
+	name := mux.Vars(r)["name"]
+
+	// Other cluster members only need to reload the peer keys and zones of their DNS server.
+	if isClusterNotification(r) {
+		err := networkZonesReload(d)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		return response.EmptySyncResponse
+	}
+
+	// Get the existing zone.
+	id, _, err := d.cluster.GetNetworkZone(name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	usedBy, err := zone.UsedBy(d.State(), name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if len(usedBy) > 0 {
+		return response.BadRequest(fmt.Errorf("Cannot delete network zone %q as it is in use", name))
+	}
+
+	err = d.cluster.DeleteNetworkZone(id)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = networkZonesNotify(d, func(client lxd.InstanceServer) error {
+		return client.DeleteNetworkZone(name)
+	})
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// networkZonesNotify reloads the peer keys and zones of the local DNS server and notifies the other cluster members so
+// they do the same.
+func networkZonesNotify(d *Daemon, hook func(client lxd.InstanceServer) error) error {
+	err := networkZonesReload(d)
+	if err != nil {
+		return err
+	}
+
+	notifier, err := cluster.NewNotifier(d.State(), d.endpoints.NetworkCert(), cluster.NotifyAlive)
+	if err != nil {
+		return err
+	}
+
+	return notifier(hook)
+}
+
+// networkZonesReload loads the TSIG keys of the peers of all zones into the DNS server and marks its cached
+// zones as stale.
+func networkZonesReload(d *Daemon) error {
+	d.dns.InvalidateZones()
+
+	names, err := d.cluster.GetNetworkZones()
+	if err != nil {
+		return err
+	}
+
+	keys := map[string]string{}
+	for _, name := range names {
+		_, zoneInfo, err := d.cluster.GetNetworkZone(name)
+		if err != nil {
+			return err
+		}
+
+		for peerName, peer := range zone.Peers(zoneInfo.Config) {
+			if peer["key"] != "" {
+				keys[zone.TSIGKeyName(name, peerName)] = peer["key"]
+			}
+		}
+	}
+
+	return d.dns.UpdateTSIG(keys)
+}
+
+// networkZoneRecords generates the records of the named zone from the addresses on the networks linked to it.
+// Returns nil if the zone doesn't exist.
+func networkZoneRecords(s *state.State, name string) (*lxdDNS.Zone, error) {
+	_, zoneInfo, err := s.Cluster.GetNetworkZone(name)
+	if err != nil {
+		if err == db.ErrNoSuchObject {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	zoneFQDN := dns.Fqdn(name)
+	result := &lxdDNS.Zone{Name: name}
+
+	for peerName, peer := range zone.Peers(zoneInfo.Config) {
+		zonePeer := lxdDNS.ZonePeer{Address: peer["address"]}
+		if peer["key"] != "" {
+			zonePeer.KeyName = zone.TSIGKeyName(name, peerName)
+		}
+
+		result.Peers = append(result.Peers, zonePeer)
+	}
+
+	header := func(recordName string, recordType uint16) dns.RR_Header {
+		return dns.RR_Header{Name: recordName, Rrtype: recordType, Class: dns.ClassINET, Ttl: networkZoneTTL}
+	}
+
+	// Start with the SOA and NS records. The serial is set by the DNS server when the zone's records change.
+	nameservers := zone.Nameservers(zoneInfo.Config)
+	primary := zoneFQDN
+	if len(nameservers) > 0 {
+		primary = dns.Fqdn(nameservers[0])
+	}
+
+	result.Records = append(result.Records, &dns.SOA{
+		Hdr:     header(zoneFQDN, dns.TypeSOA),
+		Ns:      primary,
+		Mbox:    fmt.Sprintf("hostmaster.%s", zoneFQDN),
+		Refresh: 120,
+		Retry:   60,
+		Expire:  86400,
+		Minttl:  networkZoneTTL,
+	})
+
+	for _, nameserver := range nameservers {
+		result.Records = append(result.Records, &dns.NS{Hdr: header(zoneFQDN, dns.TypeNS), Ns: dns.Fqdn(nameserver)})
+	}
+
+	links, err := zone.Networks(s, name)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, link := range links {
+		n, err := network.LoadByName(s, link.Project, link.Network)
+		if err != nil {
+			return nil, err
+		}
+
+		addresses, err := n.ZoneAddresses()
+		if err != nil {
+			if err == network.ErrNotImplemented {
+				continue
+			}
+
+			return nil, err
+		}
+
+		// Records are named after the instance within the network's forward zone. Instances from other
+		// projects than the network's have the project name added.
+		forwardZone := n.Config()["dns.zone.forward"]
+		recordName := func(address network.ZoneAddress) string {
+			if address.Project != "" && address.Project != link.Project {
+				return dns.Fqdn(fmt.Sprintf("%s.%s.%s", address.Hostname, address.Project, forwardZone))
+			}
+
+			return dns.Fqdn(fmt.Sprintf("%s.%s", address.Hostname, forwardZone))
+		}
+
+		for _, address := range addresses {
+			ip := net.ParseIP(address.Address)
+			if ip == nil || address.Hostname == "" || forwardZone == "" {
+				continue
+			}
+
+			var record dns.RR
+			switch link.Key {
+			case "dns.zone.forward":
+				if ip.To4() != nil {
+					record = &dns.A{Hdr: header(recordName(address), dns.TypeA), A: ip.To4()}
+				} else {
+					record = &dns.AAAA{Hdr: header(recordName(address), dns.TypeAAAA), AAAA: ip}
+				}
+
+			case "dns.zone.reverse.ipv4", "dns.zone.reverse.ipv6":
+				if (ip.To4() != nil) != (link.Key == "dns.zone.reverse.ipv4") {
+					continue
+				}
+
+				reverseName, err := dns.ReverseAddr(ip.String())
+				if err != nil || !dns.IsSubDomain(zoneFQDN, reverseName) {
+					continue
+				}
+
+				record = &dns.PTR{Hdr: header(reverseName, dns.TypePTR), Ptr: recordName(address)}
+			}
+
+			if record == nil || seen[record.String()] {
+				continue
+			}
+
+			seen[record.String()] = true
+			result.Records = append(result.Records, record)
+		}
+	}
+
+	return result, nil
+}
@@ -55,7 +55,14 @@ var networkCmd = APIEndpoint{
 var networkLeasesCmd = APIEndpoint{
 	Path: "networks/{name}/leases",
 
-	Get: APIEndpointAction{Handler: networkLeasesGet, AccessHandler: allowAuthenticated},
+	Get:  APIEndpointAction{Handler: networkLeasesGet, AccessHandler: allowAuthenticated},
+	Post: APIEndpointAction{Handler: networkLeasesPost},
+}
+
+var networkLeaseCmd = APIEndpoint{
+	Path: "networks/{name}/leases/{hwaddr}",
+
+	Delete: APIEndpointAction{Handler: networkLeaseDelete},
 }
 
 var networkStateCmd = APIEndpoint{
@@ -64,11 +71,32 @@ var networkStateCmd = APIEndpoint{
 	Get: APIEndpointAction{Handler: networkStateGet, AccessHandler: allowAuthenticated},
 }
 
+// networkURL returns the URL of the network with the given name in the given project.
+func networkURL(projectName string, name string) string {
+	url := fmt.Sprintf("/%s/networks/%s", version.APIVersion, name)
+	if projectName != project.Default {
+		url += fmt.Sprintf("?project=%s", projectName)
+	}
+
+	return url
+}
+
 // API endpoints
 func networksGet(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	recursion := util.IsRecursionRequest(r)
 
-	ifs, err := networkGetInterfaces(d.cluster)
+	// Networks in the default project are listed along with the host's network interfaces.
+	var ifs []string
+	if projectName == project.Default {
+		ifs, err = networkGetInterfaces(d.cluster)
+	} else {
+		ifs, err = d.cluster.GetNetworks(projectName)
+	}
 	if err != nil {
 		return response.InternalError(err)
 	}
@@ -77,9 +105,9 @@ func networksGet(d *Daemon, r *http.Request) response.Response {
 	resultMap := []api.Network{}
 	for _, iface := range ifs {
 		if !recursion {
-			resultString = append(resultString, fmt.Sprintf("/%s/networks/%s", version.APIVersion, iface))
+			resultString = append(resultString, networkURL(projectName, iface))
 		} else {
-			net, err := doNetworkGet(d, iface)
+			net, err := doNetworkGet(d, projectName, iface)
 			if err != nil {
 				continue
 			}
@@ -95,13 +123,18 @@ func networksGet(d *Daemon, r *http.Request) response.Response {
 }
 
 func networksPost(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	networkCreateLock.Lock()
 	defer networkCreateLock.Unlock()
 
 	req := api.NetworksPost{}
 
 	// Parse the request.
-	err := json.NewDecoder(r.Body).Decode(&req)
+	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		return response.BadRequest(err)
 	}
@@ -135,17 +168,18 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 		dbNetType = db.NetworkTypeSriov
 	case "ovn":
 		dbNetType = db.NetworkTypeOVN
+	case "physical":
+		dbNetType = db.NetworkTypePhysical
 	default:
 		return response.BadRequest(fmt.Errorf("Unrecognised network type"))
 	}
 
-	url := fmt.Sprintf("/%s/networks/%s", version.APIVersion, req.Name)
-	resp := response.SyncResponseLocation(true, nil, url)
+	resp := response.SyncResponseLocation(true, nil, networkURL(projectName, req.Name))
 
 	if isClusterNotification(r) {
 		// This is an internal request which triggers the actual creation of the network across all nodes
 		// after they have been previously defined.
-		err = doNetworksCreate(d, req, true)
+		err = doNetworksCreate(d, projectName, req, true)
 		if err != nil {
 			return response.SmartError(err)
 		}
@@ -153,6 +187,38 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 	}
 
 	targetNode := queryParam(r, "target")
+
+	// Networks in non-default projects are restricted to OVN networks defined cluster-wide, and are subject to
+	// the project's limits and restrictions.
+	if projectName != project.Default {
+		if req.Type != "ovn" {
+			return response.BadRequest(fmt.Errorf("Only OVN networks can be created in non-default projects"))
+		}
+
+		if targetNode != "" {
+			return response.BadRequest(fmt.Errorf("Networks in non-default projects can't have node-specific config"))
+		}
+
+		_, _, err = d.cluster.GetNetworkInAnyState(projectName, req.Name)
+		if err == nil {
+			return response.Conflict(fmt.Errorf("Network %q already exists in project %q", req.Name, projectName))
+		} else if err != db.ErrNoSuchObject {
+			return response.SmartError(err)
+		}
+
+		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			err := project.AllowNetworkCreation(tx, projectName)
+			if err != nil {
+				return err
+			}
+
+			return project.AllowNetworkUplink(tx, projectName, req.Config["parent"])
+		})
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
+
 	if targetNode != "" {
 		// A targetNode was specified, let's just define the node's network without actually creating it.
 		// Check that only NodeSpecificNetworkConfig keys are specified.
@@ -163,7 +229,7 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 		}
 
 		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
-			return tx.CreatePendingNetwork(targetNode, req.Name, dbNetType, req.Config)
+			return tx.CreatePendingNetwork(targetNode, projectName, req.Name, dbNetType, req.Config)
 		})
 		if err != nil {
 			if err == db.ErrAlreadyDefined {
@@ -181,7 +247,7 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 	}
 
 	if count > 1 {
-		err = networksPostCluster(d, req)
+		err = networksPostCluster(d, projectName, req, dbNetType)
 		if err != nil {
 			return response.SmartError(err)
 		}
@@ -195,30 +261,32 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
-	networks, err := networkGetInterfaces(d.cluster)
-	if err != nil {
-		return response.InternalError(err)
-	}
+	if projectName == project.Default {
+		networks, err := networkGetInterfaces(d.cluster)
+		if err != nil {
+			return response.InternalError(err)
+		}
 
-	if shared.StringInSlice(req.Name, networks) {
-		return response.BadRequest(fmt.Errorf("The network already exists"))
+		if shared.StringInSlice(req.Name, networks) {
+			return response.BadRequest(fmt.Errorf("The network already exists"))
+		}
 	}
 
 	revert := revert.New()
 	defer revert.Fail()
 
 	// Create the database entry.
-	_, err = d.cluster.CreateNetwork(req.Name, req.Description, dbNetType, req.Config)
+	_, err = d.cluster.CreateNetwork(projectName, req.Name, req.Description, dbNetType, req.Config)
 	if err != nil {
 		return response.SmartError(errors.Wrapf(err, "Error inserting %q into database", req.Name))
 	}
 
 	revert.Add(func() {
-		d.cluster.DeleteNetwork(req.Name)
+		d.cluster.DeleteNetwork(projectName, req.Name)
 	})
 
 	// Create network and pass false to clusterNotification so the database record is removed on error.
-	err = doNetworksCreate(d, req, false)
+	err = doNetworksCreate(d, projectName, req, false)
 	if err != nil {
 		return response.SmartError(err)
 	}
@@ -227,17 +295,19 @@ func networksPost(d *Daemon, r *http.Request) response.Response {
 	return resp
 }
 
-func networksPostCluster(d *Daemon, req api.NetworksPost) error {
+func networksPostCluster(d *Daemon, projectName string, req api.NetworksPost, dbNetType db.NetworkType) error {
 	// Check that no node-specific config key has been defined.
-	for key := range req.Config {
-		if shared.StringInSlice(key, db.NodeSpecificNetworkConfig) {
-			return fmt.Errorf("Config key %q is node-specific", key)
+	if projectName == project.Default {
+		for key := range req.Config {
+			if shared.StringInSlice(key, db.NodeSpecificNetworkConfig) {
+				return fmt.Errorf("Config key %q is node-specific", key)
+			}
 		}
 	}
 
 	// Check that the requested network type matches the type created when adding the local node config.
 	// If network doesn't exist yet, ignore not found error, as this will be checked by NetworkNodeConfigs().
-	_, netInfo, err := d.cluster.GetNetworkInAnyState(req.Name)
+	_, netInfo, err := d.cluster.GetNetworkInAnyState(projectName, req.Name)
 	if err != nil && err != db.ErrNoSuchObject {
 		return err
 	}
@@ -252,13 +322,35 @@ func networksPostCluster(d *Daemon, req api.NetworksPost) error {
 		return err
 	}
 
+	// Networks in non-default projects have no node-specific config, so define them on all nodes in one go.
+	if projectName != project.Default {
+		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			nodes, err := tx.GetNodes()
+			if err != nil {
+				return err
+			}
+
+			for _, node := range nodes {
+				err = tx.CreatePendingNetwork(node.Name, projectName, req.Name, dbNetType, nil)
+				if err != nil && err != db.ErrAlreadyDefined {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	// Check that the network is properly defined, get the node-specific configs and merge with global config.
 	var configs map[string]map[string]string
 	var nodeName string
 	var networkID int64
 	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
 		// Fetch the network ID.
-		networkID, err = tx.GetNetworkID(req.Name)
+		networkID, err = tx.GetNetworkID(projectName, req.Name)
 		if err != nil {
 			return err
 		}
@@ -303,20 +395,20 @@ func networksPostCluster(d *Daemon, req api.NetworksPost) error {
 
 	revert.Add(func() {
 		d.cluster.Transaction(func(tx *db.ClusterTx) error {
-			return tx.NetworkErrored(req.Name)
+			return tx.NetworkErrored(projectName, req.Name)
 		})
 	})
 
 	// We need to mark the network as created now, because the network.LoadByName call invoked by
 	// doNetworksCreate would fail with not-found otherwise.
 	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
-		return tx.NetworkCreated(req.Name)
+		return tx.NetworkCreated(projectName, req.Name)
 	})
 	if err != nil {
 		return err
 	}
 
-	err = doNetworksCreate(d, nodeReq, false)
+	err = doNetworksCreate(d, projectName, nodeReq, false)
 	if err != nil {
 		return err
 	}
@@ -332,7 +424,7 @@ func networksPostCluster(d *Daemon, req api.NetworksPost) error {
 			nodeReq.Config[key] = value
 		}
 
-		return client.CreateNetwork(nodeReq)
+		return client.UseProject(projectName).CreateNetwork(nodeReq)
 	})
 	if err != nil {
 		return err
@@ -344,9 +436,9 @@ func networksPostCluster(d *Daemon, req api.NetworksPost) error {
 
 // Create the network on the system. The clusterNotification flag is used to indicate whether creation request
 // is coming from a cluster notification (and if so we should not delete the database record on error).
-func doNetworksCreate(d *Daemon, req api.NetworksPost, clusterNotification bool) error {
+func doNetworksCreate(d *Daemon, projectName string, req api.NetworksPost, clusterNotification bool) error {
 	// Start the network.
-	n, err := network.LoadByName(d.State(), req.Name)
+	n, err := network.LoadByName(d.State(), projectName, req.Name)
 	if err != nil {
 		return err
 	}
@@ -379,9 +471,14 @@ func networkGet(d *Daemon, r *http.Request) response.Response {
 		return resp
 	}
 
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	name := mux.Vars(r)["name"]
 
-	n, err := doNetworkGet(d, name)
+	n, err := doNetworkGet(d, projectName, name)
 	if err != nil {
 		return response.SmartError(err)
 	}
@@ -394,7 +491,7 @@ func networkGet(d *Daemon, r *http.Request) response.Response {
 
 	// If no target node is specified and the daemon is clustered, we omit
 	// the node-specific fields.
-	if targetNode == "" && clustered {
+	if targetNode == "" && clustered && projectName == project.Default {
 		for _, key := range db.NodeSpecificNetworkConfig {
 			delete(n.Config, key)
 		}
@@ -405,15 +502,19 @@ func networkGet(d *Daemon, r *http.Request) response.Response {
 	return response.SyncResponseETag(true, &n, etag)
 }
 
-func doNetworkGet(d *Daemon, name string) (api.Network, error) {
+func doNetworkGet(d *Daemon, projectName string, name string) (api.Network, error) {
 	// Ignore veth pairs (for performance reasons)
 	if strings.HasPrefix(name, "veth") {
 		return api.Network{}, os.ErrNotExist
 	}
 
-	// Get some information
-	osInfo, _ := net.InterfaceByName(name)
-	_, dbInfo, _ := d.cluster.GetNetworkInAnyState(name)
+	// Get some information (only networks in the default project can be host network interfaces).
+	var osInfo *net.Interface
+	if projectName == project.Default {
+		osInfo, _ = net.InterfaceByName(name)
+	}
+
+	_, dbInfo, _ := d.cluster.GetNetworkInAnyState(projectName, name)
 
 	// Sanity check
 	if osInfo == nil && dbInfo == nil {
@@ -460,7 +561,7 @@ func doNetworkGet(d *Daemon, name string) (api.Network, error) {
 		}
 
 		for _, inst := range insts {
-			inUse, err := network.IsInUseByInstance(d.State(), inst, n.Name)
+			inUse, err := network.IsInUseByInstance(d.State(), inst, projectName, n.Name)
 			if err != nil {
 				return api.Network{}, err
 			}
@@ -489,7 +590,7 @@ func doNetworkGet(d *Daemon, name string) (api.Network, error) {
 		}
 
 		for _, profile := range profiles {
-			inUse, err := network.IsInUseByProfile(d.State(), *db.ProfileToAPI(&profile), n.Name)
+			inUse, err := network.IsInUseByProfile(d.State(), profile.Project, *db.ProfileToAPI(&profile), projectName, n.Name)
 			if err != nil {
 				return api.Network{}, err
 			}
@@ -513,16 +614,21 @@ func doNetworkGet(d *Daemon, name string) (api.Network, error) {
 }
 
 func networkDelete(d *Daemon, r *http.Request) response.Response {
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	name := mux.Vars(r)["name"]
 	state := d.State()
 
 	// Check if the network is pending, if so we just need to delete it from the database.
-	_, dbNetwork, err := d.cluster.GetNetworkInAnyState(name)
+	_, dbNetwork, err := d.cluster.GetNetworkInAnyState(projectName, name)
 	if err != nil {
 		return response.SmartError(err)
 	}
 	if dbNetwork.Status == api.NetworkStatusPending {
-		err := d.cluster.DeleteNetwork(name)
+		err := d.cluster.DeleteNetwork(projectName, name)
 		if err != nil {
 			return response.SmartError(err)
 		}
@@ -530,7 +636,7 @@ func networkDelete(d *Daemon, r *http.Request) response.Response {
 	}
 
 	// Get the existing network.
-	n, err := network.LoadByName(state, name)
+	n, err := network.LoadByName(state, projectName, name)
 	if err != nil {
 		return response.NotFound(err)
 	}
@@ -573,6 +679,11 @@ func networkPost(d *Daemon, r *http.Request) response.Response {
 		return response.BadRequest(fmt.Errorf("Renaming a network not supported in LXD clusters"))
 	}
 
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	name := mux.Vars(r)["name"]
 	req := api.NetworkPost{}
 	state := d.State()
@@ -584,7 +695,7 @@ func networkPost(d *Daemon, r *http.Request) response.Response {
 	}
 
 	// Get the existing network
-	n, err := network.LoadByName(state, name)
+	n, err := network.LoadByName(state, projectName, name)
 	if err != nil {
 		return response.NotFound(err)
 	}
@@ -600,7 +711,12 @@ func networkPost(d *Daemon, r *http.Request) response.Response {
 	}
 
 	// Check that the name isn't already in use
-	networks, err := networkGetInterfaces(d.cluster)
+	var networks []string
+	if projectName == project.Default {
+		networks, err = networkGetInterfaces(d.cluster)
+	} else {
+		networks, err = d.cluster.GetNetworks(projectName)
+	}
 	if err != nil {
 		return response.InternalError(err)
 	}
@@ -615,7 +731,7 @@ func networkPost(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
-	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/networks/%s", version.APIVersion, req.Name))
+	return response.SyncResponseLocation(true, nil, networkURL(projectName, req.Name))
 }
 
 func networkPut(d *Daemon, r *http.Request) response.Response {
@@ -625,10 +741,15 @@ func networkPut(d *Daemon, r *http.Request) response.Response {
 		return resp
 	}
 
+	projectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
 	name := mux.Vars(r)["name"]
 
 	// Get the existing network.
-	_, dbInfo, err := d.cluster.GetNetworkInAnyState(name)
+	_, dbInfo, err := d.cluster.GetNetworkInAnyState(projectName, name)
 	if err != nil {
 		return response.SmartError(err)
 	}
@@ -639,6 +760,16 @@ func networkPut(d *Daemon, r *http.Request) response.Response {
 		return response.SmartError(err)
 	}
 
+	// Networks in non-default projects don't have node-specific config, so their config is the same on all
+	// nodes and is always updated as a whole.
+	if projectName != project.Default {
+		if targetNode != "" {
+			return response.BadRequest(fmt.Errorf("Networks in non-default projects can't have node-specific config"))
+		}
+
+		clustered = false
+	}
+
 	// If no target node is specified and the daemon is clustered, we omit the node-specific fields so that
 	// the e-tag can be generated correctly. This is because the GET request used to populate the request
 	// will also remove node-specific keys when no target is specified.
@@ -681,7 +812,7 @@ func networkPut(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
-	return doNetworkUpdate(d, name, req, targetNode, isClusterNotification(r), r.Method, clustered)
+	return doNetworkUpdate(d, projectName, name, req, targetNode, isClusterNotification(r), r.Method, clustered)
 }
 
 func networkPatch(d *Daemon, r *http.Request) response.Response {
@@ -690,9 +821,9 @@ func networkPatch(d *Daemon, r *http.Request) response.Response {
 
 // doNetworkUpdate loads the current local network config, merges with the requested network config, validates
 // and applies the changes. Will also notify other cluster nodes of non-node specific config if needed.
-func doNetworkUpdate(d *Daemon, name string, req api.NetworkPut, targetNode string, clusterNotification bool, httpMethod string, clustered bool) response.Response {
+func doNetworkUpdate(d *Daemon, projectName string, name string, req api.NetworkPut, targetNode string, clusterNotification bool, httpMethod string, clustered bool) response.Response {
 	// Load the local node-specific network.
-	n, err := network.LoadByName(d.State(), name)
+	n, err := network.LoadByName(d.State(), projectName, name)
 	if err != nil {
 		return response.NotFound(err)
 	}
@@ -729,6 +860,16 @@ func doNetworkUpdate(d *Daemon, name string, req api.NetworkPut, targetNode stri
 		return response.BadRequest(err)
 	}
 
+	// Check the project's restrictions allow the uplink network if it is being changed.
+	if projectName != project.Default && !clusterNotification && req.Config["parent"] != n.Config()["parent"] {
+		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			return project.AllowNetworkUplink(tx, projectName, req.Config["parent"])
+		})
+		if err != nil {
+			return response.BadRequest(err)
+		}
+	}
+
 	// Apply the new configuration (will also notify other cluster nodes if needed).
 	err = n.Update(req, targetNode, clusterNotification)
 	if err != nil {
@@ -740,10 +881,15 @@ func doNetworkUpdate(d *Daemon, name string, req api.NetworkPut, targetNode stri
 
 func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 	name := mux.Vars(r)["name"]
-	project := projectParam(r)
+	projectName := projectParam(r)
+
+	networkProjectName, err := project.NetworkProject(d.State().Cluster, projectName)
+	if err != nil {
+		return response.SmartError(err)
+	}
 
 	// Try to get the network
-	n, err := doNetworkGet(d, name)
+	n, err := doNetworkGet(d, networkProjectName, name)
 	if err != nil {
 		return response.SmartError(err)
 	}
@@ -759,7 +905,7 @@ func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 	// Get all static leases
 	if !isClusterNotification(r) {
 		// Get all the instances
-		instances, err := instance.LoadByProject(d.State(), project)
+		instances, err := instance.LoadByProject(d.State(), projectName)
 		if err != nil {
 			return response.SmartError(err)
 		}
@@ -772,7 +918,7 @@ func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 					continue
 				}
 
-				nicType, err := nictype.NICType(d.State(), dev)
+				nicType, err := nictype.NICType(d.State(), projectName, dev)
 				if err != nil || nicType != "bridged" {
 					continue
 				}
@@ -820,6 +966,36 @@ func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 		}
 	}
 
+	// Get the DHCP reservations of the network, which are only visible from the project the network is in.
+	if !isClusterNotification(r) && projectName == networkProjectName {
+		networkID, _, err := d.cluster.GetNetworkInAnyState(networkProjectName, name)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		reservations, err := d.cluster.GetNetworkLeaseReservations(networkID)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		for _, reservation := range reservations {
+			projectMacs = append(projectMacs, reservation.Hwaddr)
+
+			for _, address := range []string{reservation.IPv4Address, reservation.IPv6Address} {
+				if address == "" {
+					continue
+				}
+
+				leases = append(leases, api.NetworkLease{
+					Hostname: reservation.Hostname,
+					Address:  address,
+					Hwaddr:   reservation.Hwaddr,
+					Type:     "static",
+				})
+			}
+		}
+	}
+
 	// Local server name.
 	var serverName string
 	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
@@ -912,32 +1088,126 @@ func networkLeasesGet(d *Daemon, r *http.Request) response.Response {
 	return response.SyncResponse(true, leases)
 }
 
+// networkLeaseResponse converts a network driver error into a response.
+func networkLeaseResponse(n network.Network, err error) response.Response {
+	if err == network.ErrNotImplemented {
+		return response.BadRequest(fmt.Errorf("Network driver %q does not support DHCP reservations", n.Type()))
+	}
+
+	return response.SmartError(err)
+}
+
+func networkLeasesPost(d *Daemon, r *http.Request) response.Response {
+	name := mux.Vars(r)["name"]
+	clusterNotification := isClusterNotification(r)
+
+	networkProjectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), networkProjectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	req := api.NetworkLeasesPost{}
+
+	// Parse the request.
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	mac, err := net.ParseMAC(req.Hwaddr)
+	if err != nil {
+		return response.BadRequest(fmt.Errorf("Invalid MAC address %q", req.Hwaddr))
+	}
+
+	req.Hwaddr = mac.String()
+
+	// The reservation is already in the database when notified by another cluster member.
+	if !clusterNotification {
+		_, _, err = d.cluster.GetNetworkLeaseReservation(n.ID(), req.Hwaddr)
+		if err == nil {
+			return response.Conflict(fmt.Errorf("A DHCP reservation for %q already exists", req.Hwaddr))
+		} else if err != db.ErrNoSuchObject {
+			return response.SmartError(err)
+		}
+	}
+
+	err = n.LeaseReservationCreate(req, clusterNotification)
+	if err != nil {
+		return networkLeaseResponse(n, err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+func networkLeaseDelete(d *Daemon, r *http.Request) response.Response {
+	name := mux.Vars(r)["name"]
+	clusterNotification := isClusterNotification(r)
+
+	networkProjectName, err := project.NetworkProject(d.State().Cluster, projectParam(r))
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	n, err := network.LoadByName(d.State(), networkProjectName, name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	mac, err := net.ParseMAC(mux.Vars(r)["hwaddr"])
+	if err != nil {
+		return response.BadRequest(fmt.Errorf("Invalid MAC address %q", mux.Vars(r)["hwaddr"]))
+	}
+
+	err = n.LeaseReservationDelete(mac.String(), clusterNotification)
+	if err != nil {
+		return networkLeaseResponse(n, err)
+	}
+
+	return response.EmptySyncResponse
+}
+
 func networkStartup(s *state.State) error {
 	// Get a list of managed networks.
-	networks, err := s.Cluster.GetNonPendingNetworks()
+	projectNetworks, err := s.Cluster.GetNonPendingNetworks()
 	if err != nil {
 		return errors.Wrapf(err, "Failed to load networks")
 	}
 
-	// Bring them all up.
-	for _, name := range networks {
-		n, err := network.LoadByName(s, name)
-		if err != nil {
-			return errors.Wrapf(err, "Failed to load network %q", name)
+	// Bring up the networks in the default project first, as networks in other projects may use them as
+	// uplinks.
+	projectNames := []string{project.Default}
+	for projectName := range projectNetworks {
+		if projectName != project.Default {
+			projectNames = append(projectNames, projectName)
 		}
+	}
 
-		err = n.Validate(n.Config())
-		if err != nil {
-			// Don't cause LXD to fail to start entirely on network start up failure.
-			logger.Error("Failed to validate network", log.Ctx{"err": err, "name": name})
-			continue
-		}
+	// Bring them all up.
+	for _, projectName := range projectNames {
+		for _, name := range projectNetworks[projectName] {
+			n, err := network.LoadByName(s, projectName, name)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to load network %q in project %q", name, projectName)
+			}
 
-		err = n.Start()
-		if err != nil {
-			// Don't cause LXD to fail to start entirely on network start up failure.
-			logger.Error("Failed to bring up network", log.Ctx{"err": err, "name": name})
-			continue
+			err = n.Validate(n.Config())
+			if err != nil {
+				// Don't cause LXD to fail to start entirely on network start up failure.
+				logger.Error("Failed to validate network", log.Ctx{"err": err, "project": projectName, "name": name})
+				continue
+			}
+
+			err = n.Start()
+			if err != nil {
+				// Don't cause LXD to fail to start entirely on network start up failure.
+				logger.Error("Failed to bring up network", log.Ctx{"err": err, "project": projectName, "name": name})
+				continue
+			}
 		}
 	}
 
@@ -946,21 +1216,23 @@ func networkStartup(s *state.State) error {
 
 func networkShutdown(s *state.State) error {
 	// Get a list of managed networks
-	networks, err := s.Cluster.GetNetworks()
+	projectNetworks, err := s.Cluster.GetNonPendingNetworks()
 	if err != nil {
 		return err
 	}
 
-	// Bring them all up
-	for _, name := range networks {
-		n, err := network.LoadByName(s, name)
-		if err != nil {
-			return err
-		}
+	// Bring them all down
+	for projectName, networks := range projectNetworks {
+		for _, name := range networks {
+			n, err := network.LoadByName(s, projectName, name)
+			if err != nil {
+				return err
+			}
 
-		err = n.Stop()
-		if err != nil {
-			logger.Error("Failed to bring down network", log.Ctx{"err": err, "name": name})
+			err = n.Stop()
+			if err != nil {
+				logger.Error("Failed to bring down network", log.Ctx{"err": err, "project": projectName, "name": name})
+			}
 		}
 	}
 
@@ -99,6 +99,8 @@ var patches = []patch{
 	{name: "storage_lvm_skipactivation", stage: patchPostDaemonStorage, run: patchGenericStorage},
 	{name: "clustering_drop_database_role", stage: patchPostDaemonStorage, run: patchClusteringDropDatabaseRole},
 	{name: "network_clear_bridge_volatile_hwaddr", stage: patchPostDaemonStorage, run: patchNetworkCearBridgeVolatileHwaddr},
+	{name: "network_ovn_enable_nat", stage: patchPostDaemonStorage, run: patchNetworkOVNEnableNAT},
+	{name: "storage_create_buckets", stage: patchPostDaemonStorage, run: patchGenericStorage},
 }
 
 type patch struct {
@@ -298,7 +300,7 @@ func patchInvalidProfileNames(name string, d *Daemon) error {
 
 func patchNetworkPermissions(name string, d *Daemon) error {
 	// Get the list of networks
-	networks, err := d.cluster.GetNetworks()
+	networks, err := d.cluster.GetNetworks(project.Default)
 	if err != nil {
 		return err
 	}
@@ -2660,7 +2662,7 @@ func patchStorageZFSVolumeSize(name string, d *Daemon) error {
 
 func patchNetworkDnsmasqHosts(name string, d *Daemon) error {
 	// Get the list of networks
-	networks, err := d.cluster.GetNetworks()
+	networks, err := d.cluster.GetNetworks(project.Default)
 	if err != nil {
 		return err
 	}
@@ -3448,20 +3450,20 @@ func patchClusteringDropDatabaseRole(name string, d *Daemon) error {
 // patchNetworkCearBridgeVolatileHwaddr removes the unsupported `volatile.bridge.hwaddr` config key from networks.
 func patchNetworkCearBridgeVolatileHwaddr(name string, d *Daemon) error {
 	// Get the list of networks.
-	networks, err := d.cluster.GetNetworks()
+	networks, err := d.cluster.GetNetworks(project.Default)
 	if err != nil {
 		return errors.Wrapf(err, "Failed loading networks for network_clear_bridge_volatile_hwaddr patch")
 	}
 
 	for _, networkName := range networks {
-		_, net, err := d.cluster.GetNetworkInAnyState(networkName)
+		_, net, err := d.cluster.GetNetworkInAnyState(project.Default, networkName)
 		if err != nil {
 			return errors.Wrapf(err, "Failed loading network %q for network_clear_bridge_volatile_hwaddr patch", networkName)
 		}
 
 		if net.Config["volatile.bridge.hwaddr"] != "" {
 			delete(net.Config, "volatile.bridge.hwaddr")
-			err = d.cluster.UpdateNetwork(net.Name, net.Description, net.Config)
+			err = d.cluster.UpdateNetwork(project.Default, net.Name, net.Description, net.Config)
 			if err != nil {
 				return errors.Wrapf(err, "Failed updating network %q for network_clear_bridge_volatile_hwaddr patch", networkName)
 			}
@@ -3471,6 +3473,47 @@ func patchNetworkCearBridgeVolatileHwaddr(name string, d *Daemon) error {
 	return nil
 }
 
+// patchNetworkOVNEnableNAT adds "ipv4.nat" and "ipv6.nat" keys set to "true" to OVN networks if not present.
+// This is to ensure existing networks retain the old behaviour of always having NAT enabled as we introduce
+// the new NAT settings which default to disabled if not specified.
+func patchNetworkOVNEnableNAT(name string, d *Daemon) error {
+	// Get the list of networks.
+	networks, err := d.cluster.GetNetworks(project.Default)
+	if err != nil {
+		return errors.Wrapf(err, "Failed loading networks for network_ovn_enable_nat patch")
+	}
+
+	for _, networkName := range networks {
+		_, net, err := d.cluster.GetNetworkInAnyState(project.Default, networkName)
+		if err != nil {
+			return errors.Wrapf(err, "Failed loading network %q for network_ovn_enable_nat patch", networkName)
+		}
+
+		if net.Type != "ovn" {
+			continue
+		}
+
+		modified := false
+
+		// Enable NAT for existing IP families.
+		for _, keyPrefix := range []string{"ipv4", "ipv6"} {
+			if !shared.StringInSlice(net.Config[fmt.Sprintf("%s.address", keyPrefix)], []string{"", "none"}) && net.Config[fmt.Sprintf("%s.nat", keyPrefix)] == "" {
+				net.Config[fmt.Sprintf("%s.nat", keyPrefix)] = "true"
+				modified = true
+			}
+		}
+
+		if modified {
+			err = d.cluster.UpdateNetwork(project.Default, net.Name, net.Description, net.Config)
+			if err != nil {
+				return errors.Wrapf(err, "Failed updating network %q for network_ovn_enable_nat patch", networkName)
+			}
+		}
+	}
+
+	return nil
+}
+
 // Patches end here
 
 // Here are a couple of legacy patches that were originally in
@@ -170,6 +170,64 @@ func AllowVolumeCreation(tx *db.ClusterTx, projectName string, req api.StorageVo
 	return nil
 }
 
+// AllowNetworkCreation returns an error if any project-specific limit or
+// restriction is violated when creating a new network in a project.
+func AllowNetworkCreation(tx *db.ClusterTx, projectName string) error {
+	project, err := tx.GetProject(projectName)
+	if err != nil {
+		return errors.Wrap(err, "Fetch project database object")
+	}
+
+	value, ok := project.Config["limits.networks"]
+	if !ok {
+		return nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return fmt.Errorf("Unexpected 'limits.networks' value: '%s'", value)
+	}
+
+	networks, err := tx.GetNetworksInProject(projectName)
+	if err != nil {
+		return err
+	}
+
+	if len(networks) >= limit {
+		return fmt.Errorf("Reached maximum number of networks in project %s", projectName)
+	}
+
+	return nil
+}
+
+// AllowNetworkUplink returns an error if the network with the given name can't
+// be used as an uplink by networks in the project, as per its
+// "restricted.networks.uplinks" setting.
+func AllowNetworkUplink(tx *db.ClusterTx, projectName string, uplinkName string) error {
+	project, err := tx.GetProject(projectName)
+	if err != nil {
+		return errors.Wrap(err, "Fetch project database object")
+	}
+
+	if !shared.IsTrue(project.Config["restricted"]) {
+		return nil
+	}
+
+	allowedUplinks := []string{}
+	for _, uplink := range strings.Split(project.Config["restricted.networks.uplinks"], ",") {
+		uplink = strings.TrimSpace(uplink)
+		if uplink != "" {
+			allowedUplinks = append(allowedUplinks, uplink)
+		}
+	}
+
+	if !shared.StringInSlice(uplinkName, allowedUplinks) {
+		return fmt.Errorf("Uplink network %q is not allowed in project %s", uplinkName, projectName)
+	}
+
+	return nil
+}
+
 // GetImageSpaceBudget returns how much disk space is left in the given project
 // for writing images.
 //
@@ -708,6 +766,11 @@ func AllowProjectUpdate(tx *db.ClusterTx, projectName string, config map[string]
 			if err != nil {
 				return errors.Wrapf(err, "Can't change %q in project %q", key, projectName)
 			}
+		case "limits.networks":
+			err := validateNetworkCountLimit(tx, config[key], projectName)
+			if err != nil {
+				return errors.Wrapf(err, "Can't change %q in project %q", key, projectName)
+			}
 		case "limits.processes":
 			fallthrough
 		case "limits.cpu":
@@ -771,6 +834,31 @@ func validateInstanceCountLimit(instances []db.Instance, key, value, project str
 	return nil
 }
 
+// Check that limits.networks is equal or above the current count.
+func validateNetworkCountLimit(tx *db.ClusterTx, value, project string) error {
+	if value == "" {
+		return nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+
+	networks, err := tx.GetNetworksInProject(project)
+	if err != nil {
+		return err
+	}
+
+	if limit < len(networks) {
+		return fmt.Errorf(
+			"'limits.networks' is too low: there currently are %d networks in project %s",
+			len(networks), project)
+	}
+
+	return nil
+}
+
 var countConfigInstanceType = map[string]api.InstanceType{
 	"limits.containers":       api.InstanceTypeContainer,
 	"limits.virtual-machines": api.InstanceTypeVM,
@@ -74,3 +74,19 @@ func forwardedResponseIfVolumeIsRemote(d *Daemon, r *http.Request, poolID int64,
 	}
 	return response.ForwardedResponse(client, r)
 }
+
+// forwardedResponseIfBucketIsRemote redirects a request to the node holding the storage bucket with the given
+// pool ID, project and name. If the bucket is local, nothing gets done and nil is returned.
+func forwardedResponseIfBucketIsRemote(d *Daemon, r *http.Request, poolID int64, projectName string, bucketName string) response.Response {
+	cert := d.endpoints.NetworkCert()
+	client, err := cluster.ConnectIfBucketIsRemote(d.cluster, poolID, projectName, bucketName, cert)
+	if err != nil && err != db.ErrNoSuchObject {
+		return response.SmartError(err)
+	}
+
+	if client == nil {
+		return nil
+	}
+
+	return response.ForwardedResponse(client, r)
+}
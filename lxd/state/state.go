@@ -8,6 +8,7 @@ import (
 	"net/url"
 
 	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/dns"
 	"github.com/lxc/lxd/lxd/endpoints"
 	"github.com/lxc/lxd/lxd/events"
 	"github.com/lxc/lxd/lxd/firewall"
@@ -40,12 +41,15 @@ type State struct {
 	// Firewall instance
 	Firewall firewall.Firewall
 
+	// DNS server serving the network zones
+	DNS *dns.Server
+
 	Context context.Context
 }
 
 // NewState returns a new State object with the given database and operating
 // system components.
-func NewState(ctx context.Context, node *db.Node, cluster *db.Cluster, maas *maas.Controller, os *sys.OS, endpoints *endpoints.Endpoints, events *events.Server, devlxdEvents *events.Server, firewall firewall.Firewall, proxy func(req *http.Request) (*url.URL, error)) *State {
+func NewState(ctx context.Context, node *db.Node, cluster *db.Cluster, maas *maas.Controller, os *sys.OS, endpoints *endpoints.Endpoints, events *events.Server, devlxdEvents *events.Server, firewall firewall.Firewall, dns *dns.Server, proxy func(req *http.Request) (*url.URL, error)) *State {
 	return &State{
 		Node:         node,
 		Cluster:      cluster,
@@ -55,6 +59,7 @@ func NewState(ctx context.Context, node *db.Node, cluster *db.Cluster, maas *maa
 		DevlxdEvents: devlxdEvents,
 		Events:       events,
 		Firewall:     firewall,
+		DNS:          dns,
 		Proxy:        proxy,
 		Context:      ctx,
 	}
@@ -109,6 +109,12 @@ func setupStorageDriver(s *state.State, forceCheck bool) error {
 		}
 	}
 
+	// Mount the storage buckets now that their pools are mounted.
+	err = storageBucketsMount(s)
+	if err != nil {
+		return err
+	}
+
 	// Update the storage drivers cache in api_1.0.go.
 	storagePoolDriversCacheUpdate(s)
 	return nil
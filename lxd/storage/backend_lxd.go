@@ -152,6 +152,46 @@ func (b *lxdBackend) newVolume(volType drivers.VolumeType, contentType drivers.C
 	return drivers.NewVolume(b.driver, b.name, volType, contentType, volName, newConfig, newPoolConfig)
 }
 
+// copyVolumeKey copies the encryption key of the source volume to the target volume on this pool. This is needed
+// as copies of encrypted volumes made by the storage driver are encrypted using the source volume's key.
+// Does nothing if the target volume isn't encrypted or the source volume doesn't have a key.
+func (b *lxdBackend) copyVolumeKey(vol drivers.Volume, srcVol drivers.Volume) error {
+	if !vol.IsEncrypted() {
+		return nil
+	}
+
+	srcParentName, _, _ := shared.InstanceGetParentAndSnapshotName(srcVol.Name())
+	key, err := getPoolKey(b.name, srcVol.Type(), srcParentName)
+	if err != nil {
+		return err
+	}
+
+	if key == "" {
+		return nil
+	}
+
+	return setPoolKey(b.name, vol.Type(), vol.Name(), key)
+}
+
+// importVolumeKey checks the keyfile of the pool has the encryption key of a recovered volume.
+// Does nothing if the volume isn't encrypted.
+func (b *lxdBackend) importVolumeKey(vol drivers.Volume) error {
+	if !vol.IsEncrypted() {
+		return nil
+	}
+
+	key, err := getPoolKey(b.name, vol.Type(), vol.Name())
+	if err != nil {
+		return err
+	}
+
+	if key == "" {
+		return fmt.Errorf("Encryption key of volume %q not found in %q", vol.Name(), poolKeysPath(b.name))
+	}
+
+	return nil
+}
+
 // GetResources returns utilisation information about the pool.
 func (b *lxdBackend) GetResources() (*api.ResourcesStoragePool, error) {
 	logger := logging.AddContext(b.logger, nil)
@@ -514,17 +554,36 @@ func (b *lxdBackend) CreateInstanceFromBackup(srcBackup backup.Info, srcData io.
 	// We will apply the config as part of the post hook function returned if driver needs to.
 	vol := b.newVolume(volType, contentType, volStorageName, nil)
 
+	if *srcBackup.OptimizedStorage && vol.IsEncrypted() {
+		return nil, nil, fmt.Errorf("Optimized backups cannot be restored into encrypted volumes")
+	}
+
 	revert := revert.New()
 	defer revert.Fail()
 
+	// Generate the encryption key of the volume in the pool's keyfile for the driver to use.
+	if vol.IsEncrypted() {
+		key, err := shared.RandomCryptoString()
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "Failed to generate encryption key of volume %q", volStorageName)
+		}
+
+		err = setPoolKey(b.name, volType, volStorageName, key)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		revert.Add(func() { deletePoolKey(b.name, volType, volStorageName) })
+	}
+
 	// Unpack the backup into the new storage volume(s).
-	volPostHook, revertHook, err := b.driver.CreateVolumeFromBackup(vol, srcBackup, srcData, op)
+	volPostHook, volRevertHook, err := b.driver.CreateVolumeFromBackup(vol, srcBackup, srcData, op)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	if revertHook != nil {
-		revert.Add(revertHook)
+	if volRevertHook != nil {
+		revert.Add(volRevertHook)
 	}
 
 	err = b.ensureInstanceSymlink(instanceType, srcBackup.Project, srcBackup.Name, vol.MountPath())
@@ -612,6 +671,7 @@ func (b *lxdBackend) CreateInstanceFromBackup(srcBackup backup.Info, srcData io.
 		return nil
 	}
 
+	revertHook := revert.Clone().Fail
 	revert.Success()
 	return postHook, revertHook, nil
 }
@@ -675,6 +735,11 @@ func (b *lxdBackend) CreateInstanceFromCopy(inst instance.Instance, src instance
 
 	if b.Name() == srcPool.Name() {
 		logger.Debug("CreateInstanceFromCopy same-pool mode detected")
+		err = b.copyVolumeKey(vol, srcVol)
+		if err != nil {
+			return err
+		}
+
 		err = b.driver.CreateVolumeFromCopy(vol, srcVol, snapshots, op)
 		if err != nil {
 			return err
@@ -699,9 +764,9 @@ func (b *lxdBackend) CreateInstanceFromCopy(inst instance.Instance, src instance
 		}
 
 		// Negotiate the migration type to use.
-		offeredTypes := srcPool.MigrationTypes(contentType, false)
+		offeredTypes := VolumeMigrationTypes(srcPool, volType, contentType, nil, false)
 		offerHeader := migration.TypesToHeader(offeredTypes...)
-		migrationTypes, err := migration.MatchTypes(offerHeader, FallbackMigrationType(contentType), b.MigrationTypes(contentType, false))
+		migrationTypes, err := migration.MatchTypes(offerHeader, FallbackMigrationType(contentType), VolumeMigrationTypes(b, volType, contentType, nil, false))
 		if err != nil {
 			return fmt.Errorf("Failed to negotiate copy migration type: %v", err)
 		}
@@ -844,6 +909,11 @@ func (b *lxdBackend) RefreshInstance(inst instance.Instance, src instance.Instan
 
 	if b.Name() == srcPool.Name() {
 		logger.Debug("RefreshInstance same-pool mode detected")
+		err = b.copyVolumeKey(vol, srcVol)
+		if err != nil {
+			return err
+		}
+
 		err = b.driver.RefreshVolume(vol, srcVol, srcSnapVols, op)
 		if err != nil {
 			return err
@@ -861,9 +931,9 @@ func (b *lxdBackend) RefreshInstance(inst instance.Instance, src instance.Instan
 		}
 
 		// Negotiate the migration type to use.
-		offeredTypes := srcPool.MigrationTypes(contentType, true)
+		offeredTypes := VolumeMigrationTypes(srcPool, volType, contentType, nil, true)
 		offerHeader := migration.TypesToHeader(offeredTypes...)
-		migrationTypes, err := migration.MatchTypes(offerHeader, FallbackMigrationType(contentType), b.MigrationTypes(contentType, true))
+		migrationTypes, err := migration.MatchTypes(offerHeader, FallbackMigrationType(contentType), VolumeMigrationTypes(b, volType, contentType, nil, true))
 		if err != nil {
 			return fmt.Errorf("Failed to negotiate copy migration type: %v", err)
 		}
@@ -985,8 +1055,9 @@ func (b *lxdBackend) CreateInstanceFromImage(inst instance.Instance, fingerprint
 	revert.Add(func() { b.DeleteInstance(inst, op) })
 
 	// If the driver doesn't support optimized image volumes then create a new empty volume and
-	// populate it with the contents of the image archive.
-	if !b.driver.Info().OptimizedImages {
+	// populate it with the contents of the image archive. This is also done for encrypted volumes as
+	// optimized image volumes aren't encrypted.
+	if !b.driver.Info().OptimizedImages || vol.IsEncrypted() {
 		volFiller := drivers.VolumeFiller{
 			Fingerprint: fingerprint,
 			Fill:        b.imageFiller(fingerprint, op),
@@ -1257,6 +1328,20 @@ func (b *lxdBackend) RenameInstance(inst instance.Instance, newName string, op *
 		b.driver.RenameVolume(newVol, volStorageName, op)
 	})
 
+	err = renamePoolKey(b.name, volType, volStorageName, newVolStorageName)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { renamePoolKey(b.name, volType, newVolStorageName, volStorageName) })
+
+	err = renameVolumeBackupManifest(b.name, volType, volStorageName, newVolStorageName)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { renameVolumeBackupManifest(b.name, volType, newVolStorageName, volStorageName) })
+
 	// Remove old instance symlink and create new one.
 	err = b.removeInstanceSymlink(inst.Type(), inst.Project(), inst.Name())
 	if err != nil {
@@ -1360,7 +1445,12 @@ func (b *lxdBackend) DeleteInstance(inst instance.Instance, op *operations.Opera
 		return errors.Wrapf(err, "Error deleting storage volume from database")
 	}
 
-	return nil
+	err = deleteVolumeBackupManifest(b.name, volType, volStorageName)
+	if err != nil {
+		return err
+	}
+
+	return deletePoolKey(b.name, volType, volStorageName)
 }
 
 // UpdateInstance updates an instance volume's config.
@@ -1507,7 +1597,7 @@ func (b *lxdBackend) MigrateInstance(inst instance.Instance, conn io.ReadWriteCl
 }
 
 // BackupInstance creates an instance backup.
-func (b *lxdBackend) BackupInstance(inst instance.Instance, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error {
+func (b *lxdBackend) BackupInstance(inst instance.Instance, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, args drivers.BackupArgs, op *operations.Operation) error {
 	logger := logging.AddContext(b.logger, log.Ctx{"project": inst.Project(), "instance": inst.Name(), "optimized": optimized, "snapshots": snapshots})
 	logger.Debug("BackupInstance started")
 	defer logger.Debug("BackupInstance finished")
@@ -1535,14 +1625,109 @@ func (b *lxdBackend) BackupInstance(inst instance.Instance, tarWriter *instancew
 	}
 
 	vol := b.newVolume(volType, contentType, volStorageName, rootDiskConf)
-	err = b.driver.BackupVolume(vol, tarWriter, optimized, snapshots, op)
+
+	if vol.IsEncrypted() {
+		if optimized {
+			return fmt.Errorf("Optimized backups aren't supported for encrypted volumes")
+		}
+
+		// Backups of encrypted volumes contain the decrypted data, so require the caller to opt in.
+		if !args.AllowPlaintext {
+			return fmt.Errorf("Backups of encrypted volumes are unencrypted and must be explicitly allowed")
+		}
+	}
+
+	err = b.driver.BackupVolume(vol, tarWriter, optimized, snapshots, args, op)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RefreshInstanceFromBackup applies an incremental backup onto the existing volume of an instance that was
+// restored from the parent of the backup. The instance must be stopped.
+func (b *lxdBackend) RefreshInstanceFromBackup(inst instance.Instance, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) error {
+	logger := logging.AddContext(b.logger, log.Ctx{"project": inst.Project(), "instance": inst.Name(), "optimizedStorage": *srcBackup.OptimizedStorage})
+	logger.Debug("RefreshInstanceFromBackup started")
+	defer logger.Debug("RefreshInstanceFromBackup finished")
+
+	if inst.IsRunning() {
+		return fmt.Errorf("Instance must be stopped to apply an incremental backup")
+	}
+
+	volType, err := InstanceTypeToVolumeType(inst.Type())
+	if err != nil {
+		return err
+	}
+
+	contentType := InstanceContentType(inst)
+
+	// Get the root disk device config.
+	rootDiskConf, err := b.instanceRootVolumeConfig(inst)
+	if err != nil {
+		return err
+	}
+
+	// Get the volume name on storage.
+	volStorageName := project.Instance(inst.Project(), inst.Name())
+
+	vol := b.newVolume(volType, contentType, volStorageName, rootDiskConf)
+
+	if *srcBackup.OptimizedStorage && vol.IsEncrypted() {
+		return fmt.Errorf("Optimized backups cannot be restored into encrypted volumes")
+	}
+
+	err = b.driver.RefreshVolumeFromBackup(vol, srcBackup, srcData, op)
 	if err != nil {
 		return err
 	}
 
+	// Update pool information in the backup.yaml file.
+	err = vol.MountTask(func(mountPath string, op *operations.Operation) error {
+		return backup.UpdateInstanceConfigStoragePool(b.state.Cluster, srcBackup, mountPath)
+	}, op)
+	if err != nil {
+		return errors.Wrapf(err, "Error updating backup file")
+	}
+
+	// Reapply quota config from root device if its set, as some drivers replace the volume when refreshing it.
+	if rootDiskConf["size"] != "" {
+		logger.Debug("Applying volume quota from root disk config", log.Ctx{"size": rootDiskConf["size"]})
+		err = b.driver.SetVolumeQuota(vol, rootDiskConf["size"], op)
+		if err != nil {
+			if errors.Cause(err) != drivers.ErrCannotBeShrunk {
+				return err
+			}
+
+			logger.Warn("Could not apply volume quota from root disk config as refreshed volume cannot be shrunk", log.Ctx{"size": rootDiskConf["size"]})
+		}
+	}
+
 	return nil
 }
 
+// DeleteInstanceBackupBase removes the base kept on storage for incremental backups of the instance whose parent
+// is the backup with the given UUID.
+func (b *lxdBackend) DeleteInstanceBackupBase(inst instance.Instance, uuid string, op *operations.Operation) error {
+	logger := logging.AddContext(b.logger, log.Ctx{"project": inst.Project(), "instance": inst.Name(), "uuid": uuid})
+	logger.Debug("DeleteInstanceBackupBase started")
+	defer logger.Debug("DeleteInstanceBackupBase finished")
+
+	volType, err := InstanceTypeToVolumeType(inst.Type())
+	if err != nil {
+		return err
+	}
+
+	contentType := InstanceContentType(inst)
+
+	// There's no need to pass config as it's not needed when deleting a backup base.
+	volStorageName := project.Instance(inst.Project(), inst.Name())
+	vol := b.newVolume(volType, contentType, volStorageName, nil)
+
+	return b.driver.DeleteVolumeBackupBase(vol, uuid, op)
+}
+
 // GetInstanceUsage returns the disk usage of the instance's root volume.
 func (b *lxdBackend) GetInstanceUsage(inst instance.Instance) (int64, error) {
 	logger := logging.AddContext(b.logger, log.Ctx{"project": inst.Project(), "instance": inst.Name()})
@@ -1563,10 +1748,11 @@ func (b *lxdBackend) GetInstanceUsage(inst instance.Instance) (int64, error) {
 	return b.driver.GetVolumeUsage(vol)
 }
 
-// SetInstanceQuota sets the quota on the instance's root volume.
+// SetInstanceQuota sets the quota on the instance's root volume. For VMs using block volumes, the vmStateSize
+// argument sets the quota on the associated filesystem volume that holds the VM config and saved state.
 // Returns ErrRunningQuotaResizeNotSupported if the instance is running and the storage driver
 // doesn't support resizing whilst the instance is running.
-func (b *lxdBackend) SetInstanceQuota(inst instance.Instance, size string, op *operations.Operation) error {
+func (b *lxdBackend) SetInstanceQuota(inst instance.Instance, size string, vmStateSize string, op *operations.Operation) error {
 	logger := logging.AddContext(b.logger, log.Ctx{"project": inst.Project(), "instance": inst.Name()})
 	logger.Debug("SetInstanceQuota started")
 	defer logger.Debug("SetInstanceQuota finished")
@@ -1588,7 +1774,21 @@ func (b *lxdBackend) SetInstanceQuota(inst instance.Instance, size string, op *o
 	// There's no need to pass config as it's not needed when setting quotas.
 	vol := b.newVolume(volType, contentVolume, volStorageName, nil)
 
-	return b.driver.SetVolumeQuota(vol, size, op)
+	err = b.driver.SetVolumeQuota(vol, size, op)
+	if err != nil {
+		return err
+	}
+
+	// Apply the state quota on the VM's filesystem volume.
+	if vmStateSize != "" && vol.IsVMBlock() {
+		fsVol := vol.NewVMBlockFilesystemVolume()
+		err = b.driver.SetVolumeQuota(fsVol, vmStateSize, op)
+		if err != nil {
+			return errors.Wrap(err, "Failed setting VM state quota")
+		}
+	}
+
+	return nil
 }
 
 // MountInstance mounts the instance's root volume.
@@ -2375,6 +2575,11 @@ func (b *lxdBackend) CreateCustomVolumeFromCopy(projectName string, volName stri
 			}
 		}
 
+		err = b.copyVolumeKey(vol, srcVol)
+		if err != nil {
+			return err
+		}
+
 		err = b.driver.CreateVolumeFromCopy(vol, srcVol, !srcVolOnly, op)
 		if err != nil {
 			return err
@@ -2389,9 +2594,9 @@ func (b *lxdBackend) CreateCustomVolumeFromCopy(projectName string, volName stri
 	logger.Debug("CreateCustomVolumeFromCopy cross-pool mode detected")
 
 	// Negotiate the migration type to use.
-	offeredTypes := srcPool.MigrationTypes(contentType, false)
+	offeredTypes := VolumeMigrationTypes(srcPool, drivers.VolumeTypeCustom, contentType, srcVolRow.Config, false)
 	offerHeader := migration.TypesToHeader(offeredTypes...)
-	migrationTypes, err := migration.MatchTypes(offerHeader, FallbackMigrationType(contentType), b.MigrationTypes(contentType, false))
+	migrationTypes, err := migration.MatchTypes(offerHeader, FallbackMigrationType(contentType), VolumeMigrationTypes(b, drivers.VolumeTypeCustom, contentType, config, false))
 	if err != nil {
 		return fmt.Errorf("Failed to negotiate copy migration type: %v", err)
 	}
@@ -2593,6 +2798,11 @@ func (b *lxdBackend) RenameCustomVolume(projectName string, volName string, newV
 		return fmt.Errorf("New volume name cannot be a snapshot")
 	}
 
+	volID, _, err := b.state.Cluster.GetLocalStoragePoolVolume(projectName, volName, db.StoragePoolVolumeTypeCustom, b.ID())
+	if err != nil {
+		return err
+	}
+
 	revert := revert.New()
 	defer revert.Fail()
 
@@ -2624,6 +2834,25 @@ func (b *lxdBackend) RenameCustomVolume(projectName string, volName string, newV
 		b.state.Cluster.RenameStoragePoolVolume(projectName, newVolName, volName, db.StoragePoolVolumeTypeCustom, b.ID())
 	})
 
+	// Rename the backups to have the new parent volume prefix.
+	backups, err := backup.VolumeBackupsLoad(b.state, volID)
+	if err != nil {
+		return err
+	}
+
+	for _, volBackup := range backups {
+		volBackup := volBackup
+		oldBackupName := volBackup.Name()
+		newBackupName := fmt.Sprintf("%s/%s", newVolName, strings.SplitN(oldBackupName, "/", 2)[1])
+
+		err = volBackup.Rename(newBackupName)
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() { volBackup.Rename(oldBackupName) })
+	}
+
 	// Get the volume name on storage.
 	volStorageName := project.StorageVolume(projectName, volName)
 	newVolStorageName := project.StorageVolume(projectName, newVolName)
@@ -2636,6 +2865,23 @@ func (b *lxdBackend) RenameCustomVolume(projectName string, volName string, newV
 		return err
 	}
 
+	revert.Add(func() {
+		newVol := b.newVolume(drivers.VolumeTypeCustom, drivers.ContentTypeFS, newVolStorageName, nil)
+		b.driver.RenameVolume(newVol, volStorageName, op)
+	})
+
+	err = renamePoolKey(b.name, drivers.VolumeTypeCustom, volStorageName, newVolStorageName)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { renamePoolKey(b.name, drivers.VolumeTypeCustom, newVolStorageName, volStorageName) })
+
+	err = renameVolumeBackupManifest(b.name, drivers.VolumeTypeCustom, volStorageName, newVolStorageName)
+	if err != nil {
+		return err
+	}
+
 	revert.Success()
 	return nil
 }
@@ -2835,7 +3081,7 @@ func (b *lxdBackend) DeleteCustomVolume(projectName string, volName string, op *
 	volStorageName := project.StorageVolume(projectName, volName)
 
 	// Get the volume.
-	_, poolVol, err := b.state.Cluster.GetLocalStoragePoolVolume(projectName, volName, db.StoragePoolVolumeTypeCustom, b.ID())
+	volID, poolVol, err := b.state.Cluster.GetLocalStoragePoolVolume(projectName, volName, db.StoragePoolVolumeTypeCustom, b.ID())
 	if err != nil {
 		return err
 	}
@@ -2862,13 +3108,31 @@ func (b *lxdBackend) DeleteCustomVolume(projectName string, volName string, op *
 		}
 	}
 
+	// Remove all backups, only once the volume is gone so that they're kept if it couldn't be deleted.
+	backups, err := backup.VolumeBackupsLoad(b.state, volID)
+	if err != nil {
+		return err
+	}
+
+	for _, volBackup := range backups {
+		err = volBackup.Delete()
+		if err != nil {
+			return err
+		}
+	}
+
 	// Finally, remove the volume record from the database.
 	err = b.state.Cluster.RemoveStoragePoolVolume(projectName, volName, db.StoragePoolVolumeTypeCustom, b.ID())
 	if err != nil {
 		return err
 	}
 
-	return nil
+	err = deleteVolumeBackupManifest(b.name, drivers.VolumeTypeCustom, volStorageName)
+	if err != nil {
+		return err
+	}
+
+	return deletePoolKey(b.name, drivers.VolumeTypeCustom, volStorageName)
 }
 
 // GetCustomVolumeDisk returns the location of the disk.
@@ -3180,79 +3444,317 @@ func (b *lxdBackend) RestoreCustomVolume(projectName, volName string, snapshotNa
 	return nil
 }
 
-func (b *lxdBackend) createStorageStructure(path string) error {
-	for _, volType := range b.driver.Info().VolumeTypes {
-		for _, name := range drivers.BaseDirectories[volType] {
-			path := filepath.Join(path, name)
-			err := os.MkdirAll(path, 0711)
-			if err != nil && !os.IsExist(err) {
-				return errors.Wrapf(err, "Failed to create directory '%s'", path)
-			}
-		}
-	}
+// BackupCustomVolume writes a custom volume and optionally its snapshots to the backup tarball.
+func (b *lxdBackend) BackupCustomVolume(projectName string, volName string, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, args drivers.BackupArgs, op *operations.Operation) error {
+	logger := logging.AddContext(b.logger, log.Ctx{"project": projectName, "volName": volName, "optimized": optimized, "snapshots": snapshots})
+	logger.Debug("BackupCustomVolume started")
+	defer logger.Debug("BackupCustomVolume finished")
 
-	return nil
-}
+	if shared.IsSnapshot(volName) {
+		return fmt.Errorf("Volume cannot be snapshot")
+	}
 
-// UpdateInstanceBackupFile writes the instance's config to the backup.yaml file on the storage device.
-func (b *lxdBackend) UpdateInstanceBackupFile(inst instance.Instance, op *operations.Operation) error {
-	logger := logging.AddContext(b.logger, log.Ctx{"project": inst.Project(), "instance": inst.Name()})
-	logger.Debug("UpdateInstanceBackupFile started")
-	defer logger.Debug("UpdateInstanceBackupFile finished")
+	// Get the volume config.
+	_, dbVol, err := b.state.Cluster.GetLocalStoragePoolVolume(projectName, volName, db.StoragePoolVolumeTypeCustom, b.ID())
+	if err != nil {
+		if err == db.ErrNoSuchObject {
+			return fmt.Errorf("Volume doesn't exist")
+		}
 
-	// We only write backup files out for actual instances.
-	if inst.IsSnapshot() {
-		return nil
+		return err
 	}
 
-	// Immediately return if the instance directory doesn't exist yet.
-	if !shared.PathExists(inst.Path()) {
-		return os.ErrNotExist
+	// Get the volume name on storage.
+	volStorageName := project.StorageVolume(projectName, volName)
+	vol := b.newVolume(drivers.VolumeTypeCustom, drivers.ContentType(dbVol.ContentType), volStorageName, dbVol.Config)
+
+	if vol.IsEncrypted() {
+		if optimized {
+			return fmt.Errorf("Optimized backups aren't supported for encrypted volumes")
+		}
+
+		// Backups of encrypted volumes contain the decrypted data, so require the caller to opt in.
+		if !args.AllowPlaintext {
+			return fmt.Errorf("Backups of encrypted volumes are unencrypted and must be explicitly allowed")
+		}
 	}
 
-	// Generate the YAML.
-	ci, _, err := inst.Render()
+	err = b.driver.BackupVolume(vol, tarWriter, optimized, snapshots, args, op)
 	if err != nil {
-		return errors.Wrap(err, "Failed to render instance metadata")
+		return err
 	}
 
-	snapshots, err := inst.Snapshots()
-	if err != nil {
-		return errors.Wrap(err, "Failed to get snapshots")
+	return nil
+}
+
+// CreateCustomVolumeFromBackup restores a custom volume and its snapshots from a backup tarball.
+func (b *lxdBackend) CreateCustomVolumeFromBackup(srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) error {
+	logger := logging.AddContext(b.logger, log.Ctx{"project": srcBackup.Project, "volName": srcBackup.Name, "snapshots": srcBackup.Snapshots, "optimizedStorage": *srcBackup.OptimizedStorage})
+	logger.Debug("CreateCustomVolumeFromBackup started")
+	defer logger.Debug("CreateCustomVolumeFromBackup finished")
+
+	if srcBackup.Volume == nil {
+		return fmt.Errorf("Backup doesn't contain the volume config")
 	}
 
-	var sis []*api.InstanceSnapshot
+	// Backups created before the content type was recorded are of filesystem volumes.
+	contentType := drivers.ContentTypeFS
+	if srcBackup.Volume.ContentType != "" {
+		contentType = drivers.ContentType(srcBackup.Volume.ContentType)
+	}
 
-	for _, s := range snapshots {
-		si, _, err := s.Render()
+	// Check the volume doesn't exist already.
+	_, _, err := b.state.Cluster.GetLocalStoragePoolVolume(srcBackup.Project, srcBackup.Name, db.StoragePoolVolumeTypeCustom, b.ID())
+	if err != db.ErrNoSuchObject {
 		if err != nil {
 			return err
 		}
 
-		sis = append(sis, si.(*api.InstanceSnapshot))
+		return fmt.Errorf("Volume by that name already exists")
 	}
 
-	volType, err := InstanceTypeToVolumeType(inst.Type())
+	// Get the volume name on storage.
+	volStorageName := project.StorageVolume(srcBackup.Project, srcBackup.Name)
+
+	// Validate config.
+	vol := b.newVolume(drivers.VolumeTypeCustom, contentType, volStorageName, srcBackup.Volume.Config)
+	err = b.driver.ValidateVolume(vol, false)
 	if err != nil {
 		return err
 	}
 
-	volDBType, err := VolumeTypeToDBType(volType)
-	if err != nil {
-		return err
+	if *srcBackup.OptimizedStorage && vol.IsEncrypted() {
+		return fmt.Errorf("Optimized backups cannot be restored into encrypted volumes")
 	}
 
-	contentType := InstanceContentType(inst)
+	revert := revert.New()
+	defer revert.Fail()
 
-	_, volume, err := b.state.Cluster.GetLocalStoragePoolVolume(inst.Project(), inst.Name(), volDBType, b.ID())
+	// Create database entry for the new storage volume first.
+	err = VolumeDBCreate(b.state, srcBackup.Project, b.name, srcBackup.Name, srcBackup.Volume.Description, db.StoragePoolVolumeTypeNameCustom, false, vol.Config(), time.Time{}, string(contentType))
 	if err != nil {
 		return err
 	}
 
-	data, err := yaml.Marshal(&backup.InstanceConfig{
-		Container: ci.(*api.Instance),
-		Snapshots: sis,
-		Pool:      &b.db,
+	revert.Add(func() {
+		b.state.Cluster.RemoveStoragePoolVolume(srcBackup.Project, srcBackup.Name, db.StoragePoolVolumeTypeCustom, b.ID())
+	})
+
+	// Unpack the backup into the new storage volume(s).
+	volPostHook, revertHook, err := b.driver.CreateVolumeFromBackup(vol, srcBackup, srcData, op)
+	if err != nil {
+		return err
+	}
+
+	if revertHook != nil {
+		revert.Add(revertHook)
+	}
+
+	// Create database entries for the new storage volume's snapshots.
+	for _, snapName := range srcBackup.Snapshots {
+		snapDesc := srcBackup.Volume.Description
+		snapConfig := vol.Config()
+		snapExpiryDate := time.Time{}
+
+		for _, snap := range srcBackup.VolumeSnapshots {
+			if snap.Name != snapName {
+				continue
+			}
+
+			snapDesc = snap.Description
+			snapConfig = snap.Config
+
+			if snap.ExpiresAt != nil {
+				snapExpiryDate = *snap.ExpiresAt
+			}
+		}
+
+		fullSnapName := drivers.GetSnapshotVolumeName(srcBackup.Name, snapName)
+		err = VolumeDBCreate(b.state, srcBackup.Project, b.name, fullSnapName, snapDesc, db.StoragePoolVolumeTypeNameCustom, true, snapConfig, snapExpiryDate, string(contentType))
+		if err != nil {
+			return err
+		}
+
+		revert.Add(func() {
+			b.state.Cluster.RemoveStoragePoolVolume(srcBackup.Project, fullSnapName, db.StoragePoolVolumeTypeCustom, b.ID())
+		})
+	}
+
+	// If the driver returned a post hook, run it now that the database records exist.
+	if volPostHook != nil {
+		err = volPostHook(vol)
+		if err != nil {
+			return err
+		}
+	}
+
+	revert.Success()
+	return nil
+}
+
+// RefreshCustomVolumeFromBackup applies an incremental backup onto an existing custom volume that was restored
+// from the parent of the backup, and then applies the volume config contained in the backup.
+func (b *lxdBackend) RefreshCustomVolumeFromBackup(srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) error {
+	logger := logging.AddContext(b.logger, log.Ctx{"project": srcBackup.Project, "volName": srcBackup.Name, "optimizedStorage": *srcBackup.OptimizedStorage})
+	logger.Debug("RefreshCustomVolumeFromBackup started")
+	defer logger.Debug("RefreshCustomVolumeFromBackup finished")
+
+	if srcBackup.Volume == nil {
+		return fmt.Errorf("Backup doesn't contain the volume config")
+	}
+
+	// Get the existing volume.
+	_, dbVol, err := b.state.Cluster.GetLocalStoragePoolVolume(srcBackup.Project, srcBackup.Name, db.StoragePoolVolumeTypeCustom, b.ID())
+	if err != nil {
+		if err == db.ErrNoSuchObject {
+			return fmt.Errorf("Volume doesn't exist")
+		}
+
+		return err
+	}
+
+	if srcBackup.Volume.ContentType != "" && srcBackup.Volume.ContentType != dbVol.ContentType {
+		return fmt.Errorf("Content type of the incremental backup differs from the one of the volume")
+	}
+
+	// Check the volume isn't in use by running instances.
+	usingVolume, err := VolumeUsedByRunningInstancesWithProfilesGet(b.state, srcBackup.Project, b.Name(), srcBackup.Name, db.StoragePoolVolumeTypeNameCustom, true)
+	if err != nil {
+		return err
+	}
+
+	if len(usingVolume) != 0 {
+		return fmt.Errorf("Cannot apply an incremental backup to a volume used by running instances")
+	}
+
+	// Get the volume name on storage.
+	volStorageName := project.StorageVolume(srcBackup.Project, srcBackup.Name)
+	vol := b.newVolume(drivers.VolumeTypeCustom, drivers.ContentType(dbVol.ContentType), volStorageName, dbVol.Config)
+
+	if *srcBackup.OptimizedStorage && vol.IsEncrypted() {
+		return fmt.Errorf("Optimized backups cannot be restored into encrypted volumes")
+	}
+
+	err = b.driver.RefreshVolumeFromBackup(vol, srcBackup, srcData, op)
+	if err != nil {
+		return err
+	}
+
+	err = b.UpdateCustomVolume(srcBackup.Project, srcBackup.Name, srcBackup.Volume.Description, srcBackup.Volume.Config, op)
+	if err != nil {
+		return err
+	}
+
+	// Reapply the quota of the volume, as some drivers replace the volume when refreshing it.
+	size := srcBackup.Volume.Config["size"]
+	if size != "" {
+		err = b.driver.SetVolumeQuota(vol, size, op)
+		if err != nil {
+			if errors.Cause(err) != drivers.ErrCannotBeShrunk {
+				return err
+			}
+
+			logger.Warn("Could not apply volume quota as refreshed volume cannot be shrunk", log.Ctx{"size": size})
+		}
+	}
+
+	return nil
+}
+
+// DeleteCustomVolumeBackupBase removes the base kept on storage for incremental backups of the custom volume
+// whose parent is the backup with the given UUID.
+func (b *lxdBackend) DeleteCustomVolumeBackupBase(projectName string, volName string, uuid string, op *operations.Operation) error {
+	logger := logging.AddContext(b.logger, log.Ctx{"project": projectName, "volName": volName, "uuid": uuid})
+	logger.Debug("DeleteCustomVolumeBackupBase started")
+	defer logger.Debug("DeleteCustomVolumeBackupBase finished")
+
+	// Get the volume content type.
+	_, dbVol, err := b.state.Cluster.GetLocalStoragePoolVolume(projectName, volName, db.StoragePoolVolumeTypeCustom, b.ID())
+	if err != nil {
+		return err
+	}
+
+	// Get the volume name on storage. There's no need to pass config as it's not needed when deleting a
+	// backup base.
+	volStorageName := project.StorageVolume(projectName, volName)
+	vol := b.newVolume(drivers.VolumeTypeCustom, drivers.ContentType(dbVol.ContentType), volStorageName, nil)
+
+	return b.driver.DeleteVolumeBackupBase(vol, uuid, op)
+}
+
+func (b *lxdBackend) createStorageStructure(path string) error {
+	for _, volType := range b.driver.Info().VolumeTypes {
+		for _, name := range drivers.BaseDirectories[volType] {
+			path := filepath.Join(path, name)
+			err := os.MkdirAll(path, 0711)
+			if err != nil && !os.IsExist(err) {
+				return errors.Wrapf(err, "Failed to create directory '%s'", path)
+			}
+		}
+	}
+
+	return nil
+}
+
+// UpdateInstanceBackupFile writes the instance's config to the backup.yaml file on the storage device.
+func (b *lxdBackend) UpdateInstanceBackupFile(inst instance.Instance, op *operations.Operation) error {
+	logger := logging.AddContext(b.logger, log.Ctx{"project": inst.Project(), "instance": inst.Name()})
+	logger.Debug("UpdateInstanceBackupFile started")
+	defer logger.Debug("UpdateInstanceBackupFile finished")
+
+	// We only write backup files out for actual instances.
+	if inst.IsSnapshot() {
+		return nil
+	}
+
+	// Immediately return if the instance directory doesn't exist yet.
+	if !shared.PathExists(inst.Path()) {
+		return os.ErrNotExist
+	}
+
+	// Generate the YAML.
+	ci, _, err := inst.Render()
+	if err != nil {
+		return errors.Wrap(err, "Failed to render instance metadata")
+	}
+
+	snapshots, err := inst.Snapshots()
+	if err != nil {
+		return errors.Wrap(err, "Failed to get snapshots")
+	}
+
+	var sis []*api.InstanceSnapshot
+
+	for _, s := range snapshots {
+		si, _, err := s.Render()
+		if err != nil {
+			return err
+		}
+
+		sis = append(sis, si.(*api.InstanceSnapshot))
+	}
+
+	volType, err := InstanceTypeToVolumeType(inst.Type())
+	if err != nil {
+		return err
+	}
+
+	volDBType, err := VolumeTypeToDBType(volType)
+	if err != nil {
+		return err
+	}
+
+	contentType := InstanceContentType(inst)
+
+	_, volume, err := b.state.Cluster.GetLocalStoragePoolVolume(inst.Project(), inst.Name(), volDBType, b.ID())
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(&backup.InstanceConfig{
+		Container: ci.(*api.Instance),
+		Snapshots: sis,
+		Pool:      &b.db,
 		Volume:    volume,
 	})
 	if err != nil {
@@ -3392,3 +3894,458 @@ func (b *lxdBackend) CheckInstanceBackupFileSnapshots(backupConf *backup.Instanc
 
 	return existingSnapshots, nil
 }
+
+// ListUnknownVolumes returns the instance and custom volumes that exist on the storage pool but don't have
+// records in the database, keyed by project name. Instances are described using their backup.yaml file and
+// custom volumes using the information the storage driver has about them.
+func (b *lxdBackend) ListUnknownVolumes(op *operations.Operation) (map[string][]*backup.InstanceConfig, error) {
+	logger := logging.AddContext(b.logger, nil)
+	logger.Debug("ListUnknownVolumes started")
+	defer logger.Debug("ListUnknownVolumes finished")
+
+	// Ensure the pool's directory structure exists so that volumes can be mounted.
+	err := b.createStorageStructure(drivers.GetPoolMountPath(b.name))
+	if err != nil {
+		return nil, err
+	}
+
+	poolVols, err := b.driver.ListVolumes()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed getting volumes on pool %q", b.name)
+	}
+
+	projectVols := make(map[string][]*backup.InstanceConfig)
+
+	for _, poolVol := range poolVols {
+		switch poolVol.Type() {
+		case drivers.VolumeTypeContainer, drivers.VolumeTypeVM:
+			err = b.detectUnknownInstanceVolume(poolVol, projectVols, op)
+		case drivers.VolumeTypeCustom:
+			err = b.detectUnknownCustomVolume(poolVol, projectVols, op)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return projectVols, nil
+}
+
+// detectUnknownInstanceVolume adds the backup config of the instance volume to projectVols if the instance
+// has no volume record in the database. The backup config is read from the instance's backup.yaml file.
+func (b *lxdBackend) detectUnknownInstanceVolume(vol drivers.Volume, projectVols map[string][]*backup.InstanceConfig, op *operations.Operation) error {
+	volType := vol.Type()
+
+	volDBType, err := VolumeTypeToDBType(volType)
+	if err != nil {
+		return err
+	}
+
+	projectName, instName := project.InstanceParts(vol.Name())
+
+	// Skip the volume if it is already known.
+	_, _, err = b.state.Cluster.GetLocalStoragePoolVolume(projectName, instName, volDBType, b.ID())
+	if err == nil {
+		return nil
+	} else if err != db.ErrNoSuchObject {
+		return err
+	}
+
+	var backupConf *backup.InstanceConfig
+
+	// Read the backup.yaml file stored on the instance volume.
+	err = vol.MountTask(func(mountPath string, op *operations.Operation) error {
+		backupYamlPath := filepath.Join(mountPath, "backup.yaml")
+		if !shared.PathExists(backupYamlPath) {
+			return fmt.Errorf("No backup.yaml file found")
+		}
+
+		backupConf, err = backup.ParseInstanceConfigYamlFile(backupYamlPath)
+		if err != nil {
+			return errors.Wrapf(err, "Failed parsing %q", backupYamlPath)
+		}
+
+		return nil
+	}, op)
+	if err != nil {
+		return errors.Wrapf(err, "Failed reading backup file of instance %q in project %q", instName, projectName)
+	}
+
+	// Sanity check the backup config against the volume it was found on.
+	if backupConf.Container == nil {
+		return fmt.Errorf("No instance in backup file of instance %q in project %q", instName, projectName)
+	}
+
+	if backupConf.Container.Name != instName {
+		return fmt.Errorf("Instance name %q in backup file doesn't match volume name of instance %q in project %q", backupConf.Container.Name, instName, projectName)
+	}
+
+	instType, err := instancetype.New(string(backupConf.Container.Type))
+	if err != nil {
+		return errors.Wrapf(err, "Invalid type in backup file of instance %q in project %q", instName, projectName)
+	}
+
+	instVolType, err := InstanceTypeToVolumeType(instType)
+	if err != nil {
+		return err
+	}
+
+	if instVolType != volType {
+		return fmt.Errorf("Instance type %q in backup file doesn't match volume type %q of instance %q in project %q", backupConf.Container.Type, volType, instName, projectName)
+	}
+
+	// Update snapshot names to include instance name (if needed).
+	for _, snap := range backupConf.Snapshots {
+		if !strings.Contains(snap.Name, shared.SnapshotDelimiter) {
+			snap.Name = drivers.GetSnapshotVolumeName(instName, snap.Name)
+		}
+	}
+
+	// Check the snapshots on the storage device match the ones in the backup file.
+	_, err = b.CheckInstanceBackupFileSnapshots(backupConf, projectName, false, op)
+	if err != nil {
+		return errors.Wrapf(err, "Instance %q in project %q has snapshot inconsistency", instName, projectName)
+	}
+
+	projectVols[projectName] = append(projectVols[projectName], backupConf)
+
+	return nil
+}
+
+// detectUnknownCustomVolume adds a generated backup config of the custom volume to projectVols if the volume
+// has no record in the database.
+func (b *lxdBackend) detectUnknownCustomVolume(vol drivers.Volume, projectVols map[string][]*backup.InstanceConfig, op *operations.Operation) error {
+	projectName, volName := project.StorageVolumeParts(vol.Name())
+
+	// Skip the volume if it is already known.
+	_, _, err := b.state.Cluster.GetLocalStoragePoolVolume(projectName, volName, db.StoragePoolVolumeTypeCustom, b.ID())
+	if err == nil {
+		return nil
+	} else if err != db.ErrNoSuchObject {
+		return err
+	}
+
+	volConfig := vol.Config()
+
+	// The drivers can't tell encrypted volumes apart, so rely on the pool's keyfile having a key for the volume.
+	key, err := getPoolKey(b.name, vol.Type(), vol.Name())
+	if err != nil {
+		return err
+	}
+
+	if key != "" {
+		volConfig["block.encryption"] = "luks"
+
+		// A filesystem probed on the encrypted device is the LUKS header, not the volume's filesystem.
+		if volConfig["block.filesystem"] == "crypto_LUKS" {
+			delete(volConfig, "block.filesystem")
+		}
+	}
+
+	snapshots, err := vol.Snapshots(op)
+	if err != nil {
+		return errors.Wrapf(err, "Failed listing snapshots of custom volume %q in project %q", volName, projectName)
+	}
+
+	snapshotVols := make([]*api.StorageVolumeSnapshot, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		_, snapName, _ := shared.InstanceGetParentAndSnapshotName(snapshot.Name())
+		snapshotVols = append(snapshotVols, &api.StorageVolumeSnapshot{
+			Name:        snapName,
+			Config:      volConfig,
+			ContentType: string(vol.ContentType()),
+		})
+	}
+
+	projectVols[projectName] = append(projectVols[projectName], &backup.InstanceConfig{
+		Volume: &api.StorageVolume{
+			StorageVolumePut: api.StorageVolumePut{
+				Config: volConfig,
+			},
+			Name:        volName,
+			Type:        db.StoragePoolVolumeTypeNameCustom,
+			ContentType: string(vol.ContentType()),
+		},
+		VolumeSnapshots: snapshotVols,
+	})
+
+	return nil
+}
+
+// ImportInstance recreates the mount paths and symlinks of an instance whose volume already exists on the
+// storage device and whose database records have been restored.
+func (b *lxdBackend) ImportInstance(inst instance.Instance, op *operations.Operation) error {
+	logger := logging.AddContext(b.logger, log.Ctx{"project": inst.Project(), "instance": inst.Name()})
+	logger.Debug("ImportInstance started")
+	defer logger.Debug("ImportInstance finished")
+
+	volType, err := InstanceTypeToVolumeType(inst.Type())
+	if err != nil {
+		return err
+	}
+
+	contentType := InstanceContentType(inst)
+
+	// Get the volume name on storage.
+	volStorageName := project.Instance(inst.Project(), inst.Name())
+
+	rootDiskConf, err := b.instanceRootVolumeConfig(inst)
+	if err != nil {
+		return err
+	}
+
+	vol := b.newVolume(volType, contentType, volStorageName, rootDiskConf)
+
+	// Check the encryption key of the recovered volume is available.
+	err = b.importVolumeKey(vol)
+	if err != nil {
+		return err
+	}
+
+	err = vol.EnsureMountPath()
+	if err != nil {
+		return err
+	}
+
+	err = b.ensureInstanceSymlink(inst.Type(), inst.Project(), inst.Name(), vol.MountPath())
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := inst.Snapshots()
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	snapshotDir := drivers.GetVolumeSnapshotDir(b.name, volType, volStorageName)
+	if !shared.PathExists(snapshotDir) {
+		err = os.Mkdir(snapshotDir, 0700)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to create directory %q", snapshotDir)
+		}
+	}
+
+	for _, snapshot := range snapshots {
+		snapVol := b.newVolume(volType, contentType, project.Instance(inst.Project(), snapshot.Name()), nil)
+		err = snapVol.EnsureMountPath()
+		if err != nil {
+			return err
+		}
+	}
+
+	return b.ensureInstanceSnapshotSymlink(inst.Type(), inst.Project(), inst.Name())
+}
+
+// ImportCustomVolume recreates the database records of a custom volume and its snapshots that exist on the
+// storage device, using the config returned by ListUnknownVolumes.
+func (b *lxdBackend) ImportCustomVolume(projectName string, poolVol *backup.InstanceConfig, op *operations.Operation) error {
+	if poolVol.Volume == nil {
+		return fmt.Errorf("Invalid pool volume config supplied")
+	}
+
+	logger := logging.AddContext(b.logger, log.Ctx{"project": projectName, "volName": poolVol.Volume.Name})
+	logger.Debug("ImportCustomVolume started")
+	defer logger.Debug("ImportCustomVolume finished")
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Create the storage volume DB records.
+	err := VolumeDBCreate(b.state, projectName, b.name, poolVol.Volume.Name, poolVol.Volume.Description, db.StoragePoolVolumeTypeNameCustom, false, poolVol.Volume.Config, time.Time{}, poolVol.Volume.ContentType)
+	if err != nil {
+		return errors.Wrapf(err, "Failed creating custom volume %q record in project %q", poolVol.Volume.Name, projectName)
+	}
+
+	revert.Add(func() {
+		b.state.Cluster.RemoveStoragePoolVolume(projectName, poolVol.Volume.Name, db.StoragePoolVolumeTypeCustom, b.ID())
+	})
+
+	// Create the storage volume snapshot DB records.
+	for _, poolVolSnap := range poolVol.VolumeSnapshots {
+		fullSnapName := drivers.GetSnapshotVolumeName(poolVol.Volume.Name, poolVolSnap.Name)
+
+		err = VolumeDBCreate(b.state, projectName, b.name, fullSnapName, poolVolSnap.Description, db.StoragePoolVolumeTypeNameCustom, true, poolVolSnap.Config, time.Time{}, poolVolSnap.ContentType)
+		if err != nil {
+			return errors.Wrapf(err, "Failed creating custom volume snapshot %q record in project %q", fullSnapName, projectName)
+		}
+
+		revert.Add(func() {
+			b.state.Cluster.RemoveStoragePoolVolume(projectName, fullSnapName, db.StoragePoolVolumeTypeCustom, b.ID())
+		})
+	}
+
+	// Check the encryption key of the recovered volume is available.
+	vol := b.newVolume(drivers.VolumeTypeCustom, drivers.ContentType(poolVol.Volume.ContentType), project.StorageVolume(projectName, poolVol.Volume.Name), poolVol.Volume.Config)
+	err = b.importVolumeKey(vol)
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// supportsBuckets returns whether the pool's driver can hold storage buckets.
+func (b *lxdBackend) supportsBuckets() bool {
+	for _, volType := range b.driver.Info().VolumeTypes {
+		if volType == drivers.VolumeTypeBucket {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newBucketVolume returns the volume holding the data of a storage bucket. The bucket's size is used as the
+// volume's size.
+func (b *lxdBackend) newBucketVolume(projectName string, bucketName string, bucketConfig map[string]string) drivers.Volume {
+	volConfig := map[string]string{}
+	if bucketConfig["size"] != "" {
+		volConfig["size"] = bucketConfig["size"]
+	}
+
+	return b.newVolume(drivers.VolumeTypeBucket, drivers.ContentTypeFS, project.StorageVolume(projectName, bucketName), volConfig)
+}
+
+// CreateBucket creates a storage bucket backed by a new volume on the pool.
+func (b *lxdBackend) CreateBucket(projectName string, bucket api.StorageBucketsPost, op *operations.Operation) error {
+	logger := logging.AddContext(b.logger, log.Ctx{"project": projectName, "bucketName": bucket.Name, "desc": bucket.Description, "config": bucket.Config})
+	logger.Debug("CreateBucket started")
+	defer logger.Debug("CreateBucket finished")
+
+	if !b.supportsBuckets() {
+		return fmt.Errorf("Storage pool driver %q doesn't support buckets", b.driver.Info().Name)
+	}
+
+	err := BucketValidateConfig(bucket.Config)
+	if err != nil {
+		return err
+	}
+
+	vol := b.newBucketVolume(projectName, bucket.Name, bucket.Config)
+	err = b.driver.ValidateVolume(vol, false)
+	if err != nil {
+		return err
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Create the database record first so that conflicting bucket names are detected.
+	bucketID, err := b.state.Cluster.CreateStorageBucket(b.ID(), projectName, &bucket)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { b.state.Cluster.DeleteStorageBucket(bucketID) })
+
+	// Create the bucket volume, its size is applied as its quota by the driver.
+	err = b.driver.CreateVolume(vol, nil, op)
+	if err != nil {
+		return err
+	}
+
+	revert.Add(func() { b.driver.DeleteVolume(vol, op) })
+
+	// Keep the bucket volume mounted while the bucket exists, so that it can be served over S3.
+	_, err = b.driver.MountVolume(vol, op)
+	if err != nil {
+		return errors.Wrapf(err, "Failed mounting bucket %q", bucket.Name)
+	}
+
+	revert.Success()
+	return nil
+}
+
+// UpdateBucket updates the description and config of a storage bucket. Changes to the bucket's size are applied
+// to its volume's quota.
+func (b *lxdBackend) UpdateBucket(projectName string, bucketName string, bucket api.StorageBucketPut, op *operations.Operation) error {
+	logger := logging.AddContext(b.logger, log.Ctx{"project": projectName, "bucketName": bucketName, "desc": bucket.Description, "config": bucket.Config})
+	logger.Debug("UpdateBucket started")
+	defer logger.Debug("UpdateBucket finished")
+
+	curBucket, err := b.state.Cluster.GetStorageBucket(b.ID(), projectName, bucketName)
+	if err != nil {
+		return err
+	}
+
+	err = BucketValidateConfig(bucket.Config)
+	if err != nil {
+		return err
+	}
+
+	if bucket.Config["size"] != curBucket.Config["size"] {
+		vol := b.newBucketVolume(projectName, bucketName, curBucket.Config)
+		err = b.driver.SetVolumeQuota(vol, bucket.Config["size"], op)
+		if err != nil {
+			return errors.Wrapf(err, "Failed setting quota of bucket %q", bucketName)
+		}
+	}
+
+	return b.state.Cluster.UpdateStorageBucket(curBucket.ID, &bucket)
+}
+
+// DeleteBucket deletes a storage bucket along with its volume and access keys.
+func (b *lxdBackend) DeleteBucket(projectName string, bucketName string, op *operations.Operation) error {
+	logger := logging.AddContext(b.logger, log.Ctx{"project": projectName, "bucketName": bucketName})
+	logger.Debug("DeleteBucket started")
+	defer logger.Debug("DeleteBucket finished")
+
+	curBucket, err := b.state.Cluster.GetStorageBucket(b.ID(), projectName, bucketName)
+	if err != nil {
+		return err
+	}
+
+	// There's no need to pass config as it's not needed when deleting a volume.
+	vol := b.newBucketVolume(projectName, bucketName, nil)
+	if b.driver.HasVolume(vol) {
+		_, err = b.driver.UnmountVolume(vol, op)
+		if err != nil {
+			return err
+		}
+
+		err = b.driver.DeleteVolume(vol, op)
+		if err != nil {
+			return err
+		}
+	}
+
+	return b.state.Cluster.DeleteStorageBucket(curBucket.ID)
+}
+
+// MountBucket mounts the volume of a storage bucket. Bucket volumes are mounted when the bucket is created and
+// when LXD starts, and stay mounted until the bucket is deleted or LXD shuts down.
+func (b *lxdBackend) MountBucket(projectName string, bucketName string, op *operations.Operation) error {
+	// There's no need to pass config as it's not needed when mounting a volume.
+	vol := b.newBucketVolume(projectName, bucketName, nil)
+
+	_, err := b.driver.MountVolume(vol, op)
+	if err != nil {
+		return errors.Wrapf(err, "Failed mounting bucket %q", bucketName)
+	}
+
+	return nil
+}
+
+// UnmountBucket unmounts the volume of a storage bucket.
+func (b *lxdBackend) UnmountBucket(projectName string, bucketName string, op *operations.Operation) error {
+	// There's no need to pass config as it's not needed when unmounting a volume.
+	vol := b.newBucketVolume(projectName, bucketName, nil)
+
+	_, err := b.driver.UnmountVolume(vol, op)
+	if err != nil {
+		return errors.Wrapf(err, "Failed unmounting bucket %q", bucketName)
+	}
+
+	return nil
+}
+
+// GetBucketPath returns the path holding the data of a storage bucket.
+func (b *lxdBackend) GetBucketPath(projectName string, bucketName string) string {
+	return b.newBucketVolume(projectName, bucketName, nil).MountPath()
+}
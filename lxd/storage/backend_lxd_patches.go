@@ -21,6 +21,7 @@ var lxdLatePatches = map[string]func(b *lxdBackend) error{
 	"storage_create_vm":                        lxdPatchStorageCreateVM,
 	"storage_create_vm_again":                  lxdPatchStorageCreateVM,
 	"storage_rename_custom_volume_add_project": lxdPatchStorageRenameCustomVolumeAddProject,
+	"storage_create_buckets":                   lxdPatchStorageCreateVM,
 }
 
 // Patches start here.
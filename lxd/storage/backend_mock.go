@@ -67,6 +67,10 @@ func (b *mockBackend) ApplyPatch(name string) error {
 	return nil
 }
 
+func (b *mockBackend) ListUnknownVolumes(op *operations.Operation) (map[string][]*backup.InstanceConfig, error) {
+	return nil, nil
+}
+
 func (b *mockBackend) CreateInstance(inst instance.Instance, op *operations.Operation) error {
 	return nil
 }
@@ -107,6 +111,10 @@ func (b *mockBackend) CheckInstanceBackupFileSnapshots(backupConf *backup.Instan
 	return nil, nil
 }
 
+func (b *mockBackend) ImportInstance(inst instance.Instance, op *operations.Operation) error {
+	return nil
+}
+
 func (b *mockBackend) MigrateInstance(inst instance.Instance, conn io.ReadWriteCloser, args *migration.VolumeSourceArgs, op *operations.Operation) error {
 	return nil
 }
@@ -115,7 +123,15 @@ func (b *mockBackend) RefreshInstance(i instance.Instance, src instance.Instance
 	return nil
 }
 
-func (b *mockBackend) BackupInstance(inst instance.Instance, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error {
+func (b *mockBackend) BackupInstance(inst instance.Instance, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, args drivers.BackupArgs, op *operations.Operation) error {
+	return nil
+}
+
+func (b *mockBackend) RefreshInstanceFromBackup(inst instance.Instance, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) error {
+	return nil
+}
+
+func (b *mockBackend) DeleteInstanceBackupBase(inst instance.Instance, uuid string, op *operations.Operation) error {
 	return nil
 }
 
@@ -123,7 +139,7 @@ func (b *mockBackend) GetInstanceUsage(inst instance.Instance) (int64, error) {
 	return 0, nil
 }
 
-func (b *mockBackend) SetInstanceQuota(inst instance.Instance, size string, op *operations.Operation) error {
+func (b *mockBackend) SetInstanceQuota(inst instance.Instance, size string, vmStateSize string, op *operations.Operation) error {
 	return nil
 }
 
@@ -199,6 +215,22 @@ func (b *mockBackend) DeleteCustomVolume(projectName string, volName string, op
 	return nil
 }
 
+func (b *mockBackend) BackupCustomVolume(projectName string, volName string, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, args drivers.BackupArgs, op *operations.Operation) error {
+	return nil
+}
+
+func (b *mockBackend) CreateCustomVolumeFromBackup(srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) error {
+	return nil
+}
+
+func (b *mockBackend) RefreshCustomVolumeFromBackup(srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) error {
+	return nil
+}
+
+func (b *mockBackend) DeleteCustomVolumeBackupBase(projectName string, volName string, uuid string, op *operations.Operation) error {
+	return nil
+}
+
 func (b *mockBackend) MigrateCustomVolume(projectName string, conn io.ReadWriteCloser, args *migration.VolumeSourceArgs, op *operations.Operation) error {
 	return nil
 }
@@ -223,6 +255,10 @@ func (b *mockBackend) UnmountCustomVolume(projectName string, volName string, op
 	return true, nil
 }
 
+func (b *mockBackend) ImportCustomVolume(projectName string, poolVol *backup.InstanceConfig, op *operations.Operation) error {
+	return nil
+}
+
 func (b *mockBackend) CreateCustomVolumeSnapshot(projectName string, volName string, newSnapshotName string, expiryDate time.Time, op *operations.Operation) error {
 	return nil
 }
@@ -242,3 +278,27 @@ func (b *mockBackend) UpdateCustomVolumeSnapshot(projectName string, volName str
 func (b *mockBackend) RestoreCustomVolume(projectName string, volName string, snapshotName string, op *operations.Operation) error {
 	return nil
 }
+
+func (b *mockBackend) CreateBucket(projectName string, bucket api.StorageBucketsPost, op *operations.Operation) error {
+	return nil
+}
+
+func (b *mockBackend) UpdateBucket(projectName string, bucketName string, bucket api.StorageBucketPut, op *operations.Operation) error {
+	return nil
+}
+
+func (b *mockBackend) DeleteBucket(projectName string, bucketName string, op *operations.Operation) error {
+	return nil
+}
+
+func (b *mockBackend) MountBucket(projectName string, bucketName string, op *operations.Operation) error {
+	return nil
+}
+
+func (b *mockBackend) UnmountBucket(projectName string, bucketName string, op *operations.Operation) error {
+	return nil
+}
+
+func (b *mockBackend) GetBucketPath(projectName string, bucketName string) string {
+	return ""
+}
@@ -36,6 +36,7 @@ func (d *btrfs) load() error {
 		"storage_zfs_volmode":                      nil,
 		"storage_rename_custom_volume_add_project": nil,
 		"storage_lvm_skipactivation":               nil,
+		"storage_create_buckets":                   nil,
 	}
 
 	// Done if previously loaded.
@@ -78,7 +79,7 @@ func (d *btrfs) Info() Info {
 		OptimizedBackupHeader: true,
 		PreservesInodes:       !d.state.OS.RunningInUserNS,
 		Remote:                false,
-		VolumeTypes:           []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM},
+		VolumeTypes:           []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM, VolumeTypeBucket},
 		BlockBacking:          false,
 		RunningQuotaResize:    true,
 		RunningSnapshotFreeze: false,
@@ -244,7 +245,8 @@ func (d *btrfs) Delete(op *operations.Operation) error {
 // Validate checks that all provide keys are supported and that no conflicting or missing configuration is present.
 func (d *btrfs) Validate(config map[string]string) error {
 	rules := map[string]func(value string) error{
-		"btrfs.mount_options": validate.IsAny,
+		"btrfs.mount_options":     validate.IsAny,
+		"volume.block.encryption": validateBlockEncryption,
 	}
 
 	return d.validatePool(config, rules)
@@ -252,7 +254,11 @@ func (d *btrfs) Validate(config map[string]string) error {
 
 // Update applies any driver changes required from a configuration change.
 func (d *btrfs) Update(changedConfig map[string]string) error {
-	// We only care about btrfs.mount_options.
+	if _, changed := changedConfig["volume.block.encryption"]; changed {
+		return fmt.Errorf("volume.block.encryption cannot be changed")
+	}
+
+	// Otherwise we only care about btrfs.mount_options.
 	val, ok := changedConfig["btrfs.mount_options"]
 	if !ok {
 		return nil
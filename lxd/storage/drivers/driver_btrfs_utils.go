@@ -18,6 +18,7 @@ import (
 
 	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/ioprogress"
+	log "github.com/lxc/lxd/shared/log15"
 	"github.com/lxc/lxd/shared/logger"
 )
 
@@ -362,6 +363,17 @@ type BTRFSMetaDataHeader struct {
 	Subvolumes []BTRFSSubVolume `json:"subvolumes" yaml:"subvolumes"` // Sub volumes inside the volume (including the top level ones).
 }
 
+// hasNestedSubvolumes returns whether the main volume described by the header has subvolumes below its root.
+func (h *BTRFSMetaDataHeader) hasNestedSubvolumes() bool {
+	for _, subVol := range h.Subvolumes {
+		if subVol.Snapshot == "" && subVol.Path != string(filepath.Separator) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // restorationHeader scans the volume and any specified snapshots, returning a header containing subvolume metadata
 // for use in restoring a volume and its snapshots onto another system. The metadata returned represents how the
 // subvolumes should be restored, not necessarily how they are on disk now. Most of the time this is the same,
@@ -485,3 +497,59 @@ func (d *btrfs) receiveSubVolume(r io.Reader, receivePath string) (string, error
 
 	return subVolPath, nil
 }
+
+// backupBasesPath returns the path of the directory holding the read-only subvolumes kept as the bases for
+// incremental backups of a volume.
+func (d *btrfs) backupBasesPath(vol Volume) string {
+	return filepath.Join(GetPoolMountPath(d.name), fmt.Sprintf("%s-backups", vol.volType), vol.name)
+}
+
+// backupBasePath returns the path of the base kept for incremental backups by the backup with the given UUID.
+func (d *btrfs) backupBasePath(vol Volume, uuid string) string {
+	return filepath.Join(d.backupBasesPath(vol), uuid)
+}
+
+// receiveBackupBase receives the subvolume file of an optimized backup tarball as the base at basePath.
+// The received subvolume is left readonly so that it can be the parent of subsequent incremental streams.
+func (d *btrfs) receiveBackupBase(r io.ReadSeeker, unpacker []string, srcFile string, basePath string) error {
+	basesPath := filepath.Dir(basePath)
+	err := os.MkdirAll(basesPath, 0700)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to create directory %q", basesPath)
+	}
+
+	tr, cancelFunc, err := shared.CompressedTarReader(context.Background(), r, unpacker)
+	if err != nil {
+		return err
+	}
+	defer cancelFunc()
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break // End of archive
+		}
+		if err != nil {
+			return err
+		}
+
+		if hdr.Name == srcFile {
+			d.logger.Debug("Receiving optimized volume base", log.Ctx{"source": srcFile, "path": basePath})
+			err = shared.RunCommandWithFds(tr, nil, "btrfs", "receive", "-e", basesPath)
+			if err != nil {
+				return err
+			}
+
+			cancelFunc()
+
+			// The subvolume is named after the base it was sent from.
+			if !btrfsIsSubVolume(basePath) {
+				return fmt.Errorf("Backup doesn't contain the expected subvolume %q", filepath.Base(basePath))
+			}
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Could not find %q", srcFile)
+}
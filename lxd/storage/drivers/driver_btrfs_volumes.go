@@ -48,13 +48,25 @@ func (d *btrfs) CreateVolume(vol Volume, filler *VolumeFiller, op *operations.Op
 	rootBlockPath := ""
 	if vol.contentType == ContentTypeBlock {
 		// We expect the filler to copy the VM image into this path.
-		rootBlockPath, err = d.GetVolumeDiskPath(vol)
+		rootBlockPath, err = genericVFSGetVolumeDiskPath(vol)
 		if err != nil {
 			return err
 		}
 	}
 
-	err = d.runFiller(vol, rootBlockPath, filler)
+	// Setup encryption if requested. The block file is then created upfront and the filler writes to its
+	// opened LUKS device instead.
+	devPath := rootBlockPath
+	if vol.IsEncrypted() {
+		devPath, err = d.luksSetupBlockFile(vol, rootBlockPath)
+		if err != nil {
+			return err
+		}
+
+		defer d.luksClose(vol)
+	}
+
+	err = d.runFiller(vol, devPath, filler)
 	if err != nil {
 		return err
 	}
@@ -77,7 +89,7 @@ func (d *btrfs) CreateVolume(vol Volume, filler *VolumeFiller, op *operations.Op
 
 		// Move the GPT alt header to end of disk if needed and if filler specified.
 		if vol.IsVMBlock() && filler != nil && filler.Fill != nil {
-			err = d.moveGPTAltHeader(rootBlockPath)
+			err = d.moveGPTAltHeader(devPath)
 			if err != nil {
 				return err
 			}
@@ -284,18 +296,27 @@ func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcDat
 	}
 
 	// Extract main volume.
-	srcFilePrefix := "container"
-	if vol.volType == VolumeTypeVM {
-		if vol.contentType == ContentTypeFS {
-			srcFilePrefix = "virtual-machine-config"
-		} else {
-			srcFilePrefix = "virtual-machine"
+	if srcBackup.UUID != "" && !optimizedHeader.hasNestedSubvolumes() {
+		// Keep the received subvolume as the base needed to apply subsequent incremental backups, with the
+		// volume being a writable snapshot of it.
+		base := d.backupBasePath(vol, srcBackup.UUID)
+		err = d.receiveBackupBase(srcData, unpacker, d.backupVolumeFile(vol), base)
+		if err != nil {
+			return nil, nil, err
 		}
-	}
 
-	err = unpackVolume(vol, srcFilePrefix)
-	if err != nil {
-		return nil, nil, err
+		// Clear the target for the volume to use.
+		os.Remove(vol.MountPath())
+
+		err = d.snapshotSubvolume(base, vol.MountPath(), false)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		err = unpackVolume(vol, d.backupVolumeFilePrefix(vol))
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	// Restore readonly property on subvolumes that need it.
@@ -321,6 +342,90 @@ func (d *btrfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcDat
 	return nil, revertHook, nil
 }
 
+// RefreshVolumeFromBackup applies an incremental backup onto an existing volume.
+// For optimized backups, the volume must have the base kept when the parent backup was imported.
+func (d *btrfs) RefreshVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) error {
+	// Handle the non-optimized tarballs through the generic unpacker.
+	if !*srcBackup.OptimizedStorage {
+		return genericVFSBackupRefresh(d, vol, srcData, op)
+	}
+
+	parentBase := d.backupBasePath(vol, srcBackup.Parent)
+	if !btrfsIsSubVolume(parentBase) {
+		return fmt.Errorf("Volume %q doesn't have the base of the parent backup", vol.name)
+	}
+
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Find the compression algorithm used for backup source data.
+	srcData.Seek(0, 0)
+	_, _, unpacker, err := shared.DetectCompressionFile(srcData)
+	if err != nil {
+		return err
+	}
+
+	// Receive the changes next to the base of the parent backup, which the stream is relative to.
+	base := d.backupBasePath(vol, srcBackup.UUID)
+	err = d.receiveBackupBase(srcData, unpacker, d.backupVolumeFile(vol), base)
+	if err != nil {
+		return err
+	}
+	revert.Add(func() { d.deleteSubvolume(base, false) })
+
+	target := vol.MountPath()
+
+	// Move the volume aside so we can revert.
+	backupSubvolume := fmt.Sprintf("%s%s", target, tmpVolSuffix)
+	err = os.Rename(target, backupSubvolume)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to rename %q to %q", target, backupSubvolume)
+	}
+	revert.Add(func() { os.Rename(backupSubvolume, target) })
+
+	// Replace the volume with a writable snapshot of the new base, discarding any changes made to it since
+	// the parent backup was imported.
+	err = d.snapshotSubvolume(base, target, false)
+	if err != nil {
+		return err
+	}
+	revert.Add(func() { d.deleteSubvolume(target, false) })
+
+	revert.Success()
+
+	// Remove the previous volume and the base of the parent backup, which isn't needed anymore.
+	err = d.deleteSubvolume(backupSubvolume, true)
+	if err != nil {
+		return err
+	}
+
+	err = d.deleteSubvolume(parentBase, false)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// backupVolumeFilePrefix returns the prefix of the files holding the main volume in an optimized backup tarball.
+func (d *btrfs) backupVolumeFilePrefix(vol Volume) string {
+	if vol.volType == VolumeTypeVM {
+		if vol.contentType == ContentTypeFS {
+			return "virtual-machine-config"
+		}
+
+		return "virtual-machine"
+	}
+
+	return "container"
+}
+
+// backupVolumeFile returns the name of the file holding the root subvolume of the main volume in an optimized
+// backup tarball.
+func (d *btrfs) backupVolumeFile(vol Volume) string {
+	return filepath.Join("backup", fmt.Sprintf("%s.bin", d.backupVolumeFilePrefix(vol)))
+}
+
 // CreateVolumeFromCopy provides same-pool volume copying functionality.
 func (d *btrfs) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots bool, op *operations.Operation) error {
 	revert := revert.New()
@@ -565,12 +670,41 @@ func (d *btrfs) DeleteVolume(vol Volume, op *operations.Operation) error {
 		return fmt.Errorf("Cannot remove a volume that has snapshots")
 	}
 
+	// Delete the bases kept for incremental backups.
+	basesPath := d.backupBasesPath(vol)
+	if shared.PathExists(basesPath) {
+		bases, err := ioutil.ReadDir(basesPath)
+		if err != nil {
+			return errors.Wrapf(err, "Failed listing contents of %q", basesPath)
+		}
+
+		for _, base := range bases {
+			err = d.deleteSubvolume(filepath.Join(basesPath, base.Name()), true)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = os.Remove(basesPath)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to remove %q", basesPath)
+		}
+	}
+
 	// If the volume doesn't exist, then nothing more to do.
 	volPath := GetVolumeMountPath(d.name, vol.volType, vol.name)
 	if !shared.PathExists(volPath) {
 		return nil
 	}
 
+	// Close the LUKS device of the block file if opened.
+	if vol.contentType == ContentTypeBlock {
+		_, err = d.luksClose(vol)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Delete the volume (and any subvolumes).
 	err = d.deleteSubvolume(volPath, true)
 	if err != nil {
@@ -592,9 +726,21 @@ func (d *btrfs) HasVolume(vol Volume) bool {
 	return genericVFSHasVolume(vol)
 }
 
+// ListVolumes returns a list of LXD volumes in storage pool.
+func (d *btrfs) ListVolumes() ([]Volume, error) {
+	return genericVFSListVolumes(d)
+}
+
 // ValidateVolume validates the supplied volume config.
 func (d *btrfs) ValidateVolume(vol Volume, removeUnknownKeys bool) error {
-	return d.validateVolume(vol, nil, removeUnknownKeys)
+	rules := map[string]func(value string) error{}
+
+	// Only volumes backed by block files can be encrypted.
+	if vol.contentType == ContentTypeBlock {
+		rules["block.encryption"] = validateBlockEncryption
+	}
+
+	return d.validateVolume(vol, rules, removeUnknownKeys)
 }
 
 // UpdateVolume applies config changes to the volume.
@@ -603,6 +749,10 @@ func (d *btrfs) UpdateVolume(vol Volume, changedConfig map[string]string) error
 		return ErrNotSupported
 	}
 
+	if _, changed := changedConfig["block.encryption"]; changed {
+		return fmt.Errorf("block.encryption cannot be changed")
+	}
+
 	if _, changed := changedConfig["size"]; changed {
 		err := d.SetVolumeQuota(vol, changedConfig["size"], nil)
 		if err != nil {
@@ -644,7 +794,7 @@ func (d *btrfs) SetVolumeQuota(vol Volume, size string, op *operations.Operation
 			return nil
 		}
 
-		rootBlockPath, err := d.GetVolumeDiskPath(vol)
+		rootBlockPath, err := genericVFSGetVolumeDiskPath(vol)
 		if err != nil {
 			return err
 		}
@@ -654,11 +804,28 @@ func (d *btrfs) SetVolumeQuota(vol Volume, size string, op *operations.Operation
 			return err
 		}
 
+		// Grow the LUKS device if opened.
+		if vol.IsEncrypted() && resized {
+			err = d.luksResizeBlockFile(vol, rootBlockPath)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Move the GPT alt header to end of disk if needed and resize has taken place (not needed in
 		// unsafe resize mode as it is expected the caller will do all necessary post resize actions
 		// themselves).
 		if vol.IsVMBlock() && resized && !vol.allowUnsafeResize {
-			err = d.moveGPTAltHeader(rootBlockPath)
+			devPath, opened, err := d.luksDevPath(vol, rootBlockPath)
+			if err != nil {
+				return err
+			}
+
+			if opened {
+				defer d.luksClose(vol)
+			}
+
+			err = d.moveGPTAltHeader(devPath)
 			if err != nil {
 				return err
 			}
@@ -667,6 +834,11 @@ func (d *btrfs) SetVolumeQuota(vol Volume, size string, op *operations.Operation
 		return nil
 	}
 
+	// The VM filesystem volume shares its directory with the block file, so there is no separate quota.
+	if vol.volType == VolumeTypeVM {
+		return nil
+	}
+
 	// For non-VM block volumes, set filesystem quota.
 	volPath := vol.MountPath()
 
@@ -748,7 +920,17 @@ func (d *btrfs) SetVolumeQuota(vol Volume, size string, op *operations.Operation
 
 // GetVolumeDiskPath returns the location and file format of a disk volume.
 func (d *btrfs) GetVolumeDiskPath(vol Volume) (string, error) {
-	return genericVFSGetVolumeDiskPath(vol)
+	rootBlockPath, err := genericVFSGetVolumeDiskPath(vol)
+	if err != nil {
+		return "", err
+	}
+
+	devPath, _, err := d.luksDevPath(vol, rootBlockPath)
+	if err != nil {
+		return "", err
+	}
+
+	return devPath, nil
 }
 
 // MountVolume simulates mounting a volume. As the driver doesn't have volumes to mount it returns
@@ -768,12 +950,35 @@ func (d *btrfs) MountVolume(vol Volume, op *operations.Operation) (bool, error)
 
 // UnmountVolume simulates unmounting a volume.
 func (d *btrfs) UnmountVolume(vol Volume, op *operations.Operation) (bool, error) {
+	// Close the LUKS device of the block file if opened.
+	if vol.contentType == ContentTypeBlock {
+		_, err := d.luksClose(vol)
+		if err != nil {
+			return false, err
+		}
+	}
+
 	return false, nil
 }
 
 // RenameVolume renames a volume and its snapshots.
 func (d *btrfs) RenameVolume(vol Volume, newVolName string, op *operations.Operation) error {
-	return genericVFSRenameVolume(d, vol, newVolName, op)
+	err := genericVFSRenameVolume(d, vol, newVolName, op)
+	if err != nil {
+		return err
+	}
+
+	// Move the bases kept for incremental backups along with the volume.
+	basesPath := d.backupBasesPath(vol)
+	if shared.PathExists(basesPath) {
+		newVol := NewVolume(d, d.name, vol.volType, vol.contentType, newVolName, vol.config, vol.poolConfig)
+		err = os.Rename(basesPath, d.backupBasesPath(newVol))
+		if err != nil {
+			return errors.Wrapf(err, "Failed to rename %q to %q", basesPath, d.backupBasesPath(newVol))
+		}
+	}
+
+	return nil
 }
 
 // MigrateVolume sends a volume for migration.
@@ -937,7 +1142,7 @@ func (d *btrfs) MigrateVolume(vol Volume, conn io.ReadWriteCloser, volSrcArgs *m
 
 // BackupVolume copies a volume (and optionally its snapshots) to a specified target path.
 // This driver does not support optimized backups.
-func (d *btrfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error {
+func (d *btrfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, args BackupArgs, op *operations.Operation) error {
 	// Handle the non-optimized tarballs through the generic packer.
 	if !optimized {
 		// Because the generic backup method will not take a consistent backup if files are being modified
@@ -977,7 +1182,7 @@ func (d *btrfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWr
 			defer d.deleteSubvolume(mountPath, true)
 		}
 
-		return genericVFSBackupVolume(d, vol, tarWriter, snapshots, op)
+		return genericVFSBackupVolume(d, vol, tarWriter, snapshots, args, op)
 	}
 
 	// Optimized backup.
@@ -1151,28 +1356,56 @@ func (d *btrfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWr
 		lastVolPath = snapVol.MountPath()
 	}
 
-	// Make a temporary copy of the instance.
-	sourceVolume := vol.MountPath()
-	instancesPath := GetVolumeMountPath(d.name, vol.volType, "")
+	// Incremental backups only contain the changes since the base kept by the parent backup.
+	if args.ParentUUID != "" {
+		if optimizedHeader.hasNestedSubvolumes() {
+			return fmt.Errorf("Optimized incremental backups aren't supported for volumes with nested subvolumes")
+		}
 
-	tmpInstanceMntPoint, err := ioutil.TempDir(instancesPath, "backup.")
-	if err != nil {
-		return errors.Wrapf(err, "Failed to create temporary directory under %q", instancesPath)
+		lastVolPath = d.backupBasePath(vol, args.ParentUUID)
+		if !btrfsIsSubVolume(lastVolPath) {
+			return fmt.Errorf("The base of the parent backup doesn't exist anymore")
+		}
 	}
-	defer os.RemoveAll(tmpInstanceMntPoint)
 
-	err = os.Chmod(tmpInstanceMntPoint, 0100)
-	if err != nil {
-		return errors.Wrapf(err, "Failed to chmod %q", tmpInstanceMntPoint)
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Make a read-only copy of the instance, which is kept as the base for subsequent incremental backups if
+	// the backup has a UUID and is temporary otherwise.
+	sourceVolume := vol.MountPath()
+	keepBase := args.UUID != "" && !optimizedHeader.hasNestedSubvolumes()
+
+	var targetVolume string
+	if keepBase {
+		targetVolume = d.backupBasePath(vol, args.UUID)
+		err = os.MkdirAll(filepath.Dir(targetVolume), 0700)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to create directory %q", filepath.Dir(targetVolume))
+		}
+	} else {
+		instancesPath := GetVolumeMountPath(d.name, vol.volType, "")
+
+		tmpInstanceMntPoint, err := ioutil.TempDir(instancesPath, "backup.")
+		if err != nil {
+			return errors.Wrapf(err, "Failed to create temporary directory under %q", instancesPath)
+		}
+		defer os.RemoveAll(tmpInstanceMntPoint)
+
+		err = os.Chmod(tmpInstanceMntPoint, 0100)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to chmod %q", tmpInstanceMntPoint)
+		}
+
+		targetVolume = fmt.Sprintf("%s/.backup", tmpInstanceMntPoint)
 	}
 
 	// Create the read-only snapshot.
-	targetVolume := fmt.Sprintf("%s/.backup", tmpInstanceMntPoint)
 	err = d.snapshotSubvolume(sourceVolume, targetVolume, true)
 	if err != nil {
 		return err
 	}
-	defer d.deleteSubvolume(targetVolume, true)
+	revert.Add(func() { d.deleteSubvolume(targetVolume, true) })
 
 	err = d.setSubvolumeReadonlyProperty(targetVolume, true)
 	if err != nil {
@@ -1180,26 +1413,45 @@ func (d *btrfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWr
 	}
 
 	// Dump the instance to a file.
-	fileNamePrefix := "container"
-	if vol.volType == VolumeTypeVM {
-		if vol.contentType == ContentTypeFS {
-			fileNamePrefix = "virtual-machine-config"
-		} else {
-			fileNamePrefix = "virtual-machine"
+	err = addVolume(vol, targetVolume, lastVolPath, d.backupVolumeFilePrefix(vol))
+	if err != nil {
+		return err
+	}
+
+	if !keepBase {
+		// Ensure snapshot sub volumes are removed.
+		err = d.deleteSubvolume(targetVolume, true)
+		if err != nil {
+			return err
 		}
 	}
 
-	err = addVolume(vol, targetVolume, lastVolPath, fileNamePrefix)
-	if err != nil {
-		return err
+	revert.Success()
+	return nil
+}
+
+// DeleteVolumeBackupBase removes the base kept for incremental backups by the backup with the given UUID.
+func (d *btrfs) DeleteVolumeBackupBase(vol Volume, uuid string, op *operations.Operation) error {
+	base := d.backupBasePath(vol, uuid)
+	if !shared.PathExists(base) {
+		return nil
 	}
 
-	// Ensure snapshot sub volumes are removed.
-	err = d.deleteSubvolume(targetVolume, true)
+	err := d.deleteSubvolume(base, true)
 	if err != nil {
 		return err
 	}
 
+	// Remove the directory of the bases if it's now empty.
+	basesPath := d.backupBasesPath(vol)
+	empty, _ := shared.PathIsEmpty(basesPath)
+	if empty {
+		err = os.Remove(basesPath)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to remove %q", basesPath)
+		}
+	}
+
 	return nil
 }
 
@@ -1283,6 +1535,14 @@ func (d *btrfs) MountVolumeSnapshot(snapVol Volume, op *operations.Operation) (b
 
 // UnmountVolumeSnapshot removes the read-only mount placed on top of a snapshot.
 func (d *btrfs) UnmountVolumeSnapshot(snapVol Volume, op *operations.Operation) (bool, error) {
+	// Close the LUKS device of the block file if opened.
+	if snapVol.contentType == ContentTypeBlock {
+		_, err := d.luksClose(snapVol)
+		if err != nil {
+			return false, err
+		}
+	}
+
 	snapPath := snapVol.MountPath()
 	return forceUnmount(snapPath)
 }
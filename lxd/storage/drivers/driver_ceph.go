@@ -35,6 +35,7 @@ func (d *ceph) load() error {
 		"storage_zfs_volmode":                      nil,
 		"storage_rename_custom_volume_add_project": nil,
 		"storage_lvm_skipactivation":               nil,
+		"storage_create_buckets":                   nil,
 	}
 
 	// Done if previously loaded.
@@ -246,6 +247,7 @@ func (d *ceph) Validate(config map[string]string) error {
 		"ceph.rbd.clone_copy":     validate.Optional(validate.IsBool),
 		"ceph.user.name":          validate.IsAny,
 		"volatile.pool.pristine":  validate.IsAny,
+		"volume.block.encryption": validateBlockEncryption,
 		"volume.block.filesystem": func(value string) error {
 			if value == "" {
 				return nil
@@ -260,6 +262,10 @@ func (d *ceph) Validate(config map[string]string) error {
 
 // Update applies any driver changes required from a configuration change.
 func (d *ceph) Update(changedConfig map[string]string) error {
+	if _, changed := changedConfig["volume.block.encryption"]; changed {
+		return fmt.Errorf("volume.block.encryption cannot be changed")
+	}
+
 	return nil
 }
 
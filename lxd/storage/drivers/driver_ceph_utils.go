@@ -141,10 +141,16 @@ func (d *ceph) rbdMapVolume(vol Volume) (string, error) {
 // rbdUnmapVolume unmaps a given RBD storage volume.
 // This is a precondition in order to delete an RBD storage volume can.
 func (d *ceph) rbdUnmapVolume(vol Volume, unmapUntilEINVAL bool) error {
+	// Close the LUKS device (if opened) as it keeps the RBD device busy.
+	_, err := d.luksClose(vol)
+	if err != nil {
+		return err
+	}
+
 	busyCount := 0
 
 again:
-	_, err := shared.RunCommand(
+	_, err = shared.RunCommand(
 		"rbd",
 		"--id", d.config["ceph.user.name"],
 		"--cluster", d.config["ceph.cluster_name"],
@@ -139,6 +139,19 @@ func (d *ceph) CreateVolume(vol Volume, filler *VolumeFiller, op *operations.Ope
 
 	revert.Add(func() { d.rbdUnmapVolume(vol, true) })
 
+	// Setup encryption if requested.
+	if vol.IsEncrypted() {
+		err = d.luksFormat(vol, RBDDevPath)
+		if err != nil {
+			return err
+		}
+
+		RBDDevPath, _, err = d.luksOpen(vol, RBDDevPath)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Get filesystem.
 	RBDFilesystem := vol.ConfigBlockFilesystem()
 
@@ -309,6 +322,11 @@ func (d *ceph) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots boo
 		}
 		defer d.rbdUnmapVolume(v, true)
 
+		RBDDevPath, _, err = d.luksDevPath(v, RBDDevPath)
+		if err != nil {
+			return err
+		}
+
 		if vol.contentType == ContentTypeFS {
 			// Re-generate the UUID. Do this first as ensuring permissions and setting quota can
 			// rely on being able to mount the volume.
@@ -587,6 +605,11 @@ func (d *ceph) CreateVolumeFromMigration(vol Volume, conn io.ReadWriteCloser, vo
 	}
 	defer d.rbdUnmapVolume(vol, true)
 
+	RBDDevPath, _, err = d.luksDevPath(vol, RBDDevPath)
+	if err != nil {
+		return err
+	}
+
 	// Re-generate the UUID.
 	err = d.generateUUID(vol.ConfigBlockFilesystem(), RBDDevPath)
 	if err != nil {
@@ -709,9 +732,101 @@ func (d *ceph) HasVolume(vol Volume) bool {
 	return err == nil
 }
 
+// ListVolumes returns a list of LXD volumes in storage pool.
+func (d *ceph) ListVolumes() ([]Volume, error) {
+	msg, err := shared.RunCommand(
+		"rbd",
+		"--id", d.config["ceph.user.name"],
+		"--format", "json",
+		"--cluster", d.config["ceph.cluster_name"],
+		"--pool", d.config["ceph.osd.pool_name"],
+		"ls",
+		"--long")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to list RBD volumes of %q", d.config["ceph.osd.pool_name"])
+	}
+
+	var data []struct {
+		Image    string `json:"image"`
+		Snapshot string `json:"snapshot"`
+		Size     int64  `json:"size"`
+	}
+
+	err = json.Unmarshal([]byte(msg), &data)
+	if err != nil {
+		return nil, err
+	}
+
+	volTypePrefixes := map[string]VolumeType{
+		fmt.Sprintf("%s_", db.StoragePoolVolumeTypeNameContainer): VolumeTypeContainer,
+		fmt.Sprintf("%s_", db.StoragePoolVolumeTypeNameVM):        VolumeTypeVM,
+		fmt.Sprintf("%s_", db.StoragePoolVolumeTypeNameCustom):    VolumeTypeCustom,
+	}
+
+	vols := []Volume{}
+	for _, entry := range data {
+		// Skip snapshots.
+		if entry.Snapshot != "" {
+			continue
+		}
+
+		var volType VolumeType
+		var volName string
+		for prefix, prefixVolType := range volTypePrefixes {
+			if strings.HasPrefix(entry.Image, prefix) {
+				volType = prefixVolType
+				volName = strings.TrimPrefix(entry.Image, prefix)
+				break
+			}
+		}
+
+		// Ignore images, zombie volumes and any RBD volume not created by LXD.
+		if volType == "" || strings.HasSuffix(volName, tmpVolSuffix) {
+			continue
+		}
+
+		contentType := ContentTypeFS
+		if strings.HasSuffix(volName, ".block") {
+			contentType = ContentTypeBlock
+			volName = strings.TrimSuffix(volName, ".block")
+		}
+
+		// Only return the block volume of virtual machines, its filesystem volume is implied.
+		if volType == VolumeTypeVM && contentType != ContentTypeBlock {
+			continue
+		}
+
+		volConfig := map[string]string{"size": fmt.Sprintf("%d", entry.Size)}
+		vol := NewVolume(d, d.name, volType, contentType, volName, volConfig, d.config)
+
+		// Detect the filesystem of the volume so that it can be mounted.
+		if contentType == ContentTypeFS {
+			devPath, err := d.rbdMapVolume(vol)
+			if err != nil {
+				return nil, err
+			}
+
+			fsType, err := fsProbe(devPath)
+			if err == nil && fsType != "" {
+				volConfig["block.filesystem"] = fsType
+			}
+
+			err = d.rbdUnmapVolume(vol, false)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		vols = append(vols, vol)
+	}
+
+	return vols, nil
+}
+
 // ValidateVolume validates the supplied volume config.
 func (d *ceph) ValidateVolume(vol Volume, removeUnknownKeys bool) error {
 	rules := map[string]func(value string) error{
+		"block.encryption":    validateBlockEncryption,
 		"block.filesystem":    validate.IsAny,
 		"block.mount_options": validate.IsAny,
 	}
@@ -725,6 +840,11 @@ func (d *ceph) UpdateVolume(vol Volume, changedConfig map[string]string) error {
 		return ErrNotSupported
 	}
 
+	_, ok := changedConfig["block.encryption"]
+	if ok {
+		return fmt.Errorf("block.encryption cannot be changed")
+	}
+
 	val, ok := changedConfig["size"]
 	if ok {
 		err := d.SetVolumeQuota(vol, val, nil)
@@ -848,6 +968,10 @@ func (d *ceph) SetVolumeQuota(vol Volume, size string, op *operations.Operation)
 			return errors.Wrap(ErrCannotBeShrunk, "You cannot shrink block volumes")
 		}
 
+		if vol.IsEncrypted() {
+			return errors.Wrap(ErrCannotBeShrunk, "You cannot shrink encrypted volumes")
+		}
+
 		// Shrink the filesystem.
 		if vol.contentType == ContentTypeFS {
 			err = shrinkFileSystem(fsType, RBDDevPath, vol, sizeBytes)
@@ -883,9 +1007,22 @@ func (d *ceph) SetVolumeQuota(vol Volume, size string, op *operations.Operation)
 			return err
 		}
 
+		// Grow the LUKS device if opened.
+		if vol.IsEncrypted() {
+			err = d.luksResize(vol)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Grow the filesystem.
 		if vol.contentType == ContentTypeFS {
-			err = growFileSystem(fsType, RBDDevPath, vol)
+			devPath, _, err := d.luksDevPath(vol, RBDDevPath)
+			if err != nil {
+				return err
+			}
+
+			err = growFileSystem(fsType, devPath, vol)
 			if err != nil {
 				return err
 			}
@@ -895,7 +1032,16 @@ func (d *ceph) SetVolumeQuota(vol Volume, size string, op *operations.Operation)
 	// Move the VM GPT alt header to end of disk if needed (not needed in unsafe resize mode as it is
 	// expected the caller will do all necessary post resize actions themselves).
 	if vol.IsVMBlock() && !vol.allowUnsafeResize {
-		err = d.moveGPTAltHeader(RBDDevPath)
+		devPath, opened, err := d.luksDevPath(vol, RBDDevPath)
+		if err != nil {
+			return err
+		}
+
+		if opened {
+			defer d.luksClose(vol)
+		}
+
+		err = d.moveGPTAltHeader(devPath)
 		if err != nil {
 			return err
 		}
@@ -907,7 +1053,17 @@ func (d *ceph) SetVolumeQuota(vol Volume, size string, op *operations.Operation)
 // GetVolumeDiskPath returns the location of a root disk block device.
 func (d *ceph) GetVolumeDiskPath(vol Volume) (string, error) {
 	if vol.IsVMBlock() || vol.volType == VolumeTypeCustom && vol.contentType == ContentTypeBlock {
-		return d.getRBDMappedDevPath(vol)
+		RBDDevPath, err := d.getRBDMappedDevPath(vol)
+		if err != nil {
+			return "", err
+		}
+
+		devPath, _, err := d.luksDevPath(vol, RBDDevPath)
+		if err != nil {
+			return "", err
+		}
+
+		return devPath, nil
 	}
 
 	return "", ErrNotSupported
@@ -923,6 +1079,12 @@ func (d *ceph) MountVolume(vol Volume, op *operations.Operation) (bool, error) {
 		return false, err
 	}
 
+	// Unlock the volume if encrypted.
+	RBDDevPath, _, err = d.luksDevPath(vol, RBDDevPath)
+	if err != nil {
+		return false, err
+	}
+
 	if vol.contentType == ContentTypeFS && !shared.IsMountPoint(mountPath) {
 		err := vol.EnsureMountPath()
 		if err != nil {
@@ -1146,8 +1308,19 @@ func (d *ceph) MigrateVolume(vol Volume, conn io.ReadWriteCloser, volSrcArgs *mi
 }
 
 // BackupVolume creates an exported version of a volume.
-func (d *ceph) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error {
-	return genericVFSBackupVolume(d, vol, tarWriter, snapshots, op)
+func (d *ceph) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, args BackupArgs, op *operations.Operation) error {
+	return genericVFSBackupVolume(d, vol, tarWriter, snapshots, args, op)
+}
+
+// RefreshVolumeFromBackup applies an incremental backup tarball onto an existing volume.
+func (d *ceph) RefreshVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) error {
+	return genericVFSBackupRefresh(d, vol, srcData, op)
+}
+
+// DeleteVolumeBackupBase removes the base kept for incremental backups by a backup.
+// This driver doesn't keep any, as non-optimized incremental backups use the manifest of their parent.
+func (d *ceph) DeleteVolumeBackupBase(vol Volume, uuid string, op *operations.Operation) error {
+	return nil
 }
 
 // CreateVolumeSnapshot creates a snapshot of a volume.
@@ -1308,6 +1481,18 @@ func (d *ceph) MountVolumeSnapshot(snapVol Volume, op *operations.Operation) (bo
 
 		revert.Add(func() { d.rbdUnmapVolume(cloneVol, true) })
 
+		// Unlock the clone if encrypted. It is opened writable under the name of a temporary volume of the
+		// snapshot as the filesystem UUID may need to be regenerated below.
+		if snapVol.IsEncrypted() {
+			tmpVol := NewVolume(d, d.name, snapVol.volType, snapVol.contentType, fmt.Sprintf("%s%s", snapVol.name, tmpVolSuffix), snapVol.config, snapVol.poolConfig)
+			rbdDevPath, _, err = d.luksOpen(tmpVol, rbdDevPath)
+			if err != nil {
+				return false, err
+			}
+
+			revert.Add(func() { d.luksClose(tmpVol) })
+		}
+
 		if shared.IsMountPoint(mountPath) {
 			return false, nil
 		}
@@ -1353,6 +1538,14 @@ func (d *ceph) MountVolumeSnapshot(snapVol Volume, op *operations.Operation) (bo
 func (d *ceph) UnmountVolumeSnapshot(snapVol Volume, op *operations.Operation) (bool, error) {
 	mountPath := snapVol.MountPath()
 
+	// Close the LUKS device of the snapshot if opened when getting its disk path.
+	if snapVol.contentType == ContentTypeBlock {
+		_, err := d.luksClose(snapVol)
+		if err != nil {
+			return false, err
+		}
+	}
+
 	if !shared.IsMountPoint(mountPath) {
 		return false, nil
 	}
@@ -1368,6 +1561,13 @@ func (d *ceph) UnmountVolumeSnapshot(snapVol Volume, op *operations.Operation) (
 
 	cloneVol := NewVolume(d, d.name, VolumeType("snapshots"), ContentTypeFS, cloneName, nil, nil)
 
+	// Close the LUKS device of the clone if opened.
+	tmpVol := NewVolume(d, d.name, snapVol.volType, snapVol.contentType, fmt.Sprintf("%s%s", snapVol.name, tmpVolSuffix), snapVol.config, snapVol.poolConfig)
+	_, err = d.luksClose(tmpVol)
+	if err != nil {
+		return false, err
+	}
+
 	err = d.rbdUnmapVolume(cloneVol, true)
 	if err != nil {
 		return false, err
@@ -1453,6 +1653,11 @@ func (d *ceph) RestoreVolume(vol Volume, snapshotName string, op *operations.Ope
 	}
 	defer d.rbdUnmapVolume(snapVol, true)
 
+	RBDDevPath, _, err = d.luksDevPath(snapVol, RBDDevPath)
+	if err != nil {
+		return err
+	}
+
 	// Re-generate the UUID.
 	err = d.generateUUID(snapVol.ConfigBlockFilesystem(), RBDDevPath)
 	if err != nil {
@@ -275,6 +275,11 @@ func (d *cephfs) HasVolume(vol Volume) bool {
 	return genericVFSHasVolume(vol)
 }
 
+// ListVolumes returns a list of LXD volumes in storage pool.
+func (d *cephfs) ListVolumes() ([]Volume, error) {
+	return genericVFSListVolumes(d)
+}
+
 // ValidateVolume validates the supplied volume config. Optionally removes invalid keys from the volume's config.
 func (d *cephfs) ValidateVolume(vol Volume, removeUnknownKeys bool) error {
 	return d.validateVolume(vol, nil, removeUnknownKeys)
@@ -443,10 +448,20 @@ func (d *cephfs) MigrateVolume(vol Volume, conn io.ReadWriteCloser, volSrcArgs *
 }
 
 // BackupVolume creates an exported version of a volume.
-func (d *cephfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error {
+func (d *cephfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, args BackupArgs, op *operations.Operation) error {
+	return ErrNotImplemented
+}
+
+// RefreshVolumeFromBackup applies an incremental backup onto an existing volume.
+func (d *cephfs) RefreshVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) error {
 	return ErrNotImplemented
 }
 
+// DeleteVolumeBackupBase removes the base kept for incremental backups by a backup.
+func (d *cephfs) DeleteVolumeBackupBase(vol Volume, uuid string, op *operations.Operation) error {
+	return nil
+}
+
 // CreateVolumeSnapshot creates a new snapshot.
 func (d *cephfs) CreateVolumeSnapshot(snapVol Volume, op *operations.Operation) error {
 	parentName, snapName, _ := shared.InstanceGetParentAndSnapshotName(snapVol.name)
@@ -18,16 +18,18 @@ type common struct {
 	name        string
 	config      map[string]string
 	getVolID    func(volType VolumeType, volName string) (int64, error)
+	getVolKey   func(volType VolumeType, volName string, create bool) (string, error)
 	commonRules *Validators
 	state       *state.State
 	logger      logger.Logger
 	patches     map[string]func() error
 }
 
-func (d *common) init(state *state.State, name string, config map[string]string, logger logger.Logger, volIDFunc func(volType VolumeType, volName string) (int64, error), commonRules *Validators) {
+func (d *common) init(state *state.State, name string, config map[string]string, logger logger.Logger, volIDFunc func(volType VolumeType, volName string) (int64, error), volKeyFunc func(volType VolumeType, volName string, create bool) (string, error), commonRules *Validators) {
 	d.name = name
 	d.config = config
 	d.getVolID = volIDFunc
+	d.getVolKey = volKeyFunc
 	d.commonRules = commonRules
 	d.state = state
 	d.logger = logger
@@ -115,9 +117,9 @@ func (d *common) validateVolume(vol Volume, driverRules map[string]func(value st
 		}
 	}
 
-	// If volume type is not custom, don't allow "size" property.
-	if vol.volType != VolumeTypeCustom && vol.config["size"] != "" {
-		return fmt.Errorf("Volume %q property is only valid for custom volume types", "size")
+	// If volume type is not custom or bucket, don't allow "size" property.
+	if vol.volType != VolumeTypeCustom && vol.volType != VolumeTypeBucket && vol.config["size"] != "" {
+		return fmt.Errorf("Volume %q property is only valid for custom and bucket volume types", "size")
 	}
 
 	return nil
@@ -26,6 +26,7 @@ func (d *dir) load() error {
 		"storage_zfs_volmode":                      nil,
 		"storage_rename_custom_volume_add_project": nil,
 		"storage_lvm_skipactivation":               nil,
+		"storage_create_buckets":                   nil,
 	}
 
 	return nil
@@ -39,7 +40,7 @@ func (d *dir) Info() Info {
 		OptimizedImages:       false,
 		PreservesInodes:       false,
 		Remote:                false,
-		VolumeTypes:           []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM},
+		VolumeTypes:           []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM, VolumeTypeBucket},
 		BlockBacking:          false,
 		RunningQuotaResize:    true,
 		RunningSnapshotFreeze: true,
@@ -100,11 +101,19 @@ func (d *dir) Delete(op *operations.Operation) error {
 
 // Validate checks that all provide keys are supported and that no conflicting or missing configuration is present.
 func (d *dir) Validate(config map[string]string) error {
-	return d.validatePool(config, nil)
+	rules := map[string]func(value string) error{
+		"volume.block.encryption": validateBlockEncryption,
+	}
+
+	return d.validatePool(config, rules)
 }
 
 // Update applies any driver changes required from a configuration change.
 func (d *dir) Update(changedConfig map[string]string) error {
+	if _, changed := changedConfig["volume.block.encryption"]; changed {
+		return fmt.Errorf("volume.block.encryption cannot be changed")
+	}
+
 	return nil
 }
 
@@ -37,7 +37,7 @@ func (d *dir) CreateVolume(vol Volume, filler *VolumeFiller, op *operations.Oper
 	rootBlockPath := ""
 	if vol.contentType == ContentTypeBlock {
 		// We expect the filler to copy the VM image into this path.
-		rootBlockPath, err = d.GetVolumeDiskPath(vol)
+		rootBlockPath, err = genericVFSGetVolumeDiskPath(vol)
 		if err != nil {
 			return err
 		}
@@ -54,7 +54,19 @@ func (d *dir) CreateVolume(vol Volume, filler *VolumeFiller, op *operations.Oper
 	}
 
 	// Run the volume filler function if supplied.
-	err = d.runFiller(vol, rootBlockPath, filler)
+	// Setup encryption if requested. The block file is then created upfront and the filler writes to its
+	// opened LUKS device instead.
+	devPath := rootBlockPath
+	if vol.IsEncrypted() {
+		devPath, err = d.luksSetupBlockFile(vol, rootBlockPath)
+		if err != nil {
+			return err
+		}
+
+		defer d.luksClose(vol)
+	}
+
+	err = d.runFiller(vol, devPath, filler)
 	if err != nil {
 		return err
 	}
@@ -77,7 +89,7 @@ func (d *dir) CreateVolume(vol Volume, filler *VolumeFiller, op *operations.Oper
 
 		// Move the GPT alt header to end of disk if needed and if filler specified.
 		if vol.IsVMBlock() && filler != nil && filler.Fill != nil {
-			err = d.moveGPTAltHeader(rootBlockPath)
+			err = d.moveGPTAltHeader(devPath)
 			if err != nil {
 				return err
 			}
@@ -168,6 +180,14 @@ func (d *dir) DeleteVolume(vol Volume, op *operations.Operation) error {
 		return nil
 	}
 
+	// Close the LUKS device of the block file if opened.
+	if vol.contentType == ContentTypeBlock {
+		_, err = d.luksClose(vol)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Get the volume ID for the volume, which is used to remove project quota.
 	volID, err := d.getVolID(vol.volType, vol.name)
 	if err != nil {
@@ -201,13 +221,29 @@ func (d *dir) HasVolume(vol Volume) bool {
 	return genericVFSHasVolume(vol)
 }
 
+// ListVolumes returns a list of LXD volumes in storage pool.
+func (d *dir) ListVolumes() ([]Volume, error) {
+	return genericVFSListVolumes(d)
+}
+
 // ValidateVolume validates the supplied volume config. Optionally removes invalid keys from the volume's config.
 func (d *dir) ValidateVolume(vol Volume, removeUnknownKeys bool) error {
-	return d.validateVolume(vol, nil, removeUnknownKeys)
+	rules := map[string]func(value string) error{}
+
+	// Only volumes backed by block files can be encrypted.
+	if vol.contentType == ContentTypeBlock {
+		rules["block.encryption"] = validateBlockEncryption
+	}
+
+	return d.validateVolume(vol, rules, removeUnknownKeys)
 }
 
 // UpdateVolume applies config changes to the volume.
 func (d *dir) UpdateVolume(vol Volume, changedConfig map[string]string) error {
+	if _, changed := changedConfig["block.encryption"]; changed {
+		return fmt.Errorf("block.encryption cannot be changed")
+	}
+
 	if _, changed := changedConfig["size"]; changed {
 		err := d.SetVolumeQuota(vol, changedConfig["size"], nil)
 		if err != nil {
@@ -264,7 +300,7 @@ func (d *dir) SetVolumeQuota(vol Volume, size string, op *operations.Operation)
 			return nil
 		}
 
-		rootBlockPath, err := d.GetVolumeDiskPath(vol)
+		rootBlockPath, err := genericVFSGetVolumeDiskPath(vol)
 		if err != nil {
 			return err
 		}
@@ -274,11 +310,28 @@ func (d *dir) SetVolumeQuota(vol Volume, size string, op *operations.Operation)
 			return err
 		}
 
+		// Grow the LUKS device if opened.
+		if vol.IsEncrypted() && resized {
+			err = d.luksResizeBlockFile(vol, rootBlockPath)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Move the GPT alt header to end of disk if needed and resize has taken place (not needed in
 		// unsafe resize mode as it is expected the caller will do all necessary post resize actions
 		// themselves).
 		if vol.IsVMBlock() && resized && !vol.allowUnsafeResize {
-			err = d.moveGPTAltHeader(rootBlockPath)
+			devPath, opened, err := d.luksDevPath(vol, rootBlockPath)
+			if err != nil {
+				return err
+			}
+
+			if opened {
+				defer d.luksClose(vol)
+			}
+
+			err = d.moveGPTAltHeader(devPath)
 			if err != nil {
 				return err
 			}
@@ -287,6 +340,11 @@ func (d *dir) SetVolumeQuota(vol Volume, size string, op *operations.Operation)
 		return nil
 	}
 
+	// The VM filesystem volume shares its directory with the block file, so there is no separate quota.
+	if vol.volType == VolumeTypeVM {
+		return nil
+	}
+
 	// For non-VM block volumes, set filesystem quota.
 	volID, err := d.getVolID(vol.volType, vol.name)
 	if err != nil {
@@ -298,7 +356,17 @@ func (d *dir) SetVolumeQuota(vol Volume, size string, op *operations.Operation)
 
 // GetVolumeDiskPath returns the location of a disk volume.
 func (d *dir) GetVolumeDiskPath(vol Volume) (string, error) {
-	return genericVFSGetVolumeDiskPath(vol)
+	rootBlockPath, err := genericVFSGetVolumeDiskPath(vol)
+	if err != nil {
+		return "", err
+	}
+
+	devPath, _, err := d.luksDevPath(vol, rootBlockPath)
+	if err != nil {
+		return "", err
+	}
+
+	return devPath, nil
 }
 
 // MountVolume simulates mounting a volume. As the driver doesn't have volumes to mount it returns
@@ -319,6 +387,14 @@ func (d *dir) MountVolume(vol Volume, op *operations.Operation) (bool, error) {
 // UnmountVolume simulates unmounting a volume. As dir driver doesn't have volumes to unmount it
 // returns false indicating the volume was already unmounted.
 func (d *dir) UnmountVolume(vol Volume, op *operations.Operation) (bool, error) {
+	// Close the LUKS device of the block file if opened.
+	if vol.contentType == ContentTypeBlock {
+		_, err := d.luksClose(vol)
+		if err != nil {
+			return false, err
+		}
+	}
+
 	return false, nil
 }
 
@@ -334,8 +410,19 @@ func (d *dir) MigrateVolume(vol Volume, conn io.ReadWriteCloser, volSrcArgs *mig
 
 // BackupVolume copies a volume (and optionally its snapshots) to a specified target path.
 // This driver does not support optimized backups.
-func (d *dir) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error {
-	return genericVFSBackupVolume(d, vol, tarWriter, snapshots, op)
+func (d *dir) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, args BackupArgs, op *operations.Operation) error {
+	return genericVFSBackupVolume(d, vol, tarWriter, snapshots, args, op)
+}
+
+// RefreshVolumeFromBackup applies an incremental backup tarball onto an existing volume.
+func (d *dir) RefreshVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) error {
+	return genericVFSBackupRefresh(d, vol, srcData, op)
+}
+
+// DeleteVolumeBackupBase removes the base kept for incremental backups by a backup.
+// This driver doesn't keep any, as non-optimized incremental backups use the manifest of their parent.
+func (d *dir) DeleteVolumeBackupBase(vol Volume, uuid string, op *operations.Operation) error {
+	return nil
 }
 
 // CreateVolumeSnapshot creates a snapshot of a volume.
@@ -415,6 +502,14 @@ func (d *dir) MountVolumeSnapshot(snapVol Volume, op *operations.Operation) (boo
 
 // UnmountVolumeSnapshot removes the read-only mount placed on top of a snapshot.
 func (d *dir) UnmountVolumeSnapshot(snapVol Volume, op *operations.Operation) (bool, error) {
+	// Close the LUKS device of the block file if opened.
+	if snapVol.contentType == ContentTypeBlock {
+		_, err := d.luksClose(snapVol)
+		if err != nil {
+			return false, err
+		}
+	}
+
 	snapPath := snapVol.MountPath()
 	return forceUnmount(snapPath)
 }
@@ -39,6 +39,7 @@ func (d *lvm) load() error {
 		"storage_zfs_volmode":                      nil,
 		"storage_rename_custom_volume_add_project": nil,
 		"storage_lvm_skipactivation":               d.patchStorageSkipActivation,
+		"storage_create_buckets":                   nil,
 	}
 
 	// Done if previously loaded.
@@ -92,7 +93,7 @@ func (d *lvm) Info() Info {
 		OptimizedImages:       d.usesThinpool(), // Only thinpool pools support optimized images.
 		PreservesInodes:       false,
 		Remote:                false,
-		VolumeTypes:           []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM},
+		VolumeTypes:           []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM, VolumeTypeBucket},
 		BlockBacking:          true,
 		RunningQuotaResize:    false,
 		RunningSnapshotFreeze: false,
@@ -433,6 +434,7 @@ func (d *lvm) Validate(config map[string]string) error {
 		"lvm.thinpool_name":          validate.IsAny,
 		"lvm.use_thinpool":           validate.Optional(validate.IsBool),
 		"volume.block.mount_options": validate.IsAny,
+		"volume.block.encryption":    validateBlockEncryption,
 		"volume.block.filesystem": func(value string) error {
 			if value == "" {
 				return nil
@@ -470,6 +472,10 @@ func (d *lvm) Update(changedConfig map[string]string) error {
 		return fmt.Errorf("volume.lvm.stripes.size cannot be changed when using thin pool")
 	}
 
+	if _, changed := changedConfig["volume.block.encryption"]; changed {
+		return fmt.Errorf("volume.block.encryption cannot be changed")
+	}
+
 	if changedConfig["lvm.vg_name"] != "" {
 		_, err := shared.TryRunCommand("vgrename", d.config["lvm.vg_name"], changedConfig["lvm.vg_name"])
 		if err != nil {
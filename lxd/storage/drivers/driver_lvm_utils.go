@@ -353,8 +353,25 @@ func (d *lvm) createLogicalVolume(vgName, thinPoolName string, vol Volume, makeT
 
 	volDevPath := d.lvmDevPath(vgName, vol.volType, vol.contentType, vol.name)
 
+	if vol.IsEncrypted() {
+		err = d.luksFormat(vol, volDevPath)
+		if err != nil {
+			return err
+		}
+	}
+
 	if vol.contentType == ContentTypeFS {
-		_, err = makeFSType(volDevPath, vol.ConfigBlockFilesystem(), nil)
+		fsDevPath, opened, err := d.luksDevPath(vol, volDevPath)
+		if err != nil {
+			return err
+		}
+
+		_, err = makeFSType(fsDevPath, vol.ConfigBlockFilesystem(), nil)
+
+		if opened {
+			d.luksClose(vol)
+		}
+
 		if err != nil {
 			return errors.Wrapf(err, "Error making filesystem on LVM logical volume")
 		}
@@ -474,6 +491,8 @@ func (d *lvm) lvmFullVolumeName(volType VolumeType, contentType ContentType, vol
 		volTypePrefix = "images"
 	case VolumeTypeCustom:
 		volTypePrefix = "custom"
+	case VolumeTypeBucket:
+		volTypePrefix = "buckets"
 	}
 
 	// Invalid volume type supplied.
@@ -614,8 +633,18 @@ func (d *lvm) copyThinpoolVolume(vol, srcVol Volume, srcSnapshots []Volume, refr
 				return err
 			}
 
-			d.logger.Debug("Regenerating filesystem UUID", log.Ctx{"dev": volDevPath, "fs": vol.ConfigBlockFilesystem()})
-			err = regenerateFilesystemUUID(vol.ConfigBlockFilesystem(), volDevPath)
+			fsDevPath, opened, err := d.luksDevPath(vol, volDevPath)
+			if err != nil {
+				return err
+			}
+
+			d.logger.Debug("Regenerating filesystem UUID", log.Ctx{"dev": fsDevPath, "fs": vol.ConfigBlockFilesystem()})
+			err = regenerateFilesystemUUID(vol.ConfigBlockFilesystem(), fsDevPath)
+
+			if opened {
+				d.luksClose(vol)
+			}
+
 			if err != nil {
 				return err
 			}
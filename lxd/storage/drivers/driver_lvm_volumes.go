@@ -194,6 +194,11 @@ func (d *lvm) DeleteVolume(vol Volume, op *operations.Operation) error {
 			}
 		}
 
+		_, err = d.luksClose(vol)
+		if err != nil {
+			return err
+		}
+
 		err = d.removeLogicalVolume(d.lvmDevPath(d.config["lvm.vg_name"], vol.volType, vol.contentType, vol.name))
 		if err != nil {
 			return errors.Wrapf(err, "Error removing LVM logical volume")
@@ -239,6 +244,89 @@ func (d *lvm) HasVolume(vol Volume) bool {
 	return volExists
 }
 
+// ListVolumes returns a list of LXD volumes in storage pool.
+func (d *lvm) ListVolumes() ([]Volume, error) {
+	vgName := d.config["lvm.vg_name"]
+
+	out, err := shared.RunCommand("lvs", "--noheadings", "--nosuffix", "--units", "b", "-o", "lv_name,lv_size", vgName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to list logical volumes of %q", vgName)
+	}
+
+	volTypePrefixes := map[string]VolumeType{
+		"containers_":       VolumeTypeContainer,
+		"virtual-machines_": VolumeTypeVM,
+		"custom_":           VolumeTypeCustom,
+	}
+
+	vols := []Volume{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		var volType VolumeType
+		var lvName string
+		for prefix, prefixVolType := range volTypePrefixes {
+			if strings.HasPrefix(fields[0], prefix) {
+				volType = prefixVolType
+				lvName = strings.TrimPrefix(fields[0], prefix)
+				break
+			}
+		}
+
+		// Ignore images, the thin pool and any logical volume not created by LXD.
+		if volType == "" || strings.HasSuffix(lvName, tmpVolSuffix) {
+			continue
+		}
+
+		contentType := ContentTypeFS
+		if strings.HasSuffix(lvName, lvmBlockVolSuffix) {
+			contentType = ContentTypeBlock
+			lvName = strings.TrimSuffix(lvName, lvmBlockVolSuffix)
+		}
+
+		// Only return the block volume of virtual machines, its filesystem volume is implied.
+		if volType == VolumeTypeVM && contentType != ContentTypeBlock {
+			continue
+		}
+
+		// Snapshots are the only logical volumes with an unescaped hyphen in their name.
+		if strings.Contains(strings.Replace(lvName, lvmEscapedHyphen, "", -1), lvmSnapshotSeparator) {
+			continue
+		}
+
+		volName := strings.Replace(lvName, lvmEscapedHyphen, "-", -1)
+		volConfig := map[string]string{"size": fields[1]}
+
+		// Detect the filesystem of the volume so that it can be mounted.
+		if contentType == ContentTypeFS {
+			volDevPath := d.lvmDevPath(vgName, volType, contentType, volName)
+			activated, err := d.activateVolume(volDevPath)
+			if err != nil {
+				return nil, err
+			}
+
+			fsType, err := fsProbe(volDevPath)
+			if err == nil && fsType != "" {
+				volConfig["block.filesystem"] = fsType
+			}
+
+			if activated {
+				_, err = d.deactivateVolume(volDevPath)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		vols = append(vols, NewVolume(d, d.name, volType, contentType, volName, volConfig, d.config))
+	}
+
+	return vols, nil
+}
+
 // ValidateVolume validates the supplied volume config.
 func (d *lvm) ValidateVolume(vol Volume, removeUnknownKeys bool) error {
 	rules := map[string]func(value string) error{
@@ -248,6 +336,7 @@ func (d *lvm) ValidateVolume(vol Volume, removeUnknownKeys bool) error {
 			}
 			return validate.IsOneOf(value, lvmAllowedFilesystems)
 		},
+		"block.encryption": validateBlockEncryption,
 		"lvm.stripes":      validate.Optional(validate.IsUint32),
 		"lvm.stripes.size": validate.Optional(validate.IsSize),
 	}
@@ -285,6 +374,10 @@ func (d *lvm) UpdateVolume(vol Volume, changedConfig map[string]string) error {
 		return fmt.Errorf("lvm.stripes.size cannot be changed")
 	}
 
+	if _, changed := changedConfig["block.encryption"]; changed {
+		return fmt.Errorf("block.encryption cannot be changed")
+	}
+
 	return nil
 }
 
@@ -370,6 +463,11 @@ func (d *lvm) SetVolumeQuota(vol Volume, size string, op *operations.Operation)
 		defer d.deactivateVolume(volDevPath)
 	}
 
+	// The LUKS device of encrypted volumes sits between the logical volume and its contents.
+	if sizeBytes < oldSizeBytes && vol.IsEncrypted() {
+		return errors.Wrap(ErrCannotBeShrunk, "You cannot shrink encrypted volumes")
+	}
+
 	// Resize filesystem if needed.
 	if vol.contentType == ContentTypeFS {
 		if sizeBytes < oldSizeBytes {
@@ -391,7 +489,18 @@ func (d *lvm) SetVolumeQuota(vol Volume, size string, op *operations.Operation)
 				return err
 			}
 
-			err = growFileSystem(vol.ConfigBlockFilesystem(), volDevPath, vol)
+			fsDevPath := volDevPath
+			if vol.IsEncrypted() {
+				err = d.luksResize(vol)
+				if err != nil {
+					return err
+				}
+
+				// The LUKS device is opened when mounting the volume to grow the filesystem.
+				fsDevPath = luksMapperPath(vol)
+			}
+
+			err = growFileSystem(vol.ConfigBlockFilesystem(), fsDevPath, vol)
 			if err != nil {
 				return err
 			}
@@ -408,10 +517,24 @@ func (d *lvm) SetVolumeQuota(vol Volume, size string, op *operations.Operation)
 
 		}
 
+		err = d.luksResize(vol)
+		if err != nil {
+			return err
+		}
+
 		// Move the VM GPT alt header to end of disk if needed (not needed in unsafe resize mode as it is
 		// expected the caller will do all necessary post resize actions themselves).
 		if vol.IsVMBlock() && !vol.allowUnsafeResize {
-			err = d.moveGPTAltHeader(volDevPath)
+			devPath, opened, err := d.luksDevPath(vol, volDevPath)
+			if err != nil {
+				return err
+			}
+
+			if opened {
+				defer d.luksClose(vol)
+			}
+
+			err = d.moveGPTAltHeader(devPath)
 			if err != nil {
 				return err
 			}
@@ -425,7 +548,8 @@ func (d *lvm) SetVolumeQuota(vol Volume, size string, op *operations.Operation)
 func (d *lvm) GetVolumeDiskPath(vol Volume) (string, error) {
 	if vol.IsVMBlock() || vol.volType == VolumeTypeCustom && vol.contentType == ContentTypeBlock {
 		volDevPath := d.lvmDevPath(d.config["lvm.vg_name"], vol.volType, vol.contentType, vol.name)
-		return volDevPath, nil
+		devPath, _, err := d.luksDevPath(vol, volDevPath)
+		return devPath, err
 	}
 
 	return "", ErrNotSupported
@@ -451,16 +575,31 @@ func (d *lvm) MountVolume(vol Volume, op *operations.Operation) (bool, error) {
 			return false, err
 		}
 
+		fsDevPath, _, err := d.luksDevPath(vol, volDevPath)
+		if err != nil {
+			return false, err
+		}
+
 		mountFlags, mountOptions := resolveMountOptions(vol.ConfigBlockMountOptions())
-		err = TryMount(volDevPath, mountPath, vol.ConfigBlockFilesystem(), mountFlags, mountOptions)
+		err = TryMount(fsDevPath, mountPath, vol.ConfigBlockFilesystem(), mountFlags, mountOptions)
 		if err != nil {
 			return false, errors.Wrapf(err, "Failed to mount LVM logical volume")
 		}
-		d.logger.Debug("Mounted logical volume", log.Ctx{"dev": volDevPath, "path": mountPath, "options": mountOptions})
+		d.logger.Debug("Mounted logical volume", log.Ctx{"dev": fsDevPath, "path": mountPath, "options": mountOptions})
 
 		return true, nil
 	}
 
+	// Unlock encrypted block volumes.
+	if vol.contentType == ContentTypeBlock {
+		_, opened, err := d.luksDevPath(vol, volDevPath)
+		if err != nil {
+			return false, err
+		}
+
+		activated = activated || opened
+	}
+
 	// For VMs, mount the filesystem volume.
 	if vol.IsVMBlock() {
 		fsVol := vol.NewVMBlockFilesystemVolume()
@@ -484,6 +623,11 @@ func (d *lvm) UnmountVolume(vol Volume, op *operations.Operation) (bool, error)
 		}
 		d.logger.Debug("Unmounted logical volume", log.Ctx{"path": mountPath})
 
+		_, err = d.luksClose(vol)
+		if err != nil {
+			return false, err
+		}
+
 		// We only deactivate filesystem volumes if an unmount was needed to better align with our
 		// unmount return value indicator.
 		_, err = d.deactivateVolume(volDevPath)
@@ -496,10 +640,17 @@ func (d *lvm) UnmountVolume(vol Volume, op *operations.Operation) (bool, error)
 
 	deactivated := false
 	if vol.contentType == ContentTypeBlock {
+		closed, err := d.luksClose(vol)
+		if err != nil {
+			return false, err
+		}
+
 		deactivated, err = d.deactivateVolume(volDevPath)
 		if err != nil {
 			return false, err
 		}
+
+		deactivated = deactivated || closed
 	}
 
 	// For VMs, unmount the filesystem volume.
@@ -602,8 +753,19 @@ func (d *lvm) MigrateVolume(vol Volume, conn io.ReadWriteCloser, volSrcArgs *mig
 
 // BackupVolume copies a volume (and optionally its snapshots) to a specified target path.
 // This driver does not support optimized backups.
-func (d *lvm) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, _, snapshots bool, op *operations.Operation) error {
-	return genericVFSBackupVolume(d, vol, tarWriter, snapshots, op)
+func (d *lvm) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, _, snapshots bool, args BackupArgs, op *operations.Operation) error {
+	return genericVFSBackupVolume(d, vol, tarWriter, snapshots, args, op)
+}
+
+// RefreshVolumeFromBackup applies an incremental backup tarball onto an existing volume.
+func (d *lvm) RefreshVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) error {
+	return genericVFSBackupRefresh(d, vol, srcData, op)
+}
+
+// DeleteVolumeBackupBase removes the base kept for incremental backups by a backup.
+// This driver doesn't keep any, as non-optimized incremental backups use the manifest of their parent.
+func (d *lvm) DeleteVolumeBackupBase(vol Volume, uuid string, op *operations.Operation) error {
+	return nil
 }
 
 // CreateVolumeSnapshot creates a snapshot of a volume.
@@ -757,6 +919,14 @@ func (d *lvm) MountVolumeSnapshot(snapVol Volume, op *operations.Operation) (boo
 			return false, err
 		}
 
+		// Use the opened LUKS device for encrypted volumes.
+		volDevPath, _, err = d.luksDevPath(mountVol, volDevPath)
+		if err != nil {
+			return false, err
+		}
+
+		revert.Add(func() { d.luksClose(mountVol) })
+
 		if regenerateFSUUID {
 			tmpVolFsType := mountVol.ConfigBlockFilesystem()
 
@@ -796,6 +966,14 @@ func (d *lvm) MountVolumeSnapshot(snapVol Volume, op *operations.Operation) (boo
 		if err != nil {
 			return false, err
 		}
+
+		// Unlock encrypted block volumes.
+		_, opened, err := d.luksDevPath(snapVol, volDevPath)
+		if err != nil {
+			return false, err
+		}
+
+		activated = activated || opened
 	}
 
 	// For VMs, mount the filesystem volume.
@@ -830,7 +1008,19 @@ func (d *lvm) UnmountVolumeSnapshot(snapVol Volume, op *operations.Operation) (b
 			return true, errors.Wrapf(err, "Failed to check existence of temporary LVM snapshot volume %q", tmpVolDevPath)
 		}
 
+		// Close the LUKS devices of encrypted volumes, whichever was mounted.
+		_, err = d.luksClose(snapVol)
+		if err != nil {
+			return true, err
+		}
+
 		if exists {
+			tmpVol := NewVolume(d, d.name, snapVol.volType, snapVol.contentType, tmpVolName, snapVol.config, snapVol.poolConfig)
+			_, err = d.luksClose(tmpVol)
+			if err != nil {
+				return true, err
+			}
+
 			err = d.removeLogicalVolume(tmpVolDevPath)
 			if err != nil {
 				return true, errors.Wrapf(err, "Failed to remove temporary LVM snapshot volume %q", tmpVolDevPath)
@@ -849,10 +1039,17 @@ func (d *lvm) UnmountVolumeSnapshot(snapVol Volume, op *operations.Operation) (b
 
 	deactivated := false
 	if snapVol.contentType == ContentTypeBlock {
+		closed, err := d.luksClose(snapVol)
+		if err != nil {
+			return false, err
+		}
+
 		deactivated, err = d.deactivateVolume(volDevPath)
 		if err != nil {
 			return false, err
 		}
+
+		deactivated = deactivated || closed
 	}
 
 	// For VMs, unmount the filesystem volume.
@@ -965,8 +1162,18 @@ func (d *lvm) RestoreVolume(vol Volume, snapshotName string, op *operations.Oper
 				return err
 			}
 
-			d.logger.Debug("Regenerating filesystem UUID", log.Ctx{"dev": volDevPath, "fs": vol.ConfigBlockFilesystem()})
-			err = regenerateFilesystemUUID(vol.ConfigBlockFilesystem(), volDevPath)
+			fsDevPath, opened, err := d.luksDevPath(vol, volDevPath)
+			if err != nil {
+				return err
+			}
+
+			d.logger.Debug("Regenerating filesystem UUID", log.Ctx{"dev": fsDevPath, "fs": vol.ConfigBlockFilesystem()})
+			err = regenerateFilesystemUUID(vol.ConfigBlockFilesystem(), fsDevPath)
+
+			if opened {
+				d.luksClose(vol)
+			}
+
 			if err != nil {
 				return err
 			}
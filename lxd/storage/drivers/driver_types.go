@@ -1,5 +1,9 @@
 package drivers
 
+import (
+	"io"
+)
+
 // Info represents information about a storage driver.
 type Info struct {
 	Name                  string
@@ -24,3 +28,11 @@ type VolumeFiller struct {
 
 	Fingerprint string // If the Filler will unpack an image, it should be this fingerprint.
 }
+
+// BackupArgs provides a struct for the arguments of a volume backup.
+type BackupArgs struct {
+	UUID           string        // UUID of the backup, used to name the base kept for subsequent incremental backups.
+	ParentUUID     string        // UUID of the parent backup, only set for incremental backups.
+	ParentData     io.ReadSeeker // Tarball of the parent backup, only set for incremental backups.
+	AllowPlaintext bool          // Whether encrypted volumes may be backed up, their backups being unencrypted.
+}
@@ -48,6 +48,7 @@ func (d *zfs) load() error {
 		"storage_zfs_volmode":                      d.patchStorageZFSVolMode,
 		"storage_rename_custom_volume_add_project": nil,
 		"storage_lvm_skipactivation":               nil,
+		"storage_create_buckets":                   d.patchStorageCreateVM,
 	}
 
 	// Done if previously loaded.
@@ -109,7 +110,7 @@ func (d *zfs) Info() Info {
 		OptimizedBackups:      true,
 		PreservesInodes:       true,
 		Remote:                false,
-		VolumeTypes:           []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM},
+		VolumeTypes:           []VolumeType{VolumeTypeCustom, VolumeTypeImage, VolumeTypeContainer, VolumeTypeVM, VolumeTypeBucket},
 		BlockBacking:          false,
 		RunningQuotaResize:    true,
 		RunningSnapshotFreeze: false,
@@ -346,6 +347,7 @@ func (d *zfs) Validate(config map[string]string) error {
 		"zfs.clone_copy":              validate.Optional(validate.IsBool),
 		"volume.zfs.remove_snapshots": validate.Optional(validate.IsBool),
 		"volume.zfs.use_refquota":     validate.Optional(validate.IsBool),
+		"volume.block.encryption":     validateBlockEncryption,
 	}
 
 	return d.validatePool(config, rules)
@@ -358,6 +360,10 @@ func (d *zfs) Update(changedConfig map[string]string) error {
 		return fmt.Errorf("zfs.pool_name cannot be modified")
 	}
 
+	if _, changed := changedConfig["volume.block.encryption"]; changed {
+		return fmt.Errorf("volume.block.encryption cannot be changed")
+	}
+
 	return nil
 }
 
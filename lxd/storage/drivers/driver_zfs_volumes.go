@@ -173,6 +173,19 @@ func (d *zfs) CreateVolume(vol Volume, filler *VolumeFiller, op *operations.Oper
 	}
 
 	err := vol.MountTask(func(mountPath string, op *operations.Operation) error {
+		// Setup encryption if requested (the zvol is only visible once mounted).
+		if vol.IsEncrypted() {
+			zvolDevPath, err := d.getVolumeDiskPath(vol)
+			if err != nil {
+				return err
+			}
+
+			err = d.luksFormat(vol, zvolDevPath)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Run the volume filler function if supplied.
 		if filler != nil && filler.Fill != nil {
 			var err error
@@ -287,40 +300,6 @@ func (d *zfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData
 	// Only execute the revert function if we have had an error internally.
 	revert.Add(revertHook)
 
-	// Define function to unpack a volume from a backup tarball file.
-	unpackVolume := func(r io.ReadSeeker, unpacker []string, srcFile string, target string) error {
-		d.Logger().Debug("Unpacking optimized volume", log.Ctx{"source": srcFile, "target": target})
-		tr, cancelFunc, err := shared.CompressedTarReader(context.Background(), r, unpacker)
-		if err != nil {
-			return err
-		}
-		defer cancelFunc()
-
-		for {
-			hdr, err := tr.Next()
-			if err == io.EOF {
-				break // End of archive
-			}
-			if err != nil {
-				return err
-			}
-
-			if hdr.Name == srcFile {
-				// Extract the backup.
-				err = shared.RunCommandWithFds(tr, nil, "zfs", "receive", "-F", target)
-
-				if err != nil {
-					return err
-				}
-
-				cancelFunc()
-				return nil
-			}
-		}
-
-		return fmt.Errorf("Could not find %q", srcFile)
-	}
-
 	// Find the compression algorithm used for backup source data.
 	srcData.Seek(0, 0)
 	_, _, unpacker, err := shared.DetectCompressionFile(srcData)
@@ -349,23 +328,14 @@ func (d *zfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData
 
 		srcFile := fmt.Sprintf("backup/%s/%s", prefix, fileName)
 		dstSnapshot := fmt.Sprintf("%s@snapshot-%s", d.dataset(vol, false), snapName)
-		err = unpackVolume(srcData, unpacker, srcFile, dstSnapshot)
+		err = d.unpackBackupVolume(srcData, unpacker, srcFile, dstSnapshot)
 		if err != nil {
 			return nil, nil, err
 		}
 	}
 
 	// Extract main volume.
-	fileName := "container.bin"
-	if vol.volType == VolumeTypeVM {
-		if vol.contentType == ContentTypeFS {
-			fileName = "virtual-machine-config.bin"
-		} else {
-			fileName = "virtual-machine.bin"
-		}
-	}
-
-	err = unpackVolume(srcData, unpacker, fmt.Sprintf("backup/%s", fileName), d.dataset(vol, false))
+	err = d.unpackBackupVolume(srcData, unpacker, d.backupVolumeFile(vol), d.dataset(vol, false))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -376,12 +346,16 @@ func (d *zfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData
 		return nil, nil, err
 	}
 
-	// Filter only the snapshots.
+	// Filter only the snapshots, keeping the base needed to apply subsequent incremental backups.
 	for _, entry := range entries {
 		if strings.HasPrefix(entry, "@snapshot-") {
 			continue
 		}
 
+		if srcBackup.UUID != "" && entry == fmt.Sprintf("@backup-%s", srcBackup.UUID) {
+			continue
+		}
+
 		if strings.HasPrefix(entry, "@") {
 			_, err := shared.RunCommand("zfs", "destroy", fmt.Sprintf("%s%s", d.dataset(vol, false), entry))
 			if err != nil {
@@ -413,6 +387,118 @@ func (d *zfs) CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData
 	return postHook, revertHook, nil
 }
 
+// RefreshVolumeFromBackup applies an incremental backup onto an existing volume.
+// For optimized backups, the volume must have the base kept when the parent backup was imported and no snapshot
+// more recent than it.
+func (d *zfs) RefreshVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) error {
+	// Handle the non-optimized tarballs through the generic unpacker.
+	if !*srcBackup.OptimizedStorage {
+		return genericVFSBackupRefresh(d, vol, srcData, op)
+	}
+
+	// Refresh VM config volumes first.
+	if vol.IsVMBlock() {
+		fsVol := vol.NewVMBlockFilesystemVolume()
+		err := d.RefreshVolumeFromBackup(fsVol, srcBackup, srcData, op)
+		if err != nil {
+			return err
+		}
+	}
+
+	dataset := d.dataset(vol, false)
+	parentBase := fmt.Sprintf("@backup-%s", srcBackup.Parent)
+
+	entries, err := d.getDatasets(dataset)
+	if err != nil {
+		return err
+	}
+
+	// The incremental stream can only be received on top of the most recent snapshot.
+	lastSnapshot := ""
+	for _, entry := range entries {
+		if strings.HasPrefix(entry, "@") {
+			lastSnapshot = entry
+		}
+	}
+
+	if !shared.StringInSlice(parentBase, entries) {
+		return fmt.Errorf("Volume %q doesn't have the base of the parent backup", vol.name)
+	}
+
+	if lastSnapshot != parentBase {
+		return fmt.Errorf("Volume %q has snapshots more recent than the base of the parent backup", vol.name)
+	}
+
+	// Find the compression algorithm used for backup source data.
+	srcData.Seek(0, 0)
+	_, _, unpacker, err := shared.DetectCompressionFile(srcData)
+	if err != nil {
+		return err
+	}
+
+	// Receive the changes, discarding any made to the volume since the parent backup was imported.
+	err = d.unpackBackupVolume(srcData, unpacker, d.backupVolumeFile(vol), dataset)
+	if err != nil {
+		return err
+	}
+
+	// Only the base of the new backup is needed from now on.
+	_, err = shared.RunCommand("zfs", "destroy", fmt.Sprintf("%s%s", dataset, parentBase))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// backupVolumeFile returns the name of the file holding the main volume in an optimized backup tarball.
+func (d *zfs) backupVolumeFile(vol Volume) string {
+	fileName := "container.bin"
+	if vol.volType == VolumeTypeVM {
+		if vol.contentType == ContentTypeFS {
+			fileName = "virtual-machine-config.bin"
+		} else {
+			fileName = "virtual-machine.bin"
+		}
+	}
+
+	return fmt.Sprintf("backup/%s", fileName)
+}
+
+// unpackBackupVolume receives the optimized volume file of a backup tarball into the target dataset.
+func (d *zfs) unpackBackupVolume(r io.ReadSeeker, unpacker []string, srcFile string, target string) error {
+	d.Logger().Debug("Unpacking optimized volume", log.Ctx{"source": srcFile, "target": target})
+	tr, cancelFunc, err := shared.CompressedTarReader(context.Background(), r, unpacker)
+	if err != nil {
+		return err
+	}
+	defer cancelFunc()
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break // End of archive
+		}
+		if err != nil {
+			return err
+		}
+
+		if hdr.Name == srcFile {
+			// Extract the backup.
+			err = shared.RunCommandWithFds(tr, nil, "zfs", "receive", "-F", target)
+
+			if err != nil {
+				return err
+			}
+
+			cancelFunc()
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Could not find %q", srcFile)
+}
+
 // CreateVolumeFromCopy provides same-pool volume copying functionality.
 func (d *zfs) CreateVolumeFromCopy(vol Volume, srcVol Volume, copySnapshots bool, op *operations.Operation) error {
 	// Revert handling
@@ -704,6 +790,14 @@ func (d *zfs) RefreshVolume(vol Volume, srcVol Volume, srcSnapshots []Volume, op
 // DeleteVolume deletes a volume of the storage device. If any snapshots of the volume remain then
 // this function will return an error.
 func (d *zfs) DeleteVolume(vol Volume, op *operations.Operation) error {
+	// Close the LUKS device (if opened) as it keeps the zvol busy.
+	if vol.contentType == ContentTypeBlock {
+		_, err := d.luksClose(vol)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Check that we have a dataset to delete.
 	if d.checkDataset(d.dataset(vol, false)) {
 		// Handle clones.
@@ -793,6 +887,72 @@ func (d *zfs) HasVolume(vol Volume) bool {
 	return d.checkDataset(d.dataset(vol, false))
 }
 
+// ListVolumes returns a list of LXD volumes in storage pool.
+func (d *zfs) ListVolumes() ([]Volume, error) {
+	poolName := d.config["zfs.pool_name"]
+
+	// Get the filesystem and volume datasets but not the snapshots.
+	out, err := shared.RunCommand("zfs", "list", "-H", "-p", "-r", "-t", "filesystem,volume", "-o", "name,type,volsize", poolName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to list datasets of %q", poolName)
+	}
+
+	vols := []Volume{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+
+		// Only consider datasets at the <pool>/<type>/<volume> level.
+		parts := strings.Split(strings.TrimPrefix(fields[0], poolName+"/"), "/")
+		if len(parts) != 2 || strings.HasSuffix(parts[1], tmpVolSuffix) {
+			continue
+		}
+
+		volType := VolumeType(parts[0])
+		volName := parts[1]
+		isBlock := fields[1] == "volume"
+
+		switch volType {
+		case VolumeTypeContainer:
+			if isBlock {
+				continue
+			}
+
+		case VolumeTypeVM:
+			// Only return the block volume of virtual machines, its filesystem volume is implied.
+			if !isBlock {
+				continue
+			}
+
+			volName = strings.TrimSuffix(volName, ".block")
+
+		case VolumeTypeCustom:
+			// Custom block volumes don't use the block suffix.
+
+		default:
+			// Images and deleted volumes are ignored.
+			continue
+		}
+
+		contentType := ContentTypeFS
+		volConfig := map[string]string{}
+		if isBlock {
+			contentType = ContentTypeBlock
+
+			_, err := strconv.ParseInt(fields[2], 10, 64)
+			if err == nil {
+				volConfig["size"] = fields[2]
+			}
+		}
+
+		vols = append(vols, NewVolume(d, d.name, volType, contentType, volName, volConfig, d.config))
+	}
+
+	return vols, nil
+}
+
 // ValidateVolume validates the supplied volume config.
 func (d *zfs) ValidateVolume(vol Volume, removeUnknownKeys bool) error {
 	rules := map[string]func(value string) error{
@@ -800,11 +960,21 @@ func (d *zfs) ValidateVolume(vol Volume, removeUnknownKeys bool) error {
 		"zfs.use_refquota":     validate.Optional(validate.IsBool),
 	}
 
+	// Only volumes backed by zvols can be encrypted.
+	if vol.contentType == ContentTypeBlock {
+		rules["block.encryption"] = validateBlockEncryption
+	}
+
 	return d.validateVolume(vol, rules, removeUnknownKeys)
 }
 
 // UpdateVolume applies config changes to the volume.
 func (d *zfs) UpdateVolume(vol Volume, changedConfig map[string]string) error {
+	_, ok := changedConfig["block.encryption"]
+	if ok {
+		return fmt.Errorf("block.encryption cannot be changed")
+	}
+
 	for k, v := range changedConfig {
 		if k == "size" {
 			return d.SetVolumeQuota(vol, v, nil)
@@ -926,12 +1096,24 @@ func (d *zfs) SetVolumeQuota(vol Volume, size string, op *operations.Operation)
 			return errors.Wrap(ErrCannotBeShrunk, "You cannot shrink block volumes")
 		}
 
+		if sizeBytes < oldVolSizeBytes && vol.IsEncrypted() {
+			return errors.Wrap(ErrCannotBeShrunk, "You cannot shrink encrypted volumes")
+		}
+
 		err = d.setDatasetProperties(d.dataset(vol, false), fmt.Sprintf("volsize=%d", sizeBytes))
 		if err != nil {
 			return err
 		}
 
 		err = vol.MountTask(func(mountPath string, op *operations.Operation) error {
+			// Grow the LUKS device if opened.
+			if vol.IsEncrypted() {
+				err := d.luksResize(vol)
+				if err != nil {
+					return err
+				}
+			}
+
 			devPath, err := d.GetVolumeDiskPath(vol)
 			if err != nil {
 				return err
@@ -976,6 +1158,21 @@ func (d *zfs) SetVolumeQuota(vol Volume, size string, op *operations.Operation)
 
 // GetVolumeDiskPath returns the location of a root disk block device.
 func (d *zfs) GetVolumeDiskPath(vol Volume) (string, error) {
+	zvolDevPath, err := d.getVolumeDiskPath(vol)
+	if err != nil {
+		return "", err
+	}
+
+	devPath, _, err := d.luksDevPath(vol, zvolDevPath)
+	if err != nil {
+		return "", err
+	}
+
+	return devPath, nil
+}
+
+// getVolumeDiskPath returns the location of the zvol device of a volume.
+func (d *zfs) getVolumeDiskPath(vol Volume) (string, error) {
 	// Shortcut for udev.
 	if tryExists(filepath.Join("/dev/zvol", d.dataset(vol, false))) {
 		return filepath.Join("/dev/zvol", d.dataset(vol, false)), nil
@@ -1106,7 +1303,12 @@ func (d *zfs) UnmountVolume(vol Volume, op *operations.Operation) (bool, error)
 
 	// For block devices, we make them disappear.
 	if vol.contentType == ContentTypeBlock {
-		err := d.setDatasetProperties(dataset, "volmode=none")
+		_, err := d.luksClose(vol)
+		if err != nil {
+			return false, err
+		}
+
+		err = d.setDatasetProperties(dataset, "volmode=none")
 		if err != nil {
 			return false, err
 		}
@@ -1285,7 +1487,7 @@ func (d *zfs) MigrateVolume(vol Volume, conn io.ReadWriteCloser, volSrcArgs *mig
 }
 
 // BackupVolume creates an exported version of a volume.
-func (d *zfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error {
+func (d *zfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, args BackupArgs, op *operations.Operation) error {
 	// Handle the non-optimized tarballs through the generic packer.
 	if !optimized {
 		// For block volumes that are exporting snapshots, we need to activate parent volume first so that
@@ -1352,13 +1554,13 @@ func (d *zfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWrit
 			}(srcSnapshot, vol.MountPath())
 		}
 
-		return genericVFSBackupVolume(d, vol, tarWriter, snapshots, op)
+		return genericVFSBackupVolume(d, vol, tarWriter, snapshots, args, op)
 	}
 
 	// Backup VM config volumes first.
 	if vol.IsVMBlock() {
 		fsVol := vol.NewVMBlockFilesystemVolume()
-		err := d.BackupVolume(fsVol, tarWriter, optimized, snapshots, op)
+		err := d.BackupVolume(fsVol, tarWriter, optimized, snapshots, args, op)
 		if err != nil {
 			return err
 		}
@@ -1444,25 +1646,63 @@ func (d *zfs) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWrit
 		}
 	}
 
-	// Create a temporary read-only snapshot.
-	srcSnapshot := fmt.Sprintf("%s@backup-%s", d.dataset(vol, false), uuid.NewRandom().String())
+	// Incremental backups only contain the changes since the base kept by the parent backup.
+	if args.ParentUUID != "" {
+		finalParent = fmt.Sprintf("%s@backup-%s", d.dataset(vol, false), args.ParentUUID)
+		if !d.checkDataset(finalParent) {
+			return fmt.Errorf("The base of the parent backup doesn't exist anymore")
+		}
+	}
+
+	// Create a read-only snapshot, which is kept as the base for subsequent incremental backups if the
+	// backup has a UUID and is temporary otherwise.
+	revert := revert.New()
+	defer revert.Fail()
+
+	backupUUID := args.UUID
+	if backupUUID == "" {
+		backupUUID = uuid.NewRandom().String()
+	}
+
+	srcSnapshot := fmt.Sprintf("%s@backup-%s", d.dataset(vol, false), backupUUID)
 	_, err := shared.RunCommand("zfs", "snapshot", srcSnapshot)
 	if err != nil {
 		return err
 	}
-	defer shared.RunCommand("zfs", "destroy", srcSnapshot)
+
+	if args.UUID == "" {
+		defer shared.RunCommand("zfs", "destroy", srcSnapshot)
+	} else {
+		revert.Add(func() { shared.RunCommand("zfs", "destroy", srcSnapshot) })
+	}
 
 	// Dump the container to a file.
-	fileName := "container.bin"
-	if vol.volType == VolumeTypeVM {
-		if vol.contentType == ContentTypeFS {
-			fileName = "virtual-machine-config.bin"
-		} else {
-			fileName = "virtual-machine.bin"
+	err = sendToFile(srcSnapshot, finalParent, d.backupVolumeFile(vol))
+	if err != nil {
+		return err
+	}
+
+	revert.Success()
+	return nil
+}
+
+// DeleteVolumeBackupBase removes the base kept for incremental backups by the backup with the given UUID.
+func (d *zfs) DeleteVolumeBackupBase(vol Volume, uuid string, op *operations.Operation) error {
+	// Delete the base of VM config volumes too.
+	if vol.IsVMBlock() {
+		fsVol := vol.NewVMBlockFilesystemVolume()
+		err := d.DeleteVolumeBackupBase(fsVol, uuid, op)
+		if err != nil {
+			return err
 		}
 	}
 
-	err = sendToFile(srcSnapshot, finalParent, fmt.Sprintf("backup/%s", fileName))
+	base := fmt.Sprintf("%s@backup-%s", d.dataset(vol, false), uuid)
+	if !d.checkDataset(base) {
+		return nil
+	}
+
+	_, err := shared.RunCommand("zfs", "destroy", base)
 	if err != nil {
 		return err
 	}
@@ -1667,7 +1907,12 @@ func (d *zfs) UnmountVolumeSnapshot(vol Volume, op *operations.Operation) (bool,
 		parentVol := NewVolume(d, d.Name(), vol.volType, vol.contentType, parent, vol.config, vol.poolConfig)
 		parentDataset := d.dataset(parentVol, false)
 
-		err := d.setDatasetProperties(parentDataset, "snapdev=hidden")
+		_, err := d.luksClose(vol)
+		if err != nil {
+			return false, err
+		}
+
+		err = d.setDatasetProperties(parentDataset, "snapdev=hidden")
 		if err != nil {
 			return false, err
 		}
@@ -1722,6 +1967,7 @@ func (d *zfs) RestoreVolume(vol Volume, snapshotName string, op *operations.Oper
 	// Check if more recent snapshots exist.
 	idx := -1
 	snapshots := []string{}
+	backupBases := []string{}
 	for i, entry := range entries {
 		if entry == fmt.Sprintf("@snapshot-%s", snapshotName) {
 			// Located the current snapshot.
@@ -1738,6 +1984,12 @@ func (d *zfs) RestoreVolume(vol Volume, snapshotName string, op *operations.Oper
 			continue
 		}
 
+		if strings.HasPrefix(entry, "@backup-") {
+			// Located the base of an incremental backup, which the restore invalidates anyway.
+			backupBases = append(backupBases, entry)
+			continue
+		}
+
 		if strings.HasPrefix(entry, "@") {
 			// Located an internal snapshot.
 			return fmt.Errorf("Snapshot '%s' cannot be restored due to subsequent internal snapshot(s) (from a copy)", snapshotName)
@@ -1756,6 +2008,14 @@ func (d *zfs) RestoreVolume(vol Volume, snapshotName string, op *operations.Oper
 		return err
 	}
 
+	// Remove the bases of incremental backups more recent than the snapshot.
+	for _, entry := range backupBases {
+		_, err := shared.RunCommand("zfs", "destroy", fmt.Sprintf("%s%s", d.dataset(vol, false), entry))
+		if err != nil {
+			return err
+		}
+	}
+
 	// Restore the snapshot.
 	_, err = shared.RunCommand("zfs", "rollback", d.dataset(snapVol, false))
 	if err != nil {
@@ -165,7 +165,17 @@ func (d *mock) MigrateVolume(vol Volume, conn io.ReadWriteCloser, volSrcArgs *mi
 
 // BackupVolume copies a volume (and optionally its snapshots) to a specified target path.
 // This driver does not support optimized backups.
-func (d *mock) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error {
+func (d *mock) BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, args BackupArgs, op *operations.Operation) error {
+	return nil
+}
+
+// RefreshVolumeFromBackup applies an incremental backup onto an existing volume.
+func (d *mock) RefreshVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) error {
+	return nil
+}
+
+// DeleteVolumeBackupBase removes the base kept for incremental backups by a backup.
+func (d *mock) DeleteVolumeBackupBase(vol Volume, uuid string, op *operations.Operation) error {
 	return nil
 }
 
@@ -195,6 +205,11 @@ func (d *mock) VolumeSnapshots(vol Volume, op *operations.Operation) ([]string,
 	return nil, nil
 }
 
+// ListVolumes returns a list of LXD volumes in storage pool.
+func (d *mock) ListVolumes() ([]Volume, error) {
+	return nil, nil
+}
+
 // RestoreVolume restores a volume from a snapshot.
 func (d *mock) RestoreVolume(vol Volume, snapshotName string, op *operations.Operation) error {
 	return nil
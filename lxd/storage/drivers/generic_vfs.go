@@ -1,6 +1,7 @@
 package drivers
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -8,9 +9,11 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
 
 	"github.com/lxc/lxd/lxd/migration"
 	"github.com/lxc/lxd/lxd/operations"
@@ -159,6 +162,10 @@ func genericVFSMigrateVolume(d Driver, s *state.State, vol Volume, conn io.ReadW
 
 		if strings.HasPrefix(diskPath, vol.MountPath()) {
 			rsyncArgs = []string{"--exclude", filepath.Base(diskPath)}
+		} else if vol.IsEncrypted() {
+			// The disk path is the opened LUKS device, so exclude the block file backing it (if any).
+			rootBlockPath, _ := genericVFSGetVolumeDiskPath(vol)
+			rsyncArgs = []string{"--exclude", filepath.Base(rootBlockPath)}
 		}
 	} else if vol.contentType == ContentTypeBlock && volSrcArgs.MigrationType.FSType != migration.MigrationFSType_BLOCK_AND_RSYNC || vol.contentType == ContentTypeFS && volSrcArgs.MigrationType.FSType != migration.MigrationFSType_RSYNC {
 		return ErrNotSupported
@@ -439,6 +446,46 @@ func genericVFSHasVolume(vol Volume) bool {
 	return false
 }
 
+// genericVFSListVolumes is a generic ListVolumes implementation for VFS-only drivers.
+func genericVFSListVolumes(d Driver) ([]Volume, error) {
+	vols := []Volume{}
+	poolMountPath := GetPoolMountPath(d.Name())
+
+	for _, volType := range d.Info().VolumeTypes {
+		// Images are not tracked on recovery, they get downloaded again when needed.
+		// Buckets aren't recovered as their access keys only exist in the database.
+		if volType == VolumeTypeImage || volType == VolumeTypeBucket {
+			continue
+		}
+
+		volTypePath := filepath.Join(poolMountPath, string(volType))
+		ents, err := ioutil.ReadDir(volTypePath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return nil, errors.Wrapf(err, "Failed to list directory %q", volTypePath)
+		}
+
+		for _, ent := range ents {
+			volName := ent.Name()
+			if !ent.IsDir() || strings.HasSuffix(volName, tmpVolSuffix) {
+				continue
+			}
+
+			contentType := ContentTypeFS
+			if volType == VolumeTypeVM || shared.PathExists(filepath.Join(volTypePath, volName, "root.img")) {
+				contentType = ContentTypeBlock
+			}
+
+			vols = append(vols, NewVolume(d, d.Name(), volType, contentType, volName, map[string]string{}, d.Config()))
+		}
+	}
+
+	return vols, nil
+}
+
 // genericVFSGetVolumeDiskPath is a generic GetVolumeDiskPath implementation for VFS-only drivers.
 func genericVFSGetVolumeDiskPath(vol Volume) (string, error) {
 	if vol.contentType != ContentTypeBlock {
@@ -449,17 +496,33 @@ func genericVFSGetVolumeDiskPath(vol Volume) (string, error) {
 }
 
 // genericVFSBackupVolume is a generic BackupVolume implementation for VFS-only drivers.
-func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.InstanceTarWriter, snapshots bool, op *operations.Operation) error {
+// The manifest of filesystem volumes is added to the backup so that it can be used as the parent of an incremental
+// backup, which only contains the entries of the volume that were added or changed since its parent.
+func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.InstanceTarWriter, snapshots bool, args BackupArgs, op *operations.Operation) error {
+	var parentManifest map[string]backupManifestEntry
+	if args.ParentData != nil {
+		if vol.contentType != ContentTypeFS {
+			return fmt.Errorf("Non-optimized incremental backups are only supported for filesystem volumes")
+		}
+
+		var err error
+		parentManifest, err = genericVFSReadBackupManifest(args.ParentData)
+		if err != nil {
+			return errors.Wrapf(err, "Failed reading manifest of parent backup")
+		}
+	}
+
 	// Define a function that can copy a volume into the backup target location.
-	backupVolume := func(v Volume, prefix string) error {
+	// If include is not nil, only the entries of a filesystem volume whose path it contains are copied.
+	backupVolume := func(v Volume, prefix string, include map[string]bool) error {
 		return v.MountTask(func(mountPath string, op *operations.Operation) error {
 			// Reset hard link cache as we are copying a new volume (instance or snapshot).
 			tarWriter.ResetHardLinkMap()
 
-			if v.IsVMBlock() {
+			if v.contentType == ContentTypeBlock {
 				blockPath, err := d.GetVolumeDiskPath(v)
 				if err != nil {
-					return errors.Wrapf(err, "Error getting VM block volume disk path")
+					return errors.Wrapf(err, "Error getting block volume disk path")
 				}
 
 				var blockDiskSize int64
@@ -471,37 +534,44 @@ func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.Inst
 					return errors.Wrapf(err, "Error getting block device size %q", blockPath)
 				}
 
-				if !shared.IsBlockdevPath(blockPath) {
-					// Exclude the VM root disk path from the config volume backup part.
-					// We will read it as a block device later instead.
-					exclude = append(exclude, blockPath)
-				}
-
-				d.Logger().Debug("Copying virtual machine config volume", log.Ctx{"sourcePath": mountPath, "prefix": prefix})
-				err = filepath.Walk(mountPath, func(srcPath string, fi os.FileInfo, err error) error {
-					if err != nil {
-						return err
+				// Custom block volumes don't have a config volume to copy.
+				if v.IsVMBlock() {
+					if !shared.IsBlockdevPath(blockPath) {
+						// Exclude the VM root disk path from the config volume backup part.
+						// We will read it as a block device later instead.
+						exclude = append(exclude, blockPath)
+					} else if v.IsEncrypted() {
+						// The disk path is the opened LUKS device, so exclude the block file backing it (if any).
+						rootBlockPath, _ := genericVFSGetVolumeDiskPath(v)
+						exclude = append(exclude, rootBlockPath)
 					}
 
-					// Skip any exluded files.
-					if shared.StringInSlice(srcPath, exclude) {
-						return nil
-					}
+					d.Logger().Debug("Copying virtual machine config volume", log.Ctx{"sourcePath": mountPath, "prefix": prefix})
+					err = filepath.Walk(mountPath, func(srcPath string, fi os.FileInfo, err error) error {
+						if err != nil {
+							return err
+						}
 
-					name := filepath.Join(prefix, strings.TrimPrefix(srcPath, mountPath))
-					err = tarWriter.WriteFile(name, srcPath, fi, false)
+						// Skip any exluded files.
+						if shared.StringInSlice(srcPath, exclude) {
+							return nil
+						}
+
+						name := filepath.Join(prefix, strings.TrimPrefix(srcPath, mountPath))
+						err = tarWriter.WriteFile(name, srcPath, fi, false)
+						if err != nil {
+							return errors.Wrapf(err, "Error adding %q as %q to tarball", srcPath, name)
+						}
+
+						return nil
+					})
 					if err != nil {
-						return errors.Wrapf(err, "Error adding %q as %q to tarball", srcPath, name)
+						return err
 					}
-
-					return nil
-				})
-				if err != nil {
-					return err
 				}
 
 				name := fmt.Sprintf("%s.img", prefix)
-				d.Logger().Debug("Copying virtual machine block volume", log.Ctx{"sourcePath": blockPath, "file": name, "size": blockDiskSize})
+				d.Logger().Debug("Copying block volume", log.Ctx{"sourcePath": blockPath, "file": name, "size": blockDiskSize})
 				from, err := os.Open(blockPath)
 				if err != nil {
 					return errors.Wrapf(err, "Error opening file for reading %q", blockPath)
@@ -531,6 +601,10 @@ func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.Inst
 						return errors.Wrapf(err, "Error walking file during export: %q", srcPath)
 					}
 
+					if include != nil && !include[filepath.Join("/", strings.TrimPrefix(srcPath, mountPath))] {
+						return nil
+					}
+
 					name := filepath.Join(prefix, strings.TrimPrefix(srcPath, mountPath))
 
 					// Write the file to the tarball with ignoreGrowth enabled so that if the
@@ -549,6 +623,36 @@ func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.Inst
 		}, op)
 	}
 
+	// Add the manifest of the main volume ahead of its snapshots, so that it is quick to find when the backup is
+	// later used as a parent.
+	var manifest map[string]backupManifestEntry
+	if vol.contentType == ContentTypeFS {
+		err := vol.MountTask(func(mountPath string, op *operations.Operation) error {
+			var err error
+			manifest, err = genericVFSBackupManifest(mountPath)
+			if err != nil {
+				return errors.Wrapf(err, "Failed generating backup manifest")
+			}
+
+			manifestData, err := yaml.Marshal(manifest)
+			if err != nil {
+				return err
+			}
+
+			fi := instancewriter.FileInfo{
+				FileName:    genericVFSBackupManifestFile,
+				FileSize:    int64(len(manifestData)),
+				FileMode:    0644,
+				FileModTime: time.Now(),
+			}
+
+			return tarWriter.WriteFileFromReader(bytes.NewReader(manifestData), &fi)
+		}, op)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Handle snapshots.
 	if snapshots {
 		snapshotsPrefix := "backup/snapshots"
@@ -565,27 +669,206 @@ func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.Inst
 		for _, snapshot := range snapshots {
 			_, snapName, _ := shared.InstanceGetParentAndSnapshotName(snapshot.Name())
 			prefix := filepath.Join(snapshotsPrefix, snapName)
-			err := backupVolume(snapshot, prefix)
+			err := backupVolume(snapshot, prefix, nil)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
+	// Only include the entries that were added or changed since the parent backup for incremental backups.
+	var include map[string]bool
+	if parentManifest != nil {
+		include = genericVFSBackupManifestChanges(parentManifest, manifest)
+
+		// Always include the root of the volume so that the unpacker finds it.
+		include["/"] = true
+	}
+
 	// Copy the main volume itself.
 	prefix := "backup/container"
 	if vol.IsVMBlock() {
 		prefix = "backup/virtual-machine"
 	}
 
-	err := backupVolume(vol, prefix)
+	err := backupVolume(vol, prefix, include)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// genericVFSBackupManifestFile is the path of the manifest of the main volume in non-optimized backups.
+const genericVFSBackupManifestFile = "backup/manifest.yaml"
+
+// backupManifestEntry describes an entry of a filesystem volume in the manifest of a non-optimized backup.
+type backupManifestEntry struct {
+	Mode  os.FileMode `yaml:"mode"`
+	Size  int64       `yaml:"size"`
+	Ctime int64       `yaml:"ctime"`
+}
+
+// genericVFSBackupManifest returns the manifest of the filesystem volume mounted at mountPath, keyed by the path
+// of each entry relative to the volume.
+func genericVFSBackupManifest(mountPath string) (map[string]backupManifestEntry, error) {
+	manifest := map[string]backupManifestEntry{}
+
+	err := filepath.Walk(mountPath, func(srcPath string, fi os.FileInfo, err error) error {
+		if err != nil {
+			// Files vanishing during export are skipped by the backup too.
+			if os.IsNotExist(err) {
+				return nil
+			}
+
+			return err
+		}
+
+		entry := backupManifestEntry{
+			Mode: fi.Mode(),
+			Size: fi.Size(),
+		}
+
+		// Any change to the content or metadata of an entry updates its ctime.
+		stat, ok := fi.Sys().(*syscall.Stat_t)
+		if ok {
+			entry.Ctime = stat.Ctim.Nano()
+		}
+
+		manifest[filepath.Join("/", strings.TrimPrefix(srcPath, mountPath))] = entry
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// genericVFSBackupManifestChanges returns the paths of the entries of manifest that were added or changed since
+// parentManifest.
+func genericVFSBackupManifestChanges(parentManifest map[string]backupManifestEntry, manifest map[string]backupManifestEntry) map[string]bool {
+	changes := map[string]bool{}
+	for path, entry := range manifest {
+		parentEntry, found := parentManifest[path]
+		if !found || parentEntry != entry {
+			changes[path] = true
+		}
+	}
+
+	return changes
+}
+
+// genericVFSWriteBackupManifest records the manifest of a filesystem volume mounted at mountPath after it was
+// restored from a non-optimized backup, so that changes made to the volume since then can be detected.
+func genericVFSWriteBackupManifest(d Driver, vol Volume, mountPath string) error {
+	manifest, err := genericVFSBackupManifest(mountPath)
+	if err != nil {
+		return errors.Wrapf(err, "Failed generating backup manifest")
+	}
+
+	manifestData, err := yaml.Marshal(manifest)
 	if err != nil {
 		return err
 	}
 
+	manifestPath := GetVolumeBackupManifestPath(d.Name(), vol.volType, vol.name)
+	err = os.MkdirAll(filepath.Dir(manifestPath), 0700)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to create directory %q", filepath.Dir(manifestPath))
+	}
+
+	err = ioutil.WriteFile(manifestPath, manifestData, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "Failed writing backup manifest %q", manifestPath)
+	}
+
+	return nil
+}
+
+// genericVFSCheckBackupManifest checks that the filesystem volume mounted at mountPath wasn't changed since it
+// was last restored from a non-optimized backup, as applying an incremental backup would otherwise keep a mix
+// of the changes made to the volume and of the ones contained in the backup.
+func genericVFSCheckBackupManifest(d Driver, vol Volume, mountPath string) error {
+	manifestPath := GetVolumeBackupManifestPath(d.Name(), vol.volType, vol.name)
+	manifestData, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("Volume %q has no manifest of the backup it was restored from", vol.name)
+		}
+
+		return errors.Wrapf(err, "Failed reading backup manifest %q", manifestPath)
+	}
+
+	restoredManifest := map[string]backupManifestEntry{}
+	err = yaml.Unmarshal(manifestData, &restoredManifest)
+	if err != nil {
+		return errors.Wrapf(err, "Failed parsing backup manifest %q", manifestPath)
+	}
+
+	manifest, err := genericVFSBackupManifest(mountPath)
+	if err != nil {
+		return errors.Wrapf(err, "Failed generating backup manifest")
+	}
+
+	// Comparing the manifests both ways finds the added, changed and removed entries.
+	changes := genericVFSBackupManifestChanges(restoredManifest, manifest)
+	for path := range genericVFSBackupManifestChanges(manifest, restoredManifest) {
+		changes[path] = true
+	}
+
+	// Ignore the root of the volume, whose permissions are reset when mounting it, and the backup file of
+	// instances, which LXD updates itself.
+	delete(changes, "/")
+	if vol.volType != VolumeTypeCustom {
+		delete(changes, "/backup.yaml")
+	}
+
+	if len(changes) > 0 {
+		return fmt.Errorf("Volume %q was modified since it was restored from the parent backup, restore the full backup instead", vol.name)
+	}
+
 	return nil
 }
 
+// genericVFSReadBackupManifest reads the manifest of the main volume from a non-optimized backup tarball.
+func genericVFSReadBackupManifest(srcData io.ReadSeeker) (map[string]backupManifestEntry, error) {
+	srcData.Seek(0, 0)
+	_, _, unpacker, err := shared.DetectCompressionFile(srcData)
+	if err != nil {
+		return nil, err
+	}
+
+	tr, cancelFunc, err := shared.CompressedTarReader(context.Background(), srcData, unpacker)
+	if err != nil {
+		return nil, err
+	}
+	defer cancelFunc()
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break // End of archive
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr.Name == genericVFSBackupManifestFile {
+			manifest := map[string]backupManifestEntry{}
+			err = yaml.NewDecoder(tr).Decode(&manifest)
+			if err != nil {
+				return nil, err
+			}
+
+			return manifest, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Backup is missing %q", genericVFSBackupManifestFile)
+}
+
 // genericVFSBackupUnpack unpacks a non-optimized backup tarball through a storage driver.
 // Returns a post hook function that should be called once the database entries for the restored backup have been
 // created and a revert function that can be used to undo the actions this function performs should something
@@ -593,43 +876,46 @@ func genericVFSBackupVolume(d Driver, vol Volume, tarWriter *instancewriter.Inst
 func genericVFSBackupUnpack(d Driver, vol Volume, snapshots []string, srcData io.ReadSeeker, op *operations.Operation) (func(vol Volume) error, func(), error) {
 	// Define function to unpack a volume from a backup tarball file.
 	unpackVolume := func(r io.ReadSeeker, tarArgs []string, unpacker []string, srcPrefix string, mountPath string) error {
-		volTypeName := "container"
-		if vol.IsVMBlock() {
-			volTypeName = "virtual machine"
-		}
-
-		// Clear the volume ready for unpack.
-		err := wipeDirectory(mountPath)
-		if err != nil {
-			return errors.Wrapf(err, "Error clearing volume before unpack")
-		}
+		// Custom block volumes only consist of their block file, which is overwritten below.
+		if !vol.IsCustomBlock() {
+			volTypeName := "container"
+			if vol.IsVMBlock() {
+				volTypeName = "virtual machine"
+			}
 
-		// Prepare tar arguments.
-		srcParts := strings.Split(srcPrefix, string(os.PathSeparator))
-		args := append(tarArgs, []string{
-			"-",
-			"--xattrs-include=*",
-			fmt.Sprintf("--strip-components=%d", len(srcParts)),
-			"-C", mountPath, srcPrefix,
-		}...)
+			// Clear the volume ready for unpack.
+			err := wipeDirectory(mountPath)
+			if err != nil {
+				return errors.Wrapf(err, "Error clearing volume before unpack")
+			}
 
-		// Extract filesystem volume.
-		d.Logger().Debug(fmt.Sprintf("Unpacking %s filesystem volume", volTypeName), log.Ctx{"source": srcPrefix, "target": mountPath})
-		srcData.Seek(0, 0)
-		err = shared.RunCommandWithFds(r, nil, "tar", args...)
-		if err != nil {
-			return errors.Wrapf(err, "Error starting unpack")
+			// Prepare tar arguments.
+			srcParts := strings.Split(srcPrefix, string(os.PathSeparator))
+			args := append(tarArgs, []string{
+				"-",
+				"--xattrs-include=*",
+				fmt.Sprintf("--strip-components=%d", len(srcParts)),
+				"-C", mountPath, srcPrefix,
+			}...)
+
+			// Extract filesystem volume.
+			d.Logger().Debug(fmt.Sprintf("Unpacking %s filesystem volume", volTypeName), log.Ctx{"source": srcPrefix, "target": mountPath})
+			srcData.Seek(0, 0)
+			err = shared.RunCommandWithFds(r, nil, "tar", args...)
+			if err != nil {
+				return errors.Wrapf(err, "Error starting unpack")
+			}
 		}
 
-		// Extract block file to block volume if VM.
-		if vol.IsVMBlock() {
+		// Extract block file to block volume if VM or custom block volume.
+		if vol.contentType == ContentTypeBlock {
 			targetPath, err := d.GetVolumeDiskPath(vol)
 			if err != nil {
 				return err
 			}
 
 			srcFile := fmt.Sprintf("%s.img", srcPrefix)
-			d.Logger().Debug("Unpacking virtual machine block volume", log.Ctx{"source": srcFile, "target": targetPath})
+			d.Logger().Debug("Unpacking block volume", log.Ctx{"source": srcFile, "target": targetPath})
 
 			tr, cancelFunc, err := shared.CompressedTarReader(context.Background(), r, unpacker)
 			if err != nil {
@@ -772,11 +1058,96 @@ func genericVFSBackupUnpack(d Driver, vol Volume, snapshots []string, srcData io
 		return nil, nil, err
 	}
 
+	// Record the manifest of filesystem volumes so that incremental backups can later be applied onto them.
+	if vol.contentType == ContentTypeFS {
+		err = genericVFSWriteBackupManifest(d, vol, mountPath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		revert.Add(func() { os.Remove(GetVolumeBackupManifestPath(d.Name(), vol.volType, vol.name)) })
+	}
+
 	revertExternal := revert.Clone() // Clone before calling revert.Success() so we can return the Fail func.
 	revert.Success()
 	return postHook, revertExternal.Fail, nil
 }
 
+// genericVFSBackupRefresh applies a non-optimized incremental backup tarball onto an existing filesystem volume
+// through a storage driver. The volume must not have been changed since it was restored from the parent backup.
+// The entries of the volume missing from the manifest of the backup are removed before the entries added or
+// changed since the parent backup are unpacked over the volume.
+func genericVFSBackupRefresh(d Driver, vol Volume, srcData io.ReadSeeker, op *operations.Operation) error {
+	if vol.contentType != ContentTypeFS {
+		return fmt.Errorf("Non-optimized incremental backups are only supported for filesystem volumes")
+	}
+
+	manifest, err := genericVFSReadBackupManifest(srcData)
+	if err != nil {
+		return err
+	}
+
+	// Find the compression algorithm used for backup source data.
+	srcData.Seek(0, 0)
+	tarArgs, _, _, err := shared.DetectCompressionFile(srcData)
+	if err != nil {
+		return err
+	}
+
+	return vol.MountTask(func(mountPath string, op *operations.Operation) error {
+		err := genericVFSCheckBackupManifest(d, vol, mountPath)
+		if err != nil {
+			return err
+		}
+
+		// Remove the entries that were deleted or replaced by an entry of another type since the parent backup.
+		err = filepath.Walk(mountPath, func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			entry, found := manifest[filepath.Join("/", strings.TrimPrefix(path, mountPath))]
+			if found && entry.Mode&os.ModeType == fi.Mode()&os.ModeType {
+				return nil
+			}
+
+			err = os.RemoveAll(path)
+			if err != nil {
+				return errors.Wrapf(err, "Failed removing %q", path)
+			}
+
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Prepare tar arguments.
+		srcPrefix := "backup/container"
+		srcParts := strings.Split(srcPrefix, string(os.PathSeparator))
+		args := append(tarArgs, []string{
+			"-",
+			"--xattrs-include=*",
+			fmt.Sprintf("--strip-components=%d", len(srcParts)),
+			"-C", mountPath, srcPrefix,
+		}...)
+
+		// Extract the added and changed entries over the volume.
+		d.Logger().Debug("Unpacking incremental filesystem volume", log.Ctx{"source": srcPrefix, "target": mountPath})
+		srcData.Seek(0, 0)
+		err = shared.RunCommandWithFds(srcData, nil, "tar", args...)
+		if err != nil {
+			return errors.Wrapf(err, "Error unpacking incremental backup")
+		}
+
+		return genericVFSWriteBackupManifest(d, vol, mountPath)
+	}, op)
+}
+
 // genericVFSCopyVolume copies a volume and its snapshots using a non-optimized method.
 // initVolume is run against the main volume (not the snapshots) and is often used for quota initialization.
 func genericVFSCopyVolume(d Driver, initVolume func(vol Volume) (func(), error), vol Volume, srcVol Volume, srcSnapshots []Volume, refresh bool, op *operations.Operation) error {
@@ -0,0 +1,114 @@
+package drivers
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+// Test genericVFSBackupManifest
+func TestGenericVFSBackupManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lxd-backup-manifest-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "etc"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "etc", "hostname"), []byte("c1\n"), 0644))
+	require.NoError(t, os.Symlink("etc/hostname", filepath.Join(dir, "hostname")))
+
+	manifest, err := genericVFSBackupManifest(dir)
+	require.NoError(t, err)
+
+	assert.Len(t, manifest, 4)
+	assert.True(t, manifest["/"].Mode.IsDir())
+	assert.True(t, manifest["/etc"].Mode.IsDir())
+	assert.True(t, manifest["/etc/hostname"].Mode.IsRegular())
+	assert.Equal(t, int64(3), manifest["/etc/hostname"].Size)
+	assert.NotZero(t, manifest["/etc/hostname"].Ctime)
+	assert.Equal(t, os.ModeSymlink, manifest["/hostname"].Mode&os.ModeType)
+
+	// Changing the content of an entry changes its manifest entry.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "etc", "hostname"), []byte("c2-renamed\n"), 0644))
+
+	newManifest, err := genericVFSBackupManifest(dir)
+	require.NoError(t, err)
+	assert.NotEqual(t, manifest["/etc/hostname"], newManifest["/etc/hostname"])
+	assert.Equal(t, manifest["/hostname"], newManifest["/hostname"])
+}
+
+// Test genericVFSReadBackupManifest
+func TestGenericVFSReadBackupManifest(t *testing.T) {
+	manifest := map[string]backupManifestEntry{
+		"/":    {Mode: os.ModeDir | 0755, Ctime: 1},
+		"/foo": {Mode: 0644, Size: 3, Ctime: 2},
+	}
+
+	manifestData, err := yaml.Marshal(manifest)
+	require.NoError(t, err)
+
+	// Write a tarball with the manifest following some other entry.
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"backup/index.yaml", []byte("name: c1\n")},
+		{genericVFSBackupManifestFile, manifestData},
+	}
+
+	for _, file := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: file.name, Mode: 0644, Size: int64(len(file.data))}))
+		_, err = tw.Write(file.data)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+
+	readManifest, err := genericVFSReadBackupManifest(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, manifest, readManifest)
+
+	// Tarballs without a manifest can't be used as the parent of an incremental backup.
+	buf.Reset()
+	tw = tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "backup/index.yaml", Mode: 0644, Size: 0}))
+	require.NoError(t, tw.Close())
+
+	_, err = genericVFSReadBackupManifest(bytes.NewReader(buf.Bytes()))
+	assert.Error(t, err)
+}
+
+// Test genericVFSBackupManifestChanges
+func TestGenericVFSBackupManifestChanges(t *testing.T) {
+	parentManifest := map[string]backupManifestEntry{
+		"/":        {Mode: os.ModeDir | 0755, Ctime: 1},
+		"/same":    {Mode: 0644, Size: 3, Ctime: 1},
+		"/changed": {Mode: 0644, Size: 3, Ctime: 1},
+		"/removed": {Mode: 0644, Size: 3, Ctime: 1},
+	}
+
+	manifest := map[string]backupManifestEntry{
+		"/":        {Mode: os.ModeDir | 0755, Ctime: 2},
+		"/same":    {Mode: 0644, Size: 3, Ctime: 1},
+		"/changed": {Mode: 0644, Size: 3, Ctime: 2},
+		"/added":   {Mode: 0644, Size: 5, Ctime: 2},
+	}
+
+	changes := genericVFSBackupManifestChanges(parentManifest, manifest)
+	assert.Equal(t, map[string]bool{"/": true, "/changed": true, "/added": true}, changes)
+
+	// Comparing the other way round finds the removed entries.
+	changes = genericVFSBackupManifestChanges(manifest, parentManifest)
+	assert.Equal(t, map[string]bool{"/": true, "/changed": true, "/removed": true}, changes)
+
+	// No changes against itself.
+	assert.Empty(t, genericVFSBackupManifestChanges(manifest, manifest))
+}
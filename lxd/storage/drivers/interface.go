@@ -16,7 +16,7 @@ import (
 type driver interface {
 	Driver
 
-	init(state *state.State, name string, config map[string]string, logger logger.Logger, volIDFunc func(volType VolumeType, volName string) (int64, error), commonRules *Validators)
+	init(state *state.State, name string, config map[string]string, logger logger.Logger, volIDFunc func(volType VolumeType, volName string) (int64, error), volKeyFunc func(volType VolumeType, volName string, create bool) (string, error), commonRules *Validators)
 	load() error
 }
 
@@ -78,12 +78,23 @@ type Driver interface {
 	VolumeSnapshots(vol Volume, op *operations.Operation) ([]string, error)
 	RestoreVolume(vol Volume, snapshotName string, op *operations.Operation) error
 
+	// ListVolumes returns the instance and custom volumes that exist on the storage device, without their
+	// snapshots. Virtual machines are only returned as their block volume.
+	ListVolumes() ([]Volume, error)
+
 	// Migration.
 	MigrationTypes(contentType ContentType, refresh bool) []migration.Type
 	MigrateVolume(vol Volume, conn io.ReadWriteCloser, volSrcArgs *migration.VolumeSourceArgs, op *operations.Operation) error
 	CreateVolumeFromMigration(vol Volume, conn io.ReadWriteCloser, volTargetArgs migration.VolumeTargetArgs, preFiller *VolumeFiller, op *operations.Operation) error
 
 	// Backup.
-	BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error
+	BackupVolume(vol Volume, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, args BackupArgs, op *operations.Operation) error
 	CreateVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) (func(vol Volume) error, func(), error)
+
+	// RefreshVolumeFromBackup applies an incremental backup onto an existing volume, which must be in the
+	// state of the parent backup.
+	RefreshVolumeFromBackup(vol Volume, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) error
+
+	// DeleteVolumeBackupBase removes the base kept for incremental backups by the backup with the given UUID.
+	DeleteVolumeBackupBase(vol Volume, uuid string, op *operations.Operation) error
 }
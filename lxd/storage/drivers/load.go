@@ -21,7 +21,7 @@ type Validators struct {
 }
 
 // Load returns a Driver for an existing low-level storage pool.
-func Load(state *state.State, driverName string, name string, config map[string]string, logger logger.Logger, volIDFunc func(volType VolumeType, volName string) (int64, error), commonRules *Validators) (Driver, error) {
+func Load(state *state.State, driverName string, name string, config map[string]string, logger logger.Logger, volIDFunc func(volType VolumeType, volName string) (int64, error), volKeyFunc func(volType VolumeType, volName string, create bool) (string, error), commonRules *Validators) (Driver, error) {
 	var driverFunc func() driver
 
 	// Locate the driver loader.
@@ -36,7 +36,7 @@ func Load(state *state.State, driverName string, name string, config map[string]
 	}
 
 	d := driverFunc()
-	d.init(state, name, config, logger, volIDFunc, commonRules)
+	d.init(state, name, config, logger, volIDFunc, volKeyFunc, commonRules)
 
 	err := d.load()
 	if err != nil {
@@ -51,7 +51,7 @@ func SupportedDrivers(s *state.State) []Info {
 	supportedDrivers := make([]Info, 0, len(drivers))
 
 	for driverName := range drivers {
-		driver, err := Load(s, driverName, "", nil, nil, nil, nil)
+		driver, err := Load(s, driverName, "", nil, nil, nil, nil, nil)
 		if err != nil {
 			continue
 		}
@@ -209,6 +209,16 @@ func fsUUID(path string) (string, error) {
 	return shared.RunCommand("blkid", "-s", "UUID", "-o", "value", path)
 }
 
+// fsProbe returns the filesystem type for the given block path.
+func fsProbe(path string) (string, error) {
+	fsType, err := shared.RunCommand("blkid", "-s", "TYPE", "-o", "value", path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(fsType), nil
+}
+
 // hasFilesystem checks if a given path is backed by a specified filesystem.
 func hasFilesystem(path string, fsType int64) bool {
 	fs := unix.Statfs_t{}
@@ -247,6 +257,12 @@ func GetVolumeSnapshotDir(poolName string, volType VolumeType, volName string) s
 	return shared.VarPath("storage-pools", poolName, fmt.Sprintf("%s-snapshots", string(volType)), parent)
 }
 
+// GetVolumeBackupManifestPath returns the path of the manifest recorded for a filesystem volume when it was last
+// restored from a non-optimized backup, which is used to detect changes made to the volume since then.
+func GetVolumeBackupManifestPath(poolName string, volType VolumeType, volName string) string {
+	return shared.VarPath("storage-pools", poolName, fmt.Sprintf("%s-backup-manifests", string(volType)), fmt.Sprintf("%s.yaml", volName))
+}
+
 // GetSnapshotVolumeName returns the full volume name for a parent volume and snapshot name.
 func GetSnapshotVolumeName(parentName, snapshotName string) string {
 	return fmt.Sprintf("%s%s%s", parentName, shared.SnapshotDelimiter, snapshotName)
@@ -0,0 +1,199 @@
+package drivers
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/shared"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/units"
+	"github.com/lxc/lxd/shared/validate"
+)
+
+// luksMapperPrefix is the prefix of the device mapper names used for the opened LUKS devices of volumes.
+const luksMapperPrefix = "lxd-luks-"
+
+// LUKSHeaderSize is the space reserved for the LUKS header at the start of encrypted volumes.
+const LUKSHeaderSize = 16 * 1024 * 1024
+
+// blockEncryptionTypes are the supported values of the block.encryption volume config key.
+var blockEncryptionTypes = []string{"luks"}
+
+// validateBlockEncryption validates the value of the block.encryption volume config key.
+func validateBlockEncryption(value string) error {
+	return validate.IsOneOf(value, blockEncryptionTypes)
+}
+
+// luksMapperName returns the device mapper name used for the opened LUKS device of the volume.
+// A hash is used as volume names can exceed the maximum device mapper name length.
+func luksMapperName(vol Volume) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%s/%s", vol.pool, vol.volType, vol.contentType, vol.name)))
+	return fmt.Sprintf("%s%x", luksMapperPrefix, hash[:8])
+}
+
+// luksMapperPath returns the path of the opened LUKS device of the volume.
+func luksMapperPath(vol Volume) string {
+	return filepath.Join("/dev/mapper", luksMapperName(vol))
+}
+
+// luksKey returns the encryption key of the volume. If create is true, a new key is generated if the volume
+// doesn't have one yet, otherwise an error is returned.
+func (d *common) luksKey(vol Volume, create bool) (string, error) {
+	if d.getVolKey == nil {
+		return "", fmt.Errorf("Encryption keys aren't available for volume %q", vol.name)
+	}
+
+	return d.getVolKey(vol.volType, vol.name, create)
+}
+
+// luksFormat sets up LUKS encryption on the device at devPath using the volume's encryption key.
+func (d *common) luksFormat(vol Volume, devPath string) error {
+	key, err := d.luksKey(vol, true)
+	if err != nil {
+		return err
+	}
+
+	err = shared.RunCommandWithFds(strings.NewReader(key), nil, "cryptsetup", "luksFormat", "--batch-mode", "--type", "luks2", "--offset", fmt.Sprintf("%d", LUKSHeaderSize/512), "--key-file", "-", devPath)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to format LUKS device %q", devPath)
+	}
+
+	d.logger.Debug("Formatted LUKS device", log.Ctx{"dev": devPath})
+	return nil
+}
+
+// luksOpen opens the LUKS device at devPath using the volume's encryption key and returns the path of the
+// opened device. Snapshots are opened read-only. Returns true if the device was opened by this call.
+func (d *common) luksOpen(vol Volume, devPath string) (string, bool, error) {
+	mapperPath := luksMapperPath(vol)
+	if shared.PathExists(mapperPath) {
+		return mapperPath, false, nil
+	}
+
+	key, err := d.luksKey(vol, false)
+	if err != nil {
+		return "", false, err
+	}
+
+	// Snapshots are read-only, unlike the temporary volumes created from them.
+	args := []string{"open", "--type", "luks", "--key-file", "-"}
+	if vol.IsSnapshot() && !strings.HasSuffix(vol.name, tmpVolSuffix) {
+		args = append(args, "--readonly")
+	}
+
+	args = append(args, devPath, luksMapperName(vol))
+
+	err = shared.RunCommandWithFds(strings.NewReader(key), nil, "cryptsetup", args...)
+	if err != nil {
+		return "", false, errors.Wrapf(err, "Failed to open LUKS device %q", devPath)
+	}
+
+	d.logger.Debug("Opened LUKS device", log.Ctx{"dev": devPath, "path": mapperPath})
+	return mapperPath, true, nil
+}
+
+// luksClose closes the opened LUKS device of the volume. Returns true if the device was closed.
+func (d *common) luksClose(vol Volume) (bool, error) {
+	mapperPath := luksMapperPath(vol)
+	if !shared.PathExists(mapperPath) {
+		return false, nil
+	}
+
+	_, err := shared.TryRunCommand("cryptsetup", "close", luksMapperName(vol))
+	if err != nil {
+		return false, errors.Wrapf(err, "Failed to close LUKS device %q", mapperPath)
+	}
+
+	d.logger.Debug("Closed LUKS device", log.Ctx{"path": mapperPath})
+	return true, nil
+}
+
+// luksResize grows the opened LUKS device of the volume to the size of its underlying device.
+// Does nothing if the device isn't opened as it then picks up the new size when opened.
+func (d *common) luksResize(vol Volume) error {
+	mapperPath := luksMapperPath(vol)
+	if !shared.PathExists(mapperPath) {
+		return nil
+	}
+
+	key, err := d.luksKey(vol, false)
+	if err != nil {
+		return err
+	}
+
+	err = shared.RunCommandWithFds(strings.NewReader(key), nil, "cryptsetup", "resize", "--key-file", "-", luksMapperName(vol))
+	if err != nil {
+		return errors.Wrapf(err, "Failed to resize LUKS device %q", mapperPath)
+	}
+
+	d.logger.Debug("Resized LUKS device", log.Ctx{"path": mapperPath})
+	return nil
+}
+
+// luksDevPath returns the path of the device holding the volume's data. For encrypted volumes, the LUKS device at
+// devPath is opened and the path of the opened device is returned, otherwise devPath is returned as is.
+// Returns true if the LUKS device was opened by this call.
+func (d *common) luksDevPath(vol Volume, devPath string) (string, bool, error) {
+	if !vol.IsEncrypted() {
+		return devPath, false, nil
+	}
+
+	return d.luksOpen(vol, devPath)
+}
+
+// luksResizeBlockFile grows the opened LUKS device of a volume stored in the block file at filePath to the size of
+// the file. The loop device set up when opening the file is refreshed first so it picks up the new size.
+func (d *common) luksResizeBlockFile(vol Volume, filePath string) error {
+	if !shared.PathExists(luksMapperPath(vol)) {
+		return nil
+	}
+
+	out, err := shared.RunCommand("losetup", "--associated", filePath)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to find loop devices of %q", filePath)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.SplitN(line, ":", 2)
+		if len(fields) < 2 {
+			continue
+		}
+
+		_, err = shared.RunCommand("losetup", "--set-capacity", fields[0])
+		if err != nil {
+			return errors.Wrapf(err, "Failed to refresh size of loop device %q", fields[0])
+		}
+	}
+
+	return d.luksResize(vol)
+}
+
+// luksSetupBlockFile creates the block file of a volume at filePath with the volume's size, sets up LUKS encryption
+// on it and returns the path of its opened LUKS device.
+func (d *common) luksSetupBlockFile(vol Volume, filePath string) (string, error) {
+	sizeBytes, err := units.ParseByteSizeString(vol.ConfigSize())
+	if err != nil {
+		return "", err
+	}
+
+	_, err = ensureVolumeBlockFile(filePath, sizeBytes)
+	if err != nil {
+		return "", err
+	}
+
+	err = d.luksFormat(vol, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	devPath, _, err := d.luksOpen(vol, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return devPath, nil
+}
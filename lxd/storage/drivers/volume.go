@@ -46,6 +46,9 @@ const VolumeTypeContainer = VolumeType("containers")
 // VolumeTypeVM represents a virtual-machine storage volume.
 const VolumeTypeVM = VolumeType("virtual-machines")
 
+// VolumeTypeBucket represents a storage bucket volume.
+const VolumeTypeBucket = VolumeType("buckets")
+
 // ContentType indicates the format of the volume.
 type ContentType string
 
@@ -62,6 +65,7 @@ var BaseDirectories = map[VolumeType][]string{
 	VolumeTypeCustom:    {"custom", "custom-snapshots"},
 	VolumeTypeImage:     {"images"},
 	VolumeTypeVM:        {"virtual-machines", "virtual-machines-snapshots"},
+	VolumeTypeBucket:    {"buckets"},
 }
 
 // Volume represents a storage volume, and provides functions to mount and unmount it.
@@ -316,6 +320,11 @@ func (v Volume) IsVMBlock() bool {
 	return (v.volType == VolumeTypeVM || v.volType == VolumeTypeImage) && v.contentType == ContentTypeBlock
 }
 
+// IsCustomBlock returns true if volume is a custom block volume.
+func (v Volume) IsCustomBlock() bool {
+	return v.volType == VolumeTypeCustom && v.contentType == ContentTypeBlock
+}
+
 // NewVMBlockFilesystemVolume returns a copy of the volume with the content type set to ContentTypeFS and the
 // config "size" property set to vmBlockFilesystemSize.
 func (v Volume) NewVMBlockFilesystemVolume() Volume {
@@ -352,6 +361,26 @@ func (v Volume) ConfigBlockFilesystem() string {
 	return DefaultFilesystem
 }
 
+// ConfigBlockEncryption returns the encryption to use for block volumes. Returns config value "block.encryption"
+// if defined in volume or pool's volume config, otherwise an empty string. Image volumes are never encrypted.
+func (v Volume) ConfigBlockEncryption() string {
+	if v.volType == VolumeTypeImage {
+		return ""
+	}
+
+	return v.ExpandedConfig("block.encryption")
+}
+
+// IsEncrypted indicates whether the volume's block device is encrypted. Only block volumes and the volumes of
+// block-backed drivers can be encrypted.
+func (v Volume) IsEncrypted() bool {
+	if v.ConfigBlockEncryption() == "" {
+		return false
+	}
+
+	return v.contentType == ContentTypeBlock || v.IsBlockBacked()
+}
+
 // ConfigBlockMountOptions returns the filesystem mount options to use for block volumes. Returns config value
 // "block.mount_options" if defined in volume or pool's volume config, otherwise defaultFilesystemMountOptions.
 func (v Volume) ConfigBlockMountOptions() string {
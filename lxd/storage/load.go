@@ -3,12 +3,15 @@ package storage
 import (
 	"fmt"
 
+	"github.com/pkg/errors"
+
 	"github.com/lxc/lxd/lxd/db"
 	"github.com/lxc/lxd/lxd/instance"
 	"github.com/lxc/lxd/lxd/operations"
 	"github.com/lxc/lxd/lxd/project"
 	"github.com/lxc/lxd/lxd/state"
 	"github.com/lxc/lxd/lxd/storage/drivers"
+	"github.com/lxc/lxd/shared"
 	"github.com/lxc/lxd/shared/api"
 	log "github.com/lxc/lxd/shared/log15"
 	"github.com/lxc/lxd/shared/logger"
@@ -55,6 +58,42 @@ func volIDFuncMake(state *state.State, poolID int64) func(volType drivers.Volume
 	}
 }
 
+// volKeyFuncMake returns a function that can be supplied to the underlying storage drivers allowing them to
+// retrieve the encryption key of a specific volume type and volume name from the pool's keyfile. The keys are only
+// kept there, on the local server, so that they aren't replicated with the database. If create is true and the
+// volume has no key yet, a new one is generated, otherwise a missing key is an error. Snapshots share the
+// encryption key of their parent.
+func volKeyFuncMake(poolName string) func(volType drivers.VolumeType, volName string, create bool) (string, error) {
+	return func(volType drivers.VolumeType, volName string, create bool) (string, error) {
+		parentName, _, _ := shared.InstanceGetParentAndSnapshotName(volName)
+
+		key, err := getPoolKey(poolName, volType, parentName)
+		if err != nil {
+			return "", err
+		}
+
+		if key != "" {
+			return key, nil
+		}
+
+		if !create {
+			return "", fmt.Errorf("Encryption key of volume %q not found", volName)
+		}
+
+		key, err = shared.RandomCryptoString()
+		if err != nil {
+			return "", errors.Wrapf(err, "Failed to generate encryption key of volume %q", volName)
+		}
+
+		err = setPoolKey(poolName, volType, parentName, key)
+		if err != nil {
+			return "", err
+		}
+
+		return key, nil
+	}
+}
+
 // commonRules returns a set of common validators.
 func commonRules() *drivers.Validators {
 	return &drivers.Validators{
@@ -82,7 +121,7 @@ func CreatePool(state *state.State, poolID int64, dbPool *api.StoragePoolsPost,
 		pool.name = dbPool.Name
 		pool.state = state
 		pool.logger = logging.AddContext(logger.Log, log.Ctx{"driver": "mock", "pool": pool.name})
-		driver, err := drivers.Load(state, "mock", "", nil, pool.logger, nil, nil)
+		driver, err := drivers.Load(state, "mock", "", nil, pool.logger, nil, nil, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -94,7 +133,7 @@ func CreatePool(state *state.State, poolID int64, dbPool *api.StoragePoolsPost,
 	logger := logging.AddContext(logger.Log, log.Ctx{"driver": dbPool.Driver, "pool": dbPool.Name})
 
 	// Load the storage driver.
-	driver, err := drivers.Load(state, dbPool.Driver, dbPool.Name, dbPool.Config, logger, volIDFuncMake(state, poolID), commonRules())
+	driver, err := drivers.Load(state, dbPool.Driver, dbPool.Name, dbPool.Config, logger, volIDFuncMake(state, poolID), volKeyFuncMake(dbPool.Name), commonRules())
 	if err != nil {
 		return nil, err
 	}
@@ -121,6 +160,43 @@ func CreatePool(state *state.State, poolID int64, dbPool *api.StoragePoolsPost,
 	return &pool, nil
 }
 
+// PoolIDTemporary is the ID used for pools that are not (yet) recorded in the database.
+const PoolIDTemporary = -1
+
+// NewTemporary returns a Pool interface for a pool that exists on the storage device but has no database record.
+// This is used to inspect the pool, for instance during disaster recovery, without creating it.
+// If the pool's driver is not recognised then drivers.ErrUnknownDriver is returned.
+func NewTemporary(state *state.State, info *api.StoragePool) (Pool, error) {
+	// Sanity checks.
+	if info == nil {
+		return nil, ErrNilValue
+	}
+
+	// Ensure a config map exists.
+	if info.Config == nil {
+		info.Config = map[string]string{}
+	}
+
+	logger := logging.AddContext(logger.Log, log.Ctx{"driver": info.Driver, "pool": info.Name})
+
+	// Load the storage driver.
+	driver, err := drivers.Load(state, info.Driver, info.Name, info.Config, logger, nil, volKeyFuncMake(info.Name), commonRules())
+	if err != nil {
+		return nil, err
+	}
+
+	// Setup the pool struct.
+	pool := lxdBackend{}
+	pool.driver = driver
+	pool.id = PoolIDTemporary
+	pool.db = *info
+	pool.name = info.Name
+	pool.state = state
+	pool.logger = logger
+
+	return &pool, nil
+}
+
 // GetPoolByName retrieves the pool from the database by its name and returns a Pool interface.
 // If the pool's driver is not recognised then drivers.ErrUnknownDriver is returned.
 func GetPoolByName(state *state.State, name string) (Pool, error) {
@@ -130,7 +206,7 @@ func GetPoolByName(state *state.State, name string) (Pool, error) {
 		pool.name = name
 		pool.state = state
 		pool.logger = logging.AddContext(logger.Log, log.Ctx{"driver": "mock", "pool": pool.name})
-		driver, err := drivers.Load(state, "mock", "", nil, pool.logger, nil, nil)
+		driver, err := drivers.Load(state, "mock", "", nil, pool.logger, nil, nil, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -153,7 +229,7 @@ func GetPoolByName(state *state.State, name string) (Pool, error) {
 	logger := logging.AddContext(logger.Log, log.Ctx{"driver": dbPool.Driver, "pool": dbPool.Name})
 
 	// Load the storage driver.
-	driver, err := drivers.Load(state, dbPool.Driver, dbPool.Name, dbPool.Config, logger, volIDFuncMake(state, poolID), commonRules())
+	driver, err := drivers.Load(state, dbPool.Driver, dbPool.Name, dbPool.Config, logger, volIDFuncMake(state, poolID), volKeyFuncMake(dbPool.Name), commonRules())
 	if err != nil {
 		return nil, err
 	}
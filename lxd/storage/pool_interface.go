@@ -29,6 +29,8 @@ type Pool interface {
 
 	ApplyPatch(name string) error
 
+	ListUnknownVolumes(op *operations.Operation) (map[string][]*backup.InstanceConfig, error)
+
 	// Instances.
 	CreateInstance(inst instance.Instance, op *operations.Operation) error
 	CreateInstanceFromBackup(srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) (func(instance.Instance) error, func(), error)
@@ -40,13 +42,16 @@ type Pool interface {
 	UpdateInstance(inst instance.Instance, newDesc string, newConfig map[string]string, op *operations.Operation) error
 	UpdateInstanceBackupFile(inst instance.Instance, op *operations.Operation) error
 	CheckInstanceBackupFileSnapshots(backupConf *backup.InstanceConfig, projectName string, deleteMissing bool, op *operations.Operation) ([]*api.InstanceSnapshot, error)
+	ImportInstance(inst instance.Instance, op *operations.Operation) error
 
 	MigrateInstance(inst instance.Instance, conn io.ReadWriteCloser, args *migration.VolumeSourceArgs, op *operations.Operation) error
 	RefreshInstance(inst instance.Instance, src instance.Instance, srcSnapshots []instance.Instance, op *operations.Operation) error
-	BackupInstance(inst instance.Instance, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, op *operations.Operation) error
+	BackupInstance(inst instance.Instance, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, args drivers.BackupArgs, op *operations.Operation) error
+	RefreshInstanceFromBackup(inst instance.Instance, srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) error
+	DeleteInstanceBackupBase(inst instance.Instance, uuid string, op *operations.Operation) error
 
 	GetInstanceUsage(inst instance.Instance) (int64, error)
-	SetInstanceQuota(inst instance.Instance, size string, op *operations.Operation) error
+	SetInstanceQuota(inst instance.Instance, size string, vmStateSize string, op *operations.Operation) error
 
 	MountInstance(inst instance.Instance, op *operations.Operation) (bool, error)
 	UnmountInstance(inst instance.Instance, op *operations.Operation) (bool, error)
@@ -76,6 +81,7 @@ type Pool interface {
 	GetCustomVolumeUsage(projectName string, volName string) (int64, error)
 	MountCustomVolume(projectName string, volName string, op *operations.Operation) (bool, error)
 	UnmountCustomVolume(projectName string, volName string, op *operations.Operation) (bool, error)
+	ImportCustomVolume(projectName string, poolVol *backup.InstanceConfig, op *operations.Operation) error
 
 	// Custom volume snapshots.
 	CreateCustomVolumeSnapshot(projectName string, volName string, newSnapshotName string, newExpiryDate time.Time, op *operations.Operation) error
@@ -84,8 +90,22 @@ type Pool interface {
 	UpdateCustomVolumeSnapshot(projectName string, volName string, newDesc string, newConfig map[string]string, newExpiryDate time.Time, op *operations.Operation) error
 	RestoreCustomVolume(projectName string, volName string, snapshotName string, op *operations.Operation) error
 
+	// Custom volume backups.
+	BackupCustomVolume(projectName string, volName string, tarWriter *instancewriter.InstanceTarWriter, optimized bool, snapshots bool, args drivers.BackupArgs, op *operations.Operation) error
+	CreateCustomVolumeFromBackup(srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) error
+	RefreshCustomVolumeFromBackup(srcBackup backup.Info, srcData io.ReadSeeker, op *operations.Operation) error
+	DeleteCustomVolumeBackupBase(projectName string, volName string, uuid string, op *operations.Operation) error
+
 	// Custom volume migration.
 	MigrationTypes(contentType drivers.ContentType, refresh bool) []migration.Type
 	CreateCustomVolumeFromMigration(projectName string, conn io.ReadWriteCloser, args migration.VolumeTargetArgs, op *operations.Operation) error
 	MigrateCustomVolume(projectName string, conn io.ReadWriteCloser, args *migration.VolumeSourceArgs, op *operations.Operation) error
+
+	// Buckets.
+	CreateBucket(projectName string, bucket api.StorageBucketsPost, op *operations.Operation) error
+	UpdateBucket(projectName string, bucketName string, bucket api.StorageBucketPut, op *operations.Operation) error
+	DeleteBucket(projectName string, bucketName string, op *operations.Operation) error
+	MountBucket(projectName string, bucketName string, op *operations.Operation) error
+	UnmountBucket(projectName string, bucketName string, op *operations.Operation) error
+	GetBucketPath(projectName string, bucketName string) string
 }
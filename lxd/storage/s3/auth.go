@@ -0,0 +1,462 @@
+package s3
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Values of the x-amz-content-sha256 header which don't hold the hash of the payload.
+const (
+	unsignedPayload                 = "UNSIGNED-PAYLOAD"
+	streamingPayload                = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	streamingPayloadTrailer         = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+	streamingUnsignedPayloadTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+)
+
+// signV4Algorithm is the only supported signing algorithm.
+const signV4Algorithm = "AWS4-HMAC-SHA256"
+
+// amzDateFormat is the format of the X-Amz-Date header.
+const amzDateFormat = "20060102T150405Z"
+
+// maxClockSkew is the maximum difference allowed between the time of a request and the server's time.
+const maxClockSkew = 15 * time.Minute
+
+// maxChunkSize is the maximum size of the chunks of aws-chunked payloads.
+const maxChunkSize = 16 * 1024 * 1024
+
+// emptySHA256 is the SHA256 hash of an empty payload.
+var emptySHA256 = hex.EncodeToString(sha256.New().Sum(nil))
+
+// signature represents the AWS signature version 4 of a request.
+type signature struct {
+	accessKey     string
+	scopeDate     string
+	region        string
+	service       string
+	signedHeaders []string
+	signature     string
+	amzDate       string
+	payloadHash   string
+	presigned     bool
+}
+
+// parseSignature parses the AWS signature version 4 of a request from either its Authorization header or its
+// query string (presigned URLs). Returns nil if the request isn't signed.
+func parseSignature(r *http.Request) (*signature, error) {
+	sig := &signature{}
+	var credential string
+	var signedHeaders string
+
+	query := r.URL.Query()
+	if query.Get("X-Amz-Algorithm") != "" {
+		if query.Get("X-Amz-Algorithm") != signV4Algorithm {
+			return nil, errAuthorizationHeaderMalformed.withMessage("Unsupported signing algorithm %q", query.Get("X-Amz-Algorithm"))
+		}
+
+		sig.presigned = true
+		credential = query.Get("X-Amz-Credential")
+		signedHeaders = query.Get("X-Amz-SignedHeaders")
+		sig.signature = query.Get("X-Amz-Signature")
+		sig.amzDate = query.Get("X-Amz-Date")
+		sig.payloadHash = query.Get("X-Amz-Content-Sha256")
+		if sig.payloadHash == "" {
+			sig.payloadHash = unsignedPayload
+		}
+	} else {
+		auth := r.Header.Get("Authorization")
+		if auth == "" {
+			return nil, nil
+		}
+
+		if !strings.HasPrefix(auth, signV4Algorithm+" ") {
+			return nil, errAuthorizationHeaderMalformed.withMessage("Only the %s signing algorithm is supported", signV4Algorithm)
+		}
+
+		for _, field := range strings.Split(strings.TrimPrefix(auth, signV4Algorithm+" "), ",") {
+			parts := strings.SplitN(strings.TrimSpace(field), "=", 2)
+			if len(parts) != 2 {
+				return nil, errAuthorizationHeaderMalformed
+			}
+
+			switch parts[0] {
+			case "Credential":
+				credential = parts[1]
+			case "SignedHeaders":
+				signedHeaders = parts[1]
+			case "Signature":
+				sig.signature = parts[1]
+			}
+		}
+
+		sig.amzDate = r.Header.Get("X-Amz-Date")
+		if sig.amzDate == "" {
+			date, err := http.ParseTime(r.Header.Get("Date"))
+			if err != nil {
+				return nil, errMissingSecurityHeader.withMessage("Missing X-Amz-Date header")
+			}
+
+			sig.amzDate = date.UTC().Format(amzDateFormat)
+		}
+
+		sig.payloadHash = r.Header.Get("X-Amz-Content-Sha256")
+		if sig.payloadHash == "" {
+			return nil, errMissingSecurityHeader.withMessage("Missing x-amz-content-sha256 header")
+		}
+	}
+
+	scope := strings.Split(credential, "/")
+	if len(scope) != 5 || scope[0] == "" || scope[4] != "aws4_request" {
+		return nil, errAuthorizationHeaderMalformed.withMessage("Invalid credential %q", credential)
+	}
+
+	if signedHeaders == "" || sig.signature == "" {
+		return nil, errAuthorizationHeaderMalformed
+	}
+
+	sig.accessKey = scope[0]
+	sig.scopeDate = scope[1]
+	sig.region = scope[2]
+	sig.service = scope[3]
+	sig.signedHeaders = strings.Split(signedHeaders, ";")
+
+	return sig, nil
+}
+
+// scope returns the credential scope of the signature.
+func (s *signature) scope() string {
+	return strings.Join([]string{s.scopeDate, s.region, s.service, "aws4_request"}, "/")
+}
+
+// signingKey derives the signing key of the signature from the secret key.
+func (s *signature) signingKey(secretKey string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretKey), s.scopeDate)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s.service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+// verify checks the validity period and the signature of the request using the secret key. The request body is
+// replaced by one verifying its payload hash or decoding and verifying its aws-chunked encoding as needed.
+func (s *signature) verify(r *http.Request, secretKey string, now time.Time) error {
+	date, err := time.Parse(amzDateFormat, s.amzDate)
+	if err != nil {
+		return errAuthorizationHeaderMalformed.withMessage("Invalid date %q", s.amzDate)
+	}
+
+	if s.presigned {
+		expires, err := strconv.Atoi(r.URL.Query().Get("X-Amz-Expires"))
+		if err != nil || expires < 1 || expires > 7*24*3600 {
+			return errAuthorizationHeaderMalformed.withMessage("Invalid X-Amz-Expires value")
+		}
+
+		if now.After(date.Add(time.Duration(expires) * time.Second)) {
+			return errAccessDenied.withMessage("Request has expired")
+		}
+
+		if date.After(now.Add(maxClockSkew)) {
+			return errRequestTimeTooSkewed
+		}
+	} else if date.Before(now.Add(-maxClockSkew)) || date.After(now.Add(maxClockSkew)) {
+		return errRequestTimeTooSkewed
+	}
+
+	key := s.signingKey(secretKey)
+	expected := s.compute(r, key)
+	if !hmac.Equal([]byte(expected), []byte(s.signature)) {
+		return errSignatureDoesNotMatch
+	}
+
+	switch s.payloadHash {
+	case unsignedPayload:
+	case streamingPayload, streamingPayloadTrailer, streamingUnsignedPayloadTrailer:
+		reader := &chunkedReader{
+			reader:  bufio.NewReader(r.Body),
+			body:    r.Body,
+			trailer: s.payloadHash != streamingPayload,
+		}
+
+		if s.payloadHash != streamingUnsignedPayloadTrailer {
+			reader.sig = s
+			reader.signingKey = key
+			reader.prevSignature = s.signature
+		}
+
+		r.Body = reader
+
+		length, err := strconv.ParseInt(r.Header.Get("X-Amz-Decoded-Content-Length"), 10, 64)
+		if err == nil {
+			r.ContentLength = length
+		}
+
+	default:
+		if len(s.payloadHash) != sha256.Size*2 {
+			return errContentSHA256Mismatch
+		}
+
+		r.Body = &hashVerifier{ReadCloser: r.Body, hash: sha256.New(), expected: s.payloadHash}
+	}
+
+	return nil
+}
+
+// compute returns the signature of the request using the signing key.
+func (s *signature) compute(r *http.Request, signingKey []byte) string {
+	stringToSign := strings.Join([]string{
+		signV4Algorithm,
+		s.amzDate,
+		s.scope(),
+		hexSHA256([]byte(s.canonicalRequest(r))),
+	}, "\n")
+
+	return hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+}
+
+// canonicalRequest returns the canonical form of the request used for signing.
+func (s *signature) canonicalRequest(r *http.Request) string {
+	// Build the canonical query string, leaving out the signature of presigned requests.
+	query := r.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		if s.presigned && k == "X-Amz-Signature" {
+			continue
+		}
+
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	params := []string{}
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+
+		for _, v := range values {
+			params = append(params, fmt.Sprintf("%s=%s", uriEncode(k, true), uriEncode(v, true)))
+		}
+	}
+
+	// Build the canonical headers.
+	var headers strings.Builder
+	for _, name := range s.signedHeaders {
+		var values []string
+		switch name {
+		case "host":
+			values = []string{r.Host}
+		case "content-length":
+			values = r.Header.Values("Content-Length")
+			if len(values) == 0 && r.ContentLength >= 0 {
+				values = []string{strconv.FormatInt(r.ContentLength, 10)}
+			}
+
+		default:
+			values = r.Header.Values(name)
+		}
+
+		for i := range values {
+			values[i] = strings.Join(strings.Fields(values[i]), " ")
+		}
+
+		fmt.Fprintf(&headers, "%s:%s\n", name, strings.Join(values, ","))
+	}
+
+	path := r.URL.Path
+	if path == "" {
+		path = "/"
+	}
+
+	return strings.Join([]string{
+		r.Method,
+		uriEncode(path, false),
+		strings.Join(params, "&"),
+		headers.String(),
+		strings.Join(s.signedHeaders, ";"),
+		s.payloadHash,
+	}, "\n")
+}
+
+// uriEncode encodes the value as specified for AWS signatures, only leaving unreserved characters (and slashes
+// unless encodeSlash is set) unencoded.
+func uriEncode(value string, encodeSlash bool) string {
+	var result strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			result.WriteByte(c)
+		} else {
+			fmt.Fprintf(&result, "%%%02X", c)
+		}
+	}
+
+	return result.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashVerifier checks that the hash of the data read from a request body matches the expected one once the
+// whole body has been read.
+type hashVerifier struct {
+	io.ReadCloser
+	hash     hash.Hash
+	expected string
+}
+
+// Read reads from the request body, returning an error at the end of the body if the hashes don't match.
+func (v *hashVerifier) Read(p []byte) (int, error) {
+	n, err := v.ReadCloser.Read(p)
+	v.hash.Write(p[:n])
+
+	if err == io.EOF && hex.EncodeToString(v.hash.Sum(nil)) != v.expected {
+		return n, errContentSHA256Mismatch
+	}
+
+	return n, err
+}
+
+// chunkedReader decodes a request body using the aws-chunked encoding, verifying the signature of each chunk
+// for signed payloads. Trailing headers are skipped as the data itself is covered by the chunk signatures.
+type chunkedReader struct {
+	reader  *bufio.Reader
+	body    io.Closer
+	trailer bool
+
+	sig           *signature
+	signingKey    []byte
+	prevSignature string
+
+	buf []byte
+	err error
+}
+
+// Read returns the decoded data of the request body.
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for len(c.buf) == 0 {
+		if c.err != nil {
+			return 0, c.err
+		}
+
+		c.err = c.readChunk()
+	}
+
+	n := copy(p, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
+
+// Close closes the request body.
+func (c *chunkedReader) Close() error {
+	return c.body.Close()
+}
+
+// readLine reads a CRLF terminated line.
+func (c *chunkedReader) readLine() (string, error) {
+	line, err := c.reader.ReadSlice('\n')
+	if err != nil {
+		if err == io.EOF {
+			return "", errIncompleteBody
+		}
+
+		return "", errIncompleteBody.withMessage("Invalid chunk: %v", err)
+	}
+
+	return strings.TrimSuffix(string(line), "\r\n"), nil
+}
+
+// readChunk reads the next chunk into the buffer, returning io.EOF after the last chunk.
+func (c *chunkedReader) readChunk() error {
+	header, err := c.readLine()
+	if err != nil {
+		return err
+	}
+
+	fields := strings.SplitN(header, ";", 2)
+	size, err := strconv.ParseInt(fields[0], 16, 64)
+	if err != nil || size < 0 || size > maxChunkSize {
+		return errIncompleteBody.withMessage("Invalid chunk size %q", fields[0])
+	}
+
+	data := make([]byte, size)
+	_, err = io.ReadFull(c.reader, data)
+	if err != nil {
+		return errIncompleteBody
+	}
+
+	if c.sig != nil {
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "chunk-signature=") {
+			return errSignatureDoesNotMatch.withMessage("Missing chunk signature")
+		}
+
+		stringToSign := strings.Join([]string{
+			"AWS4-HMAC-SHA256-PAYLOAD",
+			c.sig.amzDate,
+			c.sig.scope(),
+			c.prevSignature,
+			emptySHA256,
+			hexSHA256(data),
+		}, "\n")
+
+		expected := hex.EncodeToString(hmacSHA256(c.signingKey, stringToSign))
+		if !hmac.Equal([]byte(expected), []byte(strings.TrimPrefix(fields[1], "chunk-signature="))) {
+			return errSignatureDoesNotMatch
+		}
+
+		c.prevSignature = expected
+	}
+
+	if size > 0 {
+		// Data is followed by an empty line.
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+
+		if line != "" {
+			return errIncompleteBody.withMessage("Invalid chunk terminator")
+		}
+
+		c.buf = data
+		return nil
+	}
+
+	// The last chunk is followed by the optional trailing headers and an empty line.
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+
+		if line == "" {
+			break
+		}
+
+		if !c.trailer {
+			return errIncompleteBody.withMessage("Unexpected trailing header")
+		}
+	}
+
+	return io.EOF
+}
+
+// Ensure the readers implement io.ReadCloser.
+var _ io.ReadCloser = &hashVerifier{}
+var _ io.ReadCloser = &chunkedReader{}
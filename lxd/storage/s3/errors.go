@@ -0,0 +1,63 @@
+package s3
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+)
+
+// Error represents an S3 error returned to clients.
+type Error struct {
+	Code       string
+	Message    string
+	StatusCode int
+}
+
+// Error returns the message of the error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// withMessage returns a copy of the error with the given message.
+func (e *Error) withMessage(format string, args ...interface{}) *Error {
+	return &Error{Code: e.Code, Message: fmt.Sprintf(format, args...), StatusCode: e.StatusCode}
+}
+
+// S3 errors returned by the handler.
+var (
+	errAccessDenied                 = &Error{Code: "AccessDenied", Message: "Access Denied", StatusCode: http.StatusForbidden}
+	errAuthorizationHeaderMalformed = &Error{Code: "AuthorizationHeaderMalformed", Message: "The authorization header is malformed", StatusCode: http.StatusBadRequest}
+	errBadDigest                    = &Error{Code: "BadDigest", Message: "The Content-MD5 you specified did not match what was received", StatusCode: http.StatusBadRequest}
+	errBucketAlreadyOwnedByYou      = &Error{Code: "BucketAlreadyOwnedByYou", Message: "Your previous request to create the named bucket succeeded and you already own it", StatusCode: http.StatusConflict}
+	errContentSHA256Mismatch        = &Error{Code: "XAmzContentSHA256Mismatch", Message: "The provided 'x-amz-content-sha256' header does not match what was computed", StatusCode: http.StatusBadRequest}
+	errIncompleteBody               = &Error{Code: "IncompleteBody", Message: "You did not provide the number of bytes specified by the Content-Length HTTP header", StatusCode: http.StatusBadRequest}
+	errInternalError                = &Error{Code: "InternalError", Message: "We encountered an internal error, please try again", StatusCode: http.StatusInternalServerError}
+	errInvalidAccessKeyID           = &Error{Code: "InvalidAccessKeyId", Message: "The access key ID you provided does not exist in our records", StatusCode: http.StatusForbidden}
+	errInvalidArgument              = &Error{Code: "InvalidArgument", Message: "Invalid argument", StatusCode: http.StatusBadRequest}
+	errInvalidDigest                = &Error{Code: "InvalidDigest", Message: "The Content-MD5 you specified is not valid", StatusCode: http.StatusBadRequest}
+	errInvalidPart                  = &Error{Code: "InvalidPart", Message: "One or more of the specified parts could not be found or did not match", StatusCode: http.StatusBadRequest}
+	errInvalidPartOrder             = &Error{Code: "InvalidPartOrder", Message: "The list of parts was not in ascending order", StatusCode: http.StatusBadRequest}
+	errKeyTooLong                   = &Error{Code: "KeyTooLongError", Message: "Your key is too long", StatusCode: http.StatusBadRequest}
+	errMalformedXML                 = &Error{Code: "MalformedXML", Message: "The XML you provided was not well-formed or did not validate against our published schema", StatusCode: http.StatusBadRequest}
+	errMethodNotAllowed             = &Error{Code: "MethodNotAllowed", Message: "The specified method is not allowed against this resource", StatusCode: http.StatusMethodNotAllowed}
+	errMissingSecurityHeader        = &Error{Code: "MissingSecurityHeader", Message: "Your request is missing a required header", StatusCode: http.StatusBadRequest}
+	errNoSuchBucket                 = &Error{Code: "NoSuchBucket", Message: "The specified bucket does not exist", StatusCode: http.StatusNotFound}
+	errNoSuchKey                    = &Error{Code: "NoSuchKey", Message: "The specified key does not exist", StatusCode: http.StatusNotFound}
+	errNoSuchUpload                 = &Error{Code: "NoSuchUpload", Message: "The specified multipart upload does not exist", StatusCode: http.StatusNotFound}
+	errNotImplemented               = &Error{Code: "NotImplemented", Message: "A header or query you provided implies functionality that is not implemented", StatusCode: http.StatusNotImplemented}
+	errQuotaExceeded                = &Error{Code: "QuotaExceeded", Message: "The bucket has reached its size limit", StatusCode: http.StatusInsufficientStorage}
+	errRequestTimeTooSkewed         = &Error{Code: "RequestTimeTooSkewed", Message: "The difference between the request time and the server's time is too large", StatusCode: http.StatusForbidden}
+	errSignatureDoesNotMatch        = &Error{Code: "SignatureDoesNotMatch", Message: "The request signature we calculated does not match the signature you provided", StatusCode: http.StatusForbidden}
+)
+
+// errorResponse is the body of S3 error responses.
+type errorResponse struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string
+	Message   string
+	Resource  string
+	RequestID string `xml:"RequestId"`
+}
+
+// ErrServiceUnavailable can be returned by the retrievers of the handler when buckets can't be served yet.
+var ErrServiceUnavailable = &Error{Code: "ServiceUnavailable", Message: "The service is temporarily unavailable", StatusCode: http.StatusServiceUnavailable}
@@ -0,0 +1,1039 @@
+package s3
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// xmlns is the namespace of the S3 XML documents.
+const xmlns = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+// timeFormat is the format of the timestamps in S3 XML documents.
+const timeFormat = "2006-01-02T15:04:05.000Z"
+
+// maxKeyLength is the maximum length of object keys.
+const maxKeyLength = 1024
+
+// maxPartNumber is the maximum number of parts of multipart uploads.
+const maxPartNumber = 10000
+
+// maxXMLBodySize is the maximum size of the XML request bodies.
+const maxXMLBodySize = 1024 * 1024
+
+// unsupportedSubresources are the subresources of buckets and objects which aren't implemented.
+var unsupportedSubresources = []string{"accelerate", "acl", "analytics", "cors", "encryption", "intelligent-tiering", "inventory", "legal-hold", "lifecycle", "logging", "metrics", "notification", "object-lock", "ownershipControls", "policy", "policyStatus", "publicAccessBlock", "replication", "requestPayment", "restore", "retention", "select", "tagging", "torrent", "versionId", "versions", "website"}
+
+// Bucket represents a bucket served by the handler.
+type Bucket struct {
+	Name string
+	Path string // Path of the mounted volume holding the bucket's objects.
+}
+
+// Credential represents an access key of a bucket.
+type Credential struct {
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	ReadOnly  bool
+}
+
+// BucketRetriever returns the bucket with the given name, or nil if it doesn't exist.
+// Errors of type *Error are returned to the client as is.
+type BucketRetriever func(name string) (*Bucket, error)
+
+// CredentialRetriever returns the credential with the given access key, or nil if it doesn't exist.
+// Errors of type *Error are returned to the client as is.
+type CredentialRetriever func(accessKey string) (*Credential, error)
+
+// Handler serves buckets over an S3-compatible API using path-style requests.
+type Handler struct {
+	buckets     BucketRetriever
+	credentials CredentialRetriever
+
+	// mu serializes changes to the stored objects with reading them.
+	mu sync.RWMutex
+
+	// now returns the current time, used when checking request signatures.
+	now func() time.Time
+}
+
+// NewHandler returns a new S3 handler using the given retrievers.
+func NewHandler(buckets BucketRetriever, credentials CredentialRetriever) *Handler {
+	return &Handler{
+		buckets:     buckets,
+		credentials: credentials,
+		now:         time.Now,
+	}
+}
+
+// request holds the state of a request being served.
+type request struct {
+	w      http.ResponseWriter
+	r      *http.Request
+	id     string
+	bucket string
+	key    string
+	query  url.Values
+	store  *store
+}
+
+// ServeHTTP serves an S3 request.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req := &request{
+		w:     w,
+		r:     r,
+		id:    requestID(),
+		query: r.URL.Query(),
+	}
+
+	w.Header().Set("x-amz-request-id", req.id)
+	w.Header().Set("Server", "LXD")
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	parts := strings.SplitN(path, "/", 2)
+	req.bucket = parts[0]
+	if len(parts) == 2 {
+		req.key = parts[1]
+	}
+
+	err := h.serve(req)
+	if err != nil {
+		req.writeError(err)
+	}
+}
+
+// serve authenticates the request and dispatches it.
+func (h *Handler) serve(req *request) error {
+	cred, err := h.authenticate(req.r)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range unsupportedSubresources {
+		_, ok := req.query[name]
+		if ok {
+			return errNotImplemented.withMessage("The %q subresource isn't supported", name)
+		}
+	}
+
+	if req.bucket == "" {
+		if req.r.Method != http.MethodGet {
+			return errMethodNotAllowed
+		}
+
+		return h.listBuckets(req, cred)
+	}
+
+	if req.bucket != cred.Bucket {
+		return errAccessDenied
+	}
+
+	if cred.ReadOnly && req.r.Method != http.MethodGet && req.r.Method != http.MethodHead {
+		return errAccessDenied
+	}
+
+	bucket, err := h.buckets(req.bucket)
+	if err != nil {
+		return err
+	}
+
+	if bucket == nil {
+		return errNoSuchBucket
+	}
+
+	req.store = &store{path: bucket.Path}
+
+	if req.key == "" {
+		return h.serveBucket(req)
+	}
+
+	if len(req.key) > maxKeyLength {
+		return errKeyTooLong
+	}
+
+	return h.serveObject(req)
+}
+
+// authenticate checks the signature of the request and returns the credential used to sign it.
+func (h *Handler) authenticate(r *http.Request) (*Credential, error) {
+	sig, err := parseSignature(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if sig == nil {
+		return nil, errAccessDenied.withMessage("Anonymous access isn't allowed")
+	}
+
+	cred, err := h.credentials(sig.accessKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if cred == nil {
+		return nil, errInvalidAccessKeyID
+	}
+
+	err = sig.verify(r, cred.SecretKey, h.now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	return cred, nil
+}
+
+// serveBucket serves requests on a bucket.
+func (h *Handler) serveBucket(req *request) error {
+	switch req.r.Method {
+	case http.MethodHead:
+		req.w.WriteHeader(http.StatusOK)
+		return nil
+	case http.MethodGet:
+		_, ok := req.query["location"]
+		if ok {
+			return req.writeXML(http.StatusOK, locationConstraint{Xmlns: xmlns})
+		}
+
+		_, ok = req.query["versioning"]
+		if ok {
+			return req.writeXML(http.StatusOK, versioningConfiguration{Xmlns: xmlns})
+		}
+
+		_, ok = req.query["uploads"]
+		if ok {
+			return h.listUploads(req)
+		}
+
+		if req.query.Get("list-type") == "2" {
+			return h.listObjectsV2(req)
+		}
+
+		return h.listObjects(req)
+	case http.MethodPut:
+		return errBucketAlreadyOwnedByYou
+	case http.MethodDelete:
+		return errMethodNotAllowed.withMessage("Buckets can only be deleted through the LXD API")
+	case http.MethodPost:
+		_, ok := req.query["delete"]
+		if ok {
+			return h.deleteObjects(req)
+		}
+	}
+
+	return errMethodNotAllowed
+}
+
+// serveObject serves requests on an object.
+func (h *Handler) serveObject(req *request) error {
+	_, isUploads := req.query["uploads"]
+	_, isUpload := req.query["uploadId"]
+
+	switch req.r.Method {
+	case http.MethodHead, http.MethodGet:
+		if isUpload {
+			return errNotImplemented.withMessage("Listing the parts of multipart uploads isn't supported")
+		}
+
+		return h.getObject(req)
+	case http.MethodPut:
+		if isUpload {
+			return h.uploadPart(req)
+		}
+
+		if req.r.Header.Get("X-Amz-Copy-Source") != "" {
+			return h.copyObject(req)
+		}
+
+		return h.putObject(req)
+	case http.MethodDelete:
+		if isUpload {
+			return h.abortUpload(req)
+		}
+
+		return h.deleteObject(req)
+	case http.MethodPost:
+		if isUploads {
+			return h.createUpload(req)
+		}
+
+		if isUpload {
+			return h.completeUpload(req)
+		}
+	}
+
+	return errMethodNotAllowed
+}
+
+// listBuckets lists the bucket of the credential.
+func (h *Handler) listBuckets(req *request, cred *Credential) error {
+	result := listAllMyBucketsResult{
+		Xmlns: xmlns,
+		Owner: owner{ID: cred.AccessKey, DisplayName: cred.AccessKey},
+	}
+
+	bucket, err := h.buckets(cred.Bucket)
+	if err != nil {
+		return err
+	}
+
+	if bucket != nil {
+		created := time.Time{}
+		info, err := os.Stat(bucket.Path)
+		if err == nil {
+			created = info.ModTime()
+		}
+
+		result.Buckets = append(result.Buckets, bucketEntry{Name: bucket.Name, CreationDate: formatTime(created)})
+	}
+
+	return req.writeXML(http.StatusOK, result)
+}
+
+// listParams represents the parameters of object listings.
+type listParams struct {
+	prefix    string
+	delimiter string
+	marker    string
+	maxKeys   int
+}
+
+// parseListParams parses the parameters common to both versions of object listings.
+func parseListParams(query url.Values) (*listParams, error) {
+	params := &listParams{
+		prefix:    query.Get("prefix"),
+		delimiter: query.Get("delimiter"),
+		maxKeys:   1000,
+	}
+
+	if query.Get("max-keys") != "" {
+		maxKeys, err := strconv.Atoi(query.Get("max-keys"))
+		if err != nil || maxKeys < 0 {
+			return nil, errInvalidArgument.withMessage("Invalid max-keys value")
+		}
+
+		if maxKeys < params.maxKeys {
+			params.maxKeys = maxKeys
+		}
+	}
+
+	encoding := query.Get("encoding-type")
+	if encoding != "" && encoding != "url" {
+		return nil, errInvalidArgument.withMessage("Invalid encoding-type value")
+	}
+
+	return params, nil
+}
+
+// list returns the objects and common prefixes matching the parameters, whether the listing was truncated
+// and the last key or prefix returned.
+func (h *Handler) list(req *request, params *listParams) ([]*objectInfo, []string, bool, string, error) {
+	h.mu.RLock()
+	objects, err := req.store.listObjects()
+	h.mu.RUnlock()
+	if err != nil {
+		return nil, nil, false, "", err
+	}
+
+	contents := []*objectInfo{}
+	prefixes := []string{}
+	last := ""
+
+	if params.maxKeys == 0 {
+		return contents, prefixes, false, last, nil
+	}
+
+	for _, object := range objects {
+		if !strings.HasPrefix(object.Key, params.prefix) || object.Key <= params.marker {
+			continue
+		}
+
+		commonPrefix := ""
+		if params.delimiter != "" {
+			idx := strings.Index(object.Key[len(params.prefix):], params.delimiter)
+			if idx >= 0 {
+				commonPrefix = object.Key[:len(params.prefix)+idx+len(params.delimiter)]
+			}
+		}
+
+		// Skip keys within a common prefix which was already returned.
+		if commonPrefix != "" && (commonPrefix == last || commonPrefix <= params.marker) {
+			continue
+		}
+
+		if len(contents)+len(prefixes) >= params.maxKeys {
+			return contents, prefixes, true, last, nil
+		}
+
+		if commonPrefix != "" {
+			prefixes = append(prefixes, commonPrefix)
+			last = commonPrefix
+		} else {
+			contents = append(contents, object)
+			last = object.Key
+		}
+	}
+
+	return contents, prefixes, false, last, nil
+}
+
+// listResultEntries converts listed objects and common prefixes to their XML form.
+func listResultEntries(contents []*objectInfo, prefixes []string, encode func(string) string) ([]objectEntry, []commonPrefix) {
+	objects := make([]objectEntry, 0, len(contents))
+	for _, object := range contents {
+		objects = append(objects, objectEntry{
+			Key:          encode(object.Key),
+			LastModified: formatTime(object.LastModified),
+			ETag:         quoteETag(object.ETag),
+			Size:         object.Size,
+			StorageClass: "STANDARD",
+		})
+	}
+
+	commonPrefixes := make([]commonPrefix, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		commonPrefixes = append(commonPrefixes, commonPrefix{Prefix: encode(prefix)})
+	}
+
+	return objects, commonPrefixes
+}
+
+// keyEncoder returns the function used to encode keys in listings depending on the requested encoding.
+func keyEncoder(query url.Values) func(string) string {
+	if query.Get("encoding-type") == "url" {
+		return url.QueryEscape
+	}
+
+	return func(value string) string { return value }
+}
+
+// listObjects lists the objects of the bucket (ListObjects).
+func (h *Handler) listObjects(req *request) error {
+	params, err := parseListParams(req.query)
+	if err != nil {
+		return err
+	}
+
+	params.marker = req.query.Get("marker")
+
+	contents, prefixes, truncated, last, err := h.list(req, params)
+	if err != nil {
+		return err
+	}
+
+	encode := keyEncoder(req.query)
+	result := listBucketResult{
+		Xmlns:        xmlns,
+		Name:         req.bucket,
+		Prefix:       encode(params.prefix),
+		Marker:       encode(params.marker),
+		MaxKeys:      params.maxKeys,
+		Delimiter:    encode(params.delimiter),
+		IsTruncated:  truncated,
+		EncodingType: req.query.Get("encoding-type"),
+	}
+
+	if truncated {
+		result.NextMarker = encode(last)
+	}
+
+	result.Contents, result.CommonPrefixes = listResultEntries(contents, prefixes, encode)
+
+	return req.writeXML(http.StatusOK, result)
+}
+
+// listObjectsV2 lists the objects of the bucket (ListObjectsV2).
+func (h *Handler) listObjectsV2(req *request) error {
+	params, err := parseListParams(req.query)
+	if err != nil {
+		return err
+	}
+
+	token := req.query.Get("continuation-token")
+	startAfter := req.query.Get("start-after")
+
+	params.marker = startAfter
+	if token != "" {
+		marker, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return errInvalidArgument.withMessage("Invalid continuation token")
+		}
+
+		if string(marker) > params.marker {
+			params.marker = string(marker)
+		}
+	}
+
+	contents, prefixes, truncated, last, err := h.list(req, params)
+	if err != nil {
+		return err
+	}
+
+	encode := keyEncoder(req.query)
+	result := listBucketResultV2{
+		Xmlns:             xmlns,
+		Name:              req.bucket,
+		Prefix:            encode(params.prefix),
+		ContinuationToken: token,
+		StartAfter:        encode(startAfter),
+		KeyCount:          len(contents) + len(prefixes),
+		MaxKeys:           params.maxKeys,
+		Delimiter:         encode(params.delimiter),
+		IsTruncated:       truncated,
+		EncodingType:      req.query.Get("encoding-type"),
+	}
+
+	if truncated {
+		result.NextContinuationToken = base64.StdEncoding.EncodeToString([]byte(last))
+	}
+
+	result.Contents, result.CommonPrefixes = listResultEntries(contents, prefixes, encode)
+
+	return req.writeXML(http.StatusOK, result)
+}
+
+// getObject returns an object or its metadata (GetObject and HeadObject).
+func (h *Handler) getObject(req *request) error {
+	h.mu.RLock()
+	f, info, err := req.store.openObject(req.key)
+	h.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	header := req.w.Header()
+	for name, value := range info.Headers {
+		header.Set(name, value)
+	}
+
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "binary/octet-stream")
+	}
+
+	header.Set("ETag", quoteETag(info.ETag))
+
+	http.ServeContent(req.w, req.r, "", info.LastModified, f)
+	return nil
+}
+
+// contentMD5 returns the MD5 hash passed in the Content-MD5 header of the request, if any.
+func contentMD5(r *http.Request) ([]byte, error) {
+	value := r.Header.Get("Content-MD5")
+	if value == "" {
+		return nil, nil
+	}
+
+	hash, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(hash) != md5.Size {
+		return nil, errInvalidDigest
+	}
+
+	return hash, nil
+}
+
+// receiveData writes the request body to a temporary file, checking its size and MD5 hash. Returns the path of
+// the file along with the size and MD5 hash of the data.
+func (h *Handler) receiveData(req *request) (string, int64, []byte, error) {
+	expectedHash, err := contentMD5(req.r)
+	if err != nil {
+		return "", -1, nil, err
+	}
+
+	path, size, hash, err := req.store.writeTemp(req.r.Body)
+	if err != nil {
+		return "", -1, nil, err
+	}
+
+	if req.r.ContentLength >= 0 && size != req.r.ContentLength {
+		os.Remove(path)
+		return "", -1, nil, errIncompleteBody
+	}
+
+	if expectedHash != nil && !bytes.Equal(hash, expectedHash) {
+		os.Remove(path)
+		return "", -1, nil, errBadDigest
+	}
+
+	return path, size, hash, nil
+}
+
+// putObject stores an object (PutObject).
+func (h *Handler) putObject(req *request) error {
+	path, size, hash, err := h.receiveData(req)
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(path)
+
+	info := &objectInfo{
+		Key:          req.key,
+		Size:         size,
+		ETag:         hex.EncodeToString(hash),
+		LastModified: time.Now().UTC(),
+		Headers:      headersFromRequest(req.r),
+	}
+
+	h.mu.Lock()
+	err = req.store.commitObject(path, info)
+	h.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	req.w.Header().Set("ETag", quoteETag(info.ETag))
+	req.w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// copyObject copies an object within the bucket (CopyObject).
+func (h *Handler) copyObject(req *request) error {
+	source, err := url.PathUnescape(req.r.Header.Get("X-Amz-Copy-Source"))
+	if err != nil {
+		return errInvalidArgument.withMessage("Invalid copy source")
+	}
+
+	if strings.Contains(source, "?") {
+		return errNotImplemented.withMessage("Copying object versions isn't supported")
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(source, "/"), "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return errInvalidArgument.withMessage("Invalid copy source")
+	}
+
+	if parts[0] != req.bucket {
+		return errNotImplemented.withMessage("Copying objects between buckets isn't supported")
+	}
+
+	directive := req.r.Header.Get("X-Amz-Metadata-Directive")
+	if directive != "" && directive != "COPY" && directive != "REPLACE" {
+		return errInvalidArgument.withMessage("Invalid metadata directive")
+	}
+
+	h.mu.RLock()
+	path, sourceInfo, err := req.store.copyObject(parts[1])
+	h.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(path)
+
+	info := &objectInfo{
+		Key:          req.key,
+		Size:         sourceInfo.Size,
+		ETag:         sourceInfo.ETag,
+		LastModified: time.Now().UTC(),
+		Headers:      sourceInfo.Headers,
+	}
+
+	if directive == "REPLACE" {
+		info.Headers = headersFromRequest(req.r)
+	}
+
+	h.mu.Lock()
+	err = req.store.commitObject(path, info)
+	h.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return req.writeXML(http.StatusOK, copyObjectResult{
+		Xmlns:        xmlns,
+		LastModified: formatTime(info.LastModified),
+		ETag:         quoteETag(info.ETag),
+	})
+}
+
+// deleteObject deletes an object (DeleteObject).
+func (h *Handler) deleteObject(req *request) error {
+	h.mu.Lock()
+	err := req.store.deleteObject(req.key)
+	h.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	req.w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// deleteObjects deletes multiple objects (DeleteObjects).
+func (h *Handler) deleteObjects(req *request) error {
+	body := deleteRequest{}
+	err := readXML(req.r, &body)
+	if err != nil {
+		return err
+	}
+
+	if len(body.Objects) == 0 || len(body.Objects) > 1000 {
+		return errMalformedXML
+	}
+
+	result := deleteResult{Xmlns: xmlns}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, object := range body.Objects {
+		err := req.store.deleteObject(object.Key)
+		if err != nil {
+			logger.Warn("Failed deleting S3 object", log.Ctx{"bucket": req.bucket, "key": object.Key, "err": err})
+			result.Errors = append(result.Errors, deleteError{Key: object.Key, Code: errInternalError.Code, Message: errInternalError.Message})
+			continue
+		}
+
+		if !body.Quiet {
+			result.Deleted = append(result.Deleted, deletedObject{Key: object.Key})
+		}
+	}
+
+	return req.writeXML(http.StatusOK, result)
+}
+
+// createUpload starts a multipart upload (CreateMultipartUpload).
+func (h *Handler) createUpload(req *request) error {
+	uploadID, err := req.store.createUpload(&uploadInfo{
+		Key:       req.key,
+		Initiated: time.Now().UTC(),
+		Headers:   headersFromRequest(req.r),
+	})
+	if err != nil {
+		return err
+	}
+
+	return req.writeXML(http.StatusOK, initiateMultipartUploadResult{
+		Xmlns:    xmlns,
+		Bucket:   req.bucket,
+		Key:      req.key,
+		UploadID: uploadID,
+	})
+}
+
+// uploadPart stores a part of a multipart upload (UploadPart).
+func (h *Handler) uploadPart(req *request) error {
+	if req.r.Header.Get("X-Amz-Copy-Source") != "" {
+		return errNotImplemented.withMessage("Copying parts of multipart uploads isn't supported")
+	}
+
+	partNumber, err := strconv.Atoi(req.query.Get("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > maxPartNumber {
+		return errInvalidArgument.withMessage("Part number must be an integer between 1 and %d", maxPartNumber)
+	}
+
+	uploadID := req.query.Get("uploadId")
+
+	h.mu.RLock()
+	_, _, err = req.store.getUpload(req.key, uploadID)
+	h.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	path, _, hash, err := h.receiveData(req)
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(path)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Check the upload wasn't completed or aborted while receiving the part.
+	uploadPath, _, err := req.store.getUpload(req.key, uploadID)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(path, partPath(uploadPath, partNumber))
+	if err != nil {
+		return err
+	}
+
+	req.w.Header().Set("ETag", quoteETag(hex.EncodeToString(hash)))
+	req.w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+// completeUpload assembles the parts of a multipart upload into an object (CompleteMultipartUpload).
+func (h *Handler) completeUpload(req *request) error {
+	body := completeMultipartUpload{}
+	err := readXML(req.r, &body)
+	if err != nil {
+		return err
+	}
+
+	if len(body.Parts) == 0 {
+		return errMalformedXML
+	}
+
+	for i := 1; i < len(body.Parts); i++ {
+		if body.Parts[i].PartNumber <= body.Parts[i-1].PartNumber {
+			return errInvalidPartOrder
+		}
+	}
+
+	uploadID := req.query.Get("uploadId")
+
+	h.mu.RLock()
+	path, info, size, etag, err := h.assembleUpload(req, uploadID, body.Parts)
+	h.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(path)
+
+	object := &objectInfo{
+		Key:          req.key,
+		Size:         size,
+		ETag:         etag,
+		LastModified: time.Now().UTC(),
+		Headers:      info.Headers,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Check the upload wasn't completed or aborted while assembling the parts.
+	uploadPath, _, err := req.store.getUpload(req.key, uploadID)
+	if err != nil {
+		return err
+	}
+
+	err = req.store.commitObject(path, object)
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(uploadPath)
+	if err != nil {
+		logger.Warn("Failed removing S3 multipart upload", log.Ctx{"bucket": req.bucket, "upload": uploadID, "err": err})
+	}
+
+	return req.writeXML(http.StatusOK, completeMultipartUploadResult{
+		Xmlns:  xmlns,
+		Bucket: req.bucket,
+		Key:    req.key,
+		ETag:   quoteETag(object.ETag),
+	})
+}
+
+// assembleUpload concatenates the given parts of a multipart upload into a temporary file, checking their
+// ETags. Returns the path of the file, the upload's metadata and the size and ETag of the resulting object.
+func (h *Handler) assembleUpload(req *request, uploadID string, parts []completedPart) (string, *uploadInfo, int64, string, error) {
+	uploadPath, info, err := req.store.getUpload(req.key, uploadID)
+	if err != nil {
+		return "", nil, -1, "", err
+	}
+
+	// Hash each part while concatenating them so that they only need to be read once.
+	readers := make([]io.Reader, 0, len(parts))
+	hashers := make([]*partHasher, 0, len(parts))
+	for _, part := range parts {
+		f, err := os.Open(partPath(uploadPath, part.PartNumber))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return "", nil, -1, "", errInvalidPart
+			}
+
+			return "", nil, -1, "", err
+		}
+
+		defer f.Close()
+
+		hasher := &partHasher{reader: f, hash: md5.New()}
+		readers = append(readers, hasher)
+		hashers = append(hashers, hasher)
+	}
+
+	path, size, _, err := req.store.writeTemp(io.MultiReader(readers...))
+	if err != nil {
+		return "", nil, -1, "", err
+	}
+
+	sums := []byte{}
+	for i, hasher := range hashers {
+		sum := hasher.hash.Sum(nil)
+		if hex.EncodeToString(sum) != strings.Trim(parts[i].ETag, `"`) {
+			os.Remove(path)
+			return "", nil, -1, "", errInvalidPart
+		}
+
+		sums = append(sums, sum...)
+	}
+
+	etag := fmt.Sprintf("%x-%d", md5.Sum(sums), len(parts))
+
+	return path, info, size, etag, nil
+}
+
+// partHasher hashes the data read from a part of a multipart upload.
+type partHasher struct {
+	reader io.Reader
+	hash   hash.Hash
+}
+
+// Read reads from the part and hashes the data.
+func (p *partHasher) Read(buf []byte) (int, error) {
+	n, err := p.reader.Read(buf)
+	p.hash.Write(buf[:n])
+	return n, err
+}
+
+// abortUpload deletes a multipart upload and its parts (AbortMultipartUpload).
+func (h *Handler) abortUpload(req *request) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	uploadPath, _, err := req.store.getUpload(req.key, req.query.Get("uploadId"))
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(uploadPath)
+	if err != nil {
+		return err
+	}
+
+	req.w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// listUploads lists the ongoing multipart uploads of the bucket (ListMultipartUploads).
+func (h *Handler) listUploads(req *request) error {
+	h.mu.RLock()
+	uploads, err := req.store.listUploads()
+	h.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	prefix := req.query.Get("prefix")
+	encode := keyEncoder(req.query)
+
+	result := listMultipartUploadsResult{
+		Xmlns:        xmlns,
+		Bucket:       req.bucket,
+		Prefix:       encode(prefix),
+		MaxUploads:   1000,
+		EncodingType: req.query.Get("encoding-type"),
+	}
+
+	for uploadID, info := range uploads {
+		if !strings.HasPrefix(info.Key, prefix) {
+			continue
+		}
+
+		result.Uploads = append(result.Uploads, upload{
+			Key:          encode(info.Key),
+			UploadID:     uploadID,
+			Initiated:    formatTime(info.Initiated),
+			StorageClass: "STANDARD",
+		})
+	}
+
+	sort.Slice(result.Uploads, func(i, j int) bool {
+		if result.Uploads[i].Key == result.Uploads[j].Key {
+			return result.Uploads[i].Initiated < result.Uploads[j].Initiated
+		}
+
+		return result.Uploads[i].Key < result.Uploads[j].Key
+	})
+
+	return req.writeXML(http.StatusOK, result)
+}
+
+// readXML decodes the XML body of the request.
+func readXML(r *http.Request, value interface{}) error {
+	data, err := ioutil.ReadAll(io.LimitReader(r.Body, maxXMLBodySize+1))
+	if err != nil {
+		return err
+	}
+
+	if len(data) > maxXMLBodySize {
+		return errMalformedXML.withMessage("Request body is too large")
+	}
+
+	err = xml.Unmarshal(data, value)
+	if err != nil {
+		return errMalformedXML
+	}
+
+	return nil
+}
+
+// writeXML writes the value as the XML body of the response.
+func (req *request) writeXML(statusCode int, value interface{}) error {
+	data, err := xml.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	req.w.Header().Set("Content-Type", "application/xml")
+	req.w.WriteHeader(statusCode)
+	_, _ = req.w.Write([]byte(xml.Header))
+	_, _ = req.w.Write(data)
+	return nil
+}
+
+// writeError writes the error response. Errors which aren't S3 errors are logged and reported as internal errors.
+func (req *request) writeError(err error) {
+	var s3Err *Error
+	if !errors.As(err, &s3Err) {
+		s3Err = errInternalError
+		logger.Warn("Failed serving S3 request", log.Ctx{"method": req.r.Method, "path": req.r.URL.Path, "err": err})
+	}
+
+	if req.r.Method == http.MethodHead {
+		req.w.WriteHeader(s3Err.StatusCode)
+		return
+	}
+
+	_ = req.writeXML(s3Err.StatusCode, errorResponse{
+		Code:      s3Err.Code,
+		Message:   s3Err.Message,
+		Resource:  req.r.URL.Path,
+		RequestID: req.id,
+	})
+}
+
+// requestID returns a new random request ID.
+func requestID() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return strings.ToUpper(hex.EncodeToString(buf))
+}
+
+// formatTime formats the time as used in S3 XML documents.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+// quoteETag returns the ETag in its quoted form.
+func quoteETag(etag string) string {
+	return fmt.Sprintf("%q", etag)
+}
@@ -0,0 +1,412 @@
+package s3
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Directories used within the bucket volume.
+const (
+	objectsDir = "objects"
+	uploadsDir = "uploads"
+	tmpDir     = "tmp"
+)
+
+// storedHeaders are the request headers stored alongside objects and returned when they are retrieved.
+var storedHeaders = []string{"Cache-Control", "Content-Disposition", "Content-Encoding", "Content-Language", "Content-Type", "Expires"}
+
+// objectInfo represents the metadata of a stored object.
+type objectInfo struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	ETag         string            `json:"etag"`
+	LastModified time.Time         `json:"last_modified"`
+	Headers      map[string]string `json:"headers"`
+}
+
+// uploadInfo represents the metadata of a multipart upload.
+type uploadInfo struct {
+	Key       string            `json:"key"`
+	Initiated time.Time         `json:"initiated"`
+	Headers   map[string]string `json:"headers"`
+}
+
+// store manages the objects of a bucket within its volume.
+type store struct {
+	path string
+}
+
+// objectID returns the name of the files holding the object with the given key.
+func objectID(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// storageError converts errors caused by a full volume or a truncated request body to S3 errors.
+func storageError(err error) error {
+	if errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EDQUOT) {
+		return errQuotaExceeded
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return errIncompleteBody
+	}
+
+	return err
+}
+
+// headersFromRequest returns the headers of the request to store alongside an object.
+func headersFromRequest(r *http.Request) map[string]string {
+	headers := map[string]string{}
+	for _, name := range storedHeaders {
+		value := r.Header.Get(name)
+		if name == "Content-Encoding" {
+			// The aws-chunked encoding only applies to the transfer of the object.
+			encodings := []string{}
+			for _, encoding := range strings.Split(value, ",") {
+				encoding = strings.TrimSpace(encoding)
+				if encoding != "" && encoding != "aws-chunked" {
+					encodings = append(encodings, encoding)
+				}
+			}
+
+			value = strings.Join(encodings, ",")
+		}
+
+		if value != "" {
+			headers[name] = value
+		}
+	}
+
+	for name, values := range r.Header {
+		if strings.HasPrefix(strings.ToLower(name), "x-amz-meta-") {
+			headers[http.CanonicalHeaderKey(name)] = strings.Join(values, ",")
+		}
+	}
+
+	return headers
+}
+
+// mkdir creates the given directory of the bucket if missing and returns its path.
+func (s *store) mkdir(dir string) (string, error) {
+	path := filepath.Join(s.path, dir)
+	err := os.MkdirAll(path, 0700)
+	if err != nil {
+		return "", storageError(err)
+	}
+
+	return path, nil
+}
+
+// writeTemp writes the data read from the reader to a temporary file and returns its path along with the
+// size and MD5 hash of the data.
+func (s *store) writeTemp(reader io.Reader) (string, int64, []byte, error) {
+	dir, err := s.mkdir(tmpDir)
+	if err != nil {
+		return "", -1, nil, err
+	}
+
+	f, err := ioutil.TempFile(dir, "data.")
+	if err != nil {
+		return "", -1, nil, storageError(err)
+	}
+
+	hash := md5.New()
+	size, err := io.Copy(f, io.TeeReader(reader, hash))
+	if err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+		return "", -1, nil, storageError(err)
+	}
+
+	return f.Name(), size, hash.Sum(nil), nil
+}
+
+// writeJSON atomically writes the value as JSON to the given path.
+func (s *store) writeJSON(path string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	dir, err := s.mkdir(tmpDir)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(dir, "meta.")
+	if err != nil {
+		return storageError(err)
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Close()
+	} else {
+		f.Close()
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+		return storageError(err)
+	}
+
+	return nil
+}
+
+// readJSON reads the JSON value at the given path.
+func readJSON(path string, value interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, value)
+}
+
+// objectPaths returns the paths of the data and metadata files of the object with the given key.
+func (s *store) objectPaths(key string) (string, string) {
+	id := objectID(key)
+	return filepath.Join(s.path, objectsDir, id), filepath.Join(s.path, objectsDir, id+".json")
+}
+
+// getObject returns the metadata of the object with the given key.
+func (s *store) getObject(key string) (*objectInfo, error) {
+	_, metaPath := s.objectPaths(key)
+
+	info := objectInfo{}
+	err := readJSON(metaPath, &info)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errNoSuchKey
+		}
+
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// openObject opens the data of the object with the given key.
+func (s *store) openObject(key string) (*os.File, *objectInfo, error) {
+	info, err := s.getObject(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dataPath, _ := s.objectPaths(key)
+	f, err := os.Open(dataPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, errNoSuchKey
+		}
+
+		return nil, nil, err
+	}
+
+	return f, info, nil
+}
+
+// commitObject moves the data file at dataPath in place of the object described by info.
+func (s *store) commitObject(dataPath string, info *objectInfo) error {
+	_, err := s.mkdir(objectsDir)
+	if err != nil {
+		return err
+	}
+
+	objDataPath, objMetaPath := s.objectPaths(info.Key)
+
+	// Remove the old metadata first so that the object is never reported with the wrong data.
+	err = os.Remove(objMetaPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	err = os.Rename(dataPath, objDataPath)
+	if err != nil {
+		return err
+	}
+
+	return s.writeJSON(objMetaPath, info)
+}
+
+// copyObject copies the data of the object with the given key to a temporary file and returns its path.
+func (s *store) copyObject(key string) (string, *objectInfo, error) {
+	f, info, err := s.openObject(key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	defer f.Close()
+
+	path, _, _, err := s.writeTemp(f)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return path, info, nil
+}
+
+// deleteObject deletes the object with the given key. Deleting a missing object isn't an error.
+func (s *store) deleteObject(key string) error {
+	dataPath, metaPath := s.objectPaths(key)
+
+	for _, path := range []string{metaPath, dataPath} {
+		err := os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listObjects returns the metadata of all objects sorted by key.
+func (s *store) listObjects() ([]*objectInfo, error) {
+	entries, err := ioutil.ReadDir(filepath.Join(s.path, objectsDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	objects := []*objectInfo{}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		info := objectInfo{}
+		err := readJSON(filepath.Join(s.path, objectsDir, entry.Name()), &info)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		objects = append(objects, &info)
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+
+	return objects, nil
+}
+
+// uploadPath returns the path of the directory of the multipart upload with the given ID.
+func (s *store) uploadPath(uploadID string) (string, error) {
+	_, err := hex.DecodeString(uploadID)
+	if err != nil || len(uploadID) != 32 {
+		return "", errNoSuchUpload
+	}
+
+	return filepath.Join(s.path, uploadsDir, uploadID), nil
+}
+
+// createUpload creates a new multipart upload and returns its ID.
+func (s *store) createUpload(info *uploadInfo) (string, error) {
+	buf := make([]byte, 16)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	uploadID := hex.EncodeToString(buf)
+
+	path, err := s.mkdir(filepath.Join(uploadsDir, uploadID))
+	if err != nil {
+		return "", err
+	}
+
+	err = s.writeJSON(filepath.Join(path, "upload.json"), info)
+	if err != nil {
+		os.RemoveAll(path)
+		return "", err
+	}
+
+	return uploadID, nil
+}
+
+// getUpload returns the metadata of the multipart upload with the given ID for the given key.
+func (s *store) getUpload(key string, uploadID string) (string, *uploadInfo, error) {
+	path, err := s.uploadPath(uploadID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	info := uploadInfo{}
+	err = readJSON(filepath.Join(path, "upload.json"), &info)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil, errNoSuchUpload
+		}
+
+		return "", nil, err
+	}
+
+	if info.Key != key {
+		return "", nil, errNoSuchUpload
+	}
+
+	return path, &info, nil
+}
+
+// partPath returns the path of the given part of the multipart upload stored at uploadPath.
+func partPath(uploadPath string, partNumber int) string {
+	return filepath.Join(uploadPath, fmt.Sprintf("part.%d", partNumber))
+}
+
+// listUploads returns the IDs and metadata of the ongoing multipart uploads.
+func (s *store) listUploads() (map[string]*uploadInfo, error) {
+	entries, err := ioutil.ReadDir(filepath.Join(s.path, uploadsDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	uploads := map[string]*uploadInfo{}
+	for _, entry := range entries {
+		info := uploadInfo{}
+		err := readJSON(filepath.Join(s.path, uploadsDir, entry.Name(), "upload.json"), &info)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		uploads[entry.Name()] = &info
+	}
+
+	return uploads, nil
+}
@@ -0,0 +1,175 @@
+package s3
+
+import (
+	"encoding/xml"
+)
+
+// owner represents the owner of buckets.
+type owner struct {
+	ID          string
+	DisplayName string
+}
+
+// bucketEntry represents a bucket in bucket listings.
+type bucketEntry struct {
+	Name         string
+	CreationDate string
+}
+
+// listAllMyBucketsResult is the response of ListBuckets.
+type listAllMyBucketsResult struct {
+	XMLName xml.Name      `xml:"ListAllMyBucketsResult"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	Owner   owner         `xml:"Owner"`
+	Buckets []bucketEntry `xml:"Buckets>Bucket"`
+}
+
+// locationConstraint is the response of GetBucketLocation.
+type locationConstraint struct {
+	XMLName xml.Name `xml:"LocationConstraint"`
+	Xmlns   string   `xml:"xmlns,attr"`
+}
+
+// versioningConfiguration is the response of GetBucketVersioning, versioning is never enabled.
+type versioningConfiguration struct {
+	XMLName xml.Name `xml:"VersioningConfiguration"`
+	Xmlns   string   `xml:"xmlns,attr"`
+}
+
+// objectEntry represents an object in object listings.
+type objectEntry struct {
+	Key          string
+	LastModified string
+	ETag         string
+	Size         int64
+	StorageClass string
+}
+
+// commonPrefix represents a common prefix in object listings.
+type commonPrefix struct {
+	Prefix string
+}
+
+// listBucketResult is the response of ListObjects.
+type listBucketResult struct {
+	XMLName        xml.Name       `xml:"ListBucketResult"`
+	Xmlns          string         `xml:"xmlns,attr"`
+	Name           string         `xml:"Name"`
+	Prefix         string         `xml:"Prefix"`
+	Marker         string         `xml:"Marker"`
+	NextMarker     string         `xml:"NextMarker,omitempty"`
+	MaxKeys        int            `xml:"MaxKeys"`
+	Delimiter      string         `xml:"Delimiter,omitempty"`
+	IsTruncated    bool           `xml:"IsTruncated"`
+	EncodingType   string         `xml:"EncodingType,omitempty"`
+	Contents       []objectEntry  `xml:"Contents"`
+	CommonPrefixes []commonPrefix `xml:"CommonPrefixes"`
+}
+
+// listBucketResultV2 is the response of ListObjectsV2.
+type listBucketResultV2 struct {
+	XMLName               xml.Name       `xml:"ListBucketResult"`
+	Xmlns                 string         `xml:"xmlns,attr"`
+	Name                  string         `xml:"Name"`
+	Prefix                string         `xml:"Prefix"`
+	ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+	StartAfter            string         `xml:"StartAfter,omitempty"`
+	KeyCount              int            `xml:"KeyCount"`
+	MaxKeys               int            `xml:"MaxKeys"`
+	Delimiter             string         `xml:"Delimiter,omitempty"`
+	IsTruncated           bool           `xml:"IsTruncated"`
+	EncodingType          string         `xml:"EncodingType,omitempty"`
+	Contents              []objectEntry  `xml:"Contents"`
+	CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+}
+
+// copyObjectResult is the response of CopyObject.
+type copyObjectResult struct {
+	XMLName      xml.Name `xml:"CopyObjectResult"`
+	Xmlns        string   `xml:"xmlns,attr"`
+	LastModified string   `xml:"LastModified"`
+	ETag         string   `xml:"ETag"`
+}
+
+// objectIdentifier identifies an object to delete in DeleteObjects requests.
+type objectIdentifier struct {
+	Key string `xml:"Key"`
+}
+
+// deleteRequest is the body of DeleteObjects requests.
+type deleteRequest struct {
+	XMLName xml.Name           `xml:"Delete"`
+	Quiet   bool               `xml:"Quiet"`
+	Objects []objectIdentifier `xml:"Object"`
+}
+
+// deletedObject represents a deleted object in DeleteObjects responses.
+type deletedObject struct {
+	Key string `xml:"Key"`
+}
+
+// deleteError represents an object which couldn't be deleted in DeleteObjects responses.
+type deleteError struct {
+	Key     string `xml:"Key"`
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// deleteResult is the response of DeleteObjects.
+type deleteResult struct {
+	XMLName xml.Name        `xml:"DeleteResult"`
+	Xmlns   string          `xml:"xmlns,attr"`
+	Deleted []deletedObject `xml:"Deleted"`
+	Errors  []deleteError   `xml:"Error"`
+}
+
+// initiateMultipartUploadResult is the response of CreateMultipartUpload.
+type initiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	UploadID string   `xml:"UploadId"`
+}
+
+// completedPart represents a part in CompleteMultipartUpload requests.
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// completeMultipartUpload is the body of CompleteMultipartUpload requests.
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+// completeMultipartUploadResult is the response of CompleteMultipartUpload.
+type completeMultipartUploadResult struct {
+	XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Bucket  string   `xml:"Bucket"`
+	Key     string   `xml:"Key"`
+	ETag    string   `xml:"ETag"`
+}
+
+// upload represents a multipart upload in ListMultipartUploads responses.
+type upload struct {
+	Key          string `xml:"Key"`
+	UploadID     string `xml:"UploadId"`
+	Initiated    string `xml:"Initiated"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+// listMultipartUploadsResult is the response of ListMultipartUploads.
+type listMultipartUploadsResult struct {
+	XMLName      xml.Name `xml:"ListMultipartUploadsResult"`
+	Xmlns        string   `xml:"xmlns,attr"`
+	Bucket       string   `xml:"Bucket"`
+	Prefix       string   `xml:"Prefix"`
+	MaxUploads   int      `xml:"MaxUploads"`
+	IsTruncated  bool     `xml:"IsTruncated"`
+	EncodingType string   `xml:"EncodingType,omitempty"`
+	Uploads      []upload `xml:"Upload"`
+}
@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -208,8 +210,13 @@ func VolumeDBCreate(s *state.State, project, poolName, volumeName, volumeDescrip
 		return err
 	}
 
+	contentType, err := VolumeDBContentTypeToContentType(volDBContentType)
+	if err != nil {
+		return err
+	}
+
 	// Validate the requested storage volume configuration.
-	err = VolumeValidateConfig(s, volumeName, volType, volumeConfig, poolStruct)
+	err = VolumeValidateConfig(s, volumeName, volType, contentType, volumeConfig, poolStruct)
 	if err != nil {
 		return err
 	}
@@ -239,6 +246,14 @@ var SupportedPoolTypes = []string{"btrfs", "ceph", "cephfs", "dir", "lvm", "zfs"
 // StorageVolumeConfigKeys config validation for btrfs, ceph, cephfs, dir, lvm, zfs types.
 // Deprecated: these are being moved to the per-storage-driver implementations.
 var StorageVolumeConfigKeys = map[string]func(value string) ([]string, error){
+	"block.encryption": func(value string) ([]string, error) {
+		err := validate.IsOneOf(value, []string{"luks"})
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{"btrfs", "ceph", "dir", "lvm", "zfs"}, nil
+	},
 	"block.filesystem": func(value string) ([]string, error) {
 		err := validate.IsOneOf(value, []string{"btrfs", "ext4", "xfs"})
 		if err != nil {
@@ -293,15 +308,13 @@ var StorageVolumeConfigKeys = map[string]func(value string) ([]string, error){
 }
 
 // VolumeValidateConfig validations volume config. Deprecated.
-func VolumeValidateConfig(s *state.State, volName string, volType drivers.VolumeType, config map[string]string, parentPool *api.StoragePool) error {
+func VolumeValidateConfig(s *state.State, volName string, volType drivers.VolumeType, contentType drivers.ContentType, config map[string]string, parentPool *api.StoragePool) error {
 	logger := logging.AddContext(logger.Log, log.Ctx{"driver": parentPool.Driver, "pool": parentPool.Name})
 
 	// Validate volume config using the new driver interface if supported.
-	driver, err := drivers.Load(s, parentPool.Driver, parentPool.Name, parentPool.Config, logger, nil, commonRules())
+	driver, err := drivers.Load(s, parentPool.Driver, parentPool.Name, parentPool.Config, logger, nil, nil, commonRules())
 	if err != drivers.ErrUnknownDriver {
-		// Note: This legacy validation function doesn't have the concept of validating different content
-		// types, so it is hardcoded as ContentTypeFS.
-		return driver.ValidateVolume(drivers.NewVolume(driver, parentPool.Name, volType, drivers.ContentTypeFS, volName, config, parentPool.Config), false)
+		return driver.ValidateVolume(drivers.NewVolume(driver, parentPool.Name, volType, contentType, volName, config, parentPool.Config), false)
 	}
 
 	// Otherwise fallback to doing legacy validation.
@@ -364,6 +377,11 @@ func VolumeFillDefault(config map[string]string, parentPool *api.StoragePool) er
 			// Unchangeable volume property: Set unconditionally.
 			config["block.mount_options"] = "discard"
 		}
+
+		if config["block.encryption"] == "" && parentPool.Config["volume.block.encryption"] != "" {
+			// Unchangeable volume property: Set unconditionally.
+			config["block.encryption"] = parentPool.Config["volume.block.encryption"]
+		}
 	}
 
 	return nil
@@ -471,10 +489,11 @@ func validateVolumeCommonRules(vol drivers.Volume) map[string]func(string) error
 		rules["block.filesystem"] = validate.IsAny
 	}
 
-	// security.shifted and security.unmapped are only relevant for custom volumes.
+	// security.shifted, security.unmapped and volatile.backup.uuid are only relevant for custom volumes.
 	if vol.Type() == drivers.VolumeTypeCustom {
 		rules["security.shifted"] = validate.Optional(validate.IsBool)
 		rules["security.unmapped"] = validate.Optional(validate.IsBool)
+		rules["volatile.backup.uuid"] = validate.IsAny
 	}
 
 	// volatile.rootfs.size is only used for image volumes.
@@ -587,8 +606,14 @@ func ImageUnpack(imageFile string, vol drivers.Volume, destBlockFile string, blo
 					return -1, err
 				}
 
-				logger.Debugf("Increasing %q volume size from %d to %d to accomomdate image %q unpack", dstPath, volSizeBytes, imgInfo.VirtualSize, imgPath)
-				err = vol.SetQuota(fmt.Sprintf("%d", imgInfo.VirtualSize), nil)
+				// Encrypted volumes need room for the LUKS header on top of the image.
+				newSizeBytes := imgInfo.VirtualSize
+				if vol.IsEncrypted() {
+					newSizeBytes += drivers.LUKSHeaderSize
+				}
+
+				logger.Debugf("Increasing %q volume size from %d to %d to accomomdate image %q unpack", dstPath, volSizeBytes, newSizeBytes, imgPath)
+				err = vol.SetQuota(fmt.Sprintf("%d", newSizeBytes), nil)
 				if err != nil {
 					return -1, errors.Wrapf(err, "Error increasing volume size")
 				}
@@ -650,7 +675,15 @@ func ImageUnpack(imageFile string, vol drivers.Volume, destBlockFile string, blo
 
 		// Transfer the content excluding the destBlockFile name so that we don't delete the block file
 		// created above if the storage driver stores image files in the same directory as destPath.
-		_, err = rsync.LocalCopy(tempDir, destPath, "", true, "--exclude", filepath.Base(destBlockFile))
+		rsyncArgs := []string{"--exclude", filepath.Base(destBlockFile)}
+
+		// For encrypted volumes destBlockFile is the opened LUKS device, so also exclude the block file
+		// backing it (if any).
+		if vol.IsEncrypted() {
+			rsyncArgs = append(rsyncArgs, "--exclude", "root.img")
+		}
+
+		_, err = rsync.LocalCopy(tempDir, destPath, "", true, rsyncArgs...)
 		if err != nil {
 			return -1, err
 		}
@@ -764,6 +797,26 @@ func FallbackMigrationType(contentType drivers.ContentType) migration.MigrationF
 	return migration.MigrationFSType_RSYNC
 }
 
+// VolumeMigrationTypes returns the migration types supported by the pool for a volume of the given type, content
+// type and config. Encrypted volumes are limited to the fallback migration type, as the optimized migration types
+// send the data as stored, which can't be unlocked with the encryption key of the target volume.
+func VolumeMigrationTypes(pool Pool, volType drivers.VolumeType, contentType drivers.ContentType, volConfig map[string]string, refresh bool) []migration.Type {
+	driver := pool.Driver()
+	vol := drivers.NewVolume(driver, pool.Name(), volType, contentType, "", volConfig, driver.Config())
+	if !vol.IsEncrypted() {
+		return pool.MigrationTypes(contentType, refresh)
+	}
+
+	fallbackType := FallbackMigrationType(contentType)
+	for _, migrationType := range pool.MigrationTypes(contentType, refresh) {
+		if migrationType.FSType == fallbackType {
+			return []migration.Type{migrationType}
+		}
+	}
+
+	return []migration.Type{}
+}
+
 // RenderSnapshotUsage can be used as an optional argument to Instance.Render() to return snapshot usage.
 // As this is a relatively expensive operation it is provided as an optional feature rather than on by default.
 func RenderSnapshotUsage(s *state.State, snapInst instance.Instance) func(response interface{}) error {
@@ -809,3 +862,62 @@ func InstanceDiskBlockSize(pool Pool, inst instance.Instance, op *operations.Ope
 
 	return blockDiskSize, nil
 }
+
+// bucketNameRegexp matches the names allowed for S3 buckets.
+var bucketNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// BucketValidName validates the provided storage bucket name. As buckets are served over S3, their names must be
+// valid S3 bucket names.
+func BucketValidName(name string) error {
+	if !bucketNameRegexp.MatchString(name) || strings.Contains(name, "..") || net.ParseIP(name) != nil {
+		return fmt.Errorf("Bucket names must be between 3 and 63 characters long, consist of lowercase letters, numbers, dots and hyphens, and begin and end with a letter or number")
+	}
+
+	return nil
+}
+
+// BucketValidateConfig validates the storage bucket config.
+func BucketValidateConfig(config map[string]string) error {
+	rules := map[string]func(value string) error{
+		"size": validate.Optional(validate.IsSize),
+	}
+
+	for k, v := range config {
+		validator, ok := rules[k]
+		if !ok {
+			return fmt.Errorf("Invalid bucket configuration key: %s", k)
+		}
+
+		err := validator(v)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid value for bucket configuration key %q", k)
+		}
+	}
+
+	return nil
+}
+
+// renameVolumeBackupManifest moves the backup manifest recorded for a volume (if any) to its new name.
+func renameVolumeBackupManifest(poolName string, volType drivers.VolumeType, volName string, newVolName string) error {
+	manifestPath := drivers.GetVolumeBackupManifestPath(poolName, volType, volName)
+	newManifestPath := drivers.GetVolumeBackupManifestPath(poolName, volType, newVolName)
+
+	err := os.Rename(manifestPath, newManifestPath)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "Failed to rename %q to %q", manifestPath, newManifestPath)
+	}
+
+	return nil
+}
+
+// deleteVolumeBackupManifest removes the backup manifest recorded for a volume (if any).
+func deleteVolumeBackupManifest(poolName string, volType drivers.VolumeType, volName string) error {
+	manifestPath := drivers.GetVolumeBackupManifestPath(poolName, volType, volName)
+
+	err := os.Remove(manifestPath)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "Failed to remove %q", manifestPath)
+	}
+
+	return nil
+}
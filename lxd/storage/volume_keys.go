@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/lxc/lxd/lxd/storage/drivers"
+)
+
+// poolKeysMu serialises access to the encryption keyfiles of the storage pools.
+var poolKeysMu sync.Mutex
+
+// poolKeysPath returns the path of the file holding the encryption keys of the volumes of a pool. The keys are
+// only kept there, on the local server, rather than in the replicated database. This also allows encrypted
+// volumes to be unlocked by `lxd recover`.
+func poolKeysPath(poolName string) string {
+	return filepath.Join(drivers.GetPoolMountPath(poolName), "keys.yaml")
+}
+
+// poolKeyName returns the name under which the key of a volume is stored in the keyfile of its pool.
+func poolKeyName(volType drivers.VolumeType, volName string) string {
+	return fmt.Sprintf("%s/%s", volType, volName)
+}
+
+// loadPoolKeys reads the encryption keys of the volumes of a pool. Returns an empty map if the pool has no
+// keyfile. The caller must hold poolKeysMu.
+func loadPoolKeys(poolName string) (map[string]string, error) {
+	keys := map[string]string{}
+
+	content, err := ioutil.ReadFile(poolKeysPath(poolName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return keys, nil
+		}
+
+		return nil, errors.Wrapf(err, "Failed reading encryption keys of pool %q", poolName)
+	}
+
+	err = yaml.Unmarshal(content, &keys)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed parsing encryption keys of pool %q", poolName)
+	}
+
+	return keys, nil
+}
+
+// getPoolKey returns the encryption key of a volume from the keyfile of its pool, or an empty string if the
+// keyfile has no key for the volume.
+func getPoolKey(poolName string, volType drivers.VolumeType, volName string) (string, error) {
+	poolKeysMu.Lock()
+	defer poolKeysMu.Unlock()
+
+	keys, err := loadPoolKeys(poolName)
+	if err != nil {
+		return "", err
+	}
+
+	return keys[poolKeyName(volType, volName)], nil
+}
+
+// updatePoolKeys applies the supplied function to the encryption keys of a pool and writes the result back to
+// the pool's keyfile.
+func updatePoolKeys(poolName string, update func(keys map[string]string)) error {
+	poolKeysMu.Lock()
+	defer poolKeysMu.Unlock()
+
+	keys, err := loadPoolKeys(poolName)
+	if err != nil {
+		return err
+	}
+
+	update(keys)
+
+	content, err := yaml.Marshal(keys)
+	if err != nil {
+		return errors.Wrapf(err, "Failed encoding encryption keys of pool %q", poolName)
+	}
+
+	// Write to a temporary file first so that a failed write can't lose the existing keys.
+	path := poolKeysPath(poolName)
+	err = ioutil.WriteFile(path+".tmp", content, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "Failed writing encryption keys of pool %q", poolName)
+	}
+
+	err = os.Rename(path+".tmp", path)
+	if err != nil {
+		os.Remove(path + ".tmp")
+		return errors.Wrapf(err, "Failed writing encryption keys of pool %q", poolName)
+	}
+
+	return nil
+}
+
+// setPoolKey records the encryption key of a volume in the keyfile of its pool.
+func setPoolKey(poolName string, volType drivers.VolumeType, volName string, key string) error {
+	return updatePoolKeys(poolName, func(keys map[string]string) {
+		keys[poolKeyName(volType, volName)] = key
+	})
+}
+
+// renamePoolKey moves the encryption key of a volume to its new name in the keyfile of its pool.
+func renamePoolKey(poolName string, volType drivers.VolumeType, volName string, newVolName string) error {
+	return updatePoolKeys(poolName, func(keys map[string]string) {
+		key, ok := keys[poolKeyName(volType, volName)]
+		if !ok {
+			return
+		}
+
+		keys[poolKeyName(volType, newVolName)] = key
+		delete(keys, poolKeyName(volType, volName))
+	})
+}
+
+// deletePoolKey removes the encryption key of a volume from the keyfile of its pool.
+func deletePoolKey(poolName string, volType drivers.VolumeType, volName string) error {
+	return updatePoolKeys(poolName, func(keys map[string]string) {
+		delete(keys, poolKeyName(volType, volName))
+	})
+}
@@ -0,0 +1,552 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/node"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	"github.com/lxc/lxd/shared/version"
+)
+
+// storageBucketKeyRoles are the supported roles of storage bucket keys.
+var storageBucketKeyRoles = []string{"admin", "read-only"}
+
+var storagePoolBucketsCmd = APIEndpoint{
+	Path: "storage-pools/{name}/buckets",
+
+	Get:  APIEndpointAction{Handler: storagePoolBucketsGet, AccessHandler: allowProjectPermission("storage-volumes", "view")},
+	Post: APIEndpointAction{Handler: storagePoolBucketsPost, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+}
+
+var storagePoolBucketCmd = APIEndpoint{
+	Path: "storage-pools/{name}/buckets/{bucketName}",
+
+	Delete: APIEndpointAction{Handler: storagePoolBucketDelete, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+	Get:    APIEndpointAction{Handler: storagePoolBucketGet, AccessHandler: allowProjectPermission("storage-volumes", "view")},
+	Patch:  APIEndpointAction{Handler: storagePoolBucketPut, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+	Put:    APIEndpointAction{Handler: storagePoolBucketPut, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+}
+
+var storagePoolBucketKeysCmd = APIEndpoint{
+	Path: "storage-pools/{name}/buckets/{bucketName}/keys",
+
+	Get:  APIEndpointAction{Handler: storagePoolBucketKeysGet, AccessHandler: allowProjectPermission("storage-volumes", "view")},
+	Post: APIEndpointAction{Handler: storagePoolBucketKeysPost, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+}
+
+var storagePoolBucketKeyCmd = APIEndpoint{
+	Path: "storage-pools/{name}/buckets/{bucketName}/keys/{keyName}",
+
+	Delete: APIEndpointAction{Handler: storagePoolBucketKeyDelete, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+	Get:    APIEndpointAction{Handler: storagePoolBucketKeyGet, AccessHandler: allowProjectPermission("storage-volumes", "view")},
+	Patch:  APIEndpointAction{Handler: storagePoolBucketKeyPut, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+	Put:    APIEndpointAction{Handler: storagePoolBucketKeyPut, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+}
+
+// API endpoints
+func storagePoolBucketsGet(d *Daemon, r *http.Request) response.Response {
+	poolName := mux.Vars(r)["name"]
+	recursion := util.IsRecursionRequest(r)
+
+	projectName, err := project.StorageVolumeProject(d.State().Cluster, projectParam(r), db.StoragePoolVolumeTypeCustom)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	poolID, err := d.cluster.GetStoragePoolID(poolName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	names, err := d.cluster.GetStorageBuckets(poolID, projectName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resultString := []string{}
+	resultMap := []api.StorageBucket{}
+	for _, name := range names {
+		if !recursion {
+			resultString = append(resultString, fmt.Sprintf("/%s/storage-pools/%s/buckets/%s", version.APIVersion, poolName, name))
+		} else {
+			bucket, err := doStoragePoolBucketGet(d, poolID, projectName, name)
+			if err != nil {
+				continue
+			}
+
+			resultMap = append(resultMap, *bucket)
+		}
+	}
+
+	if !recursion {
+		return response.SyncResponse(true, resultString)
+	}
+
+	return response.SyncResponse(true, resultMap)
+}
+
+// doStoragePoolBucketGet returns the storage bucket with the given name. Its S3 URL is only filled in if the
+// bucket is held by this cluster member as it depends on the member's storage buckets address.
+func doStoragePoolBucketGet(d *Daemon, poolID int64, projectName string, name string) (*api.StorageBucket, error) {
+	bucket, err := d.cluster.GetStorageBucket(poolID, projectName, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var localName string
+	err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+		localName, err = tx.GetLocalNodeName()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if bucket.Location == localName {
+		address, err := node.StorageBucketsAddress(d.db)
+		if err != nil {
+			return nil, err
+		}
+
+		if address != "" {
+			bucket.S3URL = fmt.Sprintf("https://%s/%s", util.CanonicalNetworkAddress(address), bucket.Name)
+		}
+	}
+
+	return &bucket.StorageBucket, nil
+}
+
+func storagePoolBucketsPost(d *Daemon, r *http.Request) response.Response {
+	poolName := mux.Vars(r)["name"]
+
+	projectName, err := project.StorageVolumeProject(d.State().Cluster, projectParam(r), db.StoragePoolVolumeTypeCustom)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	req := api.StorageBucketsPost{}
+
+	// Parse the request.
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = storagePools.BucketValidName(req.Name)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Bucket names are unique across all pools and projects as they share the S3 namespace.
+	exists, err := d.cluster.StorageBucketExists(req.Name)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	if exists {
+		return response.Conflict(fmt.Errorf("Storage bucket %q already exists, bucket names must be unique across all projects and storage pools", req.Name))
+	}
+
+	if req.Config == nil {
+		req.Config = map[string]string{}
+	}
+
+	pool, err := storagePools.GetPoolByName(d.State(), poolName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = pool.CreateBucket(projectName, req, nil)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponseLocation(true, nil, fmt.Sprintf("/%s/storage-pools/%s/buckets/%s", version.APIVersion, poolName, req.Name))
+}
+
+func storagePoolBucketGet(d *Daemon, r *http.Request) response.Response {
+	poolName := mux.Vars(r)["name"]
+	bucketName := mux.Vars(r)["bucketName"]
+
+	projectName, err := project.StorageVolumeProject(d.State().Cluster, projectParam(r), db.StoragePoolVolumeTypeCustom)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	poolID, err := d.cluster.GetStoragePoolID(poolName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp := forwardedResponseIfBucketIsRemote(d, r, poolID, projectName, bucketName)
+	if resp != nil {
+		return resp
+	}
+
+	bucket, err := doStoragePoolBucketGet(d, poolID, projectName, bucketName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	etag := []interface{}{bucket.Name, bucket.Description, bucket.Config}
+
+	return response.SyncResponseETag(true, bucket, etag)
+}
+
+func storagePoolBucketPut(d *Daemon, r *http.Request) response.Response {
+	poolName := mux.Vars(r)["name"]
+	bucketName := mux.Vars(r)["bucketName"]
+
+	projectName, err := project.StorageVolumeProject(d.State().Cluster, projectParam(r), db.StoragePoolVolumeTypeCustom)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	poolID, err := d.cluster.GetStoragePoolID(poolName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp := forwardedResponseIfBucketIsRemote(d, r, poolID, projectName, bucketName)
+	if resp != nil {
+		return resp
+	}
+
+	// Get the existing bucket.
+	bucket, err := d.cluster.GetStorageBucket(poolID, projectName, bucketName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Validate the ETag.
+	etag := []interface{}{bucket.Name, bucket.Description, bucket.Config}
+	err = util.EtagCheck(r, etag)
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	// Decode the request.
+	req := api.StorageBucketPut{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if r.Method == http.MethodPatch {
+		// Only replace the fields that were provided.
+		if req.Description == "" {
+			req.Description = bucket.Description
+		}
+
+		if req.Config == nil {
+			req.Config = map[string]string{}
+		}
+
+		for k, v := range bucket.Config {
+			_, ok := req.Config[k]
+			if !ok {
+				req.Config[k] = v
+			}
+		}
+	}
+
+	pool, err := storagePools.GetPoolByName(d.State(), poolName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = pool.UpdateBucket(projectName, bucketName, req, nil)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+func storagePoolBucketDelete(d *Daemon, r *http.Request) response.Response {
+	poolName := mux.Vars(r)["name"]
+	bucketName := mux.Vars(r)["bucketName"]
+
+	projectName, err := project.StorageVolumeProject(d.State().Cluster, projectParam(r), db.StoragePoolVolumeTypeCustom)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	poolID, err := d.cluster.GetStoragePoolID(poolName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resp := forwardedResponseIfBucketIsRemote(d, r, poolID, projectName, bucketName)
+	if resp != nil {
+		return resp
+	}
+
+	pool, err := storagePools.GetPoolByName(d.State(), poolName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = pool.DeleteBucket(projectName, bucketName, nil)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+// storagePoolBucketFromRequest returns the storage bucket referenced by the request, or a response forwarding
+// the request to the cluster member holding the bucket.
+func storagePoolBucketFromRequest(d *Daemon, r *http.Request) (*db.StorageBucket, response.Response) {
+	poolName := mux.Vars(r)["name"]
+	bucketName := mux.Vars(r)["bucketName"]
+
+	projectName, err := project.StorageVolumeProject(d.State().Cluster, projectParam(r), db.StoragePoolVolumeTypeCustom)
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	poolID, err := d.cluster.GetStoragePoolID(poolName)
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	resp := forwardedResponseIfBucketIsRemote(d, r, poolID, projectName, bucketName)
+	if resp != nil {
+		return nil, resp
+	}
+
+	bucket, err := d.cluster.GetStorageBucket(poolID, projectName, bucketName)
+	if err != nil {
+		return nil, response.SmartError(err)
+	}
+
+	return bucket, nil
+}
+
+func storagePoolBucketKeysGet(d *Daemon, r *http.Request) response.Response {
+	recursion := util.IsRecursionRequest(r)
+
+	bucket, resp := storagePoolBucketFromRequest(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	names, err := d.cluster.GetStorageBucketKeys(bucket.ID)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resultString := []string{}
+	resultMap := []api.StorageBucketKey{}
+	for _, name := range names {
+		if !recursion {
+			resultString = append(resultString, fmt.Sprintf("/%s/storage-pools/%s/buckets/%s/keys/%s", version.APIVersion, bucket.PoolName, bucket.Name, name))
+		} else {
+			_, key, err := d.cluster.GetStorageBucketKey(bucket.ID, name)
+			if err != nil {
+				continue
+			}
+
+			resultMap = append(resultMap, *key)
+		}
+	}
+
+	if !recursion {
+		return response.SyncResponse(true, resultString)
+	}
+
+	return response.SyncResponse(true, resultMap)
+}
+
+// storageBucketKeyGenerate returns a new random key of the given length in bytes, encoded as hex.
+func storageBucketKeyGenerate(length int) (string, error) {
+	buf := make([]byte, length)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
+
+// storageBucketKeyValidate validates the fields of a storage bucket key, generating missing access and secret keys.
+func storageBucketKeyValidate(key *api.StorageBucketKeyPut) error {
+	var err error
+
+	if key.Role == "" {
+		key.Role = "admin"
+	}
+
+	if !shared.StringInSlice(key.Role, storageBucketKeyRoles) {
+		return fmt.Errorf("Invalid key role %q", key.Role)
+	}
+
+	if key.AccessKey == "" {
+		key.AccessKey, err = storageBucketKeyGenerate(10)
+		if err != nil {
+			return err
+		}
+	}
+
+	if key.SecretKey == "" {
+		key.SecretKey, err = storageBucketKeyGenerate(20)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func storagePoolBucketKeysPost(d *Daemon, r *http.Request) response.Response {
+	bucket, resp := storagePoolBucketFromRequest(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	req := api.StorageBucketKeysPost{}
+
+	// Parse the request.
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if req.Name == "" {
+		return response.BadRequest(fmt.Errorf("No name provided"))
+	}
+
+	err = storageBucketKeyValidate(&req.StorageBucketKeyPut)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	_, err = d.cluster.CreateStorageBucketKey(bucket.ID, &req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	key := api.StorageBucketKey{
+		StorageBucketKeyPut: req.StorageBucketKeyPut,
+		Name:                req.Name,
+	}
+
+	return response.SyncResponseLocation(true, key, fmt.Sprintf("/%s/storage-pools/%s/buckets/%s/keys/%s", version.APIVersion, bucket.PoolName, bucket.Name, req.Name))
+}
+
+func storagePoolBucketKeyGet(d *Daemon, r *http.Request) response.Response {
+	keyName := mux.Vars(r)["keyName"]
+
+	bucket, resp := storagePoolBucketFromRequest(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	_, key, err := d.cluster.GetStorageBucketKey(bucket.ID, keyName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	etag := []interface{}{key.Name, key.Description, key.Role, key.AccessKey, key.SecretKey}
+
+	return response.SyncResponseETag(true, key, etag)
+}
+
+func storagePoolBucketKeyPut(d *Daemon, r *http.Request) response.Response {
+	keyName := mux.Vars(r)["keyName"]
+
+	bucket, resp := storagePoolBucketFromRequest(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	// Get the existing key.
+	id, key, err := d.cluster.GetStorageBucketKey(bucket.ID, keyName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Validate the ETag.
+	etag := []interface{}{key.Name, key.Description, key.Role, key.AccessKey, key.SecretKey}
+	err = util.EtagCheck(r, etag)
+	if err != nil {
+		return response.PreconditionFailed(err)
+	}
+
+	// Decode the request.
+	req := api.StorageBucketKeyPut{}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if r.Method == http.MethodPatch {
+		// Only replace the fields that were provided.
+		if req.Description == "" {
+			req.Description = key.Description
+		}
+
+		if req.Role == "" {
+			req.Role = key.Role
+		}
+
+		if req.AccessKey == "" {
+			req.AccessKey = key.AccessKey
+		}
+
+		if req.SecretKey == "" {
+			req.SecretKey = key.SecretKey
+		}
+	}
+
+	err = storageBucketKeyValidate(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	err = d.cluster.UpdateStorageBucketKey(id, &req)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
+
+func storagePoolBucketKeyDelete(d *Daemon, r *http.Request) response.Response {
+	keyName := mux.Vars(r)["keyName"]
+
+	bucket, resp := storagePoolBucketFromRequest(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	id, _, err := d.cluster.GetStorageBucketKey(bucket.ID, keyName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = d.cluster.DeleteStorageBucketKey(id)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.EmptySyncResponse
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/state"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/lxd/storage/s3"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+)
+
+// storageBucketsServer creates an http.Server serving the storage buckets held by this member over the S3 API.
+func storageBucketsServer(d *Daemon) *http.Server {
+	// setupDone returns whether the daemon is ready to serve buckets.
+	setupDone := func() bool {
+		select {
+		case <-d.setupChan:
+			return true
+		default:
+			return false
+		}
+	}
+
+	buckets := func(name string) (*s3.Bucket, error) {
+		if !setupDone() {
+			return nil, s3.ErrServiceUnavailable
+		}
+
+		bucket, err := d.cluster.GetLocalStorageBucket(name)
+		if err != nil {
+			if err == db.ErrNoSuchObject {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+
+		pool, err := storagePools.GetPoolByName(d.State(), bucket.PoolName)
+		if err != nil {
+			return nil, err
+		}
+
+		// The bucket volumes are kept mounted by storageBucketsMount.
+		return &s3.Bucket{Name: bucket.Name, Path: pool.GetBucketPath(bucket.ProjectName, bucket.Name)}, nil
+	}
+
+	credentials := func(accessKey string) (*s3.Credential, error) {
+		if !setupDone() {
+			return nil, s3.ErrServiceUnavailable
+		}
+
+		bucketName, key, err := d.cluster.GetLocalStorageBucketKeyByAccessKey(accessKey)
+		if err != nil {
+			if err == db.ErrNoSuchObject {
+				return nil, nil
+			}
+
+			return nil, err
+		}
+
+		return &s3.Credential{
+			AccessKey: key.AccessKey,
+			SecretKey: key.SecretKey,
+			Bucket:    bucketName,
+			ReadOnly:  key.Role == "read-only",
+		}, nil
+	}
+
+	return &http.Server{Handler: s3.NewHandler(buckets, credentials)}
+}
+
+// storageBucketsMount mounts the volumes of the storage buckets held by this member, so that they can be served
+// without mounting them on each request. Buckets which fail to mount are logged and skipped.
+func storageBucketsMount(s *state.State) error {
+	buckets, err := s.Cluster.GetLocalStorageBuckets()
+	if err != nil {
+		return errors.Wrap(err, "Failed loading storage buckets")
+	}
+
+	for _, bucket := range buckets {
+		pool, err := storagePools.GetPoolByName(s, bucket.PoolName)
+		if err != nil {
+			logger.Warn("Failed loading storage pool of bucket", log.Ctx{"bucket": bucket.Name, "pool": bucket.PoolName, "err": err})
+			continue
+		}
+
+		err = pool.MountBucket(bucket.ProjectName, bucket.Name, nil)
+		if err != nil {
+			logger.Warn("Failed mounting storage bucket", log.Ctx{"bucket": bucket.Name, "pool": bucket.PoolName, "err": err})
+		}
+	}
+
+	return nil
+}
+
+// storageBucketsUnmountFunc returns a function unmounting the volumes of the storage buckets held by this member.
+// The buckets are loaded upfront so that the returned function can be called once the database is closed.
+func storageBucketsUnmountFunc(s *state.State) (func() error, error) {
+	buckets, err := s.Cluster.GetLocalStorageBuckets()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed loading storage buckets")
+	}
+
+	pools := make([]storagePools.Pool, 0, len(buckets))
+	for _, bucket := range buckets {
+		pool, err := storagePools.GetPoolByName(s, bucket.PoolName)
+		if err != nil {
+			return nil, err
+		}
+
+		pools = append(pools, pool)
+	}
+
+	return func() error {
+		errs := []error{}
+		for i, bucket := range buckets {
+			err := pools[i].UnmountBucket(bucket.ProjectName, bucket.Name, nil)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if len(errs) > 0 {
+			return fmt.Errorf("%v", errs)
+		}
+
+		return nil
+	}, nil
+}
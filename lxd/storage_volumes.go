@@ -279,6 +279,20 @@ func storagePoolVolumesTypePost(d *Daemon, r *http.Request) response.Response {
 		return resp
 	}
 
+	// If we're getting binary content, process separately.
+	if r.Header.Get("Content-Type") == "application/octet-stream" {
+		if mux.Vars(r)["type"] != db.StoragePoolVolumeTypeNameCustom {
+			return response.BadRequest(fmt.Errorf("Backups can only be imported as custom volumes"))
+		}
+
+		projectName, err := project.StorageVolumeProject(d.State().Cluster, projectParam(r), db.StoragePoolVolumeTypeCustom)
+		if err != nil {
+			return response.SmartError(err)
+		}
+
+		return createStoragePoolVolumeFromBackup(d, projectName, mux.Vars(r)["name"], r.Body, r.Header.Get("X-LXD-name"))
+	}
+
 	req := api.StorageVolumesPost{}
 
 	// Parse the request.
@@ -967,6 +981,15 @@ func storagePoolVolumeTypePut(d *Daemon, r *http.Request, volumeTypeName string)
 			}
 		}
 
+		if req.Config == nil {
+			req.Config = map[string]string{}
+		}
+
+		err = storagePoolVolumeKeepBackupUUID(vol.Config, req.Config)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+
 		// Handle custom volume update requests.
 		err = pool.UpdateCustomVolume(projectName, vol.Name, req.Description, req.Config, nil)
 		if err != nil {
@@ -1098,6 +1121,11 @@ func storagePoolVolumeTypePatch(d *Daemon, r *http.Request, volumeTypeName strin
 		}
 	}
 
+	err = storagePoolVolumeKeepBackupUUID(vol.Config, req.Config)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
 	err = pool.UpdateCustomVolume(projectName, vol.Name, req.Description, req.Config, nil)
 	if err != nil {
 		return response.SmartError(err)
@@ -1215,3 +1243,21 @@ func storagePoolVolumeTypeCustomDelete(d *Daemon, r *http.Request) response.Resp
 func storagePoolVolumeTypeImageDelete(d *Daemon, r *http.Request) response.Response {
 	return storagePoolVolumeTypeDelete(d, r, "image")
 }
+
+// storagePoolVolumeKeepBackupUUID checks that a volume update doesn't change the volatile.backup.uuid key, which
+// records the backup the volume was restored from and is only set by LXD itself, and keeps its current value if
+// omitted.
+func storagePoolVolumeKeepBackupUUID(curConfig map[string]string, newConfig map[string]string) error {
+	curUUID := curConfig["volatile.backup.uuid"]
+
+	newUUID, ok := newConfig["volatile.backup.uuid"]
+	if ok && newUUID != curUUID {
+		return fmt.Errorf("Config key %q is read-only", "volatile.backup.uuid")
+	}
+
+	if curUUID != "" {
+		newConfig["volatile.backup.uuid"] = curUUID
+	}
+
+	return nil
+}
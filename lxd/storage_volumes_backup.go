@@ -0,0 +1,557 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/lxc/lxd/lxd/backup"
+	"github.com/lxc/lxd/lxd/db"
+	"github.com/lxc/lxd/lxd/operations"
+	"github.com/lxc/lxd/lxd/project"
+	"github.com/lxc/lxd/lxd/response"
+	"github.com/lxc/lxd/lxd/revert"
+	storagePools "github.com/lxc/lxd/lxd/storage"
+	"github.com/lxc/lxd/lxd/util"
+	"github.com/lxc/lxd/shared"
+	"github.com/lxc/lxd/shared/api"
+	log "github.com/lxc/lxd/shared/log15"
+	"github.com/lxc/lxd/shared/logger"
+	"github.com/lxc/lxd/shared/version"
+)
+
+var storagePoolVolumeTypeCustomBackupsCmd = APIEndpoint{
+	Path: "storage-pools/{pool}/volumes/{type}/{name}/backups",
+
+	Get:  APIEndpointAction{Handler: storagePoolVolumeTypeCustomBackupsGet, AccessHandler: allowProjectPermission("storage-volumes", "view")},
+	Post: APIEndpointAction{Handler: storagePoolVolumeTypeCustomBackupsPost, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+}
+
+var storagePoolVolumeTypeCustomBackupCmd = APIEndpoint{
+	Path: "storage-pools/{pool}/volumes/{type}/{name}/backups/{backupName}",
+
+	Delete: APIEndpointAction{Handler: storagePoolVolumeTypeCustomBackupDelete, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+	Get:    APIEndpointAction{Handler: storagePoolVolumeTypeCustomBackupGet, AccessHandler: allowProjectPermission("storage-volumes", "view")},
+	Post:   APIEndpointAction{Handler: storagePoolVolumeTypeCustomBackupPost, AccessHandler: allowProjectPermission("storage-volumes", "manage-storage-volumes")},
+}
+
+var storagePoolVolumeTypeCustomBackupExportCmd = APIEndpoint{
+	Path: "storage-pools/{pool}/volumes/{type}/{name}/backups/{backupName}/export",
+
+	Get: APIEndpointAction{Handler: storagePoolVolumeTypeCustomBackupExportGet, AccessHandler: allowProjectPermission("storage-volumes", "view")},
+}
+
+// storagePoolVolumeBackupVolumeGet parses the common request fields of the custom volume backup endpoints
+// and returns the project, pool and volume names along with the volume ID, or a response to return if the request should be
+// forwarded to another node or is invalid.
+func storagePoolVolumeBackupVolumeGet(d *Daemon, r *http.Request) (string, string, string, int64, response.Response) {
+	// Get the name of the storage pool the volume is supposed to be attached to.
+	poolName := mux.Vars(r)["pool"]
+
+	// Get the name of the volume type.
+	volumeTypeName := mux.Vars(r)["type"]
+
+	// Get the name of the storage volume.
+	volumeName := mux.Vars(r)["name"]
+
+	// Convert the volume type name to our internal integer representation.
+	volumeType, err := storagePools.VolumeTypeNameToType(volumeTypeName)
+	if err != nil {
+		return "", "", "", -1, response.BadRequest(err)
+	}
+
+	// Check that the storage volume type is valid.
+	if volumeType != db.StoragePoolVolumeTypeCustom {
+		return "", "", "", -1, response.BadRequest(fmt.Errorf("Invalid storage volume type %q", volumeTypeName))
+	}
+
+	projectName, err := project.StorageVolumeProject(d.State().Cluster, projectParam(r), volumeType)
+	if err != nil {
+		return "", "", "", -1, response.SmartError(err)
+	}
+
+	// Retrieve ID of the storage pool (and check if the storage pool exists).
+	poolID, err := d.cluster.GetStoragePoolID(poolName)
+	if err != nil {
+		return "", "", "", -1, response.SmartError(err)
+	}
+
+	resp := forwardedResponseIfTargetIsRemote(d, r)
+	if resp != nil {
+		return "", "", "", -1, resp
+	}
+
+	resp = forwardedResponseIfVolumeIsRemote(d, r, poolID, volumeName, volumeType)
+	if resp != nil {
+		return "", "", "", -1, resp
+	}
+
+	volumeID, _, err := d.cluster.GetLocalStoragePoolVolume(projectName, volumeName, volumeType, poolID)
+	if err != nil {
+		return "", "", "", -1, response.SmartError(err)
+	}
+
+	return projectName, poolName, volumeName, volumeID, nil
+}
+
+func storagePoolVolumeTypeCustomBackupsGet(d *Daemon, r *http.Request) response.Response {
+	_, poolName, volumeName, volumeID, resp := storagePoolVolumeBackupVolumeGet(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	recursion := util.IsRecursionRequest(r)
+
+	backups, err := backup.VolumeBackupsLoad(d.State(), volumeID)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	resultString := []string{}
+	resultMap := []*api.StoragePoolVolumeBackup{}
+
+	for _, volBackup := range backups {
+		if !recursion {
+			url := fmt.Sprintf("/%s/storage-pools/%s/volumes/custom/%s/backups/%s",
+				version.APIVersion, poolName, volumeName, strings.Split(volBackup.Name(), "/")[1])
+			resultString = append(resultString, url)
+		} else {
+			resultMap = append(resultMap, volBackup.Render())
+		}
+	}
+
+	if !recursion {
+		return response.SyncResponse(true, resultString)
+	}
+
+	return response.SyncResponse(true, resultMap)
+}
+
+func storagePoolVolumeTypeCustomBackupsPost(d *Daemon, r *http.Request) response.Response {
+	projectName, poolName, volumeName, volumeID, resp := storagePoolVolumeBackupVolumeGet(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	rj := shared.Jmap{}
+	err := json.NewDecoder(r.Body).Decode(&rj)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	expiry, _ := rj.GetString("expires_at")
+	if expiry == "" {
+		// Disable expiration by setting it to zero time.
+		rj["expires_at"] = time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	// Create body with correct expiry.
+	body, err := json.Marshal(rj)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	req := api.StoragePoolVolumeBackupsPost{}
+
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if req.Name == "" {
+		// come up with a name.
+		backups, err := backup.VolumeBackupsLoad(d.State(), volumeID)
+		if err != nil {
+			return response.BadRequest(err)
+		}
+
+		base := volumeName + shared.SnapshotDelimiter + "backup"
+		length := len(base)
+		max := 0
+
+		for _, volBackup := range backups {
+			// Ignore backups not containing base.
+			if !strings.HasPrefix(volBackup.Name(), base) {
+				continue
+			}
+
+			substr := volBackup.Name()[length:]
+			var num int
+			count, err := fmt.Sscanf(substr, "%d", &num)
+			if err != nil || count != 1 {
+				continue
+			}
+			if num >= max {
+				max = num + 1
+			}
+		}
+
+		req.Name = fmt.Sprintf("backup%d", max)
+	}
+
+	// Validate the name.
+	if strings.Contains(req.Name, "/") {
+		return response.BadRequest(fmt.Errorf("Backup names may not contain slashes"))
+	}
+
+	fullName := volumeName + shared.SnapshotDelimiter + req.Name
+	volumeOnly := req.VolumeOnly
+
+	// Incremental backups never include snapshots.
+	parentName := ""
+	if req.Parent != "" {
+		if strings.Contains(req.Parent, "/") {
+			return response.BadRequest(fmt.Errorf("Backup names may not contain slashes"))
+		}
+
+		parentName = volumeName + shared.SnapshotDelimiter + req.Parent
+		volumeOnly = true
+	}
+
+	run := func(op *operations.Operation) error {
+		args := db.StoragePoolVolumeBackup{
+			Name:                 fullName,
+			VolumeID:             volumeID,
+			CreationDate:         time.Now(),
+			ExpiryDate:           req.ExpiresAt,
+			VolumeOnly:           volumeOnly,
+			OptimizedStorage:     req.OptimizedStorage,
+			CompressionAlgorithm: req.CompressionAlgorithm,
+			Parent:               parentName,
+		}
+
+		err := volumeBackupCreate(d.State(), args, projectName, poolName, volumeName, req.AllowPlaintext)
+		if err != nil {
+			return errors.Wrap(err, "Create volume backup")
+		}
+
+		return nil
+	}
+
+	resources := map[string][]string{}
+	resources["storage_volumes"] = []string{volumeName}
+	resources["backups"] = []string{req.Name}
+
+	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationCustomVolumeBackupCreate, resources, nil, run, nil, nil)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+func storagePoolVolumeTypeCustomBackupGet(d *Daemon, r *http.Request) response.Response {
+	_, _, volumeName, volumeID, resp := storagePoolVolumeBackupVolumeGet(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	backupName := mux.Vars(r)["backupName"]
+
+	fullName := volumeName + shared.SnapshotDelimiter + backupName
+	volBackup, err := backup.VolumeBackupLoadByName(d.State(), volumeID, fullName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	return response.SyncResponse(true, volBackup.Render())
+}
+
+func storagePoolVolumeTypeCustomBackupPost(d *Daemon, r *http.Request) response.Response {
+	projectName, _, volumeName, volumeID, resp := storagePoolVolumeBackupVolumeGet(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	backupName := mux.Vars(r)["backupName"]
+
+	req := api.StoragePoolVolumeBackupPost{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	// Validate the name.
+	if req.Name == "" {
+		return response.BadRequest(fmt.Errorf("No name provided"))
+	}
+
+	if strings.Contains(req.Name, "/") {
+		return response.BadRequest(fmt.Errorf("Backup names may not contain slashes"))
+	}
+
+	oldName := volumeName + shared.SnapshotDelimiter + backupName
+	volBackup, err := backup.VolumeBackupLoadByName(d.State(), volumeID, oldName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	newName := volumeName + shared.SnapshotDelimiter + req.Name
+
+	rename := func(op *operations.Operation) error {
+		return volBackup.Rename(newName)
+	}
+
+	resources := map[string][]string{}
+	resources["storage_volumes"] = []string{volumeName}
+
+	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationCustomVolumeBackupRename, resources, nil, rename, nil, nil)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+func storagePoolVolumeTypeCustomBackupDelete(d *Daemon, r *http.Request) response.Response {
+	projectName, poolName, volumeName, volumeID, resp := storagePoolVolumeBackupVolumeGet(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	backupName := mux.Vars(r)["backupName"]
+
+	fullName := volumeName + shared.SnapshotDelimiter + backupName
+	volBackup, err := backup.VolumeBackupLoadByName(d.State(), volumeID, fullName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	err = volumeBackupCheckNoChildren(d.State(), volumeID, fullName, volBackup.UUID())
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	remove := func(op *operations.Operation) error {
+		err := volumeBackupDeleteBase(d.State(), projectName, poolName, volBackup)
+		if err != nil {
+			return err
+		}
+
+		return volBackup.Delete()
+	}
+
+	resources := map[string][]string{}
+	resources["storage_volumes"] = []string{volumeName}
+
+	op, err := operations.OperationCreate(d.State(), projectName, operations.OperationClassTask, db.OperationCustomVolumeBackupRemove, resources, nil, remove, nil, nil)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	return operations.OperationResponse(op)
+}
+
+func storagePoolVolumeTypeCustomBackupExportGet(d *Daemon, r *http.Request) response.Response {
+	_, _, volumeName, volumeID, resp := storagePoolVolumeBackupVolumeGet(d, r)
+	if resp != nil {
+		return resp
+	}
+
+	backupName := mux.Vars(r)["backupName"]
+
+	fullName := volumeName + shared.SnapshotDelimiter + backupName
+	volBackup, err := backup.VolumeBackupLoadByName(d.State(), volumeID, fullName)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	ent := response.FileResponseEntry{
+		Path: volBackup.Path(),
+	}
+
+	return response.FileResponse(r, []response.FileResponseEntry{ent}, nil, false)
+}
+
+// createStoragePoolVolumeFromBackup restores a custom volume from an uploaded backup tarball.
+func createStoragePoolVolumeFromBackup(d *Daemon, projectName string, poolName string, data io.Reader, volumeName string) response.Response {
+	revert := revert.New()
+	defer revert.Fail()
+
+	// Create temporary file to store uploaded backup data.
+	backupFile, err := ioutil.TempFile(shared.VarPath("backups"), "lxd_backup_")
+	if err != nil {
+		return response.InternalError(err)
+	}
+	defer os.Remove(backupFile.Name())
+	revert.Add(func() { backupFile.Close() })
+
+	// Stream uploaded backup data into temporary file.
+	_, err = io.Copy(backupFile, data)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	// Detect squashfs compression and convert to tarball.
+	backupFile.Seek(0, 0)
+	_, algo, decomArgs, err := shared.DetectCompressionFile(backupFile)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	if algo == ".squashfs" {
+		// Pass the temporary file as program argument to the decompression command.
+		decomArgs := append(decomArgs, backupFile.Name())
+
+		// Create temporary file to store the decompressed tarball in.
+		tarFile, err := ioutil.TempFile(shared.VarPath("backups"), "lxd_backup_decompress_")
+		if err != nil {
+			return response.InternalError(err)
+		}
+		defer os.Remove(tarFile.Name())
+
+		// Decompress to tarData temporary file.
+		err = shared.RunCommandWithFds(nil, tarFile, decomArgs[0], decomArgs[1:]...)
+		if err != nil {
+			return response.InternalError(err)
+		}
+
+		// We don't need the original squashfs file anymore.
+		backupFile.Close()
+		os.Remove(backupFile.Name())
+
+		// Replace the backup file handle with the handle to the tar file.
+		backupFile = tarFile
+	}
+
+	// Parse the backup information.
+	backupFile.Seek(0, 0)
+	logger.Debug("Reading backup file info")
+	bInfo, err := backup.GetInfo(backupFile)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	if bInfo.Type != backup.TypeCustom {
+		return response.BadRequest(fmt.Errorf("Backup isn't of a custom volume"))
+	}
+
+	bInfo.Project = projectName
+	bInfo.Pool = poolName
+
+	// Override volume name.
+	if volumeName != "" {
+		bInfo.Name = volumeName
+	}
+
+	err = storagePools.ValidName(bInfo.Name)
+	if err != nil {
+		return response.BadRequest(err)
+	}
+
+	logger.Debug("Backup file info loaded", log.Ctx{
+		"type":      bInfo.Type,
+		"name":      bInfo.Name,
+		"project":   bInfo.Project,
+		"backend":   bInfo.Backend,
+		"pool":      bInfo.Pool,
+		"optimized": *bInfo.OptimizedStorage,
+		"snapshots": bInfo.Snapshots,
+	})
+
+	pool, err := storagePools.GetPoolByName(d.State(), bInfo.Pool)
+	if err != nil {
+		return response.SmartError(err)
+	}
+
+	// Check if the backup is optimized that the source pool driver matches the target pool driver.
+	if *bInfo.OptimizedStorage && pool.Driver().Info().Name != bInfo.Backend {
+		return response.BadRequest(fmt.Errorf("Optimized backup storage driver %q differs from the target storage pool driver %q", bInfo.Backend, pool.Driver().Info().Name))
+	}
+
+	// Record the backup the volume is restored from, so that its incremental backups can be applied later on.
+	if bInfo.Volume != nil && bInfo.UUID != "" {
+		if bInfo.Volume.Config == nil {
+			bInfo.Volume.Config = map[string]string{}
+		}
+
+		bInfo.Volume.Config["volatile.backup.uuid"] = bInfo.UUID
+	}
+
+	_, vol, err := d.cluster.GetLocalStoragePoolVolume(bInfo.Project, bInfo.Name, db.StoragePoolVolumeTypeCustom, pool.ID())
+	if bInfo.Parent != "" {
+		// Incremental backups are applied onto the volume restored from their parent.
+		if err != nil {
+			if err == db.ErrNoSuchObject {
+				return response.BadRequest(fmt.Errorf("The parent of the incremental backup must be restored first"))
+			}
+
+			return response.SmartError(err)
+		}
+
+		if vol.Config["volatile.backup.uuid"] != bInfo.Parent {
+			return response.BadRequest(fmt.Errorf("Volume %q wasn't restored from the parent of the incremental backup", bInfo.Name))
+		}
+	} else {
+		// Check the volume doesn't exist already.
+		if err != db.ErrNoSuchObject {
+			if err != nil {
+				return response.SmartError(err)
+			}
+
+			return response.Conflict(fmt.Errorf("Volume by that name already exists"))
+		}
+
+		// Check the project limits allow the new volume.
+		req := api.StorageVolumesPost{
+			Name: bInfo.Name,
+			Type: db.StoragePoolVolumeTypeNameCustom,
+		}
+
+		if bInfo.Volume != nil {
+			req.Config = bInfo.Volume.Config
+		}
+
+		err = d.cluster.Transaction(func(tx *db.ClusterTx) error {
+			return project.AllowVolumeCreation(tx, bInfo.Project, req)
+		})
+		if err != nil {
+			return response.SmartError(err)
+		}
+	}
+
+	// Copy reverter so far so we can use it inside run after this function has finished.
+	runRevert := revert.Clone()
+
+	run := func(op *operations.Operation) error {
+		defer backupFile.Close()
+		defer runRevert.Fail()
+
+		if bInfo.Parent != "" {
+			err := pool.RefreshCustomVolumeFromBackup(*bInfo, backupFile, op)
+			if err != nil {
+				return errors.Wrap(err, "Refresh custom volume from incremental backup")
+			}
+
+			runRevert.Success()
+			return nil
+		}
+
+		err := pool.CreateCustomVolumeFromBackup(*bInfo, backupFile, op)
+		if err != nil {
+			return errors.Wrap(err, "Create custom volume from backup")
+		}
+
+		runRevert.Success()
+		return nil
+	}
+
+	resources := map[string][]string{}
+	resources["storage_volumes"] = []string{bInfo.Name}
+
+	op, err := operations.OperationCreate(d.State(), bInfo.Project, operations.OperationClassTask, db.OperationCustomVolumeBackupRestore, resources, nil, run, nil, nil)
+	if err != nil {
+		return response.InternalError(err)
+	}
+
+	revert.Success()
+	return operations.OperationResponse(op)
+}
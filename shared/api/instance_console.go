@@ -18,3 +18,14 @@ type InstanceConsolePost struct {
 	// API extension: console_vga_type
 	Type string `json:"type" yaml:"type"`
 }
+
+// InstanceConsoleKeysPost represents keys to press on the VGA console of a LXD virtual machine.
+//
+// API extension: console_vga_screenshot
+type InstanceConsoleKeysPost struct {
+	// QEMU key codes of the keys to press at the same time (e.g. "ctrl", "alt", "delete")
+	Keys []string `json:"keys" yaml:"keys"`
+
+	// How long to hold the keys down for in milliseconds (0 for the default)
+	HoldTime int `json:"hold_time" yaml:"hold_time"`
+}
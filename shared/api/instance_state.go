@@ -36,6 +36,10 @@ type InstanceStateDisk struct {
 // API extension: instances
 type InstanceStateCPU struct {
 	Usage int64 `json:"usage" yaml:"usage"`
+
+	// Number of vCPUs currently available to a virtual machine
+	// API extension: vm_live_resize
+	Count int64 `json:"count" yaml:"count"`
 }
 
 // InstanceStateMemory represents the memory information section of a LXD instance's state.
@@ -46,6 +50,10 @@ type InstanceStateMemory struct {
 	UsagePeak     int64 `json:"usage_peak" yaml:"usage_peak"`
 	SwapUsage     int64 `json:"swap_usage" yaml:"swap_usage"`
 	SwapUsagePeak int64 `json:"swap_usage_peak" yaml:"swap_usage_peak"`
+
+	// Memory currently available to a virtual machine
+	// API extension: vm_live_resize
+	Total int64 `json:"total" yaml:"total"`
 }
 
 // InstanceStateNetwork represents the network information section of a LXD instance's state.
@@ -73,6 +73,16 @@ type NetworkLease struct {
 	Location string `json:"location" yaml:"location"`
 }
 
+// NetworkLeasesPost represents the fields of a new DHCP reservation
+//
+// API extension: network_leases_reservations
+type NetworkLeasesPost struct {
+	Hwaddr      string `json:"hwaddr" yaml:"hwaddr"`
+	Hostname    string `json:"hostname" yaml:"hostname"`
+	IPv4Address string `json:"ipv4_address" yaml:"ipv4_address"`
+	IPv6Address string `json:"ipv6_address" yaml:"ipv6_address"`
+}
+
 // NetworkState represents the network state
 type NetworkState struct {
 	Addresses []NetworkStateAddress `json:"addresses" yaml:"addresses"`
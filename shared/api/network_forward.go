@@ -0,0 +1,44 @@
+package api
+
+// NetworkForwardPort represents a port specification in a network address forward.
+//
+// API extension: network_forward
+type NetworkForwardPort struct {
+	Description   string `json:"description" yaml:"description"`       // Friendly description of the port.
+	Protocol      string `json:"protocol" yaml:"protocol"`             // Either "tcp" or "udp".
+	ListenPort    string `json:"listen_port" yaml:"listen_port"`       // Comma separated list of ports and port ranges.
+	TargetPort    string `json:"target_port" yaml:"target_port"`       // Target port(s), defaults to the listen port(s).
+	TargetAddress string `json:"target_address" yaml:"target_address"` // Target IP address.
+}
+
+// NetworkForwardPut represents the modifiable fields of a network address forward.
+//
+// API extension: network_forward
+type NetworkForwardPut struct {
+	Description string               `json:"description" yaml:"description"` // Friendly description of the forward.
+	Config      map[string]string    `json:"config" yaml:"config"`           // Config options, such as "target_address".
+	Ports       []NetworkForwardPort `json:"ports" yaml:"ports"`             // Port specifications.
+}
+
+// NetworkForwardsPost represents the fields of a new network address forward.
+//
+// API extension: network_forward
+type NetworkForwardsPost struct {
+	NetworkForwardPut `yaml:",inline"`
+
+	ListenAddress string `json:"listen_address" yaml:"listen_address"` // External address to listen on.
+}
+
+// NetworkForward represents a network address forward.
+//
+// API extension: network_forward
+type NetworkForward struct {
+	NetworkForwardPut `yaml:",inline"`
+
+	ListenAddress string `json:"listen_address" yaml:"listen_address"` // External address to listen on.
+}
+
+// Writable converts a full NetworkForward struct into a NetworkForwardPut struct (filters read-only fields).
+func (f *NetworkForward) Writable() NetworkForwardPut {
+	return f.NetworkForwardPut
+}
@@ -0,0 +1,54 @@
+package api
+
+// NetworkLoadBalancerBackend represents a target backend specification in a network load balancer.
+//
+// API extension: network_load_balancer
+type NetworkLoadBalancerBackend struct {
+	Name          string `json:"name" yaml:"name"`                     // Name of the backend.
+	Description   string `json:"description" yaml:"description"`       // Friendly description of the backend.
+	TargetAddress string `json:"target_address" yaml:"target_address"` // Target IP address.
+	TargetPort    string `json:"target_port" yaml:"target_port"`       // Target port(s), defaults to the listen port(s).
+}
+
+// NetworkLoadBalancerPort represents a port specification in a network load balancer.
+//
+// API extension: network_load_balancer
+type NetworkLoadBalancerPort struct {
+	Description   string   `json:"description" yaml:"description"`       // Friendly description of the port.
+	Protocol      string   `json:"protocol" yaml:"protocol"`             // Either "tcp" or "udp".
+	ListenPort    string   `json:"listen_port" yaml:"listen_port"`       // Comma separated list of ports and port ranges.
+	TargetBackend []string `json:"target_backend" yaml:"target_backend"` // Names of the backends to spread traffic across.
+}
+
+// NetworkLoadBalancerPut represents the modifiable fields of a network load balancer.
+//
+// API extension: network_load_balancer
+type NetworkLoadBalancerPut struct {
+	Description string                       `json:"description" yaml:"description"` // Friendly description of the load balancer.
+	Config      map[string]string            `json:"config" yaml:"config"`           // Config options, such as "healthcheck".
+	Backends    []NetworkLoadBalancerBackend `json:"backends" yaml:"backends"`       // Backend specifications.
+	Ports       []NetworkLoadBalancerPort    `json:"ports" yaml:"ports"`             // Port specifications.
+}
+
+// NetworkLoadBalancersPost represents the fields of a new network load balancer.
+//
+// API extension: network_load_balancer
+type NetworkLoadBalancersPost struct {
+	NetworkLoadBalancerPut `yaml:",inline"`
+
+	ListenAddress string `json:"listen_address" yaml:"listen_address"` // External address to listen on, allocated from the uplink network if empty.
+}
+
+// NetworkLoadBalancer represents a network load balancer.
+//
+// API extension: network_load_balancer
+type NetworkLoadBalancer struct {
+	NetworkLoadBalancerPut `yaml:",inline"`
+
+	ListenAddress string `json:"listen_address" yaml:"listen_address"` // External address to listen on.
+}
+
+// Writable converts a full NetworkLoadBalancer struct into a NetworkLoadBalancerPut struct (filters read-only fields).
+func (l *NetworkLoadBalancer) Writable() NetworkLoadBalancerPut {
+	return l.NetworkLoadBalancerPut
+}
@@ -0,0 +1,37 @@
+package api
+
+// NetworkPeerPut represents the modifiable fields of a network peering.
+//
+// API extension: network_peer
+type NetworkPeerPut struct {
+	Description string            `json:"description" yaml:"description"` // Friendly description of the peering.
+	Config      map[string]string `json:"config" yaml:"config"`           // Config options, only "user." keys are allowed.
+}
+
+// NetworkPeersPost represents the fields of a new network peering.
+//
+// API extension: network_peer
+type NetworkPeersPost struct {
+	NetworkPeerPut `yaml:",inline"`
+
+	Name          string `json:"name" yaml:"name"`                     // Name of the peering.
+	TargetProject string `json:"target_project" yaml:"target_project"` // Project of the network to peer with.
+	TargetNetwork string `json:"target_network" yaml:"target_network"` // Name of the network to peer with.
+}
+
+// NetworkPeer represents a network peering.
+//
+// API extension: network_peer
+type NetworkPeer struct {
+	NetworkPeerPut `yaml:",inline"`
+
+	Name          string `json:"name" yaml:"name"`                     // Name of the peering.
+	TargetProject string `json:"target_project" yaml:"target_project"` // Project of the network to peer with.
+	TargetNetwork string `json:"target_network" yaml:"target_network"` // Name of the network to peer with.
+	Status        string `json:"status" yaml:"status"`                 // Either "Pending" or "Created".
+}
+
+// Writable converts a full NetworkPeer struct into a NetworkPeerPut struct (filters read-only fields).
+func (p *NetworkPeer) Writable() NetworkPeerPut {
+	return p.NetworkPeerPut
+}
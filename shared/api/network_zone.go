@@ -0,0 +1,33 @@
+package api
+
+// NetworkZonePut represents the modifiable fields of a network zone.
+//
+// API extension: network_zones
+type NetworkZonePut struct {
+	Description string            `json:"description" yaml:"description"` // Friendly description of the zone.
+	Config      map[string]string `json:"config" yaml:"config"`           // Config options for the zone.
+}
+
+// NetworkZonesPost represents the fields of a new network zone.
+//
+// API extension: network_zones
+type NetworkZonesPost struct {
+	NetworkZonePut `yaml:",inline"`
+
+	Name string `json:"name" yaml:"name"` // Name of the zone (DNS domain name).
+}
+
+// NetworkZone represents a network zone.
+//
+// API extension: network_zones
+type NetworkZone struct {
+	NetworkZonePut `yaml:",inline"`
+
+	Name   string   `json:"name" yaml:"name"`       // Name of the zone (DNS domain name).
+	UsedBy []string `json:"used_by" yaml:"used_by"` // Resources that use the zone.
+}
+
+// Writable converts a full NetworkZone struct into a NetworkZonePut struct (filters read-only fields).
+func (z *NetworkZone) Writable() NetworkZonePut {
+	return z.NetworkZonePut
+}
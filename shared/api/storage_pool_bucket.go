@@ -0,0 +1,67 @@
+package api
+
+// StorageBucketPut represents the modifiable fields of a LXD storage bucket.
+//
+// API extension: storage_buckets
+type StorageBucketPut struct {
+	Config      map[string]string `json:"config" yaml:"config"`           // Config options for the bucket.
+	Description string            `json:"description" yaml:"description"` // Friendly description of the bucket.
+}
+
+// StorageBucketsPost represents the fields of a new LXD storage bucket.
+//
+// API extension: storage_buckets
+type StorageBucketsPost struct {
+	StorageBucketPut `yaml:",inline"`
+
+	Name string `json:"name" yaml:"name"` // Name of the bucket.
+}
+
+// StorageBucket represents a LXD storage bucket.
+//
+// API extension: storage_buckets
+type StorageBucket struct {
+	StorageBucketPut `yaml:",inline"`
+
+	Name     string `json:"name" yaml:"name"`         // Name of the bucket.
+	S3URL    string `json:"s3_url" yaml:"s3_url"`     // URL of the bucket on the S3 listener, if enabled.
+	Location string `json:"location" yaml:"location"` // Cluster member holding the bucket.
+}
+
+// Writable converts a full StorageBucket struct into a StorageBucketPut struct (filters read-only fields).
+func (b *StorageBucket) Writable() StorageBucketPut {
+	return b.StorageBucketPut
+}
+
+// StorageBucketKeyPut represents the modifiable fields of a LXD storage bucket key.
+//
+// API extension: storage_buckets
+type StorageBucketKeyPut struct {
+	Description string `json:"description" yaml:"description"` // Friendly description of the key.
+	Role        string `json:"role" yaml:"role"`               // Role of the key ("admin" or "read-only").
+	AccessKey   string `json:"access_key" yaml:"access_key"`   // S3 access key (generated if empty).
+	SecretKey   string `json:"secret_key" yaml:"secret_key"`   // S3 secret key (generated if empty).
+}
+
+// StorageBucketKeysPost represents the fields of a new LXD storage bucket key.
+//
+// API extension: storage_buckets
+type StorageBucketKeysPost struct {
+	StorageBucketKeyPut `yaml:",inline"`
+
+	Name string `json:"name" yaml:"name"` // Name of the key.
+}
+
+// StorageBucketKey represents a LXD storage bucket key.
+//
+// API extension: storage_buckets
+type StorageBucketKey struct {
+	StorageBucketKeyPut `yaml:",inline"`
+
+	Name string `json:"name" yaml:"name"` // Name of the key.
+}
+
+// Writable converts a full StorageBucketKey struct into a StorageBucketKeyPut struct (filters read-only fields).
+func (k *StorageBucketKey) Writable() StorageBucketKeyPut {
+	return k.StorageBucketKeyPut
+}
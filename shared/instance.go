@@ -133,6 +133,19 @@ var KnownInstanceConfigKeys = map[string]func(value string) error{
 
 		return nil
 	},
+	"limits.cpu.nodes": func(value string) error {
+		if value == "" {
+			return nil
+		}
+
+		match, _ := regexp.MatchString("^[0-9]+(-[0-9]+)?(,[0-9]+(-[0-9]+)?)*$", value)
+		if !match {
+			return fmt.Errorf("Invalid NUMA node list syntax")
+		}
+
+		return nil
+	},
+	"limits.cpu.hotplug":  validate.Optional(validate.IsBool),
 	"limits.cpu.priority": validate.Optional(validate.IsPriority),
 
 	"limits.disk.priority": validate.Optional(validate.IsPriority),
@@ -169,6 +182,7 @@ var KnownInstanceConfigKeys = map[string]func(value string) error{
 	"limits.memory.swap":          validate.Optional(validate.IsBool),
 	"limits.memory.swap.priority": validate.Optional(validate.IsPriority),
 	"limits.memory.hugepages":     validate.Optional(validate.IsBool),
+	"limits.memory.hotplug":       validate.Optional(validate.IsBool),
 
 	"limits.network.priority": validate.Optional(validate.IsPriority),
 
@@ -248,6 +262,7 @@ var KnownInstanceConfigKeys = map[string]func(value string) error{
 	"raw.seccomp":  validate.IsAny,
 
 	"volatile.apply_template":   validate.IsAny,
+	"volatile.backup.uuid":      validate.IsAny,
 	"volatile.base_image":       validate.IsAny,
 	"volatile.last_state.idmap": validate.IsAny,
 	"volatile.last_state.power": validate.IsAny,
@@ -301,7 +316,7 @@ func ConfigKeyChecker(key string) (func(value string) error, error) {
 			return validate.IsAny, nil
 		}
 
-		if strings.HasSuffix(key, ".apply_quota") {
+		if strings.HasSuffix(key, ".apply_quota") || strings.HasSuffix(key, ".apply_quota.state") {
 			return validate.IsAny, nil
 		}
 
@@ -343,6 +343,21 @@ func SplitExt(fpath string) (string, string) {
 	return b[:len(b)-len(ext)], ext
 }
 
+// SplitNTrimSpace returns the result of strings.SplitN() with each element trimmed of surrounding whitespace.
+// If nilIfEmpty is true, nil is returned when s is empty or only contains whitespace.
+func SplitNTrimSpace(s string, sep string, n int, nilIfEmpty bool) []string {
+	if nilIfEmpty && strings.TrimSpace(s) == "" {
+		return nil
+	}
+
+	parts := strings.SplitN(s, sep, n)
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+
+	return parts
+}
+
 func AtoiEmptyDefault(s string, def int) (int, error) {
 	if s == "" {
 		return def, nil
@@ -174,3 +174,10 @@ func TestGetSnapshotExpiry(t *testing.T) {
 	require.Error(t, err)
 	require.Equal(t, time.Time{}, expiryDate)
 }
+
+func TestSplitNTrimSpace(t *testing.T) {
+	require.Equal(t, []string{"web", "dns"}, SplitNTrimSpace(" web, dns ", ",", -1, true))
+	require.Equal(t, []string{"a", "b, c"}, SplitNTrimSpace("a, b, c", ",", 2, true))
+	require.Nil(t, SplitNTrimSpace("  ", ",", -1, true))
+	require.Equal(t, []string{""}, SplitNTrimSpace("  ", ",", -1, false))
+}
@@ -224,6 +224,31 @@ var APIExtensions = []string{
 	"network_type_sriov",
 	"container_syscall_intercept_bpf_devices",
 	"network_type_ovn",
+	"vm_stateful_stop",
+	"vm_stateful_migration",
+	"vm_hotplug",
+	"vm_usb",
+	"vm_proxy",
+	"vm_live_resize",
+	"vm_tpm",
+	"console_vga_screenshot",
+	"vm_numa",
+	"vm_unix_devices",
+	"network_acl",
+	"network_forward",
+	"network_ovn_options",
+	"projects_networks",
+	"network_type_physical",
+	"network_peer",
+	"network_leases_reservations",
+	"network_zones",
+	"nic_routed_vm",
+	"network_load_balancer",
+	"custom_volume_backup",
+	"storage_volume_encryption",
+	"storage_buckets",
+	"backup_incremental",
+	"nic_ipvlan_vm",
 }
 
 // APIExtensionsCount returns the number of available API extensions.
@@ -33,3 +33,20 @@ EOF
 my_curl() {
     curl -k -s --cert "${LXD_CONF}/client.crt" --key "${LXD_CONF}/client.key" "$@"
 }
+
+# Return whether the OVN northbound database used by LXD can be reached
+ovn_available() {
+    if ! which ovn-nbctl >/dev/null 2>&1; then
+        return 1
+    fi
+
+    ovn-nbctl --timeout=5 show >/dev/null 2>&1
+}
+
+# Return the prefix of the OVN objects created for a LXD network
+ovn_network_prefix() {
+    # shellcheck disable=SC2039
+    local id
+    id=$(lxd sql global "SELECT id FROM networks WHERE name = '${1}'" | awk '/^\| *[0-9]+ *\|$/ {print $2}')
+    echo "lxd-net${id}"
+}
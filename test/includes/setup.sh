@@ -28,3 +28,34 @@ ensure_import_testimage() {
         fi
     fi
 }
+
+ensure_import_vmimage() {
+    # Virtual machine tests need a bootable image which can't be generated
+    # locally, return false when none was provided through LXD_VM_IMAGE.
+    if [ ! -e "${LXD_VM_IMAGE:-}" ]; then
+        return 1
+    fi
+
+    if ! lxc image alias list | grep -q "^| vmimage\\s*|.*$"; then
+        if [ -e "${LXD_VM_IMAGE_ROOTFS:-}" ]; then
+            lxc image import "${LXD_VM_IMAGE}" "${LXD_VM_IMAGE_ROOTFS}" --alias vmimage
+        else
+            lxc image import "${LXD_VM_IMAGE}" --alias vmimage
+        fi
+    fi
+}
+
+wait_for_vm_agent() {
+    # shellcheck disable=SC2039
+    local remote_vm=${1}
+    for _ in $(seq 90); do
+        if lxc exec "${remote_vm}" -- true >/dev/null 2>&1; then
+            return 0
+        fi
+
+        sleep 1
+    done
+
+    echo "Timed out waiting for the agent in ${remote_vm}"
+    return 1
+}
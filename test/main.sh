@@ -233,6 +233,14 @@ run_test test_container_snapshot_config "container snapshot configuration"
 run_test test_server_config "server configuration"
 run_test test_filemanip "file manipulations"
 run_test test_network "network management"
+run_test test_network_acl "network ACLs"
+run_test test_network_forward "network address forwards"
+run_test test_network_ovn "OVN network options"
+run_test test_network_physical "physical networks"
+run_test test_network_peer "network peers"
+run_test test_network_leases "network leases and DHCP reservations"
+run_test test_network_zone "network zones"
+run_test test_network_load_balancer "network load balancers"
 run_test test_idmap "id mapping"
 run_test test_template "file templating"
 run_test test_pki "PKI mode"
@@ -247,7 +255,9 @@ run_test test_init_interactive "lxd init interactive"
 run_test test_init_preseed "lxd init preseed"
 run_test test_storage_profiles "storage profiles"
 run_test test_container_import "container import"
+run_test test_container_recover "container recover"
 run_test test_storage_volume_attach "attaching storage volumes"
+run_test test_storage_volume_encryption "storage volume encryption"
 run_test test_storage_driver_btrfs "btrfs storage driver"
 run_test test_storage_driver_ceph "ceph storage driver"
 run_test test_storage_driver_cephfs "cephfs storage driver"
@@ -260,6 +270,8 @@ run_test test_storage_local_volume_handling "storage local volume handling"
 run_test test_backup_import "backup import"
 run_test test_backup_export "backup export"
 run_test test_backup_rename "backup rename"
+run_test test_backup_volume_export "custom volume backup export and import"
+run_test test_backup_incremental "incremental backups"
 run_test test_container_local_cross_pool_handling "container local cross pool handling"
 run_test test_incremental_copy "incremental container copy"
 run_test test_profiles_project_default "profiles in default project"
@@ -267,6 +279,7 @@ run_test test_profiles_project_images_profiles "profiles in project with images
 run_test test_profiles_project_images "profiles in project with images enabled and profiles disabled"
 run_test test_profiles_project_profiles "profiles in project with images disabled and profiles enabled"
 run_test test_filtering "API filtering"
+run_test test_vm_stateful "virtual machine stateful stop and snapshots"
 
 # shellcheck disable=SC2034
 TEST_RESULT=success
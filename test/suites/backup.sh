@@ -155,6 +155,110 @@ test_container_import() {
   kill_lxd "${LXD_IMPORT_DIR}"
 }
 
+test_container_recover() {
+  LXD_RECOVER_DIR=$(mktemp -d -p "${TEST_DIR}" XXX)
+  chmod +x "${LXD_RECOVER_DIR}"
+  spawn_lxd "${LXD_RECOVER_DIR}" true
+  (
+    set -e
+
+    # shellcheck disable=SC2030
+    LXD_DIR=${LXD_RECOVER_DIR}
+    lxd_backend=$(storage_backend "$LXD_DIR")
+    poolName="lxdtest-$(basename "${LXD_DIR}")"
+
+    ensure_import_testimage
+
+    lxc profile create recover
+    lxc init testimage c1 -p default -p recover
+    lxc config set c1 user.foo=bar
+    lxc snapshot c1 snap0
+    lxc storage volume create "${poolName}" vol1
+    lxc storage volume snapshot "${poolName}" vol1 snap0
+
+    # Nothing is unknown while the database records exist.
+    printf 'no\nyes\n' | lxd recover | grep "No unknown volumes found. Nothing to do."
+
+    # Remove the database records of the instance and of the custom volume.
+    lxd sql global "PRAGMA foreign_keys=ON; DELETE FROM instances WHERE name='c1'"
+    lxd sql global "PRAGMA foreign_keys=ON; DELETE FROM storage_volumes WHERE name='c1'"
+    lxd sql global "PRAGMA foreign_keys=ON; DELETE FROM storage_volumes WHERE name='vol1'"
+    ! lxc info c1 || false
+    ! lxc storage volume show "${poolName}" vol1 || false
+
+    # Missing profiles are reported and prevent the import.
+    lxc profile delete recover
+    validate="{\"pools\": [{\"name\": \"${poolName}\", \"driver\": \"${lxd_backend}\"}]}"
+    lxc query -X POST -d "${validate}" /internal/recover/validate | jq -r '.dependency_errors[]' | grep -F 'Profile "recover" in project "default"'
+    ! lxc query -X POST -d "${validate}" /internal/recover/import || false
+    ! lxc info c1 || false
+
+    # Both volumes and their snapshots are found once the profile exists again.
+    lxc profile create recover
+    lxc query -X POST -d "${validate}" /internal/recover/validate > "${TEST_DIR}/recover.json"
+    [ "$(jq '.dependency_errors | length' "${TEST_DIR}/recover.json")" = "0" ]
+    [ "$(jq -r '.unknown_volumes[] | select(.name == "c1") | "\(.type) \(.snapshot_count)"' "${TEST_DIR}/recover.json")" = "container 1" ]
+    [ "$(jq -r '.unknown_volumes[] | select(.name == "vol1") | "\(.type) \(.snapshot_count)"' "${TEST_DIR}/recover.json")" = "custom 1" ]
+    rm -f "${TEST_DIR}/recover.json"
+
+    # Declining the recovery leaves the database untouched.
+    printf 'no\nyes\nno\n' | lxd recover
+    ! lxc info c1 || false
+
+    printf 'no\nyes\nyes\n' | lxd recover | grep "All unknown volumes have been recovered"
+
+    # The instance is recovered stopped along with its config, profiles and snapshots.
+    lxc info c1 | grep -q "Status: Stopped"
+    [ "$(lxc config get c1 user.foo)" = "bar" ]
+    lxc config show c1 | grep -q "^- recover$"
+    lxc info c1 | grep -q snap0
+    lxc storage volume show "${poolName}" c1 --type=container
+    lxc start c1
+    lxc exec c1 -- true
+    lxc stop -f c1
+    lxc restore c1 snap0
+
+    # The custom volume is recovered along with its snapshot.
+    lxc storage volume show "${poolName}" vol1
+    lxc storage volume show "${poolName}" vol1/snap0
+    lxc storage volume attach "${poolName}" vol1 c1 /mnt
+    lxc start c1
+    lxc exec c1 -- touch /mnt/foo
+    lxc stop -f c1
+    lxc storage volume restore "${poolName}" vol1 snap0
+
+    lxc delete -f c1
+    lxc storage volume delete "${poolName}" vol1
+    lxc profile delete recover
+
+    # Unknown pools are recovered along with their volumes.
+    if [ "${lxd_backend}" = "dir" ]; then
+      poolSource=$(mktemp -d -p "${TEST_DIR}" XXX)
+      lxc storage create recover dir source="${poolSource}"
+      lxc storage volume create recover vol2
+      lxc init testimage c2 -s recover
+
+      lxd sql global "PRAGMA foreign_keys=ON; DELETE FROM instances WHERE name='c2'"
+      lxd sql global "PRAGMA foreign_keys=ON; DELETE FROM storage_pools WHERE name='recover'"
+      ! lxc storage show recover || false
+
+      printf 'yes\nrecover\ndir\n%s\n\nno\nyes\nyes\n' "${poolSource}" | lxd recover
+      lxc storage show recover | grep -q "source: ${poolSource}"
+      lxc storage volume show recover vol2
+      lxc info c2 | grep -q "Status: Stopped"
+      [ "$(lxc config device get c2 root pool)" = "recover" ]
+
+      lxc delete c2
+      lxc storage volume delete recover vol2
+      lxc storage delete recover
+      rm -rf "${poolSource}"
+    fi
+  )
+  # shellcheck disable=SC2031
+  LXD_DIR=${LXD_DIR}
+  kill_lxd "${LXD_RECOVER_DIR}"
+}
+
 test_backup_import() {
   test_backup_import_with_project
   test_backup_import_with_project foo
@@ -413,3 +517,235 @@ test_backup_rename() {
 
   lxc delete --force c2
 }
+
+test_backup_volume_export() {
+  ensure_import_testimage
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  # shellcheck disable=2039
+  local pool lxd_backend
+  pool="lxdtest-$(basename "${LXD_DIR}")"
+  lxd_backend=$(storage_backend "$LXD_DIR")
+
+  # Create a custom volume with a snapshot and different data in the volume itself.
+  lxc storage volume create "${pool}" vol1
+  lxc launch testimage c1
+  lxc storage volume attach "${pool}" vol1 c1 vol1 /mnt
+  lxc exec c1 -- sh -c "echo foo > /mnt/test"
+  lxc storage volume snapshot "${pool}" vol1 snap0
+  lxc exec c1 -- sh -c "echo bar > /mnt/test"
+
+  mkdir "${LXD_DIR}/optimized" "${LXD_DIR}/non-optimized"
+
+  # Test exports of the volume only and with snapshots.
+  lxc storage volume export "${pool}" vol1 "${LXD_DIR}/vol1-volume-only.tar.gz" --volume-only
+  lxc storage volume export "${pool}" vol1 "${LXD_DIR}/vol1.tar.gz"
+  tar -xzf "${LXD_DIR}/vol1.tar.gz" -C "${LXD_DIR}/non-optimized"
+  [ -f "${LXD_DIR}/non-optimized/backup/index.yaml" ]
+  grep -q "snap0" "${LXD_DIR}/non-optimized/backup/index.yaml"
+
+  if [ "$lxd_backend" = "btrfs" ] || [ "$lxd_backend" = "zfs" ]; then
+    lxc storage volume export "${pool}" vol1 "${LXD_DIR}/vol1-optimized.tar.gz" --optimized-storage
+    tar -xzf "${LXD_DIR}/vol1-optimized.tar.gz" -C "${LXD_DIR}/optimized"
+    [ -f "${LXD_DIR}/optimized/backup/index.yaml" ]
+    [ -f "${LXD_DIR}/optimized/backup/container.bin" ]
+  fi
+
+  # Test the compression option.
+  lxc storage volume export "${pool}" vol1 "${LXD_DIR}/vol1-uncompressed.tar" --compression none
+  tar -tf "${LXD_DIR}/vol1-uncompressed.tar" | grep -q "^backup/index.yaml$"
+
+  # Importing under the name of an existing volume must fail.
+  ! lxc storage volume import "${pool}" "${LXD_DIR}/vol1.tar.gz" || false
+
+  # Test imports, with and without snapshots.
+  lxc storage volume import "${pool}" "${LXD_DIR}/vol1.tar.gz" vol2
+  lxc storage volume show "${pool}" vol2/snap0
+  lxc storage volume import "${pool}" "${LXD_DIR}/vol1-volume-only.tar.gz" vol3
+  ! lxc storage volume show "${pool}" vol3/snap0 || false
+  lxc storage volume import "${pool}" "${LXD_DIR}/vol1-uncompressed.tar" vol4
+
+  lxc storage volume attach "${pool}" vol2 c1 vol2 /mnt2
+  lxc storage volume attach "${pool}" vol3 c1 vol3 /mnt3
+  lxc storage volume attach "${pool}" vol4 c1 vol4 /mnt4
+  [ "$(lxc exec c1 -- cat /mnt2/test)" = "bar" ]
+  [ "$(lxc exec c1 -- cat /mnt3/test)" = "bar" ]
+  [ "$(lxc exec c1 -- cat /mnt4/test)" = "bar" ]
+  lxc storage volume detach "${pool}" vol2 c1 vol2
+  lxc storage volume restore "${pool}" vol2 snap0
+  lxc storage volume attach "${pool}" vol2 c1 vol2 /mnt2
+  [ "$(lxc exec c1 -- cat /mnt2/test)" = "foo" ]
+
+  if [ "$lxd_backend" = "btrfs" ] || [ "$lxd_backend" = "zfs" ]; then
+    lxc storage volume import "${pool}" "${LXD_DIR}/vol1-optimized.tar.gz" vol5
+    lxc storage volume show "${pool}" vol5/snap0
+    lxc storage volume attach "${pool}" vol5 c1 vol5 /mnt5
+    [ "$(lxc exec c1 -- cat /mnt5/test)" = "bar" ]
+    lxc storage volume detach "${pool}" vol5 c1 vol5
+    lxc storage volume delete "${pool}" vol5
+  fi
+
+  # Test backups created through the API are kept with the volume.
+  lxc query -X POST --wait -d '{"name": "foo"}' "/1.0/storage-pools/${pool}/volumes/custom/vol1/backups"
+  lxc query "/1.0/storage-pools/${pool}/volumes/custom/vol1/backups" | grep "/1.0/storage-pools/${pool}/volumes/custom/vol1/backups/foo"
+  my_curl -f -o "${LXD_DIR}/vol1-foo.tar.gz" "https://${LXD_ADDR}/1.0/storage-pools/${pool}/volumes/custom/vol1/backups/foo/export"
+  tar -tzf "${LXD_DIR}/vol1-foo.tar.gz" | grep -q "^backup/index.yaml$"
+  lxc query -X POST --wait -d '{"name": "bar"}' "/1.0/storage-pools/${pool}/volumes/custom/vol1/backups/foo"
+  lxc query "/1.0/storage-pools/${pool}/volumes/custom/vol1/backups/bar"
+
+  # Test block custom volumes, which are restored with their content type.
+  lxc storage volume create "${pool}" blk1 --type=block size=16MiB
+  if [ "$lxd_backend" = "dir" ]; then
+    dd if=/dev/urandom of="${LXD_DIR}/storage-pools/${pool}/custom/default_blk1/root.img" bs=1M count=1 conv=notrunc
+  fi
+  lxc storage volume snapshot "${pool}" blk1 snap0
+  lxc storage volume export "${pool}" blk1 "${LXD_DIR}/blk1.tar.gz"
+  tar -tzf "${LXD_DIR}/blk1.tar.gz" | grep -q "^backup/container.img$"
+  tar -tzf "${LXD_DIR}/blk1.tar.gz" | grep -q "^backup/snapshots/snap0.img$"
+  lxc storage volume import "${pool}" "${LXD_DIR}/blk1.tar.gz" blk2
+  [ "$(lxc storage volume show "${pool}" blk2 | awk '/^content_type:/ {print $2}')" = "block" ]
+  lxc storage volume show "${pool}" blk2/snap0
+  lxc storage volume export "${pool}" blk2 "${LXD_DIR}/blk2.tar.gz" --volume-only
+  [ "$(tar -xzOf "${LXD_DIR}/blk1.tar.gz" backup/container.img | sha256sum)" = "$(tar -xzOf "${LXD_DIR}/blk2.tar.gz" backup/container.img | sha256sum)" ]
+  lxc storage volume delete "${pool}" blk2
+  lxc storage volume delete "${pool}" blk1
+  rm -f "${LXD_DIR}/blk1.tar.gz" "${LXD_DIR}/blk2.tar.gz"
+
+  lxc delete -f c1
+  lxc storage volume delete "${pool}" vol1
+  ! lxc query "/1.0/storage-pools/${pool}/volumes/custom/vol1/backups/bar" || false
+  lxc storage volume delete "${pool}" vol2
+  lxc storage volume delete "${pool}" vol3
+  lxc storage volume delete "${pool}" vol4
+  rm -rf "${LXD_DIR}/optimized" "${LXD_DIR}/non-optimized" "${LXD_DIR}/vol1"*
+}
+
+test_backup_incremental() {
+  ensure_import_testimage
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  # shellcheck disable=2039
+  local pool lxd_backend vol_url inst_url uuid
+  pool="lxdtest-$(basename "${LXD_DIR}")"
+  lxd_backend=$(storage_backend "$LXD_DIR")
+  vol_url="/1.0/storage-pools/${pool}/volumes/custom/vol1/backups"
+  inst_url="/1.0/instances/c2/backups"
+
+  lxc storage volume create "${pool}" vol1
+  lxc launch testimage c1
+  lxc storage volume attach "${pool}" vol1 c1 vol1 /mnt
+  lxc exec c1 -- sh -c "echo foo > /mnt/foo && echo bar > /mnt/bar"
+
+  # The parent must exist.
+  ! lxc query -X POST --wait -d '{"name": "inc1", "parent": "full"}' "${vol_url}" || false
+
+  # Create a full backup followed by two incremental ones.
+  lxc query -X POST --wait -d '{"name": "full", "volume_only": true}' "${vol_url}"
+  lxc exec c1 -- sh -c "echo baz > /mnt/bar && echo new > /mnt/new && rm /mnt/foo"
+  lxc query -X POST --wait -d '{"name": "inc1", "parent": "full"}' "${vol_url}"
+  [ "$(lxc query "${vol_url}/inc1" | jq -r .parent)" = "full" ]
+  lxc exec c1 -- sh -c "echo newer > /mnt/new"
+  lxc query -X POST --wait -d '{"name": "inc2", "parent": "inc1"}' "${vol_url}"
+
+  # Incremental backups don't contain the unchanged files.
+  for backup in full inc1 inc2; do
+    my_curl -f -o "${LXD_DIR}/vol1-${backup}.tar.gz" "https://${LXD_ADDR}${vol_url}/${backup}/export"
+  done
+  tar -tzf "${LXD_DIR}/vol1-full.tar.gz" | grep -q "^backup/container/foo$"
+  tar -tzf "${LXD_DIR}/vol1-inc1.tar.gz" | grep -q "^backup/container/bar$"
+  ! tar -tzf "${LXD_DIR}/vol1-inc2.tar.gz" | grep -q "^backup/container/bar$" || false
+
+  # Backups can't be deleted while other backups are incremental to them.
+  ! lxc query -X DELETE --wait "${vol_url}/full" || false
+  ! lxc query -X DELETE --wait "${vol_url}/inc1" || false
+
+  # Incremental backups are applied onto the volume restored from their parent.
+  ! lxc storage volume import "${pool}" "${LXD_DIR}/vol1-inc1.tar.gz" volr || false
+  lxc storage volume import "${pool}" "${LXD_DIR}/vol1-full.tar.gz" volr
+  uuid=$(lxc storage volume get "${pool}" volr volatile.backup.uuid)
+  [ -n "${uuid}" ]
+  ! lxc storage volume set "${pool}" volr volatile.backup.uuid=foo || false
+  ! lxc storage volume import "${pool}" "${LXD_DIR}/vol1-inc2.tar.gz" volr || false
+  lxc storage volume import "${pool}" "${LXD_DIR}/vol1-inc1.tar.gz" volr
+  [ "$(lxc storage volume get "${pool}" volr volatile.backup.uuid)" != "${uuid}" ]
+  ! lxc storage volume import "${pool}" "${LXD_DIR}/vol1-inc1.tar.gz" volr || false
+
+  # Volumes used by running instances or modified since their last import are refused.
+  lxc storage volume attach "${pool}" volr c1 volr /mnt2
+  [ "$(lxc exec c1 -- cat /mnt2/bar)" = "baz" ]
+  [ "$(lxc exec c1 -- cat /mnt2/new)" = "new" ]
+  ! lxc exec c1 -- test -e /mnt2/foo || false
+  ! lxc storage volume import "${pool}" "${LXD_DIR}/vol1-inc2.tar.gz" volr || false
+  lxc exec c1 -- touch /mnt2/extra
+  lxc storage volume detach "${pool}" volr c1 volr
+  ! lxc storage volume import "${pool}" "${LXD_DIR}/vol1-inc2.tar.gz" volr || false
+
+  # Restoring the whole chain again works.
+  lxc storage volume delete "${pool}" volr
+  for backup in full inc1 inc2; do
+    lxc storage volume import "${pool}" "${LXD_DIR}/vol1-${backup}.tar.gz" volr
+  done
+  lxc storage volume attach "${pool}" volr c1 volr /mnt2
+  [ "$(lxc exec c1 -- cat /mnt2/new)" = "newer" ]
+  ! lxc exec c1 -- test -e /mnt2/extra || false
+  lxc storage volume detach "${pool}" volr c1 volr
+  lxc storage volume delete "${pool}" volr
+
+  # Optimized incremental backups use the storage driver's send and receive.
+  if [ "$lxd_backend" = "btrfs" ] || [ "$lxd_backend" = "zfs" ]; then
+    lxc query -X POST --wait -d '{"name": "full-optimized", "volume_only": true, "optimized_storage": true}' "${vol_url}"
+    ! lxc query -X POST --wait -d '{"name": "inc-optimized", "parent": "full", "optimized_storage": true}' "${vol_url}" || false
+    lxc exec c1 -- sh -c "echo newest > /mnt/new"
+    lxc query -X POST --wait -d '{"name": "inc-optimized", "parent": "full-optimized", "optimized_storage": true}' "${vol_url}"
+    for backup in full-optimized inc-optimized; do
+      my_curl -f -o "${LXD_DIR}/vol1-${backup}.tar.gz" "https://${LXD_ADDR}${vol_url}/${backup}/export"
+      lxc storage volume import "${pool}" "${LXD_DIR}/vol1-${backup}.tar.gz" volo
+    done
+    lxc storage volume attach "${pool}" volo c1 volo /mnt3
+    [ "$(lxc exec c1 -- cat /mnt3/new)" = "newest" ]
+    lxc storage volume detach "${pool}" volo c1 volo
+    lxc storage volume delete "${pool}" volo
+    lxc query -X DELETE --wait "${vol_url}/inc-optimized"
+    lxc query -X DELETE --wait "${vol_url}/full-optimized"
+  fi
+
+  # Backups can be deleted once their incremental backups are gone.
+  lxc query -X DELETE --wait "${vol_url}/inc2"
+  lxc query -X DELETE --wait "${vol_url}/inc1"
+  lxc query -X DELETE --wait "${vol_url}/full"
+
+  lxc delete -f c1
+  lxc storage volume delete "${pool}" vol1
+
+  # Incremental instance backups also carry the instance config.
+  lxc launch testimage c2
+  lxc exec c2 -- sh -c "echo foo > /root/foo"
+  lxc stop -f c2
+  lxc query -X POST --wait -d '{"name": "full", "instance_only": true}' "${inst_url}"
+  lxc start c2
+  lxc exec c2 -- sh -c "echo bar > /root/foo"
+  lxc stop -f c2
+  lxc config set c2 user.foo=bar
+  lxc query -X POST --wait -d '{"name": "inc1", "parent": "full"}' "${inst_url}"
+  ! lxc query -X DELETE --wait "${inst_url}/full" || false
+  for backup in full inc1; do
+    my_curl -f -o "${LXD_DIR}/c2-${backup}.tar.gz" "https://${LXD_ADDR}${inst_url}/${backup}/export"
+  done
+  lxc delete c2
+
+  ! lxc import "${LXD_DIR}/c2-inc1.tar.gz" || false
+  lxc import "${LXD_DIR}/c2-full.tar.gz"
+  [ -n "$(lxc config get c2 volatile.backup.uuid)" ]
+  ! lxc config set c2 volatile.backup.uuid=foo || false
+  ! lxc config get c2 user.foo | grep -q bar || false
+  lxc import "${LXD_DIR}/c2-inc1.tar.gz"
+  [ "$(lxc config get c2 user.foo)" = "bar" ]
+  lxc start c2
+  [ "$(lxc exec c2 -- cat /root/foo)" = "bar" ]
+
+  # Incremental backups can only be applied once.
+  ! lxc import "${LXD_DIR}/c2-inc1.tar.gz" || false
+  lxc delete -f c2
+
+  rm -f "${LXD_DIR}/vol1-"*.tar.gz "${LXD_DIR}/c2-"*.tar.gz
+}
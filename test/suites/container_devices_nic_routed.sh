@@ -135,6 +135,36 @@ test_container_devices_nic_routed() {
   lxc stop -f "${ctName}2"
   lxc stop -f "${ctName}"
 
+  # Check routed NICs in virtual machines, whose addresses are configured through cloud-init.
+  if ensure_import_vmimage; then
+    lxc init vmimage "${ctName}vm" --vm
+    lxc config device add "${ctName}vm" eth0 nic \
+      nictype=routed \
+      parent=${ctName} \
+      ipv4.address="192.0.2.4${ipRand}" \
+      ipv6.address="2001:db8::4${ipRand}"
+    lxc start "${ctName}vm"
+    wait_for_vm_agent "${ctName}vm"
+    lxc exec "${ctName}vm" -- cloud-init status --wait || true
+
+    # Check the addresses and default gateways are configured inside the VM.
+    lxc exec "${ctName}vm" -- ip -4 addr show | grep "192.0.2.4${ipRand}/32"
+    lxc exec "${ctName}vm" -- ip -6 addr show | grep "2001:db8::4${ipRand}/128"
+    lxc exec "${ctName}vm" -- ip -4 route show default | grep "via 169.254.0.1"
+    lxc exec "${ctName}vm" -- ip -6 route show default | grep "via fe80::1"
+
+    # Check comms with the host and the containers.
+    lxc exec "${ctName}vm" -- ping -c2 -W5 "192.0.2.1"
+    lxc exec "${ctName}vm" -- ping -6 -c3 -W5 "2001:db8::1"
+    lxc start "${ctName}"
+    lxc exec "${ctName}" -- ping -c2 -W5 "192.0.2.4${ipRand}"
+    lxc stop -f "${ctName}"
+
+    lxc delete -f "${ctName}vm"
+  else
+    echo "==> SKIP: routed NICs in virtual machines (no LXD_VM_IMAGE)"
+  fi
+
   # Check routed ontop of VLAN parent with custom routing tables.
   lxc config device set "${ctName}" eth0 vlan 1234
   lxc config device set "${ctName}" eth0 ipv4.host_table=100
@@ -46,11 +46,42 @@ test_migration() {
     lxc_remote storage delete l2:"$storage_pool2"
   fi
 
+  migration_vm_stateful
+
   lxc_remote remote remove l1
   lxc_remote remote remove l2
   kill_lxd "$LXD2_DIR"
 }
 
+migration_vm_stateful() {
+  if ! ensure_import_vmimage; then
+    echo "==> SKIP: stateful migration of virtual machines (no LXD_VM_IMAGE)"
+    return
+  fi
+
+  lxc_remote init vmimage l1:v1 --vm -c limits.memory=512MiB
+  lxc_remote config device override l1:v1 root size.state=1GiB
+  lxc_remote start l1:v1
+  wait_for_vm_agent l1:v1
+
+  # Keep a marker in memory only so it survives solely through the transferred state.
+  lxc_remote exec l1:v1 -- sh -c "echo migrated > /dev/shm/marker"
+
+  # Test stateful move of a running virtual machine.
+  lxc_remote move l1:v1 l2:v1
+  [ "$(lxc_remote list l2:v1 -c s --format csv)" = "RUNNING" ]
+  wait_for_vm_agent l2:v1
+  [ "$(lxc_remote exec l2:v1 -- cat /dev/shm/marker)" = "migrated" ]
+
+  # Test stateful move back to the original server.
+  lxc_remote move l2:v1 l1:v1
+  [ "$(lxc_remote list l1:v1 -c s --format csv)" = "RUNNING" ]
+  wait_for_vm_agent l1:v1
+  [ "$(lxc_remote exec l1:v1 -- cat /dev/shm/marker)" = "migrated" ]
+
+  lxc_remote delete -f l1:v1
+}
+
 migration() {
   # shellcheck disable=2039
   local lxd2_dir lxd_backend lxd2_backend
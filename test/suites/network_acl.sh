@@ -0,0 +1,83 @@
+test_network_acl() {
+  ensure_import_testimage
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  firewallDriver=$(lxc info | awk -F ":" '/firewall:/{gsub(/ /, "", $0); print $2}')
+  brName="lxdt$$"
+
+  # Test ACL creation and validation.
+  lxc query -X POST -d '{"name": "lxdtweb", "description": "Web servers", "ingress": [{"action": "allow", "protocol": "tcp", "destination_port": "80,8000-8080", "state": "enabled"}]}' /1.0/network-acls
+  ! lxc query -X POST -d '{"name": "lxdtweb"}' /1.0/network-acls || false
+  ! lxc query -X POST -d '{"name": "1invalid"}' /1.0/network-acls || false
+  ! lxc query -X POST -d '{"name": "lxdtinvalid", "ingress": [{"action": "accept", "state": "enabled"}]}' /1.0/network-acls || false
+  ! lxc query -X POST -d '{"name": "lxdtinvalid", "ingress": [{"action": "allow", "protocol": "icmp4", "destination_port": "80", "state": "enabled"}]}' /1.0/network-acls || false
+  ! lxc query -X POST -d '{"name": "lxdtinvalid", "egress": [{"action": "allow", "destination": "lxdtunknown", "state": "enabled"}]}' /1.0/network-acls || false
+  lxc query /1.0/network-acls | grep "/1.0/network-acls/lxdtweb"
+  [ "$(lxc query /1.0/network-acls/lxdtweb | jq -r .description)" = "Web servers" ]
+  [ "$(lxc query /1.0/network-acls/lxdtweb | jq -r '.ingress[0].destination_port')" = "80,8000-8080" ]
+
+  # Test ACL update, rename and rule subjects referencing other ACLs.
+  lxc query -X PATCH -d '{"config": {"user.foo": "bar"}}' /1.0/network-acls/lxdtweb
+  [ "$(lxc query /1.0/network-acls/lxdtweb | jq -r '.config["user.foo"]')" = "bar" ]
+  [ "$(lxc query /1.0/network-acls/lxdtweb | jq -r '.ingress | length')" = "1" ]
+  ! lxc query -X PATCH -d '{"config": {"foo": "bar"}}' /1.0/network-acls/lxdtweb || false
+  lxc query -X POST -d '{"name": "lxdtweb2"}' /1.0/network-acls/lxdtweb
+  lxc query -X POST -d '{"name": "lxdtweb"}' /1.0/network-acls/lxdtweb2
+  lxc query -X POST -d '{"name": "lxdtdb", "ingress": [{"action": "allow", "source": "lxdtweb", "protocol": "tcp", "destination_port": "5432", "state": "enabled"}, {"action": "drop", "state": "enabled"}]}' /1.0/network-acls
+  ! lxc query -X DELETE /1.0/network-acls/lxdtweb || false
+  ! lxc query -X POST -d '{"name": "lxdtweb2"}' /1.0/network-acls/lxdtweb || false
+
+  # Test applying ACLs to a bridge network.
+  lxc network create "${brName}" ipv4.address=192.0.2.1/24 ipv4.nat=true ipv6.address=none
+  ! lxc network set "${brName}" security.acls=lxdtunknown || false
+  lxc network set "${brName}" security.acls=lxdtweb
+  lxc query /1.0/network-acls/lxdtweb | jq -r '.used_by[]' | grep "/1.0/networks/${brName}"
+  ! lxc query -X DELETE /1.0/network-acls/lxdtweb || false
+
+  if [ "$firewallDriver" = "xtables" ]; then
+    iptables -S | grep "LXD network ${brName} ACL" | grep -- "--dports 80,8000:8080"
+  else
+    nft -nn list chain inet lxd "aclfwd.${brName}" | grep -E "tcp dport \{ ?80, 8000-8080 ?\} accept"
+  fi
+
+  # Test ACL name subjects resolving to the addresses of the NICs the named ACL applies to.
+  lxc init testimage c1
+  lxc config device add c1 eth0 nic network="${brName}" ipv4.address=192.0.2.10
+  lxc init testimage c2
+  lxc config device add c2 eth0 nic network="${brName}" ipv4.address=192.0.2.20 security.acls=lxdtdb
+  lxc query /1.0/network-acls/lxdtdb | jq -r '.used_by[]' | grep "/1.0/instances/c2"
+
+  if [ "$firewallDriver" = "nftables" ]; then
+    lxc start c1 c2
+    nft -nn list chain inet lxd "aclfwd.c2.eth0" | grep "dport 5432 accept" | grep "192.0.2.10" | grep "192.0.2.20"
+    nft -nn list chain inet lxd "aclfwd.c2.eth0" | grep "oifname .* drop"
+
+    # Changing the network ACLs updates the rules referencing them.
+    lxc network unset "${brName}" security.acls
+    ! nft -nn list chain inet lxd "aclfwd.c2.eth0" | grep "dport 5432 accept" || false
+    lxc network set "${brName}" security.acls=lxdtweb
+    nft -nn list chain inet lxd "aclfwd.c2.eth0" | grep "dport 5432 accept" | grep "192.0.2.10" | grep "192.0.2.20"
+
+    # Stopping the instance removes its rules.
+    lxc stop -f c2
+    ! nft -nn list chain inet lxd "aclfwd.c2.eth0" || false
+    lxc stop -f c1
+  else
+    echo "==> SKIP: ACLs on bridged NICs require the nftables firewall driver"
+  fi
+
+  # Test ACL removal.
+  lxc delete -f c1 c2
+  lxc network unset "${brName}" security.acls
+
+  if [ "$firewallDriver" = "xtables" ]; then
+    ! iptables -S | grep "LXD network ${brName} ACL" || false
+  else
+    ! nft -nn list chain inet lxd "aclfwd.${brName}" || false
+  fi
+
+  lxc network delete "${brName}"
+  lxc query -X DELETE /1.0/network-acls/lxdtdb
+  lxc query -X DELETE /1.0/network-acls/lxdtweb
+  ! lxc query /1.0/network-acls/lxdtweb || false
+}
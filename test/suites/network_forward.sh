@@ -0,0 +1,75 @@
+test_network_forward() {
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  firewallDriver=$(lxc info | awk -F ":" '/firewall:/{gsub(/ /, "", $0); print $2}')
+  netName="lxdt$$"
+
+  lxc network create "${netName}" ipv4.address=192.0.2.1/24 ipv6.address=fd42:4242:4242:1010::1/64
+
+  # Test forward creation and validation.
+  lxc query -X POST -d '{"listen_address": "198.51.100.1", "config": {"target_address": "192.0.2.10"}, "ports": [{"protocol": "tcp", "listen_port": "80,8000-8080", "target_address": "192.0.2.20"}, {"protocol": "udp", "listen_port": "5000-5010", "target_port": "53", "target_address": "192.0.2.20"}, {"protocol": "tcp", "listen_port": "2000-2001", "target_port": "3000-3001", "target_address": "192.0.2.30"}]}' "/1.0/networks/${netName}/forwards"
+  ! lxc query -X POST -d '{"listen_address": "198.51.100.1"}' "/1.0/networks/${netName}/forwards" || false
+  ! lxc query -X POST -d '{"listen_address": "192.0.2.100"}' "/1.0/networks/${netName}/forwards" || false
+  ! lxc query -X POST -d '{"listen_address": "198.51.100.2", "config": {"target_address": "203.0.113.1"}}' "/1.0/networks/${netName}/forwards" || false
+  ! lxc query -X POST -d '{"listen_address": "198.51.100.2", "config": {"target_address": "fd42:4242:4242:1010::10"}}' "/1.0/networks/${netName}/forwards" || false
+  ! lxc query -X POST -d '{"listen_address": "198.51.100.2", "ports": [{"protocol": "tcp", "listen_port": "80-82", "target_port": "90-91", "target_address": "192.0.2.20"}]}' "/1.0/networks/${netName}/forwards" || false
+  ! lxc query -X POST -d '{"listen_address": "198.51.100.2", "ports": [{"protocol": "tcp", "listen_port": "80", "target_address": "192.0.2.20"}, {"protocol": "tcp", "listen_port": "70-90", "target_address": "192.0.2.30"}]}' "/1.0/networks/${netName}/forwards" || false
+  ! lxc query -X POST -d '{"listen_address": "198.51.100.2", "ports": [{"protocol": "icmp4", "listen_port": "80", "target_address": "192.0.2.20"}]}' "/1.0/networks/${netName}/forwards" || false
+  lxc query "/1.0/networks/${netName}/forwards" | grep "/1.0/networks/${netName}/forwards/198.51.100.1"
+  [ "$(lxc query "/1.0/networks/${netName}/forwards/198.51.100.1" | jq -r '.config.target_address')" = "192.0.2.10" ]
+  [ "$(lxc query "/1.0/networks/${netName}/forwards/198.51.100.1" | jq -r '.ports | length')" = "3" ]
+
+  # Test the forward rules, with ranges kept as ranges unless the target ports are shifted.
+  if [ "$firewallDriver" = "xtables" ]; then
+    iptables -t nat -S | grep "LXD network ${netName} forward" | grep -- "--dport 8000:8080" | grep -- "--to-destination 192.0.2.20:8000-8080"
+    iptables -t nat -S | grep "LXD network ${netName} forward" | grep -- "--dport 5000:5010" | grep -- "--to-destination 192.0.2.20:53"
+    iptables -t nat -S | grep "LXD network ${netName} forward" | grep -- "--dport 2001 " | grep -- "--to-destination 192.0.2.30:3001"
+    iptables -t nat -S | grep "LXD network ${netName} forward" | grep -- "-d 198.51.100.1/32 " | grep -- "--to-destination 192.0.2.10"
+  else
+    nft -nn list chain ip lxd "fwdprert.${netName}" | grep "dport 8000-8080 dnat" | grep "192.0.2.20:8000-8080"
+    nft -nn list chain ip lxd "fwdprert.${netName}" | grep "dport 5000-5010 dnat" | grep "192.0.2.20:53"
+    nft -nn list chain ip lxd "fwdprert.${netName}" | grep "dport 2001 dnat" | grep "192.0.2.30:3001"
+    nft -nn list chain ip lxd "fwdprert.${netName}" | grep "daddr 198.51.100.1 dnat" | grep "192.0.2.10"
+  fi
+
+  # Test listen addresses are unique across networks.
+  lxc network create "${netName}b" ipv4.address=192.0.3.1/24 ipv6.address=none
+  ! lxc query -X POST -d '{"listen_address": "198.51.100.1"}' "/1.0/networks/${netName}b/forwards" || false
+  lxc network delete "${netName}b"
+
+  # Test forward update.
+  lxc query -X PATCH -d '{"description": "Test forward"}' "/1.0/networks/${netName}/forwards/198.51.100.1"
+  [ "$(lxc query "/1.0/networks/${netName}/forwards/198.51.100.1" | jq -r '.description')" = "Test forward" ]
+  lxc query -X PUT -d '{"config": {}, "ports": [{"protocol": "tcp", "listen_port": "443", "target_address": "192.0.2.20"}]}' "/1.0/networks/${netName}/forwards/198.51.100.1"
+  [ "$(lxc query "/1.0/networks/${netName}/forwards/198.51.100.1" | jq -r '.ports | length')" = "1" ]
+
+  if [ "$firewallDriver" = "xtables" ]; then
+    ! iptables -t nat -S | grep "LXD network ${netName} forward" | grep -- "--dport 8000:8080" || false
+    iptables -t nat -S | grep "LXD network ${netName} forward" | grep -- "--dport 443" | grep -- "--to-destination 192.0.2.20:443"
+  else
+    ! nft -nn list chain ip lxd "fwdprert.${netName}" | grep "dport 8000-8080" || false
+    nft -nn list chain ip lxd "fwdprert.${netName}" | grep "dport 443 dnat" | grep "192.0.2.20:443"
+  fi
+
+  # Test IPv6 forwards.
+  lxc query -X POST -d '{"listen_address": "2001:db8::1", "config": {"target_address": "fd42:4242:4242:1010::10"}}' "/1.0/networks/${netName}/forwards"
+
+  if [ "$firewallDriver" = "xtables" ]; then
+    ip6tables -t nat -S | grep "LXD network ${netName} forward" | grep -- "--to-destination fd42:4242:4242:1010::10"
+  else
+    nft -nn list chain ip6 lxd "fwdprert.${netName}" | grep "daddr 2001:db8::1 dnat" | grep "fd42:4242:4242:1010::10"
+  fi
+
+  # Test forward removal, including when the network is deleted.
+  lxc query -X DELETE "/1.0/networks/${netName}/forwards/198.51.100.1"
+  ! lxc query "/1.0/networks/${netName}/forwards/198.51.100.1" || false
+  lxc network delete "${netName}"
+
+  if [ "$firewallDriver" = "xtables" ]; then
+    ! iptables -t nat -S | grep "LXD network ${netName} forward" || false
+    ! ip6tables -t nat -S | grep "LXD network ${netName} forward" || false
+  else
+    ! nft -nn list chain ip lxd "fwdprert.${netName}" || false
+    ! nft -nn list chain ip6 lxd "fwdprert.${netName}" || false
+  fi
+}
@@ -0,0 +1,51 @@
+test_network_leases() {
+  ensure_import_testimage
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  netName="lxdt$$"
+  hostsDir="${LXD_DIR}/networks/${netName}/dnsmasq.hosts"
+
+  lxc network create "${netName}" ipv4.address=192.0.2.1/24 ipv6.address=fd42:4242:4242:1010::1/64
+
+  # Test reservation validation.
+  ! lxc query -X POST -d '{"hwaddr": "invalid", "ipv4_address": "192.0.2.50"}' "/1.0/networks/${netName}/leases" || false
+  ! lxc query -X POST -d '{"hwaddr": "00:16:3e:00:00:01"}' "/1.0/networks/${netName}/leases" || false
+  ! lxc query -X POST -d '{"hwaddr": "00:16:3e:00:00:01", "ipv4_address": "198.51.100.50"}' "/1.0/networks/${netName}/leases" || false
+  ! lxc query -X POST -d '{"hwaddr": "00:16:3e:00:00:01", "ipv4_address": "192.0.2.1"}' "/1.0/networks/${netName}/leases" || false
+  ! lxc query -X POST -d '{"hwaddr": "00:16:3e:00:00:01", "ipv4_address": "192.0.2.50", "hostname": "invalid_name"}' "/1.0/networks/${netName}/leases" || false
+  ! lxc query -X POST -d '{"hwaddr": "00:16:3e:00:00:01", "ipv6_address": "fd42:4242:4242:1010::50"}' "/1.0/networks/${netName}/leases" || false
+
+  # Test reservation creation.
+  lxc query -X POST -d '{"hwaddr": "00:16:3E:00:00:01", "hostname": "switch1", "ipv4_address": "192.0.2.50"}' "/1.0/networks/${netName}/leases"
+  ! lxc query -X POST -d '{"hwaddr": "00:16:3e:00:00:01", "ipv4_address": "192.0.2.51"}' "/1.0/networks/${netName}/leases" || false
+  ! lxc query -X POST -d '{"hwaddr": "00:16:3e:00:00:02", "ipv4_address": "192.0.2.50"}' "/1.0/networks/${netName}/leases" || false
+  [ "$(lxc query "/1.0/networks/${netName}/leases" | jq -r '.[] | select(.hwaddr == "00:16:3e:00:00:01") | .type')" = "static" ]
+  [ "$(lxc query "/1.0/networks/${netName}/leases" | jq -r '.[] | select(.hwaddr == "00:16:3e:00:00:01") | .hostname')" = "switch1" ]
+  [ "$(lxc query "/1.0/networks/${netName}/leases" | jq -r '.[] | select(.hwaddr == "00:16:3e:00:00:01") | .address')" = "192.0.2.50" ]
+  lxc network list-leases "${netName}" | grep switch1
+  grep -Fx "00:16:3e:00:00:01,192.0.2.50,switch1" "${hostsDir}/00:16:3e:00:00:01"
+
+  # Test IPv6 reservations, which require stateful DHCPv6.
+  lxc network set "${netName}" ipv6.dhcp.stateful=true
+  lxc query -X POST -d '{"hwaddr": "00:16:3e:00:00:02", "ipv6_address": "fd42:4242:4242:1010::50"}' "/1.0/networks/${netName}/leases"
+  grep -Fx "00:16:3e:00:00:02,[fd42:4242:4242:1010::50]" "${hostsDir}/00:16:3e:00:00:02"
+
+  # Reserved addresses and MAC addresses can't be used by instance NICs.
+  lxc init testimage c1
+  ! lxc config device add c1 eth0 nic network="${netName}" ipv4.address=192.0.2.50 || false
+  ! lxc config device add c1 eth0 nic network="${netName}" ipv6.address=fd42:4242:4242:1010::50 || false
+  lxc config device add c1 eth0 nic network="${netName}" ipv4.address=192.0.2.50 hwaddr=00:16:3e:00:00:01
+  lxc config device set c1 eth0 ipv4.address=192.0.2.60 hwaddr=00:16:3e:00:00:10
+  lxc start c1
+  ! lxc query -X POST -d '{"hwaddr": "00:16:3e:00:00:10", "ipv4_address": "192.0.2.70"}' "/1.0/networks/${netName}/leases" || false
+  ! lxc query -X POST -d '{"hwaddr": "00:16:3e:00:00:03", "ipv4_address": "192.0.2.60"}' "/1.0/networks/${netName}/leases" || false
+  lxc delete -f c1
+
+  # Test reservation removal.
+  lxc query -X DELETE "/1.0/networks/${netName}/leases/00:16:3e:00:00:01"
+  ! lxc query -X DELETE "/1.0/networks/${netName}/leases/00:16:3e:00:00:01" || false
+  [ ! -e "${hostsDir}/00:16:3e:00:00:01" ]
+  [ "$(lxc query "/1.0/networks/${netName}/leases" | jq -r '.[] | select(.hwaddr == "00:16:3e:00:00:01") | .type')" = "" ]
+
+  lxc network delete "${netName}"
+}
@@ -0,0 +1,65 @@
+test_network_load_balancer() {
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  if ! ovn_available; then
+    echo "==> SKIP: No OVN northbound database available"
+    return
+  fi
+
+  uplinkName="lxdt$$"
+  ovnName="lxdt$$o"
+
+  lxc network create "${uplinkName}" ipv4.address=192.0.2.1/24 ipv4.nat=true ipv4.ovn.ranges=192.0.2.100-192.0.2.150 ipv6.address=none
+  lxc network create "${ovnName}" --type=ovn parent="${uplinkName}" ipv4.address=10.10.10.1/24 ipv6.address=fd42:10:10:10::1/64
+  lbPrefix="$(ovn_network_prefix "${ovnName}")-lb-backends"
+
+  # Test load balancer creation and validation.
+  lxc query -X POST -d '{"listen_address": "198.51.100.1", "backends": [{"name": "b1", "target_address": "10.10.10.10"}, {"name": "b2", "target_address": "10.10.10.20", "target_port": "8080"}], "ports": [{"protocol": "tcp", "listen_port": "80", "target_backend": ["b1", "b2"]}, {"protocol": "udp", "listen_port": "53", "target_backend": ["b1"]}]}' "/1.0/networks/${ovnName}/load-balancers"
+  ! lxc query -X POST -d '{"listen_address": "198.51.100.1"}' "/1.0/networks/${ovnName}/load-balancers" || false
+  ! lxc query -X POST -d '{"listen_address": "10.10.10.100"}' "/1.0/networks/${ovnName}/load-balancers" || false
+  ! lxc query -X POST -d '{"listen_address": "198.51.100.2", "backends": [{"name": "b1", "target_address": "203.0.113.1"}]}' "/1.0/networks/${ovnName}/load-balancers" || false
+  ! lxc query -X POST -d '{"listen_address": "198.51.100.2", "backends": [{"name": "b1", "target_address": "10.10.10.10"}, {"name": "b1", "target_address": "10.10.10.20"}]}' "/1.0/networks/${ovnName}/load-balancers" || false
+  ! lxc query -X POST -d '{"listen_address": "198.51.100.2", "ports": [{"protocol": "tcp", "listen_port": "80", "target_backend": ["missing"]}]}' "/1.0/networks/${ovnName}/load-balancers" || false
+  ! lxc query -X POST -d '{"listen_address": "198.51.100.2", "backends": [{"name": "b1", "target_address": "10.10.10.10"}], "ports": [{"protocol": "tcp", "listen_port": "80", "target_backend": ["b1"]}, {"protocol": "tcp", "listen_port": "70-90", "target_backend": ["b1"]}]}' "/1.0/networks/${ovnName}/load-balancers" || false
+  ! lxc query -X POST -d '{"listen_address": "2001:db8::1", "config": {"healthcheck": "true"}}' "/1.0/networks/${ovnName}/load-balancers" || false
+  lxc query "/1.0/networks/${ovnName}/load-balancers" | grep "/1.0/networks/${ovnName}/load-balancers/198.51.100.1"
+  [ "$(lxc query "/1.0/networks/${ovnName}/load-balancers/198.51.100.1" | jq -r '.backends | length')" = "2" ]
+
+  # Test the OVN load balancers.
+  ovn-nbctl lb-list | grep "${lbPrefix}-198.51.100.1-tcp" | grep "198.51.100.1:80" | grep "10.10.10.10:80,10.10.10.20:8080"
+  ovn-nbctl lb-list | grep "${lbPrefix}-198.51.100.1-udp" | grep "198.51.100.1:53" | grep "10.10.10.10:53"
+
+  # Listen addresses are shared with forwards.
+  ! lxc query -X POST -d '{"listen_address": "198.51.100.1"}' "/1.0/networks/${ovnName}/forwards" || false
+  lxc query -X POST -d '{"listen_address": "198.51.100.3"}' "/1.0/networks/${ovnName}/forwards"
+  ! lxc query -X POST -d '{"listen_address": "198.51.100.3"}' "/1.0/networks/${ovnName}/load-balancers" || false
+  lxc query -X DELETE "/1.0/networks/${ovnName}/forwards/198.51.100.3"
+
+  # Test load balancer update.
+  lxc query -X PATCH -d '{"ports": [{"protocol": "tcp", "listen_port": "443", "target_backend": ["b2"]}]}' "/1.0/networks/${ovnName}/load-balancers/198.51.100.1"
+  ! ovn-nbctl lb-list | grep "${lbPrefix}-198.51.100.1-udp" || false
+  ! ovn-nbctl lb-list | grep "198.51.100.1:80" || false
+  ovn-nbctl lb-list | grep "${lbPrefix}-198.51.100.1-tcp" | grep "198.51.100.1:443" | grep "10.10.10.20:8080"
+
+  # Test health checks.
+  ! lxc query -X PATCH -d '{"config": {"healthcheck": "true", "healthcheck.interval": "invalid"}}' "/1.0/networks/${ovnName}/load-balancers/198.51.100.1" || false
+  lxc query -X PATCH -d '{"config": {"healthcheck": "true", "healthcheck.interval": "10"}}' "/1.0/networks/${ovnName}/load-balancers/198.51.100.1"
+  ovn-nbctl list load_balancer_health_check | grep "198.51.100.1:443"
+  lxc query -X PATCH -d '{"config": {"healthcheck": "false"}}' "/1.0/networks/${ovnName}/load-balancers/198.51.100.1"
+  ! ovn-nbctl list load_balancer_health_check | grep "198.51.100.1:443" || false
+
+  # Test listen address allocation from the uplink OVN ranges.
+  lxc query -X POST -d '{"backends": [{"name": "b1", "target_address": "10.10.10.10"}], "ports": [{"protocol": "tcp", "listen_port": "80", "target_backend": ["b1"]}]}' "/1.0/networks/${ovnName}/load-balancers"
+  allocated=$(lxc query "/1.0/networks/${ovnName}/load-balancers" | jq -r '.[]' | awk -F/ '{print $NF}' | grep "^192\\.0\\.2\\.")
+  echo "${allocated}" | grep -E "^192\\.0\\.2\\.1([0-4][0-9]|50)$"
+  [ "${allocated}" != "$(lxc network get "${ovnName}" volatile.parent.ipv4.address)" ]
+  ovn-nbctl lb-list | grep "${lbPrefix}-${allocated}-tcp"
+
+  # Test load balancer removal, including when the network is deleted.
+  lxc query -X DELETE "/1.0/networks/${ovnName}/load-balancers/198.51.100.1"
+  ! lxc query "/1.0/networks/${ovnName}/load-balancers/198.51.100.1" || false
+  ! ovn-nbctl lb-list | grep "${lbPrefix}-198.51.100.1-" || false
+  lxc network delete "${ovnName}"
+  ! ovn-nbctl lb-list | grep "${lbPrefix}-" || false
+  lxc network delete "${uplinkName}"
+}
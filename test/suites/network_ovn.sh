@@ -0,0 +1,68 @@
+test_network_ovn() {
+  ensure_import_testimage
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  if ! ovn_available; then
+    echo "==> SKIP: No OVN northbound database available"
+    return
+  fi
+
+  uplinkName="lxdt$$"
+  ovnName="lxdt$$o"
+
+  lxc network create "${uplinkName}" \
+    ipv4.address=192.0.2.1/24 ipv4.nat=true ipv4.ovn.ranges=192.0.2.100-192.0.2.150 \
+    ipv6.address=2001:db8:1::1/64 ipv6.nat=true ipv6.ovn.ranges=2001:db8:1::100-2001:db8:1::150
+
+  # NAT is only enabled by default for generated subnets.
+  lxc network create "${ovnName}" --type=ovn parent="${uplinkName}" ipv4.address=10.10.10.1/24 ipv6.address=fd42:10:10:10::1/64
+  [ "$(lxc network get "${ovnName}" ipv4.nat)" = "" ]
+  ovnPrefix=$(ovn_network_prefix "${ovnName}")
+  ! ovn-nbctl lr-nat-list "${ovnPrefix}-lr" | grep "10.10.10.0/24" || false
+  lxc network set "${ovnName}" ipv4.nat=true
+  ovn-nbctl lr-nat-list "${ovnPrefix}-lr" | grep "snat" | grep "10.10.10.0/24"
+  lxc network set "${ovnName}" ipv6.nat=true
+  ovn-nbctl lr-nat-list "${ovnPrefix}-lr" | grep "snat" | grep "fd42:10:10:10::/64"
+  lxc network unset "${ovnName}" ipv4.nat
+  ! ovn-nbctl lr-nat-list "${ovnPrefix}-lr" | grep "10.10.10.0/24" || false
+
+  lxc network create "${ovnName}b" --type=ovn parent="${uplinkName}"
+  [ "$(lxc network get "${ovnName}b" ipv4.nat)" = "true" ]
+  [ "$(lxc network get "${ovnName}b" ipv6.nat)" = "true" ]
+  lxc network delete "${ovnName}b"
+
+  # Test the DHCP options.
+  ! lxc network set "${ovnName}" ipv6.dhcp=false ipv6.dhcp.stateful=true || false
+  lxc network set "${ovnName}" ipv6.dhcp.stateful=true
+  ovn-nbctl get logical_router_port "${ovnPrefix}-lr-lrp-int" ipv6_ra_configs | grep "address_mode=dhcpv6_stateful"
+  lxc network set "${ovnName}" ipv6.dhcp=false ipv6.dhcp.stateful=false
+  ovn-nbctl get logical_router_port "${ovnPrefix}-lr-lrp-int" ipv6_ra_configs | grep "address_mode=slaac"
+  lxc network unset "${ovnName}" ipv6.dhcp
+  lxc network unset "${ovnName}" ipv6.dhcp.stateful
+  ovn-nbctl get logical_router_port "${ovnPrefix}-lr-lrp-int" ipv6_ra_configs | grep "address_mode=dhcpv6_stateless"
+
+  # Static NIC addresses require DHCP.
+  lxc init testimage c1
+  lxc config device add c1 eth0 nic network="${ovnName}" ipv4.address=10.10.10.10
+  ! lxc network set "${ovnName}" ipv4.dhcp=false || false
+  ! lxc config device set c1 eth0 ipv6.address=fd42:10:10:10::10 || false
+  lxc network set "${ovnName}" ipv6.dhcp.stateful=true
+  lxc config device set c1 eth0 ipv6.address=fd42:10:10:10::10
+
+  # Test the NIC routes.
+  ! lxc config device set c1 eth0 ipv4.routes=10.10.10.128/25 || false
+  ! lxc config device set c1 eth0 ipv4.routes=invalid || false
+  lxc config device set c1 eth0 ipv4.routes=198.51.100.0/24 ipv6.routes=2001:db8:2::/64
+  lxc start c1
+  ovn-nbctl lr-route-list "${ovnPrefix}-lr" | grep "198.51.100.0/24" | grep "10.10.10.10"
+  ovn-nbctl lr-route-list "${ovnPrefix}-lr" | grep "2001:db8:2::/64" | grep "fd42:10:10:10::10"
+  lxc config device set c1 eth0 ipv4.routes=198.51.100.0/25
+  ! ovn-nbctl lr-route-list "${ovnPrefix}-lr" | grep "198.51.100.0/24" || false
+  ovn-nbctl lr-route-list "${ovnPrefix}-lr" | grep "198.51.100.0/25" | grep "10.10.10.10"
+  lxc stop -f c1
+  ! ovn-nbctl lr-route-list "${ovnPrefix}-lr" | grep "198.51.100.0/25" || false
+
+  lxc delete -f c1
+  lxc network delete "${ovnName}"
+  lxc network delete "${uplinkName}"
+}
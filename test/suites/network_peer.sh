@@ -0,0 +1,70 @@
+test_network_peer() {
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  if ! ovn_available; then
+    echo "==> SKIP: No OVN northbound database available"
+    return
+  fi
+
+  uplinkName="lxdt$$"
+  netA="lxdt$$a"
+  netB="lxdt$$b"
+  netC="lxdt$$c"
+
+  lxc network create "${uplinkName}" ipv4.address=192.0.2.1/24 ipv4.nat=true ipv4.ovn.ranges=192.0.2.100-192.0.2.150 ipv6.address=none
+  lxc network create "${netA}" --type=ovn parent="${uplinkName}" ipv4.address=10.10.10.1/24 ipv6.address=fd42:10:10:10::1/64
+  lxc network create "${netB}" --type=ovn parent="${uplinkName}" ipv4.address=10.10.11.1/24 ipv6.address=fd42:10:10:11::1/64
+  lxc network create "${netC}" --type=ovn parent="${uplinkName}" ipv4.address=10.10.10.1/24 ipv6.address=none
+  routerA="$(ovn_network_prefix "${netA}")-lr"
+  routerB="$(ovn_network_prefix "${netB}")-lr"
+
+  # Test peering validation.
+  ! lxc query -X POST -d "{\"name\": \"peer\", \"target_network\": \"${netA}\"}" "/1.0/networks/${netA}/peers" || false
+  ! lxc query -X POST -d "{\"name\": \"peer\", \"target_network\": \"${netC}\"}" "/1.0/networks/${netA}/peers" || false
+  ! lxc query -X POST -d "{\"name\": \"peer\", \"target_network\": \"${uplinkName}\"}" "/1.0/networks/${netA}/peers" || false
+  ! lxc query -X POST -d '{"name": "peer", "target_network": "lxdtmissing"}' "/1.0/networks/${netA}/peers" || false
+  ! lxc query -X POST -d "{\"name\": \"peer\", \"target_network\": \"${netB}\", \"config\": {\"foo\": \"bar\"}}" "/1.0/networks/${netA}/peers" || false
+  ! lxc query -X POST -d "{\"name\": \"peer\", \"target_network\": \"${netA}\"}" "/1.0/networks/${uplinkName}/peers" || false
+
+  # A one sided peering stays pending.
+  lxc query -X POST -d "{\"name\": \"atob\", \"target_network\": \"${netB}\"}" "/1.0/networks/${netA}/peers"
+  ! lxc query -X POST -d "{\"name\": \"atob\", \"target_network\": \"${netB}\"}" "/1.0/networks/${netA}/peers" || false
+  lxc query "/1.0/networks/${netA}/peers" | grep "/1.0/networks/${netA}/peers/atob"
+  [ "$(lxc query "/1.0/networks/${netA}/peers/atob" | jq -r .status)" = "Pending" ]
+  [ "$(lxc query "/1.0/networks/${netA}/peers/atob" | jq -r .target_project)" = "default" ]
+  ! ovn-nbctl lr-route-list "${routerA}" | grep "10.10.11.0/24" || false
+
+  # The mutual peering links the routers.
+  lxc query -X POST -d "{\"name\": \"btoa\", \"target_network\": \"${netA}\"}" "/1.0/networks/${netB}/peers"
+  [ "$(lxc query "/1.0/networks/${netA}/peers/atob" | jq -r .status)" = "Created" ]
+  [ "$(lxc query "/1.0/networks/${netB}/peers/btoa" | jq -r .status)" = "Created" ]
+  ovn-nbctl lr-route-list "${routerA}" | grep "10.10.11.0/24"
+  ovn-nbctl lr-route-list "${routerA}" | grep "fd42:10:10:11::/64"
+  ovn-nbctl lr-route-list "${routerB}" | grep "10.10.10.0/24"
+  ovn-nbctl lr-route-list "${routerB}" | grep "fd42:10:10:10::/64"
+
+  # Test peering update.
+  lxc query -X PATCH -d '{"description": "Peering", "config": {"user.foo": "bar"}}' "/1.0/networks/${netA}/peers/atob"
+  [ "$(lxc query "/1.0/networks/${netA}/peers/atob" | jq -r .description)" = "Peering" ]
+  [ "$(lxc query "/1.0/networks/${netA}/peers/atob" | jq -r '.config["user.foo"]')" = "bar" ]
+  ! lxc query -X PATCH -d '{"config": {"foo": "bar"}}' "/1.0/networks/${netA}/peers/atob" || false
+
+  # Removing either side unlinks the routers and sets the other side back to pending.
+  lxc query -X DELETE "/1.0/networks/${netB}/peers/btoa"
+  [ "$(lxc query "/1.0/networks/${netA}/peers/atob" | jq -r .status)" = "Pending" ]
+  ! ovn-nbctl lr-route-list "${routerA}" | grep "10.10.11.0/24" || false
+  ! ovn-nbctl lr-route-list "${routerB}" | grep "10.10.10.0/24" || false
+
+  lxc query -X POST -d "{\"name\": \"btoa\", \"target_network\": \"${netA}\"}" "/1.0/networks/${netB}/peers"
+  [ "$(lxc query "/1.0/networks/${netA}/peers/atob" | jq -r .status)" = "Created" ]
+  lxc network delete "${netB}"
+  [ "$(lxc query "/1.0/networks/${netA}/peers/atob" | jq -r .status)" = "Pending" ]
+  ! ovn-nbctl lr-route-list "${routerA}" | grep "10.10.11.0/24" || false
+
+  lxc query -X DELETE "/1.0/networks/${netA}/peers/atob"
+  ! lxc query "/1.0/networks/${netA}/peers/atob" || false
+
+  lxc network delete "${netC}"
+  lxc network delete "${netA}"
+  lxc network delete "${uplinkName}"
+}
@@ -0,0 +1,73 @@
+test_network_physical() {
+  ensure_import_testimage
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  parentName="lxdt$$"
+  netName="lxdt$$p"
+
+  ip link add "${parentName}" type dummy
+
+  # Test validation.
+  ! lxc network create "${netName}" --type=physical || false
+  ! lxc network create "${netName}" --type=physical parent="${parentName}" ipv4.ovn.ranges=192.0.2.100-192.0.2.150 || false
+  ! lxc network create "${netName}" --type=physical parent="${parentName}" ipv4.gateway=192.0.2.1/24 ipv4.ovn.ranges=198.51.100.100-198.51.100.150 || false
+  ! lxc network create "${netName}" --type=physical parent="${parentName}" dns.nameservers=invalid || false
+  ! lxc network create "${netName}" --type=physical parent="${parentName}missing" || false
+
+  # Test using the parent interface directly, it must be left in place.
+  lxc network create "${netName}" --type=physical parent="${parentName}" mtu=1400 \
+    ipv4.gateway=192.0.2.1/24 ipv4.ovn.ranges=192.0.2.100-192.0.2.150 \
+    ipv6.gateway=2001:db8:1::1/64 ipv6.ovn.ranges=2001:db8:1::100-2001:db8:1::150 \
+    dns.nameservers=192.0.2.53,2001:db8:1::53
+  [ "$(cat /sys/class/net/${parentName}/mtu)" = "1400" ]
+  [ "$(lxc network get "${netName}" volatile.last_state.created)" = "" ]
+  lxc network delete "${netName}"
+  [ -e "/sys/class/net/${parentName}" ]
+
+  # Test using a VLAN on top of the parent interface, it must be removed with the network.
+  lxc network create "${netName}" --type=physical parent="${parentName}" vlan=10 mtu=1300
+  [ -e "/sys/class/net/${parentName}.10" ]
+  [ "$(cat /sys/class/net/${parentName}.10/mtu)" = "1300" ]
+  [ "$(lxc network get "${netName}" volatile.last_state.created)" = "true" ]
+
+  # Changing the VLAN replaces the interface.
+  lxc network set "${netName}" vlan=11
+  [ ! -e "/sys/class/net/${parentName}.10" ]
+  [ -e "/sys/class/net/${parentName}.11" ]
+  [ "$(lxc network get "${netName}" volatile.last_state.created)" = "true" ]
+
+  # Changing other settings keeps the interface.
+  lxc network set "${netName}" ipv4.gateway=192.0.2.1/24
+  [ -e "/sys/class/net/${parentName}.11" ]
+  [ "$(lxc network get "${netName}" volatile.last_state.created)" = "true" ]
+
+  # An existing VLAN interface isn't removed.
+  ip link add link "${parentName}" name "${parentName}.12" type vlan id 12
+  lxc network set "${netName}" vlan=12
+  [ ! -e "/sys/class/net/${parentName}.11" ]
+  [ "$(lxc network get "${netName}" volatile.last_state.created)" = "" ]
+  lxc network delete "${netName}"
+  [ -e "/sys/class/net/${parentName}.12" ]
+  ip link delete "${parentName}.12"
+
+  # Test using the physical network as an OVN uplink.
+  if ovn_available; then
+    lxc network create "${netName}" --type=physical parent="${parentName}" \
+      ipv4.gateway=192.0.2.1/24 ipv4.ovn.ranges=192.0.2.100-192.0.2.101
+    lxc network create "${netName}o" --type=ovn parent="${netName}"
+    lxc network get "${netName}o" volatile.parent.ipv4.address | grep -E "^192\\.0\\.2\\.10[01]$"
+
+    # The OVN ranges limit the number of OVN networks using the uplink.
+    lxc network create "${netName}o2" --type=ovn parent="${netName}"
+    ! lxc network create "${netName}o3" --type=ovn parent="${netName}" || false
+    [ "$(lxc network get "${netName}o" volatile.parent.ipv4.address)" != "$(lxc network get "${netName}o2" volatile.parent.ipv4.address)" ]
+
+    lxc network delete "${netName}o2"
+    lxc network delete "${netName}o"
+    lxc network delete "${netName}"
+  else
+    echo "==> SKIP: No OVN northbound database available"
+  fi
+
+  ip link delete "${parentName}"
+}
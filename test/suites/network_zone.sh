@@ -0,0 +1,72 @@
+test_network_zone() {
+  ensure_import_testimage
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  netName="lxdt$$"
+  dnsPort=$(local_tcp_port)
+
+  lxc config set core.dns_address "127.0.0.1:${dnsPort}"
+
+  # Test zone creation and validation.
+  ! lxc query -X POST -d '{"name": "invalid_zone"}' /1.0/network-zones || false
+  ! lxc query -X POST -d '{"name": "lxd.example.net", "config": {"foo": "bar"}}' /1.0/network-zones || false
+  ! lxc query -X POST -d '{"name": "lxd.example.net", "config": {"peers.test.address": "invalid"}}' /1.0/network-zones || false
+  ! lxc query -X POST -d '{"name": "lxd.example.net", "config": {"peers.test.key": "!invalid!"}}' /1.0/network-zones || false
+  lxc query -X POST -d '{"name": "lxd.example.net", "config": {"dns.nameservers": "ns1.example.net", "peers.test.address": "127.0.0.1"}}' /1.0/network-zones
+  lxc query -X POST -d '{"name": "2.0.192.in-addr.arpa", "config": {"peers.test.address": "127.0.0.1"}}' /1.0/network-zones
+  ! lxc query -X POST -d '{"name": "lxd.example.net"}' /1.0/network-zones || false
+  lxc query /1.0/network-zones | grep "/1.0/network-zones/lxd.example.net"
+
+  # Test linking the zones to a network.
+  lxc network create "${netName}" ipv4.address=192.0.2.1/24 ipv6.address=none
+  ! lxc network set "${netName}" dns.zone.forward=missing.example.net || false
+  lxc network set "${netName}" dns.zone.forward=lxd.example.net dns.zone.reverse.ipv4=2.0.192.in-addr.arpa
+  lxc query /1.0/network-zones/lxd.example.net | jq -r '.used_by[]' | grep "/1.0/networks/${netName}"
+  ! lxc query -X DELETE /1.0/network-zones/lxd.example.net || false
+
+  # A zone can only be linked to a single network.
+  lxc network create "${netName}b" ipv4.address=192.0.3.1/24 ipv6.address=none
+  ! lxc network set "${netName}b" dns.zone.forward=lxd.example.net || false
+  lxc network delete "${netName}b"
+
+  lxc init testimage c1
+  lxc config device add c1 eth0 nic network="${netName}" ipv4.address=192.0.2.10
+  lxc start c1
+
+  if ! which dig >/dev/null 2>&1; then
+    echo "==> SKIP: DNS server queries (missing dig)"
+  else
+    # Test the generated records.
+    [ "$(dig @127.0.0.1 -p "${dnsPort}" +short c1.lxd.example.net A)" = "192.0.2.10" ]
+    [ "$(dig @127.0.0.1 -p "${dnsPort}" +short -x 192.0.2.10)" = "c1.lxd.example.net." ]
+    dig @127.0.0.1 -p "${dnsPort}" +short lxd.example.net NS | grep -Fx "ns1.example.net."
+    dig @127.0.0.1 -p "${dnsPort}" lxd.example.net AXFR | grep "^c1.lxd.example.net."
+
+    # The serial only changes when the records change.
+    serial=$(dig @127.0.0.1 -p "${dnsPort}" +short lxd.example.net SOA | awk '{print $3}')
+    [ "$(dig @127.0.0.1 -p "${dnsPort}" +short lxd.example.net SOA | awk '{print $3}')" = "${serial}" ]
+    lxc init testimage c2
+    lxc config device add c2 eth0 nic network="${netName}" ipv4.address=192.0.2.20
+    [ "$(dig @127.0.0.1 -p "${dnsPort}" +short c2.lxd.example.net A)" = "192.0.2.20" ]
+    [ "$(dig @127.0.0.1 -p "${dnsPort}" +short lxd.example.net SOA | awk '{print $3}')" -gt "${serial}" ]
+    lxc delete -f c2
+
+    # Test peer restrictions and TSIG.
+    dig @127.0.0.1 -p "${dnsPort}" unknown.example.net A | grep "status: REFUSED"
+    lxc query -X PATCH -d '{"config": {"peers.test.address": "127.0.0.2"}}' /1.0/network-zones/lxd.example.net
+    dig @127.0.0.1 -p "${dnsPort}" c1.lxd.example.net A | grep "status: REFUSED"
+    lxc query -X PATCH -d '{"config": {"peers.test.address": "127.0.0.1", "peers.test.key": "c2VjcmV0c2VjcmV0c2VjcmV0"}}' /1.0/network-zones/lxd.example.net
+    dig @127.0.0.1 -p "${dnsPort}" c1.lxd.example.net A | grep "status: REFUSED"
+    [ "$(dig @127.0.0.1 -p "${dnsPort}" +short -y "hmac-sha256:lxd.example.net_test.:c2VjcmV0c2VjcmV0c2VjcmV0" c1.lxd.example.net A)" = "192.0.2.10" ]
+  fi
+
+  # Test zone removal.
+  lxc delete -f c1
+  lxc network unset "${netName}" dns.zone.forward
+  lxc network unset "${netName}" dns.zone.reverse.ipv4
+  lxc query -X DELETE /1.0/network-zones/lxd.example.net
+  lxc query -X DELETE /1.0/network-zones/2.0.192.in-addr.arpa
+  ! lxc query /1.0/network-zones/lxd.example.net || false
+  lxc network delete "${netName}"
+  lxc config unset core.dns_address
+}
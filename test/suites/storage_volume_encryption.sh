@@ -0,0 +1,131 @@
+test_storage_volume_encryption() {
+  if ! which cryptsetup >/dev/null 2>&1; then
+    echo "==> SKIP: No cryptsetup binary available"
+    return
+  fi
+
+  # shellcheck disable=2039
+  local pool pool2 keys keys2
+  pool="lxdtest-$(basename "${LXD_DIR}")-enc"
+  pool2="lxdtest-$(basename "${LXD_DIR}")-enc2"
+  keys="${LXD_DIR}/storage-pools/${pool}/keys.yaml"
+  keys2="${LXD_DIR}/storage-pools/${pool2}/keys.yaml"
+
+  # The block files of dir pools allow checking the volumes on the host.
+  lxc storage create "${pool}" dir
+
+  # Validation.
+  ! lxc storage volume create "${pool}" vol1 --type=block block.encryption=foo || false
+  ! lxc storage volume create "${pool}" vol1 block.encryption=luks || false
+  ! lxc storage set "${pool}" volume.block.encryption=luks || false
+  [ ! -e "${keys}" ]
+
+  # Encrypted block volumes get a key in the pool's keyfile.
+  lxc storage volume create "${pool}" vol1 --type=block block.encryption=luks size=64MiB
+  cryptsetup isLuks "${LXD_DIR}/storage-pools/${pool}/custom/default_vol1/root.img"
+  [ "$(stat -c %a "${keys}")" = "600" ]
+  grep -q "^custom/default_vol1: " "${keys}"
+  ! lxc storage volume unset "${pool}" vol1 block.encryption || false
+
+  # Encrypted volumes can be grown but not shrunk.
+  lxc storage volume set "${pool}" vol1 size=128MiB
+  ! lxc storage volume set "${pool}" vol1 size=64MiB || false
+  [ "$(lxc storage volume get "${pool}" vol1 size)" = "128MiB" ]
+
+  # Snapshots and copies use the key of the source volume.
+  lxc storage volume snapshot "${pool}" vol1 snap0
+  cryptsetup isLuks "${LXD_DIR}/storage-pools/${pool}/custom-snapshots/default_vol1/snap0/root.img"
+  lxc storage volume copy "${pool}/vol1" "${pool}/vol2"
+  cryptsetup isLuks "${LXD_DIR}/storage-pools/${pool}/custom/default_vol2/root.img"
+  [ "$(lxc storage volume get "${pool}" vol2 block.encryption)" = "luks" ]
+  [ "$(grep "^custom/default_vol1: " "${keys}" | cut -d' ' -f2)" = "$(grep "^custom/default_vol2: " "${keys}" | cut -d' ' -f2)" ]
+
+  # Keys follow renames and are removed along with their volume.
+  lxc storage volume rename "${pool}" vol2 vol3
+  ! grep -q "^custom/default_vol2: " "${keys}" || false
+  grep -q "^custom/default_vol3: " "${keys}"
+  lxc storage volume delete "${pool}" vol3
+  ! grep -q "^custom/default_vol3: " "${keys}" || false
+
+  # Backups aren't encrypted and must be allowed explicitly, restoring them generates a new key.
+  ! lxc storage volume export "${pool}" vol1 "${LXD_DIR}/vol1.tar.gz" --volume-only || false
+  lxc storage volume export "${pool}" vol1 "${LXD_DIR}/vol1.tar.gz" --volume-only --allow-plaintext
+  lxc storage volume import "${pool}" "${LXD_DIR}/vol1.tar.gz" vol2
+  rm "${LXD_DIR}/vol1.tar.gz"
+  cryptsetup isLuks "${LXD_DIR}/storage-pools/${pool}/custom/default_vol2/root.img"
+  [ "$(grep "^custom/default_vol1: " "${keys}" | cut -d' ' -f2)" != "$(grep "^custom/default_vol2: " "${keys}" | cut -d' ' -f2)" ]
+  lxc storage volume delete "${pool}" vol2
+
+  # Recovered volumes are encrypted when the keyfile has their key.
+  lxd sql global "PRAGMA foreign_keys=ON; DELETE FROM storage_volumes WHERE name='vol1' AND storage_pool_id=(SELECT id FROM storage_pools WHERE name='${pool}')"
+  ! lxc storage volume show "${pool}" vol1 || false
+  printf 'no\nyes\nyes\n' | lxd recover
+  [ "$(lxc storage volume get "${pool}" vol1 block.encryption)" = "luks" ]
+  lxc storage volume show "${pool}" vol1/snap0
+  lxc storage volume copy "${pool}/vol1" "${pool}/vol2"
+  cryptsetup isLuks "${LXD_DIR}/storage-pools/${pool}/custom/default_vol2/root.img"
+  lxc storage volume delete "${pool}" vol2
+  lxc storage volume delete "${pool}" vol1
+  ! grep -q "^custom/default_vol1: " "${keys}" || false
+
+  # Pool wide encryption applies to the block volumes created on the pool.
+  lxc storage create "${pool2}" dir volume.block.encryption=luks
+  ! lxc storage unset "${pool2}" volume.block.encryption || false
+  lxc storage volume create "${pool2}" vol1 --type=block size=64MiB
+  cryptsetup isLuks "${LXD_DIR}/storage-pools/${pool2}/custom/default_vol1/root.img"
+  grep -q "^custom/default_vol1: " "${keys2}"
+  lxc storage volume create "${pool2}" vol2
+  ! grep -q "^custom/default_vol2: " "${keys2}" || false
+  lxc storage volume delete "${pool2}" vol1
+  lxc storage volume delete "${pool2}" vol2
+
+  if ensure_import_vmimage; then
+    lxc init vmimage v1 --vm -s "${pool2}"
+    cryptsetup isLuks "${LXD_DIR}/storage-pools/${pool2}/virtual-machines/v1/root.img"
+    grep -q "^virtual-machines/default_v1: " "${keys2}"
+
+    # The volume is unlocked automatically when used.
+    lxc start v1
+    wait_for_vm_agent v1
+    lxc exec v1 -- touch /root/foo
+    lxc stop -f v1
+
+    # Backups aren't encrypted and must be allowed explicitly.
+    ! lxc export v1 "${LXD_DIR}/v1.tar.gz" || false
+    lxc export v1 "${LXD_DIR}/v1.tar.gz" --allow-plaintext
+    mkdir "${LXD_DIR}/v1-backup"
+    tar -xzf "${LXD_DIR}/v1.tar.gz" -C "${LXD_DIR}/v1-backup" backup/virtual-machine.img
+    ! cryptsetup isLuks "${LXD_DIR}/v1-backup/backup/virtual-machine.img" || false
+    rm -rf "${LXD_DIR}/v1-backup"
+    lxc delete v1
+    ! grep -q "^virtual-machines/default_v1: " "${keys2}" || false
+
+    # Restored backups are encrypted using a new key.
+    lxc import "${LXD_DIR}/v1.tar.gz"
+    rm "${LXD_DIR}/v1.tar.gz"
+    cryptsetup isLuks "${LXD_DIR}/storage-pools/${pool2}/virtual-machines/v1/root.img"
+    grep -q "^virtual-machines/default_v1: " "${keys2}"
+    lxc start v1
+    wait_for_vm_agent v1
+    lxc exec v1 -- test -e /root/foo
+    lxc stop -f v1
+
+    # Instances can only be recovered while the keyfile has their key.
+    lxd sql global "PRAGMA foreign_keys=ON; DELETE FROM instances WHERE name='v1'"
+    lxd sql global "PRAGMA foreign_keys=ON; DELETE FROM storage_volumes WHERE name='v1'"
+    mv "${keys2}" "${keys2}.bak"
+    ! printf 'no\nyes\nyes\n' | lxd recover || false
+    ! lxc info v1 || false
+    mv "${keys2}.bak" "${keys2}"
+    printf 'no\nyes\nyes\n' | lxd recover
+    lxc start v1
+    wait_for_vm_agent v1
+    lxc exec v1 -- test -e /root/foo
+    lxc delete -f v1
+  else
+    echo "==> SKIP: No virtual machine image (LXD_VM_IMAGE)"
+  fi
+
+  lxc storage delete "${pool2}"
+  lxc storage delete "${pool}"
+}
@@ -0,0 +1,48 @@
+test_vm_stateful() {
+  ensure_has_localhost_remote "${LXD_ADDR}"
+
+  if ! ensure_import_vmimage; then
+    echo "==> SKIP: No virtual machine image (LXD_VM_IMAGE)"
+    return
+  fi
+
+  vmName="vm$$"
+
+  # Stateful stop requires room for the memory state on the root disk.
+  lxc init vmimage "${vmName}" --vm -c limits.memory=512MiB
+  lxc config device override "${vmName}" root size.state=1GiB
+  lxc start "${vmName}"
+  wait_for_vm_agent "${vmName}"
+
+  # Keep a marker in memory only so it survives solely through the saved state.
+  lxc exec "${vmName}" -- sh -c "echo stateful > /dev/shm/marker"
+
+  # Test stateful stop and start.
+  lxc stop "${vmName}" --stateful
+  [ "$(lxc list "${vmName}" -c s --format csv)" = "STOPPED" ]
+  [ "$(lxc query "/1.0/instances/${vmName}" | jq -r .stateful)" = "true" ]
+  lxc start "${vmName}"
+  wait_for_vm_agent "${vmName}"
+  [ "$(lxc exec "${vmName}" -- cat /dev/shm/marker)" = "stateful" ]
+  [ "$(lxc query "/1.0/instances/${vmName}" | jq -r .stateful)" = "false" ]
+
+  # A state volume too small for the memory must fail and keep the VM running.
+  lxc config device set "${vmName}" root size.state=64MiB
+  ! lxc stop "${vmName}" --stateful || false
+  [ "$(lxc list "${vmName}" -c s --format csv)" = "RUNNING" ]
+  lxc config device set "${vmName}" root size.state=1GiB
+
+  # Test stateful snapshots.
+  lxc snapshot "${vmName}" snap0 --stateful
+  lxc exec "${vmName}" -- rm /dev/shm/marker
+  lxc restore "${vmName}" snap0 --stateful
+  wait_for_vm_agent "${vmName}"
+  [ "$(lxc exec "${vmName}" -- cat /dev/shm/marker)" = "stateful" ]
+
+  # Stateless restore of a stateful snapshot boots from scratch.
+  lxc restore "${vmName}" snap0
+  wait_for_vm_agent "${vmName}"
+  ! lxc exec "${vmName}" -- test -e /dev/shm/marker || false
+
+  lxc delete -f "${vmName}"
+}